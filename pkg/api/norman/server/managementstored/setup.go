@@ -22,6 +22,7 @@
 	"github.com/rancher/rancher/pkg/api/norman/customization/secret"
 	"github.com/rancher/rancher/pkg/api/norman/customization/setting"
 	"github.com/rancher/rancher/pkg/api/norman/store/cert"
+	"github.com/rancher/rancher/pkg/api/norman/store/changefreeze"
 	"github.com/rancher/rancher/pkg/api/norman/store/cluster"
 	featStore "github.com/rancher/rancher/pkg/api/norman/store/feature"
 	globalRoleStore "github.com/rancher/rancher/pkg/api/norman/store/globalrole"
@@ -32,9 +33,11 @@
 	"github.com/rancher/rancher/pkg/api/norman/store/scoped"
 	settingstore "github.com/rancher/rancher/pkg/api/norman/store/setting"
 	"github.com/rancher/rancher/pkg/api/scheme"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	authapi "github.com/rancher/rancher/pkg/auth/api"
 	"github.com/rancher/rancher/pkg/auth/requests"
 	"github.com/rancher/rancher/pkg/auth/tokens"
+	freeze "github.com/rancher/rancher/pkg/changefreeze"
 	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
 	projectclient "github.com/rancher/rancher/pkg/client/generated/project/v3"
 	"github.com/rancher/rancher/pkg/clustermanager"
@@ -126,6 +129,7 @@ func Setup(ctx context.Context, apiContext *config.ScaledContext, clusterManager
 
 func setupScopedTypes(schemas *types.Schemas, management *config.ScaledContext) {
 	projectLister := management.Management.Projects("").Controller().Lister()
+	checker := freeze.NewChecker(management.Wrangler.Mgmt.Cluster().Cache())
 
 	for _, schema := range schemas.Schemas() {
 		if schema.Scope != types.NamespaceScope || schema.Store == nil || schema.Store.Context() != config.ManagementStorageContext {
@@ -142,7 +146,7 @@ func setupScopedTypes(schemas *types.Schemas, management *config.ScaledContext)
 				continue
 			}
 
-			schema.Store = scoped.NewScopedStore(key, schema.Store, projectLister)
+			schema.Store = changefreeze.NewScopedStore(key, scoped.NewScopedStore(key, schema.Store, projectLister), checker)
 			ns.Required = false
 			schema.ResourceFields["namespaceId"] = ns
 			break
@@ -174,7 +178,15 @@ func Clusters(ctx context.Context, schemas *types.Schemas, managementContext *co
 		GrLister:      managementContext.Management.GlobalRoles("").Controller().Lister(),
 	}
 
-	schema.ActionHandler = handler.ClusterActionHandler
+	checker := freeze.NewChecker(managementContext.Wrangler.Mgmt.Cluster().Cache())
+	schema.ActionHandler = func(actionName string, action *types.Action, apiContext *types.APIContext) error {
+		if actionName == v3.ClusterActionImportYaml {
+			if err := changefreeze.Check(apiContext, checker, apiContext.ID); err != nil {
+				return err
+			}
+		}
+		return handler.ClusterActionHandler(actionName, action, apiContext)
+	}
 	schema.Validator = clusterValidator.Validator
 }
 
@@ -13,6 +13,7 @@
 	"github.com/rancher/rancher/pkg/api/norman/customization/yaml"
 	"github.com/rancher/rancher/pkg/api/norman/store/apiservice"
 	"github.com/rancher/rancher/pkg/api/norman/store/cert"
+	"github.com/rancher/rancher/pkg/api/norman/store/changefreeze"
 	"github.com/rancher/rancher/pkg/api/norman/store/hpa"
 	"github.com/rancher/rancher/pkg/api/norman/store/ingress"
 	"github.com/rancher/rancher/pkg/api/norman/store/namespace"
@@ -23,6 +24,7 @@
 	"github.com/rancher/rancher/pkg/api/norman/store/service"
 	"github.com/rancher/rancher/pkg/api/norman/store/storageclass"
 	"github.com/rancher/rancher/pkg/api/norman/store/workload"
+	freeze "github.com/rancher/rancher/pkg/changefreeze"
 	clusterClient "github.com/rancher/rancher/pkg/client/generated/cluster/v3"
 	client "github.com/rancher/rancher/pkg/client/generated/project/v3"
 	"github.com/rancher/rancher/pkg/clustermanager"
@@ -65,6 +67,7 @@ func Setup(ctx context.Context, mgmt *config.ScaledContext, clusterManager *clus
 	SetProjectID(schemas, clusterManager, k8sProxy)
 	StorageClass(schemas)
 	PersistentVolumeClaim(clusterManager, schemas)
+	ChangeFreeze(schemas, mgmt, clusterManager)
 
 	return nil
 }
@@ -93,6 +96,17 @@ func SetProjectID(schemas *types.Schemas, clusterManager *clustermanager.Manager
 	}
 }
 
+// ChangeFreeze rejects the changes to the resources of downstream clusters in a change freeze.
+func ChangeFreeze(schemas *types.Schemas, mgmt *config.ScaledContext, clusterManager *clustermanager.Manager) {
+	checker := freeze.NewChecker(mgmt.Wrangler.Mgmt.Cluster().Cache())
+	for _, schema := range schemas.Schemas() {
+		if schema.Store == nil || schema.Store.Context() != config.UserStorageContext {
+			continue
+		}
+		schema.Store = changefreeze.NewUserStore(schema.Store, checker, clusterManager)
+	}
+}
+
 func StorageClass(schemas *types.Schemas) {
 	storageClassSchema := schemas.Schema(&clusterschema.Version, "storageClass")
 	storageClassSchema.Store = storageclass.Wrap(storageClassSchema.Store)
@@ -0,0 +1,98 @@
+package changefreeze
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	freeze "github.com/rancher/rancher/pkg/changefreeze"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// ClusterNamer returns the cluster of a request to the API of a downstream cluster, it is implemented by the cluster
+// manager.
+type ClusterNamer interface {
+	ClusterName(apiContext *types.APIContext) string
+}
+
+type clusterIDFunc func(apiContext *types.APIContext, data map[string]interface{}) string
+
+type store struct {
+	types.Store
+
+	checker   *freeze.Checker
+	clusterID clusterIDFunc
+}
+
+// NewUserStore rejects the changes to the resources of downstream clusters in a change freeze.
+func NewUserStore(s types.Store, checker *freeze.Checker, clusters ClusterNamer) types.Store {
+	return &store{
+		Store:   s,
+		checker: checker,
+		clusterID: func(apiContext *types.APIContext, _ map[string]interface{}) string {
+			return clusters.ClusterName(apiContext)
+		},
+	}
+}
+
+// NewScopedStore rejects the changes to the management resources of clusters in a change freeze, such as role
+// bindings. The cluster of a resource is read from its key field, either a cluster ID or a project ID.
+func NewScopedStore(key string, s types.Store, checker *freeze.Checker) types.Store {
+	return &store{
+		Store:   s,
+		checker: checker,
+		clusterID: func(_ *types.APIContext, data map[string]interface{}) string {
+			clusterID, _, _ := strings.Cut(convert.ToString(data[key]), ":")
+			return clusterID
+		},
+	}
+}
+
+func (s *store) Create(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}) (map[string]interface{}, error) {
+	if err := s.check(apiContext, data); err != nil {
+		return nil, err
+	}
+	return s.Store.Create(apiContext, schema, data)
+}
+
+func (s *store) Update(apiContext *types.APIContext, schema *types.Schema, data map[string]interface{}, id string) (map[string]interface{}, error) {
+	if err := s.checkExisting(apiContext, schema, id); err != nil {
+		return nil, err
+	}
+	return s.Store.Update(apiContext, schema, data, id)
+}
+
+func (s *store) Delete(apiContext *types.APIContext, schema *types.Schema, id string) (map[string]interface{}, error) {
+	if err := s.checkExisting(apiContext, schema, id); err != nil {
+		return nil, err
+	}
+	return s.Store.Delete(apiContext, schema, id)
+}
+
+// checkExisting checks the change freeze of the cluster of an existing resource.
+func (s *store) checkExisting(apiContext *types.APIContext, schema *types.Schema, id string) error {
+	existing, err := s.Store.ByID(apiContext, schema, id)
+	if err != nil {
+		return err
+	}
+	return s.check(apiContext, existing)
+}
+
+func (s *store) check(apiContext *types.APIContext, data map[string]interface{}) error {
+	return Check(apiContext, s.checker, s.clusterID(apiContext, data))
+}
+
+// Check returns a permission denied API error if the cluster is in a change freeze and the user of the request isn't
+// exempt.
+func Check(apiContext *types.APIContext, checker *freeze.Checker, clusterID string) error {
+	ctx := apiContext.Request.Context()
+	u, _ := request.UserFrom(ctx)
+	err := checker.Check(ctx, clusterID, u)
+	var frozenErr *freeze.FrozenError
+	if errors.As(err, &frozenErr) {
+		return httperror.NewAPIError(httperror.PermissionDenied, frozenErr.Error())
+	}
+	return err
+}
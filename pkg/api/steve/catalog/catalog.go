@@ -47,7 +47,7 @@ func Register(ctx context.Context, server *steve.Server,
 }
 
 // addSchemas adds and customizes API schemas for operations, app, repo, and clusterrepo.
-// It adds action handlers and resource actions for install, upgrade, and uninstall operations of Charts.
+// It adds action handlers and resource actions for install, upgrade, rollback and uninstall operations of Charts.
 // It also sets up handlers for byID and link requests.
 //
 // The function uses predefined structure templates for API schemas, allowing for customization
@@ -62,7 +62,9 @@ func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUpgrade{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartInstallAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartInstall{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ChartRollbackAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartActionOutput{}, nil)
+	server.BaseSchemas.MustImportAndCustomize(types2.ReleaseHistory{}, nil)
 
 	operationTemplate := schema2.Template{
 		Group: catalog.GroupName,
@@ -84,12 +86,20 @@ func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
 		Customize: func(apiSchema *types.APISchema) {
 			apiSchema.ActionHandlers = map[string]http.Handler{
 				"uninstall": ops,
+				"rollback":  ops,
 			}
 			apiSchema.ResourceActions = map[string]schemas3.Action{
 				"uninstall": {
 					Input:  "chartUninstallAction",
 					Output: "chartActionOutput",
 				},
+				"rollback": {
+					Input:  "chartRollbackAction",
+					Output: "chartActionOutput",
+				},
+			}
+			apiSchema.LinkHandlers = map[string]http.Handler{
+				"history": ops,
 			}
 		},
 	}
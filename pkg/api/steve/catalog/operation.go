@@ -47,7 +47,7 @@ func newOperation(
 // For example, if the api request is for installing a chart, then it will call the
 // install function of the Operation struct.
 //
-// All chart actions (install, upgrade, and uninstall) are served through this method.
+// All chart actions (install, upgrade, rollback and uninstall) and the logs and history links are served through this method.
 func (o *operation) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	// Get the APIContext from the current request's context. This APIContext
 	// encapsulates the details of the API request, which will be used to
@@ -73,12 +73,22 @@ func (o *operation) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		op, err = o.ops.Upgrade(apiRequest.Context(), user, ns, name, req.Body, o.imageOverride)
 	case "uninstall":
 		op, err = o.ops.Uninstall(apiRequest.Context(), user, ns, name, req.Body, o.imageOverride)
+	case "rollback":
+		op, err = o.ops.Rollback(apiRequest.Context(), user, ns, name, req.Body, o.imageOverride)
 	}
 
 	switch apiRequest.Link {
 	case "logs":
 		err = o.ops.Log(apiRequest.Response, apiRequest.Request,
 			apiRequest.Namespace, apiRequest.Name)
+	case "history":
+		var history *catalogtypes.ReleaseHistory
+		if history, err = o.ops.History(apiRequest, apiRequest.Namespace, apiRequest.Name); err == nil {
+			apiRequest.WriteResponse(http.StatusOK, types.APIObject{
+				Type:   "releaseHistory",
+				Object: history,
+			})
+		}
 	}
 
 	if err != nil {
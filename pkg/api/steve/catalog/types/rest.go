@@ -2,7 +2,7 @@
 Package types define several types representing Helm chart operations.
 
 These types are used by the Steve Catalog API to handle requests and responses
-associated with Helm chart actions such as install, upgrade, and uninstall.
+associated with Helm chart actions such as install, upgrade, rollback and uninstall.
 
 Types in this package include:
 
@@ -12,7 +12,9 @@
   - ChartUninstallAction: Describes the configuration for an uninstallation action.
   - ChartUpgradeAction: Describes the configuration for an upgrade action.
   - ChartUpgrade: Represents a Helm chart upgrade request.
+  - ChartRollbackAction: Describes the configuration for a rollback action.
   - ChartActionOutput: Represents the output after performing a Helm chart action.
+  - ReleaseHistory: Lists the revisions of a release and the value changes between them.
 
 Each type includes fields that map directly to properties of Helm chart operations,
 allowing for a structured approach to managing Helm charts through the API.
@@ -94,6 +96,46 @@ type ChartUpgrade struct {
 	Annotations map[string]string     `json:"annotations,omitempty"`
 }
 
+// ChartRollbackAction represents the input received when rolling back a release to a previous revision
+type ChartRollbackAction struct {
+	// Revision is the revision to roll back to, the previous revision is used when it is 0
+	Revision               int                 `json:"revision,omitempty"`
+	Timeout                *metav1.Duration    `json:"timeout,omitempty"`
+	Wait                   bool                `json:"wait,omitempty"`
+	DisableHooks           bool                `json:"noHooks,omitempty"`
+	DryRun                 bool                `json:"dryRun,omitempty"`
+	Force                  bool                `json:"force,omitempty"`
+	CleanupOnFail          bool                `json:"cleanupOnFail,omitempty"`
+	MaxHistory             int                 `json:"historyMax,omitempty"`
+	OperationTolerations   []corev1.Toleration `json:"operationTolerations,omitempty"`
+	AutomaticCPTolerations bool                `json:"automaticCPTolerations,omitempty"`
+}
+
+// ReleaseHistory lists the revisions of a release, oldest first
+type ReleaseHistory struct {
+	Revisions []ReleaseRevision `json:"revisions,omitempty"`
+}
+
+// ReleaseRevision describes a revision of a release and how its values differ from the previous revision
+type ReleaseRevision struct {
+	Revision     int           `json:"revision,omitempty"`
+	Status       string        `json:"status,omitempty"`
+	Chart        string        `json:"chart,omitempty"`
+	ChartVersion string        `json:"chartVersion,omitempty"`
+	AppVersion   string        `json:"appVersion,omitempty"`
+	Updated      *metav1.Time  `json:"updated,omitempty"`
+	Description  string        `json:"description,omitempty"`
+	Changes      []ValueChange `json:"changes,omitempty"`
+}
+
+// ValueChange is a value added, removed or changed between two revisions. Key is the dotted path of the value.
+type ValueChange struct {
+	Key      string      `json:"key,omitempty"`
+	Type     string      `json:"type,omitempty"`
+	Previous interface{} `json:"previous,omitempty"`
+	Current  interface{} `json:"current,omitempty"`
+}
+
 type ChartActionOutput struct {
 	OperationName      string `json:"operationName,omitempty"`
 	OperationNamespace string `json:"operationNamespace,omitempty"`
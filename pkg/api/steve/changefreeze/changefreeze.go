@@ -0,0 +1,150 @@
+// Package changefreeze rejects the changes made through the Steve API of the local cluster to clusters in a change
+// freeze. Changes to downstream clusters are rejected by the cluster proxy instead.
+package changefreeze
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/catalogv2/helmop"
+	freeze "github.com/rancher/rancher/pkg/changefreeze"
+	"github.com/rancher/steve/pkg/attributes"
+	schema2 "github.com/rancher/steve/pkg/schema"
+	steve "github.com/rancher/steve/pkg/server"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const localCluster = "local"
+
+// Register wraps the store of every schema, so that its changes are checked against the change freeze of their cluster,
+// and rejects the Helm operations on the local cluster while it is frozen.
+func Register(server *steve.Server, clusters freeze.ClusterGetter, operations *helmop.Operations) {
+	checker := freeze.NewChecker(clusters)
+	operations.SetChangeCheck(func(ctx context.Context, u user.Info) error {
+		return check(ctx, checker, localCluster, u)
+	})
+	server.SchemaFactory.AddTemplate(schema2.Template{
+		Customize: func(schema *types.APISchema) {
+			if schema.Store == nil {
+				return
+			}
+			if _, ok := schema.Store.(*Store); ok {
+				return
+			}
+			schema.Store = &Store{Store: schema.Store, checker: checker}
+		},
+	})
+}
+
+// Store rejects the changes to the resources of clusters in a change freeze.
+type Store struct {
+	types.Store
+
+	checker *freeze.Checker
+}
+
+func (s *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	if err := s.check(apiOp, clusterOf(schema, data.Data(), apiOp.Namespace, false)); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Create(apiOp, schema, data)
+}
+
+func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
+	if err := s.checkExisting(apiOp, schema, id); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Update(apiOp, schema, data, id)
+}
+
+func (s *Store) Delete(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	if err := s.checkExisting(apiOp, schema, id); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Delete(apiOp, schema, id)
+}
+
+// checkExisting checks the change freeze of the cluster of an existing resource. The resources which aren't subject to
+// change freezes aren't looked up.
+func (s *Store) checkExisting(apiOp *types.APIRequest, schema *types.APISchema, id string) error {
+	if !subject(schema) {
+		return nil
+	}
+	if !needsObject(schema) {
+		return s.check(apiOp, localCluster)
+	}
+	existing, err := s.Store.ByID(apiOp, schema, id)
+	if err != nil {
+		return err
+	}
+	return s.check(apiOp, clusterOf(schema, existing.Data(), apiOp.Namespace, true))
+}
+
+func (s *Store) check(apiOp *types.APIRequest, clusterID string) error {
+	u, _ := request.UserFrom(apiOp.Context())
+	return check(apiOp.Context(), s.checker, clusterID, u)
+}
+
+// check returns a permission denied API error if the cluster is in a change freeze and the user isn't exempt.
+func check(ctx context.Context, checker *freeze.Checker, clusterID string, u user.Info) error {
+	err := checker.Check(ctx, clusterID, u)
+	var frozenErr *freeze.FrozenError
+	if errors.As(err, &frozenErr) {
+		return apierror.NewAPIError(validation.PermissionDenied, frozenErr.Error())
+	}
+	return err
+}
+
+// subject returns whether the resources of a schema are subject to change freezes. Global management and provisioning
+// resources aren't, the management cluster itself in particular, so that its change freeze can be lifted.
+func subject(schema *types.APISchema) bool {
+	switch attributes.Group(schema) {
+	case "management.cattle.io":
+		return attributes.Namespaced(schema)
+	case "provisioning.cattle.io":
+		return attributes.Kind(schema) == "Cluster"
+	}
+	return true
+}
+
+// needsObject returns whether the cluster of a resource depends on the resource itself.
+func needsObject(schema *types.APISchema) bool {
+	switch attributes.Group(schema) {
+	case "management.cattle.io", "provisioning.cattle.io":
+		return true
+	}
+	return false
+}
+
+// clusterOf returns the cluster of a resource, or an empty string if it isn't subject to change freezes:
+//   - namespaced management resources belong to the cluster of their project, if any, or to the cluster named after
+//     their namespace, or the namespace of the request, such as cluster role template bindings
+//   - provisioning clusters belong to their management cluster, which doesn't exist before they are created
+//   - every other resource belongs to the local cluster
+func clusterOf(schema *types.APISchema, obj data.Object, namespace string, existing bool) string {
+	if !subject(schema) {
+		return ""
+	}
+	switch attributes.Group(schema) {
+	case "management.cattle.io":
+		if clusterID, _, ok := strings.Cut(obj.String("projectName"), ":"); ok {
+			return clusterID
+		}
+		if ns := obj.String("metadata", "namespace"); ns != "" {
+			return ns
+		}
+		return namespace
+	case "provisioning.cattle.io":
+		if !existing {
+			return ""
+		}
+		return obj.String("status", "clusterName")
+	}
+	return localCluster
+}
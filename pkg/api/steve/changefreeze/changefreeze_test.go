@@ -0,0 +1,78 @@
+package changefreeze
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	wschemas "github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newSchema(group, kind string, namespaced bool) *types.APISchema {
+	s := &types.APISchema{Schema: &wschemas.Schema{ID: kind}}
+	attributes.SetGVK(s, schema.GroupVersionKind{Group: group, Version: "v1", Kind: kind})
+	attributes.SetNamespaced(s, namespaced)
+	return s
+}
+
+func TestClusterOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		schema    *types.APISchema
+		obj       data.Object
+		namespace string
+		existing  bool
+		want      string
+	}{
+		{
+			name:   "local resource",
+			schema: newSchema("apps", "Deployment", true),
+			obj:    data.Object{"metadata": map[string]any{"namespace": "default"}},
+			want:   localCluster,
+		},
+		{
+			name:   "cluster role template binding",
+			schema: newSchema("management.cattle.io", "ClusterRoleTemplateBinding", true),
+			obj:    data.Object{"metadata": map[string]any{"namespace": "c-abcde"}},
+			want:   "c-abcde",
+		},
+		{
+			name:      "namespace of the request",
+			schema:    newSchema("management.cattle.io", "Project", true),
+			obj:       data.Object{},
+			namespace: "c-abcde",
+			want:      "c-abcde",
+		},
+		{
+			name:   "project role template binding",
+			schema: newSchema("management.cattle.io", "ProjectRoleTemplateBinding", true),
+			obj:    data.Object{"projectName": "c-abcde:p-fghij", "metadata": map[string]any{"namespace": "c-abcde-p-fghij"}},
+			want:   "c-abcde",
+		},
+		{
+			name:   "management cluster",
+			schema: newSchema("management.cattle.io", "Cluster", false),
+			obj:    data.Object{"metadata": map[string]any{"name": "c-abcde"}},
+		},
+		{
+			name:     "provisioning cluster",
+			schema:   newSchema("provisioning.cattle.io", "Cluster", true),
+			obj:      data.Object{"status": map[string]any{"clusterName": "c-abcde"}},
+			existing: true,
+			want:     "c-abcde",
+		},
+		{
+			name:   "new provisioning cluster",
+			schema: newSchema("provisioning.cattle.io", "Cluster", true),
+			obj:    data.Object{"metadata": map[string]any{"namespace": "fleet-default"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, clusterOf(tt.schema, tt.obj, tt.namespace, tt.existing))
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// Package clusterquery serves queries listing resources across downstream clusters. A query is sent in parallel to
+// every selected cluster the user can access, through the cluster proxy and with the permissions of the user, and the
+// results are streamed as they arrive, each annotated with its cluster.
+package clusterquery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/util"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	managementv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/rancher/steve/pkg/auth"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/authorization/authorizerfactory"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	// Endpoint is the path of the cluster query API - used for routing
+	Endpoint = "/v1/clusterQuery"
+
+	// maxParallel is the number of clusters queried at once.
+	maxParallel = 20
+
+	ReasonClusterUnavailable = "ClusterUnavailable"
+	ReasonTimeout            = "Timeout"
+	ReasonProxyError         = "ProxyError"
+)
+
+var connected = condition.Cond("Connected")
+
+// Item is a resource of a cluster, or the error of a cluster that couldn't be queried.
+type Item struct {
+	Cluster string          `json:"cluster"`
+	Object  json.RawMessage `json:"object,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is why a cluster couldn't be queried.
+type Error struct {
+	Reason  string `json:"reason"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+// ClusterStatus summarizes the results of a cluster.
+type ClusterStatus struct {
+	ID       string `json:"id"`
+	Count    int    `json:"count"`
+	HasMore  bool   `json:"hasMore,omitempty"`
+	Error    *Error `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// clusterResult is the response of a cluster to a query.
+type clusterResult struct {
+	clusterID string
+	items     []json.RawMessage
+	next      string
+	err       *Error
+	duration  time.Duration
+}
+
+// Handler implements http.Handler - and serves cluster queries.
+type Handler struct {
+	clusters   mgmtcontrollers.ClusterCache
+	authorizer authorizer.Authorizer
+	// proxy is the cluster proxy serving /k8s/clusters/<id> requests.
+	proxy http.Handler
+}
+
+// NewHandler creates a cluster query handler sending the queries to the clusters through proxy.
+func NewHandler(scaledContext *config.ScaledContext, proxy http.Handler) (*Handler, error) {
+	cfg := authorizerfactory.DelegatingAuthorizerConfig{
+		SubjectAccessReviewClient: scaledContext.K8sClient.AuthorizationV1(),
+		AllowCacheTTL:             time.Second * time.Duration(settings.AuthorizationCacheTTLSeconds.GetInt()),
+		DenyCacheTTL:              time.Second * time.Duration(settings.AuthorizationDenyCacheTTLSeconds.GetInt()),
+		WebhookRetryBackoff:       &auth.WebhookBackoff,
+	}
+	authorizer, err := cfg.New()
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{
+		clusters:   scaledContext.Wrangler.Mgmt.Cluster().Cache(),
+		authorizer: authorizer,
+		proxy:      proxy,
+	}, nil
+}
+
+// ServeHTTP implements http.Handler - streams a JSON object with the resources of every cluster as items, the status
+// of every cluster as clusters, and the token of the next page as continue, if any cluster has more resources.
+// Clusters that fail or time out are reported as items with an error, and don't fail the query.
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	q, err := parseQuery(req.URL.Query())
+	if err != nil {
+		util.ReturnHTTPError(rw, req, http.StatusBadRequest, err.Error())
+		return
+	}
+	userInfo, ok := request.UserFrom(req.Context())
+	if !ok {
+		util.ReturnHTTPError(rw, req, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+		return
+	}
+	clusters, err := h.selectClusters(req.Context(), userInfo, q)
+	if err != nil {
+		logrus.Errorf("[clusterquery] Failed to select clusters: %v", err)
+		util.ReturnHTTPError(rw, req, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	results := make(chan clusterResult)
+	go h.fanOut(req, q, clusters, results)
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	h.stream(rw, results)
+}
+
+// selectClusters returns the clusters to query, sorted by name: the clusters with more results when continuing a
+// query, the clusters matching the cluster selector otherwise, among the clusters the user can access.
+func (h *Handler) selectClusters(ctx context.Context, userInfo user.Info, q *query) ([]*v3.Cluster, error) {
+	var clusters []*v3.Cluster
+	if q.continues != nil {
+		for clusterID := range q.continues {
+			cluster, err := h.clusters.Get(clusterID)
+			if err != nil {
+				continue
+			}
+			clusters = append(clusters, cluster)
+		}
+	} else {
+		var err error
+		if clusters, err = h.clusters.List(q.clusterSelector); err != nil {
+			return nil, err
+		}
+	}
+	slices.SortFunc(clusters, func(a, b *v3.Cluster) int { return strings.Compare(a.Name, b.Name) })
+
+	return slices.DeleteFunc(clusters, func(cluster *v3.Cluster) bool {
+		decision, _, err := h.authorizer.Authorize(ctx, authorizer.AttributesRecord{
+			ResourceRequest: true,
+			User:            userInfo,
+			Verb:            "get",
+			APIGroup:        managementv3.GroupName,
+			APIVersion:      managementv3.Version,
+			Resource:        "clusters",
+			Name:            cluster.Name,
+		})
+		return err != nil || decision != authorizer.DecisionAllow
+	}), nil
+}
+
+// fanOut queries the clusters in parallel and sends their results, closing results once all clusters answered.
+func (h *Handler) fanOut(req *http.Request, q *query, clusters []*v3.Cluster, results chan<- clusterResult) {
+	defer close(results)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxParallel)
+	for _, cluster := range clusters {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results <- h.queryCluster(req, q, cluster)
+		}()
+	}
+	wg.Wait()
+}
+
+// queryCluster sends the query to a cluster through the cluster proxy, with the impersonation headers of the original
+// request so that the cluster enforces the permissions of the user.
+func (h *Handler) queryCluster(req *http.Request, q *query, cluster *v3.Cluster) clusterResult {
+	result := clusterResult{clusterID: cluster.Name}
+	if connected.IsFalse(cluster) {
+		result.err = &Error{Reason: ReasonClusterUnavailable, Message: "cluster agent is disconnected"}
+		return result
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(req.Context(), q.timeout)
+	defer cancel()
+	sub := req.Clone(ctx)
+	sub.Method = http.MethodGet
+	sub.Body = http.NoBody
+	sub.ContentLength = 0
+	sub.URL.Path = q.path(cluster.Name)
+	sub.URL.RawPath = ""
+	sub.URL.RawQuery = q.rawQuery(cluster.Name)
+	sub.RequestURI = sub.URL.RequestURI()
+	sub.Header.Set("Accept", "application/json")
+	for _, header := range []string{"Accept-Encoding", "Connection", "Upgrade", "Content-Type"} {
+		sub.Header.Del(header)
+	}
+
+	resp := newResponseBuffer()
+	h.proxy.ServeHTTP(resp, sub)
+	result.duration = time.Since(start)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.err = &Error{Reason: ReasonTimeout, Message: fmt.Sprintf("cluster didn't answer within %s", q.timeout)}
+		return result
+	}
+	if resp.status != http.StatusOK {
+		result.err = responseError(resp)
+		return result
+	}
+
+	var list struct {
+		Metadata metav1.ListMeta   `json:"metadata"`
+		Items    []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(resp.body.Bytes(), &list); err != nil {
+		result.err = &Error{Reason: ReasonProxyError, Code: resp.status, Message: fmt.Sprintf("invalid list response: %v", err)}
+		return result
+	}
+	result.items = list.Items
+	result.next = list.Metadata.Continue
+	return result
+}
+
+// responseError returns the error of a failed response, using the Kubernetes status if the cluster returned one.
+func responseError(resp *responseBuffer) *Error {
+	var status metav1.Status
+	if err := json.Unmarshal(resp.body.Bytes(), &status); err == nil && status.Kind == "Status" {
+		return &Error{Reason: string(status.Reason), Code: resp.status, Message: status.Message}
+	}
+	message := strings.TrimSpace(resp.body.String())
+	if message == "" {
+		message = http.StatusText(resp.status)
+	}
+	return &Error{Reason: ReasonProxyError, Code: resp.status, Message: message}
+}
+
+// stream writes the results as they arrive.
+func (h *Handler) stream(rw http.ResponseWriter, results <-chan clusterResult) {
+	flusher, _ := rw.(http.Flusher)
+	enc := json.NewEncoder(rw)
+	enc.SetEscapeHTML(false)
+
+	first := true
+	writeItem := func(item Item) {
+		if !first {
+			rw.Write([]byte(","))
+		}
+		first = false
+		enc.Encode(item)
+	}
+
+	var statuses []ClusterStatus
+	continues := map[string]string{}
+	rw.Write([]byte(`{"type":"clusterQueryResult","items":[`))
+	for result := range results {
+		for _, object := range result.items {
+			writeItem(Item{Cluster: result.clusterID, Object: object})
+		}
+		if result.err != nil {
+			writeItem(Item{Cluster: result.clusterID, Error: result.err})
+		}
+		if result.next != "" {
+			continues[result.clusterID] = result.next
+		}
+		statuses = append(statuses, ClusterStatus{
+			ID:       result.clusterID,
+			Count:    len(result.items),
+			HasMore:  result.next != "",
+			Error:    result.err,
+			Duration: result.duration.Round(time.Millisecond).String(),
+		})
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	slices.SortFunc(statuses, func(a, b ClusterStatus) int { return strings.Compare(a.ID, b.ID) })
+
+	rw.Write([]byte(`],"clusters":`))
+	if statuses == nil {
+		statuses = []ClusterStatus{}
+	}
+	enc.Encode(statuses)
+	if token := encodeContinue(continues); token != "" {
+		rw.Write([]byte(`,"continue":`))
+		enc.Encode(token)
+	}
+	rw.Write([]byte("}\n"))
+}
+
+// responseBuffer is an http.ResponseWriter buffering the response of a cluster.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: http.Header{}}
+}
+
+func (r *responseBuffer) Header() http.Header {
+	return r.header
+}
+
+func (r *responseBuffer) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseBuffer) Write(data []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(data)
+}
+
+func (r *responseBuffer) Flush() {}
@@ -0,0 +1,218 @@
+package clusterquery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type response struct {
+	Items    []Item          `json:"items"`
+	Clusters []ClusterStatus `json:"clusters"`
+	Continue string          `json:"continue"`
+}
+
+func cluster(name string, clusterLabels map[string]string, isConnected bool) *v3.Cluster {
+	c := &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: clusterLabels}}
+	if isConnected {
+		connected.True(c)
+	} else {
+		connected.False(c)
+	}
+	return c
+}
+
+func newTestHandler(t *testing.T, proxy http.HandlerFunc) *Handler {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	clusters := []*v3.Cluster{
+		cluster("c-prod1", map[string]string{"env": "prod"}, true),
+		cluster("c-prod2", map[string]string{"env": "prod"}, true),
+		cluster("c-prod3", map[string]string{"env": "prod"}, false),
+		cluster("c-secret", map[string]string{"env": "prod"}, true),
+		cluster("c-dev", map[string]string{"env": "dev"}, true),
+	}
+	cache := fake.NewMockNonNamespacedCacheInterface[*v3.Cluster](ctrl)
+	cache.EXPECT().List(gomock.Any()).DoAndReturn(func(selector labels.Selector) ([]*v3.Cluster, error) {
+		var selected []*v3.Cluster
+		for _, c := range clusters {
+			if selector.Matches(labels.Set(c.Labels)) {
+				selected = append(selected, c)
+			}
+		}
+		return selected, nil
+	}).AnyTimes()
+	cache.EXPECT().Get(gomock.Any()).DoAndReturn(func(name string) (*v3.Cluster, error) {
+		for _, c := range clusters {
+			if c.Name == name {
+				return c, nil
+			}
+		}
+		return nil, apierrors.NewNotFound(schema.GroupResource{}, name)
+	}).AnyTimes()
+
+	return &Handler{
+		clusters: cache,
+		authorizer: authorizer.AuthorizerFunc(func(_ context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+			if a.GetName() == "c-secret" {
+				return authorizer.DecisionDeny, "", nil
+			}
+			return authorizer.DecisionAllow, "", nil
+		}),
+		proxy: proxy,
+	}
+}
+
+func serve(t *testing.T, h *Handler, target string) response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "u-abcde"}))
+	req.Header.Set("Impersonate-User", "u-abcde")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	require.Equal(t, http.StatusOK, rw.Code, rw.Body.String())
+
+	var resp response
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp), rw.Body.String())
+	return resp
+}
+
+func writeList(rw http.ResponseWriter, next string, names ...string) {
+	list := map[string]any{"metadata": map[string]any{"continue": next}}
+	var items []any
+	for _, name := range names {
+		items = append(items, map[string]any{"metadata": map[string]any{"name": name}})
+	}
+	list["items"] = items
+	json.NewEncoder(rw).Encode(list)
+}
+
+func TestServeHTTP(t *testing.T) {
+	h := newTestHandler(t, func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "u-abcde", req.Header.Get("Impersonate-User"))
+		assert.Equal(t, "app=web", req.URL.Query().Get("labelSelector"))
+		switch req.URL.Path {
+		case "/k8s/clusters/c-prod1/apis/apps/v1/namespaces/default/deployments":
+			writeList(rw, "", "web")
+		case "/k8s/clusters/c-prod2/apis/apps/v1/namespaces/default/deployments":
+			status := apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "", nil).ErrStatus
+			status.Kind = "Status"
+			rw.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(rw).Encode(status)
+		default:
+			t.Errorf("unexpected request %s", req.URL.Path)
+		}
+	})
+
+	resp := serve(t, h, "/v1/clusterQuery?apiVersion=apps/v1&resource=deployments&namespace=default&labelSelector=app%3Dweb&clusterSelector=env%3Dprod")
+
+	require.Len(t, resp.Items, 3)
+	byCluster := map[string]Item{}
+	for _, item := range resp.Items {
+		byCluster[item.Cluster] = item
+	}
+	assert.JSONEq(t, `{"metadata":{"name":"web"}}`, string(byCluster["c-prod1"].Object))
+	assert.Equal(t, string(metav1.StatusReasonForbidden), byCluster["c-prod2"].Error.Reason)
+	assert.Equal(t, http.StatusForbidden, byCluster["c-prod2"].Error.Code)
+	assert.Equal(t, ReasonClusterUnavailable, byCluster["c-prod3"].Error.Reason)
+	assert.NotContains(t, byCluster, "c-secret")
+
+	require.Len(t, resp.Clusters, 3)
+	assert.Equal(t, []string{"c-prod1", "c-prod2", "c-prod3"}, []string{resp.Clusters[0].ID, resp.Clusters[1].ID, resp.Clusters[2].ID})
+	assert.Equal(t, 1, resp.Clusters[0].Count)
+	assert.Empty(t, resp.Continue)
+}
+
+func TestServeHTTPPagination(t *testing.T) {
+	h := newTestHandler(t, func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "1", req.URL.Query().Get("limit"))
+		clusterID := strings.Split(req.URL.Path, "/")[3]
+		switch req.URL.Query().Get("continue") {
+		case "":
+			writeList(rw, clusterID+"-page2", clusterID+"-pod1")
+		case "c-dev-page2":
+			writeList(rw, "", clusterID+"-pod2")
+		default:
+			t.Errorf("unexpected continue token for cluster %s", clusterID)
+		}
+	})
+
+	resp := serve(t, h, "/v1/clusterQuery?resource=pods&limit=1&clusterSelector=env%3Ddev")
+	require.Len(t, resp.Items, 1)
+	assert.True(t, resp.Clusters[0].HasMore)
+	require.NotEmpty(t, resp.Continue)
+
+	resp = serve(t, h, "/v1/clusterQuery?resource=pods&limit=1&continue="+resp.Continue)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "c-dev", resp.Items[0].Cluster)
+	assert.JSONEq(t, `{"metadata":{"name":"c-dev-pod2"}}`, string(resp.Items[0].Object))
+	assert.Empty(t, resp.Continue)
+}
+
+func TestServeHTTPTimeout(t *testing.T) {
+	h := newTestHandler(t, func(rw http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/k8s/clusters/c-prod1/") {
+			<-req.Context().Done()
+			rw.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		writeList(rw, "", "kube-system")
+	})
+
+	start := time.Now()
+	resp := serve(t, h, "/v1/clusterQuery?resource=namespaces&timeoutSeconds=1&clusterSelector=env%3Dprod")
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var timedOut []string
+	objects := 0
+	for _, item := range resp.Items {
+		if item.Error != nil && item.Error.Reason == ReasonTimeout {
+			timedOut = append(timedOut, item.Cluster)
+		}
+		if item.Object != nil {
+			objects++
+		}
+	}
+	assert.Equal(t, []string{"c-prod1"}, timedOut)
+	assert.Equal(t, 1, objects)
+}
+
+func TestServeHTTPInvalidQuery(t *testing.T) {
+	h := newTestHandler(t, func(rw http.ResponseWriter, req *http.Request) {
+		t.Errorf("unexpected request %s", req.URL.Path)
+	})
+	for _, target := range []string{
+		"/v1/clusterQuery",
+		"/v1/clusterQuery?resource=../secrets",
+		"/v1/clusterQuery?resource=pods&apiVersion=a/b/c",
+		"/v1/clusterQuery?resource=pods&limit=0",
+		"/v1/clusterQuery?resource=pods&timeoutSeconds=3600",
+		"/v1/clusterQuery?resource=pods&clusterSelector=%3D%3D",
+		"/v1/clusterQuery?resource=pods&continue=invalid",
+	} {
+		t.Run(target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "u-abcde"}))
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req)
+			assert.Equal(t, http.StatusBadRequest, rw.Code)
+		})
+	}
+}
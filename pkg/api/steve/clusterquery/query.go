@@ -0,0 +1,161 @@
+package clusterquery
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	defaultLimit   = 100
+	maxLimit       = 1000
+	defaultTimeout = 30 * time.Second
+	maxTimeout     = 5 * time.Minute
+)
+
+// pathSegment matches the API groups, versions, resources and namespaces that can be part of a query path.
+var pathSegment = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+
+// query is a list request sent to every selected cluster.
+type query struct {
+	apiVersion      string
+	resource        string
+	namespace       string
+	labelSelector   string
+	fieldSelector   string
+	clusterSelector labels.Selector
+	limit           int
+	timeout         time.Duration
+	// continues holds the continue token of every cluster with more results, when continuing a previous query.
+	continues map[string]string
+}
+
+// parseQuery parses the parameters of a query:
+//   - apiVersion and resource, such as apps/v1 and deployments, are the type of the resources to list
+//   - namespace optionally restricts the query to a namespace
+//   - labelSelector and fieldSelector select the resources in every cluster
+//   - clusterSelector is a label selector of the clusters to query
+//   - limit is the number of resources returned per cluster and page
+//   - timeoutSeconds is how long every cluster has to answer
+//   - continue is the token returned by the previous page
+func parseQuery(values url.Values) (*query, error) {
+	q := &query{
+		apiVersion:    values.Get("apiVersion"),
+		resource:      values.Get("resource"),
+		namespace:     values.Get("namespace"),
+		labelSelector: values.Get("labelSelector"),
+		fieldSelector: values.Get("fieldSelector"),
+		limit:         defaultLimit,
+		timeout:       defaultTimeout,
+	}
+
+	if q.resource == "" || !pathSegment.MatchString(q.resource) {
+		return nil, fmt.Errorf("invalid resource %q", q.resource)
+	}
+	if q.apiVersion == "" {
+		q.apiVersion = "v1"
+	}
+	for _, segment := range strings.Split(q.apiVersion, "/") {
+		if !pathSegment.MatchString(segment) {
+			return nil, fmt.Errorf("invalid apiVersion %q", q.apiVersion)
+		}
+	}
+	if strings.Count(q.apiVersion, "/") > 1 {
+		return nil, fmt.Errorf("invalid apiVersion %q", q.apiVersion)
+	}
+	if q.namespace != "" && !pathSegment.MatchString(q.namespace) {
+		return nil, fmt.Errorf("invalid namespace %q", q.namespace)
+	}
+	if _, err := labels.Parse(q.labelSelector); err != nil {
+		return nil, fmt.Errorf("invalid labelSelector: %w", err)
+	}
+	if _, err := fields.ParseSelector(q.fieldSelector); err != nil {
+		return nil, fmt.Errorf("invalid fieldSelector: %w", err)
+	}
+
+	var err error
+	if q.clusterSelector, err = labels.Parse(values.Get("clusterSelector")); err != nil {
+		return nil, fmt.Errorf("invalid clusterSelector: %w", err)
+	}
+	if limit := values.Get("limit"); limit != "" {
+		if q.limit, err = strconv.Atoi(limit); err != nil || q.limit <= 0 || q.limit > maxLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+	}
+	if timeout := values.Get("timeoutSeconds"); timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		q.timeout = time.Duration(seconds) * time.Second
+		if err != nil || q.timeout <= 0 || q.timeout > maxTimeout {
+			return nil, fmt.Errorf("timeoutSeconds must be between 1 and %d", int(maxTimeout.Seconds()))
+		}
+	}
+	if token := values.Get("continue"); token != "" {
+		if q.continues, err = decodeContinue(token); err != nil {
+			return nil, err
+		}
+	}
+	return q, nil
+}
+
+// path returns the path of the list request sent to a cluster through the cluster proxy.
+func (q *query) path(clusterID string) string {
+	path := "/k8s/clusters/" + clusterID + "/apis/" + q.apiVersion
+	if !strings.Contains(q.apiVersion, "/") {
+		path = "/k8s/clusters/" + clusterID + "/api/" + q.apiVersion
+	}
+	if q.namespace != "" {
+		path += "/namespaces/" + q.namespace
+	}
+	return path + "/" + q.resource
+}
+
+// rawQuery returns the query string of the list request sent to a cluster.
+func (q *query) rawQuery(clusterID string) string {
+	values := url.Values{}
+	values.Set("limit", strconv.Itoa(q.limit))
+	if q.labelSelector != "" {
+		values.Set("labelSelector", q.labelSelector)
+	}
+	if q.fieldSelector != "" {
+		values.Set("fieldSelector", q.fieldSelector)
+	}
+	if token := q.continues[clusterID]; token != "" {
+		values.Set("continue", token)
+	}
+	return values.Encode()
+}
+
+// encodeContinue returns the continue token of a query from the continue tokens of the clusters with more results, or
+// an empty string if all the clusters returned all their results.
+func encodeContinue(continues map[string]string) string {
+	if len(continues) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(continues)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeContinue(token string) (map[string]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid continue token")
+	}
+	continues := map[string]string{}
+	if err := json.Unmarshal(data, &continues); err != nil || len(continues) == 0 {
+		return nil, fmt.Errorf("invalid continue token")
+	}
+	for clusterID := range continues {
+		if !pathSegment.MatchString(clusterID) {
+			return nil, fmt.Errorf("invalid continue token")
+		}
+	}
+	return continues, nil
+}
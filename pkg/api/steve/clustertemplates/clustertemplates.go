@@ -0,0 +1,201 @@
+// Package clustertemplates applies cluster templates to the provisioning clusters created through the Steve API, and
+// checks them for the principals that may only create clusters from a template. The templates are enforced for them by
+// an admission policy, whichever API they use, the checks of the store only report clearer errors.
+package clustertemplates
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/provisioningv2/clustertemplate"
+	"github.com/rancher/steve/pkg/attributes"
+	schema2 "github.com/rancher/steve/pkg/schema"
+	steve "github.com/rancher/steve/pkg/server"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// Register wraps the store of provisioning clusters with the cluster template checks. The store is only set by the
+// default template, which runs after the templates of a group and kind, so the wrapper is registered from a template of
+// every schema.
+func Register(server *steve.Server, templates clustertemplate.TemplateGetter, revisions clustertemplate.RevisionGetter) {
+	server.SchemaFactory.AddTemplate(schema2.Template{
+		Customize: func(schema *types.APISchema) {
+			if schema.Store == nil || attributes.Group(schema) != "provisioning.cattle.io" || attributes.Kind(schema) != "Cluster" {
+				return
+			}
+			if _, ok := schema.Store.(*Store); ok {
+				return
+			}
+			schema.Store = &Store{Store: schema.Store, templates: templates, revisions: revisions}
+		},
+	})
+}
+
+// Store binds the clusters it creates to the default revision of their template and applies the revision to them, so
+// that they are created with the configuration of the template. Enforced principals can't create clusters without a
+// template, unbind them from it or change the fields owned by the template.
+type Store struct {
+	types.Store
+
+	templates clustertemplate.TemplateGetter
+	revisions clustertemplate.RevisionGetter
+}
+
+func (s *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, obj types.APIObject) (types.APIObject, error) {
+	spec, err := toSpec(obj.Data())
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	if spec.ClusterTemplate == nil {
+		if s.enforced(apiOp) {
+			return types.APIObject{}, apierror.NewAPIError(validation.PermissionDenied, "clusters must be created from a cluster template")
+		}
+		return s.Store.Create(apiOp, schema, obj)
+	}
+
+	namespace := obj.Data().String("metadata", "namespace")
+	if namespace == "" {
+		namespace = apiOp.Namespace
+	}
+	revision, err := clustertemplate.Resolve(s.templates, s.revisions, namespace, spec.ClusterTemplate)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	if !clustertemplate.Enabled(revision) {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("revision %s is disabled", revision.Name))
+	}
+	config, err := clustertemplate.Render(revision, spec.ClusterTemplate.Answers)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("revision %s: %v", revision.Name, err))
+	}
+
+	spec.ClusterTemplate.Revision = revision.Name
+	if err := config.Apply(spec); err != nil {
+		return types.APIObject{}, err
+	}
+	if err := setSpec(obj.Data(), spec); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Create(apiOp, schema, obj)
+}
+
+func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, obj types.APIObject, id string) (types.APIObject, error) {
+	spec, err := toSpec(obj.Data())
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	existing, err := s.Store.ByID(apiOp, schema, id)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	existingSpec, err := toSpec(existing.Data())
+	if err != nil {
+		return types.APIObject{}, err
+	}
+
+	enforced := s.enforced(apiOp)
+	if existingSpec.ClusterTemplate != nil && enforced &&
+		(spec.ClusterTemplate == nil || spec.ClusterTemplate.Name != existingSpec.ClusterTemplate.Name) {
+		return types.APIObject{}, apierror.NewAPIError(validation.PermissionDenied, "clusters can not be unbound from their cluster template")
+	}
+	if spec.ClusterTemplate == nil {
+		return s.Store.Update(apiOp, schema, obj, id)
+	}
+	upgrade := spec.ClusterTemplate.Revision != "" &&
+		(existingSpec.ClusterTemplate == nil || spec.ClusterTemplate.Revision != existingSpec.ClusterTemplate.Revision)
+	if !upgrade && !enforced {
+		return s.Store.Update(apiOp, schema, obj, id)
+	}
+
+	revision, err := clustertemplate.Resolve(s.templates, s.revisions, existing.Data().String("metadata", "namespace"), spec.ClusterTemplate)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	// upgrades are only allowed to enabled revisions
+	if upgrade && !clustertemplate.Enabled(revision) {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("revision %s is disabled", revision.Name))
+	}
+	if enforced {
+		applied, err := enforce(revision, spec, existingSpec)
+		if err != nil {
+			return types.APIObject{}, err
+		}
+		if applied {
+			if err := setSpec(obj.Data(), spec); err != nil {
+				return types.APIObject{}, err
+			}
+		}
+	}
+	return s.Store.Update(apiOp, schema, obj, id)
+}
+
+// enforce rejects the changes an enforced principal makes to the fields of a cluster owned by its template. Binding the
+// cluster to another revision, or answering the questions differently, applies the revision to the spec instead, as
+// when creating the cluster. It returns whether the revision was applied.
+func enforce(revision *rancherv1.ClusterTemplateRevision, spec, existingSpec *rancherv1.ClusterSpec) (bool, error) {
+	config, err := clustertemplate.Render(revision, spec.ClusterTemplate.Answers)
+	if err != nil {
+		return false, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("revision %s: %v", revision.Name, err))
+	}
+	if existingSpec.ClusterTemplate == nil || existingSpec.ClusterTemplate.Revision != revision.Name ||
+		!maps.Equal(existingSpec.ClusterTemplate.Answers, spec.ClusterTemplate.Answers) {
+		spec.ClusterTemplate.Revision = revision.Name
+		return true, config.Apply(spec)
+	}
+
+	drift, err := config.Drift(*spec)
+	if err != nil {
+		return false, err
+	}
+	// the drift the cluster already has isn't introduced by the update
+	existingDrift, err := config.Drift(*existingSpec)
+	if err != nil {
+		return false, err
+	}
+	drift = slices.DeleteFunc(drift, func(path string) bool {
+		return slices.Contains(existingDrift, path)
+	})
+	if len(drift) > 0 {
+		return false, apierror.NewAPIError(validation.PermissionDenied,
+			fmt.Sprintf("fields owned by cluster template %s can not be changed: %s", spec.ClusterTemplate.Name, strings.Join(drift, ", ")))
+	}
+	return false, nil
+}
+
+func (s *Store) enforced(apiOp *types.APIRequest) bool {
+	u, _ := request.UserFrom(apiOp.Context())
+	return clustertemplate.Enforced(u)
+}
+
+func toSpec(obj data.Object) (*rancherv1.ClusterSpec, error) {
+	spec := &rancherv1.ClusterSpec{}
+	raw, err := json.Marshal(obj.Map("spec"))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, spec); err != nil {
+		return nil, fmt.Errorf("invalid cluster spec: %w", err)
+	}
+	return spec, nil
+}
+
+func setSpec(obj data.Object, spec *rancherv1.ClusterSpec) error {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	result := data.Object{}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return err
+	}
+	obj["spec"] = map[string]interface{}(result)
+	return nil
+}
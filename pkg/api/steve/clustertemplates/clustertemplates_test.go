@@ -0,0 +1,194 @@
+package clustertemplates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/steve/pkg/attributes"
+	schema2 "github.com/rancher/steve/pkg/schema"
+	steve "github.com/rancher/steve/pkg/server"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	wschemas "github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/utils/ptr"
+)
+
+// fakeStore is the store set by the default template, it records the objects created and updated.
+type fakeStore struct {
+	types.Store
+
+	existing types.APIObject
+	created  types.APIObject
+	updated  types.APIObject
+}
+
+func (f *fakeStore) ByID(_ *types.APIRequest, _ *types.APISchema, _ string) (types.APIObject, error) {
+	return f.existing, nil
+}
+
+func (f *fakeStore) Create(_ *types.APIRequest, _ *types.APISchema, obj types.APIObject) (types.APIObject, error) {
+	f.created = obj
+	return obj, nil
+}
+
+func (f *fakeStore) Update(_ *types.APIRequest, _ *types.APISchema, obj types.APIObject, _ string) (types.APIObject, error) {
+	f.updated = obj
+	return obj, nil
+}
+
+func newSchema(group, kind string) *types.APISchema {
+	s := &types.APISchema{Schema: &wschemas.Schema{ID: group + "." + kind}}
+	attributes.SetGVK(s, schema.GroupVersionKind{Group: group, Version: "v1", Kind: kind})
+	return s
+}
+
+func newRevision(name, kubernetesVersion string, enabled bool) *rancherv1.ClusterTemplateRevision {
+	return &rancherv1.ClusterTemplateRevision{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: name},
+		Spec: rancherv1.ClusterTemplateRevisionSpec{
+			ClusterTemplateName: "rke2",
+			Enabled:             ptr.To(enabled),
+			ClusterConfig: rancherv1.ClusterSpec{
+				KubernetesVersion: kubernetesVersion,
+			},
+		},
+	}
+}
+
+func newCluster(spec map[string]any) types.APIObject {
+	return types.APIObject{Object: map[string]any{
+		"metadata": map[string]any{"namespace": "fleet-default", "name": "prod"},
+		"spec":     spec,
+	}}
+}
+
+func newRequest(name string) *types.APIRequest {
+	req := httptest.NewRequest(http.MethodPut, "/v1/provisioning.cattle.io.clusters/fleet-default/prod", nil)
+	ctx := request.WithUser(context.Background(), &user.DefaultInfo{Name: name})
+	return &types.APIRequest{Request: req.WithContext(ctx), Namespace: "fleet-default"}
+}
+
+func assertAPIError(t *testing.T, err error, code validation.ErrorCode) {
+	t.Helper()
+	var apiErr *apierror.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, code, apiErr.Code)
+}
+
+func TestStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	templates := fake.NewMockCacheInterface[*rancherv1.ClusterTemplate](ctrl)
+	revisions := fake.NewMockCacheInterface[*rancherv1.ClusterTemplateRevision](ctrl)
+	templates.EXPECT().Get("fleet-default", "rke2").Return(&rancherv1.ClusterTemplate{
+		Spec: rancherv1.ClusterTemplateSpec{DefaultRevisionName: "rke2-v1"},
+	}, nil).AnyTimes()
+	revisions.EXPECT().Get("fleet-default", "rke2-v1").Return(newRevision("rke2-v1", "v1.33.1+rke2r1", true), nil).AnyTimes()
+	revisions.EXPECT().Get("fleet-default", "rke2-v2").Return(newRevision("rke2-v2", "v1.34.1+rke2r1", false), nil).AnyTimes()
+	revisions.EXPECT().Get("fleet-default", "rke2-v3").Return(newRevision("rke2-v3", "v1.34.1+rke2r1", true), nil).AnyTimes()
+	require.NoError(t, settings.ClusterTemplateEnforcedPrincipals.Set("u-enforced"))
+	t.Cleanup(func() { _ = settings.ClusterTemplateEnforcedPrincipals.Set("") })
+
+	// the store is wrapped through the schema factory, after the default template set it
+	inner := &fakeStore{}
+	collection := schema2.NewCollection(context.Background(), types.EmptyAPISchemas(), nil)
+	collection.AddTemplate(schema2.Template{Store: inner})
+	Register(&steve.Server{SchemaFactory: collection}, templates, revisions)
+	clusters, deployments := newSchema("provisioning.cattle.io", "Cluster"), newSchema("apps", "Deployment")
+	collection.Reset(map[string]*types.APISchema{clusters.ID: clusters, deployments.ID: deployments})
+	assert.Same(t, inner, collection.Schema(deployments.ID).Store)
+	store, ok := collection.Schema(clusters.ID).Store.(*Store)
+	require.True(t, ok)
+
+	t.Run("create from the default revision", func(t *testing.T) {
+		_, err := store.Create(newRequest("u-enforced"), clusters, newCluster(map[string]any{
+			"clusterTemplate":   map[string]any{"name": "rke2"},
+			"kubernetesVersion": "v1.20.0+rke2r1",
+		}))
+		require.NoError(t, err)
+		assert.Equal(t, "rke2-v1", inner.created.Data().String("spec", "clusterTemplate", "revision"))
+		assert.Equal(t, "v1.33.1+rke2r1", inner.created.Data().String("spec", "kubernetesVersion"))
+	})
+
+	t.Run("enforced create without template", func(t *testing.T) {
+		_, err := store.Create(newRequest("u-enforced"), clusters, newCluster(map[string]any{}))
+		assertAPIError(t, err, validation.PermissionDenied)
+	})
+
+	t.Run("create from a disabled revision", func(t *testing.T) {
+		_, err := store.Create(newRequest("u-other"), clusters, newCluster(map[string]any{
+			"clusterTemplate": map[string]any{"name": "rke2", "revision": "rke2-v2"},
+		}))
+		assertAPIError(t, err, validation.InvalidBodyContent)
+	})
+
+	inner.existing = newCluster(map[string]any{
+		"clusterTemplate":   map[string]any{"name": "rke2", "revision": "rke2-v1"},
+		"kubernetesVersion": "v1.33.1+rke2r1",
+	})
+
+	t.Run("enforced update drifting from the template", func(t *testing.T) {
+		inner.updated = types.APIObject{}
+		_, err := store.Update(newRequest("u-enforced"), clusters, newCluster(map[string]any{
+			"clusterTemplate":   map[string]any{"name": "rke2", "revision": "rke2-v1"},
+			"kubernetesVersion": "v1.34.1+rke2r1",
+		}), "fleet-default/prod")
+		assertAPIError(t, err, validation.PermissionDenied)
+		assert.Contains(t, err.Error(), "kubernetesVersion")
+		assert.Nil(t, inner.updated.Object)
+	})
+
+	t.Run("enforced update of fields not owned by the template", func(t *testing.T) {
+		_, err := store.Update(newRequest("u-enforced"), clusters, newCluster(map[string]any{
+			"clusterTemplate":   map[string]any{"name": "rke2", "revision": "rke2-v1"},
+			"kubernetesVersion": "v1.33.1+rke2r1",
+			"defaultPodSecurityAdmissionConfigurationTemplateName": "rancher-restricted",
+		}), "fleet-default/prod")
+		require.NoError(t, err)
+		assert.Equal(t, "rancher-restricted", inner.updated.Data().String("spec", "defaultPodSecurityAdmissionConfigurationTemplateName"))
+	})
+
+	t.Run("enforced unbinding", func(t *testing.T) {
+		_, err := store.Update(newRequest("u-enforced"), clusters, newCluster(map[string]any{
+			"kubernetesVersion": "v1.33.1+rke2r1",
+		}), "fleet-default/prod")
+		assertAPIError(t, err, validation.PermissionDenied)
+	})
+
+	t.Run("update drifting from the template", func(t *testing.T) {
+		_, err := store.Update(newRequest("u-other"), clusters, newCluster(map[string]any{
+			"clusterTemplate":   map[string]any{"name": "rke2", "revision": "rke2-v1"},
+			"kubernetesVersion": "v1.34.1+rke2r1",
+		}), "fleet-default/prod")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.34.1+rke2r1", inner.updated.Data().String("spec", "kubernetesVersion"))
+	})
+
+	t.Run("enforced upgrade", func(t *testing.T) {
+		_, err := store.Update(newRequest("u-enforced"), clusters, newCluster(map[string]any{
+			"clusterTemplate":   map[string]any{"name": "rke2", "revision": "rke2-v3"},
+			"kubernetesVersion": "v1.33.1+rke2r1",
+		}), "fleet-default/prod")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.34.1+rke2r1", inner.updated.Data().String("spec", "kubernetesVersion"))
+	})
+
+	t.Run("upgrade to a disabled revision", func(t *testing.T) {
+		_, err := store.Update(newRequest("u-other"), clusters, newCluster(map[string]any{
+			"clusterTemplate": map[string]any{"name": "rke2", "revision": "rke2-v2"},
+		}), "fleet-default/prod")
+		assertAPIError(t, err, validation.InvalidBodyContent)
+	})
+}
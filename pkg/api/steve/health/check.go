@@ -0,0 +1,156 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	statusOK       = "ok"
+	statusFailed   = "failed"
+	statusExcluded = "excluded"
+
+	// checkCacheInterval is the interval during which the result of a check is reported again instead of running the
+	// check, so that frequent probes don't translate into as many requests to the components being checked.
+	checkCacheInterval = 5 * time.Second
+)
+
+// Check is a named health check of a component of the Rancher server.
+type Check struct {
+	Name string
+	// Check returns a short description of the state of the component, or an error if it is unhealthy.
+	Check func(req *http.Request) (string, error)
+}
+
+type checkResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type report struct {
+	Status   string        `json:"status"`
+	Checks   []checkResult `json:"checks"`
+	Warnings []string      `json:"warnings,omitempty"`
+}
+
+// installChecks serves the given checks at the given path, and each of them individually at <path>/<name>. Like the
+// health endpoints of the Kubernetes apiserver, checks can be skipped with the "exclude" query parameter, and the
+// "verbose" query parameter lists the result of each check. Results are reported in JSON, and are always reported when
+// a check fails. As they may include the addresses of internal components, the messages and errors of the checks are
+// only reported to the requests for which authorized returns true.
+func installChecks(router *mux.Router, path string, authorized func(req *http.Request) bool, checks ...Check) {
+	for i := range checks {
+		checks[i] = cached(checks[i], time.Now)
+	}
+	router.Handle(path, checkHandler(checks, authorized))
+	for _, check := range checks {
+		router.Handle(path+"/"+check.Name, checkHandler([]Check{check}, authorized))
+	}
+}
+
+// cached returns a check reporting the result of the given check, which is run at most once per checkCacheInterval.
+// The check is not canceled with the request running it, as its result is reported to other requests.
+func cached(check Check, now func() time.Time) Check {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		message string
+		err     error
+	)
+	return Check{
+		Name: check.Name,
+		Check: func(req *http.Request) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if checked.IsZero() || now().Sub(checked) >= checkCacheInterval {
+				message, err = check.Check(req.WithContext(context.WithoutCancel(req.Context())))
+				checked = now()
+			}
+			return message, err
+		},
+	}
+}
+
+func checkHandler(checks []Check, authorized func(req *http.Request) bool) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		_, verbose := query["verbose"]
+		result := run(req, checks, query["exclude"])
+		if (result.Status != statusOK || verbose) && !authorized(req) {
+			for i := range result.Checks {
+				result.Checks[i].Message = ""
+				result.Checks[i].Error = ""
+			}
+		}
+
+		if result.Status == statusOK && !verbose {
+			rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			rw.Header().Set("X-Content-Type-Options", "nosniff")
+			rw.Write([]byte(statusOK))
+			return
+		}
+
+		data, err := json.Marshal(result)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("X-Content-Type-Options", "nosniff")
+		if result.Status != statusOK {
+			rw.WriteHeader(http.StatusInternalServerError)
+		}
+		rw.Write(data)
+	})
+}
+
+// run runs the given checks concurrently, except for the excluded ones.
+func run(req *http.Request, checks []Check, exclude []string) report {
+	result := report{
+		Status: statusOK,
+		Checks: make([]checkResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		result.Checks[i].Name = check.Name
+		if slices.Contains(exclude, check.Name) {
+			result.Checks[i].Status = statusExcluded
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			message, err := check.Check(req)
+			result.Checks[i].Message = message
+			if err != nil {
+				result.Checks[i].Status = statusFailed
+				result.Checks[i].Error = err.Error()
+			} else {
+				result.Checks[i].Status = statusOK
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, check := range result.Checks {
+		if check.Status == statusFailed {
+			result.Status = statusFailed
+		}
+	}
+	for _, name := range exclude {
+		if !slices.ContainsFunc(checks, func(check Check) bool { return check.Name == name }) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("no check named %q to exclude", name))
+		}
+	}
+	return result
+}
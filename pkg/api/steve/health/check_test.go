@@ -0,0 +1,157 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newRouter(authorized bool) *mux.Router {
+	router := mux.NewRouter()
+	installChecks(router, "/readyz", func(*http.Request) bool { return authorized },
+		ping(),
+		Check{Name: "caches", Check: func(*http.Request) (string, error) {
+			return "", errors.New("caches have not synced")
+		}},
+	)
+	return router
+}
+
+func serve(t *testing.T, router *mux.Router, target string) (int, report, string) {
+	t.Helper()
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, target, nil))
+
+	var result report
+	if rw.Header().Get("Content-Type") == "application/json" {
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+	}
+	return rw.Code, result, rw.Body.String()
+}
+
+func TestChecks(t *testing.T) {
+	router := newRouter(true)
+
+	code, result, _ := serve(t, router, "/readyz")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, report{
+		Status: statusFailed,
+		Checks: []checkResult{
+			{Name: "ping", Status: statusOK, Message: "pong"},
+			{Name: "caches", Status: statusFailed, Error: "caches have not synced"},
+		},
+	}, result)
+
+	code, _, body := serve(t, router, "/readyz?exclude=caches")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body)
+
+	code, result, _ = serve(t, router, "/readyz?exclude=caches&exclude=webhook&verbose")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, report{
+		Status: statusOK,
+		Checks: []checkResult{
+			{Name: "ping", Status: statusOK, Message: "pong"},
+			{Name: "caches", Status: statusExcluded},
+		},
+		Warnings: []string{`no check named "webhook" to exclude`},
+	}, result)
+
+	code, _, body = serve(t, router, "/readyz/ping")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body)
+
+	code, result, _ = serve(t, router, "/readyz/caches")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, []checkResult{{Name: "caches", Status: statusFailed, Error: "caches have not synced"}}, result.Checks)
+}
+
+func TestChecksUnauthorized(t *testing.T) {
+	router := newRouter(false)
+
+	code, result, _ := serve(t, router, "/readyz")
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, report{
+		Status: statusFailed,
+		Checks: []checkResult{
+			{Name: "ping", Status: statusOK},
+			{Name: "caches", Status: statusFailed},
+		},
+	}, result)
+
+	code, _, body := serve(t, router, "/readyz?exclude=caches")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body)
+}
+
+func TestCached(t *testing.T) {
+	now := time.Now()
+	runs := 0
+	check := cached(Check{Name: "count", Check: func(*http.Request) (string, error) {
+		runs++
+		return "", errors.New("failed")
+	}}, func() time.Time { return now })
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	_, err := check.Check(req)
+	assert.EqualError(t, err, "failed")
+	_, err = check.Check(req)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 1, runs)
+
+	now = now.Add(checkCacheInterval)
+	_, err = check.Check(req)
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, 2, runs)
+}
+
+func TestAuthProviderAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]any
+		expected []string
+	}{
+		{
+			name:     "ldap",
+			config:   map[string]any{"servers": []any{"ldap1.example.com", "ldap2.example.com"}, "port": int64(636)},
+			expected: []string{"ldap1.example.com:636", "ldap2.example.com:636"},
+		},
+		{
+			name:     "ldap default port",
+			config:   map[string]any{"servers": []any{"ldap.example.com"}},
+			expected: []string{"ldap.example.com:389"},
+		},
+		{
+			name:     "oidc",
+			config:   map[string]any{"issuer": "https://keycloak.example.com:8443/realms/rancher"},
+			expected: []string{"keycloak.example.com:8443"},
+		},
+		{
+			name:     "azure ad",
+			config:   map[string]any{"graphEndpoint": "https://graph.microsoft.com"},
+			expected: []string{"graph.microsoft.com:443"},
+		},
+		{
+			name:     "github",
+			config:   map[string]any{"hostname": "github.example.com", "tls": false},
+			expected: []string{"github.example.com:80"},
+		},
+		{
+			name:   "saml",
+			config: map[string]any{"idpMetadataContent": "<xml/>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, authProviderAddresses(&unstructured.Unstructured{Object: tt.config}))
+		})
+	}
+}
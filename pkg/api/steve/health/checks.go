@@ -0,0 +1,266 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/ext"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/peermanager"
+	admissioncontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/admissionregistration.k8s.io/v1"
+	apiregistrationcontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/apiregistration.k8s.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// checkTimeout bounds the time a check waits for a remote component.
+	checkTimeout = 2 * time.Second
+	// webhookConfigurationName is the name of the validating webhook configuration of rancher-webhook.
+	webhookConfigurationName = "rancher.cattle.io"
+	// localProvider is the name of the auth config of local users, which has no server.
+	localProvider = "local"
+)
+
+var authConfigResource = v3.SchemeGroupVersion.WithResource("authconfigs")
+
+// cacheSyncCheck fails until the controllers have started and all caches have synced, so that no traffic is routed to
+// a replica serving from incomplete caches.
+func cacheSyncCheck(cachesSynced func(ctx context.Context) ([]schema.GroupVersionKind, error)) Check {
+	return Check{
+		Name: "caches",
+		Check: func(req *http.Request) (string, error) {
+			ctx, cancel := context.WithTimeout(req.Context(), 100*time.Millisecond)
+			defer cancel()
+			unsynced, err := cachesSynced(ctx)
+			if err != nil {
+				return "", err
+			}
+			if len(unsynced) > 0 {
+				var kinds []string
+				for _, gvk := range unsynced {
+					kinds = append(kinds, gvk.String())
+				}
+				slices.Sort(kinds)
+				return "", fmt.Errorf("caches have not synced: %s", strings.Join(kinds, ", "))
+			}
+			return "all caches synced", nil
+		},
+	}
+}
+
+// peersCheck reports the leadership and peers of the replica. It does not fail when the replica is not listed as a
+// ready endpoint of the Rancher service, as that only happens once the replica is ready.
+func peersCheck(peerManager peermanager.PeerManager) Check {
+	return Check{
+		Name: "peers",
+		Check: func(_ *http.Request) (string, error) {
+			if peerManager == nil {
+				return "single server mode", nil
+			}
+			peers := peerManager.Peers()
+			ids := slices.Clone(peers.IDs)
+			slices.Sort(ids)
+			return fmt.Sprintf("id=%s leader=%t ready=%t peers=[%s]", peers.SelfID, peers.Leader, peers.Ready, strings.Join(ids, ",")), nil
+		},
+	}
+}
+
+// authProviderCheck checks that the servers of the enabled auth providers are reachable. Providers without a
+// configured server, such as SAML providers, are skipped. The enabled providers are read from the cache, and only their
+// auth configs are fetched in full, as the cached auth configs don't hold the fields specific to each provider.
+func authProviderCheck(authConfigs mgmtcontrollers.AuthConfigCache, client dynamic.Interface) Check {
+	return Check{
+		Name: "auth-provider",
+		Check: func(req *http.Request) (string, error) {
+			configs, err := authConfigs.List(labels.Everything())
+			if err != nil {
+				return "", err
+			}
+
+			var checked []string
+			for _, config := range configs {
+				if !config.Enabled || config.Name == localProvider {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(req.Context(), checkTimeout)
+				obj, err := client.Resource(authConfigResource).Get(ctx, config.Name, metav1.GetOptions{})
+				cancel()
+				if err != nil {
+					return "", err
+				}
+				addresses := authProviderAddresses(obj)
+				if len(addresses) == 0 {
+					continue
+				}
+				if err := dialAny(req.Context(), addresses); err != nil {
+					return "", fmt.Errorf("auth provider %s is unreachable: %w", config.Name, err)
+				}
+				checked = append(checked, config.Name)
+			}
+			if len(checked) == 0 {
+				return "no auth provider server to check", nil
+			}
+			slices.Sort(checked)
+			return "reachable: " + strings.Join(checked, ", "), nil
+		},
+	}
+}
+
+// authProviderAddresses returns the host:port addresses of the servers of an auth config: the servers of LDAP
+// providers, the issuer of OIDC providers, the graph endpoint of Azure AD and the host of GitHub.
+func authProviderAddresses(obj *unstructured.Unstructured) []string {
+	var addresses []string
+	if servers, _, _ := unstructured.NestedStringSlice(obj.Object, "servers"); len(servers) > 0 {
+		port, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "port")
+		portString := fmt.Sprint(port)
+		if port == nil {
+			portString = "389"
+		}
+		for _, server := range servers {
+			addresses = append(addresses, net.JoinHostPort(server, portString))
+		}
+		return addresses
+	}
+	for _, field := range []string{"issuer", "graphEndpoint"} {
+		if endpoint, _, _ := unstructured.NestedString(obj.Object, field); endpoint != "" {
+			if address := urlAddress(endpoint); address != "" {
+				return []string{address}
+			}
+		}
+	}
+	if hostname, _, _ := unstructured.NestedString(obj.Object, "hostname"); hostname != "" {
+		tls, found, _ := unstructured.NestedBool(obj.Object, "tls")
+		if !found || tls {
+			return []string{net.JoinHostPort(hostname, "443")}
+		}
+		return []string{net.JoinHostPort(hostname, "80")}
+	}
+	return nil
+}
+
+func urlAddress(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	if port := u.Port(); port != "" {
+		return net.JoinHostPort(u.Hostname(), port)
+	}
+	if u.Scheme == "http" {
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+	return net.JoinHostPort(u.Hostname(), "443")
+}
+
+// dialAny succeeds if any of the given addresses accepts a TCP connection.
+func dialAny(ctx context.Context, addresses []string) error {
+	dialer := net.Dialer{Timeout: checkTimeout}
+	var errs []error
+	for _, address := range addresses {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// aggregationCheck checks that the kube-apiserver has contacted the extension API server through API aggregation.
+func aggregationCheck(apiServices apiregistrationcontrollers.APIServiceClient) Check {
+	return Check{
+		Name: "ext-aggregation",
+		Check: func(_ *http.Request) (string, error) {
+			if !ext.AggregationPreCheck(apiServices) {
+				return "", fmt.Errorf("kube-apiserver has not contacted the extension API server through APIService %s", ext.APIServiceName)
+			}
+			return "API aggregation available", nil
+		},
+	}
+}
+
+// systemChartsCheck checks that the system charts manager has started and installed the system charts.
+func systemChartsCheck(ready func() error) Check {
+	return Check{
+		Name: "system-charts",
+		Check: func(_ *http.Request) (string, error) {
+			if err := ready(); err != nil {
+				return "", err
+			}
+			return "system charts installed", nil
+		},
+	}
+}
+
+// webhookCheck checks that the rancher-webhook service targeted by its validating webhook configuration responds to
+// HTTPS requests, using the CA bundle of the webhook configuration.
+func webhookCheck(webhookConfigurations admissioncontrollers.ValidatingWebhookConfigurationClient) Check {
+	return Check{
+		Name: "webhook",
+		Check: func(req *http.Request) (string, error) {
+			config, err := webhookConfigurations.Get(webhookConfigurationName, metav1.GetOptions{})
+			if err != nil {
+				return "", err
+			}
+			if len(config.Webhooks) == 0 {
+				return "", fmt.Errorf("validating webhook configuration %s has no webhooks", webhookConfigurationName)
+			}
+
+			clientConfig := config.Webhooks[0].ClientConfig
+			var target string
+			switch {
+			case clientConfig.URL != nil:
+				u, err := url.Parse(*clientConfig.URL)
+				if err != nil {
+					return "", err
+				}
+				target = "https://" + u.Host + "/healthz"
+			case clientConfig.Service != nil:
+				port := int32(443)
+				if clientConfig.Service.Port != nil {
+					port = *clientConfig.Service.Port
+				}
+				target = "https://" + net.JoinHostPort(clientConfig.Service.Name+"."+clientConfig.Service.Namespace+".svc", strconv.Itoa(int(port))) + "/healthz"
+			default:
+				return "", fmt.Errorf("validating webhook configuration %s has no client config", webhookConfigurationName)
+			}
+
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(clientConfig.CABundle)
+			client := &http.Client{
+				Timeout: checkTimeout,
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{RootCAs: pool},
+				},
+			}
+			healthReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, target, nil)
+			if err != nil {
+				return "", err
+			}
+			resp, err := client.Do(healthReq)
+			if err != nil {
+				return "", fmt.Errorf("rancher-webhook is unreachable: %w", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return "", fmt.Errorf("rancher-webhook responded with %s", resp.Status)
+			}
+			return "rancher-webhook reachable", nil
+		},
+	}
+}
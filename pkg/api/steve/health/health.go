@@ -4,12 +4,40 @@
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/rancher/rancher/pkg/features"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/client-go/dynamic"
 )
 
-func Register(router *mux.Router) {
+func Register(router *mux.Router, config *wrangler.Context) error {
 	healthz.InstallHandler((*muxWrapper)(router))
 	router.Handle("/ping", Pong())
+
+	client, err := dynamic.NewForConfig(config.RESTConfig)
+	if err != nil {
+		return err
+	}
+
+	authorized := isAdmin(config.ASL)
+	installChecks(router, "/livez", authorized, ping())
+
+	readyChecks := []Check{
+		ping(),
+		cacheSyncCheck(config.CachesSynced),
+		peersCheck(config.PeerManager),
+		authProviderCheck(config.Mgmt.AuthConfig().Cache(), client),
+		systemChartsCheck(config.SystemChartsManager.Ready),
+		webhookCheck(config.Admission.ValidatingWebhookConfiguration()),
+	}
+	if !features.MCMAgent.Enabled() {
+		readyChecks = append(readyChecks, aggregationCheck(config.API.APIService()))
+	}
+	installChecks(router, "/readyz", authorized, readyChecks...)
+	return nil
 }
 
 func Pong() http.Handler {
@@ -18,6 +46,27 @@ func Pong() http.Handler {
 	})
 }
 
+// isAdmin returns whether the user of a request is allowed all verbs on all resources, which is required to read the
+// details of the checks.
+func isAdmin(asl accesscontrol.AccessSetLookup) func(req *http.Request) bool {
+	return func(req *http.Request) bool {
+		user, ok := request.UserFrom(req.Context())
+		if !ok {
+			return false
+		}
+		return asl.AccessFor(user).Grants("*", schema.GroupResource{Group: "*", Resource: "*"}, "", "")
+	}
+}
+
+func ping() Check {
+	return Check{
+		Name: "ping",
+		Check: func(_ *http.Request) (string, error) {
+			return "pong", nil
+		},
+	}
+}
+
 type muxWrapper mux.Router
 
 func (m *muxWrapper) Handle(path string, handler http.Handler) {
@@ -2,6 +2,7 @@
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/rancher/pkg/capr"
@@ -15,6 +16,10 @@ func Register(server *steve.Server, clients *wrangler.Context) {
 		sshClient := &sshClient{
 			machines: clients.CAPI.Machine(),
 			secrets:  clients.Core.Secret(),
+			recordingStore: func() (recordingStore, error) {
+				return newRecordingStore(clients.Core.Secret())
+			},
+			now: time.Now,
 		}
 		server.SchemaFactory.AddTemplate(schema2.Template{
 			Group: "cluster.x-k8s.io",
@@ -25,12 +30,16 @@ func Register(server *steve.Server, clients *wrangler.Context) {
 				}
 				schema.LinkHandlers["shell"] = sshClient
 				schema.LinkHandlers["sshkeys"] = sshClient
+				schema.LinkHandlers["recordings"] = sshClient
 				schema.Formatter = func(request *types.APIRequest, resource *types.RawResource) {
 					if err := request.AccessControl.CanUpdate(request, types.APIObject{}, request.Schema); err != nil ||
 						resource.APIObject.Data().String("spec", "infrastructureRef", "apiVersion") != capr.RKEMachineAPIVersion {
 						delete(resource.Links, "shell")
 						delete(resource.Links, "sshkeys")
 					}
+					if canViewRecordings(request, resource.APIObject.Namespace(), resource.APIObject.Name()) != nil {
+						delete(resource.Links, "recordings")
+					}
 				}
 			},
 		})
@@ -0,0 +1,186 @@
+package machine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+const (
+	outputEvent = "o"
+	inputEvent  = "i"
+	resizeEvent = "r"
+)
+
+// recordingMetadata describes a recorded shell session.
+type recordingMetadata struct {
+	ID               string     `json:"id"`
+	MachineNamespace string     `json:"machineNamespace"`
+	MachineName      string     `json:"machineName"`
+	User             string     `json:"user"`
+	Groups           []string   `json:"groups,omitempty"`
+	StartTime        time.Time  `json:"startTime"`
+	EndTime          *time.Time `json:"endTime,omitempty"`
+	// ExitStatus is the exit status of the shell, nil while the session is running or if the session ended without
+	// one, e.g. because the client disconnected.
+	ExitStatus *int   `json:"exitStatus,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Truncated is set if the session exceeded the maximum recording size of the store, in which case later events
+	// are dropped.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// castHeader is the header line of an asciicast v2 file.
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// recorder records a shell session in the asciicast v2 format, see
+// https://docs.asciinema.org/manual/asciicast/v2/. Output and input are recorded as they are passed between the client
+// and the machine, along with the terminal resizes.
+type recorder struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	metadata recordingMetadata
+	cast     bytes.Buffer
+	maxSize  int
+	// partial holds the trailing bytes of the last output and input that are not a complete UTF-8 sequence, as cast
+	// events must be valid UTF-8.
+	partial map[string][]byte
+}
+
+func newRecorder(machineNamespace, machineName string, user user.Info, width, height, maxSize int, now func() time.Time) (*recorder, error) {
+	start := now()
+	r := &recorder{
+		now: now,
+		metadata: recordingMetadata{
+			ID:               strconv.FormatInt(start.Unix(), 10) + "-" + utilrand.String(8),
+			MachineNamespace: machineNamespace,
+			MachineName:      machineName,
+			User:             user.GetName(),
+			Groups:           user.GetGroups(),
+			StartTime:        start.UTC(),
+		},
+		maxSize: maxSize,
+		partial: map[string][]byte{},
+	}
+
+	header, err := json.Marshal(castHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: start.Unix(),
+		Title:     fmt.Sprintf("%s/%s (%s)", machineNamespace, machineName, user.GetName()),
+		Env:       map[string]string{"TERM": "xterm"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.cast.Write(header)
+	r.cast.WriteByte('\n')
+	return r, nil
+}
+
+func (r *recorder) output(data []byte) {
+	r.record(outputEvent, data)
+}
+
+func (r *recorder) input(data []byte) {
+	r.record(inputEvent, data)
+}
+
+func (r *recorder) resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event(resizeEvent, fmt.Sprintf("%dx%d", width, height))
+}
+
+func (r *recorder) record(code string, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data = append(r.partial[code], data...)
+	complete := len(data)
+	// look back for the start of a trailing incomplete sequence, which is at most utf8.UTFMax-1 bytes long
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax+1; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				complete = i
+			}
+			break
+		}
+	}
+	r.partial[code] = append([]byte(nil), data[complete:]...)
+	if complete > 0 {
+		r.event(code, string(data[:complete]))
+	}
+}
+
+func (r *recorder) event(code, data string) {
+	if r.metadata.Truncated {
+		return
+	}
+	elapsed := r.now().Sub(r.metadata.StartTime).Seconds()
+	line, err := json.Marshal([]any{json.Number(strconv.FormatFloat(elapsed, 'f', 6, 64)), code, data})
+	if err != nil {
+		return
+	}
+	if r.cast.Len()+len(line)+1 > r.maxSize {
+		r.metadata.Truncated = true
+		return
+	}
+	r.cast.Write(line)
+	r.cast.WriteByte('\n')
+}
+
+// finish records the end of the session along with its exit status, given the result of waiting for the shell.
+func (r *recorder) finish(waitErr error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	end := r.now().UTC()
+	r.metadata.EndTime = &end
+
+	var exitErr *ssh.ExitError
+	switch {
+	case waitErr == nil:
+		status := 0
+		r.metadata.ExitStatus = &status
+	case errors.As(waitErr, &exitErr):
+		status := exitErr.ExitStatus()
+		r.metadata.ExitStatus = &status
+	default:
+		r.metadata.Error = waitErr.Error()
+	}
+}
+
+// snapshot returns the metadata and cast recorded so far.
+func (r *recorder) snapshot() (recordingMetadata, []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.metadata, bytes.Clone(r.cast.Bytes())
+}
+
+// recordingWriter records the data written to it as output events.
+type recordingWriter struct {
+	recorder *recorder
+}
+
+func (w recordingWriter) Write(data []byte) (int, error) {
+	w.recorder.output(data)
+	return len(data), nil
+}
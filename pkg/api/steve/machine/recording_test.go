@@ -0,0 +1,94 @@
+package machine
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+var start = time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+
+// clock returns a clock advancing by half a second each time it is read.
+func clock() func() time.Time {
+	now := start.Add(-500 * time.Millisecond)
+	return func() time.Time {
+		now = now.Add(500 * time.Millisecond)
+		return now
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r, err := newRecorder("fleet-default", "machine", &user.DefaultInfo{Name: "u-abc", Groups: []string{"system:authenticated"}}, 80, 20, 1024, clock())
+	require.NoError(t, err)
+
+	r.input([]byte("ls\r"))
+	// "é" split across two writes
+	r.output([]byte("caf\xc3"))
+	r.output([]byte("\xa9\r\n"))
+	r.resize(120, 40)
+	r.finish(nil)
+
+	metadata, cast := r.snapshot()
+	assert.Equal(t, `{"version":2,"width":80,"height":20,"timestamp":1704744000,"title":"fleet-default/machine (u-abc)","env":{"TERM":"xterm"}}
+[0.500000,"i","ls\r"]
+[1.000000,"o","caf"]
+[1.500000,"o","é\r\n"]
+[2.000000,"r","120x40"]
+`, string(cast))
+	assert.Equal(t, "u-abc", metadata.User)
+	assert.Equal(t, []string{"system:authenticated"}, metadata.Groups)
+	assert.Equal(t, start, metadata.StartTime)
+	assert.Equal(t, start.Add(2500*time.Millisecond), *metadata.EndTime)
+	assert.Equal(t, 0, *metadata.ExitStatus)
+	assert.False(t, metadata.Truncated)
+}
+
+func TestRecorderTruncation(t *testing.T) {
+	r, err := newRecorder("fleet-default", "machine", &user.DefaultInfo{Name: "u-abc"}, 80, 20, 160, clock())
+	require.NoError(t, err)
+
+	r.output([]byte("0123456789"))
+	r.output([]byte("0123456789"))
+	r.output([]byte("0123456789"))
+	r.finish(&ssh.ExitMissingError{})
+
+	metadata, cast := r.snapshot()
+	assert.True(t, metadata.Truncated)
+	assert.LessOrEqual(t, len(cast), 160)
+	assert.Nil(t, metadata.ExitStatus)
+	assert.NotEmpty(t, metadata.Error)
+}
+
+func TestDirectoryStore(t *testing.T) {
+	store := &directoryStore{dir: t.TempDir()}
+
+	first := recordingMetadata{ID: "1", MachineNamespace: "fleet-default", MachineName: "machine", User: "u-abc", StartTime: start}
+	second := recordingMetadata{ID: "2", MachineNamespace: "fleet-default", MachineName: "machine", User: "u-abc", StartTime: start.Add(time.Hour)}
+	require.NoError(t, store.save(first, []byte("first")))
+	require.NoError(t, store.save(second, []byte("second")))
+	status := 1
+	second.ExitStatus = &status
+	require.NoError(t, store.save(second, []byte("second, updated")))
+
+	recordings, err := store.list("fleet-default", "machine")
+	require.NoError(t, err)
+	require.Len(t, recordings, 2)
+	assert.Equal(t, "2", recordings[0].ID)
+	assert.Equal(t, 1, *recordings[0].ExitStatus)
+	assert.Equal(t, "1", recordings[1].ID)
+
+	metadata, cast, err := store.get("fleet-default", "machine", "2")
+	require.NoError(t, err)
+	assert.Equal(t, "2", metadata.ID)
+	assert.Equal(t, "second, updated", string(cast))
+
+	_, _, err = store.get("fleet-default", "machine", "3")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, _, err = store.get("fleet-default", "machine", "../other/1")
+	assert.ErrorContains(t, err, "invalid recording")
+}
@@ -0,0 +1,75 @@
+package machine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	machinesResource = "cluster.x-k8s.io/machines"
+	// viewRecordingsVerb is the verb on machines granting access to the recordings of their shell sessions.
+	viewRecordingsVerb = "view-shell-recordings"
+)
+
+// canViewRecordings checks that the user is granted the view-shell-recordings verb on the machine. Recordings contain
+// everything typed in and printed by the shell, so that access to them is granted separately from shell access.
+func canViewRecordings(apiRequest *types.APIRequest, namespace, name string) error {
+	return apiRequest.AccessControl.CanDo(apiRequest, machinesResource, viewRecordingsVerb, namespace, name)
+}
+
+// recordings handles the "recordings" link of a machine object. It lists the recorded shell sessions to the machine,
+// or serves the asciicast file of the session given by the "recording" query parameter.
+func (s *sshClient) recordings(apiRequest *types.APIRequest) error {
+	if _, err := s.machines.Get(apiRequest.Namespace, apiRequest.Name, metav1.GetOptions{}); err != nil {
+		return err
+	}
+
+	store, err := s.recordingStore()
+	if err != nil {
+		return err
+	}
+
+	id := apiRequest.Request.URL.Query().Get("recording")
+	if id == "" {
+		recordings, err := store.list(apiRequest.Namespace, apiRequest.Name)
+		if err != nil {
+			return err
+		}
+		if recordings == nil {
+			recordings = []recordingMetadata{}
+		}
+		data, err := json.Marshal(map[string]any{"data": recordings})
+		if err != nil {
+			return err
+		}
+		apiRequest.Response.Header().Set("Content-Type", "application/json")
+		apiRequest.Response.WriteHeader(http.StatusOK)
+		_, err = apiRequest.Response.Write(data)
+		return err
+	}
+
+	_, cast, err := store.get(apiRequest.Namespace, apiRequest.Name, id)
+	if apierrors.IsNotFound(err) || errors.Is(err, fs.ErrNotExist) {
+		return apierror.NewAPIError(validation.NotFound, fmt.Sprintf("recording %s of machine %s not found", id, apiRequest.Name))
+	} else if err != nil {
+		return err
+	}
+
+	apiRequest.Response.Header().Set("Content-Length", strconv.Itoa(len(cast)))
+	apiRequest.Response.Header().Set("Content-Type", "application/x-asciicast")
+	apiRequest.Response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.cast", apiRequest.Name, id))
+	apiRequest.Response.Header().Set("Cache-Control", "private")
+	apiRequest.Response.WriteHeader(http.StatusOK)
+	_, err = apiRequest.Response.Write(cast)
+	return err
+}
@@ -0,0 +1,235 @@
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/settings"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	secretRecordingStore    = "secret"
+	directoryRecordingStore = "directory"
+
+	recordingSecretType  = "rke.cattle.io/machine-shell-recording"
+	recordingLabel       = "rke.cattle.io/machine-shell-recording"
+	recordingMetadataKey = "metadata.json"
+	recordingCastKey     = "session.cast"
+
+	// recordingNamespace is the namespace recordings stored in secrets are kept in. Recordings are not stored in the
+	// namespace of the machine, so that they can only be read through the recordings link of the machine, which
+	// requires the view-shell-recordings verb, rather than by anyone allowed to read secrets in that namespace.
+	recordingNamespace = namespace.System
+
+	// maxSecretRecordingSize keeps recordings stored in secrets below the size limit of secrets.
+	maxSecretRecordingSize = 900 * 1024
+	// maxDirectoryRecordingSize bounds the memory held by recordings stored in a directory.
+	maxDirectoryRecordingSize = 64 * 1024 * 1024
+)
+
+// recordingStore stores the recordings of shell sessions.
+type recordingStore interface {
+	// maxSize is the maximum size of the recorded cast.
+	maxSize() int
+	// save creates or updates a recording.
+	save(metadata recordingMetadata, cast []byte) error
+	// list returns the recordings of sessions to the given machine.
+	list(machineNamespace, machineName string) ([]recordingMetadata, error)
+	// get returns a recording of a session to the given machine.
+	get(machineNamespace, machineName, id string) (recordingMetadata, []byte, error)
+}
+
+// newRecordingStore returns the store configured by the machine-shell-recording-store setting.
+func newRecordingStore(secrets corecontrollers.SecretClient) (recordingStore, error) {
+	switch store := settings.MachineShellRecordingStore.Get(); store {
+	case secretRecordingStore:
+		return &secretStore{secrets: secrets}, nil
+	case directoryRecordingStore:
+		return &directoryStore{dir: settings.MachineShellRecordingDirectory.Get()}, nil
+	default:
+		return nil, fmt.Errorf("invalid machine shell recording store %q", store)
+	}
+}
+
+// secretStore stores each recording in a secret in the recordingNamespace.
+type secretStore struct {
+	secrets corecontrollers.SecretClient
+}
+
+func (s *secretStore) maxSize() int {
+	return maxSecretRecordingSize
+}
+
+func (s *secretStore) save(metadata recordingMetadata, cast []byte) error {
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	secretName := recordingSecretName(metadata.MachineNamespace, metadata.MachineName, metadata.ID)
+	secret, err := s.secrets.Get(recordingNamespace, secretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = s.secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: recordingNamespace,
+				Labels: map[string]string{
+					recordingLabel:             "true",
+					capr.MachineNamespaceLabel: metadata.MachineNamespace,
+					capr.MachineNameLabel:      metadata.MachineName,
+				},
+			},
+			Type: recordingSecretType,
+			Data: map[string][]byte{
+				recordingMetadataKey: metadataBytes,
+				recordingCastKey:     cast,
+			},
+		})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	secret = secret.DeepCopy()
+	secret.Data = map[string][]byte{
+		recordingMetadataKey: metadataBytes,
+		recordingCastKey:     cast,
+	}
+	_, err = s.secrets.Update(secret)
+	return err
+}
+
+func (s *secretStore) list(machineNamespace, machineName string) ([]recordingMetadata, error) {
+	secrets, err := s.secrets.List(recordingNamespace, metav1.ListOptions{
+		LabelSelector: labels.Set{recordingLabel: "true", capr.MachineNamespaceLabel: machineNamespace, capr.MachineNameLabel: machineName}.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result []recordingMetadata
+	for _, secret := range secrets.Items {
+		var metadata recordingMetadata
+		if err := json.Unmarshal(secret.Data[recordingMetadataKey], &metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata of recording %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+		if metadata.MachineNamespace == machineNamespace && metadata.MachineName == machineName {
+			result = append(result, metadata)
+		}
+	}
+	sortRecordings(result)
+	return result, nil
+}
+
+func (s *secretStore) get(machineNamespace, machineName, id string) (recordingMetadata, []byte, error) {
+	var metadata recordingMetadata
+	secret, err := s.secrets.Get(recordingNamespace, recordingSecretName(machineNamespace, machineName, id), metav1.GetOptions{})
+	if err != nil {
+		return metadata, nil, err
+	}
+	if err := json.Unmarshal(secret.Data[recordingMetadataKey], &metadata); err != nil {
+		return metadata, nil, fmt.Errorf("invalid metadata of recording %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	if metadata.MachineNamespace != machineNamespace || metadata.MachineName != machineName || metadata.ID != id {
+		return metadata, nil, apierrors.NewNotFound(corev1.Resource("secrets"), secret.Name)
+	}
+	return metadata, secret.Data[recordingCastKey], nil
+}
+
+func recordingSecretName(machineNamespace, machineName, id string) string {
+	return name.SafeConcatName(machineNamespace, machineName, "shell", id)
+}
+
+// directoryStore stores recordings as files in a directory, each in a <namespace>/<machine>/<id>.cast file along with
+// its metadata in <namespace>/<machine>/<id>.json.
+type directoryStore struct {
+	dir string
+}
+
+func (d *directoryStore) maxSize() int {
+	return maxDirectoryRecordingSize
+}
+
+func (d *directoryStore) save(metadata recordingMetadata, cast []byte) error {
+	dir := filepath.Join(d.dir, metadata.MachineNamespace, metadata.MachineName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, metadata.ID+".cast"), cast); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, metadata.ID+".json"), metadataBytes)
+}
+
+func (d *directoryStore) list(machineNamespace, machineName string) ([]recordingMetadata, error) {
+	files, err := filepath.Glob(filepath.Join(d.dir, machineNamespace, machineName, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []recordingMetadata
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var metadata recordingMetadata
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, fmt.Errorf("invalid metadata of recording %s: %w", file, err)
+		}
+		result = append(result, metadata)
+	}
+	sortRecordings(result)
+	return result, nil
+}
+
+func (d *directoryStore) get(machineNamespace, machineName, id string) (recordingMetadata, []byte, error) {
+	var metadata recordingMetadata
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return metadata, nil, fmt.Errorf("invalid recording %q", id)
+	}
+
+	dir := filepath.Join(d.dir, machineNamespace, machineName)
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		return metadata, nil, err
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, nil, fmt.Errorf("invalid metadata of recording %s: %w", id, err)
+	}
+	cast, err := os.ReadFile(filepath.Join(dir, id+".cast"))
+	return metadata, cast, err
+}
+
+// writeFile replaces the given file, so that readers never see a partially written file.
+func writeFile(file string, data []byte) error {
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// sortRecordings sorts recordings by start time, latest first.
+func sortRecordings(recordings []recordingMetadata) {
+	slices.SortFunc(recordings, func(a, b recordingMetadata) int {
+		return b.StartTime.Compare(a.StartTime)
+	})
+}
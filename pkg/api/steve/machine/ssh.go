@@ -1,35 +1,32 @@
 package machine
 
 import (
-	"archive/tar"
-	"bytes"
-	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strconv"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/capr/machinessh"
 	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1beta2"
-	"github.com/rancher/rancher/pkg/utils"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/ssh"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/endpoints/request"
 )
 
 type sshClient struct {
-	secrets  corecontrollers.SecretClient
-	machines capicontrollers.MachineClient
+	secrets        corecontrollers.SecretClient
+	machines       capicontrollers.MachineClient
+	recordingStore func() (recordingStore, error)
+	now            func() time.Time
 }
 
 var upgrader = websocket.Upgrader{
@@ -39,8 +36,11 @@ type sshClient struct {
 	Error:            onError,
 }
 
-// Define a short timeout for the connectivity check.
-const dialTimeout = 5 * time.Second
+// The initial size of the terminal, until the client resizes it.
+const (
+	initialWidth  = 80
+	initialHeight = 20
+)
 
 func onError(rw http.ResponseWriter, _ *http.Request, code int, err error) {
 	rw.WriteHeader(code)
@@ -49,6 +49,17 @@ func onError(rw http.ResponseWriter, _ *http.Request, code int, err error) {
 
 func (s *sshClient) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	apiRequest := types.GetAPIContext(req.Context())
+	if apiRequest.Link == "recordings" {
+		if err := canViewRecordings(apiRequest, apiRequest.Namespace, apiRequest.Name); err != nil {
+			apiRequest.WriteError(err)
+			return
+		}
+		if err := s.recordings(apiRequest); err != nil {
+			apiRequest.WriteError(err)
+		}
+		return
+	}
+
 	if err := apiRequest.AccessControl.CanUpdate(apiRequest, types.APIObject{}, apiRequest.Schema); err != nil {
 		apiRequest.WriteError(err)
 		return
@@ -68,45 +79,20 @@ func (s *sshClient) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
-// getAccessibleAddress probes the machine's IPv4 and IPv6 addresses to find one that is reachable on the SSH port.
-// It uses a quick TCP dial to check for connectivity before attempting a full SSH handshake.
-func getAccessibleAddress(machineInfo *machineInfo) (string, error) {
-	ipv4 := machineInfo.Driver.IPAddress
-	ipv6 := machineInfo.Driver.IPv6Address
-	name := machineInfo.Driver.MachineName
-
-	if ipv4 == "" && ipv6 == "" {
-		return "", fmt.Errorf("no IP addresses available for machine %s", name)
-	}
-
-	addresses := []string{ipv4, ipv6}
-
-	kubeHost := os.Getenv("KUBERNETES_SERVICE_HOST")
-	if kubeHost != "" && utils.IsPlainIPV6(kubeHost) {
-		addresses = []string{ipv6, ipv4}
+// shell handles the "shell" action on a machine object. It establishes an interactive SSH
+// session to the target machine and proxies it over a WebSocket connection. The machine must present its pinned host
+// key, and the session is recorded to the configured recording store.
+func (s *sshClient) shell(apiRequest *types.APIRequest) error {
+	user, ok := request.UserFrom(apiRequest.Context())
+	if !ok {
+		return validation.Unauthorized
 	}
 
-	for _, addr := range addresses {
-		if addr == "" {
-			continue
-		}
-
-		addrWithPort := net.JoinHostPort(addr, strconv.Itoa(machineInfo.Driver.SSHPort))
-
-		conn, err := net.DialTimeout("tcp", addrWithPort, dialTimeout)
-		if err == nil {
-			_ = conn.Close()
-			return addrWithPort, nil
-		}
-		logrus.Debugf("[ssh] Failed to probe machine %s at address %s: %v", name, addrWithPort, err)
+	store, err := s.recordingStore()
+	if err != nil {
+		return err
 	}
 
-	return "", fmt.Errorf("failed to find an accessible IP address for machine %s", name)
-}
-
-// shell handles the "shell" action on a machine object. It establishes an interactive SSH
-// session to the target machine and proxies it over a WebSocket connection.
-func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 	ctx, cancel := context.WithCancel(apiRequest.Context())
 	defer cancel()
 
@@ -122,35 +108,18 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 		return err
 	}
 
-	signer, err := ssh.ParsePrivateKey(machineInfo.IDRSA)
+	client, err := machinessh.Dial(machineInfo)
 	if err != nil {
 		return err
 	}
-
-	addrWithPort, err := getAccessibleAddress(machineInfo)
-	if err != nil {
-		return err
-	}
-
-	logrus.Debugf("[ssh] Attempting to connect to machine %s via SSH at %s", apiRequest.Name, addrWithPort)
-	client, err := ssh.Dial("tcp", addrWithPort, &ssh.ClientConfig{
-		User: machineInfo.Driver.SSHUser,
-		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
-		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         30 * time.Second,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to connect to machine %s via SSH: %w", apiRequest.Name, err)
-	}
 	defer client.Close()
 
 	session, err := client.NewSession()
 	if err != nil {
 		return err
 	}
-	if err := session.RequestPty("xterm", 20, 80, ssh.TerminalModes{}); err != nil {
+	defer session.Close()
+	if err := session.RequestPty("xterm", initialHeight, initialWidth, ssh.TerminalModes{}); err != nil {
 		return err
 	}
 
@@ -164,16 +133,45 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 		return err
 	}
 
+	recorder, err := newRecorder(apiRequest.Namespace, apiRequest.Name, user, initialWidth, initialHeight, store.maxSize(), s.now)
+	if err != nil {
+		return err
+	}
+	// the session is only opened once its recording is stored, so that no session goes unrecorded
+	if err := store.save(recorder.snapshot()); err != nil {
+		return fmt.Errorf("failed to record shell session to machine %s: %w", apiRequest.Name, err)
+	}
+	logrus.Infof("[ssh] User %s opened shell session %s to machine %s/%s", user.GetName(), recorder.metadata.ID, apiRequest.Namespace, apiRequest.Name)
+
 	if err := session.Shell(); err != nil {
+		recorder.finish(err)
+		s.saveRecording(store, recorder)
 		return err
 	}
 
+	waited := make(chan error, 1)
+	go func() {
+		waited <- session.Wait()
+	}()
+
 	go func() {
 		defer cancel()
 		defer conn.Close()
-		io.Copy(&writer{conn: conn}, stdOut)
+		io.Copy(io.MultiWriter(&writer{conn: conn}, recordingWriter{recorder: recorder}), stdOut)
 	}()
 
+	err = proxyInput(conn, session, stdIn, recorder)
+
+	// closing the session ends the shell if the client disconnected, and is a no-op if the shell already exited
+	session.Close()
+	recorder.finish(<-waited)
+	s.saveRecording(store, recorder)
+	return err
+}
+
+// proxyInput passes the input and terminal resizes received over the websocket connection to the session until the
+// connection is closed.
+func proxyInput(conn *websocket.Conn, session *ssh.Session, stdIn io.Writer, recorder *recorder) error {
 	for {
 		_, data, err := conn.ReadMessage()
 		if err != nil {
@@ -188,6 +186,7 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 			if err != nil {
 				return err
 			}
+			recorder.input(data)
 			if _, err := stdIn.Write(data); err != nil {
 				return err
 			}
@@ -200,6 +199,7 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 			if err := json.Unmarshal(data, resize); err != nil {
 				return err
 			}
+			recorder.resize(resize.Width, resize.Height)
 			if err := session.WindowChange(resize.Height, resize.Width); err != nil {
 				return err
 			}
@@ -207,27 +207,21 @@ func (s *sshClient) shell(apiRequest *types.APIRequest) error {
 	}
 }
 
+func (s *sshClient) saveRecording(store recordingStore, recorder *recorder) {
+	metadata, cast := recorder.snapshot()
+	if err := store.save(metadata, cast); err != nil {
+		logrus.Errorf("[ssh] Failed to store recording of shell session %s to machine %s/%s: %v", metadata.ID, metadata.MachineNamespace, metadata.MachineName, err)
+		return
+	}
+	logrus.Infof("[ssh] User %s closed shell session %s to machine %s/%s", metadata.User, metadata.ID, metadata.MachineNamespace, metadata.MachineName)
+}
+
 type resizeRequest struct {
 	Height int
 	Width  int
 }
 
-type machineInfo struct {
-	IDRSA    []byte
-	IDRSAPub []byte
-	Driver   machineConfig
-}
-
-type machineConfig struct {
-	IPAddress   string
-	IPv6Address string
-	SSHUser     string
-	SSHPort     int
-	MachineName string
-}
-
-func (s *sshClient) getSSHKey(machineNamespace, machineName string) (*machineInfo, error) {
-	result := &machineInfo{}
+func (s *sshClient) getSSHKey(machineNamespace, machineName string) (*machinessh.Info, error) {
 	machine, err := s.machines.Get(machineNamespace, machineName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
@@ -239,39 +233,7 @@ func (s *sshClient) getSSHKey(machineNamespace, machineName string) (*machineInf
 		return nil, err
 	}
 
-	gz, err := gzip.NewReader(bytes.NewReader(secret.Data["extractedConfig"]))
-	if err != nil {
-		return nil, err
-	}
-
-	tar := tar.NewReader(gz)
-
-	for {
-		header, err := tar.Next()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return nil, err
-		}
-
-		data, err := io.ReadAll(tar)
-		if err != nil {
-			return nil, err
-		}
-		switch filepath.Base(header.Name) {
-		case "id_rsa":
-			result.IDRSA = data
-		case "id_rsa.pub":
-			result.IDRSAPub = data
-		case "config.json":
-			err := json.Unmarshal(data, result)
-			if err != nil {
-				return nil, err
-			}
-		}
-	}
-
-	return result, nil
+	return machinessh.ParseState(secret)
 }
 
 type writer struct {
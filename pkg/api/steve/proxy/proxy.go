@@ -10,6 +10,8 @@
 
 	gmux "github.com/gorilla/mux"
 	"github.com/rancher/rancher/pkg/api/steve/disallow"
+	"github.com/rancher/rancher/pkg/changefreeze"
+	"github.com/rancher/rancher/pkg/clusterrouter/flowcontrol"
 	v3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	managementv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/settings"
@@ -31,6 +33,7 @@ type Handler struct {
 	authorizer         authorizer.Authorizer
 	dialerFactory      ClusterDialerFactory
 	requestInfoFactory request.RequestInfoFactory
+	changeFreeze       *changefreeze.Checker
 }
 
 type ClusterDialerFactory func(clusterID string) remotedialer.Dialer
@@ -120,6 +123,7 @@ func NewProxyHandler(authorizer authorizer.Authorizer,
 		authorizer:         authorizer,
 		dialerFactory:      dialerFactory,
 		requestInfoFactory: request.RequestInfoFactory{APIPrefixes: sets.NewString("apis", "api"), GrouplessAPIPrefixes: sets.NewString("api")},
+		changeFreeze:       changefreeze.NewChecker(clusters),
 	}
 }
 
@@ -167,6 +171,14 @@ func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		rw.WriteHeader(http.StatusUnauthorized)
 		return
 	}
+	if !h.changeFreeze.Admit(rw, req, clusterID) {
+		return
+	}
+	release, ok := flowcontrol.Admit(rw, req, clusterID)
+	if !ok {
+		return
+	}
+	defer release()
 	prefix := "/" + gmux.Vars(req)["prefix"]
 	handler, err := h.next(clusterID, prefix)
 	if err != nil {
@@ -4,7 +4,9 @@
 	"context"
 
 	"github.com/rancher/rancher/pkg/api/steve/catalog"
+	"github.com/rancher/rancher/pkg/api/steve/changefreeze"
 	"github.com/rancher/rancher/pkg/api/steve/clusters"
+	"github.com/rancher/rancher/pkg/api/steve/clustertemplates"
 	"github.com/rancher/rancher/pkg/api/steve/disallow"
 	"github.com/rancher/rancher/pkg/api/steve/machine"
 	"github.com/rancher/rancher/pkg/api/steve/navlinks"
@@ -20,10 +22,12 @@ func Setup(ctx context.Context, server *steve.Server, config *wrangler.Context,
 	if err := clusters.Register(ctx, server, config, userManager); err != nil {
 		return err
 	}
+	clustertemplates.Register(server, config.Provisioning.ClusterTemplate().Cache(), config.Provisioning.ClusterTemplateRevision().Cache())
 	machine.Register(server, config)
 	navlinks.Register(ctx, server)
 	settings.Register(server)
 	disallow.Register(server)
+	changefreeze.Register(server, config.Mgmt.Cluster().Cache(), config.HelmOperations)
 	return catalog.Register(ctx,
 		server,
 		config.HelmOperations,
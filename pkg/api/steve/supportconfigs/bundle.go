@@ -0,0 +1,75 @@
+package supportconfigs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rancher/rancher/pkg/auth/util"
+	"github.com/rancher/rancher/pkg/supportbundle"
+	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/sirupsen/logrus"
+	authzv1 "k8s.io/api/authorization/v1"
+	authv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+const (
+	// BundleEndpoint The endpoint serving support bundles of any install to administrators - used for routing
+	BundleEndpoint    = "/v1/generateRancherSupportBundle"
+	bundleContentType = "application/gzip"
+)
+
+type bundleCollector interface {
+	Write(ctx context.Context, w io.Writer) error
+}
+
+// BundleHandler implements http.Handler - and serves a redacted diagnostic bundle (gzipped tar file) of the install,
+// whether or not a CSP adapter is installed
+type BundleHandler struct {
+	SubjectAccessReviews authv1.SubjectAccessReviewInterface
+	collector            bundleCollector
+	now                  func() time.Time
+}
+
+// NewBundleHandler creates a bundle handler using the clients defined in scaledContext
+func NewBundleHandler(scaledContext *config.ScaledContext) (*BundleHandler, error) {
+	collector, err := supportbundle.New(&scaledContext.RESTConfig, supportbundle.DefaultLogLines)
+	if err != nil {
+		return nil, err
+	}
+	return &BundleHandler{
+		SubjectAccessReviews: scaledContext.K8sClient.AuthorizationV1().SubjectAccessReviews(),
+		collector:            collector,
+		now:                  time.Now,
+	}, nil
+}
+
+// ServeHTTP implements http.Handler - streams the support bundle if the user is an administrator, allowed every verb on
+// every resource
+func (h *BundleHandler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	authorized, err := subjectAccessReview(h.SubjectAccessReviews, request, &authzv1.ResourceAttributes{
+		Verb:     "*",
+		Group:    "*",
+		Resource: "*",
+	})
+	if err != nil {
+		logrus.Errorf("[%s] Failed to authorize user with error: %s", logPrefix, err.Error())
+	}
+	if err != nil || !authorized {
+		util.ReturnHTTPError(writer, request, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		return
+	}
+
+	logrus.Infof("[%s] Generating support bundle", logPrefix)
+	filename := fmt.Sprintf("rancher-support-bundle-%s.tar.gz", h.now().UTC().Format("20060102T150405Z"))
+	writer.Header().Set("Content-Type", bundleContentType)
+	writer.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"\"")
+	// the bundle is streamed, so errors can only be logged once the response has started
+	if err := h.collector.Write(request.Context(), writer); err != nil {
+		logrus.Errorf("[%s] Error when writing support bundle: %v", logPrefix, err)
+		return
+	}
+	logrus.Infof("[%s] Done Generating support bundle", logPrefix)
+}
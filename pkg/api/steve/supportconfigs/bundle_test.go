@@ -0,0 +1,76 @@
+package supportconfigs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type fakeCollector struct{}
+
+func (fakeCollector) Write(_ context.Context, w io.Writer) error {
+	_, err := w.Write([]byte("bundle"))
+	return err
+}
+
+func TestBundleHandler(t *testing.T) {
+	tests := []struct {
+		name             string
+		authorized       bool
+		expectedHTTPCode int
+		expectedBody     string
+	}{
+		{
+			name:             "administrator",
+			authorized:       true,
+			expectedHTTPCode: http.StatusOK,
+			expectedBody:     "bundle",
+		},
+		{
+			name:             "not an administrator",
+			expectedHTTPCode: http.StatusForbidden,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attributes *authv1.ResourceAttributes
+			k8sClient := fake.NewSimpleClientset()
+			k8sClient.PrependReactor("create", "subjectaccessreviews",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					ret := action.(k8stesting.CreateAction).GetObject().(*authv1.SubjectAccessReview)
+					attributes = ret.Spec.ResourceAttributes
+					ret.Status.Allowed = test.authorized
+					return true, ret, nil
+				},
+			)
+			h := &BundleHandler{
+				SubjectAccessReviews: k8sClient.AuthorizationV1().SubjectAccessReviews(),
+				collector:            fakeCollector{},
+				now:                  func() time.Time { return time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC) },
+			}
+
+			req := httptest.NewRequest(http.MethodGet, BundleEndpoint, nil)
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "foo"}))
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, &authv1.ResourceAttributes{Verb: "*", Group: "*", Resource: "*"}, attributes)
+			assert.Equal(t, test.expectedHTTPCode, rr.Code)
+			if test.expectedBody != "" {
+				assert.Equal(t, test.expectedBody, rr.Body.String())
+				assert.Equal(t, `attachment; filename="rancher-support-bundle-20240108T200000Z.tar.gz"`, rr.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
@@ -136,22 +136,28 @@ func (h *Handler) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 // authorize checks to see if the user can get the given csp adapter configmap. Returns a bool (if the user is authorized)
 // and optionally an error
 func (h *Handler) authorize(cspNamespace string, cspConfigmap string, r *http.Request) (bool, error) {
+	return subjectAccessReview(h.SubjectAccessReviews, r, &authzv1.ResourceAttributes{
+		Resource:  "configmap",
+		Verb:      "get",
+		Name:      cspConfigmap,
+		Namespace: cspNamespace,
+	})
+}
+
+// subjectAccessReview checks to see if the user of the request is allowed the given resource attributes. Returns a bool
+// (if the user is authorized) and optionally an error
+func subjectAccessReview(sars authv1.SubjectAccessReviewInterface, r *http.Request, attributes *authzv1.ResourceAttributes) (bool, error) {
 	userInfo, ok := request.UserFrom(r.Context())
 	if !ok {
 		return false, fmt.Errorf("unable to extract user info from context")
 	}
-	response, err := h.SubjectAccessReviews.Create(r.Context(), &authzv1.SubjectAccessReview{
+	response, err := sars.Create(r.Context(), &authzv1.SubjectAccessReview{
 		Spec: authzv1.SubjectAccessReviewSpec{
-			ResourceAttributes: &authzv1.ResourceAttributes{
-				Resource:  "configmap",
-				Verb:      "get",
-				Name:      cspConfigmap,
-				Namespace: cspNamespace,
-			},
-			User:   userInfo.GetName(),
-			Groups: userInfo.GetGroups(),
-			Extra:  convertExtra(userInfo.GetExtra()),
-			UID:    userInfo.GetUID(),
+			ResourceAttributes: attributes,
+			User:               userInfo.GetName(),
+			Groups:             userInfo.GetGroups(),
+			Extra:              convertExtra(userInfo.GetExtra()),
+			UID:                userInfo.GetUID(),
 		},
 	}, metav1.CreateOptions{})
 	if err != nil {
@@ -21,6 +21,8 @@ type App struct {
 type ReleaseStatus struct {
 	Summary            Summary `json:"summary,omitempty"`
 	ObservedGeneration int64   `json:"observedGeneration"`
+	// VerifiedSigner is the signer of the chart, verified against the policy of its ClusterRepo when it was installed.
+	VerifiedSigner string `json:"verifiedSigner,omitempty"`
 }
 
 type Summary struct {
@@ -110,6 +110,35 @@ type RepoSpec struct {
 	// Defaults to false, which keeps the SameOrigin check enabled. Setting this to true is not recommended
 	// in production environments due to the security implications.
 	DisableSameOriginCheck bool `json:"disableSameOriginCheck,omitempty"`
+
+	// Verification is the policy used to verify the signature of the charts of the Helm repository before they are
+	// installed or upgraded. Charts are not verified if unspecified.
+	// +optional
+	Verification *RepoVerification `json:"verification,omitempty"`
+}
+
+// VerificationMode defines how the signature of a chart is enforced.
+type VerificationMode string
+
+const (
+	// VerificationModeAudit verifies the charts and reports their signer, but allows unverified charts to be installed.
+	VerificationModeAudit VerificationMode = "audit"
+	// VerificationModeEnforce refuses to install, upgrade or roll back to charts which could not be verified.
+	VerificationModeEnforce VerificationMode = "enforce"
+)
+
+// RepoVerification is the policy used to verify the charts of a Helm repository. Charts from HTTP and Git repositories
+// are verified with their Helm provenance file, charts from OCI repositories with their cosign signature.
+type RepoVerification struct {
+	// Mode is either "audit" or "enforce". Defaults to "enforce".
+	// +kubebuilder:validation:Enum=audit;enforce
+	// +optional
+	Mode VerificationMode `json:"mode,omitempty"`
+
+	// KeyringSecret references the secret containing the trusted keys. Each value of the secret is either a PGP
+	// keyring, armored or binary, used to verify provenance files, or a PEM encoded public key used to verify cosign
+	// signatures.
+	KeyringSecret *SecretReference `json:"keyringSecret,omitempty"`
 }
 
 type RepoCondition string
@@ -454,6 +454,11 @@ func (in *RepoSpec) DeepCopyInto(out *RepoSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(RepoVerification)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -490,6 +495,27 @@ func (in *RepoStatus) DeepCopy() *RepoStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepoVerification) DeepCopyInto(out *RepoVerification) {
+	*out = *in
+	if in.KeyringSecret != nil {
+		in, out := &in.KeyringSecret, &out.KeyringSecret
+		*out = new(SecretReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepoVerification.
+func (in *RepoVerification) DeepCopy() *RepoVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(RepoVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretReference) DeepCopyInto(out *SecretReference) {
 	*out = *in
@@ -143,6 +143,15 @@ type TokenStatus struct {
 	// LastActivitySeen is the timestamp of the last time user activity
 	// (mouse movement, interaction, ...) was reported for the token.
 	LastActivitySeen *metav1.Time `json:"lastActivitySeen,omitempty"`
+	// SessionPolicies are the names of the session timeout policies applying to the token, if any.
+	SessionPolicies []string `json:"sessionPolicies,omitempty"`
+	// IdleTimeoutMinutes is the shortest idle timeout of the session timeout policies applying to the token.
+	IdleTimeoutMinutes int64 `json:"idleTimeoutMinutes,omitempty"`
+	// AbsoluteTimeoutMinutes is the shortest absolute timeout of the session timeout policies applying to the token.
+	AbsoluteTimeoutMinutes int64 `json:"absoluteTimeoutMinutes,omitempty"`
+	// SessionExpiresAt is the timestamp the token expires at under the session timeout policies applying to it,
+	// given its recorded activity.
+	SessionExpiresAt string `json:"sessionExpiresAt,omitempty"`
 	// Fully formed bearer token that is ready to use in the Authorization header to authenticate to Rancher.
 	BearerToken string `json:"bearerToken,omitempty"`
 }
@@ -254,6 +263,11 @@ type KubeconfigSpec struct {
 	// TTL is the time-to-live of the kubeconfig tokens, in seconds.
 	// +optional
 	TTL int64 `json:"ttl,omitempty"`
+	// Exec indicates that the kubeconfig doesn't embed tokens, but uses the exec credential plugin of the Rancher CLI
+	// to obtain short-lived, cluster-scoped tokens through the token subresource of the Kubeconfig.
+	// The TTL is then the time-to-live of each of these tokens, capped by kubeconfig-exec-token-ttl-minutes.
+	// +optional
+	Exec bool `json:"exec,omitempty"`
 }
 
 // KubeconfigStatus defines the most recently observed status of the Kubeconfig.
@@ -271,6 +285,9 @@ type KubeconfigStatus struct {
 	// Tokens is a list of Kubeconfig tokens.
 	// +optional
 	Tokens []string `json:"tokens,omitempty"`
+	// LastUsedAt is the timestamp of the last time any of the Kubeconfig tokens was used to authenticate.
+	// +optional
+	LastUsedAt *metav1.Time `json:"lastUsedAt,omitempty"`
 	// Value contains the generated content of the kubeconfig.
 	Value string `json:"value,omitempty"`
 }
@@ -223,6 +223,10 @@ func (in *KubeconfigStatus) DeepCopyInto(out *KubeconfigStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.LastUsedAt != nil {
+		in, out := &in.LastUsedAt, &out.LastUsedAt
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -529,6 +533,11 @@ func (in *TokenStatus) DeepCopyInto(out *TokenStatus) {
 		in, out := &in.LastActivitySeen, &out.LastActivitySeen
 		*out = (*in).DeepCopy()
 	}
+	if in.SessionPolicies != nil {
+		in, out := &in.SessionPolicies, &out.SessionPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
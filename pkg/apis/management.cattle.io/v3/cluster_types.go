@@ -72,6 +72,20 @@ func init() {
 	ClusterConditionHarvesterCloudProviderConfigMigrated condition.Cond = "HarvesterCloudProviderConfigMigrated"
 	ClusterConditionACISecretsMigrated                   condition.Cond = "ACISecretsMigrated"
 	ClusterConditionRKESecretsMigrated                   condition.Cond = "RKESecretsMigrated"
+	// ClusterConditionChangeFrozen true when the cluster is in a change freeze and Rancher rejects changes to it
+	ClusterConditionChangeFrozen condition.Cond = "ChangeFrozen"
+	// ClusterConditionAPIServerHealthy true when the livez and readyz checks of the Kubernetes API server pass
+	ClusterConditionAPIServerHealthy condition.Cond = "APIServerHealthy"
+	// ClusterConditionEtcdHealthy true when the etcd members are ready and the etcd cluster has a stable leader
+	ClusterConditionEtcdHealthy condition.Cond = "EtcdHealthy"
+	// ClusterConditionNodesHealthy true when no node of the cluster reports memory, disk or PID pressure
+	ClusterConditionNodesHealthy condition.Cond = "NodesHealthy"
+	// ClusterConditionDNSReady true when the cluster DNS pods are ready
+	ClusterConditionDNSReady condition.Cond = "DNSReady"
+	// ClusterConditionCNIReady true when the pods of the container network plugin are ready on every node
+	ClusterConditionCNIReady condition.Cond = "CNIReady"
+	// ClusterConditionAgentTunnelHealthy true when requests through the cluster agent tunnel complete within the latency threshold
+	ClusterConditionAgentTunnelHealthy condition.Cond = "AgentTunnelHealthy"
 
 	ClusterDriverImported = "imported"
 	ClusterDriverLocal    = "local"
@@ -167,6 +181,34 @@ type ClusterSpec struct {
 	ClusterTemplateAnswers              Answer                      `json:"answers,omitempty"`
 	ClusterTemplateQuestions            []Question                  `json:"questions,omitempty" norman:"nocreate,noupdate"`
 	FleetWorkspaceName                  string                      `json:"fleetWorkspaceName,omitempty"`
+	ChangeFreeze                        *ClusterChangeFreeze        `json:"changeFreeze,omitempty"`
+}
+
+// ClusterChangeFreeze makes a cluster read-only through Rancher, during an incident, an audit or a release freeze.
+// While the freeze is active, requests changing the cluster are rejected unless they come from an exempt principal.
+type ClusterChangeFreeze struct {
+	// Enabled freezes the cluster until it is disabled, regardless of the windows.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Windows freeze the cluster from their start until their end.
+	// +optional
+	Windows []ChangeFreezeWindow `json:"windows,omitempty"`
+
+	// Reason is shown to the users whose changes are rejected.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// ExemptPrincipals are the users and groups allowed to change the cluster while it is frozen. They are either user
+	// names, such as u-abcde, or principal IDs, such as local://u-abcde or okta_group://ops.
+	// +optional
+	ExemptPrincipals []string `json:"exemptPrincipals,omitempty"`
+}
+
+// ChangeFreezeWindow is a period during which a cluster is frozen.
+type ChangeFreezeWindow struct {
+	Start metav1.Time `json:"start"`
+	End   metav1.Time `json:"end"`
 }
 
 type Answer struct {
@@ -232,6 +274,23 @@ type ClusterStatus struct {
 	AADClientCertSecret        string                    `json:"aadClientCertSecret,omitempty" norman:"nocreate,noupdate"`   // Deprecated: use ClusterSpec.ClusterSecrets.AADClientCertSecret instead
 
 	AppliedClusterAgentDeploymentCustomization *AgentDeploymentCustomization `json:"appliedClusterAgentDeploymentCustomization,omitempty"`
+
+	// HealthHistory are the latest transitions of the health conditions of the cluster, oldest first.
+	HealthHistory []ClusterHealthTransition `json:"healthHistory,omitempty" norman:"nocreate,noupdate"`
+}
+
+// ClusterHealthTransition is a change of the status of a health condition of a cluster.
+type ClusterHealthTransition struct {
+	// Type is the type of the health condition.
+	Type ClusterConditionType `json:"type"`
+	// Status is the status the condition transitioned to, one of True, False, Unknown.
+	Status v1.ConditionStatus `json:"status"`
+	// Time is when the condition transitioned, in RFC 3339 format.
+	Time string `json:"time,omitempty"`
+	// Reason is the reason of the transition.
+	Reason string `json:"reason,omitempty"`
+	// Message details the transition.
+	Message string `json:"message,omitempty"`
 }
 
 type ClusterComponentStatus struct {
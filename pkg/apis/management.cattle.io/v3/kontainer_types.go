@@ -28,6 +28,9 @@ type KontainerDriverStatus struct {
 	ExecutablePath string      `json:"executablePath"`
 	Conditions     []Condition `json:"conditions"`
 	DisplayName    string      `json:"displayName"`
+	// VerificationResult is the result of the last signature verification
+	// performed on the driver binary.
+	VerificationResult *DriverVerificationStatus `json:"verificationResult,omitempty"`
 }
 
 type KontainerDriverSpec struct {
@@ -37,6 +40,9 @@ type KontainerDriverSpec struct {
 	Active           bool     `json:"active"`
 	UIURL            string   `json:"uiUrl"`
 	WhitelistDomains []string `json:"whitelistDomains,omitempty"`
+	// Verification specifies the signature which must be verified before
+	// the downloaded driver binary is installed.
+	Verification *DriverVerification `json:"verification,omitempty"`
 }
 
 var (
@@ -44,4 +50,5 @@ type KontainerDriverSpec struct {
 	KontainerDriverConditionInstalled  condition.Cond = "Installed"
 	KontainerDriverConditionActive     condition.Cond = "Active"
 	KontainerDriverConditionInactive   condition.Cond = "Inactive"
+	KontainerDriverConditionVerified   condition.Cond = "Verified"
 )
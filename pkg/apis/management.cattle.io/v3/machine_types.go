@@ -314,6 +314,11 @@ type NodeDriverStatus struct {
 	// drivers bundled within Rancher via rancher-machine.
 	// +optional
 	AppliedDockerMachineVersion string `json:"appliedDockerMachineVersion,omitempty"`
+	// VerificationResult is the result of the last signature verification
+	// performed on the driver binary. It is only populated for drivers
+	// which specify a verification source.
+	// +optional
+	VerificationResult *DriverVerificationStatus `json:"verificationResult,omitempty"`
 }
 
 var (
@@ -321,6 +326,7 @@ type NodeDriverStatus struct {
 	NodeDriverConditionInstalled  condition.Cond = "Installed"
 	NodeDriverConditionActive     condition.Cond = "Active"
 	NodeDriverConditionInactive   condition.Cond = "Inactive"
+	NodeDriverConditionVerified   condition.Cond = "Verified"
 )
 
 type NodeDriverSpec struct {
@@ -364,6 +370,60 @@ type NodeDriverSpec struct {
 	// white-listed by Rancher to allow for the driver to be downloaded.
 	// +optional
 	WhitelistDomains []string `json:"whitelistDomains,omitempty"`
+	// Verification specifies the signature which must be verified before
+	// the downloaded driver binary is installed. When unset, only the
+	// Checksum (if any) is validated.
+	// +optional
+	Verification *DriverVerification `json:"verification,omitempty"`
+}
+
+const (
+	// DriverSignatureFormatCosign is a base64 encoded detached signature
+	// as produced by "cosign sign-blob".
+	DriverSignatureFormatCosign = "cosign"
+	// DriverSignatureFormatMinisign is a signature as produced by "minisign -S".
+	DriverSignatureFormatMinisign = "minisign"
+)
+
+// DriverVerification describes where the signature of a driver binary
+// can be found and which public keys are trusted to have produced it.
+type DriverVerification struct {
+	// Format is the format of the signature, either cosign or minisign.
+	// +kubebuilder:validation:Enum=cosign;minisign
+	// +required
+	Format string `json:"format" norman:"required,type=enum,options=cosign|minisign"`
+	// SignatureURL defines the location of the detached signature of the
+	// driver binary.
+	// +required
+	SignatureURL string `json:"signatureURL" norman:"required"`
+	// PublicKeysSecretName is the name of the secret, in the form
+	// namespace:name, holding the trusted public keys. Each data entry of
+	// the secret is a single public key; the signature is accepted if any
+	// of them verifies it.
+	// +required
+	PublicKeysSecretName string `json:"publicKeysSecretName" norman:"required"`
+}
+
+// DriverVerificationStatus is the outcome of verifying the signature of a
+// driver binary.
+type DriverVerificationStatus struct {
+	// Verified is true when the installed binary was verified against one
+	// of the trusted public keys.
+	// +optional
+	Verified bool `json:"verified,omitempty"`
+	// Format is the signature format used for the verification.
+	// +optional
+	Format string `json:"format,omitempty"`
+	// SignatureURL is the url the verified signature was downloaded from.
+	// +optional
+	SignatureURL string `json:"signatureURL,omitempty"`
+	// Key is the name of the entry in the public keys secret which
+	// verified the signature.
+	// +optional
+	Key string `json:"key,omitempty"`
+	// Message contains the reason the verification failed, if any.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 type PublicEndpoint struct {
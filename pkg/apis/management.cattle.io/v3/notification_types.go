@@ -15,4 +15,126 @@ type RancherUserNotification struct {
 
 	ComponentName string `json:"componentName"`
 	Message       string `json:"message"`
+
+	// Event is the platform event the notification was published for. Notifications without an event are only
+	// displayed, they aren't delivered.
+	// +optional
+	Event *NotificationEvent `json:"event,omitempty"`
+
+	// Status is the delivery status of the notification.
+	// +optional
+	Status RancherUserNotificationStatus `json:"status,omitempty"`
+}
+
+// NotificationEventType is the type of a platform event.
+type NotificationEventType string
+
+const (
+	// NotificationEventCertificateExpiring is published when a certificate of the local cluster expired or will
+	// expire soon.
+	NotificationEventCertificateExpiring NotificationEventType = "CertificateExpiring"
+	// NotificationEventTokenExpiring is published when an API key will expire soon.
+	NotificationEventTokenExpiring NotificationEventType = "TokenExpiring"
+	// NotificationEventClusterDisconnected is published when the agent of a cluster disconnects.
+	NotificationEventClusterDisconnected NotificationEventType = "ClusterDisconnected"
+	// NotificationEventHelmOperationFailed is published when a Helm operation fails.
+	NotificationEventHelmOperationFailed NotificationEventType = "HelmOperationFailed"
+	// NotificationEventETCDSnapshotFailed is published when an etcd snapshot fails.
+	NotificationEventETCDSnapshotFailed NotificationEventType = "ETCDSnapshotFailed"
+	// NotificationEventUserDisabled is published when user retention disables an inactive user.
+	NotificationEventUserDisabled NotificationEventType = "UserDisabled"
+)
+
+// NotificationSeverity is the severity of a platform event.
+type NotificationSeverity string
+
+const (
+	NotificationSeverityInfo    NotificationSeverity = "Info"
+	NotificationSeverityWarning NotificationSeverity = "Warning"
+	NotificationSeverityError   NotificationSeverity = "Error"
+)
+
+// NotificationEvent is a platform event.
+type NotificationEvent struct {
+	// Type is the type of the event.
+	Type NotificationEventType `json:"type"`
+
+	// Severity is the severity of the event.
+	// +optional
+	Severity NotificationSeverity `json:"severity,omitempty"`
+
+	// ClusterName is the name of the cluster the event is about, if any.
+	// +optional
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// Object is the namespace and name of the resource the event is about, such as a certificate or a token.
+	// +optional
+	Object string `json:"object,omitempty"`
+
+	// UserName is the name of the user affected by the event, such as the owner of an expiring token.
+	// +optional
+	UserName string `json:"userName,omitempty"`
+
+	// FirstOccurrence is when the event first occurred.
+	// +optional
+	FirstOccurrence metav1.Time `json:"firstOccurrence,omitempty"`
+
+	// LastOccurrence is when the event last occurred.
+	// +optional
+	LastOccurrence metav1.Time `json:"lastOccurrence,omitempty"`
+
+	// Count is how many times the event occurred.
+	// +optional
+	Count int `json:"count,omitempty"`
+}
+
+// RancherUserNotificationStatus is the delivery status of a notification.
+type RancherUserNotificationStatus struct {
+	// Recipients are the users and groups targeted by the subscriptions matching the event of the notification.
+	// +optional
+	Recipients []string `json:"recipients,omitempty"`
+
+	// Deliveries are the deliveries of the notification, one per subscription and channel.
+	// +optional
+	Deliveries []NotificationDelivery `json:"deliveries,omitempty"`
+}
+
+// NotificationDeliveryState is the state of the delivery of a notification through a channel.
+type NotificationDeliveryState string
+
+const (
+	// NotificationDeliverySending is the state of a delivery being attempted. It is recorded before sending the
+	// notification, so that it isn't sent again when recording the outcome of the attempt fails.
+	NotificationDeliverySending   NotificationDeliveryState = "Sending"
+	NotificationDeliveryDelivered NotificationDeliveryState = "Delivered"
+	NotificationDeliveryFailed    NotificationDeliveryState = "Failed"
+	NotificationDeliveryThrottled NotificationDeliveryState = "Throttled"
+)
+
+// NotificationDelivery is the delivery of a notification through a channel, for a subscription.
+type NotificationDelivery struct {
+	// Subscription is the name of the subscription matching the event.
+	Subscription string `json:"subscription"`
+
+	// Channel is the name of the channel the notification is delivered through.
+	Channel string `json:"channel"`
+
+	// State is the state of the last delivery attempt.
+	State NotificationDeliveryState `json:"state"`
+
+	// Attempts is the number of failed attempts since the last successful delivery.
+	// +optional
+	Attempts int `json:"attempts,omitempty"`
+
+	// LastAttemptTime is when the notification was last sent through the channel.
+	// +optional
+	LastAttemptTime metav1.Time `json:"lastAttemptTime,omitempty"`
+
+	// LastDeliveryTime is when the notification was last delivered through the channel.
+	// +optional
+	LastDeliveryTime *metav1.Time `json:"lastDeliveryTime,omitempty"`
+
+	// Message is the error of the last failed attempt.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
@@ -1048,6 +1048,24 @@ func (in *CloudCredentialSpec) DeepCopy() *CloudCredentialSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeFreezeWindow) DeepCopyInto(out *ChangeFreezeWindow) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeFreezeWindow.
+func (in *ChangeFreezeWindow) DeepCopy() *ChangeFreezeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeFreezeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Cluster) DeepCopyInto(out *Cluster) {
 	*out = *in
@@ -1076,6 +1094,34 @@ func (in *Cluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterChangeFreeze) DeepCopyInto(out *ClusterChangeFreeze) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]ChangeFreezeWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExemptPrincipals != nil {
+		in, out := &in.ExemptPrincipals, &out.ExemptPrincipals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterChangeFreeze.
+func (in *ClusterChangeFreeze) DeepCopy() *ClusterChangeFreeze {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterChangeFreeze)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterComponentStatus) DeepCopyInto(out *ClusterComponentStatus) {
 	*out = *in
@@ -1113,6 +1159,22 @@ func (in *ClusterCondition) DeepCopy() *ClusterCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterHealthTransition) DeepCopyInto(out *ClusterHealthTransition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterHealthTransition.
+func (in *ClusterHealthTransition) DeepCopy() *ClusterHealthTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterHealthTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterList) DeepCopyInto(out *ClusterList) {
 	*out = *in
@@ -1470,6 +1532,11 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ChangeFreeze != nil {
+		in, out := &in.ChangeFreeze, &out.ChangeFreeze
+		*out = new(ClusterChangeFreeze)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1613,6 +1680,11 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 		*out = new(AgentDeploymentCustomization)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HealthHistory != nil {
+		in, out := &in.HealthHistory, &out.HealthHistory
+		*out = make([]ClusterHealthTransition, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -2156,6 +2228,38 @@ func (in *DockerInfo) DeepCopy() *DockerInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriverVerification) DeepCopyInto(out *DriverVerification) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriverVerification.
+func (in *DriverVerification) DeepCopy() *DriverVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(DriverVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DriverVerificationStatus) DeepCopyInto(out *DriverVerificationStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriverVerificationStatus.
+func (in *DriverVerificationStatus) DeepCopy() *DriverVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DriverVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DynamicSchema) DeepCopyInto(out *DynamicSchema) {
 	*out = *in
@@ -4013,6 +4117,11 @@ func (in *KontainerDriverSpec) DeepCopyInto(out *KontainerDriverSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(DriverVerification)
+		**out = **in
+	}
 	return
 }
 
@@ -4034,6 +4143,11 @@ func (in *KontainerDriverStatus) DeepCopyInto(out *KontainerDriverStatus) {
 		*out = make([]Condition, len(*in))
 		copy(*out, *in)
 	}
+	if in.VerificationResult != nil {
+		in, out := &in.VerificationResult, &out.VerificationResult
+		*out = new(DriverVerificationStatus)
+		**out = **in
+	}
 	return
 }
 
@@ -4713,6 +4827,11 @@ func (in *NodeDriverSpec) DeepCopyInto(out *NodeDriverSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(DriverVerification)
+		**out = **in
+	}
 	return
 }
 
@@ -4734,6 +4853,11 @@ func (in *NodeDriverStatus) DeepCopyInto(out *NodeDriverStatus) {
 		*out = make([]Condition, len(*in))
 		copy(*out, *in)
 	}
+	if in.VerificationResult != nil {
+		in, out := &in.VerificationResult, &out.VerificationResult
+		*out = new(DriverVerificationStatus)
+		**out = **in
+	}
 	return
 }
 
@@ -5161,6 +5285,45 @@ func (in *NodeTemplateStatus) DeepCopy() *NodeTemplateStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationDelivery) DeepCopyInto(out *NotificationDelivery) {
+	*out = *in
+	in.LastAttemptTime.DeepCopyInto(&out.LastAttemptTime)
+	if in.LastDeliveryTime != nil {
+		in, out := &in.LastDeliveryTime, &out.LastDeliveryTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationDelivery.
+func (in *NotificationDelivery) DeepCopy() *NotificationDelivery {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationDelivery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationEvent) DeepCopyInto(out *NotificationEvent) {
+	*out = *in
+	in.FirstOccurrence.DeepCopyInto(&out.FirstOccurrence)
+	in.LastOccurrence.DeepCopyInto(&out.LastOccurrence)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationEvent.
+func (in *NotificationEvent) DeepCopy() *NotificationEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OAuthEndpoint) DeepCopyInto(out *OAuthEndpoint) {
 	*out = *in
@@ -6332,6 +6495,12 @@ func (in *RancherUserNotification) DeepCopyInto(out *RancherUserNotification) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Event != nil {
+		in, out := &in.Event, &out.Event
+		*out = new(NotificationEvent)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -6386,6 +6555,34 @@ func (in *RancherUserNotificationList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RancherUserNotificationStatus) DeepCopyInto(out *RancherUserNotificationStatus) {
+	*out = *in
+	if in.Recipients != nil {
+		in, out := &in.Recipients, &out.Recipients
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Deliveries != nil {
+		in, out := &in.Deliveries, &out.Deliveries
+		*out = make([]NotificationDelivery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RancherUserNotificationStatus.
+func (in *RancherUserNotificationStatus) DeepCopy() *RancherUserNotificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RancherUserNotificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceQuotaLimit) DeepCopyInto(out *ResourceQuotaLimit) {
 	*out = *in
@@ -96,6 +96,14 @@ type ClusterSpec struct {
 	// Rancher server can update the system-upgrade-controller plan.
 	// +optional
 	RedeploySystemAgentGeneration int64 `json:"redeploySystemAgentGeneration,omitempty"`
+
+	// ClusterTemplate binds the cluster to a revision of a cluster template.
+	// The configuration of the revision is applied to the spec of the
+	// cluster when it is created, and again when the binding changes, to
+	// upgrade the cluster to a new revision for instance.
+	// +nullable
+	// +optional
+	ClusterTemplate *ClusterTemplateBinding `json:"clusterTemplate,omitempty"`
 }
 
 type ClusterAPIConfig struct {
@@ -167,6 +175,7 @@ type RKEConfig struct {
 // +kubebuilder:validation:XValidation:rule="!has(self.etcdRole) || !self.etcdRole || !has(self.autoscalingMinSize) || self.autoscalingMinSize > 0", message="AutoscalingMinSize must be greater than 0 when EtcdRole is true"
 // +kubebuilder:validation:XValidation:rule="!has(self.autoscalingMaxSize) || !has(self.autoscalingMinSize) || self.autoscalingMinSize <= self.autoscalingMaxSize", message="AutoscalingMinSize must be less than or equal to AutoscalingMaxSize when both are non-nil"
 // +kubebuilder:validation:XValidation:rule="(has(self.autoscalingMinSize) && has(self.autoscalingMaxSize)) || (!has(self.autoscalingMinSize) && !has(self.autoscalingMaxSize))", message="AutoscalingMinSize and AutoscalingMaxSize must both be set if enabling cluster-autoscaling"
+// +kubebuilder:validation:XValidation:rule="!has(self.scalingRules) || ((!has(self.etcdRole) || !self.etcdRole) && (!has(self.controlPlaneRole) || !self.controlPlaneRole)) || self.scalingRules.all(r, (!has(r.quantity) || r.quantity > 0) && (!has(r.autoscalingMinSize) || r.autoscalingMinSize > 0))", message="ScalingRules must not scale machine pools with EtcdRole or ControlPlaneRole to 0"
 type RKEMachinePool struct {
 	rkev1.RKECommonNodeConfig `json:",inline"`
 
@@ -264,6 +273,17 @@ type RKEMachinePool struct {
 	// +optional
 	AutoscalingMaxSize *int32 `json:"autoscalingMaxSize,omitempty"`
 
+	// ScalingRules are scheduled changes of the quantity or the autoscaler
+	// bounds of the machine pool. At each scheduled time, the fields set by
+	// the rule are written to the machine pool, and are kept until the next
+	// scheduled time of any rule.
+	// +kubebuilder:validation:MaxItems=100
+	// +listType=map
+	// +listMapKey=name
+	// +nullable
+	// +optional
+	ScalingRules []RKEMachinePoolScalingRule `json:"scalingRules,omitempty"`
+
 	// NodeStartupTimeout allows setting the maximum time for
 	// MachineHealthCheck to consider a Machine unhealthy if a corresponding
 	// Node isn't associated through a `Spec.ProviderID` field.
@@ -332,6 +352,53 @@ type RKEMachinePool struct {
 	HostnameLengthLimit int `json:"hostnameLengthLimit,omitempty"`
 }
 
+// RKEMachinePoolScalingRule is a scheduled change of the size of a machine pool.
+// +kubebuilder:validation:XValidation:rule="has(self.quantity) || has(self.autoscalingMinSize)", message="ScalingRule must set Quantity or AutoscalingMinSize and AutoscalingMaxSize"
+// +kubebuilder:validation:XValidation:rule="(has(self.autoscalingMinSize) && has(self.autoscalingMaxSize)) || (!has(self.autoscalingMinSize) && !has(self.autoscalingMaxSize))", message="AutoscalingMinSize and AutoscalingMaxSize must both be set if scaling the autoscaler bounds"
+// +kubebuilder:validation:XValidation:rule="!has(self.autoscalingMaxSize) || !has(self.autoscalingMinSize) || self.autoscalingMinSize <= self.autoscalingMaxSize", message="AutoscalingMinSize must be less than or equal to AutoscalingMaxSize"
+type RKEMachinePoolScalingRule struct {
+	// Name uniquely identifies the rule within the machine pool.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	// +required
+	Name string `json:"name"`
+
+	// Schedule is the cron schedule at which the rule is applied, in the
+	// standard five field format (e.g. "0 19 * * 1-5").
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Schedule string `json:"schedule"`
+
+	// TimeZone is the IANA name of the time zone the schedule is evaluated
+	// in (e.g. "Europe/Berlin").
+	// Defaults to UTC.
+	// +nullable
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Quantity is the number of machines the machine pool is scaled to.
+	// When the machine pool has autoscaler bounds, the quantity is kept
+	// within them.
+	// +kubebuilder:validation:Minimum=0
+	// +nullable
+	// +optional
+	Quantity *int32 `json:"quantity,omitempty"`
+
+	// AutoscalingMinSize is the autoscaler min node size the machine pool is
+	// set to. The quantity of the machine pool is raised to it if lower.
+	// +kubebuilder:validation:Minimum=0
+	// +nullable
+	// +optional
+	AutoscalingMinSize *int32 `json:"autoscalingMinSize,omitempty"`
+
+	// AutoscalingMaxSize is the autoscaler max node size the machine pool is
+	// set to. The quantity of the machine pool is lowered to it if higher.
+	// +kubebuilder:validation:Minimum=0
+	// +nullable
+	// +optional
+	AutoscalingMaxSize *int32 `json:"autoscalingMaxSize,omitempty"`
+}
+
 type RKEMachinePoolRollingUpdate struct {
 	// MaxUnavailable is the maximum number of machines that can be
 	// unavailable during the update.
@@ -511,6 +578,45 @@ type ClusterStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []genericcondition.GenericCondition `json:"conditions,omitempty"`
+
+	// MachinePoolScaling is the state of the scaling rules of each machine
+	// pool that has any.
+	// +optional
+	MachinePoolScaling []RKEMachinePoolScalingStatus `json:"machinePoolScaling,omitempty"`
+
+	// ClusterTemplate is the state of the cluster against the revision of
+	// its cluster template, if it is bound to one.
+	// +optional
+	ClusterTemplate *ClusterTemplateStatus `json:"clusterTemplate,omitempty"`
+}
+
+// RKEMachinePoolScalingStatus is the state of the scaling rules of a machine
+// pool.
+type RKEMachinePoolScalingStatus struct {
+	// Name is the name of the machine pool.
+	Name string `json:"name"`
+
+	// LastRule is the name of the rule that last scaled the machine pool.
+	// +optional
+	LastRule string `json:"lastRule,omitempty"`
+
+	// LastScaleTime is the scheduled time of the rule that last scaled the
+	// machine pool.
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+
+	// NextRule is the name of the rule that scales the machine pool next.
+	// +optional
+	NextRule string `json:"nextRule,omitempty"`
+
+	// NextScaleTime is the time the machine pool is scaled next.
+	// +optional
+	NextScaleTime *metav1.Time `json:"nextScaleTime,omitempty"`
+
+	// Message details why the scaling rules of the machine pool can not be
+	// applied.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +genclient
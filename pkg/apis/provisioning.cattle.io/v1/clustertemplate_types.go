@@ -0,0 +1,136 @@
+package v1
+
+import (
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterTemplateSpec is the desired state of a cluster template.
+type ClusterTemplateSpec struct {
+	// DisplayName is the human-readable name of the template.
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Description is the description of the template.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// DefaultRevisionName is the name of the revision clusters are created
+	// from when they don't name one.
+	// +optional
+	DefaultRevisionName string `json:"defaultRevisionName,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:resource:path=clustertemplates,scope=Namespaced,categories=provisioning
+// +kubebuilder:printcolumn:name="Display Name",type=string,JSONPath=".spec.displayName"
+// +kubebuilder:printcolumn:name="Default Revision",type=string,JSONPath=".spec.defaultRevisionName"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterTemplate is a revisioned cluster configuration that clusters of its
+// namespace are created from, so that they share a vetted configuration.
+type ClusterTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the desired state of the cluster template.
+	// +optional
+	Spec ClusterTemplateSpec `json:"spec,omitempty"`
+}
+
+// ClusterTemplateRevisionSpec is the desired state of a cluster template
+// revision. Changes to the questions and cluster configuration of a revision
+// are applied to every cluster bound to it, new revisions are meant to roll
+// changes out cluster by cluster instead.
+type ClusterTemplateRevisionSpec struct {
+	// ClusterTemplateName is the name of the template of the revision, in
+	// the namespace of the revision.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="ClusterTemplateName is immutable"
+	ClusterTemplateName string `json:"clusterTemplateName"`
+
+	// DisplayName is the human-readable name of the revision.
+	// +optional
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Enabled is whether clusters can be created from or upgraded to the
+	// revision. Clusters already bound to a disabled revision keep it.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	// Questions are the parameters of the revision. The variable of each
+	// question is the path of a field of the cluster spec, such as
+	// kubernetesVersion or rkeConfig.machinePools.0.quantity, which is set
+	// to the answer of the cluster, or to the default of the question. The
+	// type of the question, int, boolean, enum or string, determines the
+	// type of the field.
+	// +optional
+	Questions []v3.Question `json:"questions,omitempty"`
+
+	// ClusterConfig is the configuration of the clusters created from the
+	// revision, such as their RKE configuration, machine pools and chart
+	// values. The fields it sets are owned by the template: they are
+	// applied to the clusters bound to the revision, and the differences
+	// are reported as drift.
+	ClusterConfig ClusterSpec `json:"clusterConfig"`
+}
+
+// +genclient
+// +kubebuilder:resource:path=clustertemplaterevisions,scope=Namespaced,categories=provisioning
+// +kubebuilder:printcolumn:name="Template",type=string,JSONPath=".spec.clusterTemplateName"
+// +kubebuilder:printcolumn:name="Display Name",type=string,JSONPath=".spec.displayName"
+// +kubebuilder:printcolumn:name="Enabled",type=boolean,JSONPath=".spec.enabled"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterTemplateRevision is a revision of a cluster template.
+type ClusterTemplateRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the desired state of the cluster template revision.
+	Spec ClusterTemplateRevisionSpec `json:"spec"`
+}
+
+// ClusterTemplateBinding binds a cluster to a revision of a cluster template.
+type ClusterTemplateBinding struct {
+	// Name is the name of the cluster template, in the namespace of the
+	// cluster.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Revision is the name of the revision of the template. The default
+	// revision of the template is used when it is empty, clusters created
+	// through the Rancher API are bound to the default revision at the time.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Answers are the answers to the questions of the revision, by variable.
+	// +optional
+	Answers map[string]string `json:"answers,omitempty"`
+}
+
+// ClusterTemplateStatus is the state of a cluster against the revision of its
+// cluster template.
+type ClusterTemplateStatus struct {
+	// AppliedRevision is the name of the revision last applied to the spec of
+	// the cluster.
+	// +optional
+	AppliedRevision string `json:"appliedRevision,omitempty"`
+
+	// AppliedHash is the hash of the configuration last applied to the spec
+	// of the cluster, the configuration of the revision with the answers of
+	// the cluster. It is applied again whenever it changes.
+	// +optional
+	AppliedHash string `json:"appliedHash,omitempty"`
+
+	// Drift are the paths of the fields of the cluster spec that differ from
+	// the configuration of the revision.
+	// +optional
+	Drift []string `json:"drift,omitempty"`
+
+	// Message details why the revision can not be applied.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
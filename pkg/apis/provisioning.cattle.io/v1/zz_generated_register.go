@@ -28,7 +28,9 @@
 )
 
 var (
-	ClusterResourceName = "clusters"
+	ClusterResourceName                 = "clusters"
+	ClusterTemplateResourceName         = "clustertemplates"
+	ClusterTemplateRevisionResourceName = "clustertemplaterevisions"
 )
 
 // SchemeGroupVersion is group version used to register these objects
@@ -54,6 +56,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&Cluster{},
 		&ClusterList{},
+		&ClusterTemplate{},
+		&ClusterTemplateList{},
+		&ClusterTemplateRevision{},
+		&ClusterTemplateRevisionList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -109,6 +109,37 @@ type ClusterUpgradeStrategy struct {
 	// worker nodes, during both upgrades and machine rollouts.
 	// +optional
 	WorkerDrainOptions DrainOptions `json:"workerDrainOptions,omitempty"`
+
+	// MaintenanceWindows are the recurring windows during which disruptive
+	// plan changes are delivered. Disruptive changes, such as Kubernetes
+	// version upgrades, changes restarting the distribution services or
+	// triggering a drain, and certificate rotations, are held until a window
+	// opens, while other changes are delivered immediately.
+	// When empty, all changes are delivered as soon as they are computed.
+	// +nullable
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window during which disruptive plan
+// changes can be delivered.
+type MaintenanceWindow struct {
+	// Schedule is a standard cron expression for the start of the window,
+	// for example "0 22 * * 1-5" for 10 PM on weekdays.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^(@(annually|yearly|monthly|weekly|daily|midnight|hourly)|@every [0-9a-z.]+|[0-9A-Za-z*?,/-]+( +[0-9A-Za-z*?,/-]+){4})$`
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open once it starts.
+	// +kubebuilder:validation:XValidation:rule="duration(self) > duration('0s')",message="duration must be positive"
+	Duration metav1.Duration `json:"duration"`
+
+	// TimeZone is the IANA time zone the schedule is evaluated in, for
+	// example "Europe/Berlin". Defaults to UTC.
+	// +kubebuilder:validation:MaxLength=64
+	// +kubebuilder:validation:Pattern=`^[A-Za-z][A-Za-z0-9_+-]*(/[A-Za-z0-9_+-]+)*$`
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
 }
 
 // DrainOptions contains the drain configuration for a machine pool.
@@ -369,6 +400,51 @@ type ETCD struct {
 	// +nullable
 	// +optional
 	S3 *ETCDSnapshotS3 `json:"s3,omitempty"`
+
+	// SnapshotVerification defines the scheduled verification of the latest snapshot of the cluster.
+	// +nullable
+	// +optional
+	SnapshotVerification *ETCDSnapshotVerification `json:"snapshotVerification,omitempty"`
+
+	// Targets are additional S3 destinations snapshots of the cluster are replicated to, on top of the local and S3
+	// destinations configured above.
+	// +nullable
+	// +optional
+	Targets []ETCDSnapshotTarget `json:"targets,omitempty"`
+}
+
+// ETCDSnapshotTarget defines an additional S3 destination for the etcd snapshots of a cluster. Snapshot files are
+// copied to the target by a job in the downstream cluster, and can be restored from it.
+type ETCDSnapshotTarget struct {
+	// Name uniquely identifies the target within the cluster.
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// ScheduleCron is the cron schedule on which the latest snapshot is replicated to the target.
+	// If this field is empty, every new snapshot is replicated to the target.
+	// +nullable
+	// +optional
+	ScheduleCron string `json:"scheduleCron,omitempty"`
+
+	// S3 defines the bucket, folder and cloud credential of the target. Its retention defines the number of snapshots
+	// retained in the target, defaulting to the snapshot retention of the cluster.
+	S3 ETCDSnapshotS3 `json:"s3"`
+}
+
+// ETCDSnapshotVerification defines how and when the latest successful etcd snapshot of a cluster is verified. The
+// snapshot file is fetched into a job pod in the downstream cluster, where its integrity is checked with
+// `etcdutl snapshot status`.
+type ETCDSnapshotVerification struct {
+	// ScheduleCron is the cron schedule on which the latest successful snapshot is verified.
+	// Verification is disabled if this field is empty.
+	// +nullable
+	// +optional
+	ScheduleCron string `json:"scheduleCron,omitempty"`
+
+	// Restore defines whether the snapshot is additionally restored into a throwaway single-node etcd, whose key
+	// count is compared with the key count reported by the snapshot status.
+	// +optional
+	Restore bool `json:"restore,omitempty"`
 }
 
 // Networking contains information regarding the desired and actual networking stack of the cluster.
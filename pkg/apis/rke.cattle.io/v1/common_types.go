@@ -90,6 +90,12 @@ type ETCDSnapshotRestore struct {
 	// +nullable
 	// +optional
 	RestoreRKEConfig string `json:"restoreRKEConfig,omitempty"`
+
+	// Target is the name of the snapshot target the snapshot is restored from. If empty, the snapshot is restored
+	// from the location it was taken to.
+	// +nullable
+	// +optional
+	Target string `json:"target,omitempty"`
 }
 
 type RotateCertificates struct {
@@ -160,4 +160,105 @@ type ETCDSnapshotFile struct {
 type ETCDSnapshotStatus struct {
 	// This field is currently unused but retained for backward compatibility or future use.
 	Missing bool `json:"missing"`
+
+	// Verification is the result of the latest verification of the snapshot file.
+	// +optional
+	Verification *ETCDSnapshotVerificationStatus `json:"verification,omitempty"`
+
+	// Targets is the state of the replication of the snapshot file to each snapshot target of the cluster.
+	// +optional
+	Targets []ETCDSnapshotTargetStatus `json:"targets,omitempty"`
+}
+
+type ETCDSnapshotTargetPhase string
+
+const (
+	ETCDSnapshotTargetPhaseReplicating ETCDSnapshotTargetPhase = "Replicating"
+	ETCDSnapshotTargetPhaseReplicated  ETCDSnapshotTargetPhase = "Replicated"
+	ETCDSnapshotTargetPhaseFailed      ETCDSnapshotTargetPhase = "Failed"
+	ETCDSnapshotTargetPhasePruned      ETCDSnapshotTargetPhase = "Pruned"
+)
+
+// ETCDSnapshotTargetStatus describes the replication of a snapshot file to a snapshot target.
+type ETCDSnapshotTargetStatus struct {
+	// Name is the name of the snapshot target.
+	Name string `json:"name"`
+
+	// Phase is the phase of the replication, one of "Replicating", "Replicated", "Failed" or "Pruned". Snapshot
+	// files that were removed from the target by its retention are "Pruned".
+	// +optional
+	Phase ETCDSnapshotTargetPhase `json:"phase,omitempty"`
+
+	// Location is the s3:// URI address of the snapshot file in the target.
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// JobName is the name of the downstream job replicating the snapshot file.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// Message details why the replication failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// UpdatedAt is the time the phase last changed.
+	// +optional
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
+}
+
+type ETCDSnapshotVerificationPhase string
+
+const (
+	ETCDSnapshotVerificationPhaseRunning ETCDSnapshotVerificationPhase = "Running"
+	ETCDSnapshotVerificationPhasePassed  ETCDSnapshotVerificationPhase = "Passed"
+	ETCDSnapshotVerificationPhaseFailed  ETCDSnapshotVerificationPhase = "Failed"
+)
+
+// ETCDSnapshotVerificationStatus records the outcome of a verification of a snapshot file, and the evidence gathered
+// by it.
+type ETCDSnapshotVerificationStatus struct {
+	// Phase is the phase of the verification, one of "Running", "Passed" or "Failed".
+	// +optional
+	Phase ETCDSnapshotVerificationPhase `json:"phase,omitempty"`
+
+	// JobName is the name of the downstream job performing the verification.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// StartedAt is the time the verification was started.
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CompletedAt is the time the verification completed.
+	// +optional
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// SHA256 is the sha256 checksum of the snapshot file.
+	// +optional
+	SHA256 string `json:"sha256,omitempty"`
+
+	// Size is the size of the snapshot file in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+
+	// Hash is the hash of the etcd database contained in the snapshot, as reported by `etcdutl snapshot status`.
+	// +optional
+	Hash int64 `json:"hash,omitempty"`
+
+	// Revision is the etcd revision of the snapshot, as reported by `etcdutl snapshot status`.
+	// +optional
+	Revision int64 `json:"revision,omitempty"`
+
+	// TotalKeys is the number of keys in the snapshot, as reported by `etcdutl snapshot status`.
+	// +optional
+	TotalKeys int64 `json:"totalKeys,omitempty"`
+
+	// RestoredKeys is the number of keys found in the throwaway etcd the snapshot was restored into. It is only set
+	// if a restore was requested.
+	// +optional
+	RestoredKeys *int64 `json:"restoredKeys,omitempty"`
+
+	// Message details why the verification failed.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
@@ -158,6 +158,114 @@ type RKEControlPlaneStatus struct {
 	// established for the cluster.
 	// +optional
 	AgentConnected bool `json:"agentConnected,omitempty"`
+
+	// PlanPreview lists the plan changes that would be delivered to the
+	// machines of the cluster. It is only populated while the
+	// rke.cattle.io/plan-preview or rke.cattle.io/plan-dry-run annotation
+	// is set. Plans are still delivered with the former unless the control
+	// plane is paused, while none are delivered with the latter.
+	// +optional
+	PlanPreview *PlanPreview `json:"planPreview,omitempty"`
+
+	// MaintenanceWindow is the state of the maintenance windows of the
+	// upgrade strategy. It is only populated when maintenance windows are
+	// configured.
+	// +optional
+	MaintenanceWindow *MaintenanceWindowStatus `json:"maintenanceWindow,omitempty"`
+}
+
+// MaintenanceWindowStatus is the state of the maintenance windows of an
+// RKEControlPlane.
+type MaintenanceWindowStatus struct {
+	// Open denotes that a maintenance window is currently open.
+	// +optional
+	Open bool `json:"open,omitempty"`
+
+	// CurrentWindowEnd is when the currently open maintenance window closes.
+	// +optional
+	CurrentWindowEnd *metav1.Time `json:"currentWindowEnd,omitempty"`
+
+	// NextWindowStart is when the next maintenance window opens.
+	// +optional
+	NextWindowStart *metav1.Time `json:"nextWindowStart,omitempty"`
+
+	// PendingMachines are the names of the machines with disruptive plan
+	// changes held until a maintenance window opens.
+	// +optional
+	PendingMachines []string `json:"pendingMachines,omitempty"`
+
+	// PendingCertificateRotation denotes that a certificate rotation is held
+	// until a maintenance window opens.
+	// +optional
+	PendingCertificateRotation bool `json:"pendingCertificateRotation,omitempty"`
+}
+
+// PlanPreview describes the plan changes that would be delivered to the
+// machines of an RKEControlPlane.
+type PlanPreview struct {
+	// ObservedGeneration is the generation of the RKEControlPlane the
+	// preview was rendered for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Machines lists the machines whose plan would change.
+	// +optional
+	Machines []MachinePlanPreview `json:"machines,omitempty"`
+}
+
+// MachinePlanPreview describes how the plan of a single machine would
+// change.
+type MachinePlanPreview struct {
+	// MachineName is the name of the CAPI machine.
+	MachineName string `json:"machineName"`
+
+	// Initial denotes that the machine has no plan yet.
+	// +optional
+	Initial bool `json:"initial,omitempty"`
+
+	// Minor denotes that the change only touches files that can be
+	// delivered without restarting the services, and would be delivered
+	// immediately regardless of the upgrade strategy.
+	// +optional
+	Minor bool `json:"minor,omitempty"`
+
+	// Drain denotes that the machine would be drained before the plan is
+	// delivered.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+
+	// Restart denotes that the plan would restart the distribution
+	// services on the machine.
+	// +optional
+	Restart bool `json:"restart,omitempty"`
+
+	// Files are the paths of the files added, removed or changed.
+	// +optional
+	Files []string `json:"files,omitempty"`
+
+	// Instructions are the names of the one-time instructions added,
+	// removed or changed.
+	// +optional
+	Instructions []string `json:"instructions,omitempty"`
+
+	// PeriodicInstructions are the names of the periodic instructions
+	// added, removed or changed.
+	// +optional
+	PeriodicInstructions []string `json:"periodicInstructions,omitempty"`
+
+	// Probes are the names of the probes added, removed or changed.
+	// +optional
+	Probes []string `json:"probes,omitempty"`
+
+	// ConfigKeys are the keys of the distribution configuration file
+	// added, removed or changed.
+	// +optional
+	ConfigKeys []string `json:"configKeys,omitempty"`
+
+	// Error is set when the desired plan could not be rendered for the
+	// machine.
+	// +optional
+	Error string `json:"error,omitempty"`
 }
 
 // RKEControlPlaneInitializationStatus provides observations of the RKEControlPlane initialization process.
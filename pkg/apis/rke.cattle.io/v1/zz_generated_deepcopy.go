@@ -83,6 +83,11 @@ func (in *ClusterUpgradeStrategy) DeepCopyInto(out *ClusterUpgradeStrategy) {
 	*out = *in
 	in.ControlPlaneDrainOptions.DeepCopyInto(&out.ControlPlaneDrainOptions)
 	in.WorkerDrainOptions.DeepCopyInto(&out.WorkerDrainOptions)
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -271,6 +276,16 @@ func (in *ETCD) DeepCopyInto(out *ETCD) {
 		*out = new(ETCDSnapshotS3)
 		**out = **in
 	}
+	if in.SnapshotVerification != nil {
+		in, out := &in.SnapshotVerification, &out.SnapshotVerification
+		*out = new(ETCDSnapshotVerification)
+		**out = **in
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]ETCDSnapshotTarget, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -291,7 +306,7 @@ func (in *ETCDSnapshot) DeepCopyInto(out *ETCDSnapshot) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
 	in.SnapshotFile.DeepCopyInto(&out.SnapshotFile)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -438,6 +453,18 @@ func (in *ETCDSnapshotSpec) DeepCopy() *ETCDSnapshotSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ETCDSnapshotStatus) DeepCopyInto(out *ETCDSnapshotStatus) {
 	*out = *in
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(ETCDSnapshotVerificationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]ETCDSnapshotTargetStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -451,6 +478,88 @@ func (in *ETCDSnapshotStatus) DeepCopy() *ETCDSnapshotStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotTarget) DeepCopyInto(out *ETCDSnapshotTarget) {
+	*out = *in
+	out.S3 = in.S3
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotTarget.
+func (in *ETCDSnapshotTarget) DeepCopy() *ETCDSnapshotTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotTargetStatus) DeepCopyInto(out *ETCDSnapshotTargetStatus) {
+	*out = *in
+	if in.UpdatedAt != nil {
+		in, out := &in.UpdatedAt, &out.UpdatedAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotTargetStatus.
+func (in *ETCDSnapshotTargetStatus) DeepCopy() *ETCDSnapshotTargetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotTargetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotVerification) DeepCopyInto(out *ETCDSnapshotVerification) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotVerification.
+func (in *ETCDSnapshotVerification) DeepCopy() *ETCDSnapshotVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ETCDSnapshotVerificationStatus) DeepCopyInto(out *ETCDSnapshotVerificationStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.RestoredKeys != nil {
+		in, out := &in.RestoredKeys, &out.RestoredKeys
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ETCDSnapshotVerificationStatus.
+func (in *ETCDSnapshotVerificationStatus) DeepCopy() *ETCDSnapshotVerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ETCDSnapshotVerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvVar) DeepCopyInto(out *EnvVar) {
 	*out = *in
@@ -551,6 +660,93 @@ func (in *MachineInitializationStatus) DeepCopy() *MachineInitializationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachinePlanPreview) DeepCopyInto(out *MachinePlanPreview) {
+	*out = *in
+	if in.Files != nil {
+		in, out := &in.Files, &out.Files
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Instructions != nil {
+		in, out := &in.Instructions, &out.Instructions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PeriodicInstructions != nil {
+		in, out := &in.PeriodicInstructions, &out.PeriodicInstructions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ConfigKeys != nil {
+		in, out := &in.ConfigKeys, &out.ConfigKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachinePlanPreview.
+func (in *MachinePlanPreview) DeepCopy() *MachinePlanPreview {
+	if in == nil {
+		return nil
+	}
+	out := new(MachinePlanPreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindowStatus) DeepCopyInto(out *MaintenanceWindowStatus) {
+	*out = *in
+	if in.CurrentWindowEnd != nil {
+		in, out := &in.CurrentWindowEnd, &out.CurrentWindowEnd
+		*out = (*in).DeepCopy()
+	}
+	if in.NextWindowStart != nil {
+		in, out := &in.NextWindowStart, &out.NextWindowStart
+		*out = (*in).DeepCopy()
+	}
+	if in.PendingMachines != nil {
+		in, out := &in.PendingMachines, &out.PendingMachines
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindowStatus.
+func (in *MaintenanceWindowStatus) DeepCopy() *MaintenanceWindowStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindowStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Mirror) DeepCopyInto(out *Mirror) {
 	*out = *in
@@ -595,6 +791,29 @@ func (in *Networking) DeepCopy() *Networking {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlanPreview) DeepCopyInto(out *PlanPreview) {
+	*out = *in
+	if in.Machines != nil {
+		in, out := &in.Machines, &out.Machines
+		*out = make([]MachinePlanPreview, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlanPreview.
+func (in *PlanPreview) DeepCopy() *PlanPreview {
+	if in == nil {
+		return nil
+	}
+	out := new(PlanPreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProvisioningFileSource) DeepCopyInto(out *ProvisioningFileSource) {
 	*out = *in
@@ -1119,6 +1338,16 @@ func (in *RKEControlPlaneStatus) DeepCopyInto(out *RKEControlPlaneStatus) {
 		*out = new(ETCDSnapshotCreate)
 		**out = **in
 	}
+	if in.PlanPreview != nil {
+		in, out := &in.PlanPreview, &out.PlanPreview
+		*out = new(PlanPreview)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(MaintenanceWindowStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -0,0 +1,49 @@
+// Package annotations holds the annotations added to the audit log entry of a request by the handlers serving it, such
+// as why a request was rejected. It has no dependencies, so that any handler can annotate its requests.
+package annotations
+
+import (
+	"context"
+	"maps"
+	"sync"
+)
+
+type annotationsKey string
+
+var annotationsKeyValue annotationsKey = "audit_annotations"
+
+type annotations struct {
+	lock   sync.Mutex
+	values map[string]string
+}
+
+// WithAnnotations returns a context collecting the annotations of a request.
+func WithAnnotations(ctx context.Context) context.Context {
+	return context.WithValue(ctx, annotationsKeyValue, &annotations{})
+}
+
+// Add adds an annotation to the audit log entry of the request with the given context. It does nothing if the request
+// isn't audited.
+func Add(ctx context.Context, key, value string) {
+	a, ok := ctx.Value(annotationsKeyValue).(*annotations)
+	if !ok {
+		return
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.values == nil {
+		a.values = map[string]string{}
+	}
+	a.values[key] = value
+}
+
+// From returns the annotations of the request with the given context.
+func From(ctx context.Context) map[string]string {
+	a, ok := ctx.Value(annotationsKeyValue).(*annotations)
+	if !ok {
+		return nil
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return maps.Clone(a.values)
+}
@@ -25,8 +25,11 @@
 	}
 )
 
+// sensitiveNames matches the names of the fields redacted by default.
+const sensitiveNames = "[pP]assword|[Kk]ube[Cc]onfig|[Tt]oken"
+
 var (
-	defaultRegex = ".*([pP]assword|[Kk]ube[Cc]onfig|[Tt]oken).*"
+	defaultRegex = ".*(" + sensitiveNames + ").*"
 
 	defaultMu        sync.Mutex
 	defaultRedactors []Redactor
@@ -12,6 +12,7 @@
 
 	"github.com/pborman/uuid"
 	auditlogv1 "github.com/rancher/rancher/pkg/apis/auditlog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/auth/audit/annotations"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 )
 
@@ -78,6 +79,9 @@ type logEntry struct {
 	ResponseCode  int          `json:"responseCode,omitempty"`
 	UserLoginName string       `json:"userLoginName,omitempty"`
 
+	// Annotations are added by the handlers serving the request, see the annotations package.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
 	RequestTimestamp  string `json:"requestTimestamp,omitempty"`
 	ResponseTimestamp string `json:"responseTimestamp,omitempty"`
 
@@ -147,6 +151,7 @@ func newLog(
 		RemoteAddr:    req.RemoteAddr,
 		ResponseCode:  rw.statusCode,
 		UserLoginName: userName,
+		Annotations:   annotations.From(req.Context()),
 
 		RequestTimestamp:  reqTimestamp,
 		ResponseTimestamp: respTimestamp,
@@ -6,6 +6,7 @@
 	"time"
 
 	auditlogv1 "github.com/rancher/rancher/pkg/apis/auditlog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/auth/audit/annotations"
 )
 
 func GetAuditLoggerMiddleware(auditLog *LoggingHandler) func(next http.Handler) http.Handler {
@@ -19,6 +20,7 @@ func GetAuditLoggerMiddleware(auditLog *LoggingHandler) func(next http.Handler)
 			reqTimestamp := time.Now().Format(time.RFC3339)
 			user := getUserInfo(req)
 			context := context.WithValue(req.Context(), userKeyValue, user)
+			context = annotations.WithAnnotations(context)
 			req = req.WithContext(context)
 			keepReqBody := auditLog.level >= auditlogv1.LevelRequest
 			rawReqBody, userName := copyReqBody(req, keepReqBody)
@@ -5,6 +5,7 @@
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 
 	jsonpath "github.com/rancher/jsonpath/pkg"
 	auditlogv1 "github.com/rancher/rancher/pkg/apis/auditlog.cattle.io/v1"
@@ -232,6 +233,92 @@ func regexRedactor(patterns []string) (Redactor, error) {
 	}), nil
 }
 
+// objectRedactors are the redactors applied by RedactObject: the default redactors of secrets, config maps and fields
+// with sensitive names, and the redactions of every default policy regardless of its filters.
+var objectRedactors = sync.OnceValues(func() ([]Redactor, error) {
+	regex, err := regexRedactor([]string{defaultRegex})
+	if err != nil {
+		return nil, err
+	}
+	redactors := []Redactor{RedactFunc(redactSecret), RedactFunc(redactConfigMap), regex}
+	for _, policy := range DefaultPolicies() {
+		for _, redaction := range policy.Spec.AdditionalRedactions {
+			redactor, err := NewRedactor(redaction)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create redactor for policy %s: %w", policy.Name, err)
+			}
+			redactors = append(redactors, redactor)
+		}
+	}
+	return redactors, nil
+})
+
+// RedactObject replaces sensitive information within a JSON object, such as a Kubernetes resource or list, with
+// "[redacted]" the way the default audit policies redact response bodies. The data of secrets and config maps is
+// redacted, as well as credentials, passwords, tokens and kubeconfigs.
+func RedactObject(obj map[string]any) error {
+	redactors, err := objectRedactors()
+	if err != nil {
+		return err
+	}
+
+	redactKubernetesData(obj)
+	log := &logEntry{
+		RequestHeader:  http.Header{},
+		ResponseHeader: http.Header{},
+		RequestBody:    map[string]any{},
+		ResponseBody:   obj,
+	}
+	for _, redactor := range redactors {
+		if err := redactor.Redact(log); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// textRedactions match the values of the fields, flags and headers with sensitive names within lines of text, such as
+// key=value, key: value, "key":"value", --flag value and bearer credentials. The first group is kept.
+var textRedactions = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(--[\w-]*(?:` + sensitiveNames + `)[\w-]*[= ]+)("[^"]*"|[^\s\[]\S*)`),
+	regexp.MustCompile(`(?i)([\w.-]*(?:` + sensitiveNames + `)[\w.-]*"?\s*[:=]\s*)("[^"]*"|[^\s,;&}\]\[][^\s,;&}\]]*)`),
+	regexp.MustCompile(`(?i)(\bbearer\s+)([^\s\[]\S*)`),
+}
+
+// RedactText replaces the values of fields with sensitive names within text, such as container logs, with
+// "[redacted]". It matches the same names as the default redaction of the audit log.
+func RedactText(data []byte) []byte {
+	for _, re := range textRedactions {
+		data = re.ReplaceAll(data, []byte("${1}"+redacted))
+	}
+	return data
+}
+
+// redactKubernetesData redacts the data of secrets and config maps identified by their kind rather than their steve
+// baseType, including the items of lists.
+func redactKubernetesData(obj map[string]any) {
+	switch obj["kind"] {
+	case "Secret", "ConfigMap":
+		redactData(obj)
+	case "SecretList", "ConfigMapList":
+		items, _ := obj["items"].([]any)
+		for i, item := range items {
+			if m, ok := item.(map[string]any); ok {
+				redactData(m)
+			} else {
+				items[i] = redacted
+			}
+		}
+	case "List":
+		items, _ := obj["items"].([]any)
+		for _, item := range items {
+			if m, ok := item.(map[string]any); ok {
+				redactKubernetesData(m)
+			}
+		}
+	}
+}
+
 const (
 	redactPrefix      = "/v3/import"
 	redactedImportUrl = redactPrefix + "/" + redacted
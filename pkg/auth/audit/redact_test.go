@@ -302,3 +302,136 @@ func Test_redactImportUrl(t *testing.T) {
 		_ = settings.ServerURL.Set(originalServerURL)
 	})
 }
+
+func TestRedactObject(t *testing.T) {
+	tests := []struct {
+		name     string
+		obj      map[string]any
+		expected map[string]any
+	}{
+		{
+			name: "secret",
+			obj: map[string]any{
+				"kind": "Secret",
+				"metadata": map[string]any{
+					"name": "credentials",
+					"annotations": map[string]any{
+						"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"key":"dmFsdWU="}}`,
+					},
+				},
+				"data": map[string]any{"key": "dmFsdWU="},
+			},
+			expected: map[string]any{
+				"kind": "Secret",
+				"metadata": map[string]any{
+					"name": "credentials",
+					"annotations": map[string]any{
+						"kubectl.kubernetes.io/last-applied-configuration": redacted,
+					},
+				},
+				"data": redacted,
+			},
+		},
+		{
+			name: "secret list",
+			obj: map[string]any{
+				"kind": "SecretList",
+				"items": []any{
+					map[string]any{"metadata": map[string]any{"name": "a"}, "stringData": map[string]any{"key": "value"}},
+				},
+			},
+			expected: map[string]any{
+				"kind": "SecretList",
+				"items": []any{
+					map[string]any{"metadata": map[string]any{"name": "a"}, "stringData": redacted},
+				},
+			},
+		},
+		{
+			name: "token",
+			obj: map[string]any{
+				"kind":     "Token",
+				"metadata": map[string]any{"name": "token-abcde"},
+				"userId":   "u-abcde",
+				"token":    "secret",
+			},
+			expected: map[string]any{
+				"kind":     "Token",
+				"metadata": map[string]any{"name": "token-abcde"},
+				"userId":   "u-abcde",
+				"token":    redacted,
+			},
+		},
+		{
+			name: "operation command",
+			obj: map[string]any{
+				"kind": "List",
+				"items": []any{
+					map[string]any{
+						"kind":   "Operation",
+						"status": map[string]any{"command": []any{"helm", "install", "--password", "secret"}, "privateKey": "key"},
+					},
+				},
+			},
+			expected: map[string]any{
+				"kind": "List",
+				"items": []any{
+					map[string]any{
+						"kind":   "Operation",
+						"status": map[string]any{"command": redacted, "privateKey": redacted},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, RedactObject(tt.obj))
+			assert.Equal(t, tt.expected, tt.obj)
+		})
+	}
+}
+
+func TestRedactText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "key value",
+			text:     `level=info msg="logging in" token=abc.def password="p4ss word" user=admin`,
+			expected: `level=info msg="logging in" token=[redacted] password=[redacted] user=admin`,
+		},
+		{
+			name:     "json",
+			text:     `{"userId":"u-abcde","token":"secret","kubeconfig": "apiVersion: v1"}`,
+			expected: `{"userId":"u-abcde","token":[redacted],"kubeconfig": [redacted]}`,
+		},
+		{
+			name:     "yaml",
+			text:     "bootstrapPassword: secret\nname: admin",
+			expected: "bootstrapPassword: [redacted]\nname: admin",
+		},
+		{
+			name:     "flags",
+			text:     "helm install --password secret --set-token=abc --namespace cattle-system",
+			expected: "helm install --password [redacted] --set-token=[redacted] --namespace cattle-system",
+		},
+		{
+			name:     "bearer",
+			text:     "Authorization: Bearer kubeconfig-u-abcde:secret",
+			expected: "Authorization: Bearer [redacted]",
+		},
+		{
+			name:     "nothing sensitive",
+			text:     "level=info msg=\"Starting rancher\" version=v2.12.0",
+			expected: "level=info msg=\"Starting rancher\" version=v2.12.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(RedactText([]byte(tt.text))))
+		})
+	}
+}
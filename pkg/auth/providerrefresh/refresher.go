@@ -201,6 +201,8 @@ func (r *refresher) refreshAttributes(attribs *apiv3.UserAttribute) (*v3.UserAtt
 		// We have to find out if the user has a principal for the provider.
 		principalID := GetPrincipalIDForProvider(providerName, user)
 		var newGroupPrincipals []apiv3.Principal
+		// Failing to confirm the logins of one provider must not prevent the others from being refreshed.
+		errorConfirmingProviderLogins := false
 
 		providerDisabled, err := providers.IsDisabledProvider(providerName)
 		if err != nil {
@@ -223,8 +225,10 @@ func (r *refresher) refreshAttributes(attribs *apiv3.UserAttribute) (*v3.UserAtt
 			if hasPerUserSecrets {
 				secret, err = r.tokenMGR.GetSecret(user.Name, providerName, loginTokens[providerName])
 				if apierrors.IsNotFound(err) {
-					// There is no secret so we can't refresh, just continue to the next attribute.
-					return attribs, nil
+					// There is no secret so we can't refresh this provider, keep its principals and tokens as they are
+					// and continue to the next provider.
+					errorConfirmingLogins = true
+					continue
 				}
 				if err != nil {
 					return nil, err
@@ -243,6 +247,7 @@ func (r *refresher) refreshAttributes(attribs *apiv3.UserAttribute) (*v3.UserAtt
 					// In the case that we cant access a server, we still want to continue refreshing, but
 					// we no longer want to disable derived tokens, or remove their login tokens for this provider.
 					if err.Error() != "no access" {
+						errorConfirmingProviderLogins = true
 						errorConfirmingLogins = true
 						logrus.Warnf(
 							"Error refreshing token principals for auth provider %s, userattribute %s, principal %s, skipping: %v",
@@ -273,7 +278,7 @@ func (r *refresher) refreshAttributes(attribs *apiv3.UserAttribute) (*v3.UserAtt
 
 		canAccessProvider := false
 
-		if principalID != "" && !errorConfirmingLogins {
+		if principalID != "" && !errorConfirmingProviderLogins {
 			// We want to verify that the user still has rancher access.
 			canStillAccess, err := providers.CanAccessWithGroupProviders(providerName, principalID, newGroupPrincipals)
 			if err != nil {
@@ -288,7 +293,7 @@ func (r *refresher) refreshAttributes(attribs *apiv3.UserAttribute) (*v3.UserAtt
 
 		// Update extras if either the user has an active login token, or an API token/kubeconfig token and is still active in the auth provider.
 		// If the user cannot access the auth provider, the derived tokens are deactivated below and should not be used to determine extra attributes.
-		if principalID != "" && (len(loginTokens[providerName]) > 0 || (len(derivedTokens[providerName]) > 0 && (canAccessProvider || errorConfirmingLogins))) {
+		if principalID != "" && (len(loginTokens[providerName]) > 0 || (len(derivedTokens[providerName]) > 0 && (canAccessProvider || errorConfirmingProviderLogins))) {
 			// A user is 1:1 with its principal for a given provider, no need to get principals from tokens beyond the first one
 			var token accessor.TokenAccessor
 			if len(loginTokens[providerName]) > 0 {
@@ -311,7 +316,7 @@ func (r *refresher) refreshAttributes(attribs *apiv3.UserAttribute) (*v3.UserAtt
 
 		// If the user doesn't have access through this provider, we want to remove their
 		// login tokens for this provider
-		if !canAccessProvider && !errorConfirmingLogins {
+		if !canAccessProvider && !errorConfirmingProviderLogins {
 			for _, token := range loginTokens[providerName] {
 				var err error
 				switch token.(type) {
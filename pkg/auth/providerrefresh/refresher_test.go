@@ -13,6 +13,7 @@
 	"github.com/rancher/rancher/pkg/auth/accessor"
 	"github.com/rancher/rancher/pkg/auth/providers"
 	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/rancher/rancher/pkg/auth/providers/mocks"
 	"github.com/rancher/rancher/pkg/auth/providers/saml"
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	exttokens "github.com/rancher/rancher/pkg/ext/stores/tokens"
@@ -575,6 +576,85 @@ func TestRefreshAttributes(t *testing.T) {
 	}
 }
 
+func TestRefreshAttributesMultipleProviders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	user := &apiv3.User{
+		ObjectMeta:   metav1.ObjectMeta{Name: "user-abcde"},
+		PrincipalIDs: []string{"azuread_user://jane", "keycloakoidc_user://jane"},
+	}
+	azureGroups := []apiv3.Principal{{ObjectMeta: metav1.ObjectMeta{Name: "azuread_group://developers"}}}
+	attribs := &apiv3.UserAttribute{
+		ObjectMeta: metav1.ObjectMeta{Name: "user-abcde"},
+		GroupPrincipals: map[string]apiv3.Principals{
+			"azuread":      {Items: azureGroups},
+			"keycloakoidc": {Items: []apiv3.Principal{{ObjectMeta: metav1.ObjectMeta{Name: "keycloakoidc_group://developers"}}}},
+		},
+		ExtraByProvider: map[string]map[string][]string{},
+	}
+	loginTokenAzure := &apiv3.Token{ObjectMeta: metav1.ObjectMeta{Name: "token-azure"}, UserID: user.Name, AuthProvider: "azuread"}
+	loginTokenKeycloak := &apiv3.Token{ObjectMeta: metav1.ObjectMeta{Name: "token-keycloak"}, UserID: user.Name, AuthProvider: "keycloakoidc"}
+
+	// Azure AD can't be reached while the user was removed from Keycloak: the Keycloak login tokens must be deleted
+	// whatever the order the providers are refreshed in.
+	azureProvider := mocks.NewMockAuthProvider(ctrl)
+	azureProvider.EXPECT().IsDisabledProvider().Return(false, nil).AnyTimes()
+	azureProvider.EXPECT().RefetchGroupPrincipals("azuread_user://jane", "").Return(nil, errors.New("connection refused"))
+	azureProvider.EXPECT().GetPrincipal("azuread_user://jane", loginTokenAzure).Return(apiv3.Principal{}, nil)
+	azureProvider.EXPECT().GetUserExtraAttributes(gomock.Any()).Return(nil)
+	keycloakProvider := mocks.NewMockAuthProvider(ctrl)
+	keycloakProvider.EXPECT().IsDisabledProvider().Return(false, nil).AnyTimes()
+	keycloakProvider.EXPECT().RefetchGroupPrincipals("keycloakoidc_user://jane", "").Return(nil, errors.New("no access"))
+
+	providers.ProviderNames = map[string]bool{
+		providers.LocalProvider: true,
+		"azuread":               true,
+		"keycloakoidc":          true,
+	}
+	providers.Providers = map[string]common.AuthProvider{
+		providers.LocalProvider: &mockLocalProvider{},
+		"azuread":               azureProvider,
+		"keycloakoidc":          keycloakProvider,
+	}
+
+	secrets := fake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+	scache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+	users := fake.NewMockNonNamespacedControllerInterface[*apiv3.User, *apiv3.UserList](ctrl)
+	users.EXPECT().Cache().Return(nil)
+	secrets.EXPECT().Cache().Return(scache)
+	scache.EXPECT().List("cattle-tokens", gomock.Any()).Return([]*corev1.Secret{}, nil).AnyTimes()
+
+	var deleted []string
+	r := &refresher{
+		tokenLister: &fakes.TokenListerMock{
+			ListFunc: func(_ string, _ labels.Selector) ([]*apiv3.Token, error) {
+				return []*apiv3.Token{loginTokenAzure, loginTokenKeycloak}, nil
+			},
+		},
+		userLister: &fakes.UserListerMock{
+			GetFunc: func(_, _ string) (*apiv3.User, error) {
+				return user, nil
+			},
+		},
+		tokens: &fakes.TokenInterfaceMock{
+			DeleteFunc: func(name string, _ *metav1.DeleteOptions) error {
+				deleted = append(deleted, name)
+				return nil
+			},
+		},
+		tokenMGR: tokens.NewMockedManager(fake.NewMockNonNamespacedClientInterface[*apiv3.Token, *apiv3.TokenList](ctrl)),
+		extTokenStore: exttokens.NewSystem(nil, nil, secrets, users, nil, nil,
+			exttokens.NewTimeHandler(),
+			exttokens.NewHashHandler(),
+			exttokens.NewAuthHandler()),
+	}
+
+	got, err := r.refreshAttributes(attribs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token-keycloak"}, deleted)
+	assert.Equal(t, azureGroups, got.GroupPrincipals["azuread"].Items)
+	assert.Empty(t, got.GroupPrincipals["keycloakoidc"].Items)
+}
+
 func TestGetPrincipalIDForProvider(t *testing.T) {
 	const testUserUsername = "tUser"
 	tests := []struct {
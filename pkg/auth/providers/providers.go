@@ -1,9 +1,13 @@
+// Package providers holds the auth providers of Rancher. Several external auth providers can be enabled at once, next
+// to the local provider: users pick the one to log in with among the enabled ones, principals are looked up in the
+// provider that issued them, and principal searches span all the enabled providers.
 package providers
 
 import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 
@@ -24,6 +28,7 @@
 	"github.com/rancher/rancher/pkg/auth/providers/saml"
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -196,9 +201,14 @@ func AuthenticateUser(w http.ResponseWriter, req *http.Request, input any, provi
 }
 
 func GetPrincipal(principalID string, myToken accessor.TokenAccessor) (apiv3.Principal, error) {
-	principal, err := Providers[myToken.GetAuthProvider()].GetPrincipal(principalID, myToken)
+	providerName := myToken.GetAuthProvider()
+	if issuer := providerForPrincipal(principalID); providerName != LocalProvider && issuer != providerName && slices.Contains(enabledExternalProviders(), issuer) {
+		providerName = issuer
+	}
+
+	principal, err := Providers[providerName].GetPrincipal(principalID, myToken)
 
-	if err != nil && myToken.GetAuthProvider() != LocalProvider {
+	if err != nil && providerName != LocalProvider {
 		p2, e2 := Providers[LocalProvider].GetPrincipal(principalID, myToken)
 		if e2 == nil {
 			return p2, nil
@@ -221,6 +231,7 @@ func SearchPrincipals(name, principalType string, myToken accessor.TokenAccessor
 		return principals, err
 	}
 	if ap != LocalProvider {
+		principals = searchOtherProviders(name, principalType, myToken, principals)
 		lp := Providers[LocalProvider]
 		if lpDedupe, _ := lp.(*local.Provider); lpDedupe != nil {
 			localPrincipals, err := lpDedupe.SearchPrincipalsDedupe(name, principalType, myToken, principals)
@@ -233,6 +244,64 @@ func SearchPrincipals(name, principalType string, myToken accessor.TokenAccessor
 	return principals, err
 }
 
+// searchOtherProviders adds the principals found by the enabled external auth providers other than the one of the
+// token to the given principals, skipping the principals already found. Providers that can't search with the token,
+// such as the ones that need per-user secrets, are skipped.
+func searchOtherProviders(name, principalType string, myToken accessor.TokenAccessor, principals []apiv3.Principal) []apiv3.Principal {
+	found := map[string]bool{}
+	for _, p := range principals {
+		found[p.Name] = true
+	}
+	for _, providerName := range enabledExternalProviders() {
+		if providerName == myToken.GetAuthProvider() {
+			continue
+		}
+		others, err := Providers[providerName].SearchPrincipals(name, principalType, myToken)
+		if err != nil {
+			logrus.Warnf("[SearchPrincipals] Skipping auth provider %s: %v", providerName, err)
+			continue
+		}
+		for _, p := range others {
+			if !found[p.Name] {
+				found[p.Name] = true
+				principals = append(principals, p)
+			}
+		}
+	}
+	return principals
+}
+
+// enabledExternalProviders returns the names of the enabled auth providers other than local, sorted by name.
+func enabledExternalProviders() []string {
+	var names []string
+	for name, provider := range Providers {
+		if name == LocalProvider || provider == nil {
+			continue
+		}
+		disabled, err := provider.IsDisabledProvider()
+		if err != nil {
+			logrus.Debugf("Unable to determine if auth provider %s is disabled, assuming it is: %v", name, err)
+			continue
+		}
+		if !disabled {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// providerForPrincipal returns the name of the auth provider that issued a principal, which is the prefix of the
+// scheme of its ID, such as github for github_team://1234 or local for local://u-abcde.
+func providerForPrincipal(principalID string) string {
+	scheme, _, found := strings.Cut(principalID, "://")
+	if !found {
+		return ""
+	}
+	name, _, _ := strings.Cut(scheme, "_")
+	return name
+}
+
 func CanAccessWithGroupProviders(providerName string, userPrincipalID string, groups []apiv3.Principal) (bool, error) {
 	return Providers[providerName].CanAccessWithGroupProviders(userPrincipalID, groups)
 }
@@ -4,11 +4,16 @@
 	"fmt"
 	"testing"
 
+	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/auth/providers/azure"
 	"github.com/rancher/rancher/pkg/auth/providers/common"
 	"github.com/rancher/rancher/pkg/auth/providers/github"
+	"github.com/rancher/rancher/pkg/auth/providers/keycloakoidc"
+	"github.com/rancher/rancher/pkg/auth/providers/mocks"
+	"github.com/rancher/rancher/pkg/auth/providers/saml"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -131,3 +136,83 @@ func (m *mockUnstructured) EachListItem(func(runtime.Object) error) error
 func (m *mockUnstructured) EachListItemWithAlloc(func(runtime.Object) error) error { return nil }
 func (m *mockUnstructured) GetObjectKind() schema.ObjectKind                       { return nil }
 func (m *mockUnstructured) DeepCopyObject() runtime.Object                         { return nil }
+
+func TestGetPrincipalFromIssuingProvider(t *testing.T) {
+	t.Cleanup(cleanup)
+	ctrl := gomock.NewController(t)
+	azureProvider := mocks.NewMockAuthProvider(ctrl)
+	keycloakProvider := mocks.NewMockAuthProvider(ctrl)
+	localProvider := mocks.NewMockAuthProvider(ctrl)
+	Providers[azure.Name] = azureProvider
+	Providers[keycloakoidc.Name] = keycloakProvider
+	Providers[LocalProvider] = localProvider
+	azureProvider.EXPECT().IsDisabledProvider().Return(false, nil).AnyTimes()
+	keycloakProvider.EXPECT().IsDisabledProvider().Return(false, nil).AnyTimes()
+
+	token := &apiv3.Token{AuthProvider: azure.Name}
+	want := apiv3.Principal{ObjectMeta: metav1.ObjectMeta{Name: "keycloakoidc_user://jane"}, Provider: keycloakoidc.Name}
+	keycloakProvider.EXPECT().GetPrincipal("keycloakoidc_user://jane", token).Return(want, nil)
+	got, err := GetPrincipal("keycloakoidc_user://jane", token)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	want = apiv3.Principal{ObjectMeta: metav1.ObjectMeta{Name: "azuread_user://john"}, Provider: azure.Name}
+	azureProvider.EXPECT().GetPrincipal("azuread_user://john", token).Return(want, nil)
+	got, err = GetPrincipal("azuread_user://john", token)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// Principals of the local provider are still looked up in the provider of the token first.
+	want = apiv3.Principal{ObjectMeta: metav1.ObjectMeta{Name: "local://u-abcde"}, Provider: LocalProvider}
+	azureProvider.EXPECT().GetPrincipal("local://u-abcde", token).Return(apiv3.Principal{}, fmt.Errorf("not found"))
+	localProvider.EXPECT().GetPrincipal("local://u-abcde", token).Return(want, nil)
+	got, err = GetPrincipal("local://u-abcde", token)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestSearchPrincipalsAcrossProviders(t *testing.T) {
+	t.Cleanup(cleanup)
+	ctrl := gomock.NewController(t)
+	azureProvider := mocks.NewMockAuthProvider(ctrl)
+	keycloakProvider := mocks.NewMockAuthProvider(ctrl)
+	githubProvider := mocks.NewMockAuthProvider(ctrl)
+	disabledProvider := mocks.NewMockAuthProvider(ctrl)
+	Providers[azure.Name] = azureProvider
+	Providers[keycloakoidc.Name] = keycloakProvider
+	Providers[github.Name] = githubProvider
+	Providers[saml.OKTAName] = disabledProvider
+	for _, provider := range []*mocks.MockAuthProvider{azureProvider, keycloakProvider, githubProvider} {
+		provider.EXPECT().IsDisabledProvider().Return(false, nil).AnyTimes()
+	}
+	disabledProvider.EXPECT().IsDisabledProvider().Return(true, nil).AnyTimes()
+
+	token := &apiv3.Token{AuthProvider: azure.Name}
+	azureJane := apiv3.Principal{ObjectMeta: metav1.ObjectMeta{Name: "azuread_user://jane"}}
+	keycloakJane := apiv3.Principal{ObjectMeta: metav1.ObjectMeta{Name: "keycloakoidc_user://jane"}}
+	azureProvider.EXPECT().SearchPrincipals("jane", "user", token).Return([]apiv3.Principal{azureJane}, nil)
+	keycloakProvider.EXPECT().SearchPrincipals("jane", "user", token).Return([]apiv3.Principal{keycloakJane, azureJane}, nil)
+	githubProvider.EXPECT().SearchPrincipals("jane", "user", token).Return(nil, fmt.Errorf("no access token"))
+
+	got, err := SearchPrincipals("jane", "user", token)
+	require.NoError(t, err)
+	assert.Equal(t, []apiv3.Principal{azureJane, keycloakJane}, got)
+
+	// Errors of the provider of the token are still returned.
+	azureProvider.EXPECT().SearchPrincipals("john", "user", token).Return(nil, fmt.Errorf("unavailable"))
+	_, err = SearchPrincipals("john", "user", token)
+	assert.Error(t, err)
+}
+
+func TestProviderForPrincipal(t *testing.T) {
+	tests := map[string]string{
+		"github_team://1234":       github.Name,
+		"azuread_group://abcd":     azure.Name,
+		"keycloakoidc_user://jane": keycloakoidc.Name,
+		"local://u-abcde":          LocalProvider,
+		"invalid":                  "",
+	}
+	for principalID, want := range tests {
+		assert.Equal(t, want, providerForPrincipal(principalID), principalID)
+	}
+}
@@ -47,6 +47,7 @@ func newLoginHandler(mgmt *config.ScaledContext) *loginHandler {
 		ensureUser:            mgmt.UserManager.EnsureUser,
 		ensureUserAttribute:   mgmt.UserManager.UserAttributeCreateOrUpdate,
 		newLoginToken:         tokenManager.NewLoginToken,
+		isDisabledProvider:    providers.IsDisabledProvider,
 	}
 }
 
@@ -60,6 +61,7 @@ type loginHandler struct {
 	ensureUser            func(principalName, displayName string) (*apiv3.User, error)
 	ensureUserAttribute   func(userID, provider string, groupPrincipals []apiv3.Principal, userExtraInfo map[string][]string, loginTime ...time.Time) error
 	newLoginToken         func(userID string, userPrincipal apiv3.Principal, groupPrincipals []apiv3.Principal, providerToken string, ttl int64, description string) (*apiv3.Token, string, error)
+	isDisabledProvider    func(providerName string) (bool, error)
 }
 
 func newV1LoginHandler(scaledContext *config.ScaledContext) *v1LoginHandler {
@@ -238,6 +240,14 @@ func (h *loginHandler) login(w http.ResponseWriter, r *http.Request, input login
 		return
 	}
 
+	if disabled, err := h.isDisabledProvider(input.GetName()); err != nil || disabled {
+		if err != nil {
+			logrus.Errorf("login: Error determining if auth provider %s is disabled: %s", input.GetName(), err)
+		}
+		util.ReturnAPIError(w, apierror.NewAPIError(validation.PermissionDenied, "auth provider "+input.GetName()+" is not enabled"))
+		return
+	}
+
 	if providers.IsSAMLProviderType(input.GetType()) {
 		// SAML's login flow is different. Unlike other providers it gets the logged in user's data
 		// via the POST from the identity provider on a separate endpoint.
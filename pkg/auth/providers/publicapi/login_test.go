@@ -0,0 +1,51 @@
+package publicapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginDisabledProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		disabled bool
+		err      error
+	}{
+		{
+			name:     "disabled provider",
+			disabled: true,
+		},
+		{
+			name: "error determining if the provider is disabled",
+			err:  errors.New("unexpected error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checked string
+			h := &loginHandler{
+				isDisabledProvider: func(providerName string) (bool, error) {
+					checked = providerName
+					return tt.disabled, tt.err
+				},
+				ensureUser: func(principalName, displayName string) (*apiv3.User, error) {
+					t.Fatal("unexpected call to ensureUser")
+					return nil, nil
+				},
+			}
+
+			rw := httptest.NewRecorder()
+			input := &apiv3.GithubLogin{GenericLogin: apiv3.GenericLogin{Type: "githubProvider", Name: "github"}}
+			h.login(rw, httptest.NewRequest(http.MethodPost, "/v1-public/login", nil), input)
+
+			assert.Equal(t, "github", checked)
+			assert.Equal(t, http.StatusForbidden, rw.Code)
+			assert.Contains(t, rw.Body.String(), "auth provider github is not enabled")
+		})
+	}
+}
@@ -4,6 +4,8 @@
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/rancher/norman/httperror"
@@ -151,6 +153,9 @@ func (s *v1AuthProviderStore) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// List the enabled auth providers in a stable order for users to pick one at login.
+	slices.SortFunc(list, func(a, b *apiv3.AuthConfig) int { return strings.Compare(a.Name, b.Name) })
+
 	response := struct {
 		Data []map[string]any `json:"data"`
 	}{}
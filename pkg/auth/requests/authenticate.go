@@ -92,6 +92,7 @@ type tokenAuthenticator struct {
 	extTokenStore       *exttokenstore.SystemStore
 	keyGetter           publicKeyGetter
 	oidcClientCache     mgmtcontrollers.OIDCClientCache
+	sessionPolicies     tokens.SessionPolicyVerifier
 }
 
 // ToAuthMiddleware converts an Authenticator to an auth.Middleware.
@@ -136,8 +137,9 @@ func NewAuthenticator(ctx context.Context, clusterRouter ClusterRouter, mgmtCtx
 		refreshUser: func(userID string, force bool) {
 			go providerRefresher.TriggerUserRefresh(userID, force)
 		},
-		now:           time.Now,
-		extTokenStore: extTokenStore,
+		now:             time.Now,
+		extTokenStore:   extTokenStore,
+		sessionPolicies: tokens.NewSessionPolicyResolver(mgmtCtx.Wrangler),
 	}
 
 	if features.OIDCProvider.Enabled() {
@@ -202,7 +204,7 @@ func (a *tokenAuthenticator) Authenticate(req *http.Request) (*AuthenticatorResp
 		return nil, errors.Wrap(ErrMustAuthenticate, "user is not enabled")
 	}
 
-	var groups []string
+	var groups, groupPrincipals []string
 	hitProvider := false
 	if attribs != nil {
 		authp := token.GetAuthProvider()
@@ -213,6 +215,7 @@ func (a *tokenAuthenticator) Authenticate(req *http.Request) (*AuthenticatorResp
 			for _, principal := range gps.Items {
 				name := strings.TrimPrefix(principal.Name, "local://")
 				groups = append(groups, name)
+				groupPrincipals = append(groupPrincipals, principal.Name)
 			}
 		}
 	}
@@ -223,6 +226,7 @@ func (a *tokenAuthenticator) Authenticate(req *http.Request) (*AuthenticatorResp
 			// TODO This is a short cut for now. Will actually need to lookup groups in future
 			name := strings.TrimPrefix(principal.Name, "local://")
 			groups = append(groups, name)
+			groupPrincipals = append(groupPrincipals, principal.Name)
 		}
 	}
 	groups = append(groups, user.AllAuthenticated, "system:cattle:authenticated")
@@ -404,7 +408,7 @@ func (a *tokenAuthenticator) TokenFromRequest(req *http.Request) (accessor.Token
 			return nil, fmt.Errorf("failed to retrieve auth token, error: %v: %w",
 				err, ErrMustAuthenticate)
 		}
-		if _, err := extVerifyToken(storedToken, extTokenName, tokenKey); err != nil {
+		if _, err := extVerifyToken(storedToken, extTokenName, tokenKey, a.sessionPolicies); err != nil {
 			return nil, fmt.Errorf("failed to verify token: %v: %w", err, ErrMustAuthenticate)
 		}
 
@@ -443,7 +447,7 @@ func (a *tokenAuthenticator) TokenFromRequest(req *http.Request) (accessor.Token
 		}
 	}
 
-	if _, err := tokens.VerifyToken(storedToken, tokenName, tokenKey); err != nil {
+	if _, err := tokens.VerifyToken(storedToken, tokenName, tokenKey, a.sessionPolicies); err != nil {
 		logrus.Debugf("auth: Error verifying token %s: %v", tokenName, err)
 		return nil, errors.Wrapf(ErrMustAuthenticate, "failed to verify token: %v", err)
 	}
@@ -469,7 +473,7 @@ func (a *tokenAuthenticator) validateOIDCClientForToken(claims *authorizationTok
 
 // Given a stored token with hashed key, check if the provided (unhashed) tokenKey matches and is valid.
 // This must match the logic of [tokens.VerifyToken].
-func extVerifyToken(storedToken *ext.Token, tokenName, tokenKey string) (int, error) {
+func extVerifyToken(storedToken *ext.Token, tokenName, tokenKey string, policies tokens.SessionPolicyVerifier) (int, error) {
 	invalidAuthTokenErr := errors.New("invalid token")
 
 	if storedToken == nil || storedToken.ObjectMeta.Name != tokenName {
@@ -493,9 +497,14 @@ func extVerifyToken(storedToken *ext.Token, tokenName, tokenKey string) (int, er
 		return http.StatusGone, errors.New("must authenticate, expired")
 	}
 
-	if tokens.IsIdleExpired(storedToken, time.Now()) {
+	now := time.Now()
+	if tokens.IsIdleExpired(storedToken, now) {
 		return http.StatusGone, errors.New("must authenticate, idle session timeout expired")
 	}
 
+	if policies != nil {
+		return policies.Verify(storedToken, now)
+	}
+
 	return http.StatusOK, nil
 }
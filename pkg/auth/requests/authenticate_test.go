@@ -58,6 +58,12 @@ func (r *fakeUserRefresher) reset() {
 	r.force = false
 }
 
+type fakeSessionPolicyVerifier func(token accessor.TokenAccessor, now time.Time) (int, error)
+
+func (f fakeSessionPolicyVerifier) Verify(token accessor.TokenAccessor, now time.Time) (int, error) {
+	return f(token, now)
+}
+
 type fakeProvider struct {
 	name                       string
 	disabled                   bool
@@ -620,6 +626,48 @@ func TestTokenAuthenticatorAuthenticate(t *testing.T) {
 		require.Nil(t, resp)
 		assert.False(t, userRefresher.called)
 	})
+	t.Run("session timeout policy", func(t *testing.T) {
+		var verified accessor.TokenAccessor
+		authenticator.sessionPolicies = fakeSessionPolicyVerifier(func(token accessor.TokenAccessor, _ time.Time) (int, error) {
+			verified = token
+			return http.StatusOK, nil
+		})
+		defer func() { authenticator.sessionPolicies = nil }()
+
+		userRefresher.reset()
+
+		resp, err := authenticator.Authenticate(req)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		require.NotNil(t, verified)
+		assert.Equal(t, token.Name, verified.GetName())
+	})
+	t.Run("session timeout policy expired", func(t *testing.T) {
+		authenticator.sessionPolicies = fakeSessionPolicyVerifier(func(accessor.TokenAccessor, time.Time) (int, error) {
+			return http.StatusGone, fmt.Errorf("must authenticate, session timeout policy admins expired")
+		})
+		defer func() { authenticator.sessionPolicies = nil }()
+
+		userRefresher.reset()
+
+		resp, err := authenticator.Authenticate(req)
+		require.ErrorIs(t, err, ErrMustAuthenticate)
+		require.Nil(t, resp)
+		assert.False(t, userRefresher.called)
+	})
+	t.Run("error resolving session timeout policies", func(t *testing.T) {
+		authenticator.sessionPolicies = fakeSessionPolicyVerifier(func(accessor.TokenAccessor, time.Time) (int, error) {
+			return http.StatusInternalServerError, fmt.Errorf("unable to resolve session timeout policies")
+		})
+		defer func() { authenticator.sessionPolicies = nil }()
+
+		userRefresher.reset()
+
+		resp, err := authenticator.Authenticate(req)
+		require.ErrorIs(t, err, ErrMustAuthenticate)
+		require.Nil(t, resp)
+		assert.False(t, userRefresher.called)
+	})
 	t.Run("failed to verify token: mismatched", func(t *testing.T) {
 		userRefresher.reset()
 
@@ -0,0 +1,75 @@
+package scim
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	filterRegexp     = regexp.MustCompile(`^\s*([A-Za-z][\w.]*)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*")\s*$`)
+	memberPathRegexp = regexp.MustCompile(`^(?i:members)\s*\[\s*(?i:value)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*")\s*\]$`)
+)
+
+// filter is an equality filter on an attribute, the only filter supported by the SCIM server, and the one identity
+// providers use to look up existing resources before creating them.
+type filter struct {
+	attribute string
+	value     string
+}
+
+// parseFilter parses the filter query parameter of list requests. An empty filter matches every resource.
+func parseFilter(s string, attributes ...string) (*filter, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	match := filterRegexp.FindStringSubmatch(s)
+	if match == nil {
+		return nil, badRequest(scimTypeInvalidFilter, "unsupported filter %q, only the eq operator is supported", s)
+	}
+	value, err := strconv.Unquote(match[2])
+	if err != nil {
+		return nil, badRequest(scimTypeInvalidFilter, "invalid filter value %s", match[2])
+	}
+	for _, attribute := range attributes {
+		if strings.EqualFold(match[1], attribute) {
+			return &filter{attribute: attribute, value: value}, nil
+		}
+	}
+	return nil, badRequest(scimTypeInvalidFilter, "unsupported filter attribute %q", match[1])
+}
+
+// matches returns whether the value of the filtered attribute is the filter value. It is nil-safe.
+func (f *filter) matches(values map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	return values[f.attribute] == f.value
+}
+
+// parseMemberPath returns the member of patch operation paths selecting a single member, such as
+// members[value eq "u-abcde"].
+func parseMemberPath(path string) (string, bool) {
+	match := memberPathRegexp.FindStringSubmatch(strings.TrimSpace(path))
+	if match == nil {
+		return "", false
+	}
+	value, err := strconv.Unquote(match[1])
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// parseBool parses the boolean values of patch operations, which some identity providers send as strings.
+func parseBool(v any) (bool, error) {
+	switch b := v.(type) {
+	case bool:
+		return b, nil
+	case string:
+		if parsed, err := strconv.ParseBool(strings.ToLower(b)); err == nil {
+			return parsed, nil
+		}
+	}
+	return false, badRequest(scimTypeInvalidValue, "invalid boolean value %v", v)
+}
@@ -0,0 +1,553 @@
+package scim
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+type groupResource struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []member `json:"members,omitempty"`
+	Meta        *meta    `json:"meta,omitempty"`
+}
+
+func (g *groupResource) memberIDs() sets.Set[string] {
+	ids := sets.New[string]()
+	for _, m := range g.Members {
+		ids.Insert(m.Value)
+	}
+	return ids
+}
+
+func (h *Handler) toGroupResource(provider string, group *v3.Group, members []v3.GroupMember) groupResource {
+	res := groupResource{
+		Schemas:     []string{groupSchema},
+		ID:          group.Name,
+		ExternalID:  group.Annotations[externalIDKey(provider)],
+		DisplayName: group.DisplayName,
+		Meta: &meta{
+			ResourceType: "Group",
+			Created:      group.CreationTimestamp.UTC().Format(time.RFC3339),
+			Location:     location(provider, "Groups", group.Name),
+		},
+	}
+	for _, groupMember := range members {
+		userID := groupMember.Labels[userLabel]
+		m := member{Value: userID, Ref: location(provider, "Users", userID)}
+		if user, err := h.userCache.Get(userID); err == nil {
+			m.Display = user.DisplayName
+		}
+		res.Members = append(res.Members, m)
+	}
+	slices.SortFunc(res.Members, func(a, b member) int { return strings.Compare(a.Value, b.Value) })
+	return res
+}
+
+// providerSelector selects the groups and group members provisioned for the auth provider with the given labels.
+func providerSelector(provider string, set labels.Set) metav1.ListOptions {
+	selector := labels.Set{providerLabel: provider}
+	maps.Copy(selector, set)
+	return metav1.ListOptions{LabelSelector: selector.String()}
+}
+
+// group returns the group with the given id, if it was provisioned for the auth provider.
+func (h *Handler) group(provider, id string) (*v3.Group, error) {
+	group, err := h.groups.Get(id, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || (err == nil && group.Labels[providerLabel] != provider) {
+		return nil, notFound("group %s not found", id)
+	}
+	return group, err
+}
+
+func (h *Handler) members(provider, groupName string) ([]v3.GroupMember, error) {
+	list, err := h.groupMembers.List(providerSelector(provider, labels.Set{groupLabel: groupName}))
+	if err != nil {
+		return nil, fmt.Errorf("listing members of group %s: %w", groupName, err)
+	}
+	return list.Items, nil
+}
+
+// checkDisplayName returns a conflict error if another group of the auth provider has the given display name, since
+// the display name is the group principal.
+func (h *Handler) checkDisplayName(provider, groupName, displayName string) error {
+	if displayName == "" {
+		return badRequest(scimTypeInvalidValue, "displayName is required")
+	}
+	list, err := h.groups.List(providerSelector(provider, nil))
+	if err != nil {
+		return fmt.Errorf("listing groups: %w", err)
+	}
+	for _, group := range list.Items {
+		if group.Name != groupName && group.DisplayName == displayName {
+			return conflict("group %s already exists", displayName)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) listGroups(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	f, err := parseFilter(req.URL.Query().Get("filter"), "displayName", "externalId")
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	excludeMembers := slices.ContainsFunc(strings.Split(req.URL.Query().Get("excludedAttributes"), ","), func(attribute string) bool {
+		return strings.EqualFold(strings.TrimSpace(attribute), "members")
+	})
+
+	list, err := h.groups.List(providerSelector(provider, nil))
+	if err != nil {
+		writeInternalError(rw, err)
+		return
+	}
+	groups := list.Items
+	slices.SortFunc(groups, func(a, b v3.Group) int { return strings.Compare(a.Name, b.Name) })
+
+	var resources []any
+	for i := range groups {
+		group := &groups[i]
+		if !f.matches(map[string]string{"displayName": group.DisplayName, "externalId": group.Annotations[externalIDKey(provider)]}) {
+			continue
+		}
+		var members []v3.GroupMember
+		if !excludeMembers {
+			if members, err = h.members(provider, group.Name); err != nil {
+				writeInternalError(rw, err)
+				return
+			}
+		}
+		resources = append(resources, h.toGroupResource(provider, group, members))
+	}
+	writeJSON(rw, http.StatusOK, page(req, resources))
+}
+
+func (h *Handler) getGroup(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	group, err := h.group(provider, mux.Vars(req)["id"])
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	h.writeGroup(rw, http.StatusOK, provider, group)
+}
+
+func (h *Handler) writeGroup(rw http.ResponseWriter, status int, provider string, group *v3.Group) {
+	members, err := h.members(provider, group.Name)
+	if err != nil {
+		writeInternalError(rw, err)
+		return
+	}
+	writeJSON(rw, status, h.toGroupResource(provider, group, members))
+}
+
+func (h *Handler) createGroup(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	var res groupResource
+	if err := decode(req, &res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	if err := h.checkDisplayName(provider, "", res.DisplayName); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	users, err := h.memberUsers(provider, res.memberIDs())
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+
+	group := &v3.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "grp-",
+			Labels:       map[string]string{providerLabel: provider},
+		},
+		DisplayName: res.DisplayName,
+	}
+	if res.ExternalID != "" {
+		group.Annotations = map[string]string{externalIDKey(provider): res.ExternalID}
+	}
+	group, err = h.groups.Create(group)
+	if err != nil {
+		writeInternalError(rw, err)
+		return
+	}
+	if err := h.setMembers(provider, group, users, false); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	h.writeGroup(rw, http.StatusCreated, provider, group)
+}
+
+func (h *Handler) replaceGroup(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	group, err := h.group(provider, mux.Vars(req)["id"])
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	var res groupResource
+	if err := decode(req, &res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	if group, err = h.applyGroup(provider, group, res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	h.writeGroup(rw, http.StatusOK, provider, group)
+}
+
+func (h *Handler) patchGroup(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	group, err := h.group(provider, mux.Vars(req)["id"])
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	var patch patchRequest
+	if err := decode(req, &patch); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	members, err := h.members(provider, group.Name)
+	if err != nil {
+		writeInternalError(rw, err)
+		return
+	}
+
+	res := h.toGroupResource(provider, group, members)
+	for _, op := range patch.Operations {
+		if err := patchGroupResource(&res, op); err != nil {
+			writeErr(rw, err)
+			return
+		}
+	}
+	if group, err = h.applyGroup(provider, group, res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	h.writeGroup(rw, http.StatusOK, provider, group)
+}
+
+func (h *Handler) deleteGroup(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	group, err := h.group(provider, mux.Vars(req)["id"])
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	if err := h.groups.Delete(group.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		writeInternalError(rw, err)
+		return
+	}
+	if err := h.setMembers(provider, group, nil, false); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// patchGroupResource applies a patch operation to a group, including the operations identity providers use to add
+// and remove single members.
+func patchGroupResource(res *groupResource, op patchOperation) error {
+	path := strings.TrimSpace(op.Path)
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+		if path == "" {
+			values, ok := op.Value.(map[string]any)
+			if !ok {
+				return badRequest(scimTypeInvalidValue, "invalid value for %s operation without path", op.Op)
+			}
+			for attribute, value := range values {
+				if err := setGroupAttribute(res, op.Op, attribute, value); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return setGroupAttribute(res, op.Op, path, op.Value)
+	case "remove":
+		if userID, ok := parseMemberPath(path); ok {
+			res.Members = slices.DeleteFunc(res.Members, func(m member) bool { return m.Value == userID })
+			return nil
+		}
+		switch strings.ToLower(path) {
+		case "":
+			return badRequest(scimTypeInvalidPath, "remove operations require a path")
+		case "members":
+			if op.Value == nil {
+				res.Members = nil
+				return nil
+			}
+			removed, err := parseMembers(op.Value)
+			if err != nil {
+				return err
+			}
+			res.Members = slices.DeleteFunc(res.Members, func(m member) bool { return removed.Has(m.Value) })
+		case "externalid":
+			res.ExternalID = ""
+		case "displayname":
+			return badRequest(scimTypeMutability, "attribute displayName can't be removed")
+		}
+		return nil
+	}
+	return badRequest(scimTypeInvalidValue, "unsupported patch operation %q", op.Op)
+}
+
+func setGroupAttribute(res *groupResource, op, attribute string, value any) error {
+	switch strings.ToLower(attribute) {
+	case "displayname":
+		res.DisplayName = fmt.Sprint(value)
+	case "externalid":
+		res.ExternalID = fmt.Sprint(value)
+	case "members":
+		ids, err := parseMembers(value)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(op, "replace") {
+			res.Members = nil
+		}
+		ids = ids.Difference(res.memberIDs())
+		for _, id := range sets.List(ids) {
+			res.Members = append(res.Members, member{Value: id})
+		}
+	}
+	return nil
+}
+
+// parseMembers returns the user ids of the members of a patch operation value.
+func parseMembers(value any) (sets.Set[string], error) {
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+	ids := sets.New[string]()
+	for _, v := range values {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, badRequest(scimTypeInvalidValue, "invalid member %v", v)
+		}
+		id, ok := m["value"].(string)
+		if !ok || id == "" {
+			return nil, badRequest(scimTypeInvalidValue, "invalid member %v", v)
+		}
+		ids.Insert(id)
+	}
+	return ids, nil
+}
+
+// applyGroup updates the group and its members from its SCIM resource.
+func (h *Handler) applyGroup(provider string, group *v3.Group, res groupResource) (*v3.Group, error) {
+	users, err := h.memberUsers(provider, res.memberIDs())
+	if err != nil {
+		return nil, err
+	}
+
+	updated := group.DeepCopy()
+	if res.DisplayName != group.DisplayName {
+		if err := h.checkDisplayName(provider, group.Name, res.DisplayName); err != nil {
+			return nil, err
+		}
+		updated.DisplayName = res.DisplayName
+	}
+	if res.ExternalID != "" {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[externalIDKey(provider)] = res.ExternalID
+	} else {
+		delete(updated.Annotations, externalIDKey(provider))
+	}
+
+	renamed := updated.DisplayName != group.DisplayName
+	if !equality.Semantic.DeepEqual(group, updated) {
+		if group, err = h.groups.Update(updated); err != nil {
+			return nil, fmt.Errorf("updating group %s: %w", updated.Name, err)
+		}
+	}
+	return group, h.setMembers(provider, group, users, renamed)
+}
+
+// memberUsers returns the users of the auth provider with the given ids, so that members are validated before the
+// group is changed.
+func (h *Handler) memberUsers(provider string, userIDs sets.Set[string]) (map[string]*v3.User, error) {
+	users := map[string]*v3.User{}
+	for _, userID := range sets.List(userIDs) {
+		user, err := h.user(provider, userID)
+		var httpErr *httpError
+		if errors.As(err, &httpErr) {
+			return nil, badRequest(scimTypeInvalidValue, "member %s is not a user of auth provider %s", userID, provider)
+		}
+		if err != nil {
+			return nil, err
+		}
+		users[userID] = user
+	}
+	return users, nil
+}
+
+// setMembers makes the given users the members of the group and updates the group principals of the users whose
+// membership changed, or of every member if the group was renamed.
+func (h *Handler) setMembers(provider string, group *v3.Group, users map[string]*v3.User, renamed bool) error {
+	members, err := h.members(provider, group.Name)
+	if err != nil {
+		return err
+	}
+
+	current := sets.New[string]()
+	changed := sets.New[string]()
+	for _, groupMember := range members {
+		userID := groupMember.Labels[userLabel]
+		current.Insert(userID)
+		if _, ok := users[userID]; ok {
+			if renamed {
+				changed.Insert(userID)
+			}
+			continue
+		}
+		if err := h.groupMembers.Delete(groupMember.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("removing user %s from group %s: %w", userID, group.Name, err)
+		}
+		changed.Insert(userID)
+	}
+
+	for userID, user := range users {
+		if current.Has(userID) {
+			continue
+		}
+		_, err = h.groupMembers.Create(&v3.GroupMember{
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: "grpm-",
+				Labels: map[string]string{
+					providerLabel: provider,
+					groupLabel:    group.Name,
+					userLabel:     userID,
+				},
+			},
+			GroupName:   group.Name,
+			PrincipalID: principalForProvider(user, provider),
+		})
+		if err != nil {
+			return fmt.Errorf("adding user %s to group %s: %w", userID, group.Name, err)
+		}
+		changed.Insert(userID)
+	}
+
+	for _, userID := range sets.List(changed) {
+		if err := h.syncGroupPrincipals(provider, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeMemberships removes the user from every group of the auth provider.
+func (h *Handler) removeMemberships(provider, userID string) error {
+	list, err := h.groupMembers.List(providerSelector(provider, labels.Set{userLabel: userID}))
+	if err != nil {
+		return fmt.Errorf("listing groups of user %s: %w", userID, err)
+	}
+	for _, groupMember := range list.Items {
+		if err := h.groupMembers.Delete(groupMember.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("removing user %s from group %s: %w", userID, groupMember.GroupName, err)
+		}
+	}
+	return h.syncGroupPrincipals(provider, userID)
+}
+
+// groupPrincipals returns the principals of the groups of the auth provider the user is a member of.
+func (h *Handler) groupPrincipals(provider, userID string) ([]v3.Principal, error) {
+	list, err := h.groupMembers.List(providerSelector(provider, labels.Set{userLabel: userID}))
+	if err != nil {
+		return nil, fmt.Errorf("listing groups of user %s: %w", userID, err)
+	}
+	var principals []v3.Principal
+	for _, groupMember := range list.Items {
+		group, err := h.groups.Get(groupMember.GroupName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting group %s: %w", groupMember.GroupName, err)
+		}
+		principals = append(principals, v3.Principal{
+			ObjectMeta:    metav1.ObjectMeta{Name: groupPrincipalID(provider, group.DisplayName)},
+			DisplayName:   group.DisplayName,
+			Provider:      provider,
+			PrincipalType: "group",
+			MemberOf:      true,
+		})
+	}
+	slices.SortFunc(principals, func(a, b v3.Principal) int { return strings.Compare(a.Name, b.Name) })
+	return principals, nil
+}
+
+// syncGroupPrincipals sets the group principals of the user to the groups it is a member of, or to none if the user is
+// disabled.
+func (h *Handler) syncGroupPrincipals(provider, userID string) error {
+	user, err := h.userCache.Get(userID)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting user %s: %w", userID, err)
+	}
+	var principals []v3.Principal
+	if user.GetEnabled() {
+		if principals, err = h.groupPrincipals(provider, userID); err != nil {
+			return err
+		}
+	}
+	return h.setGroupPrincipals(provider, userID, principals)
+}
+
+// setGroupPrincipals sets the group principals of the auth provider in the attributes of the user, which is how the
+// groups of users are resolved when authorizing their requests.
+func (h *Handler) setGroupPrincipals(provider, userID string, principals []v3.Principal) error {
+	attribute, needCreate, err := h.userManager.EnsureAndGetUserAttribute(userID)
+	if err != nil {
+		return fmt.Errorf("getting attributes of user %s: %w", userID, err)
+	}
+	if equality.Semantic.DeepEqual(attribute.GroupPrincipals[provider].Items, principals) && !needCreate {
+		return nil
+	}
+	if attribute.GroupPrincipals == nil {
+		attribute.GroupPrincipals = map[string]v3.Principals{}
+	}
+	attribute.GroupPrincipals[provider] = v3.Principals{Items: principals}
+
+	if needCreate {
+		_, err = h.userAttributes.Create(attribute)
+	} else {
+		_, err = h.userAttributes.Update(attribute)
+	}
+	if err != nil {
+		return fmt.Errorf("updating attributes of user %s: %w", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+// Package scim implements a SCIM 2.0 server (RFC 7643 and RFC 7644) that lets an identity provider push the users and
+// groups of an auth provider to Rancher. Unlike the periodic auth refresh, a user deactivated in the identity provider
+// is disabled immediately, its tokens are revoked and its group principals are removed.
+//
+// The server of an auth provider is served at /v1-scim/<provider> once the auth provider is enabled and the bearer
+// token of the SCIM client is stored in the "token" key of the secret cattle-global-data/scim-token-<provider>.
+// A SCIM user is mapped to the Rancher user that logged in through the auth provider with its userName, or else to the
+// user principal of the auth provider whose login name is its userName, as the principals of most auth providers are
+// built from immutable ids rather than login names. Users that can't be resolved are rejected rather than provisioned
+// with a principal no login would match. Groups are identified by the principal <provider>_group://<displayName>.
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gorilla/mux"
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	exttokenstore "github.com/rancher/rancher/pkg/ext/stores/tokens"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/rancher/rancher/pkg/user"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PathPrefix is the path prefix of the SCIM servers of all auth providers.
+	PathPrefix = "/v1-scim"
+
+	tokenSecretPrefix = "scim-token-"
+	tokenSecretKey    = "token"
+	localProvider     = "local"
+	contentType       = "application/scim+json"
+
+	userSchema            = "urn:ietf:params:scim:schemas:core:2.0:User"
+	groupSchema           = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	listResponseSchema    = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	patchOpSchema         = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	errorSchema           = "urn:ietf:params:scim:api:messages:2.0:Error"
+	spConfigSchema        = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	resourceTypeSchema    = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	externalIDAnnotation  = "scim.cattle.io/external-id"
+	userNameAnnotation    = "scim.cattle.io/user-name"
+	providerLabel         = "scim.cattle.io/provider"
+	groupLabel            = "scim.cattle.io/group"
+	userLabel             = "scim.cattle.io/user"
+	maxResults            = 200
+	scimTypeInvalidValue  = "invalidValue"
+	scimTypeInvalidFilter = "invalidFilter"
+	scimTypeInvalidPath   = "invalidPath"
+	scimTypeUniqueness    = "uniqueness"
+	scimTypeMutability    = "mutability"
+)
+
+type tokenRevoker interface {
+	RevokeUserTokens(userID string) error
+}
+
+type extTokenStore interface {
+	ListForUser(userName string) (*ext.TokenList, error)
+	Disable(name string) error
+	Delete(name string, options *metav1.DeleteOptions) error
+}
+
+// Handler serves the SCIM servers of the auth providers.
+type Handler struct {
+	userManager     user.Manager
+	users           mgmtcontrollers.UserClient
+	userCache       mgmtcontrollers.UserCache
+	userAttributes  mgmtcontrollers.UserAttributeClient
+	attributeCache  mgmtcontrollers.UserAttributeCache
+	groups          mgmtcontrollers.GroupClient
+	groupMembers    mgmtcontrollers.GroupMemberClient
+	authConfigCache mgmtcontrollers.AuthConfigCache
+	secretCache     corecontrollers.SecretCache
+	tokens          tokenRevoker
+	extTokens       extTokenStore
+	// searchPrincipals searches the user principals of an auth provider.
+	searchPrincipals func(provider, name string) ([]v3.Principal, error)
+}
+
+// NewHandler returns the handler of the SCIM servers, to be served at PathPrefix.
+func NewHandler(scaledContext *config.ScaledContext) http.Handler {
+	w := scaledContext.Wrangler
+	h := &Handler{
+		userManager:     scaledContext.UserManager,
+		users:           w.Mgmt.User(),
+		userCache:       w.Mgmt.User().Cache(),
+		userAttributes:  w.Mgmt.UserAttribute(),
+		attributeCache:  w.Mgmt.UserAttribute().Cache(),
+		groups:          w.Mgmt.Group(),
+		groupMembers:    w.Mgmt.GroupMember(),
+		authConfigCache: w.Mgmt.AuthConfig().Cache(),
+		secretCache:     w.Core.Secret().Cache(),
+		tokens:          tokens.NewManager(w),
+		extTokens:       exttokenstore.NewSystemFromWrangler(w),
+		searchPrincipals: func(provider, name string) ([]v3.Principal, error) {
+			p, err := providers.GetProvider(provider)
+			if err != nil {
+				return nil, err
+			}
+			// The SCIM client has no token of the auth provider, the search runs with the credentials of the provider.
+			return p.SearchPrincipals(name, common.UserPrincipalType, &v3.Token{})
+		},
+	}
+	return h.router()
+}
+
+func (h *Handler) router() *mux.Router {
+	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, http.StatusNotFound, "", "resource not found")
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		writeError(rw, http.StatusMethodNotAllowed, "", "method not allowed")
+	})
+
+	api := r.PathPrefix(PathPrefix + "/{provider}").Subrouter()
+	api.Use(h.authenticate)
+	api.Methods(http.MethodGet).Path("/ServiceProviderConfig").HandlerFunc(h.serviceProviderConfig)
+	api.Methods(http.MethodGet).Path("/ResourceTypes").HandlerFunc(h.resourceTypes)
+	api.Methods(http.MethodGet).Path("/Users").HandlerFunc(h.listUsers)
+	api.Methods(http.MethodPost).Path("/Users").HandlerFunc(h.createUser)
+	api.Methods(http.MethodGet).Path("/Users/{id}").HandlerFunc(h.getUser)
+	api.Methods(http.MethodPut).Path("/Users/{id}").HandlerFunc(h.replaceUser)
+	api.Methods(http.MethodPatch).Path("/Users/{id}").HandlerFunc(h.patchUser)
+	api.Methods(http.MethodDelete).Path("/Users/{id}").HandlerFunc(h.deleteUser)
+	api.Methods(http.MethodGet).Path("/Groups").HandlerFunc(h.listGroups)
+	api.Methods(http.MethodPost).Path("/Groups").HandlerFunc(h.createGroup)
+	api.Methods(http.MethodGet).Path("/Groups/{id}").HandlerFunc(h.getGroup)
+	api.Methods(http.MethodPut).Path("/Groups/{id}").HandlerFunc(h.replaceGroup)
+	api.Methods(http.MethodPatch).Path("/Groups/{id}").HandlerFunc(h.patchGroup)
+	api.Methods(http.MethodDelete).Path("/Groups/{id}").HandlerFunc(h.deleteGroup)
+	return r
+}
+
+// authenticate only lets requests through if the auth provider is enabled and they carry the bearer token of its SCIM
+// client.
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		provider := mux.Vars(req)["provider"]
+		authConfig, err := h.authConfigCache.Get(provider)
+		if err != nil && !apierrors.IsNotFound(err) {
+			writeInternalError(rw, err)
+			return
+		}
+		if authConfig == nil || !authConfig.Enabled || provider == localProvider {
+			writeError(rw, http.StatusNotFound, "", fmt.Sprintf("auth provider %s is not enabled", provider))
+			return
+		}
+
+		secret, err := h.secretCache.Get(common.SecretsNamespace, tokenSecretPrefix+provider)
+		if err != nil && !apierrors.IsNotFound(err) {
+			writeInternalError(rw, err)
+			return
+		}
+		token, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if secret == nil || len(secret.Data[tokenSecretKey]) == 0 || !found ||
+			subtle.ConstantTimeCompare([]byte(token), secret.Data[tokenSecretKey]) != 1 {
+			rw.Header().Set("WWW-Authenticate", `Bearer realm="SCIM"`)
+			writeError(rw, http.StatusUnauthorized, "", "invalid bearer token")
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// httpError is an error reported to the SCIM client with the given status.
+type httpError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (e *httpError) Error() string {
+	return e.detail
+}
+
+func badRequest(scimType, format string, args ...any) error {
+	return &httpError{status: http.StatusBadRequest, scimType: scimType, detail: fmt.Sprintf(format, args...)}
+}
+
+func notFound(format string, args ...any) error {
+	return &httpError{status: http.StatusNotFound, detail: fmt.Sprintf(format, args...)}
+}
+
+func conflict(format string, args ...any) error {
+	return &httpError{status: http.StatusConflict, scimType: scimTypeUniqueness, detail: fmt.Sprintf(format, args...)}
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v any) {
+	rw.Header().Set("Content-Type", contentType)
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		logrus.Debugf("[scim] Failed to write response: %v", err)
+	}
+}
+
+func writeError(rw http.ResponseWriter, status int, scimType, detail string) {
+	writeJSON(rw, status, scimError{
+		Schemas:  []string{errorSchema},
+		Status:   fmt.Sprint(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func writeInternalError(rw http.ResponseWriter, err error) {
+	logrus.Errorf("[scim] %v", err)
+	writeError(rw, http.StatusInternalServerError, "", http.StatusText(http.StatusInternalServerError))
+}
+
+// writeErr writes err to the SCIM client, reporting errors other than httpErrors as internal errors.
+func writeErr(rw http.ResponseWriter, err error) {
+	if httpErr, ok := err.(*httpError); ok {
+		writeError(rw, httpErr.status, httpErr.scimType, httpErr.detail)
+		return
+	}
+	writeInternalError(rw, err)
+}
+
+func decode(req *http.Request, v any) error {
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil {
+		return badRequest(scimTypeInvalidValue, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// location returns the URL of a resource of the SCIM server of an auth provider.
+func location(provider, resource, id string) string {
+	return strings.TrimSuffix(settings.ServerURL.Get(), "/") + PathPrefix + "/" + provider + "/" + resource + "/" + id
+}
+
+// page returns the resources of the page requested by the startIndex and count query parameters, which are 1-based.
+func page(req *http.Request, resources []any) listResponse {
+	startIndex := 1
+	if _, err := fmt.Sscan(req.URL.Query().Get("startIndex"), &startIndex); err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count := maxResults
+	if _, err := fmt.Sscan(req.URL.Query().Get("count"), &count); err != nil || count < 0 || count > maxResults {
+		count = maxResults
+	}
+
+	result := listResponse{
+		Schemas:      []string{listResponseSchema},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		Resources:    []any{},
+	}
+	if startIndex <= len(resources) {
+		end := min(startIndex-1+count, len(resources))
+		result.Resources = resources[startIndex-1 : end]
+	}
+	result.ItemsPerPage = len(result.Resources)
+	return result
+}
+
+// userPrincipalPrefix returns the prefix of the user principals of the auth provider.
+func userPrincipalPrefix(provider string) string {
+	return provider + "_user://"
+}
+
+func groupPrincipalID(provider, displayName string) string {
+	return provider + "_group://" + displayName
+}
+
+// principalForProvider returns the principal of the user for the given auth provider, or "" if it has none.
+func principalForProvider(user *v3.User, provider string) string {
+	prefix := userPrincipalPrefix(provider)
+	index := slices.IndexFunc(user.PrincipalIDs, func(id string) bool { return strings.HasPrefix(id, prefix) })
+	if index < 0 {
+		return ""
+	}
+	return user.PrincipalIDs[index]
+}
+
+func (h *Handler) serviceProviderConfig(rw http.ResponseWriter, req *http.Request) {
+	writeJSON(rw, http.StatusOK, map[string]any{
+		"schemas":        []string{spConfigSchema},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxResults},
+		"changePassword": map[string]any{"supported": false},
+		"sort":           map[string]any{"supported": false},
+		"etag":           map[string]any{"supported": false},
+		"authenticationSchemes": []any{
+			map[string]any{
+				"type":        "oauthbearertoken",
+				"name":        "OAuth Bearer Token",
+				"description": "Authentication with the bearer token stored in the secret " + common.SecretsNamespace + "/" + tokenSecretPrefix + mux.Vars(req)["provider"],
+			},
+		},
+	})
+}
+
+func (h *Handler) resourceTypes(rw http.ResponseWriter, req *http.Request) {
+	writeJSON(rw, http.StatusOK, page(req, []any{
+		map[string]any{
+			"schemas":  []string{resourceTypeSchema},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   userSchema,
+		},
+		map[string]any{
+			"schemas":  []string{resourceTypeSchema},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   groupSchema,
+		},
+	}))
+}
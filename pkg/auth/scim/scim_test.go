@@ -0,0 +1,504 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/user/mocks"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+)
+
+const testToken = "scim-secret"
+
+type fakeRevoker struct {
+	revoked []string
+	err     error
+}
+
+func (f *fakeRevoker) RevokeUserTokens(userID string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.revoked = append(f.revoked, userID)
+	return nil
+}
+
+type fakeExtTokens struct {
+	tokens   []ext.Token
+	disabled []string
+	deleted  []string
+}
+
+func (f *fakeExtTokens) ListForUser(userName string) (*ext.TokenList, error) {
+	list := &ext.TokenList{}
+	for _, token := range f.tokens {
+		if token.Spec.UserID == userName {
+			list.Items = append(list.Items, token)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeExtTokens) Disable(name string) error {
+	f.disabled = append(f.disabled, name)
+	return nil
+}
+
+func (f *fakeExtTokens) Delete(name string, _ *metav1.DeleteOptions) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+// testServer is a SCIM server of the okta auth provider backed by in-memory users, attributes, groups, members and
+// principals of the auth provider.
+type testServer struct {
+	handler      http.Handler
+	users        map[string]*v3.User
+	attributes   map[string]*v3.UserAttribute
+	groups       map[string]*v3.Group
+	groupMembers map[string]*v3.GroupMember
+	principals   []v3.Principal
+	revoker      *fakeRevoker
+	extTokens    *fakeExtTokens
+}
+
+func newTestServer(t *testing.T, users ...*v3.User) *testServer {
+	ctrl := gomock.NewController(t)
+	s := &testServer{
+		users:        map[string]*v3.User{},
+		attributes:   map[string]*v3.UserAttribute{},
+		groups:       map[string]*v3.Group{},
+		groupMembers: map[string]*v3.GroupMember{},
+		revoker:      &fakeRevoker{},
+		extTokens:    &fakeExtTokens{},
+	}
+	for _, user := range users {
+		s.users[user.Name] = user
+	}
+	notFound := func(resource, name string) error {
+		return apierrors.NewNotFound(v3.Resource(resource), name)
+	}
+	matches := func(opts metav1.ListOptions, objLabels map[string]string) bool {
+		selector, err := labels.Parse(opts.LabelSelector)
+		require.NoError(t, err)
+		return selector.Matches(labels.Set(objLabels))
+	}
+	generated := 0
+	generateName := func(meta *metav1.ObjectMeta) {
+		generated++
+		meta.Name = fmt.Sprintf("%s%d", meta.GenerateName, generated)
+	}
+
+	authConfigCache := fake.NewMockNonNamespacedCacheInterface[*v3.AuthConfig](ctrl)
+	authConfigCache.EXPECT().Get(gomock.Any()).DoAndReturn(func(name string) (*v3.AuthConfig, error) {
+		switch name {
+		case "okta":
+			return &v3.AuthConfig{ObjectMeta: metav1.ObjectMeta{Name: name}, Enabled: true}, nil
+		case "github":
+			return &v3.AuthConfig{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+		}
+		return nil, notFound("authconfigs", name)
+	}).AnyTimes()
+
+	secretCache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+	secretCache.EXPECT().Get("cattle-global-data", "scim-token-okta").Return(&corev1.Secret{
+		Data: map[string][]byte{"token": []byte(testToken)},
+	}, nil).AnyTimes()
+
+	userCache := fake.NewMockNonNamespacedCacheInterface[*v3.User](ctrl)
+	userCache.EXPECT().Get(gomock.Any()).DoAndReturn(func(name string) (*v3.User, error) {
+		if user, ok := s.users[name]; ok {
+			return user.DeepCopy(), nil
+		}
+		return nil, notFound("users", name)
+	}).AnyTimes()
+	userCache.EXPECT().List(gomock.Any()).DoAndReturn(func(labels.Selector) ([]*v3.User, error) {
+		var users []*v3.User
+		for _, user := range s.users {
+			users = append(users, user.DeepCopy())
+		}
+		return users, nil
+	}).AnyTimes()
+
+	userClient := fake.NewMockNonNamespacedClientInterface[*v3.User, *v3.UserList](ctrl)
+	userClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(user *v3.User) (*v3.User, error) {
+		s.users[user.Name] = user.DeepCopy()
+		return user, nil
+	}).AnyTimes()
+
+	userManager := mocks.NewMockManager(ctrl)
+	userManager.EXPECT().GetUserByPrincipalID(gomock.Any()).DoAndReturn(func(principalID string) (*v3.User, error) {
+		for _, user := range s.users {
+			for _, id := range user.PrincipalIDs {
+				if id == principalID {
+					return user.DeepCopy(), nil
+				}
+			}
+		}
+		return nil, nil
+	}).AnyTimes()
+	userManager.EXPECT().EnsureUser(gomock.Any(), gomock.Any()).DoAndReturn(func(principalID, displayName string) (*v3.User, error) {
+		user := &v3.User{
+			ObjectMeta:   metav1.ObjectMeta{Name: fmt.Sprintf("u-%d", len(s.users)+1)},
+			DisplayName:  displayName,
+			PrincipalIDs: []string{principalID},
+		}
+		s.users[user.Name] = user
+		return user.DeepCopy(), nil
+	}).AnyTimes()
+	userManager.EXPECT().EnsureAndGetUserAttribute(gomock.Any()).DoAndReturn(func(userID string) (*v3.UserAttribute, bool, error) {
+		if attribute, ok := s.attributes[userID]; ok {
+			return attribute.DeepCopy(), false, nil
+		}
+		return &v3.UserAttribute{ObjectMeta: metav1.ObjectMeta{Name: userID}}, true, nil
+	}).AnyTimes()
+
+	attributeCache := fake.NewMockNonNamespacedCacheInterface[*v3.UserAttribute](ctrl)
+	attributeCache.EXPECT().List(gomock.Any()).DoAndReturn(func(labels.Selector) ([]*v3.UserAttribute, error) {
+		var attributes []*v3.UserAttribute
+		for _, attribute := range s.attributes {
+			attributes = append(attributes, attribute.DeepCopy())
+		}
+		return attributes, nil
+	}).AnyTimes()
+
+	userAttributes := fake.NewMockNonNamespacedClientInterface[*v3.UserAttribute, *v3.UserAttributeList](ctrl)
+	saveAttribute := func(attribute *v3.UserAttribute) (*v3.UserAttribute, error) {
+		s.attributes[attribute.Name] = attribute.DeepCopy()
+		return attribute, nil
+	}
+	userAttributes.EXPECT().Create(gomock.Any()).DoAndReturn(saveAttribute).AnyTimes()
+	userAttributes.EXPECT().Update(gomock.Any()).DoAndReturn(saveAttribute).AnyTimes()
+
+	groups := fake.NewMockNonNamespacedClientInterface[*v3.Group, *v3.GroupList](ctrl)
+	groups.EXPECT().Create(gomock.Any()).DoAndReturn(func(group *v3.Group) (*v3.Group, error) {
+		group = group.DeepCopy()
+		generateName(&group.ObjectMeta)
+		s.groups[group.Name] = group
+		return group.DeepCopy(), nil
+	}).AnyTimes()
+	groups.EXPECT().Update(gomock.Any()).DoAndReturn(func(group *v3.Group) (*v3.Group, error) {
+		s.groups[group.Name] = group.DeepCopy()
+		return group, nil
+	}).AnyTimes()
+	groups.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(func(name string, _ metav1.GetOptions) (*v3.Group, error) {
+		if group, ok := s.groups[name]; ok {
+			return group.DeepCopy(), nil
+		}
+		return nil, notFound("groups", name)
+	}).AnyTimes()
+	groups.EXPECT().List(gomock.Any()).DoAndReturn(func(opts metav1.ListOptions) (*v3.GroupList, error) {
+		list := &v3.GroupList{}
+		for _, group := range s.groups {
+			if matches(opts, group.Labels) {
+				list.Items = append(list.Items, *group.DeepCopy())
+			}
+		}
+		return list, nil
+	}).AnyTimes()
+	groups.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(func(name string, _ *metav1.DeleteOptions) error {
+		delete(s.groups, name)
+		return nil
+	}).AnyTimes()
+
+	groupMembers := fake.NewMockNonNamespacedClientInterface[*v3.GroupMember, *v3.GroupMemberList](ctrl)
+	groupMembers.EXPECT().Create(gomock.Any()).DoAndReturn(func(groupMember *v3.GroupMember) (*v3.GroupMember, error) {
+		groupMember = groupMember.DeepCopy()
+		generateName(&groupMember.ObjectMeta)
+		s.groupMembers[groupMember.Name] = groupMember
+		return groupMember.DeepCopy(), nil
+	}).AnyTimes()
+	groupMembers.EXPECT().List(gomock.Any()).DoAndReturn(func(opts metav1.ListOptions) (*v3.GroupMemberList, error) {
+		list := &v3.GroupMemberList{}
+		for _, groupMember := range s.groupMembers {
+			if matches(opts, groupMember.Labels) {
+				list.Items = append(list.Items, *groupMember.DeepCopy())
+			}
+		}
+		return list, nil
+	}).AnyTimes()
+	groupMembers.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(func(name string, _ *metav1.DeleteOptions) error {
+		delete(s.groupMembers, name)
+		return nil
+	}).AnyTimes()
+
+	h := &Handler{
+		userManager:     userManager,
+		users:           userClient,
+		userCache:       userCache,
+		userAttributes:  userAttributes,
+		attributeCache:  attributeCache,
+		groups:          groups,
+		groupMembers:    groupMembers,
+		authConfigCache: authConfigCache,
+		secretCache:     secretCache,
+		tokens:          s.revoker,
+		extTokens:       s.extTokens,
+		searchPrincipals: func(provider, name string) ([]v3.Principal, error) {
+			assert.Equal(t, "okta", provider)
+			var principals []v3.Principal
+			for _, principal := range s.principals {
+				if strings.HasPrefix(principal.LoginName, name) {
+					principals = append(principals, principal)
+				}
+			}
+			return principals, nil
+		},
+	}
+	s.handler = h.router()
+	return s
+}
+
+func (s *testServer) do(t *testing.T, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+
+	var res map[string]any
+	if rec.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	}
+	return rec.Code, res
+}
+
+func oktaUser(name, userName string) *v3.User {
+	return &v3.User{
+		ObjectMeta:   metav1.ObjectMeta{Name: name},
+		DisplayName:  userName,
+		PrincipalIDs: []string{"okta_user://" + userName, "local://" + name},
+		Enabled:      ptr.To(true),
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{name: "valid token", path: "/v1-scim/okta/ServiceProviderConfig", token: testToken, status: http.StatusOK},
+		{name: "invalid token", path: "/v1-scim/okta/ServiceProviderConfig", token: "invalid", status: http.StatusUnauthorized},
+		{name: "missing token", path: "/v1-scim/okta/Users", status: http.StatusUnauthorized},
+		{name: "disabled provider", path: "/v1-scim/github/Users", token: testToken, status: http.StatusNotFound},
+		{name: "unknown provider", path: "/v1-scim/keycloak/Users", token: testToken, status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			s.handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	s := newTestServer(t, &v3.User{
+		ObjectMeta:   metav1.ObjectMeta{Name: "u-existing"},
+		PrincipalIDs: []string{"okta_user://00u2", "local://u-existing"},
+		Enabled:      ptr.To(true),
+	})
+	s.attributes["u-existing"] = &v3.UserAttribute{
+		ObjectMeta:      metav1.ObjectMeta{Name: "u-existing"},
+		ExtraByProvider: map[string]map[string][]string{"okta": {"username": {"Jane@example.com"}}},
+	}
+	s.principals = []v3.Principal{
+		{ObjectMeta: metav1.ObjectMeta{Name: "okta_user://00u1"}, LoginName: "john@example.com", PrincipalType: "user", Provider: "okta"},
+		{ObjectMeta: metav1.ObjectMeta{Name: "okta_user://00u3"}, LoginName: "john@example.com.au", PrincipalType: "user", Provider: "okta"},
+	}
+
+	status, res := s.do(t, http.MethodPost, "/v1-scim/okta/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "john@example.com",
+		"externalId": "00u1",
+		"name": {"givenName": "John", "familyName": "Doe"},
+		"active": true
+	}`)
+	require.Equal(t, http.StatusCreated, status, res)
+	id := res["id"].(string)
+	assert.Equal(t, "john@example.com", res["userName"])
+	assert.Equal(t, "John Doe", res["displayName"])
+	assert.Equal(t, "00u1", s.users[id].Annotations["scim.cattle.io/external-id-okta"])
+	assert.Equal(t, []string{"okta_user://00u1"}, s.users[id].PrincipalIDs)
+	assert.True(t, s.users[id].GetEnabled())
+
+	status, res = s.do(t, http.MethodPost, "/v1-scim/okta/Users", `{"userName": "john@example.com"}`)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, "uniqueness", res["scimType"])
+
+	// users that logged in before being provisioned are mapped by their login name
+	status, res = s.do(t, http.MethodPost, "/v1-scim/okta/Users", `{"userName": "jane@example.com"}`)
+	require.Equal(t, http.StatusCreated, status, res)
+	assert.Equal(t, "u-existing", res["id"])
+	assert.Len(t, s.users, 2)
+
+	status, res = s.do(t, http.MethodPost, "/v1-scim/okta/Users", `{"userName": "unknown@example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "invalidValue", res["scimType"])
+	assert.Len(t, s.users, 2)
+
+	status, res = s.do(t, http.MethodGet, `/v1-scim/okta/Users?filter=userName+eq+"jane@example.com"`, "")
+	require.Equal(t, http.StatusOK, status)
+	assert.EqualValues(t, 1, res["totalResults"])
+
+	status, _ = s.do(t, http.MethodPatch, "/v1-scim/okta/Users/u-existing", `{
+		"Operations": [{"op": "replace", "path": "userName", "value": "janet@example.com"}]
+	}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestDeactivateUser(t *testing.T) {
+	s := newTestServer(t, oktaUser("u-jane", "jane@example.com"))
+	s.groups["grp-1"] = &v3.Group{
+		ObjectMeta:  metav1.ObjectMeta{Name: "grp-1", Labels: map[string]string{providerLabel: "okta"}},
+		DisplayName: "developers",
+	}
+	status, _ := s.do(t, http.MethodPatch, "/v1-scim/okta/Groups/grp-1", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "add", "path": "members", "value": [{"value": "u-jane"}]}]
+	}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, s.attributes["u-jane"].GroupPrincipals["okta"].Items, 1)
+	s.extTokens.tokens = []ext.Token{
+		{ObjectMeta: metav1.ObjectMeta{Name: "token-login"}, Spec: ext.TokenSpec{UserID: "u-jane", Kind: "session"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "token-api"}, Spec: ext.TokenSpec{UserID: "u-jane"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "token-other"}, Spec: ext.TokenSpec{UserID: "u-john"}},
+	}
+
+	status, res := s.do(t, http.MethodPatch, "/v1-scim/okta/Users/u-jane", `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "Replace", "value": {"active": "False"}}]
+	}`)
+	require.Equal(t, http.StatusOK, status, res)
+	assert.Equal(t, false, res["active"])
+	assert.False(t, s.users["u-jane"].GetEnabled())
+	assert.Equal(t, []string{"u-jane"}, s.revoker.revoked)
+	assert.Equal(t, []string{"token-login"}, s.extTokens.deleted)
+	assert.Equal(t, []string{"token-api"}, s.extTokens.disabled)
+	assert.Empty(t, s.attributes["u-jane"].GroupPrincipals["okta"].Items)
+
+	status, _ = s.do(t, http.MethodPatch, "/v1-scim/okta/Users/u-jane", `{
+		"Operations": [{"op": "replace", "path": "active", "value": true}]
+	}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.True(t, s.users["u-jane"].GetEnabled())
+	assert.Len(t, s.attributes["u-jane"].GroupPrincipals["okta"].Items, 1)
+	assert.Len(t, s.revoker.revoked, 1)
+
+	status, _ = s.do(t, http.MethodDelete, "/v1-scim/okta/Users/u-jane", "")
+	require.Equal(t, http.StatusNoContent, status)
+	assert.False(t, s.users["u-jane"].GetEnabled())
+	assert.Empty(t, s.groupMembers)
+}
+
+func TestDeactivateUserRetry(t *testing.T) {
+	s := newTestServer(t, oktaUser("u-jane", "jane@example.com"))
+	s.revoker.err = errors.New("unavailable")
+	deactivate := `{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "path": "active", "value": false}]
+	}`
+
+	status, _ := s.do(t, http.MethodPatch, "/v1-scim/okta/Users/u-jane", deactivate)
+	require.Equal(t, http.StatusInternalServerError, status)
+	// the user is disabled before its tokens are revoked
+	assert.False(t, s.users["u-jane"].GetEnabled())
+	assert.Empty(t, s.revoker.revoked)
+
+	// the retry of the IdP revokes the tokens of the user already disabled
+	s.revoker.err = nil
+	status, _ = s.do(t, http.MethodPatch, "/v1-scim/okta/Users/u-jane", deactivate)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []string{"u-jane"}, s.revoker.revoked)
+}
+
+func TestGroupMembership(t *testing.T) {
+	s := newTestServer(t, oktaUser("u-jane", "jane@example.com"), oktaUser("u-john", "john@example.com"))
+
+	status, res := s.do(t, http.MethodPost, "/v1-scim/okta/Groups", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:Group"],
+		"displayName": "developers",
+		"members": [{"value": "u-jane"}, {"value": "u-john"}]
+	}`)
+	require.Equal(t, http.StatusCreated, status, res)
+	id := res["id"].(string)
+	assert.Len(t, res["members"], 2)
+	assert.Equal(t, []v3.Principal{{
+		ObjectMeta:    metav1.ObjectMeta{Name: "okta_group://developers"},
+		DisplayName:   "developers",
+		Provider:      "okta",
+		PrincipalType: "group",
+		MemberOf:      true,
+	}}, s.attributes["u-john"].GroupPrincipals["okta"].Items)
+
+	status, _ = s.do(t, http.MethodPost, "/v1-scim/okta/Groups", `{"displayName": "developers"}`)
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, _ = s.do(t, http.MethodPatch, "/v1-scim/okta/Groups/"+id, `{
+		"Operations": [
+			{"op": "remove", "path": "members[value eq \"u-john\"]"},
+			{"op": "replace", "path": "displayName", "value": "engineering"}
+		]
+	}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, s.attributes["u-john"].GroupPrincipals["okta"].Items)
+	require.Len(t, s.attributes["u-jane"].GroupPrincipals["okta"].Items, 1)
+	assert.Equal(t, "okta_group://engineering", s.attributes["u-jane"].GroupPrincipals["okta"].Items[0].Name)
+
+	status, _ = s.do(t, http.MethodPut, "/v1-scim/okta/Groups/"+id, `{"displayName": "engineering", "members": [{"value": "u-unknown"}]}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = s.do(t, http.MethodDelete, "/v1-scim/okta/Groups/"+id, "")
+	require.Equal(t, http.StatusNoContent, status)
+	assert.Empty(t, s.groups)
+	assert.Empty(t, s.attributes["u-jane"].GroupPrincipals["okta"].Items)
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		want    *filter
+		wantErr bool
+	}{
+		{filter: "", want: nil},
+		{filter: `userName eq "jane@example.com"`, want: &filter{attribute: "userName", value: "jane@example.com"}},
+		{filter: `username EQ "jane \"j\" doe"`, want: &filter{attribute: "userName", value: `jane "j" doe`}},
+		{filter: `externalId eq "00u1"`, want: &filter{attribute: "externalId", value: "00u1"}},
+		{filter: `userName co "jane"`, wantErr: true},
+		{filter: `emails eq "jane@example.com"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			got, err := parseFilter(tt.filter, "userName", "externalId")
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
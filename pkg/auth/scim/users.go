@@ -0,0 +1,422 @@
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+)
+
+type name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type userResource struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"externalId,omitempty"`
+	UserName    string   `json:"userName"`
+	Name        *name    `json:"name,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+	Meta        *meta    `json:"meta,omitempty"`
+}
+
+// displayName returns the display name of the user, falling back to its name when the identity provider doesn't send
+// one.
+func (u *userResource) displayName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+		if full := strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName); full != "" {
+			return full
+		}
+	}
+	return u.UserName
+}
+
+type patchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+func externalIDKey(provider string) string {
+	return externalIDAnnotation + "-" + provider
+}
+
+func userNameKey(provider string) string {
+	return userNameAnnotation + "-" + provider
+}
+
+// userName returns the userName the user was provisioned with, falling back to the id of its principal for users that
+// were not provisioned by the SCIM client.
+func userName(provider string, user *v3.User) string {
+	if name := user.Annotations[userNameKey(provider)]; name != "" {
+		return name
+	}
+	return strings.TrimPrefix(principalForProvider(user, provider), userPrincipalPrefix(provider))
+}
+
+func toUserResource(provider string, user *v3.User) userResource {
+	return userResource{
+		Schemas:     []string{userSchema},
+		ID:          user.Name,
+		ExternalID:  user.Annotations[externalIDKey(provider)],
+		UserName:    userName(provider, user),
+		DisplayName: user.DisplayName,
+		Active:      ptr.To(user.GetEnabled()),
+		Meta: &meta{
+			ResourceType: "User",
+			Created:      user.CreationTimestamp.UTC().Format(time.RFC3339),
+			Location:     location(provider, "Users", user.Name),
+		},
+	}
+}
+
+// user returns the user with the given id, if it has a principal of the auth provider.
+func (h *Handler) user(provider, id string) (*v3.User, error) {
+	user, err := h.userCache.Get(id)
+	if apierrors.IsNotFound(err) || (err == nil && principalForProvider(user, provider) == "") {
+		return nil, notFound("user %s not found", id)
+	}
+	return user, err
+}
+
+func (h *Handler) listUsers(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	f, err := parseFilter(req.URL.Query().Get("filter"), "userName", "externalId")
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	users, err := h.userCache.List(labels.Everything())
+	if err != nil {
+		writeInternalError(rw, err)
+		return
+	}
+	slices.SortFunc(users, func(a, b *v3.User) int { return strings.Compare(a.Name, b.Name) })
+
+	var resources []any
+	for _, user := range users {
+		if principalForProvider(user, provider) == "" {
+			continue
+		}
+		res := toUserResource(provider, user)
+		if f.matches(map[string]string{"userName": res.UserName, "externalId": res.ExternalID}) {
+			resources = append(resources, res)
+		}
+	}
+	writeJSON(rw, http.StatusOK, page(req, resources))
+}
+
+func (h *Handler) getUser(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	user, err := h.user(provider, mux.Vars(req)["id"])
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, toUserResource(provider, user))
+}
+
+func (h *Handler) createUser(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	var res userResource
+	if err := decode(req, &res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	if res.UserName == "" {
+		writeError(rw, http.StatusBadRequest, scimTypeInvalidValue, "userName is required")
+		return
+	}
+
+	users, err := h.userCache.List(labels.Everything())
+	if err != nil {
+		writeInternalError(rw, err)
+		return
+	}
+	if slices.ContainsFunc(users, func(user *v3.User) bool {
+		return strings.EqualFold(user.Annotations[userNameKey(provider)], res.UserName)
+	}) {
+		writeErr(rw, conflict("user %s already exists", res.UserName))
+		return
+	}
+
+	user, err := h.resolveUser(provider, res)
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	if user, err = h.applyUser(provider, user, res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	writeJSON(rw, http.StatusCreated, toUserResource(provider, user))
+}
+
+// resolveUser returns the user that logged in through the auth provider with the userName of the resource, or else the
+// user of the user principal of the auth provider whose login name is the userName, which is created if needed.
+func (h *Handler) resolveUser(provider string, res userResource) (*v3.User, error) {
+	attributes, err := h.attributeCache.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, attribute := range attributes {
+		if !slices.ContainsFunc(attribute.ExtraByProvider[provider][common.UserAttributeUserName], func(name string) bool {
+			return strings.EqualFold(name, res.UserName)
+		}) {
+			continue
+		}
+		user, err := h.userCache.Get(attribute.Name)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if principalForProvider(user, provider) != "" {
+			return user, nil
+		}
+	}
+
+	principals, err := h.searchPrincipals(provider, res.UserName)
+	if err != nil {
+		return nil, fmt.Errorf("searching user %s in auth provider %s: %w", res.UserName, provider, err)
+	}
+	var principalIDs []string
+	for _, principal := range principals {
+		if principal.Provider == provider && principal.PrincipalType == common.UserPrincipalType &&
+			strings.EqualFold(principal.LoginName, res.UserName) {
+			principalIDs = append(principalIDs, principal.Name)
+		}
+	}
+	if len(principalIDs) != 1 {
+		return nil, badRequest(scimTypeInvalidValue, "userName %s doesn't match exactly one user of auth provider %s", res.UserName, provider)
+	}
+	return h.userManager.EnsureUser(principalIDs[0], res.displayName())
+}
+
+func (h *Handler) replaceUser(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	user, err := h.user(provider, mux.Vars(req)["id"])
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	var res userResource
+	if err := decode(req, &res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	if user, err = h.applyUser(provider, user, res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, toUserResource(provider, user))
+}
+
+func (h *Handler) patchUser(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	user, err := h.user(provider, mux.Vars(req)["id"])
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	var patch patchRequest
+	if err := decode(req, &patch); err != nil {
+		writeErr(rw, err)
+		return
+	}
+
+	res := toUserResource(provider, user)
+	for _, op := range patch.Operations {
+		if err := patchUserResource(&res, op); err != nil {
+			writeErr(rw, err)
+			return
+		}
+	}
+	if user, err = h.applyUser(provider, user, res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	writeJSON(rw, http.StatusOK, toUserResource(provider, user))
+}
+
+// deleteUser deactivates the user and removes it from the groups of the auth provider. Users are never deleted, so
+// that the resources they own and their role bindings are kept if the identity provider provisions them again.
+func (h *Handler) deleteUser(rw http.ResponseWriter, req *http.Request) {
+	provider := mux.Vars(req)["provider"]
+	user, err := h.user(provider, mux.Vars(req)["id"])
+	if err != nil {
+		writeErr(rw, err)
+		return
+	}
+	res := toUserResource(provider, user)
+	res.Active = ptr.To(false)
+	if _, err := h.applyUser(provider, user, res); err != nil {
+		writeErr(rw, err)
+		return
+	}
+	if err := h.removeMemberships(provider, user.Name); err != nil {
+		writeInternalError(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// patchUserResource applies a patch operation to the attributes of a user that Rancher stores. Operations on other
+// attributes, such as emails, are ignored.
+func patchUserResource(res *userResource, op patchOperation) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+		if op.Path != "" {
+			return setUserAttribute(res, op.Path, op.Value)
+		}
+		values, ok := op.Value.(map[string]any)
+		if !ok {
+			return badRequest(scimTypeInvalidValue, "invalid value for %s operation without path", op.Op)
+		}
+		for attribute, value := range values {
+			if err := setUserAttribute(res, attribute, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "remove":
+		switch strings.ToLower(op.Path) {
+		case "":
+			return badRequest(scimTypeInvalidPath, "remove operations require a path")
+		case "externalid":
+			res.ExternalID = ""
+		case "displayname":
+			res.DisplayName = ""
+		case "active", "username":
+			return badRequest(scimTypeMutability, "attribute %s can't be removed", op.Path)
+		}
+		return nil
+	}
+	return badRequest(scimTypeInvalidValue, "unsupported patch operation %q", op.Op)
+}
+
+func setUserAttribute(res *userResource, attribute string, value any) error {
+	switch strings.ToLower(attribute) {
+	case "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		res.Active = &active
+	case "displayname":
+		res.DisplayName = fmt.Sprint(value)
+	case "externalid":
+		res.ExternalID = fmt.Sprint(value)
+	case "username":
+		res.UserName = fmt.Sprint(value)
+	}
+	return nil
+}
+
+// applyUser updates the user from its SCIM resource. Inactive users are disabled, their tokens are revoked and their
+// group principals are removed, while reactivated users get the group principals of their groups back.
+func (h *Handler) applyUser(provider string, user *v3.User, res userResource) (*v3.User, error) {
+	provisioned := user.Annotations[userNameKey(provider)]
+	if res.UserName != "" && provisioned != "" && !strings.EqualFold(res.UserName, provisioned) {
+		return nil, badRequest(scimTypeMutability, "userName of user %s can't be changed", user.Name)
+	}
+
+	wasActive := user.GetEnabled()
+	updated := user.DeepCopy()
+	if res.UserName != "" && provisioned == "" {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[userNameKey(provider)] = res.UserName
+	}
+	if displayName := res.displayName(); displayName != "" {
+		updated.DisplayName = displayName
+	}
+	if res.ExternalID != "" {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[externalIDKey(provider)] = res.ExternalID
+	} else {
+		delete(updated.Annotations, externalIDKey(provider))
+	}
+	if res.Active != nil {
+		updated.Enabled = ptr.To(*res.Active)
+	}
+
+	if !equality.Semantic.DeepEqual(user, updated) {
+		var err error
+		if user, err = h.users.Update(updated); err != nil {
+			return nil, fmt.Errorf("updating user %s: %w", updated.Name, err)
+		}
+	}
+
+	// the tokens and group principals of inactive users are removed whenever they are updated, not only when they are
+	// deactivated, so that the retry of the IdP completes a revocation which failed after the user was disabled
+	switch active := user.GetEnabled(); {
+	case !active:
+		if err := h.revokeTokens(user.Name); err != nil {
+			return nil, err
+		}
+		if err := h.setGroupPrincipals(provider, user.Name, nil); err != nil {
+			return nil, err
+		}
+	case !wasActive:
+		if err := h.syncGroupPrincipals(provider, user.Name); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// revokeTokens revokes the tokens of the user, both the v3 tokens and the ext tokens: login tokens are deleted, while
+// other tokens are disabled.
+func (h *Handler) revokeTokens(userID string) error {
+	if err := h.tokens.RevokeUserTokens(userID); err != nil {
+		return fmt.Errorf("revoking tokens of user %s: %w", userID, err)
+	}
+
+	extTokens, err := h.extTokens.ListForUser(userID)
+	if err != nil {
+		return fmt.Errorf("listing ext tokens of user %s: %w", userID, err)
+	}
+	for _, token := range extTokens.Items {
+		if token.GetIsDerived() {
+			if !token.GetIsEnabled() {
+				continue
+			}
+			err = h.extTokens.Disable(token.Name)
+		} else {
+			err = h.extTokens.Delete(token.Name, &metav1.DeleteOptions{})
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("revoking ext token %s of user %s: %w", token.Name, userID, err)
+		}
+	}
+	return nil
+}
@@ -16,7 +16,9 @@
 	"github.com/rancher/rancher/pkg/auth/providers/publicapi"
 	"github.com/rancher/rancher/pkg/auth/providers/saml"
 	"github.com/rancher/rancher/pkg/auth/requests"
+	"github.com/rancher/rancher/pkg/auth/scim"
 	"github.com/rancher/rancher/pkg/auth/tokens"
+	exttokenstore "github.com/rancher/rancher/pkg/ext/stores/tokens"
 	"github.com/rancher/rancher/pkg/features"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/rancher/rancher/pkg/utils"
@@ -101,6 +103,7 @@ func newAPIManagement(ctx context.Context, scaledContext *config.ScaledContext,
 		root.PathPrefix("/v3-public").Handler(limitingHandler(v3PublicAPI)) // Deprecated. Use /v1-public instead.
 	}
 	root.PathPrefix("/v1-public").Handler(limitingHandler(v1PublicAPI))
+	root.PathPrefix(scim.PathPrefix).Handler(limitingHandler(scim.NewHandler(scaledContext)))
 	root.NotFoundHandler = privateAPI
 
 	return func(next http.Handler) http.Handler {
@@ -143,13 +146,14 @@ func (s *Server) OnLeader(ctx context.Context) error {
 	management := &config.ManagementContext{
 		Management: s.scaledContext.Management,
 		Core:       s.scaledContext.Core,
+		Wrangler:   s.scaledContext.Wrangler,
 	}
 
 	if err := data.AuthConfigs(management); err != nil {
 		return fmt.Errorf("failed to add authconfig data: %v", err)
 	}
 
-	tokens.StartPurgeDaemon(ctx, management)
+	tokens.StartPurgeDaemon(ctx, management, exttokenstore.NewSystemFromWrangler(s.scaledContext.Wrangler))
 	providerrefresh.StartRefreshDaemon(s.scaledContext, management)
 	logrus.Infof("Steve auth startup complete")
 	return nil
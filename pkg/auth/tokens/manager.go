@@ -31,6 +31,7 @@
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
 )
 
 // TODO Cleanup error logging. If error is being returned, use errors.wrap to return and dont log here
@@ -68,6 +69,7 @@ func NewManager(wContext *wrangler.Context) *Manager {
 		userCache:    wContext.Mgmt.User().Cache(),
 		secrets:      wContext.Core.Secret(),
 		secretCache:  wContext.Core.Secret().Cache(),
+		policies:     NewSessionPolicyResolver(wContext),
 	}
 }
 
@@ -78,6 +80,7 @@ type Manager struct {
 	userCache    ctrlv3.UserCache
 	secrets      ctrlv1.SecretClient
 	secretCache  ctrlv1.SecretCache
+	policies     SessionPolicyVerifier
 }
 
 func userPrincipalIndexer(obj any) ([]string, error) {
@@ -178,7 +181,7 @@ func (m *Manager) GetToken(tokenAuthValue string) (*apiv3.Token, int, error) {
 		storedToken = objs[0].(*apiv3.Token)
 	}
 
-	if code, err := VerifyToken(storedToken, tokenName, tokenKey); err != nil {
+	if code, err := VerifyToken(storedToken, tokenName, tokenKey, m.policies); err != nil {
 		return nil, code, err
 	}
 
@@ -222,6 +225,34 @@ func (m *Manager) DeleteTokenByName(tokenName string) (int, error) {
 	return 0, nil
 }
 
+// RevokeUserTokens deletes the login tokens of the given user and disables its derived tokens, such as API tokens and
+// kubeconfig tokens, so that a deprovisioned user loses access immediately rather than on the next auth refresh.
+func (m *Manager) RevokeUserTokens(userID string) error {
+	set := labels.Set(map[string]string{UserIDLabel: userID})
+	tokenList, err := m.tokens.List(metav1.ListOptions{LabelSelector: set.AsSelector().String()})
+	if err != nil {
+		return fmt.Errorf("error listing tokens of user %s: %w", userID, err)
+	}
+
+	for _, token := range tokenList.Items {
+		if !token.IsDerived {
+			if err := m.tokens.Delete(token.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error deleting token %s: %w", token.Name, err)
+			}
+			continue
+		}
+		if token.Enabled != nil && !*token.Enabled {
+			continue
+		}
+		token := token.DeepCopy()
+		token.Enabled = ptr.To(false)
+		if _, err := m.updateToken(token); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error disabling token %s: %w", token.Name, err)
+		}
+	}
+	return nil
+}
+
 // getToken will get the token by ID
 func (m *Manager) getTokenByID(tokenAuthValue string, tokenID string) (apiv3.Token, int, error) {
 	logrus.Debug("GET Token Invoked")
@@ -779,3 +810,19 @@ func GetKubeconfigDefaultTokenTTLInMilliSeconds() (*int64, error) {
 	ttlMilli := tokenTTL.Milliseconds()
 	return &ttlMilli, nil
 }
+
+// GetKubeconfigExecTokenTTLInMilliSeconds will return the default TTL for the tokens obtained by the exec credential
+// plugin of kubeconfigs that don't embed tokens.
+func GetKubeconfigExecTokenTTLInMilliSeconds() (*int64, error) {
+	execTokenTTL, err := ParseTokenTTL(settings.KubeconfigExecTokenTTLMinutes.Get())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse setting '%s': %w", settings.KubeconfigExecTokenTTLMinutes.Name, err)
+	}
+
+	tokenTTL, err := ClampToMaxTTL(execTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate token ttl: %w", err)
+	}
+	ttlMilli := tokenTTL.Milliseconds()
+	return &ttlMilli, nil
+}
@@ -18,6 +18,7 @@
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
 )
 
 var (
@@ -291,8 +292,28 @@ func mustGenerateRandomToken(t *testing.T) string {
 	return tok
 }
 
+func TestRevokeUserTokens(t *testing.T) {
+	tokenClient := &fakeTokenClient{
+		list: []apiv3.Token{
+			{ObjectMeta: metav1.ObjectMeta{Name: "token-login"}, UserID: "u-abcde"},
+			{ObjectMeta: metav1.ObjectMeta{Name: "token-api"}, UserID: "u-abcde", IsDerived: true},
+			{ObjectMeta: metav1.ObjectMeta{Name: "token-disabled"}, UserID: "u-abcde", IsDerived: true, Enabled: ptr.To(false)},
+		},
+	}
+	tokenManager := Manager{tokens: tokenClient}
+
+	assert.NoError(t, tokenManager.RevokeUserTokens("u-abcde"))
+	assert.Equal(t, []string{"token-login"}, tokenClient.deleted)
+	if assert.Len(t, tokenClient.updated, 1) {
+		assert.Equal(t, "token-api", tokenClient.updated[0].Name)
+		assert.False(t, *tokenClient.updated[0].Enabled)
+	}
+}
+
 type fakeTokenClient struct {
-	list []apiv3.Token
+	list    []apiv3.Token
+	deleted []string
+	updated []*apiv3.Token
 }
 
 func (f *fakeTokenClient) Create(o *apiv3.Token) (*apiv3.Token, error) {
@@ -304,6 +325,7 @@ func (f *fakeTokenClient) Get(name string, options metav1.GetOptions) (*apiv3.To
 }
 
 func (f *fakeTokenClient) Delete(name string, options *metav1.DeleteOptions) error {
+	f.deleted = append(f.deleted, name)
 	return nil
 }
 
@@ -311,8 +333,9 @@ func (f *fakeTokenClient) List(opts metav1.ListOptions) (*apiv3.TokenList, error
 	return &apiv3.TokenList{Items: f.list}, nil
 }
 
-func (f *fakeTokenClient) Update(*apiv3.Token) (*apiv3.Token, error) {
-	return nil, nil
+func (f *fakeTokenClient) Update(o *apiv3.Token) (*apiv3.Token, error) {
+	f.updated = append(f.updated, o)
+	return o, nil
 }
 
 // TODO: This should be moved to norman _or_ a test package in rancher.
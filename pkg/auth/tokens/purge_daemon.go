@@ -2,11 +2,16 @@
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/rancher/norman/clientbase"
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/accessor"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/notifications"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,12 +21,23 @@
 
 const intervalSeconds int64 = 3600
 
-func StartPurgeDaemon(ctx context.Context, mgmt *config.ManagementContext) {
+// expiryNotice is how long before an API key expires its owner is notified.
+const expiryNotice = 7 * 24 * time.Hour
+
+// ExtTokenLister lists the ext tokens of all users.
+type ExtTokenLister interface {
+	ListAll() (*ext.TokenList, error)
+}
+
+func StartPurgeDaemon(ctx context.Context, mgmt *config.ManagementContext, extTokens ExtTokenLister) {
 	p := &purger{
 		tokenLister:      mgmt.Management.Tokens("").Controller().Lister(),
 		tokens:           mgmt.Management.Tokens(""),
 		samlTokensLister: mgmt.Management.SamlTokens("").Controller().Lister(),
 		samlTokens:       mgmt.Management.SamlTokens(""),
+		extTokens:        extTokens,
+		publisher:        notifications.NewPublisher(mgmt.Wrangler.Mgmt.RancherUserNotification()),
+		now:              time.Now,
 	}
 	go wait.JitterUntil(p.purge, time.Duration(intervalSeconds)*time.Second, .1, true, ctx.Done())
 }
@@ -31,6 +47,9 @@ type purger struct {
 	tokens           v3.TokenInterface
 	samlTokens       v3.SamlTokenInterface
 	samlTokensLister v3.SamlTokenLister
+	extTokens        ExtTokenLister
+	publisher        *notifications.Publisher
+	now              func() time.Time
 }
 
 func (p *purger) purge() {
@@ -48,12 +67,27 @@ func (p *purger) purge() {
 				continue
 			}
 			count++
+			continue
 		}
+		p.notifyExpiring(token, token.Description, token.TTLMillis)
 	}
 	if count > 0 {
 		logrus.Infof("Purged %v expired tokens", count)
 	}
 
+	// the owners of ext tokens are notified as well before their tokens expire
+	extTokens, err := p.extTokens.ListAll()
+	if err != nil {
+		logrus.Errorf("Error listing ext tokens during purge: %v", err)
+	} else {
+		for i := range extTokens.Items {
+			token := &extTokens.Items[i]
+			if !IsExpired(token) {
+				p.notifyExpiring(token, token.Spec.Description, token.Spec.TTL)
+			}
+		}
+	}
+
 	// saml tokens store encrypted token for login request from rancher cli
 	samlTokens, err := p.samlTokensLister.List(namespace.GlobalNamespace, labels.Everything())
 	if err != nil {
@@ -76,3 +110,32 @@ func (p *purger) purge() {
 		logrus.Infof("Purged %v saml tokens", count)
 	}
 }
+
+// notifyExpiring publishes the event of an API key expiring soon.
+func (p *purger) notifyExpiring(token accessor.TokenAccessor, description string, ttlMillis int64) {
+	if !token.GetIsDerived() || ttlMillis <= 0 {
+		return
+	}
+	creationTime := token.GetCreationTime()
+	expiresAt := creationTime.Add(time.Duration(ttlMillis) * time.Millisecond)
+	if expiresAt.Sub(p.now()) > expiryNotice {
+		return
+	}
+	// ext tokens are named like the norman ones, their key is prefixed as in their bearer tokens
+	key := token.GetName()
+	if _, ok := token.(*ext.Token); ok {
+		key = "ext/" + key
+	}
+	message := fmt.Sprintf("API key %s of user %s will expire on %s", key, token.GetUserID(), expiresAt.UTC().Format(time.RFC3339))
+	if description != "" {
+		message = fmt.Sprintf("API key %s (%s) of user %s will expire on %s", key, description, token.GetUserID(), expiresAt.UTC().Format(time.RFC3339))
+	}
+	p.publisher.PublishOnce(notifications.Event{
+		Type:     apiv3.NotificationEventTokenExpiring,
+		Severity: apiv3.NotificationSeverityWarning,
+		Key:      key,
+		Object:   key,
+		UserName: token.GetUserID(),
+		Message:  message,
+	})
+}
@@ -0,0 +1,97 @@
+package tokens
+
+import (
+	"testing"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/notifications"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNotifyExpiring(t *testing.T) {
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-24 * time.Hour))
+	week := (7 * 24 * time.Hour).Milliseconds()
+
+	tests := []struct {
+		name        string
+		token       func() (*apiv3.Token, *ext.Token)
+		wantObjects []string
+	}{
+		{
+			name: "expiring tokens",
+			token: func() (*apiv3.Token, *ext.Token) {
+				return &apiv3.Token{
+					ObjectMeta: metav1.ObjectMeta{Name: "token-abcde", CreationTimestamp: created},
+					UserID:     "u-owner",
+					IsDerived:  true,
+					TTLMillis:  week,
+				}, &ext.Token{
+					ObjectMeta: metav1.ObjectMeta{Name: "token-abcde", CreationTimestamp: created},
+					Spec:       ext.TokenSpec{UserID: "u-owner", TTL: week},
+				}
+			},
+			wantObjects: []string{"token-abcde", "ext/token-abcde"},
+		},
+		{
+			name: "tokens expiring later",
+			token: func() (*apiv3.Token, *ext.Token) {
+				return &apiv3.Token{
+					ObjectMeta: metav1.ObjectMeta{Name: "token-abcde", CreationTimestamp: created},
+					UserID:     "u-owner",
+					IsDerived:  true,
+					TTLMillis:  2 * week,
+				}, &ext.Token{
+					ObjectMeta: metav1.ObjectMeta{Name: "token-abcde", CreationTimestamp: created},
+					Spec:       ext.TokenSpec{UserID: "u-owner", TTL: 2 * week},
+				}
+			},
+		},
+		{
+			name: "session tokens",
+			token: func() (*apiv3.Token, *ext.Token) {
+				return &apiv3.Token{
+					ObjectMeta: metav1.ObjectMeta{Name: "token-abcde", CreationTimestamp: created},
+					UserID:     "u-owner",
+					TTLMillis:  week,
+				}, &ext.Token{
+					ObjectMeta: metav1.ObjectMeta{Name: "token-abcde", CreationTimestamp: created},
+					Spec:       ext.TokenSpec{UserID: "u-owner", Kind: "session", TTL: week},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			client := fake.NewMockNonNamespacedClientInterface[*apiv3.RancherUserNotification, *apiv3.RancherUserNotificationList](ctrl)
+			var objects []string
+			client.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(func(name string, _ metav1.GetOptions) (*apiv3.RancherUserNotification, error) {
+				return nil, apierrors.NewNotFound(apiv3.Resource("rancherusernotifications"), name)
+			}).AnyTimes()
+			client.EXPECT().Create(gomock.Any()).DoAndReturn(func(notification *apiv3.RancherUserNotification) (*apiv3.RancherUserNotification, error) {
+				assert.Equal(t, apiv3.NotificationEventTokenExpiring, notification.Event.Type)
+				assert.Equal(t, "u-owner", notification.Event.UserName)
+				objects = append(objects, notification.Event.Object)
+				return notification, nil
+			}).AnyTimes()
+
+			p := &purger{
+				publisher: notifications.NewPublisher(client),
+				now:       func() time.Time { return now },
+			}
+			token, extToken := tt.token()
+			p.notifyExpiring(token, token.Description, token.TTLMillis)
+			p.notifyExpiring(extToken, extToken.Spec.Description, extToken.Spec.TTL)
+
+			assert.Equal(t, tt.wantObjects, objects)
+		})
+	}
+}
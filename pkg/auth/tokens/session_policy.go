@@ -0,0 +1,357 @@
+package tokens
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/accessor"
+	ctrlv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Client types the session timeout policies can be restricted to.
+const (
+	// ClientTypeUISession is the client type of the login session tokens, e.g. those of the UI.
+	ClientTypeUISession = "ui-session"
+	// ClientTypeKubeconfig is the client type of the tokens issued for kubeconfigs.
+	ClientTypeKubeconfig = "kubeconfig"
+	// ClientTypeAPIToken is the client type of all other derived tokens, e.g. API keys.
+	ClientTypeAPIToken = "api-token"
+)
+
+const grbSubjectIndex = "authn.management.cattle.io/grb-subject-index"
+
+// SessionTimeoutPolicy shortens the lifetime of the tokens of the users selected by group principal or global role.
+// The policies are configured as a JSON list in the auth-session-timeout-policies setting, e.g.
+//
+//	[{"name":"admins","globalRoles":["admin"],"idleTimeoutMinutes":15,"absoluteTimeoutMinutes":480}]
+type SessionTimeoutPolicy struct {
+	// Name identifies the policy.
+	Name string `json:"name"`
+	// Groups are the principal IDs of the groups whose members the policy applies to, e.g. okta_group://admins.
+	Groups []string `json:"groups,omitempty"`
+	// GlobalRoles are the names of the global roles whose holders the policy applies to, e.g. admin.
+	GlobalRoles []string `json:"globalRoles,omitempty"`
+	// ClientTypes are the client types of the tokens the policy applies to. The policy applies to all client types if empty.
+	ClientTypes []string `json:"clientTypes,omitempty"`
+	// IdleTimeoutMinutes is the time after which a token expires if it's not used. Zero means no idle timeout.
+	IdleTimeoutMinutes int `json:"idleTimeoutMinutes,omitempty"`
+	// AbsoluteTimeoutMinutes is the time after which a token expires since its creation. Zero means no absolute timeout.
+	AbsoluteTimeoutMinutes int `json:"absoluteTimeoutMinutes,omitempty"`
+}
+
+// ParseSessionTimeoutPolicies parses and validates the session timeout policies. An empty value has no policy.
+func ParseSessionTimeoutPolicies(raw string) ([]SessionTimeoutPolicy, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var policies []SessionTimeoutPolicy
+	if err := json.Unmarshal([]byte(raw), &policies); err != nil {
+		return nil, fmt.Errorf("parsing session timeout policies: %w", err)
+	}
+
+	names := map[string]bool{}
+	for _, policy := range policies {
+		if policy.Name == "" {
+			return nil, fmt.Errorf("session timeout policy without name")
+		}
+		if names[policy.Name] {
+			return nil, fmt.Errorf("duplicate session timeout policy %s", policy.Name)
+		}
+		names[policy.Name] = true
+
+		// The auth-user-session-* settings already apply to everyone.
+		if len(policy.Groups) == 0 && len(policy.GlobalRoles) == 0 {
+			return nil, fmt.Errorf("session timeout policy %s selects neither groups nor global roles", policy.Name)
+		}
+		for _, clientType := range policy.ClientTypes {
+			switch clientType {
+			case ClientTypeUISession, ClientTypeKubeconfig, ClientTypeAPIToken:
+			default:
+				return nil, fmt.Errorf("session timeout policy %s has invalid client type %q", policy.Name, clientType)
+			}
+		}
+		if policy.IdleTimeoutMinutes < 0 || policy.AbsoluteTimeoutMinutes < 0 {
+			return nil, fmt.Errorf("session timeout policy %s has negative timeouts", policy.Name)
+		}
+		if policy.IdleTimeoutMinutes == 0 && policy.AbsoluteTimeoutMinutes == 0 {
+			return nil, fmt.Errorf("session timeout policy %s has no timeout", policy.Name)
+		}
+	}
+
+	return policies, nil
+}
+
+func (p *SessionTimeoutPolicy) matches(clientType string, groupPrincipals, globalRoles []string) bool {
+	if len(p.ClientTypes) > 0 && !slices.Contains(p.ClientTypes, clientType) {
+		return false
+	}
+	for _, group := range p.Groups {
+		if slices.Contains(groupPrincipals, group) {
+			return true
+		}
+	}
+	for _, role := range p.GlobalRoles {
+		if slices.Contains(globalRoles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// SessionPolicy is the combination of the session timeout policies applying to a token.
+// Of all the policies, the shortest timeouts win.
+type SessionPolicy struct {
+	// Policies are the names of the policies applying to the token.
+	Policies []string
+	// ClientType is the client type of the token.
+	ClientType string
+	// IdleTimeout is the shortest idle timeout of the policies, zero if none has one.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout is the shortest absolute timeout of the policies, zero if none has one.
+	AbsoluteTimeout time.Duration
+}
+
+// ExpiresAt returns the time the token expires at under the policy, given its recorded activity.
+// The idle timeout of login sessions counts from the last user activity, and that of other tokens from their last use.
+// It returns the zero time if the policy has no timeout.
+func (p *SessionPolicy) ExpiresAt(token accessor.TokenAccessor) time.Time {
+	var expiresAt time.Time
+	if p.AbsoluteTimeout > 0 {
+		expiresAt = token.GetCreationTime().Add(p.AbsoluteTimeout)
+	}
+
+	if p.IdleTimeout > 0 {
+		lastActive := token.GetCreationTime().Time
+		if seen := token.GetLastActivitySeen(); p.ClientType == ClientTypeUISession && seen != nil {
+			lastActive = seen.Time
+		} else if used := token.GetLastUsedAt(); used != nil {
+			lastActive = used.Time
+		}
+
+		if idleExpiresAt := lastActive.Add(p.IdleTimeout); expiresAt.IsZero() || idleExpiresAt.Before(expiresAt) {
+			expiresAt = idleExpiresAt
+		}
+	}
+
+	return expiresAt
+}
+
+// IsExpired returns true if the token expired under the policy.
+func (p *SessionPolicy) IsExpired(token accessor.TokenAccessor, now time.Time) bool {
+	expiresAt := p.ExpiresAt(token)
+	return !expiresAt.IsZero() && now.After(expiresAt)
+}
+
+// ClientTypeOf returns the client type of a token.
+func ClientTypeOf(token accessor.TokenAccessor) string {
+	if !token.GetIsDerived() {
+		return ClientTypeUISession
+	}
+
+	switch token := token.(type) {
+	case *apiv3.Token:
+		if token.Labels[TokenKindLabel] == KubeconfigResponseType || token.Labels[TokenKubeconfigIDLabel] != "" {
+			return ClientTypeKubeconfig
+		}
+	case *ext.Token:
+		if token.Spec.Kind == KubeconfigResponseType {
+			return ClientTypeKubeconfig
+		}
+	}
+
+	return ClientTypeAPIToken
+}
+
+// SessionPolicyResolver resolves the session timeout policies applying to tokens.
+// The policies are parsed again whenever the setting changes.
+type SessionPolicyResolver struct {
+	grbIndexer         cache.Indexer
+	userAttributeCache ctrlv3.UserAttributeCache
+	getPolicies        func() string
+
+	mu       sync.Mutex
+	raw      string
+	loaded   bool
+	policies []SessionTimeoutPolicy
+	err      error
+}
+
+// SessionPolicyVerifier verifies that a token didn't expire under the session timeout policies applying to it.
+// It is implemented by [SessionPolicyResolver].
+type SessionPolicyVerifier interface {
+	Verify(token accessor.TokenAccessor, now time.Time) (int, error)
+}
+
+// NewSessionPolicyResolver creates a new instance of [SessionPolicyResolver].
+func NewSessionPolicyResolver(wContext *wrangler.Context) *SessionPolicyResolver {
+	grbInformer := wContext.Mgmt.GlobalRoleBinding().Informer()
+	// Deliberately ignore the error if the indexer was already added.
+	_ = grbInformer.AddIndexers(map[string]cache.IndexFunc{grbSubjectIndex: grbSubjectIndexer})
+
+	return &SessionPolicyResolver{
+		grbIndexer:         grbInformer.GetIndexer(),
+		userAttributeCache: wContext.Mgmt.UserAttribute().Cache(),
+		getPolicies:        settings.AuthSessionTimeoutPolicies.Get,
+	}
+}
+
+// grbSubjectIndexer indexes global role bindings by user name and by group principal ID.
+func grbSubjectIndexer(obj interface{}) ([]string, error) {
+	grb, ok := obj.(*apiv3.GlobalRoleBinding)
+	if !ok {
+		return []string{}, nil
+	}
+
+	if grb.GroupPrincipalName != "" {
+		return []string{grb.GroupPrincipalName}, nil
+	}
+	if grb.UserName != "" {
+		return []string{grb.UserName}, nil
+	}
+	return []string{}, nil
+}
+
+// Resolve returns the combination of the policies applying to the token of a user
+// member of the given group principals, or nil if none applies.
+func (r *SessionPolicyResolver) Resolve(token accessor.TokenAccessor, groupPrincipals []string) (*SessionPolicy, error) {
+	policies, err := r.loadPolicies()
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+
+	globalRoles, err := r.globalRoles(token.GetUserID(), groupPrincipals)
+	if err != nil {
+		return nil, err
+	}
+
+	clientType := ClientTypeOf(token)
+
+	var resolved *SessionPolicy
+	for _, policy := range policies {
+		if !policy.matches(clientType, groupPrincipals, globalRoles) {
+			continue
+		}
+
+		if resolved == nil {
+			resolved = &SessionPolicy{ClientType: clientType}
+		}
+		resolved.Policies = append(resolved.Policies, policy.Name)
+		resolved.IdleTimeout = shortest(resolved.IdleTimeout, time.Duration(policy.IdleTimeoutMinutes)*time.Minute)
+		resolved.AbsoluteTimeout = shortest(resolved.AbsoluteTimeout, time.Duration(policy.AbsoluteTimeoutMinutes)*time.Minute)
+	}
+
+	return resolved, nil
+}
+
+// ResolveForToken is like [SessionPolicyResolver.Resolve] for the group principals recorded for the token's user.
+func (r *SessionPolicyResolver) ResolveForToken(token accessor.TokenAccessor) (*SessionPolicy, error) {
+	policies, err := r.loadPolicies()
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+
+	var groupPrincipals []string
+	hitProvider := false
+	attribs, err := r.userAttributeCache.Get(token.GetUserID())
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("error getting userattribute %s: %w", token.GetUserID(), err)
+	}
+	if attribs != nil {
+		for provider, principals := range attribs.GroupPrincipals {
+			if provider == token.GetAuthProvider() {
+				hitProvider = true
+			}
+			for _, principal := range principals.Items {
+				groupPrincipals = append(groupPrincipals, principal.Name)
+			}
+		}
+	}
+	if !hitProvider {
+		for _, principal := range token.GetGroupPrincipals() {
+			groupPrincipals = append(groupPrincipals, principal.Name)
+		}
+	}
+
+	return r.Resolve(token, groupPrincipals)
+}
+
+// Verify checks the token against the session timeout policies applying to it, returning an HTTP status code
+// and an error if the token expired under them or if they couldn't be resolved.
+func (r *SessionPolicyResolver) Verify(token accessor.TokenAccessor, now time.Time) (int, error) {
+	policy, err := r.ResolveForToken(token)
+	if err != nil {
+		logrus.Errorf("auth: error resolving session timeout policies for user %s: %v", token.GetUserID(), err)
+		return http.StatusInternalServerError, fmt.Errorf("unable to resolve session timeout policies")
+	}
+
+	if policy != nil && policy.IsExpired(token, now) {
+		return http.StatusGone, fmt.Errorf("must authenticate, session timeout policy %s expired", strings.Join(policy.Policies, ","))
+	}
+
+	return http.StatusOK, nil
+}
+
+// globalRoles returns the global roles bound to the user, directly or through its group principals.
+func (r *SessionPolicyResolver) globalRoles(userID string, groupPrincipals []string) ([]string, error) {
+	var globalRoles []string
+	for _, subject := range append([]string{userID}, groupPrincipals...) {
+		objs, err := r.grbIndexer.ByIndex(grbSubjectIndex, subject)
+		if err != nil {
+			return nil, fmt.Errorf("error getting globalrolebindings of %s: %w", subject, err)
+		}
+		for _, obj := range objs {
+			if grb, ok := obj.(*apiv3.GlobalRoleBinding); ok {
+				globalRoles = append(globalRoles, grb.GlobalRoleName)
+			}
+		}
+	}
+
+	return globalRoles, nil
+}
+
+// loadPolicies parses the policies again if the setting changed. An invalid value keeps the previous policies
+// if they were loaded, otherwise it fails closed: the policies can't be resolved until the value is fixed.
+func (r *SessionPolicyResolver) loadPolicies() ([]SessionTimeoutPolicy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	raw := r.getPolicies()
+	if raw == r.raw && (r.loaded || r.err != nil) {
+		return r.policies, r.err
+	}
+	r.raw = raw
+
+	policies, err := ParseSessionTimeoutPolicies(raw)
+	if err != nil {
+		if r.loaded {
+			logrus.Errorf("auth: invalid session timeout policies, keeping the previous ones: %v", err)
+			return r.policies, nil
+		}
+		r.err = fmt.Errorf("invalid session timeout policies: %w", err)
+		return nil, r.err
+	}
+	r.loaded, r.policies, r.err = true, policies, nil
+
+	return r.policies, nil
+}
+
+// shortest returns the shortest non-zero timeout.
+func shortest(a, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
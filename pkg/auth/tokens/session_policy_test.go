@@ -0,0 +1,288 @@
+package tokens
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/accessor"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestParseSessionTimeoutPolicies(t *testing.T) {
+	t.Parallel()
+
+	policies, err := ParseSessionTimeoutPolicies(`[
+		{"name":"admins","globalRoles":["admin"],"idleTimeoutMinutes":15,"absoluteTimeoutMinutes":480},
+		{"name":"viewers","groups":["okta_group://viewers"],"clientTypes":["ui-session"],"idleTimeoutMinutes":480}
+	]`)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.Equal(t, "admins", policies[0].Name)
+	assert.Equal(t, 15, policies[0].IdleTimeoutMinutes)
+	assert.Equal(t, []string{ClientTypeUISession}, policies[1].ClientTypes)
+
+	policies, err = ParseSessionTimeoutPolicies(" ")
+	require.NoError(t, err)
+	assert.Empty(t, policies)
+
+	for desc, raw := range map[string]string{
+		"invalid json":       `{`,
+		"no name":            `[{"globalRoles":["admin"],"idleTimeoutMinutes":15}]`,
+		"duplicate name":     `[{"name":"a","globalRoles":["admin"],"idleTimeoutMinutes":15},{"name":"a","groups":["local://g-abcde"],"idleTimeoutMinutes":15}]`,
+		"no subject":         `[{"name":"a","idleTimeoutMinutes":15}]`,
+		"no timeout":         `[{"name":"a","globalRoles":["admin"]}]`,
+		"negative timeout":   `[{"name":"a","globalRoles":["admin"],"idleTimeoutMinutes":-1}]`,
+		"invalid client":     `[{"name":"a","globalRoles":["admin"],"clientTypes":["cli"],"idleTimeoutMinutes":15}]`,
+		"wrong type of list": `{"name":"a"}`,
+	} {
+		t.Run(desc, func(t *testing.T) {
+			_, err := ParseSessionTimeoutPolicies(raw)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestClientTypeOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc  string
+		token accessor.TokenAccessor
+		want  string
+	}{
+		{
+			desc:  "v3 session token",
+			token: &apiv3.Token{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{TokenKindLabel: "session"}}},
+			want:  ClientTypeUISession,
+		},
+		{
+			desc:  "v3 kubeconfig token",
+			token: &apiv3.Token{IsDerived: true, ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{TokenKindLabel: "kubeconfig"}}},
+			want:  ClientTypeKubeconfig,
+		},
+		{
+			desc:  "v3 kubeconfig exec token",
+			token: &apiv3.Token{IsDerived: true, ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{TokenKubeconfigIDLabel: "kubeconfig-49d2m"}}},
+			want:  ClientTypeKubeconfig,
+		},
+		{
+			desc:  "v3 api token",
+			token: &apiv3.Token{IsDerived: true},
+			want:  ClientTypeAPIToken,
+		},
+		{
+			desc:  "ext session token",
+			token: &ext.Token{Spec: ext.TokenSpec{Kind: "session"}},
+			want:  ClientTypeUISession,
+		},
+		{
+			desc:  "ext kubeconfig token",
+			token: &ext.Token{Spec: ext.TokenSpec{Kind: "kubeconfig"}},
+			want:  ClientTypeKubeconfig,
+		},
+		{
+			desc:  "ext api token",
+			token: &ext.Token{},
+			want:  ClientTypeAPIToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, ClientTypeOf(tt.token))
+		})
+	}
+}
+
+func TestSessionPolicyExpiresAt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().Truncate(time.Second)
+	created := metav1.NewTime(now.Add(-2 * time.Hour))
+	used := metav1.NewTime(now.Add(-30 * time.Minute))
+	seen := metav1.NewTime(now.Add(-10 * time.Minute))
+
+	tests := []struct {
+		desc    string
+		policy  *SessionPolicy
+		token   *apiv3.Token
+		want    time.Time
+		expired bool
+	}{
+		{
+			desc:   "no timeout",
+			policy: &SessionPolicy{ClientType: ClientTypeAPIToken},
+			token:  &apiv3.Token{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}},
+		},
+		{
+			desc:    "absolute timeout",
+			policy:  &SessionPolicy{ClientType: ClientTypeAPIToken, AbsoluteTimeout: time.Hour},
+			token:   &apiv3.Token{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}, LastUsedAt: &used},
+			want:    created.Add(time.Hour),
+			expired: true,
+		},
+		{
+			desc:   "idle timeout since last use",
+			policy: &SessionPolicy{ClientType: ClientTypeAPIToken, IdleTimeout: time.Hour},
+			token:  &apiv3.Token{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}, LastUsedAt: &used},
+			want:   used.Add(time.Hour),
+		},
+		{
+			desc:    "idle timeout of a token never used",
+			policy:  &SessionPolicy{ClientType: ClientTypeAPIToken, IdleTimeout: time.Hour},
+			token:   &apiv3.Token{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}},
+			want:    created.Add(time.Hour),
+			expired: true,
+		},
+		{
+			desc:   "idle timeout of a session since last activity",
+			policy: &SessionPolicy{ClientType: ClientTypeUISession, IdleTimeout: 15 * time.Minute},
+			token:  &apiv3.Token{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}, LastUsedAt: &used, ActivityLastSeenAt: &seen},
+			want:   seen.Add(15 * time.Minute),
+		},
+		{
+			desc:   "earliest of idle and absolute timeouts",
+			policy: &SessionPolicy{ClientType: ClientTypeAPIToken, IdleTimeout: time.Hour, AbsoluteTimeout: 3 * time.Hour},
+			token:  &apiv3.Token{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}, LastUsedAt: &used},
+			want:   used.Add(time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.policy.ExpiresAt(tt.token))
+			assert.Equal(t, tt.expired, tt.policy.IsExpired(tt.token, now))
+		})
+	}
+}
+
+func TestSessionPolicyResolver(t *testing.T) {
+	t.Parallel()
+
+	userID := "u-abcde"
+	adminsGroup := "okta_group://admins"
+
+	grbIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{grbSubjectIndex: grbSubjectIndexer})
+	require.NoError(t, grbIndexer.Add(&apiv3.GlobalRoleBinding{
+		ObjectMeta:     metav1.ObjectMeta{Name: "grb-user"},
+		UserName:       userID,
+		GlobalRoleName: "user",
+	}))
+	require.NoError(t, grbIndexer.Add(&apiv3.GlobalRoleBinding{
+		ObjectMeta:         metav1.ObjectMeta{Name: "grb-admins"},
+		GroupPrincipalName: adminsGroup,
+		GlobalRoleName:     "admin",
+	}))
+
+	ctrl := gomock.NewController(t)
+	userAttributeCache := fake.NewMockNonNamespacedCacheInterface[*apiv3.UserAttribute](ctrl)
+	userAttributeCache.EXPECT().Get(gomock.Any()).DoAndReturn(func(name string) (*apiv3.UserAttribute, error) {
+		if name != userID {
+			return nil, apierrors.NewNotFound(schema.GroupResource{}, name)
+		}
+		return &apiv3.UserAttribute{
+			GroupPrincipals: map[string]apiv3.Principals{
+				"okta": {Items: []apiv3.Principal{{ObjectMeta: metav1.ObjectMeta{Name: adminsGroup}}}},
+			},
+		}, nil
+	}).AnyTimes()
+
+	policies := `[
+		{"name":"admins","globalRoles":["admin"],"idleTimeoutMinutes":15,"absoluteTimeoutMinutes":480},
+		{"name":"admin-kubeconfigs","groups":["okta_group://admins"],"clientTypes":["kubeconfig"],"idleTimeoutMinutes":60,"absoluteTimeoutMinutes":240},
+		{"name":"viewers","groups":["okta_group://viewers"],"idleTimeoutMinutes":480}
+	]`
+	resolver := &SessionPolicyResolver{
+		grbIndexer:         grbIndexer,
+		userAttributeCache: userAttributeCache,
+		getPolicies:        func() string { return policies },
+	}
+
+	t.Run("admin session", func(t *testing.T) {
+		policy, err := resolver.ResolveForToken(&ext.Token{Spec: ext.TokenSpec{UserID: userID, Kind: "session"}})
+		require.NoError(t, err)
+		require.NotNil(t, policy)
+		assert.Equal(t, []string{"admins"}, policy.Policies)
+		assert.Equal(t, ClientTypeUISession, policy.ClientType)
+		assert.Equal(t, 15*time.Minute, policy.IdleTimeout)
+		assert.Equal(t, 8*time.Hour, policy.AbsoluteTimeout)
+	})
+	t.Run("admin kubeconfig takes the shortest timeouts", func(t *testing.T) {
+		policy, err := resolver.ResolveForToken(&ext.Token{Spec: ext.TokenSpec{UserID: userID, Kind: "kubeconfig"}})
+		require.NoError(t, err)
+		require.NotNil(t, policy)
+		assert.Equal(t, []string{"admins", "admin-kubeconfigs"}, policy.Policies)
+		assert.Equal(t, 15*time.Minute, policy.IdleTimeout)
+		assert.Equal(t, 4*time.Hour, policy.AbsoluteTimeout)
+	})
+	t.Run("viewer", func(t *testing.T) {
+		policy, err := resolver.Resolve(&apiv3.Token{UserID: "u-fghij", IsDerived: true}, []string{"okta_group://viewers"})
+		require.NoError(t, err)
+		require.NotNil(t, policy)
+		assert.Equal(t, []string{"viewers"}, policy.Policies)
+		assert.Equal(t, ClientTypeAPIToken, policy.ClientType)
+		assert.Equal(t, 8*time.Hour, policy.IdleTimeout)
+		assert.Zero(t, policy.AbsoluteTimeout)
+	})
+	t.Run("no matching policy", func(t *testing.T) {
+		policy, err := resolver.ResolveForToken(&ext.Token{Spec: ext.TokenSpec{UserID: "u-fghij"}})
+		require.NoError(t, err)
+		assert.Nil(t, policy)
+	})
+	t.Run("verify", func(t *testing.T) {
+		now := time.Now()
+		fresh := &ext.Token{Spec: ext.TokenSpec{UserID: userID, Kind: "session"}}
+		fresh.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+		fresh.Status.LastActivitySeen = &metav1.Time{Time: now.Add(-time.Minute)}
+		code, err := resolver.Verify(fresh, now)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+
+		idle := fresh.DeepCopy()
+		idle.Status.LastActivitySeen = &metav1.Time{Time: now.Add(-30 * time.Minute)}
+		code, err = resolver.Verify(idle, now)
+		require.ErrorContains(t, err, "session timeout policy admins expired")
+		assert.Equal(t, http.StatusGone, code)
+	})
+	t.Run("invalid policies keep the previous ones", func(t *testing.T) {
+		invalid := &SessionPolicyResolver{
+			grbIndexer:         grbIndexer,
+			userAttributeCache: userAttributeCache,
+			getPolicies:        func() string { return policies },
+		}
+		loaded, err := invalid.loadPolicies()
+		require.NoError(t, err)
+		require.Len(t, loaded, 3)
+
+		invalid.getPolicies = func() string { return "[{" }
+		loaded, err = invalid.loadPolicies()
+		require.NoError(t, err)
+		assert.Len(t, loaded, 3)
+	})
+	t.Run("invalid policies on first load fail closed", func(t *testing.T) {
+		invalid := &SessionPolicyResolver{
+			grbIndexer:         grbIndexer,
+			userAttributeCache: userAttributeCache,
+			getPolicies:        func() string { return "[{" },
+		}
+		token := &ext.Token{Spec: ext.TokenSpec{UserID: "u-fghij"}}
+		_, err := invalid.ResolveForToken(token)
+		require.Error(t, err)
+		code, err := invalid.Verify(token, time.Now())
+		require.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, code)
+
+		invalid.getPolicies = func() string { return policies }
+		code, err = invalid.Verify(token, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+	})
+}
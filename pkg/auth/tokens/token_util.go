@@ -136,8 +136,9 @@ func extractClusterIDFromResponseType(responseType string) string {
 	return responseSplit[1]
 }
 
-// Given a stored token with hashed key, check if the provided (unhashed) tokenKey matches and is valid
-func VerifyToken(storedToken *apiv3.Token, tokenName, tokenKey string) (int, error) {
+// Given a stored token with hashed key, check if the provided (unhashed) tokenKey matches and is valid.
+// The token is also checked against the session timeout policies, unless policies is nil.
+func VerifyToken(storedToken *apiv3.Token, tokenName, tokenKey string, policies SessionPolicyVerifier) (int, error) {
 	if storedToken == nil || storedToken.Name != tokenName {
 		return http.StatusUnprocessableEntity, errInvalidAuthToken
 	}
@@ -162,10 +163,15 @@ func VerifyToken(storedToken *apiv3.Token, tokenName, tokenKey string) (int, err
 		return http.StatusGone, errors.New("must authenticate, expired")
 	}
 
-	if IsIdleExpired(storedToken, time.Now()) {
+	now := time.Now()
+	if IsIdleExpired(storedToken, now) {
 		return http.StatusGone, errors.New("must authenticate, session idle timeout expired")
 	}
 
+	if policies != nil {
+		return policies.Verify(storedToken, now)
+	}
+
 	return http.StatusOK, nil
 }
 
@@ -1,10 +1,13 @@
 package tokens
 
 import (
+	"errors"
+	"net/http"
 	"testing"
 	"time"
 
 	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/accessor"
 	"github.com/rancher/rancher/pkg/auth/tokens/hashers"
 	"github.com/rancher/rancher/pkg/features"
 	"github.com/rancher/rancher/pkg/settings"
@@ -47,6 +50,7 @@ func TestVerifyToken(t *testing.T) {
 		token     *apiv3.Token
 		tokenName string
 		tokenKey  string
+		policies  SessionPolicyVerifier
 
 		wantResponseCode int
 		wantErr          bool
@@ -105,6 +109,23 @@ func TestVerifyToken(t *testing.T) {
 			wantResponseCode: 410,
 			wantErr:          true,
 		},
+		{
+			name:             "session timeout policy expired",
+			token:            &hashedToken,
+			tokenName:        hashedTokenName,
+			tokenKey:         tokenKey,
+			policies:         fakeSessionPolicyVerifier{code: 410},
+			wantResponseCode: 410,
+			wantErr:          true,
+		},
+		{
+			name:             "session timeout policy not expired",
+			token:            &hashedToken,
+			tokenName:        hashedTokenName,
+			tokenKey:         tokenKey,
+			policies:         fakeSessionPolicyVerifier{code: 200},
+			wantResponseCode: 200,
+		},
 		{
 			name:             "nil token",
 			token:            nil,
@@ -125,7 +146,7 @@ func TestVerifyToken(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			responseCode, err := VerifyToken(test.token, test.tokenName, test.tokenKey)
+			responseCode, err := VerifyToken(test.token, test.tokenName, test.tokenKey, test.policies)
 			if test.wantErr {
 				require.Error(t, err)
 			}
@@ -134,6 +155,18 @@ func TestVerifyToken(t *testing.T) {
 	}
 }
 
+// fakeSessionPolicyVerifier returns the given code, with an error unless it's 200.
+type fakeSessionPolicyVerifier struct {
+	code int
+}
+
+func (f fakeSessionPolicyVerifier) Verify(accessor.TokenAccessor, time.Time) (int, error) {
+	if f.code != http.StatusOK {
+		return f.code, errors.New("must authenticate, session timeout policy expired")
+	}
+	return f.code, nil
+}
+
 func TestConvertTokenKeyToHash(t *testing.T) {
 	plaintextToken := "cccccccccccccccccccccccccccccccccccccccccccccccccccccc"
 	token := apiv3.Token{
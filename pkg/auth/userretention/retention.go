@@ -7,6 +7,7 @@
 
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/notifications"
 	"github.com/rancher/rancher/pkg/wrangler"
 	"github.com/sirupsen/logrus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -36,6 +37,7 @@ type Retention struct {
 	userCache          mgmtcontrollers.UserCache
 	users              mgmtcontrollers.UserClient
 	readSettings       func() (settings, error)
+	publisher          *notifications.Publisher
 }
 
 // New creates a new instance of Retention.
@@ -45,6 +47,7 @@ func New(wContext *wrangler.Context) *Retention {
 		users:              wContext.Mgmt.User(),
 		userAttributeCache: wContext.Mgmt.UserAttribute().Cache(),
 		readSettings:       readSettings,
+		publisher:          notifications.NewPublisher(wContext.Mgmt.RancherUserNotification()),
 	}
 }
 
@@ -219,6 +222,17 @@ func (r *Retention) Run(ctx context.Context) error {
 				return err
 			}
 
+			if disableUser {
+				r.publisher.Publish(notifications.Event{
+					Type:     v3.NotificationEventUserDisabled,
+					Severity: v3.NotificationSeverityInfo,
+					Key:      user.Name,
+					Object:   user.Name,
+					UserName: user.Name,
+					Message:  fmt.Sprintf("User %s (%s) was disabled after %s without logging in", user.Name, user.Username, now.Sub(lastLogin).Round(time.Hour)),
+				})
+			}
+
 			return nil
 		})
 		if err != nil {
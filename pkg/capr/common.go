@@ -126,6 +126,7 @@
 	InfrastructureReady          = condition.Cond(capi.InfrastructureReadyCondition)
 	SystemUpgradeControllerReady = condition.Cond("SystemUpgradeControllerReady")
 	Bootstrapped                 = condition.Cond("Bootstrapped")
+	MaintenanceWindowsValid      = condition.Cond("MaintenanceWindowsValid")
 
 	MachineDeploymentMachinesReadyCondition = condition.Cond(capi.MachineDeploymentMachinesReadyCondition)
 
@@ -137,6 +138,15 @@
 	// dependencies and install the chart.
 	ClusterAutoscalerEnabledAnnotation = "provisioning.cattle.io/cluster-autoscaler-enabled"
 
+	// PlanPreviewAnnotation is an annotation set on a provisioning cluster to render the plans the planner would deliver
+	// to its machines into the status of the rkecontrolplane. It does not hold back delivery, pausing the cluster does.
+	PlanPreviewAnnotation = "rke.cattle.io/plan-preview"
+
+	// PlanDryRunAnnotation is an annotation set on a provisioning cluster to render the plans the planner would deliver to
+	// its machines into the status of the rkecontrolplane without delivering any of them. While it is set, the planner
+	// doesn't change the plan of any machine, including for etcd snapshots and certificate or encryption key rotations.
+	PlanDryRunAnnotation = "rke.cattle.io/plan-dry-run"
+
 	// ClusterAutoscalerPausedAnnotation is an annotation used to pause cluster autoscaling for a cluster
 	// it triggers a scale-down of the cluster-autoscaler chart in the downstream cluster
 	ClusterAutoscalerPausedAnnotation = "provisioning.cattle.io/cluster-autoscaler-paused"
@@ -0,0 +1,167 @@
+// Package etcds3 presigns requests for etcd snapshot files stored in S3, so that jobs running in downstream clusters can
+// access them without being handed the S3 credentials.
+package etcds3
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/capr/machineprovision"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+)
+
+const defaultRegion = "us-east-1"
+
+// Client presigns requests against the bucket of an etcd snapshot S3 configuration.
+type Client struct {
+	// Bucket is the bucket requests are presigned for.
+	Bucket string
+	// Folder is the folder snapshot files are stored in.
+	Folder string
+	// CA is the CA certificate of the S3 endpoint, if any.
+	CA []byte
+	// SkipSSLVerify is whether TLS certificate verification of the S3 endpoint must be skipped.
+	SkipSSLVerify bool
+
+	s3 *s3.S3
+}
+
+// New resolves the given S3 configurations, in order of precedence, against the cloud credential referenced by the
+// first of them that references one, and returns a client for the resulting bucket. The cloud credential is looked up
+// in the given namespace unless it is a global one.
+func New(secretCache corecontrollers.SecretCache, namespace string, configs ...*rkev1.ETCDSnapshotS3) (*Client, error) {
+	var merged rkev1.ETCDSnapshotS3
+	for _, config := range configs {
+		if config == nil {
+			continue
+		}
+		merged.Endpoint = first(merged.Endpoint, config.Endpoint)
+		merged.EndpointCA = first(merged.EndpointCA, endpointCA(config.EndpointCA))
+		merged.SkipSSLVerify = merged.SkipSSLVerify || config.SkipSSLVerify
+		merged.Bucket = first(merged.Bucket, config.Bucket)
+		merged.Region = first(merged.Region, config.Region)
+		merged.Folder = first(merged.Folder, config.Folder)
+		merged.CloudCredentialName = first(merged.CloudCredentialName, config.CloudCredentialName)
+	}
+	if merged.CloudCredentialName == "" {
+		return nil, fmt.Errorf("no S3 cloud credential is configured")
+	}
+
+	secret, err := machineprovision.GetCloudCredentialSecret(secretCache, namespace, merged.CloudCredentialName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup S3 cloud credential %s: %w", merged.CloudCredentialName, err)
+	}
+	data := map[string]string{}
+	for k, v := range secret.Data {
+		_, k = kv.RSplit(k, "-")
+		data[k] = string(v)
+	}
+
+	awsConfig := &aws.Config{
+		Region:      aws.String(first(merged.Region, data["defaultRegion"], defaultRegion)),
+		Credentials: credentials.NewStaticCredentials(data["accessKey"], data["secretKey"], ""),
+	}
+	if endpoint := first(merged.Endpoint, data["defaultEndpoint"]); endpoint != "" {
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+		}
+		awsConfig.Endpoint = aws.String(endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(true)
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		Bucket:        first(merged.Bucket, data["defaultBucket"]),
+		Folder:        first(merged.Folder, data["defaultFolder"]),
+		SkipSSLVerify: merged.SkipSSLVerify || data["defaultSkipSSLVerify"] == "true",
+		s3:            s3.New(sess),
+	}
+	if ca := first(merged.EndpointCA, endpointCA(data["defaultEndpointCA"])); ca != "" {
+		client.CA = []byte(ca)
+	}
+	if client.Bucket == "" {
+		return nil, fmt.Errorf("no S3 bucket is configured")
+	}
+	return client, nil
+}
+
+// Key returns the key of the given snapshot file in the folder of the client.
+func (c *Client) Key(name string) string {
+	return path.Join(c.Folder, name)
+}
+
+// Location returns the s3:// location of the given key in the bucket of the client.
+func (c *Client) Location(key string) string {
+	return fmt.Sprintf("s3://%s/%s", c.Bucket, key)
+}
+
+// PresignGet returns a URL to download the object with the given key, valid for the given duration.
+func (c *Client) PresignGet(key string, expiry time.Duration) (string, error) {
+	req, _ := c.s3.GetObjectRequest(&s3.GetObjectInput{Bucket: aws.String(c.Bucket), Key: aws.String(key)})
+	return presign(req, expiry)
+}
+
+// PresignPut returns a URL to upload the object with the given key, valid for the given duration.
+func (c *Client) PresignPut(key string, expiry time.Duration) (string, error) {
+	req, _ := c.s3.PutObjectRequest(&s3.PutObjectInput{Bucket: aws.String(c.Bucket), Key: aws.String(key)})
+	return presign(req, expiry)
+}
+
+// PresignDelete returns a URL to delete the object with the given key, valid for the given duration.
+func (c *Client) PresignDelete(key string, expiry time.Duration) (string, error) {
+	req, _ := c.s3.DeleteObjectRequest(&s3.DeleteObjectInput{Bucket: aws.String(c.Bucket), Key: aws.String(key)})
+	return presign(req, expiry)
+}
+
+func presign(req *request.Request, expiry time.Duration) (string, error) {
+	return req.Presign(expiry)
+}
+
+// ParseLocation returns the bucket and key of an s3://bucket/key snapshot location.
+func ParseLocation(location string) (string, string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid snapshot location %q: %w", location, err)
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	if u.Scheme != "s3" || u.Host == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 snapshot location %q", location)
+	}
+	return u.Host, key, nil
+}
+
+// endpointCA returns the given CA certificate as PEM, decoding it if it is base64-encoded. CA certificates given as
+// file paths of the nodes that took the snapshot are dropped.
+func endpointCA(ca string) string {
+	if ca == "" || strings.HasSuffix(ca, ".crt") {
+		return ""
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(ca); err == nil {
+		return string(decoded)
+	}
+	return ca
+}
+
+// first returns the first non-blank string.
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package etcds3
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocation(t *testing.T) {
+	bucket, key, err := ParseLocation("s3://bucket/folder/snapshot.zip")
+	require.NoError(t, err)
+	assert.Equal(t, "bucket", bucket)
+	assert.Equal(t, "folder/snapshot.zip", key)
+
+	for _, location := range []string{"file:///var/lib/snapshot", "s3://bucket", "s3:///key", "://"} {
+		_, _, err := ParseLocation(location)
+		assert.Error(t, err, location)
+	}
+}
+
+func TestKeyAndLocation(t *testing.T) {
+	c := &Client{Bucket: "bucket", Folder: "folder"}
+	assert.Equal(t, "folder/snapshot", c.Key("snapshot"))
+	assert.Equal(t, "s3://bucket/folder/snapshot", c.Location(c.Key("snapshot")))
+
+	c.Folder = ""
+	assert.Equal(t, "snapshot", c.Key("snapshot"))
+}
+
+func TestEndpointCA(t *testing.T) {
+	pem := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	assert.Equal(t, pem, endpointCA(pem))
+	assert.Equal(t, pem, endpointCA(base64.StdEncoding.EncodeToString([]byte(pem))))
+	assert.Empty(t, endpointCA("/etc/ssl/certs/ca.crt"))
+	assert.Empty(t, endpointCA(""))
+}
@@ -0,0 +1,228 @@
+// Package machinessh reads the SSH access details of provisioned machines from their machine state secrets, and
+// enforces the host keys of their SSH servers. Host keys are reported by the system agent of the machine through the
+// output of the HostKeysInstructionName periodic instruction of its plan, and pinned in the machine state secret the
+// first time they are reported, so that connections to the machine are rejected if the node presents a different key.
+package machinessh
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/rancher/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// SecretType is the type of the machine state secrets.
+	SecretType = "rke.cattle.io/machine-state"
+	// ConfigField is the field of the machine state secret holding the machine config archive written by the machine
+	// driver.
+	ConfigField = "extractedConfig"
+	// HostKeyField is the field of the machine state secret holding the pinned host keys of the machine, in the
+	// authorized_keys format.
+	HostKeyField = "sshHostKey"
+	// HostKeysInstructionName is the name of the periodic instruction of the plan of a machine printing the host keys
+	// of its SSH server.
+	HostKeysInstructionName = "ssh-host-keys"
+	// HostKeyMismatchAnnotation is set on the machine state secret when the system agent reports host keys that differ
+	// from the pinned ones, to the SHA256 fingerprints of the reported keys. The pinned keys are kept.
+	HostKeyMismatchAnnotation = "rke.cattle.io/ssh-host-key-mismatch"
+	// HostKeyRepinAnnotation is set to "true" on the machine state secret by an administrator to pin the host keys
+	// reported next by the system agent in place of the pinned ones.
+	HostKeyRepinAnnotation = "rke.cattle.io/ssh-host-key-repin"
+
+	// probeTimeout is the timeout of the connectivity check of the machine addresses.
+	probeTimeout = 5 * time.Second
+	// dialTimeout is the timeout of SSH connections to the machine.
+	dialTimeout = 30 * time.Second
+)
+
+// ErrHostKeyNotPinned is returned when connecting to a machine whose host keys have not been reported by its system
+// agent yet.
+var ErrHostKeyNotPinned = errors.New("host key has not been reported by the system agent yet")
+
+// Info holds the SSH access details of a machine.
+type Info struct {
+	IDRSA    []byte
+	IDRSAPub []byte
+	Driver   Config
+	// HostKeys are the pinned host keys of the machine, empty if they have not been reported yet.
+	HostKeys []ssh.PublicKey
+}
+
+// Config is the subset of the machine driver config needed to reach the machine.
+type Config struct {
+	IPAddress   string
+	IPv6Address string
+	SSHUser     string
+	SSHPort     int
+	MachineName string
+}
+
+// ParseState reads the SSH access details of a machine from its machine state secret.
+func ParseState(secret *corev1.Secret) (*Info, error) {
+	result := &Info{}
+
+	gz, err := gzip.NewReader(bytes.NewReader(secret.Data[ConfigField]))
+	if err != nil {
+		return nil, err
+	}
+
+	tar := tar.NewReader(gz)
+
+	for {
+		header, err := tar.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(tar)
+		if err != nil {
+			return nil, err
+		}
+		switch filepath.Base(header.Name) {
+		case "id_rsa":
+			result.IDRSA = data
+		case "id_rsa.pub":
+			result.IDRSAPub = data
+		case "config.json":
+			err := json.Unmarshal(data, result)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result.HostKeys, err = ParseHostKeys(secret.Data[HostKeyField])
+	if err != nil {
+		return nil, fmt.Errorf("invalid host key of machine %s: %w", result.Driver.MachineName, err)
+	}
+
+	return result, nil
+}
+
+// AccessibleAddress probes the machine's IPv4 and IPv6 addresses to find one that is reachable on the SSH port.
+// It uses a quick TCP dial to check for connectivity before attempting a full SSH handshake.
+func AccessibleAddress(info *Info) (string, error) {
+	ipv4 := info.Driver.IPAddress
+	ipv6 := info.Driver.IPv6Address
+	name := info.Driver.MachineName
+
+	if ipv4 == "" && ipv6 == "" {
+		return "", fmt.Errorf("no IP addresses available for machine %s", name)
+	}
+
+	addresses := []string{ipv4, ipv6}
+
+	kubeHost := os.Getenv("KUBERNETES_SERVICE_HOST")
+	if kubeHost != "" && utils.IsPlainIPV6(kubeHost) {
+		addresses = []string{ipv6, ipv4}
+	}
+
+	for _, addr := range addresses {
+		if addr == "" {
+			continue
+		}
+
+		addrWithPort := net.JoinHostPort(addr, strconv.Itoa(info.Driver.SSHPort))
+
+		conn, err := net.DialTimeout("tcp", addrWithPort, probeTimeout)
+		if err == nil {
+			_ = conn.Close()
+			return addrWithPort, nil
+		}
+		logrus.Debugf("[ssh] Failed to probe machine %s at address %s: %v", name, addrWithPort, err)
+	}
+
+	return "", fmt.Errorf("failed to find an accessible IP address for machine %s", name)
+}
+
+// ParseHostKeys parses host keys in the authorized_keys format, one per line, as printed by the
+// HostKeysInstructionName instruction.
+func ParseHostKeys(data []byte) ([]ssh.PublicKey, error) {
+	var keys []ssh.PublicKey
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		data = rest
+	}
+	return keys, nil
+}
+
+// MarshalHostKeys serializes host keys in the authorized_keys format, one per line.
+func MarshalHostKeys(keys []ssh.PublicKey) []byte {
+	var data []byte
+	for _, key := range keys {
+		data = append(data, ssh.MarshalAuthorizedKey(key)...)
+	}
+	return data
+}
+
+// FingerprintHostKeys returns the SHA256 fingerprints of host keys, comma separated.
+func FingerprintHostKeys(keys []ssh.PublicKey) string {
+	fingerprints := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fingerprints = append(fingerprints, ssh.FingerprintSHA256(key))
+	}
+	return strings.Join(fingerprints, ",")
+}
+
+// Dial opens an SSH connection to the machine, rejecting the connection unless the machine presents one of its pinned
+// host keys.
+func Dial(info *Info) (*ssh.Client, error) {
+	if len(info.HostKeys) == 0 {
+		return nil, fmt.Errorf("machine %s: %w", info.Driver.MachineName, ErrHostKeyNotPinned)
+	}
+	return dial(info, func(_ string, _ net.Addr, key ssh.PublicKey) error {
+		for _, pinned := range info.HostKeys {
+			if bytes.Equal(key.Marshal(), pinned.Marshal()) {
+				return nil
+			}
+		}
+		return errors.New("ssh: host key mismatch")
+	})
+}
+
+func dial(info *Info, hostKeyCallback ssh.HostKeyCallback) (*ssh.Client, error) {
+	signer, err := ssh.ParsePrivateKey(info.IDRSA)
+	if err != nil {
+		return nil, err
+	}
+
+	addrWithPort, err := AccessibleAddress(info)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Debugf("[ssh] Attempting to connect to machine %s via SSH at %s", info.Driver.MachineName, addrWithPort)
+	client, err := ssh.Dial("tcp", addrWithPort, &ssh.ClientConfig{
+		User: info.Driver.SSHUser,
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeys(signer),
+		},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         dialTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to machine %s via SSH: %w", info.Driver.MachineName, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,160 @@
+package machinessh
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newStateSecret returns a machine state secret holding the given private key and machine config.
+func newStateSecret(t *testing.T, idRSA []byte, config Config) *corev1.Secret {
+	t.Helper()
+
+	configJSON, err := json.Marshal(map[string]any{"Driver": config})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, data := range map[string][]byte{
+		"machines/test/id_rsa":      idRSA,
+		"machines/test/config.json": configJSON,
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data))}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return &corev1.Secret{
+		Type: SecretType,
+		Data: map[string][]byte{ConfigField: buf.Bytes()},
+	}
+}
+
+// newServer starts an SSH server accepting the given client key, and returns its address.
+func newServer(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) (string, int) {
+	t.Helper()
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if !bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, assert.AnError
+			}
+			return &ssh.Permissions{}, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for ch := range chans {
+					ch.Reject(ssh.Prohibited, "")
+				}
+			}()
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	return host, portNumber
+}
+
+func TestHostKeyPinning(t *testing.T) {
+	clientKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	idRSA := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(clientKey)})
+	clientPublicKey, err := ssh.NewPublicKey(&clientKey.PublicKey)
+	require.NoError(t, err)
+
+	_, hostPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(hostPrivateKey)
+	require.NoError(t, err)
+
+	host, port := newServer(t, hostKey, clientPublicKey)
+	secret := newStateSecret(t, idRSA, Config{IPAddress: host, SSHPort: port, SSHUser: "root", MachineName: "test"})
+
+	info, err := ParseState(secret)
+	require.NoError(t, err)
+	assert.Equal(t, idRSA, info.IDRSA)
+	assert.Equal(t, "test", info.Driver.MachineName)
+	assert.Empty(t, info.HostKeys)
+
+	_, err = Dial(info)
+	assert.ErrorIs(t, err, ErrHostKeyNotPinned)
+
+	_, otherPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ssh.NewSignerFromKey(otherPrivateKey)
+	require.NoError(t, err)
+
+	// the machine reports every host key of its SSH server, any of which may be presented
+	secret.Data[HostKeyField] = MarshalHostKeys([]ssh.PublicKey{otherKey.PublicKey(), hostKey.PublicKey()})
+	info, err = ParseState(secret)
+	require.NoError(t, err)
+	client, err := Dial(info)
+	require.NoError(t, err)
+	client.Close()
+
+	secret.Data[HostKeyField] = ssh.MarshalAuthorizedKey(otherKey.PublicKey())
+	info, err = ParseState(secret)
+	require.NoError(t, err)
+	_, err = Dial(info)
+	assert.ErrorContains(t, err, "host key mismatch")
+}
+
+func TestParseHostKeys(t *testing.T) {
+	output := []byte(`ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIE2vTuCqhZ3M1jd5p/sJyfDuoHBq4NZP8eP0GmbVLb1M root@node-1
+
+ecdsa-sha2-nistp256 AAAAE2VjZHNhLXNoYTItbmlzdHAyNTYAAAAIbmlzdHAyNTYAAABBBEbXD23O5TnLa3N2Hdtxwz55m9pUQh2NvK6G8FsZ++driWWr4UyLiSbLKmIhK5daC6cpV27DANPjRvQSXn5o68Y= root@node-1
+`)
+	keys, err := ParseHostKeys(output)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, ssh.KeyAlgoED25519, keys[0].Type())
+	assert.Equal(t, ssh.KeyAlgoECDSA256, keys[1].Type())
+
+	keys, err = ParseHostKeys(MarshalHostKeys(keys))
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	keys, err = ParseHostKeys(nil)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	_, err = ParseHostKeys([]byte("cat: /etc/ssh/ssh_host_*_key.pub: No such file or directory\n"))
+	assert.Error(t, err)
+}
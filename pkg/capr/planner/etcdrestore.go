@@ -330,14 +330,19 @@ func (p *Planner) generateEtcdSnapshotRestorePlan(controlPlane *rkev1.RKEControl
 
 	var env []string
 
+	restoreS3, err := etcdSnapshotRestoreS3(controlPlane, snapshot)
+	if err != nil {
+		return plan.NodePlan{}, "", err
+	}
+
 	if snapshot == nil {
 		// If the snapshot is nil, then we will assume the passed in snapshot name is a local snapshot.
 		args = append(args, fmt.Sprintf("--cluster-reset-restore-path=db/snapshots/%s", snapshotName), "--etcd-s3=false")
-	} else if snapshot.SnapshotFile.S3 == nil {
+	} else if restoreS3 == nil {
 		args = append(args, fmt.Sprintf("--cluster-reset-restore-path=db/snapshots/%s", snapshot.SnapshotFile.Name), "--etcd-s3=false")
 	} else {
 		args = append(args, fmt.Sprintf("--cluster-reset-restore-path=%s", snapshot.SnapshotFile.Name))
-		s3, s3Env, s3Files, err := p.etcdS3Args.ToArgs(snapshot.SnapshotFile.S3, controlPlane, "etcd-", true)
+		s3, s3Env, s3Files, err := p.etcdS3Args.ToArgs(restoreS3, controlPlane, "etcd-", true)
 		if err != nil {
 			return plan.NodePlan{}, "", err
 		}
@@ -614,8 +619,12 @@ func generateRemoveTLSAndCredDirInstructions(controlPlane *rkev1.RKEControlPlane
 
 // runEtcdRestoreInitNodeElection runs an election for an init node. Notably, it accepts a nil snapshot, and will
 func (p *Planner) runEtcdRestoreInitNodeElection(controlPlane *rkev1.RKEControlPlane, snapshot *rkev1.ETCDSnapshot, clusterPlan *plan.Plan) (string, error) {
+	restoreS3, err := etcdSnapshotRestoreS3(controlPlane, snapshot)
+	if err != nil {
+		return "", err
+	}
 	if snapshot != nil { // If the snapshot CR is not nil, then find an init node.
-		if snapshot.SnapshotFile.S3 == nil {
+		if restoreS3 == nil {
 			// If the snapshot is not an S3 snapshot, then designate the init node by machine ID defined.
 			if id, ok := snapshot.Labels[capr.MachineIDLabel]; ok {
 				logrus.Infof("[planner] rkecluster %s/%s: designating init node with machine ID: %s for local snapshot %s/%s restoration", controlPlane.Namespace, controlPlane.Name, id, snapshot.Namespace, snapshot.Name)
@@ -704,6 +713,39 @@ func (p *Planner) runEtcdRestoreServiceStop(controlPlane *rkev1.RKEControlPlane,
 	return nil
 }
 
+// etcdSnapshotRestoreS3 returns the S3 configuration the given snapshot is restored from, or nil if it is restored from
+// the snapshot directory of the node that took it. If the restore names a snapshot target, the snapshot is restored from
+// the copy replicated to that target, which requires the snapshot CR to record a successful replication.
+func etcdSnapshotRestoreS3(controlPlane *rkev1.RKEControlPlane, snapshot *rkev1.ETCDSnapshot) (*rkev1.ETCDSnapshotS3, error) {
+	target := ""
+	if controlPlane.Spec.ETCDSnapshotRestore != nil {
+		target = controlPlane.Spec.ETCDSnapshotRestore.Target
+	}
+	if target == "" {
+		if snapshot == nil {
+			return nil, nil
+		}
+		return snapshot.SnapshotFile.S3, nil
+	}
+	if snapshot == nil {
+		return nil, fmt.Errorf("unable to restore etcd snapshot %s from target %s as no corresponding etcd snapshot CR was found", controlPlane.Spec.ETCDSnapshotRestore.Name, target)
+	}
+	if controlPlane.Spec.ETCD != nil {
+		for i := range controlPlane.Spec.ETCD.Targets {
+			if controlPlane.Spec.ETCD.Targets[i].Name != target {
+				continue
+			}
+			for _, status := range snapshot.Status.Targets {
+				if status.Name == target && status.Phase == rkev1.ETCDSnapshotTargetPhaseReplicated {
+					return &controlPlane.Spec.ETCD.Targets[i].S3, nil
+				}
+			}
+			return nil, fmt.Errorf("etcd snapshot %s/%s was not replicated to target %s", snapshot.Namespace, snapshot.Name, target)
+		}
+	}
+	return nil, fmt.Errorf("etcd snapshot target %s is not configured", target)
+}
+
 // retrieveEtcdSnapshot attempts to retrieve the etcdsnapshot CR that corresponds to the etcd snapshot restore name specified on the controlplane.
 func (p *Planner) retrieveEtcdSnapshot(controlPlane *rkev1.RKEControlPlane) (*rkev1.ETCDSnapshot, error) {
 	if controlPlane == nil {
@@ -853,7 +895,7 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 		}
 		logrus.Infof("[planner] rkecluster %s/%s: running full reconcile during etcd restore to initially restart cluster", cp.Namespace, cp.Name)
 		// Run a full reconcile of the cluster at this point, ignoring drain and concurrency.
-		if status, err := p.fullReconcile(cp, status, tokensSecret, clusterPlan, true); err != nil {
+		if status, err := p.fullReconcile(cp, status, tokensSecret, clusterPlan, true, nil); err != nil {
 			return status, err
 		}
 		return p.setEtcdSnapshotRestoreState(status, cp.Spec.ETCDSnapshotRestore, rkev1.ETCDSnapshotPhasePostRestoreNodeCleanup)
@@ -868,7 +910,7 @@ func (p *Planner) restoreEtcdSnapshot(cp *rkev1.RKEControlPlane, status rkev1.RK
 		}
 		logrus.Infof("[planner] rkecluster %s/%s: running full reconcile during etcd restore to restart cluster", cp.Namespace, cp.Name)
 		// Run a full reconcile of the cluster at this point, ignoring drain and concurrency.
-		if status, err := p.fullReconcile(cp, status, tokensSecret, clusterPlan, true); err != nil {
+		if status, err := p.fullReconcile(cp, status, tokensSecret, clusterPlan, true, nil); err != nil {
 			return status, err
 		}
 		return p.setEtcdSnapshotRestoreState(status, cp.Spec.ETCDSnapshotRestore, rkev1.ETCDSnapshotPhaseFinished)
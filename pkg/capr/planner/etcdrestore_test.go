@@ -290,3 +290,84 @@ func TestForceDeleteAllDeletingEtcdMachines(t *testing.T) {
 		})
 	}
 }
+
+func TestEtcdSnapshotRestoreS3(t *testing.T) {
+	t.Parallel()
+
+	primary := &rkev1.ETCDSnapshotS3{Bucket: "primary"}
+	dr := rkev1.ETCDSnapshotTarget{Name: "dr", S3: rkev1.ETCDSnapshotS3{Bucket: "dr", Region: "eu-west-1"}}
+	snapshot := func(phase rkev1.ETCDSnapshotTargetPhase) *rkev1.ETCDSnapshot {
+		return &rkev1.ETCDSnapshot{
+			ObjectMeta:   metav1.ObjectMeta{Namespace: "fleet-default", Name: "snapshot"},
+			SnapshotFile: rkev1.ETCDSnapshotFile{S3: primary},
+			Status: rkev1.ETCDSnapshotStatus{Targets: []rkev1.ETCDSnapshotTargetStatus{
+				{Name: "dr", Phase: phase},
+			}},
+		}
+	}
+	controlPlane := func(target string) *rkev1.RKEControlPlane {
+		return &rkev1.RKEControlPlane{
+			Spec: rkev1.RKEControlPlaneSpec{
+				ClusterConfiguration: rkev1.ClusterConfiguration{
+					ETCD: &rkev1.ETCD{Targets: []rkev1.ETCDSnapshotTarget{dr}},
+				},
+				ETCDSnapshotRestore: &rkev1.ETCDSnapshotRestore{Name: "snapshot", Target: target},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		controlPlane *rkev1.RKEControlPlane
+		snapshot     *rkev1.ETCDSnapshot
+		expected     *rkev1.ETCDSnapshotS3
+		expectedErr  string
+	}{
+		{
+			name:         "no target restores from the snapshot location",
+			controlPlane: controlPlane(""),
+			snapshot:     snapshot(rkev1.ETCDSnapshotTargetPhaseReplicated),
+			expected:     primary,
+		},
+		{
+			name:         "no target and no snapshot CR restores locally",
+			controlPlane: controlPlane(""),
+		},
+		{
+			name:         "replicated target",
+			controlPlane: controlPlane("dr"),
+			snapshot:     snapshot(rkev1.ETCDSnapshotTargetPhaseReplicated),
+			expected:     &dr.S3,
+		},
+		{
+			name:         "pruned target",
+			controlPlane: controlPlane("dr"),
+			snapshot:     snapshot(rkev1.ETCDSnapshotTargetPhasePruned),
+			expectedErr:  "etcd snapshot fleet-default/snapshot was not replicated to target dr",
+		},
+		{
+			name:         "unknown target",
+			controlPlane: controlPlane("unknown"),
+			snapshot:     snapshot(rkev1.ETCDSnapshotTargetPhaseReplicated),
+			expectedErr:  "etcd snapshot target unknown is not configured",
+		},
+		{
+			name:         "target without snapshot CR",
+			controlPlane: controlPlane("dr"),
+			expectedErr:  "unable to restore etcd snapshot snapshot from target dr as no corresponding etcd snapshot CR was found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s3, err := etcdSnapshotRestoreS3(tt.controlPlane, tt.snapshot)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, s3)
+		})
+	}
+}
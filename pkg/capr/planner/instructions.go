@@ -8,6 +8,7 @@
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
 	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/capr/machinessh"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -153,6 +154,22 @@ func (p *Planner) addInitNodePeriodicInstruction(nodePlan plan.NodePlan, control
 	return nodePlan, nil
 }
 
+// addSSHHostKeysPeriodicInstruction adds the periodic instruction reporting the host keys of the SSH server of a
+// machine provisioned by a machine driver, which are pinned to connect to the machine over SSH. Custom machines are not
+// reached over SSH.
+func addSSHHostKeysPeriodicInstruction(nodePlan plan.NodePlan, entry *planEntry) plan.NodePlan {
+	if windows(entry) || entry.Machine.Spec.InfrastructureRef.APIGroup != capr.RKEMachineAPIGroup {
+		return nodePlan
+	}
+	nodePlan.PeriodicInstructions = append(nodePlan.PeriodicInstructions, plan.PeriodicInstruction{
+		Name:          machinessh.HostKeysInstructionName,
+		Command:       "sh",
+		Args:          []string{"-c", "cat /etc/ssh/ssh_host_*_key.pub"},
+		PeriodSeconds: 600,
+	})
+	return nodePlan
+}
+
 // generateManifestRemovalInstruction generates a rm -rf command for the manifests of a server. This was created in response to https://github.com/rancher/rancher/issues/41174
 func generateManifestRemovalInstruction(controlPlane *rkev1.RKEControlPlane, entry *planEntry) (bool, plan.OneTimeInstruction) {
 	runtime := capr.GetRuntime(controlPlane.Spec.KubernetesVersion)
@@ -6,6 +6,7 @@
 	v1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
 	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/capr/machinessh"
 	"github.com/rancher/rancher/pkg/provisioningv2/image"
 	"github.com/stretchr/testify/assert"
 )
@@ -258,3 +259,19 @@ type args struct {
 		})
 	}
 }
+
+func Test_addSSHHostKeysPeriodicInstruction(t *testing.T) {
+	custom := createTestPlanEntry(capr.DefaultMachineOS)
+	assert.Empty(t, addSSHHostKeysPeriodicInstruction(plan.NodePlan{}, custom).PeriodicInstructions)
+
+	provisioned := createTestPlanEntry(capr.DefaultMachineOS)
+	provisioned.Machine.Spec.InfrastructureRef.APIGroup = capr.RKEMachineAPIGroup
+	nodePlan := addSSHHostKeysPeriodicInstruction(plan.NodePlan{}, provisioned)
+	if assert.Len(t, nodePlan.PeriodicInstructions, 1) {
+		assert.Equal(t, machinessh.HostKeysInstructionName, nodePlan.PeriodicInstructions[0].Name)
+	}
+
+	windowsEntry := createTestPlanEntry(capr.WindowsMachineOS)
+	windowsEntry.Machine.Spec.InfrastructureRef.APIGroup = capr.RKEMachineAPIGroup
+	assert.Empty(t, addSSHHostKeysPeriodicInstruction(plan.NodePlan{}, windowsEntry).PeriodicInstructions)
+}
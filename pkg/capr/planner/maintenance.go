@@ -0,0 +1,137 @@
+package planner
+
+import (
+	"fmt"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const waitingForMaintenanceWindowMessage = "waiting for maintenance window"
+
+// maintenanceWindow is the state of the maintenance windows of an upgrade strategy at a point in time. Disruptive plan
+// changes are only delivered while a window is open, and the machines they were held for are recorded in pending.
+type maintenanceWindow struct {
+	open                       bool
+	end                        time.Time
+	next                       time.Time
+	pending                    []string
+	pendingCertificateRotation bool
+}
+
+// newMaintenanceWindow evaluates the given maintenance windows at now. A nil maintenanceWindow is returned if no
+// windows are configured, in which case every change can be delivered immediately.
+func newMaintenanceWindow(windows []rkev1.MaintenanceWindow, now time.Time) (*maintenanceWindow, error) {
+	if len(windows) == 0 {
+		return nil, nil
+	}
+
+	result := &maintenanceWindow{}
+	for _, window := range windows {
+		loc := time.UTC
+		if window.TimeZone != "" {
+			var err error
+			loc, err = time.LoadLocation(window.TimeZone)
+			if err != nil {
+				return nil, fmt.Errorf("invalid maintenance window time zone %q: %w", window.TimeZone, err)
+			}
+		}
+		schedule, err := cron.ParseStandard(window.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window schedule %q: %w", window.Schedule, err)
+		}
+		if window.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("invalid maintenance window duration %s for schedule %q", window.Duration.Duration, window.Schedule)
+		}
+
+		local := now.In(loc)
+		// the window is open if it started less than its duration ago
+		if start := schedule.Next(local.Add(-window.Duration.Duration)); !start.After(local) {
+			result.open = true
+			if end := start.Add(window.Duration.Duration); end.After(result.end) {
+				result.end = end
+			}
+		}
+		if next := schedule.Next(local); result.next.IsZero() || next.Before(result.next) {
+			result.next = next
+		}
+	}
+	return result, nil
+}
+
+// evaluateMaintenanceWindows evaluates the maintenance windows of the control plane at now. Invalid windows don't stall
+// the planning of the cluster: they are reported by the MaintenanceWindowsValid condition, and the changes are delivered
+// as if no window was configured until they are fixed.
+func evaluateMaintenanceWindows(cp *rkev1.RKEControlPlane, status *rkev1.RKEControlPlaneStatus, now time.Time) *maintenanceWindow {
+	window, err := newMaintenanceWindow(cp.Spec.UpgradeStrategy.MaintenanceWindows, now)
+	if err != nil {
+		logrus.Errorf("[planner] rkecluster %s/%s: ignoring maintenance windows: %v", cp.Namespace, cp.Name, err)
+		capr.MaintenanceWindowsValid.False(status)
+		capr.MaintenanceWindowsValid.Reason(status, "Invalid")
+		capr.MaintenanceWindowsValid.Message(status, err.Error())
+		return nil
+	}
+	if window != nil || capr.MaintenanceWindowsValid.GetStatus(status) != "" {
+		capr.MaintenanceWindowsValid.True(status)
+		capr.MaintenanceWindowsValid.Reason(status, "")
+		capr.MaintenanceWindowsValid.Message(status, "")
+	}
+	return window
+}
+
+// allows returns whether the given plan change can be delivered now. Only disruptive changes, which restart the
+// distribution services or drain the machine, are held while no window is open.
+func (m *maintenanceWindow) allows(entry *planEntry, desired plan.NodePlan) bool {
+	if m == nil || m.open || entry.Plan == nil || isInDrain(entry) {
+		return true
+	}
+	if getRestartStamp(&entry.Plan.Plan) == getRestartStamp(&desired) && !shouldDrain(entry.Plan.AppliedPlan, desired) {
+		return true
+	}
+	m.pending = append(m.pending, entry.Machine.Name)
+	return false
+}
+
+// allowsCertificateRotation returns whether a requested certificate rotation can be started now. As certificate
+// rotations restart the services of every machine, they are only started while a window is open. A rotation that is
+// already in progress, which pauses the CAPI cluster, is always allowed to complete.
+func (m *maintenanceWindow) allowsCertificateRotation(cp *rkev1.RKEControlPlane, rotationInProgress bool) bool {
+	if m == nil || m.open || rotationInProgress || !shouldRotate(cp) {
+		return true
+	}
+	m.pendingCertificateRotation = true
+	return false
+}
+
+// requeueAfter returns how long to wait before processing the control plane again so that held changes are delivered
+// once the next window opens. Zero is returned if no change is held.
+func (m *maintenanceWindow) requeueAfter(now time.Time) time.Duration {
+	if m == nil || m.open || m.next.IsZero() || (len(m.pending) == 0 && !m.pendingCertificateRotation) {
+		return 0
+	}
+	return m.next.Sub(now)
+}
+
+// status renders the state of the maintenance windows for the status of the control plane.
+func (m *maintenanceWindow) status() *rkev1.MaintenanceWindowStatus {
+	if m == nil {
+		return nil
+	}
+	status := &rkev1.MaintenanceWindowStatus{
+		Open:                       m.open,
+		PendingMachines:            m.pending,
+		PendingCertificateRotation: m.pendingCertificateRotation,
+	}
+	if m.open {
+		status.CurrentWindowEnd = &metav1.Time{Time: m.end.UTC().Truncate(time.Second)}
+	}
+	if !m.next.IsZero() {
+		status.NextWindowStart = &metav1.Time{Time: m.next.UTC().Truncate(time.Second)}
+	}
+	return status
+}
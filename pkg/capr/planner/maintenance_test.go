@@ -0,0 +1,146 @@
+package planner
+
+import (
+	"testing"
+	"time"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+)
+
+func Test_newMaintenanceWindow(t *testing.T) {
+	// 22:00 to 02:00 in Berlin on weekdays
+	windows := []rkev1.MaintenanceWindow{
+		{
+			Schedule: "0 22 * * 1-5",
+			Duration: metav1.Duration{Duration: 4 * time.Hour},
+			TimeZone: "Europe/Berlin",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		windows  []rkev1.MaintenanceWindow
+		now      time.Time
+		wantNil  bool
+		wantErr  bool
+		wantOpen bool
+		wantEnd  time.Time
+		wantNext time.Time
+	}{
+		{
+			name:    "no windows",
+			now:     time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC),
+			wantNil: true,
+		},
+		{
+			name:     "business hours",
+			windows:  windows,
+			now:      time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC), // Monday 13:00 in Berlin
+			wantNext: time.Date(2024, 1, 8, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "window open past midnight",
+			windows:  windows,
+			now:      time.Date(2024, 1, 9, 0, 30, 0, 0, time.UTC), // Tuesday 01:30 in Berlin
+			wantOpen: true,
+			wantEnd:  time.Date(2024, 1, 9, 1, 0, 0, 0, time.UTC),
+			wantNext: time.Date(2024, 1, 9, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekend",
+			windows:  windows,
+			now:      time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC), // Saturday
+			wantNext: time.Date(2024, 1, 15, 21, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "invalid schedule",
+			windows: []rkev1.MaintenanceWindow{{Schedule: "every night", Duration: metav1.Duration{Duration: time.Hour}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid time zone",
+			windows: []rkev1.MaintenanceWindow{{Schedule: "0 22 * * *", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Mars/Olympus"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := newMaintenanceWindow(tt.windows, tt.now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, window)
+				return
+			}
+			assert.Equal(t, tt.wantOpen, window.open)
+			assert.True(t, tt.wantNext.Equal(window.next), "next window %s, expected %s", window.next, tt.wantNext)
+			if tt.wantOpen {
+				assert.True(t, tt.wantEnd.Equal(window.end), "window end %s, expected %s", window.end, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func Test_maintenanceWindowAllows(t *testing.T) {
+	install := func(stamp string) plan.NodePlan {
+		return plan.NodePlan{
+			Instructions: []plan.OneTimeInstruction{{Name: "install", Env: []string{"RESTART_STAMP=" + stamp, "DRAIN_HASH=" + stamp}}},
+		}
+	}
+	entry := func(current plan.NodePlan) *planEntry {
+		return &planEntry{
+			Machine:  &capi.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine"}},
+			Plan:     &plan.Node{Plan: current, AppliedPlan: &current},
+			Metadata: &plan.Metadata{},
+		}
+	}
+
+	var unconfigured *maintenanceWindow
+	assert.True(t, unconfigured.allows(entry(install("1")), install("2")))
+	assert.Zero(t, unconfigured.requeueAfter(time.Now()))
+
+	now := time.Now()
+	closed := &maintenanceWindow{next: now.Add(time.Hour)}
+	assert.True(t, closed.allows(entry(install("1")), install("1")), "non-disruptive changes are delivered")
+	assert.Zero(t, closed.requeueAfter(now))
+	assert.False(t, closed.allows(entry(install("1")), install("2")), "restarting changes are held")
+	assert.Equal(t, []string{"machine"}, closed.pending)
+	assert.Equal(t, time.Hour, closed.requeueAfter(now))
+
+	open := &maintenanceWindow{open: true}
+	assert.True(t, open.allows(entry(install("1")), install("2")))
+	assert.Empty(t, open.pending)
+}
+
+func Test_evaluateMaintenanceWindows(t *testing.T) {
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	cp := &rkev1.RKEControlPlane{}
+	status := rkev1.RKEControlPlaneStatus{}
+
+	// clusters without windows don't get the condition
+	assert.Nil(t, evaluateMaintenanceWindows(cp, &status, now))
+	assert.Empty(t, capr.MaintenanceWindowsValid.GetStatus(&status))
+
+	// an invalid window is reported and ignored rather than failing the planning of the cluster
+	cp.Spec.UpgradeStrategy.MaintenanceWindows = []rkev1.MaintenanceWindow{
+		{Schedule: "0 22 * * 1-5", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "Mars/Olympus_Mons"},
+	}
+	assert.Nil(t, evaluateMaintenanceWindows(cp, &status, now))
+	assert.Equal(t, "False", capr.MaintenanceWindowsValid.GetStatus(&status))
+	assert.Contains(t, capr.MaintenanceWindowsValid.GetMessage(&status), "Mars/Olympus_Mons")
+
+	cp.Spec.UpgradeStrategy.MaintenanceWindows[0].TimeZone = "Europe/Berlin"
+	assert.NotNil(t, evaluateMaintenanceWindows(cp, &status, now))
+	assert.Equal(t, "True", capr.MaintenanceWindowsValid.GetStatus(&status))
+	assert.Empty(t, capr.MaintenanceWindowsValid.GetMessage(&status))
+}
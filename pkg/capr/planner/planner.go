@@ -12,6 +12,7 @@
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/moby/locker"
@@ -20,6 +21,7 @@
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
 	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/capr/machinessh"
 	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1beta2"
 	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	ranchercontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
@@ -323,6 +325,13 @@ func (p *Planner) Process(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlan
 		return status, err
 	}
 
+	// A plan dry run renders the plans that would be delivered into the status, and stops before anything changes the plan
+	// of a machine.
+	if cp.Annotations[capr.PlanDryRunAnnotation] == "true" {
+		status.PlanPreview = p.previewPlans(cp, clusterSecretTokens, plan)
+		return status, errWaitingf("plan dry run requested: %d machine(s) would be updated", len(status.PlanPreview.Machines))
+	}
+
 	if status, err = p.createEtcdSnapshot(cp, status, clusterSecretTokens, plan); err != nil {
 		return status, err
 	}
@@ -331,14 +340,28 @@ func (p *Planner) Process(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlan
 		return status, err
 	}
 
-	if status, err = p.rotateCertificates(cp, status, clusterSecretTokens, plan); err != nil {
-		return status, err
+	now := time.Now()
+	window := evaluateMaintenanceWindows(cp, &status, now)
+	rotateCertificates := window.allowsCertificateRotation(cp, capiannotations.IsPaused(capiCluster, cp))
+	status.MaintenanceWindow = window.status()
+
+	if rotateCertificates {
+		if status, err = p.rotateCertificates(cp, status, clusterSecretTokens, plan); err != nil {
+			return status, err
+		}
 	}
 
 	if status, err = p.rotateEncryptionKeys(cp, status, clusterSecretTokens, plan, releaseData); err != nil {
 		return status, err
 	}
 
+	// While a plan preview is requested, render the plans that are about to be delivered into the status. The preview
+	// does not hold back delivery: pausing the control plane keeps the plans from being delivered while it is reviewed.
+	status.PlanPreview = nil
+	if cp.Annotations[capr.PlanPreviewAnnotation] == "true" {
+		status.PlanPreview = p.previewPlans(cp, clusterSecretTokens, plan)
+	}
+
 	// pausing the control plane only affects machine reconciliation: etcd snapshot/restore, encryption key & cert
 	// rotation are not interruptable processes, and therefore must always be completed when requested
 	if capiannotations.IsPaused(capiCluster, cp) {
@@ -354,10 +377,15 @@ func (p *Planner) Process(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlan
 		return status, errWaiting("rkecontrolplane was already initialized but no etcd machines exist that have plans, indicating the etcd plane has been entirely replaced. Restoration from etcd snapshot is required.")
 	}
 
-	return p.fullReconcile(cp, status, clusterSecretTokens, plan, false)
+	status, err = p.fullReconcile(cp, status, clusterSecretTokens, plan, false, window)
+	status.MaintenanceWindow = window.status()
+	if after := window.requeueAfter(now); after > 0 {
+		p.rkeControlPlanes.EnqueueAfter(cp.Namespace, cp.Name, after)
+	}
+	return status, err
 }
 
-func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, clusterSecretTokens plan.Secret, plan *plan.Plan, ignoreDrainAndConcurrency bool) (rkev1.RKEControlPlaneStatus, error) {
+func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEControlPlaneStatus, clusterSecretTokens plan.Secret, plan *plan.Plan, ignoreDrainAndConcurrency bool, window *maintenanceWindow) (rkev1.RKEControlPlaneStatus, error) {
 	// on the first run through, electInitNode will return a `generic.ErrSkip` as it is attempting to wait for the cache to catch up.
 	joinServer, err := p.electInitNode(cp, plan, true)
 	if err != nil {
@@ -378,7 +406,7 @@ func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEContr
 	}
 
 	// select all etcd and then filter to just initNodes so that unavailable count is correct
-	err = p.reconcile(cp, clusterSecretTokens, plan, true, bootstrapTier, isEtcd, isNotInitNodeOrIsDeleting, "1", "", controlPlaneDrainOptions, -1, 1)
+	err = p.reconcile(cp, clusterSecretTokens, plan, true, bootstrapTier, isEtcd, isNotInitNodeOrIsDeleting, "1", "", controlPlaneDrainOptions, -1, 1, window)
 	capr.Bootstrapped.True(&status)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
@@ -397,14 +425,14 @@ func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEContr
 	}
 
 	// Process all nodes that have the etcd role and are NOT an init node or deleting. Only process 1 node at a time.
-	err = p.reconcile(cp, clusterSecretTokens, plan, true, etcdTier, isEtcd, isInitNodeOrDeleting, "1", joinServer, controlPlaneDrainOptions, -1, 1)
+	err = p.reconcile(cp, clusterSecretTokens, plan, true, etcdTier, isEtcd, isInitNodeOrDeleting, "1", joinServer, controlPlaneDrainOptions, -1, 1, window)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
 		return status, err
 	}
 
 	// Process all nodes that have the controlplane role and are NOT an init node or deleting.
-	err = p.reconcile(cp, clusterSecretTokens, plan, true, controlPlaneTier, isControlPlane, isInitNodeOrDeleting, controlPlaneConcurrency, joinServer, controlPlaneDrainOptions, -1, 1)
+	err = p.reconcile(cp, clusterSecretTokens, plan, true, controlPlaneTier, isControlPlane, isInitNodeOrDeleting, controlPlaneConcurrency, joinServer, controlPlaneDrainOptions, -1, 1, window)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
 		return status, err
@@ -421,7 +449,7 @@ func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEContr
 	}
 
 	// Process all nodes that are ONLY linux worker nodes.
-	err = p.reconcile(cp, clusterSecretTokens, plan, false, workerTier, isOnlyLinuxWorker, isInitNodeOrDeleting, workerConcurrency, "", workerDrainOptions, -1, 1)
+	err = p.reconcile(cp, clusterSecretTokens, plan, false, workerTier, isOnlyLinuxWorker, isInitNodeOrDeleting, workerConcurrency, "", workerDrainOptions, -1, 1, window)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
 		return status, err
@@ -430,7 +458,7 @@ func (p *Planner) fullReconcile(cp *rkev1.RKEControlPlane, status rkev1.RKEContr
 	// Process all nodes that are ONLY windows worker nodes.
 	// We attempt the plan 5 times before marking it as failed
 	// to allow transient errors to potentially resolve.
-	err = p.reconcile(cp, clusterSecretTokens, plan, false, workerTier, isOnlyWindowsWorker, isInitNodeOrDeleting, workerConcurrency, "", workerDrainOptions, -1, 5)
+	err = p.reconcile(cp, clusterSecretTokens, plan, false, workerTier, isOnlyWindowsWorker, isInitNodeOrDeleting, workerConcurrency, "", workerDrainOptions, -1, 5, window)
 	firstIgnoreError, err = ignoreErrors(firstIgnoreError, err)
 	if err != nil {
 		return status, err
@@ -588,17 +616,39 @@ func calculateConcurrency(maxUnavailable string, reconcilables []*reconcilable,
 	return int(math.Ceil(max)), unavailable, nil
 }
 
+// minorPeriodicInstructions are the names of the periodic instructions that only report information back from the
+// node. Adding, removing or changing them is a minor plan change, so that e.g. upgrading Rancher doesn't roll out the
+// plans of all the nodes as major changes.
+var minorPeriodicInstructions = map[string]bool{
+	machinessh.HostKeysInstructionName: true,
+}
+
+// splitPeriodicInstructions splits the periodic instructions into the major and the minor ones.
+func splitPeriodicInstructions(instructions []plan.PeriodicInstruction) (major, minor []plan.PeriodicInstruction) {
+	for _, instruction := range instructions {
+		if minorPeriodicInstructions[instruction.Name] {
+			minor = append(minor, instruction)
+		} else {
+			major = append(major, instruction)
+		}
+	}
+	return major, minor
+}
+
 func minorPlanChangeDetected(old, new plan.NodePlan) bool {
+	oldPeriodic, oldMinorPeriodic := splitPeriodicInstructions(old.PeriodicInstructions)
+	newPeriodic, newMinorPeriodic := splitPeriodicInstructions(new.PeriodicInstructions)
 	if !equality.Semantic.DeepEqual(old.Instructions, new.Instructions) ||
-		!equality.Semantic.DeepEqual(old.PeriodicInstructions, new.PeriodicInstructions) ||
+		!equality.Semantic.DeepEqual(oldPeriodic, newPeriodic) ||
 		!equality.Semantic.DeepEqual(old.Probes, new.Probes) ||
 		old.Error != new.Error {
 		return false
 	}
+	minorPeriodicChange := !equality.Semantic.DeepEqual(oldMinorPeriodic, newMinorPeriodic)
 
 	if len(old.Files) == 0 && len(new.Files) == 0 {
-		// if the old plan had no files and no new files were found, there was no plan change detected
-		return false
+		// if the old plan had no files and no new files were found, the only change can be the minor periodic instructions
+		return minorPeriodicChange
 	}
 
 	newFiles := make(map[string]plan.File)
@@ -632,7 +682,7 @@ func minorPlanChangeDetected(old, new plan.NodePlan) bool {
 		// There were new files and all were not major
 		return true
 	}
-	return false
+	return minorPeriodicChange
 }
 
 func kubeletVersionUpToDate(controlPlane *rkev1.RKEControlPlane, machine *capi.Machine) bool {
@@ -848,7 +898,7 @@ type reconcilable struct {
 }
 
 func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret plan.Secret, clusterPlan *plan.Plan, required bool, tierName string,
-	include, exclude roleFilter, maxUnavailable, forcedJoinURL string, drainOptions rkev1.DrainOptions, maxFailures, failureThreshold int) error {
+	include, exclude roleFilter, maxUnavailable, forcedJoinURL string, drainOptions rkev1.DrainOptions, maxFailures, failureThreshold int, window *maintenanceWindow) error {
 	var (
 		ready, outOfSync, nonReady, errMachines, draining, uncordoned []string
 		messages                                                      = map[string][]string{}
@@ -944,7 +994,11 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, tokensSecret pl
 			// 4. unavailable < concurrency meaning we have capacity to make something unavailable
 			// 5. If the plan was successful in application but the probes never went healthy
 			logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - concurrency: %d, unavailable: %d", controlPlane.Namespace, controlPlane.Name, tierName, concurrency, unavailable)
-			if isInDrain(r.entry) || r.entry.Plan.Failed || concurrency == 0 || unavailable < concurrency || planAppliedButProbesNeverHealthy(r.entry) {
+			if !window.allows(r.entry, r.desiredPlan) {
+				// Disruptive changes are held until the next maintenance window opens.
+				logrus.Debugf("[planner] rkecluster %s/%s reconcile tier %s - holding disruptive plan change for machine %s/%s until the next maintenance window", controlPlane.Namespace, controlPlane.Name, tierName, r.entry.Machine.Namespace, r.entry.Machine.Name)
+				messages[r.entry.Machine.Name] = append(messages[r.entry.Machine.Name], waitingForMaintenanceWindowMessage)
+			} else if isInDrain(r.entry) || r.entry.Plan.Failed || concurrency == 0 || unavailable < concurrency || planAppliedButProbesNeverHealthy(r.entry) {
 				if !isUnavailable(r) {
 					unavailable++
 				}
@@ -1134,6 +1188,8 @@ func (p *Planner) desiredPlan(controlPlane *rkev1.RKEControlPlane, tokensSecret
 		}
 	}
 
+	nodePlan = addSSHHostKeysPeriodicInstruction(nodePlan, entry)
+
 	if windows(entry) {
 		// We need to wait for the controlPlane to be ready before sending this plan
 		// to ensure that the initial installation has fully completed
@@ -14,6 +14,7 @@
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
 	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/capr/machinessh"
 	"github.com/rancher/rancher/pkg/provisioningv2/image"
 	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/assert"
@@ -859,3 +860,67 @@ func Test_renderArgAndMount(t *testing.T) {
 		})
 	}
 }
+
+func Test_minorPlanChangeDetected(t *testing.T) {
+	hostKeys := plan.PeriodicInstruction{
+		Name:          machinessh.HostKeysInstructionName,
+		Command:       "sh",
+		Args:          []string{"-c", "cat /etc/ssh/ssh_host_*_key.pub"},
+		PeriodSeconds: 600,
+	}
+	etcdName := plan.PeriodicInstruction{
+		Name:          etcdNameInstructionName,
+		Command:       "sh",
+		PeriodSeconds: 600,
+	}
+	install := plan.OneTimeInstruction{Name: "install", Command: "sh"}
+
+	tests := []struct {
+		name     string
+		old, new plan.NodePlan
+		want     bool
+	}{
+		{
+			name: "no change",
+			old:  plan.NodePlan{Instructions: []plan.OneTimeInstruction{install}},
+			new:  plan.NodePlan{Instructions: []plan.OneTimeInstruction{install}},
+			want: false,
+		},
+		{
+			name: "existing plan without the host keys instruction",
+			old:  plan.NodePlan{Instructions: []plan.OneTimeInstruction{install}, PeriodicInstructions: []plan.PeriodicInstruction{etcdName}},
+			new:  plan.NodePlan{Instructions: []plan.OneTimeInstruction{install}, PeriodicInstructions: []plan.PeriodicInstruction{etcdName, hostKeys}},
+			want: true,
+		},
+		{
+			name: "existing plan without the host keys instruction and with minor file changes",
+			old:  plan.NodePlan{Files: []plan.File{{Path: "/a", Content: "a", Minor: true}}},
+			new:  plan.NodePlan{Files: []plan.File{{Path: "/a", Content: "b", Minor: true}}, PeriodicInstructions: []plan.PeriodicInstruction{hostKeys}},
+			want: true,
+		},
+		{
+			name: "host keys instruction added with a major periodic instruction",
+			old:  plan.NodePlan{},
+			new:  plan.NodePlan{PeriodicInstructions: []plan.PeriodicInstruction{etcdName, hostKeys}},
+			want: false,
+		},
+		{
+			name: "host keys instruction added with a major file change",
+			old:  plan.NodePlan{Files: []plan.File{{Path: "/a", Content: "a"}}},
+			new:  plan.NodePlan{Files: []plan.File{{Path: "/a", Content: "b"}}, PeriodicInstructions: []plan.PeriodicInstruction{hostKeys}},
+			want: false,
+		},
+		{
+			name: "host keys instruction added with an instruction change",
+			old:  plan.NodePlan{},
+			new:  plan.NodePlan{Instructions: []plan.OneTimeInstruction{install}, PeriodicInstructions: []plan.PeriodicInstruction{hostKeys}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, minorPlanChangeDetected(tt.old, tt.new))
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package planner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/wrangler/v3/pkg/kv"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+// previewPlans renders the desired plan of every machine of the cluster plan without delivering it, and returns the
+// changes the delivery would make to the machines whose plan would change.
+func (p *Planner) previewPlans(cp *rkev1.RKEControlPlane, tokensSecret plan.Secret, clusterPlan *plan.Plan) *rkev1.PlanPreview {
+	var joinServer string
+	if initNodes := collect(clusterPlan, isInitNode); len(initNodes) == 1 {
+		joinServer = initNodes[0].Metadata.Annotations[capr.JoinURLAnnotation]
+	}
+
+	preview := &rkev1.PlanPreview{
+		ObservedGeneration: cp.Generation,
+	}
+	for _, entry := range collect(clusterPlan, roleNot(isDeleting)) {
+		var (
+			forcedJoinURL = ""
+			drainOptions  = cp.Spec.UpgradeStrategy.WorkerDrainOptions
+		)
+		if isEtcd(entry) || isControlPlane(entry) {
+			drainOptions = cp.Spec.UpgradeStrategy.ControlPlaneDrainOptions
+			if !isInitNode(entry) {
+				forcedJoinURL = joinServer
+			}
+		}
+
+		joinURL, err := determineJoinURL(cp, entry, clusterPlan, forcedJoinURL)
+		if err != nil {
+			preview.Machines = append(preview.Machines, rkev1.MachinePlanPreview{MachineName: entry.Machine.Name, Error: err.Error()})
+			continue
+		}
+		desired, _, err := p.desiredPlan(cp, tokensSecret, entry, joinURL)
+		if err != nil {
+			preview.Machines = append(preview.Machines, rkev1.MachinePlanPreview{MachineName: entry.Machine.Name, Error: err.Error()})
+			continue
+		}
+
+		if entry.Plan != nil && p.equalities.DeepEqual(entry.Plan.Plan, desired) {
+			continue
+		}
+
+		machinePreview := diffNodePlans(entry.Plan, desired)
+		machinePreview.MachineName = entry.Machine.Name
+		if entry.Plan != nil {
+			machinePreview.Drain = drainOptions.Enabled && len(clusterPlan.Machines) > 1 && shouldDrain(entry.Plan.AppliedPlan, desired)
+		}
+		preview.Machines = append(preview.Machines, machinePreview)
+	}
+
+	sort.Slice(preview.Machines, func(i, j int) bool {
+		return preview.Machines[i].MachineName < preview.Machines[j].MachineName
+	})
+	return preview
+}
+
+// diffNodePlans returns the files, instructions, probes and config keys that differ between the plan currently
+// delivered to a machine and its desired plan. A nil current plan denotes a machine that was never delivered a plan.
+func diffNodePlans(current *plan.Node, desired plan.NodePlan) rkev1.MachinePlanPreview {
+	if current == nil {
+		return rkev1.MachinePlanPreview{
+			Initial: true,
+		}
+	}
+
+	old := current.Plan
+	result := rkev1.MachinePlanPreview{
+		Minor:   minorPlanChangeDetected(old, desired),
+		Restart: getRestartStamp(&old) != getRestartStamp(&desired),
+	}
+
+	result.Files = changedKeys(filesByPath(old.Files), filesByPath(desired.Files), func(a, b plan.File) bool {
+		return a.Content == b.Content && a.Permissions == b.Permissions
+	})
+	result.Instructions = changedKeys(byName(old.Instructions, func(i plan.OneTimeInstruction) string { return i.Name }),
+		byName(desired.Instructions, func(i plan.OneTimeInstruction) string { return i.Name }), equalInstructions[plan.OneTimeInstruction])
+	result.PeriodicInstructions = changedKeys(byName(old.PeriodicInstructions, func(i plan.PeriodicInstruction) string { return i.Name }),
+		byName(desired.PeriodicInstructions, func(i plan.PeriodicInstruction) string { return i.Name }), equalInstructions[plan.PeriodicInstruction])
+	result.Probes = changedKeys(old.Probes, desired.Probes, func(a, b plan.Probe) bool {
+		return equality.Semantic.DeepEqual(a, b)
+	})
+	result.ConfigKeys = changedKeys(distroConfig(old), distroConfig(desired), func(a, b interface{}) bool {
+		return equality.Semantic.DeepEqual(a, b)
+	})
+	return result
+}
+
+// getRestartStamp returns the restart stamp of the install instruction of the given plan, which changes whenever the
+// distribution services are restarted.
+func getRestartStamp(plan *plan.NodePlan) string {
+	for _, instr := range plan.Instructions {
+		if instr.Name != "install" {
+			continue
+		}
+		for _, env := range instr.Env {
+			k, v := kv.Split(env, "=")
+			if k == "RESTART_STAMP" || k == "WINS_RESTART_STAMP" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// distroConfig decodes the distribution config file rendered by the planner, if any, from the given plan.
+func distroConfig(nodePlan plan.NodePlan) map[string]interface{} {
+	prefix, suffix, _ := strings.Cut(ConfigYamlFileName, "%s")
+	for _, file := range nodePlan.Files {
+		if !strings.HasPrefix(file.Path, prefix) || !strings.HasSuffix(file.Path, suffix) {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(file.Content)
+		if err != nil {
+			return nil
+		}
+		config := map[string]interface{}{}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil
+		}
+		return config
+	}
+	return nil
+}
+
+func filesByPath(files []plan.File) map[string]plan.File {
+	result := make(map[string]plan.File, len(files))
+	for _, file := range files {
+		result[file.Path] = file
+	}
+	return result
+}
+
+// byName groups instructions by name, as several instructions can share the same name.
+func byName[T any](instructions []T, name func(T) string) map[string][]T {
+	result := map[string][]T{}
+	for _, instruction := range instructions {
+		result[name(instruction)] = append(result[name(instruction)], instruction)
+	}
+	return result
+}
+
+func equalInstructions[T any](a, b []T) bool {
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+// changedKeys returns the sorted keys that were added, removed or whose value is not equal between old and new.
+func changedKeys[T any](old, new map[string]T, equal func(a, b T) bool) []string {
+	var result []string
+	for k, newValue := range new {
+		if oldValue, ok := old[k]; !ok || !equal(oldValue, newValue) {
+			result = append(result, k)
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			result = append(result, k)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,179 @@
+package planner
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/rancher/channelserver/pkg/model"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+)
+
+func Test_diffNodePlans(t *testing.T) {
+	configFile := func(content string) plan.File {
+		return plan.File{
+			Path:    "/etc/rancher/rke2/config.yaml.d/50-rancher.yaml",
+			Content: base64.StdEncoding.EncodeToString([]byte(content)),
+		}
+	}
+	install := func(stamp string) plan.OneTimeInstruction {
+		return plan.OneTimeInstruction{
+			Name: "install",
+			Env:  []string{"RESTART_STAMP=" + stamp, "DRAIN_HASH=" + stamp},
+		}
+	}
+
+	current := plan.NodePlan{
+		Files: []plan.File{
+			configFile(`{"cni":"calico","node-label":["a=b"]}`),
+			{Path: "/var/lib/rancher/rke2/server/manifests/rancher/addons.yaml", Content: "YQ==", Minor: true},
+		},
+		Instructions: []plan.OneTimeInstruction{install("1")},
+		Probes: map[string]plan.Probe{
+			"kubelet": {InitialDelaySeconds: 1},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		current  *plan.Node
+		desired  plan.NodePlan
+		expected rkev1.MachinePlanPreview
+	}{
+		{
+			name:     "initial plan",
+			desired:  current,
+			expected: rkev1.MachinePlanPreview{Initial: true},
+		},
+		{
+			name:    "minor manifest change",
+			current: &plan.Node{Plan: current},
+			desired: plan.NodePlan{
+				Files: []plan.File{
+					current.Files[0],
+					{Path: "/var/lib/rancher/rke2/server/manifests/rancher/addons.yaml", Content: "Yg==", Minor: true},
+				},
+				Instructions: current.Instructions,
+				Probes:       current.Probes,
+			},
+			expected: rkev1.MachinePlanPreview{
+				Minor: true,
+				Files: []string{"/var/lib/rancher/rke2/server/manifests/rancher/addons.yaml"},
+			},
+		},
+		{
+			name:    "config change restarting the service",
+			current: &plan.Node{Plan: current},
+			desired: plan.NodePlan{
+				Files: []plan.File{
+					configFile(`{"cni":"cilium","node-label":["a=b"],"protect-kernel-defaults":true}`),
+					current.Files[1],
+				},
+				Instructions: []plan.OneTimeInstruction{install("2")},
+				Probes: map[string]plan.Probe{
+					"kubelet":    {InitialDelaySeconds: 1},
+					"kube-proxy": {InitialDelaySeconds: 1},
+				},
+			},
+			expected: rkev1.MachinePlanPreview{
+				Restart:      true,
+				Files:        []string{"/etc/rancher/rke2/config.yaml.d/50-rancher.yaml"},
+				Instructions: []string{"install"},
+				Probes:       []string{"kube-proxy"},
+				ConfigKeys:   []string{"cni", "protect-kernel-defaults"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diffNodePlans(tt.current, tt.desired))
+		})
+	}
+}
+
+func TestPlanner_ProcessPlanDryRun(t *testing.T) {
+	mp := newMockPlanner(t, InfoFunctions{
+		ReleaseData: func(context.Context, *rkev1.RKEControlPlane) *model.Release {
+			return &model.Release{}
+		},
+	})
+	cp := &rkev1.RKEControlPlane{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "fleet-default",
+			Name:        "prod",
+			Annotations: map[string]string{capr.PlanDryRunAnnotation: "true"},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: capi.GroupVersion.String(),
+				Kind:       "Cluster",
+				Name:       "prod",
+				Controller: ptr.To(true),
+			}},
+		},
+		Spec: rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.33.1+rke2r1"},
+	}
+	capiCluster := &capi.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod"},
+		Status: capi.ClusterStatus{
+			Initialization: capi.ClusterInitializationStatus{InfrastructureProvisioned: ptr.To(true)},
+		},
+	}
+	machine := &capi.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod-pool-1"},
+		Spec: capi.MachineSpec{
+			Bootstrap: capi.Bootstrap{
+				ConfigRef: capi.ContractVersionedObjectReference{
+					Kind:     capr.RKEBootstrapKind,
+					Name:     "prod-pool-1",
+					APIGroup: rkev1.SchemeGroupVersion.Group,
+				},
+			},
+		},
+	}
+	planSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      capr.PlanSecretFromBootstrapName("prod-pool-1"),
+			Labels: map[string]string{
+				capr.EtcdRoleLabel:         "true",
+				capr.ControlPlaneRoleLabel: "true",
+				capr.WorkerRoleLabel:       "true",
+			},
+		},
+		Type: capr.SecretTypeMachinePlan,
+	}
+	stateSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod-rke-state"},
+		Type:       capr.SecretTypeClusterState,
+		Data:       map[string][]byte{"serverToken": []byte("server"), "agentToken": []byte("agent")},
+	}
+
+	mp.capiClusters.EXPECT().Get("fleet-default", "prod").Return(capiCluster, nil).AnyTimes()
+	mp.machinesCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*capi.Machine{machine}, nil).AnyTimes()
+	mp.secretClient.EXPECT().Get("fleet-default", planSecret.Name, gomock.Any()).Return(planSecret, nil).AnyTimes()
+	mp.secretCache.EXPECT().Get("fleet-default", stateSecret.Name).Return(stateSecret, nil).AnyTimes()
+	// rendering the plan reads any cached object, whose absence is reported in the preview
+	notFound := apierrors.NewNotFound(schema.GroupResource{}, "")
+	mp.secretCache.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, notFound).AnyTimes()
+	mp.configMapCache.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, notFound).AnyTimes()
+	mp.managementClusters.EXPECT().Get(gomock.Any()).Return(nil, notFound).AnyTimes()
+	mp.rancherClusterCache.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, notFound).AnyTimes()
+	mp.clusterRegistrationTokenCache.EXPECT().GetByIndex(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	// the secret client, machine client and bootstrap client expect no write: no plan is delivered
+
+	status, err := mp.planner.Process(cp, rkev1.RKEControlPlaneStatus{})
+	assert.ErrorContains(t, err, "plan dry run requested: 1 machine(s) would be updated")
+	if assert.NotNil(t, status.PlanPreview) && assert.Len(t, status.PlanPreview.Machines, 1) {
+		assert.Equal(t, "prod-pool-1", status.PlanPreview.Machines[0].MachineName)
+	}
+}
@@ -23,6 +23,7 @@
 	"fmt"
 	"io"
 	"net/url"
+	"path"
 	"strings"
 	"sync"
 
@@ -34,6 +35,7 @@
 	"github.com/rancher/rancher/pkg/catalogv2/helm"
 	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
 	"github.com/rancher/rancher/pkg/catalogv2/oci"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/settings"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
@@ -283,6 +285,110 @@ func (c *Manager) Info(namespace, name, chartName, version string) (*types.Chart
 	return helm.InfoFromTarball(chart)
 }
 
+// Verify verifies the chart data of a specific Helm chart against the verification policy of its repository and
+// returns the signer of the chart.
+//
+// Charts of Git and HTTP repositories are verified with their Helm provenance file and charts of OCI repositories
+// with their cosign signature. An empty signer and no error are returned if the repository has no verification policy,
+// or if the chart could not be verified and the policy is only auditing.
+func (c *Manager) Verify(namespace, name, chartName, version string, chartData []byte) (string, error) {
+	repo, err := c.getRepo(namespace, name)
+	if err != nil {
+		return "", err
+	}
+	policy := repo.spec.Verification
+	if policy == nil {
+		return "", nil
+	}
+
+	signer, err := c.verify(repo, namespace, name, chartName, version, chartData)
+	if err != nil {
+		if verify.Enforced(policy) {
+			return "", fmt.Errorf("refusing chart %s version %s from repository %s: %w", chartName, version, name, err)
+		}
+		logrus.Warnf("[catalog] chart %s version %s from repository %s is not verified: %v", chartName, version, name, err)
+		return "", nil
+	}
+
+	logrus.Infof("[catalog] chart %s version %s from repository %s is signed by %s", chartName, version, name, signer)
+	return signer, nil
+}
+
+// CheckVerified checks the signer recorded for a chart of a specific Helm repository when it was installed, such as
+// the chart of a previous revision of a release, against the verification policy of the repository.
+//
+// A chart without a recorded signer is refused if the policy is enforced, and only logged if the policy is auditing.
+func (c *Manager) CheckVerified(namespace, name, chartName, version, signer string) error {
+	repo, err := c.getRepo(namespace, name)
+	if err != nil {
+		return err
+	}
+	policy := repo.spec.Verification
+	if policy == nil || signer != "" {
+		return nil
+	}
+
+	if verify.Enforced(policy) {
+		return fmt.Errorf("refusing chart %s version %s from repository %s: chart was not verified when it was installed", chartName, version, name)
+	}
+	logrus.Warnf("[catalog] chart %s version %s from repository %s was not verified when it was installed", chartName, version, name)
+	return nil
+}
+
+func (c *Manager) verify(repo repoDef, namespace, name, chartName, version string, chartData []byte) (string, error) {
+	keyringSecret, err := catalogv2.GetKeyringSecret(c.secrets, repo.spec, repo.metadata.Namespace)
+	if err != nil {
+		return "", err
+	}
+	keyring, err := verify.NewKeyring(keyringSecret)
+	if err != nil {
+		return "", err
+	}
+
+	index, err := c.Index(namespace, name, "", true)
+	if err != nil {
+		return "", err
+	}
+	chart, err := index.Get(chartName, version)
+	if err != nil {
+		return "", err
+	}
+	if len(chart.URLs) == 0 {
+		return "", errors.New("chart has no urls specified")
+	}
+
+	if repo.status.Commit != "" {
+		prov, err := git.Provenance(namespace, name, repo.status.URL, chart)
+		if err != nil {
+			return "", err
+		}
+		return keyring.Provenance(path.Base(chart.URLs[0]), chartData, prov)
+	}
+
+	secret, err := catalogv2.GetSecret(c.secrets, repo.spec, repo.metadata.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	if registry.IsOCI(chart.URLs[0]) {
+		digest, signatures, err := oci.CosignSignatures(secret, chart, *repo.spec)
+		if err != nil {
+			return "", err
+		}
+		return keyring.Cosign(digest, signatures)
+	}
+
+	prov, err := helmhttp.Provenance(secret, repo.status.URL, repo.spec.CABundle, repo.spec.InsecureSkipTLSverify, repo.spec.DisableSameOriginCheck, chart)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(chart.URLs[0])
+	if err != nil {
+		return "", err
+	}
+	return keyring.Provenance(path.Base(u.Path), chartData, prov)
+}
+
 // getRepo returns a cluster repository based on the name
 //
 // namespace should never be empty
@@ -53,6 +53,27 @@ func Chart(namespace, name, gitURL string, chartVersion *repo.ChartVersion) (io.
 	return archive.Open()
 }
 
+// Provenance returns the content of the provenance file stored next to the chart archive in a local repository, or
+// nil if there is none. Charts stored as directories are packaged on the fly and never have a provenance file.
+func Provenance(namespace, name, gitURL string, chartVersion *repo.ChartVersion) ([]byte, error) {
+	dir := RepoDir(namespace, name, gitURL)
+
+	if len(chartVersion.URLs) == 0 {
+		return nil, fmt.Errorf("failed to find chartName %s version %s: %w", chartVersion.Name, chartVersion.Version, validation.NotFound)
+	}
+
+	file, err := relative(dir, gitURL, chartVersion.URLs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(file + ".prov")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
 func relative(base, publicURL, path string) (string, error) {
 	path = strings.TrimPrefix(path, publicURL)
 	path = strings.TrimPrefix(path, "file://")
@@ -0,0 +1,129 @@
+package helmop
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/rancher/apiserver/pkg/types"
+	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	valueAdded   = "added"
+	valueRemoved = "removed"
+	valueChanged = "changed"
+)
+
+// History lists the revisions of the release backing the given app, along with the values changed by each revision.
+// The release secrets are read with the permissions of the user making the request as they contain the release values.
+func (s *Operations) History(apiRequest *types.APIRequest, namespace, name string) (*types2.ReleaseHistory, error) {
+	rel, err := s.apps.Get(namespace, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.cg.K8sInterface(apiRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(apiRequest.Context(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			"owner": "helm",
+			"name":  rel.Spec.Name,
+		}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var releases []*catalog.ReleaseSpec
+	for i := range secrets.Items {
+		release, err := helm.ToRelease(&secrets.Items[i], func(schema.GroupVersionKind) bool { return true })
+		if err == helm.ErrNotHelmRelease {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+
+	return releaseHistory(releases), nil
+}
+
+// releaseHistory sorts the given releases by revision and computes the value changes between consecutive revisions.
+func releaseHistory(releases []*catalog.ReleaseSpec) *types2.ReleaseHistory {
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Version < releases[j].Version
+	})
+
+	history := &types2.ReleaseHistory{}
+	var previous map[string]interface{}
+	for _, release := range releases {
+		revision := types2.ReleaseRevision{
+			Revision: release.Version,
+		}
+		if release.Info != nil {
+			revision.Status = string(release.Info.Status)
+			revision.Updated = release.Info.LastDeployed
+			revision.Description = release.Info.Description
+		}
+		if release.Chart != nil && release.Chart.Metadata != nil {
+			revision.Chart = release.Chart.Metadata.Name
+			revision.ChartVersion = release.Chart.Metadata.Version
+			revision.AppVersion = release.Chart.Metadata.AppVersion
+		}
+
+		current := map[string]interface{}{}
+		flattenValues("", release.Values, current)
+		revision.Changes = diffValues(previous, current)
+		previous = current
+
+		history.Revisions = append(history.Revisions, revision)
+	}
+	return history
+}
+
+// flattenValues stores the leaves of values in result, keyed by their dotted path. Lists are treated as leaves.
+func flattenValues(prefix string, values map[string]interface{}, result map[string]interface{}) {
+	for k, v := range values {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenValues(key, nested, result)
+			continue
+		}
+		result[key] = v
+	}
+}
+
+// diffValues returns the changes needed to go from the previous flattened values to the current ones, sorted by key.
+func diffValues(previous, current map[string]interface{}) []types2.ValueChange {
+	var changes []types2.ValueChange
+	for key, value := range current {
+		old, ok := previous[key]
+		switch {
+		case !ok:
+			changes = append(changes, types2.ValueChange{Key: key, Type: valueAdded, Current: value})
+		case !reflect.DeepEqual(old, value):
+			changes = append(changes, types2.ValueChange{Key: key, Type: valueChanged, Previous: old, Current: value})
+		}
+	}
+	for key, value := range previous {
+		if _, ok := current[key]; !ok {
+			changes = append(changes, types2.ValueChange{Key: key, Type: valueRemoved, Previous: value})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
@@ -0,0 +1,61 @@
+package helmop
+
+import (
+	"testing"
+
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_releaseHistory(t *testing.T) {
+	releases := []*catalog.ReleaseSpec{
+		{
+			Version: 2,
+			Info:    &catalog.Info{Status: catalog.StatusDeployed, Description: "Upgrade complete"},
+			Chart:   &catalog.Chart{Metadata: &catalog.Metadata{Name: "test-chart", Version: "1.1.0", AppVersion: "v2"}},
+			Values: map[string]interface{}{
+				"image": map[string]interface{}{
+					"tag": "v2",
+				},
+				"replicas": 3,
+				"hosts":    []interface{}{"a", "b"},
+			},
+		},
+		{
+			Version: 1,
+			Info:    &catalog.Info{Status: catalog.StatusSuperseded, Description: "Install complete"},
+			Chart:   &catalog.Chart{Metadata: &catalog.Metadata{Name: "test-chart", Version: "1.0.0", AppVersion: "v1"}},
+			Values: map[string]interface{}{
+				"image": map[string]interface{}{
+					"tag": "v1",
+				},
+				"debug": true,
+				"hosts": []interface{}{"a"},
+			},
+		},
+	}
+
+	history := releaseHistory(releases)
+
+	assert.Len(t, history.Revisions, 2)
+	assert.Equal(t, types.ReleaseRevision{
+		Revision:     1,
+		Status:       "superseded",
+		Chart:        "test-chart",
+		ChartVersion: "1.0.0",
+		AppVersion:   "v1",
+		Description:  "Install complete",
+		Changes: []types.ValueChange{
+			{Key: "debug", Type: valueAdded, Current: true},
+			{Key: "hosts", Type: valueAdded, Current: []interface{}{"a"}},
+			{Key: "image.tag", Type: valueAdded, Current: "v1"},
+		},
+	}, history.Revisions[0])
+	assert.Equal(t, []types.ValueChange{
+		{Key: "debug", Type: valueRemoved, Previous: true},
+		{Key: "hosts", Type: valueChanged, Previous: []interface{}{"a"}, Current: []interface{}{"a", "b"}},
+		{Key: "image.tag", Type: valueChanged, Previous: "v1", Current: "v2"},
+		{Key: "replicas", Type: valueAdded, Current: 3},
+	}, history.Revisions[1].Changes)
+}
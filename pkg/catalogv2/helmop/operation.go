@@ -16,15 +16,19 @@
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode/utf8"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
 	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/catalogv2/content"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	namespaces "github.com/rancher/rancher/pkg/namespace"
 	"github.com/rancher/rancher/pkg/settings"
@@ -41,6 +45,7 @@
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -151,10 +156,14 @@ type Operations struct {
 	ops            catalogcontrollers.OperationClient   // client for operation custom resource
 	pods           corev1controllers.PodClient          // client for pod kubernetes resource
 	nodes          corev1controllers.NodeClient
+	secrets        corev1controllers.SecretClient      // client for secret kubernetes resource, holding the helm releases
 	apps           catalogcontrollers.AppClient        // client for apps custom resource
 	roles          rbacv1controllers.RoleClient        // client for role kubernetes resource
 	roleBindings   rbacv1controllers.RoleBindingClient // client for rolebinding kubernetes resource
 	cg             proxy.ClientGetter                  // dynamic kubernetes client factory
+
+	// checkChange rejects the operations of users not allowed to change the cluster
+	checkChange func(ctx context.Context, user user.Info) error
 }
 
 // NewOperations creates a new Operations struct with all fields initialized
@@ -164,7 +173,8 @@ func NewOperations(
 	rbac rbacv1controllers.Interface,
 	contentManager *content.Manager,
 	pods corev1controllers.PodClient,
-	nodes corev1controllers.NodeClient) *Operations {
+	nodes corev1controllers.NodeClient,
+	secrets corev1controllers.SecretClient) *Operations {
 	return &Operations{
 		cg:             cg,
 		contentManager: contentManager,
@@ -177,12 +187,30 @@ func NewOperations(
 		roleBindings:   rbac.RoleBinding(),
 		roles:          rbac.Role(),
 		nodes:          nodes,
+		secrets:        secrets,
+	}
+}
+
+// SetChangeCheck sets the check run before every operation changing the cluster, such as its change freeze. An error
+// returned by check rejects the operation.
+func (s *Operations) SetChangeCheck(check func(ctx context.Context, user user.Info) error) {
+	s.checkChange = check
+}
+
+// allowChange runs the change check, if any.
+func (s *Operations) allowChange(ctx context.Context, user user.Info) error {
+	if s.checkChange == nil {
+		return nil
 	}
+	return s.checkChange(ctx, user)
 }
 
 // Uninstall gets the uninstall commands using the given namespace, name and options and gets the user information using the isApp flag as true.
 // Returns a catalog.Operation that represents the helm operation to be created
 func (s *Operations) Uninstall(ctx context.Context, user user.Info, namespace, name string, options io.Reader, imageOverride string) (*catalog.Operation, error) {
+	if err := s.allowChange(ctx, user); err != nil {
+		return nil, err
+	}
 	status, cmds, err := s.getUninstallArgs(namespace, name, options)
 	if err != nil {
 		return nil, err
@@ -203,9 +231,38 @@ func (s *Operations) Uninstall(ctx context.Context, user user.Info, namespace, n
 	return s.createOperation(ctx, user, status, cmds, imageOverride)
 }
 
+// Rollback gets the rollback commands using the given namespace, name and options and gets the user information using the isApp flag as true.
+// Returns a catalog.Operation that represents the helm operation to be created
+func (s *Operations) Rollback(ctx context.Context, user user.Info, namespace, name string, options io.Reader, imageOverride string) (*catalog.Operation, error) {
+	if err := s.allowChange(ctx, user); err != nil {
+		return nil, err
+	}
+	status, cmds, err := s.getRollbackArgs(namespace, name, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if status.AutomaticCPTolerations {
+		status.Tolerations, err = s.AddCpTaintsToTolerations(status.Tolerations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add tolerations for CP nodes: %w", err)
+		}
+	}
+
+	user, err = s.getUser(user, namespace, name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createOperation(ctx, user, status, cmds, imageOverride)
+}
+
 // Upgrade gets the upgrade commands using the given namespace, name and options and gets the user using the isApp flag as false.
 // Returns a catalog.Operation that represents the helm operation to be created
 func (s *Operations) Upgrade(ctx context.Context, user user.Info, namespace, name string, options io.Reader, imageOverride string) (*catalog.Operation, error) {
+	if err := s.allowChange(ctx, user); err != nil {
+		return nil, err
+	}
 	status, cmds, err := s.getUpgradeCommand(namespace, name, options)
 	if err != nil {
 		return nil, err
@@ -229,6 +286,9 @@ func (s *Operations) Upgrade(ctx context.Context, user user.Info, namespace, nam
 // Install gets the install commands using the given namespace, name and options and gets the user using the isApp flag as false.
 // Returns a catalog.Operation that represents the helm operation to be created
 func (s *Operations) Install(ctx context.Context, user user.Info, namespace, name string, options io.Reader, imageOverride string) (*catalog.Operation, error) {
+	if err := s.allowChange(ctx, user); err != nil {
+		return nil, err
+	}
 	status, cmds, err := s.getInstallCommand(namespace, name, options)
 	if err != nil {
 		return nil, err
@@ -427,6 +487,81 @@ func (s *Operations) getUninstallArgs(appNamespace, appName string, body io.Read
 	return status, Commands{cmd}, nil
 }
 
+// getRollbackArgs receives the app namespace, app name and body of the request.
+// Returns a rollback Command according to the input received and also returns the status of the operation that will be created
+// to run the command
+func (s *Operations) getRollbackArgs(appNamespace, appName string, body io.Reader) (catalog.OperationStatus, Commands, error) {
+	rel, err := s.apps.Get(appNamespace, appName, metav1.GetOptions{})
+	if err != nil {
+		return catalog.OperationStatus{}, nil, err
+	}
+
+	rollbackArgs := &types2.ChartRollbackAction{}
+	if err := json.NewDecoder(body).Decode(rollbackArgs); err != nil {
+		return catalog.OperationStatus{}, nil, err
+	}
+
+	if rollbackArgs.Revision < 0 || rollbackArgs.Revision > rel.Spec.Version {
+		return catalog.OperationStatus{}, nil, apierror.NewAPIError(validation.InvalidBodyContent,
+			fmt.Sprintf("revision %d does not exist for release %s", rollbackArgs.Revision, rel.Spec.Name))
+	}
+	if rollbackArgs.MaxHistory == 0 {
+		rollbackArgs.MaxHistory = 5
+	}
+	if err := s.verifyRollback(rel, rollbackArgs.Revision); err != nil {
+		return catalog.OperationStatus{}, nil, err
+	}
+
+	cmd := Command{
+		Operation: "rollback",
+		ArgObjects: []interface{}{
+			rollbackArgs,
+		},
+		ReleaseName:      rel.Spec.Name,
+		ReleaseNamespace: rel.Namespace,
+		Revision:         rollbackArgs.Revision,
+	}
+
+	status := catalog.OperationStatus{
+		Action:                 cmd.Operation,
+		Release:                rel.Spec.Name,
+		Namespace:              appNamespace,
+		Tolerations:            rollbackArgs.OperationTolerations,
+		AutomaticCPTolerations: rollbackArgs.AutomaticCPTolerations,
+	}
+
+	return status, Commands{cmd}, nil
+}
+
+// verifyRollback checks the chart of the revision a release is rolled back to against the verification policy of the
+// ClusterRepo it was installed from. The chart isn't downloaded again by a rollback, so the signer recorded on the chart
+// when the revision was installed is checked instead.
+func (s *Operations) verifyRollback(rel *catalog.App, revision int) error {
+	if revision == 0 {
+		// helm rolls back to the previous revision
+		revision = rel.Spec.Version - 1
+	}
+	secret, err := s.secrets.Get(rel.Namespace, fmt.Sprintf("sh.helm.release.v1.%s.v%d", rel.Spec.Name, revision), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	// charts which weren't installed from a ClusterRepo aren't verified
+	repoName := secret.Labels[catalog.ClusterRepoNameLabel]
+	if repoName == "" {
+		return nil
+	}
+
+	target, err := helm.ToRelease(secret, func(schema.GroupVersionKind) bool { return true })
+	if err != nil {
+		return err
+	}
+	if target.Chart == nil || target.Chart.Metadata == nil {
+		return fmt.Errorf("revision %d of release %s has no chart", revision, rel.Spec.Name)
+	}
+	metadata := target.Chart.Metadata
+	return s.contentManager.CheckVerified("", repoName, metadata.Name, metadata.Version, metadata.Annotations[verify.VerifiedSignerAnnotation])
+}
+
 // getUpgradeCommand receives the repository namespace and name and body of the request.
 // Returns the status of the operation that will be created and a list of Command to upgrade the charts received in the request
 func (s *Operations) getUpgradeCommand(repoNamespace, repoName string, body io.Reader) (catalog.OperationStatus, Commands, error) {
@@ -485,6 +620,7 @@ type Command struct {
 	Chart            []byte        // content of the chart file
 	ReleaseName      string        // name of the release
 	ReleaseNamespace string        // namespace of the release
+	Revision         int           // revision of the release to roll back to, the previous one if 0
 	Kustomize        bool          // flag to inform if it should use kustomize.sh
 }
 
@@ -579,6 +715,7 @@ func (c Command) renderArgs() ([]string, error) {
 	delete(dataMap, "projectId")
 	delete(dataMap, "operationTolerations")
 	delete(dataMap, "automaticCPTolerations")
+	delete(dataMap, "revision")
 	if v, ok := dataMap["disableOpenAPIValidation"]; ok {
 		delete(dataMap, "disableOpenAPIValidation")
 		dataMap["disableOpenapiValidation"] = v
@@ -616,6 +753,9 @@ func (c Command) renderArgs() ([]string, error) {
 	if c.ReleaseName != "" {
 		args = append(args, c.ReleaseName)
 	}
+	if c.Revision > 0 {
+		args = append(args, strconv.Itoa(c.Revision))
+	}
 	if len(c.Chart) > 0 {
 		args = append(args, filepath.Join(runPath, c.ChartFile))
 	}
@@ -704,6 +844,10 @@ func addAnnotations(data []byte, annotations map[string]string) ([]byte, error)
 		chartAnnotations = map[string]interface{}{}
 	}
 	for k, v := range annotations {
+		if k == verify.VerifiedSignerAnnotation && v == "" {
+			delete(chartAnnotations, k)
+			continue
+		}
 		chartAnnotations[k] = v
 	}
 
@@ -711,6 +855,17 @@ func addAnnotations(data []byte, annotations map[string]string) ([]byte, error)
 	return yaml.Marshal(chartData)
 }
 
+// withVerifiedSigner returns a copy of the annotations recording the verified signer of the chart. The annotation is
+// always set, and removed from the chart when empty, so that a chart can not claim a signer it was not verified for.
+func withVerifiedSigner(annotations map[string]string, signer string) map[string]string {
+	result := make(map[string]string, len(annotations)+1)
+	for k, v := range annotations {
+		result[k] = v
+	}
+	result[verify.VerifiedSignerAnnotation] = signer
+	return result
+}
+
 // enableKustomize returns whether kustomize should be used. If the helm operation is
 // an upgrade and the migrated annotation is present, true will be returned.
 func (s *Operations) enableKustomize(annotations map[string]string, upgrade bool) bool {
@@ -743,7 +898,12 @@ func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion st
 		return Command{}, err
 	}
 
-	chartData, err = injectAnnotation(chartData, annotations)
+	signer, err := s.contentManager.Verify(namespace, name, chartName, chartVersion, chartData)
+	if err != nil {
+		return Command{}, err
+	}
+
+	chartData, err = injectAnnotation(chartData, withVerifiedSigner(annotations, signer))
 	if err != nil {
 		return Command{}, err
 	}
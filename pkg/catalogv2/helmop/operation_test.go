@@ -1,13 +1,26 @@
 package helmop
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	corev1 "k8s.io/api/core/v1"
 	"strings"
 	"testing"
 
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/content"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 type testCase struct {
@@ -156,6 +169,25 @@ func Test_Render(t *testing.T) {
 			},
 			failMsg: "operation toleration test case failed",
 		},
+		{
+			commands: Commands{
+				Command{
+					Operation:        "rollback",
+					ReleaseName:      "test7",
+					ReleaseNamespace: "test-ns",
+					Revision:         3,
+					ArgObjects: []interface{}{&types.ChartRollbackAction{
+						Revision:   3,
+						Wait:       true,
+						MaxHistory: 5,
+					}},
+				},
+			},
+			expected: map[string][]byte{
+				"operation000": []byte(strings.Join([]string{"rollback", "--history-max=5", "--namespace=test-ns", "--wait=true", "test7", "3"}, "\x00")),
+			},
+			failMsg: "rollback test case failed",
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -327,3 +359,134 @@ func Test_mergeTolerations(t *testing.T) {
 		asserts.ElementsMatch(resp, t.expected, t.name)
 	}
 }
+
+func Test_addAnnotationsVerifiedSigner(t *testing.T) {
+	chartYAML := []byte("name: mychart\nversion: 1.0.0\nannotations:\n  catalog.cattle.io/verified-signer: forged\n  foo: bar\n")
+
+	testCases := []struct {
+		name     string
+		signer   string
+		expected map[string]interface{}
+	}{
+		{
+			name:     "unverified chart can not claim a signer",
+			expected: map[string]interface{}{"foo": "bar"},
+		},
+		{
+			name:     "verified signer replaces the claimed one",
+			signer:   "Platform Team <platform@example.com>",
+			expected: map[string]interface{}{"foo": "bar", "catalog.cattle.io/verified-signer": "Platform Team <platform@example.com>"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := addAnnotations(chartYAML, withVerifiedSigner(nil, tc.signer))
+			assert.NoError(t, err)
+
+			chart := map[string]interface{}{}
+			assert.NoError(t, yaml.Unmarshal(data, &chart))
+			assert.Equal(t, tc.expected, chart["annotations"])
+		})
+	}
+}
+
+func Test_verifyRollback(t *testing.T) {
+	releaseSecret := func(revision int, repo, signer string) *corev1.Secret {
+		annotations := map[string]string{}
+		if signer != "" {
+			annotations[verify.VerifiedSignerAnnotation] = signer
+		}
+		data, err := json.Marshal(&release.Release{
+			Name:      "mychart",
+			Namespace: "default",
+			Version:   revision,
+			Info:      &release.Info{Status: release.StatusSuperseded},
+			Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "mychart", Version: "1.0.0", Annotations: annotations}},
+		})
+		require.NoError(t, err)
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("sh.helm.release.v1.mychart.v%d", revision),
+				Namespace: "default",
+				Labels:    map[string]string{"owner": "helm", "name": "mychart"},
+			},
+			Data: map[string][]byte{"release": []byte(base64.StdEncoding.EncodeToString(data))},
+		}
+		if repo != "" {
+			secret.Labels[catalog.ClusterRepoNameLabel] = repo
+		}
+		return secret
+	}
+
+	testCases := []struct {
+		name     string
+		revision int
+		secret   *corev1.Secret
+		mode     catalog.VerificationMode
+		wantErr  string
+	}{
+		{
+			name:     "verified revision",
+			revision: 1,
+			secret:   releaseSecret(1, "signed", "Platform Team <platform@example.com>"),
+		},
+		{
+			name:     "unverified revision is refused",
+			revision: 1,
+			secret:   releaseSecret(1, "signed", ""),
+			wantErr:  "refusing chart mychart version 1.0.0 from repository signed",
+		},
+		{
+			name:     "unverified previous revision is refused",
+			revision: 0,
+			secret:   releaseSecret(2, "signed", ""),
+			wantErr:  "refusing chart mychart version 1.0.0 from repository signed",
+		},
+		{
+			name:     "unverified revision is allowed when auditing",
+			revision: 1,
+			secret:   releaseSecret(1, "signed", ""),
+			mode:     catalog.VerificationModeAudit,
+		},
+		{
+			name:     "revision not installed from a cluster repo",
+			revision: 1,
+			secret:   releaseSecret(1, "", ""),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			secrets := fake.NewMockClientInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+			secrets.EXPECT().Get("default", gomock.Any(), gomock.Any()).DoAndReturn(func(namespace, name string, _ metav1.GetOptions) (*corev1.Secret, error) {
+				if name != tc.secret.Name {
+					return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+				}
+				return tc.secret, nil
+			})
+			clusterRepos := fake.NewMockNonNamespacedCacheInterface[*catalog.ClusterRepo](ctrl)
+			clusterRepos.EXPECT().Get("signed").Return(&catalog.ClusterRepo{
+				ObjectMeta: metav1.ObjectMeta{Name: "signed"},
+				Spec:       catalog.RepoSpec{Verification: &catalog.RepoVerification{Mode: tc.mode}},
+			}, nil).AnyTimes()
+
+			s := &Operations{
+				contentManager: content.NewManager(nil, nil, nil, clusterRepos),
+				secrets:        secrets,
+			}
+			rel := &catalog.App{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "mychart"},
+				Spec:       catalog.ReleaseSpec{Name: "mychart", Version: 3},
+			}
+
+			err := s.verifyRollback(rel, tc.revision)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -20,6 +20,9 @@
 	corev1 "k8s.io/api/core/v1"
 )
 
+// maxProvenanceSize bounds the size of a downloaded provenance file, which only holds the chart metadata and digest.
+const maxProvenanceSize = 1024 * 1024
+
 func Icon(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool, disableSameOriginCheck bool, chart *repo.ChartVersion) (io.ReadCloser, string, error) {
 	if len(chart.URLs) == 0 {
 		return nil, "", fmt.Errorf("failed to find chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
@@ -77,6 +80,58 @@ func Chart(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipT
 	}
 	defer client.CloseIdleConnections()
 
+	u, err := chartURL(repoURL, chart)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	return io.NopCloser(bytes.NewBuffer(data)), err
+}
+
+// Provenance returns the content of the provenance file of the chart, which Helm repositories serve next to the chart
+// archive with the ".prov" extension. It returns nil if the chart has no provenance file.
+func Provenance(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool, disableSameOriginCheck bool, chart *repo.ChartVersion) ([]byte, error) {
+	if len(chart.URLs) == 0 {
+		return nil, fmt.Errorf("failed to find chartName %s version %s: %w", chart.Name, chart.Version, validation.NotFound)
+	}
+
+	client, err := HelmClient(secret, caBundle, insecureSkipTLSVerify, disableSameOriginCheck, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	defer client.CloseIdleConnections()
+
+	u, err := chartURL(repoURL, chart)
+	if err != nil {
+		return nil, err
+	}
+	u.Path += ".prov"
+
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(io.LimitReader(resp.Body, maxProvenanceSize))
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to download provenance of chart %s version %s: %s", chart.Name, chart.Version, resp.Status)
+	}
+}
+
+// chartURL returns the URL of the chart archive, resolved against the repository URL if it is relative.
+func chartURL(repoURL string, chart *repo.ChartVersion) (*url.URL, error) {
 	u, err := url.Parse(chart.URLs[0])
 	if err != nil {
 		return nil, err
@@ -94,15 +149,7 @@ func Chart(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipT
 		// contain an access credential.
 		u.RawQuery = base.RawQuery
 	}
-
-	resp, err := client.Get(u.String())
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	data, err := io.ReadAll(resp.Body)
-	return io.NopCloser(bytes.NewBuffer(data)), err
+	return u, nil
 }
 
 func DownloadIndex(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool, disableSameOriginCheck bool) (*repo.IndexFile, error) {
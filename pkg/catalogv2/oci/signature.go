@@ -0,0 +1,79 @@
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	ocispecv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
+	"helm.sh/helm/v3/pkg/repo"
+	corev1 "k8s.io/api/core/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+)
+
+// maxSignatureSize bounds the size of the manifest and of the payloads of a cosign signature.
+const maxSignatureSize int64 = 1024 * 1024
+
+// CosignSignatures returns the digest of the manifest of the chart and the cosign signatures attached to it. Cosign
+// stores the signatures of a manifest in the same repository, under the tag derived from its digest.
+func CosignSignatures(credentialSecret *corev1.Secret, chart *repo.ChartVersion, clusterRepoSpec v1.RepoSpec) (string, []verify.CosignSignature, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chartURL := chart.URLs[0]
+
+	ociClient, err := NewClient(chartURL, clusterRepoSpec, credentialSecret)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create an OCI client for url %s: %w", chartURL, err)
+	}
+
+	orasRepository, err := ociClient.GetOrasRepository()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create an OCI repository for url %s: %w", chartURL, err)
+	}
+
+	manifest, err := orasRepository.Resolve(ctx, ociClient.tag)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to resolve the remote OCI artifact %s: %w", chartURL, err)
+	}
+	digest := manifest.Digest.String()
+
+	signatureTag := strings.Replace(digest, ":", "-", 1) + ".sig"
+	_, signatureBlob, err := oras.FetchBytes(ctx, orasRepository, signatureTag, oras.FetchBytesOptions{MaxBytes: maxSignatureSize})
+	if errors.Is(err, errdef.ErrNotFound) {
+		return digest, nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to fetch the signature of %s: %w", chartURL, err)
+	}
+
+	var signatureManifest ocispecv1.Manifest
+	if err := json.Unmarshal(signatureBlob, &signatureManifest); err != nil {
+		return "", nil, fmt.Errorf("unable to unmarshal the signature manifest of %s: %w", chartURL, err)
+	}
+
+	var signatures []verify.CosignSignature
+	for _, layer := range signatureManifest.Layers {
+		signature, ok := layer.Annotations[verify.CosignSignatureAnnotation]
+		if layer.MediaType != verify.CosignSimpleSigningMediaType || !ok {
+			continue
+		}
+		if layer.Size > maxSignatureSize {
+			return "", nil, fmt.Errorf("the signature payload of %s has size more than %d which is not supported", chartURL, maxSignatureSize)
+		}
+		payload, err := content.FetchAll(ctx, orasRepository, layer)
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to fetch the signature payload of %s: %w", chartURL, err)
+		}
+		signatures = append(signatures, verify.CosignSignature{Payload: payload, Signature: signature})
+	}
+
+	return digest, signatures, nil
+}
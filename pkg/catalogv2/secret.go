@@ -1,6 +1,8 @@
 package catalogv2
 
 import (
+	"fmt"
+
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	corev1controllers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -18,3 +20,16 @@ func GetSecret(secrets corev1controllers.SecretCache, repoSpec *v1.RepoSpec, rep
 
 	return secrets.Get(ns, repoSpec.ClientSecret.Name)
 }
+
+// GetKeyringSecret returns the Secret holding the keys trusted by the cluster repo's verification policy
+func GetKeyringSecret(secrets corev1controllers.SecretCache, repoSpec *v1.RepoSpec, repoNamespace string) (*corev1.Secret, error) {
+	if repoSpec.Verification == nil || repoSpec.Verification.KeyringSecret == nil {
+		return nil, fmt.Errorf("verification policy has no keyring secret")
+	}
+	ns := repoSpec.Verification.KeyringSecret.Namespace
+	if repoNamespace != "" {
+		ns = repoNamespace
+	}
+
+	return secrets.Get(ns, repoSpec.Verification.KeyringSecret.Name)
+}
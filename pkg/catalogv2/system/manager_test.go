@@ -73,6 +73,23 @@ func TestStart(t *testing.T) {
 	manager.Start(ctx)
 }
 
+func TestReady(t *testing.T) {
+	t.Parallel()
+
+	webhook := desiredKey{namespace: "cattle-system", chartName: "rancher-webhook", releaseName: "rancher-webhook"}
+	manager := Manager{}
+	assert.EqualError(t, manager.Ready(), "system charts manager has not been started")
+
+	manager.started = true
+	assert.NoError(t, manager.Ready())
+
+	manager.setFailure(webhook, errors.New("timed out"))
+	assert.EqualError(t, manager.Ready(), "failed to install system charts: cattle-system/rancher-webhook: timed out")
+
+	manager.setFailure(webhook, nil)
+	assert.NoError(t, manager.Ready())
+}
+
 func TestInstallCharts(t *testing.T) {
 	var (
 		fleetChartV1 = release.Release{
@@ -7,7 +7,10 @@
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
@@ -89,6 +92,11 @@ type Manager struct {
 	trigger               chan struct{}
 	clusterRepos          catalogcontrollers.ClusterRepoController
 	helmClient            HelmClient
+
+	// lock guards started and failures, which are read by readiness checks.
+	lock     sync.Mutex
+	started  bool
+	failures map[desiredKey]error
 }
 
 func NewManager(ctx context.Context,
@@ -120,6 +128,10 @@ func (m *Manager) Start(ctx context.Context) {
 	m.ctx = ctx
 	go m.runSync()
 
+	m.lock.Lock()
+	m.started = true
+	m.lock.Unlock()
+
 	m.settings.OnChange(ctx, "system-feature-chart-refresh", m.onSetting)
 	m.clusterRepos.OnChange(ctx, "catalog-refresh-trigger", m.onTrigger)
 }
@@ -201,6 +213,9 @@ func (m *Manager) installCharts(charts map[desiredKey]map[string]interface{}, ta
 			} else if err != nil {
 				logrus.Errorf("Failed to install system chart %s (release name: %s): %v", key.chartName, key.releaseName, err)
 				errs = append(errs, err)
+				m.setFailure(key, err)
+			} else {
+				m.setFailure(key, nil)
 			}
 			break
 		}
@@ -208,6 +223,38 @@ func (m *Manager) installCharts(charts map[desiredKey]map[string]interface{}, ta
 	return merr.NewErrors(errs...)
 }
 
+func (m *Manager) setFailure(key desiredKey, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if err == nil {
+		delete(m.failures, key)
+		return
+	}
+	if m.failures == nil {
+		m.failures = map[desiredKey]error{}
+	}
+	m.failures[key] = err
+}
+
+// Ready returns an error if the manager has not been started, or if the last installation of any system chart failed.
+func (m *Manager) Ready() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.started {
+		return errors.New("system charts manager has not been started")
+	}
+	var failed []string
+	for key, err := range m.failures {
+		failed = append(failed, fmt.Sprintf("%s/%s: %v", key.namespace, key.releaseName, err))
+	}
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		return fmt.Errorf("failed to install system charts: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
 func (m *Manager) Uninstall(namespace, name string) error {
 	if ok, err := m.hasStatus(namespace, name, action.ListDeployed|action.ListFailed); err != nil {
 		return err
@@ -0,0 +1,228 @@
+// Package verify authenticates the charts of a ClusterRepo according to its verification policy. Charts served over
+// HTTP or Git are verified with their Helm provenance file, charts served from an OCI registry with their cosign
+// signature.
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"golang.org/x/crypto/openpgp" //nolint
+	"helm.sh/helm/v3/pkg/provenance"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// VerifiedSignerAnnotation is set by Rancher on the Chart.yaml of a chart installed from a ClusterRepo with a
+	// verification policy to record the signer of the chart.
+	VerifiedSignerAnnotation = "catalog.cattle.io/verified-signer"
+
+	// CosignSignatureAnnotation is the annotation of a cosign signature layer holding the base64 encoded signature.
+	CosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// CosignSimpleSigningMediaType is the media type of the payload signed by cosign.
+	CosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	pgpArmorHeader = "-----BEGIN PGP"
+)
+
+// ErrNoSignature is returned when a chart has no provenance file or signature to verify.
+var ErrNoSignature = errors.New("chart is not signed")
+
+// Keyring holds the keys trusted by the verification policy of a ClusterRepo.
+type Keyring struct {
+	// PGP is used to verify Helm provenance files.
+	PGP openpgp.EntityList
+	// PublicKeys maps the name of each cosign public key to the key.
+	PublicKeys map[string]crypto.PublicKey
+}
+
+// CosignSignature is a signature attached to an OCI artifact by cosign.
+type CosignSignature struct {
+	// Payload is the simple signing payload, referencing the digest of the signed manifest.
+	Payload []byte
+	// Signature is the base64 encoded signature of the payload.
+	Signature string
+}
+
+// Enforced returns true if unverified charts must be refused by the policy.
+func Enforced(policy *v1.RepoVerification) bool {
+	return policy != nil && policy.Mode != v1.VerificationModeAudit
+}
+
+// NewKeyring loads the trusted keys from the data of the keyring secret of a verification policy. Each value is
+// either a PGP keyring, armored or binary, or a PEM encoded public key.
+func NewKeyring(secret *corev1.Secret) (*Keyring, error) {
+	keyring := &Keyring{PublicKeys: map[string]crypto.PublicKey{}}
+
+	names := make([]string, 0, len(secret.Data))
+	for name := range secret.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		data := secret.Data[name]
+		if block, _ := pem.Decode(data); block != nil && !bytes.Contains(data, []byte(pgpArmorHeader)) {
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse public key %s: %w", name, err)
+			}
+			keyring.PublicKeys[name] = key
+			continue
+		}
+
+		read := openpgp.ReadKeyRing
+		if bytes.Contains(data, []byte(pgpArmorHeader)) {
+			read = openpgp.ReadArmoredKeyRing
+		}
+		entities, err := read(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyring %s: %w", name, err)
+		}
+		keyring.PGP = append(keyring.PGP, entities...)
+	}
+
+	if len(keyring.PGP) == 0 && len(keyring.PublicKeys) == 0 {
+		return nil, fmt.Errorf("keyring secret %s/%s has no keys", secret.Namespace, secret.Name)
+	}
+	return keyring, nil
+}
+
+// Provenance verifies the Helm provenance file of a chart archive and returns the identity of its signer. The
+// filename is the name of the archive as listed in the provenance file.
+func (k *Keyring) Provenance(filename string, chart, prov []byte) (string, error) {
+	if len(prov) == 0 {
+		return "", ErrNoSignature
+	}
+	if len(k.PGP) == 0 {
+		return "", fmt.Errorf("no PGP keyring to verify the provenance of %s", filename)
+	}
+
+	// the provenance package only verifies files
+	dir, err := os.MkdirTemp("", "chart-provenance")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	chartPath := filepath.Join(dir, filepath.Base(filename))
+	if err := os.WriteFile(chartPath, chart, 0o600); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(chartPath+".prov", prov, 0o600); err != nil {
+		return "", err
+	}
+
+	signatory := &provenance.Signatory{KeyRing: k.PGP}
+	verification, err := signatory.Verify(chartPath, chartPath+".prov")
+	if err != nil {
+		return "", fmt.Errorf("failed to verify the provenance of %s: %w", filename, err)
+	}
+	return signer(verification.SignedBy), nil
+}
+
+// Cosign verifies that one of the signatures is valid for the manifest digest and returns the name of the key which
+// verified it.
+func (k *Keyring) Cosign(digest string, signatures []CosignSignature) (string, error) {
+	if len(signatures) == 0 {
+		return "", ErrNoSignature
+	}
+	if len(k.PublicKeys) == 0 {
+		return "", fmt.Errorf("no public key to verify the signature of %s", digest)
+	}
+
+	names := make([]string, 0, len(k.PublicKeys))
+	for name := range k.PublicKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, signature := range signatures {
+		if err := checkPayload(signature.Payload, digest); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(signature.Signature)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid signature encoding: %w", err))
+			continue
+		}
+		for _, name := range names {
+			if verifySignature(k.PublicKeys[name], signature.Payload, sig) == nil {
+				return name, nil
+			}
+		}
+		errs = append(errs, fmt.Errorf("signature is not valid for any of the %d trusted public keys", len(names)))
+	}
+	return "", fmt.Errorf("failed to verify the signature of %s: %w", digest, errors.Join(errs...))
+}
+
+// checkPayload ensures that the simple signing payload references the digest of the verified manifest, so that a
+// signature can not be copied from another artifact.
+func checkPayload(payload []byte, digest string) error {
+	var simpleSigning struct {
+		Critical struct {
+			Image struct {
+				DockerManifestDigest string `json:"docker-manifest-digest"`
+			} `json:"image"`
+		} `json:"critical"`
+	}
+	if err := json.Unmarshal(payload, &simpleSigning); err != nil {
+		return fmt.Errorf("invalid signature payload: %w", err)
+	}
+	if signed := simpleSigning.Critical.Image.DockerManifestDigest; signed != digest {
+		return fmt.Errorf("signature is for digest %s", signed)
+	}
+	return nil
+}
+
+func verifySignature(key crypto.PublicKey, payload, sig []byte) error {
+	digest := sha256.Sum256(payload)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return fmt.Errorf("invalid ecdsa signature")
+		}
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, payload, sig) {
+			return fmt.Errorf("invalid ed25519 signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
+
+// signer returns the primary identity of a PGP entity, or its key id if it has none.
+func signer(entity *openpgp.Entity) string {
+	if entity == nil {
+		return ""
+	}
+	var names []string
+	for name := range entity.Identities {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return strings.ToUpper(entity.PrimaryKey.KeyIdString())
+	}
+	sort.Strings(names)
+	return names[0]
+}
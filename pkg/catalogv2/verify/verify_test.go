@@ -0,0 +1,226 @@
+package verify
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp" //nolint
+	"golang.org/x/crypto/openpgp/armor"
+	"helm.sh/helm/v3/pkg/provenance"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+)
+
+func TestEnforced(t *testing.T) {
+	assert.False(t, Enforced(nil))
+	assert.True(t, Enforced(&v1.RepoVerification{}))
+	assert.True(t, Enforced(&v1.RepoVerification{Mode: v1.VerificationModeEnforce}))
+	assert.False(t, Enforced(&v1.RepoVerification{Mode: v1.VerificationModeAudit}))
+}
+
+func TestProvenance(t *testing.T) {
+	entity, err := openpgp.NewEntity("Platform Team", "", "platform@example.com", nil)
+	require.NoError(t, err)
+	other, err := openpgp.NewEntity("Someone Else", "", "else@example.com", nil)
+	require.NoError(t, err)
+
+	chart := chartArchive(t, "mychart", "1.0.0")
+	prov := sign(t, entity, "mychart-1.0.0.tgz", chart)
+
+	keyring, err := NewKeyring(&corev1.Secret{Data: map[string][]byte{"platform.asc": armoredPublicKey(t, entity)}})
+	require.NoError(t, err)
+	otherKeyring, err := NewKeyring(&corev1.Secret{Data: map[string][]byte{"other.gpg": binaryPublicKey(t, other)}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		keyring    *Keyring
+		filename   string
+		chart      []byte
+		prov       []byte
+		wantSigner string
+		wantErr    error
+	}{
+		{
+			name:       "signed chart",
+			keyring:    keyring,
+			filename:   "mychart-1.0.0.tgz",
+			chart:      chart,
+			prov:       prov,
+			wantSigner: "Platform Team <platform@example.com>",
+		},
+		{
+			name:     "no provenance",
+			keyring:  keyring,
+			filename: "mychart-1.0.0.tgz",
+			chart:    chart,
+			wantErr:  ErrNoSignature,
+		},
+		{
+			name:     "tampered chart",
+			keyring:  keyring,
+			filename: "mychart-1.0.0.tgz",
+			chart:    chartArchive(t, "mychart", "1.0.1"),
+			prov:     prov,
+		},
+		{
+			name:     "renamed chart",
+			keyring:  keyring,
+			filename: "other-1.0.0.tgz",
+			chart:    chart,
+			prov:     prov,
+		},
+		{
+			name:     "untrusted signer",
+			keyring:  otherKeyring,
+			filename: "mychart-1.0.0.tgz",
+			chart:    chart,
+			prov:     prov,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := tt.keyring.Provenance(tt.filename, tt.chart, tt.prov)
+			if tt.wantSigner == "" {
+				assert.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSigner, signer)
+		})
+	}
+}
+
+func TestCosign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	keyring, err := NewKeyring(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "cattle-system"},
+		Data:       map[string][]byte{"platform.pub": pemPublicKey(t, &key.PublicKey)},
+	})
+	require.NoError(t, err)
+
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name       string
+		signatures []CosignSignature
+		wantSigner string
+	}{
+		{
+			name:       "signed manifest",
+			signatures: []CosignSignature{cosignSignature(t, key, digest)},
+			wantSigner: "platform.pub",
+		},
+		{
+			name:       "one of several signatures is trusted",
+			signatures: []CosignSignature{cosignSignature(t, other, digest), cosignSignature(t, key, digest)},
+			wantSigner: "platform.pub",
+		},
+		{
+			name: "no signature",
+		},
+		{
+			name:       "untrusted key",
+			signatures: []CosignSignature{cosignSignature(t, other, digest)},
+		},
+		{
+			name:       "signature of another manifest",
+			signatures: []CosignSignature{cosignSignature(t, key, "sha256:fedcba")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := keyring.Cosign(digest, tt.signatures)
+			if tt.wantSigner == "" {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSigner, signer)
+		})
+	}
+}
+
+func TestNewKeyringWithoutKeys(t *testing.T) {
+	_, err := NewKeyring(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "keys", Namespace: "cattle-system"}})
+	assert.Error(t, err)
+
+	_, err = NewKeyring(&corev1.Secret{Data: map[string][]byte{"key": []byte("not a key")}})
+	assert.Error(t, err)
+}
+
+func chartArchive(t *testing.T, name, version string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	chartYAML := []byte(fmt.Sprintf("apiVersion: v2\nname: %s\nversion: %s\n", name, version))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name + "/Chart.yaml", Mode: 0o644, Size: int64(len(chartYAML))}))
+	_, err := tw.Write(chartYAML)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func sign(t *testing.T, entity *openpgp.Entity, filename string, chart []byte) []byte {
+	path := filepath.Join(t.TempDir(), filename)
+	require.NoError(t, os.WriteFile(path, chart, 0o600))
+	signatory := &provenance.Signatory{Entity: entity, KeyRing: openpgp.EntityList{entity}}
+	prov, err := signatory.ClearSign(path)
+	require.NoError(t, err)
+	return []byte(prov)
+}
+
+func armoredPublicKey(t *testing.T, entity *openpgp.Entity) []byte {
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func binaryPublicKey(t *testing.T, entity *openpgp.Entity) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, entity.Serialize(&buf))
+	return buf.Bytes()
+}
+
+func pemPublicKey(t *testing.T, key *ecdsa.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func cosignSignature(t *testing.T, key *ecdsa.PrivateKey, digest string) CosignSignature {
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":"registry.example.com/charts/mychart"},"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, digest))
+	sum := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	require.NoError(t, err)
+	return CosignSignature{Payload: payload, Signature: base64.StdEncoding.EncodeToString(sig)}
+}
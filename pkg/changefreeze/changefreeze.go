@@ -0,0 +1,211 @@
+// Package changefreeze enforces the change freezes of clusters: while a cluster is frozen, Rancher rejects the requests
+// changing it, unless they come from one of the exempt principals of the freeze.
+package changefreeze
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/audit/annotations"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	// AuditAnnotationRejected is the audit log annotation of the changes rejected because of a change freeze.
+	AuditAnnotationRejected = "changefreeze.cattle.io/rejected"
+	// AuditAnnotationExempt is the audit log annotation of the changes made by exempt principals during a change freeze.
+	AuditAnnotationExempt = "changefreeze.cattle.io/exempt"
+)
+
+// ClusterGetter gets management clusters by name, it is implemented by the management cluster cache.
+type ClusterGetter interface {
+	Get(name string) (*v3.Cluster, error)
+}
+
+// Active returns whether a change freeze is active at the given time. If it is, the returned time is when it ends, or
+// zero if it lasts until it is disabled. If it isn't, the returned time is when it next starts, or zero if no window
+// is scheduled.
+func Active(freeze *v3.ClusterChangeFreeze, now time.Time) (bool, time.Time) {
+	if freeze == nil {
+		return false, time.Time{}
+	}
+	if freeze.Enabled {
+		return true, time.Time{}
+	}
+
+	var until, next time.Time
+	for _, window := range freeze.Windows {
+		start, end := window.Start.Time, window.End.Time
+		if !start.After(now) && end.After(now) && end.After(until) {
+			until = end
+		} else if start.After(now) && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	if until.IsZero() {
+		return false, next
+	}
+
+	// overlapping and adjacent windows extend the freeze
+	for extended := true; extended; {
+		extended = false
+		for _, window := range freeze.Windows {
+			if !window.Start.Time.After(until) && window.End.Time.After(until) {
+				until = window.End.Time
+				extended = true
+			}
+		}
+	}
+	return true, until
+}
+
+// Exempt returns whether the user is one of the exempt principals of a change freeze, by name, principal ID or group.
+func Exempt(freeze *v3.ClusterChangeFreeze, u user.Info) bool {
+	if freeze == nil || u == nil {
+		return false
+	}
+	names := append([]string{u.GetName()}, u.GetGroups()...)
+	names = append(names, u.GetExtra()[common.UserAttributePrincipalID]...)
+	for _, name := range names {
+		if name != "" && slices.Contains(freeze.ExemptPrincipals, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// FrozenError is returned for the changes rejected because of a change freeze.
+type FrozenError struct {
+	ClusterID string
+	Reason    string
+	Until     time.Time
+}
+
+func (e *FrozenError) Error() string {
+	msg := fmt.Sprintf("cluster %s is in a change freeze", e.ClusterID)
+	if !e.Until.IsZero() {
+		msg += " until " + e.Until.UTC().Format(time.RFC3339)
+	}
+	if e.Reason != "" {
+		return msg + ": " + e.Reason
+	}
+	return msg + ", changes are rejected"
+}
+
+// Checker checks whether the changes to clusters are allowed.
+type Checker struct {
+	clusters ClusterGetter
+	now      func() time.Time
+}
+
+// NewChecker returns a checker of the change freezes of the given clusters.
+func NewChecker(clusters ClusterGetter) *Checker {
+	return &Checker{
+		clusters: clusters,
+		now:      time.Now,
+	}
+}
+
+// Check returns a FrozenError if the cluster is in a change freeze and the user isn't exempt. The decision is added to
+// the audit log of the request with the given context. Unknown clusters aren't frozen.
+func (c *Checker) Check(ctx context.Context, clusterID string, u user.Info) error {
+	if clusterID == "" {
+		return nil
+	}
+	cluster, err := c.clusters.Get(clusterID)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("getting cluster %s: %w", clusterID, err)
+	}
+
+	freeze := cluster.Spec.ChangeFreeze
+	active, until := Active(freeze, c.now())
+	if !active {
+		return nil
+	}
+	if Exempt(freeze, u) {
+		annotations.Add(ctx, AuditAnnotationExempt, clusterID)
+		return nil
+	}
+	frozenErr := &FrozenError{ClusterID: clusterID, Reason: freeze.Reason, Until: until}
+	annotations.Add(ctx, AuditAnnotationRejected, frozenErr.Error())
+	return frozenErr
+}
+
+// IsChange returns whether a request to the Kubernetes or Steve API of a cluster changes it. Every request but reads is
+// a change, except for access and token reviews, as well as exec, attach and port forwarding into pods, even over
+// websockets.
+func IsChange(req *http.Request) bool {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, part := range parts {
+		switch part {
+		case "exec", "attach", "portforward":
+			if i > 1 && parts[i-2] == "pods" {
+				return true
+			}
+		case "authorization.k8s.io", "authentication.k8s.io":
+			// the reviews of the Kubernetes API, such as /apis/authorization.k8s.io/v1/selfsubjectaccessreviews
+			if i > 0 && parts[i-1] == "apis" {
+				return false
+			}
+		}
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// Admit rejects the request if it changes a cluster in a change freeze, writing a 403 response with a Kubernetes
+// status. It returns whether the request can be served.
+func (c *Checker) Admit(rw http.ResponseWriter, req *http.Request, clusterID string) bool {
+	if !IsChange(req) {
+		return true
+	}
+	u, _ := request.UserFrom(req.Context())
+	err := c.Check(req.Context(), clusterID, u)
+	if err == nil {
+		return true
+	}
+
+	status := metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Code:     http.StatusForbidden,
+		Reason:   metav1.StatusReasonForbidden,
+		Message:  err.Error(),
+	}
+	if _, ok := err.(*FrozenError); !ok {
+		logrus.Errorf("[changefreeze] failed to check the change freeze of cluster %s: %v", clusterID, err)
+		status.Code = http.StatusInternalServerError
+		status.Reason = metav1.StatusReasonInternalError
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(int(status.Code))
+	if err := json.NewEncoder(rw).Encode(status); err != nil {
+		logrus.Debugf("[changefreeze] failed to write response: %v", err)
+	}
+	return false
+}
+
+// Wrap rejects the changes to clusters in a change freeze served by next, clusterID returns the cluster of a request.
+func (c *Checker) Wrap(next http.Handler, clusterID func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if c.Admit(rw, req, clusterID(req)) {
+			next.ServeHTTP(rw, req)
+		}
+	})
+}
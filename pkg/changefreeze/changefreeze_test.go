@@ -0,0 +1,171 @@
+package changefreeze
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/audit/annotations"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+var now = time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+
+func window(start, end time.Duration) v3.ChangeFreezeWindow {
+	return v3.ChangeFreezeWindow{Start: metav1.NewTime(now.Add(start)), End: metav1.NewTime(now.Add(end))}
+}
+
+type clusterGetter map[string]*v3.Cluster
+
+func (c clusterGetter) Get(name string) (*v3.Cluster, error) {
+	if cluster, ok := c[name]; ok {
+		return cluster, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "management.cattle.io", Resource: "clusters"}, name)
+}
+
+func newTestChecker(freeze *v3.ClusterChangeFreeze) *Checker {
+	c := NewChecker(clusterGetter{"c-abcde": {
+		ObjectMeta: metav1.ObjectMeta{Name: "c-abcde"},
+		Spec:       v3.ClusterSpec{ChangeFreeze: freeze},
+	}})
+	c.now = func() time.Time { return now }
+	return c
+}
+
+func TestActive(t *testing.T) {
+	tests := []struct {
+		name       string
+		freeze     *v3.ClusterChangeFreeze
+		wantActive bool
+		wantTime   time.Time
+	}{
+		{name: "no freeze"},
+		{name: "enabled", freeze: &v3.ClusterChangeFreeze{Enabled: true}, wantActive: true},
+		{
+			name:       "in window",
+			freeze:     &v3.ClusterChangeFreeze{Windows: []v3.ChangeFreezeWindow{window(-time.Hour, time.Hour)}},
+			wantActive: true,
+			wantTime:   now.Add(time.Hour),
+		},
+		{
+			name: "overlapping and adjacent windows",
+			freeze: &v3.ClusterChangeFreeze{Windows: []v3.ChangeFreezeWindow{
+				window(3*time.Hour, 4*time.Hour),
+				window(-time.Hour, time.Hour),
+				window(30*time.Minute, 2*time.Hour),
+				window(2*time.Hour, 3*time.Hour),
+				window(5*time.Hour, 6*time.Hour),
+			}},
+			wantActive: true,
+			wantTime:   now.Add(4 * time.Hour),
+		},
+		{
+			name:     "before windows",
+			freeze:   &v3.ClusterChangeFreeze{Windows: []v3.ChangeFreezeWindow{window(2*time.Hour, 3*time.Hour), window(time.Hour, 2*time.Hour)}},
+			wantTime: now.Add(time.Hour),
+		},
+		{
+			name:   "after window",
+			freeze: &v3.ClusterChangeFreeze{Windows: []v3.ChangeFreezeWindow{window(-2*time.Hour, 0)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, at := Active(tt.freeze, now)
+			assert.Equal(t, tt.wantActive, active)
+			assert.Equal(t, tt.wantTime, at)
+		})
+	}
+}
+
+func TestExempt(t *testing.T) {
+	freeze := &v3.ClusterChangeFreeze{ExemptPrincipals: []string{"u-admin", "github_team://1234", "okta_user://oncall"}}
+	assert.True(t, Exempt(freeze, &user.DefaultInfo{Name: "u-admin"}))
+	assert.True(t, Exempt(freeze, &user.DefaultInfo{Name: "u-abcde", Groups: []string{"github_team://1234"}}))
+	assert.True(t, Exempt(freeze, &user.DefaultInfo{Name: "u-abcde", Extra: map[string][]string{common.UserAttributePrincipalID: {"okta_user://oncall"}}}))
+	assert.False(t, Exempt(freeze, &user.DefaultInfo{Name: "u-abcde"}))
+	assert.False(t, Exempt(freeze, nil))
+	assert.False(t, Exempt(nil, &user.DefaultInfo{Name: "u-admin"}))
+}
+
+func TestCheck(t *testing.T) {
+	c := newTestChecker(&v3.ClusterChangeFreeze{
+		Windows:          []v3.ChangeFreezeWindow{window(-time.Hour, time.Hour)},
+		Reason:           "quarterly release",
+		ExemptPrincipals: []string{"u-admin"},
+	})
+
+	ctx := annotations.WithAnnotations(context.Background())
+	err := c.Check(ctx, "c-abcde", &user.DefaultInfo{Name: "u-abcde"})
+	var frozenErr *FrozenError
+	require.ErrorAs(t, err, &frozenErr)
+	assert.Equal(t, "cluster c-abcde is in a change freeze until 2024-01-08T21:00:00Z: quarterly release", err.Error())
+	assert.Equal(t, map[string]string{AuditAnnotationRejected: err.Error()}, annotations.From(ctx))
+
+	ctx = annotations.WithAnnotations(context.Background())
+	require.NoError(t, c.Check(ctx, "c-abcde", &user.DefaultInfo{Name: "u-admin"}))
+	assert.Equal(t, map[string]string{AuditAnnotationExempt: "c-abcde"}, annotations.From(ctx))
+
+	assert.NoError(t, c.Check(context.Background(), "c-unknown", &user.DefaultInfo{Name: "u-abcde"}))
+	assert.NoError(t, c.Check(context.Background(), "", &user.DefaultInfo{Name: "u-abcde"}))
+}
+
+func TestIsChange(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		want   bool
+	}{
+		{method: http.MethodGet, target: "/k8s/clusters/c-abcde/api/v1/pods"},
+		{method: http.MethodHead, target: "/k8s/clusters/c-abcde/api/v1/pods"},
+		{method: http.MethodPost, target: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods", want: true},
+		{method: http.MethodPatch, target: "/k8s/clusters/c-abcde/apis/apps/v1/namespaces/default/deployments/web", want: true},
+		{method: http.MethodDelete, target: "/k8s/clusters/c-abcde/v1/apps.deployments/default/web", want: true},
+		{method: http.MethodPost, target: "/k8s/clusters/c-abcde/apis/authorization.k8s.io/v1/selfsubjectaccessreviews"},
+		{method: http.MethodPost, target: "/k8s/clusters/c-abcde/apis/authentication.k8s.io/v1/tokenreviews"},
+		{method: http.MethodGet, target: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods/web/exec?command=sh", want: true},
+		{method: http.MethodGet, target: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods/web/portforward", want: true},
+		{method: http.MethodGet, target: "/k8s/clusters/c-abcde/api/v1/namespaces/exec/pods"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsChange(httptest.NewRequest(tt.method, tt.target, nil)))
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	c := newTestChecker(&v3.ClusterChangeFreeze{Enabled: true})
+	served := 0
+	h := c.Wrap(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { served++ }), func(*http.Request) string { return "c-abcde" })
+	serve := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/k8s/clusters/c-abcde/api/v1/namespaces/default/configmaps", nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "u-abcde"}))
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw
+	}
+
+	serve(http.MethodGet)
+	assert.Equal(t, 1, served)
+
+	rw := serve(http.MethodPost)
+	assert.Equal(t, 1, served)
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+	var status metav1.Status
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &status))
+	assert.Equal(t, metav1.StatusReasonForbidden, status.Reason)
+	assert.Equal(t, "cluster c-abcde is in a change freeze, changes are rejected", status.Message)
+}
@@ -0,0 +1,12 @@
+package client
+
+const (
+	ChangeFreezeWindowType       = "changeFreezeWindow"
+	ChangeFreezeWindowFieldEnd   = "end"
+	ChangeFreezeWindowFieldStart = "start"
+)
+
+type ChangeFreezeWindow struct {
+	End   string `json:"end,omitempty" yaml:"end,omitempty"`
+	Start string `json:"start,omitempty" yaml:"start,omitempty"`
+}
@@ -28,6 +28,7 @@
 	ClusterFieldCapabilities                                         = "capabilities"
 	ClusterFieldCapacity                                             = "capacity"
 	ClusterFieldCertificatesExpiration                               = "certificatesExpiration"
+	ClusterFieldChangeFreeze                                         = "changeFreeze"
 	ClusterFieldClusterAgentDeploymentCustomization                  = "clusterAgentDeploymentCustomization"
 	ClusterFieldClusterSecrets                                       = "clusterSecrets"
 	ClusterFieldClusterTemplateAnswers                               = "answers"
@@ -54,6 +55,7 @@
 	ClusterFieldFleetWorkspaceName                                   = "fleetWorkspaceName"
 	ClusterFieldGKEConfig                                            = "gkeConfig"
 	ClusterFieldGKEStatus                                            = "gkeStatus"
+	ClusterFieldHealthHistory                                        = "healthHistory"
 	ClusterFieldImportedConfig                                       = "importedConfig"
 	ClusterFieldInternal                                             = "internal"
 	ClusterFieldIstioEnabled                                         = "istioEnabled"
@@ -111,6 +113,7 @@ type Cluster struct {
 	Capabilities                                         *Capabilities                  `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
 	Capacity                                             map[string]string              `json:"capacity,omitempty" yaml:"capacity,omitempty"`
 	CertificatesExpiration                               map[string]CertExpiration      `json:"certificatesExpiration,omitempty" yaml:"certificatesExpiration,omitempty"`
+	ChangeFreeze                                         *ClusterChangeFreeze           `json:"changeFreeze,omitempty" yaml:"changeFreeze,omitempty"`
 	ClusterAgentDeploymentCustomization                  *AgentDeploymentCustomization  `json:"clusterAgentDeploymentCustomization,omitempty" yaml:"clusterAgentDeploymentCustomization,omitempty"`
 	ClusterSecrets                                       *ClusterSecrets                `json:"clusterSecrets,omitempty" yaml:"clusterSecrets,omitempty"`
 	ClusterTemplateAnswers                               *Answer                        `json:"answers,omitempty" yaml:"answers,omitempty"`
@@ -137,6 +140,7 @@ type Cluster struct {
 	FleetWorkspaceName                                   string                         `json:"fleetWorkspaceName,omitempty" yaml:"fleetWorkspaceName,omitempty"`
 	GKEConfig                                            *GKEClusterConfigSpec          `json:"gkeConfig,omitempty" yaml:"gkeConfig,omitempty"`
 	GKEStatus                                            *GKEStatus                     `json:"gkeStatus,omitempty" yaml:"gkeStatus,omitempty"`
+	HealthHistory                                        []ClusterHealthTransition      `json:"healthHistory,omitempty" yaml:"healthHistory,omitempty"`
 	ImportedConfig                                       *ImportedConfig                `json:"importedConfig,omitempty" yaml:"importedConfig,omitempty"`
 	Internal                                             bool                           `json:"internal,omitempty" yaml:"internal,omitempty"`
 	IstioEnabled                                         bool                           `json:"istioEnabled,omitempty" yaml:"istioEnabled,omitempty"`
@@ -0,0 +1,16 @@
+package client
+
+const (
+	ClusterChangeFreezeType                  = "clusterChangeFreeze"
+	ClusterChangeFreezeFieldEnabled          = "enabled"
+	ClusterChangeFreezeFieldExemptPrincipals = "exemptPrincipals"
+	ClusterChangeFreezeFieldReason           = "reason"
+	ClusterChangeFreezeFieldWindows          = "windows"
+)
+
+type ClusterChangeFreeze struct {
+	Enabled          bool                 `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	ExemptPrincipals []string             `json:"exemptPrincipals,omitempty" yaml:"exemptPrincipals,omitempty"`
+	Reason           string               `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Windows          []ChangeFreezeWindow `json:"windows,omitempty" yaml:"windows,omitempty"`
+}
@@ -0,0 +1,18 @@
+package client
+
+const (
+	ClusterHealthTransitionType         = "clusterHealthTransition"
+	ClusterHealthTransitionFieldMessage = "message"
+	ClusterHealthTransitionFieldReason  = "reason"
+	ClusterHealthTransitionFieldStatus  = "status"
+	ClusterHealthTransitionFieldTime    = "time"
+	ClusterHealthTransitionFieldType    = "type"
+)
+
+type ClusterHealthTransition struct {
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Status  string `json:"status,omitempty" yaml:"status,omitempty"`
+	Time    string `json:"time,omitempty" yaml:"time,omitempty"`
+	Type    string `json:"type,omitempty" yaml:"type,omitempty"`
+}
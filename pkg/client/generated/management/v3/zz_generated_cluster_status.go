@@ -26,6 +26,7 @@
 	ClusterStatusFieldEKSStatus                                  = "eksStatus"
 	ClusterStatusFieldFailedSpec                                 = "failedSpec"
 	ClusterStatusFieldGKEStatus                                  = "gkeStatus"
+	ClusterStatusFieldHealthHistory                              = "healthHistory"
 	ClusterStatusFieldIstioEnabled                               = "istioEnabled"
 	ClusterStatusFieldLimits                                     = "limits"
 	ClusterStatusFieldLinuxWorkerCount                           = "linuxWorkerCount"
@@ -69,6 +70,7 @@ type ClusterStatus struct {
 	EKSStatus                                  *EKSStatus                    `json:"eksStatus,omitempty" yaml:"eksStatus,omitempty"`
 	FailedSpec                                 *ClusterSpec                  `json:"failedSpec,omitempty" yaml:"failedSpec,omitempty"`
 	GKEStatus                                  *GKEStatus                    `json:"gkeStatus,omitempty" yaml:"gkeStatus,omitempty"`
+	HealthHistory                              []ClusterHealthTransition     `json:"healthHistory,omitempty" yaml:"healthHistory,omitempty"`
 	IstioEnabled                               bool                          `json:"istioEnabled,omitempty" yaml:"istioEnabled,omitempty"`
 	Limits                                     map[string]string             `json:"limits,omitempty" yaml:"limits,omitempty"`
 	LinuxWorkerCount                           int64                         `json:"linuxWorkerCount,omitempty" yaml:"linuxWorkerCount,omitempty"`
@@ -0,0 +1,14 @@
+package client
+
+const (
+	DriverVerificationType                      = "driverVerification"
+	DriverVerificationFieldFormat               = "format"
+	DriverVerificationFieldPublicKeysSecretName = "publicKeysSecretName"
+	DriverVerificationFieldSignatureURL         = "signatureURL"
+)
+
+type DriverVerification struct {
+	Format               string `json:"format,omitempty" yaml:"format,omitempty"`
+	PublicKeysSecretName string `json:"publicKeysSecretName,omitempty" yaml:"publicKeysSecretName,omitempty"`
+	SignatureURL         string `json:"signatureURL,omitempty" yaml:"signatureURL,omitempty"`
+}
@@ -0,0 +1,18 @@
+package client
+
+const (
+	DriverVerificationStatusType              = "driverVerificationStatus"
+	DriverVerificationStatusFieldFormat       = "format"
+	DriverVerificationStatusFieldKey          = "key"
+	DriverVerificationStatusFieldMessage      = "message"
+	DriverVerificationStatusFieldSignatureURL = "signatureURL"
+	DriverVerificationStatusFieldVerified     = "verified"
+)
+
+type DriverVerificationStatus struct {
+	Format       string `json:"format,omitempty" yaml:"format,omitempty"`
+	Key          string `json:"key,omitempty" yaml:"key,omitempty"`
+	Message      string `json:"message,omitempty" yaml:"message,omitempty"`
+	SignatureURL string `json:"signatureURL,omitempty" yaml:"signatureURL,omitempty"`
+	Verified     bool   `json:"verified,omitempty" yaml:"verified,omitempty"`
+}
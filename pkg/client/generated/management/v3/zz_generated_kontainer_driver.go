@@ -25,31 +25,35 @@
 	KontainerDriverFieldUIURL                = "uiUrl"
 	KontainerDriverFieldURL                  = "url"
 	KontainerDriverFieldUUID                 = "uuid"
+	KontainerDriverFieldVerification         = "verification"
+	KontainerDriverFieldVerificationResult   = "verificationResult"
 	KontainerDriverFieldWhitelistDomains     = "whitelistDomains"
 )
 
 type KontainerDriver struct {
 	types.Resource
-	Active               bool              `json:"active,omitempty" yaml:"active,omitempty"`
-	ActualURL            string            `json:"actualUrl,omitempty" yaml:"actualUrl,omitempty"`
-	Annotations          map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
-	BuiltIn              bool              `json:"builtIn,omitempty" yaml:"builtIn,omitempty"`
-	Checksum             string            `json:"checksum,omitempty" yaml:"checksum,omitempty"`
-	Conditions           []Condition       `json:"conditions,omitempty" yaml:"conditions,omitempty"`
-	Created              string            `json:"created,omitempty" yaml:"created,omitempty"`
-	CreatorID            string            `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
-	ExecutablePath       string            `json:"executablePath,omitempty" yaml:"executablePath,omitempty"`
-	Labels               map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
-	Name                 string            `json:"name,omitempty" yaml:"name,omitempty"`
-	OwnerReferences      []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
-	Removed              string            `json:"removed,omitempty" yaml:"removed,omitempty"`
-	State                string            `json:"state,omitempty" yaml:"state,omitempty"`
-	Transitioning        string            `json:"transitioning,omitempty" yaml:"transitioning,omitempty"`
-	TransitioningMessage string            `json:"transitioningMessage,omitempty" yaml:"transitioningMessage,omitempty"`
-	UIURL                string            `json:"uiUrl,omitempty" yaml:"uiUrl,omitempty"`
-	URL                  string            `json:"url,omitempty" yaml:"url,omitempty"`
-	UUID                 string            `json:"uuid,omitempty" yaml:"uuid,omitempty"`
-	WhitelistDomains     []string          `json:"whitelistDomains,omitempty" yaml:"whitelistDomains,omitempty"`
+	Active               bool                      `json:"active,omitempty" yaml:"active,omitempty"`
+	ActualURL            string                    `json:"actualUrl,omitempty" yaml:"actualUrl,omitempty"`
+	Annotations          map[string]string         `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	BuiltIn              bool                      `json:"builtIn,omitempty" yaml:"builtIn,omitempty"`
+	Checksum             string                    `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	Conditions           []Condition               `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	Created              string                    `json:"created,omitempty" yaml:"created,omitempty"`
+	CreatorID            string                    `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
+	ExecutablePath       string                    `json:"executablePath,omitempty" yaml:"executablePath,omitempty"`
+	Labels               map[string]string         `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Name                 string                    `json:"name,omitempty" yaml:"name,omitempty"`
+	OwnerReferences      []OwnerReference          `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
+	Removed              string                    `json:"removed,omitempty" yaml:"removed,omitempty"`
+	State                string                    `json:"state,omitempty" yaml:"state,omitempty"`
+	Transitioning        string                    `json:"transitioning,omitempty" yaml:"transitioning,omitempty"`
+	TransitioningMessage string                    `json:"transitioningMessage,omitempty" yaml:"transitioningMessage,omitempty"`
+	UIURL                string                    `json:"uiUrl,omitempty" yaml:"uiUrl,omitempty"`
+	URL                  string                    `json:"url,omitempty" yaml:"url,omitempty"`
+	UUID                 string                    `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	Verification         *DriverVerification       `json:"verification,omitempty" yaml:"verification,omitempty"`
+	VerificationResult   *DriverVerificationStatus `json:"verificationResult,omitempty" yaml:"verificationResult,omitempty"`
+	WhitelistDomains     []string                  `json:"whitelistDomains,omitempty" yaml:"whitelistDomains,omitempty"`
 }
 
 type KontainerDriverCollection struct {
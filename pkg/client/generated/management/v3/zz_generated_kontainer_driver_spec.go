@@ -7,14 +7,16 @@
 	KontainerDriverSpecFieldChecksum         = "checksum"
 	KontainerDriverSpecFieldUIURL            = "uiUrl"
 	KontainerDriverSpecFieldURL              = "url"
+	KontainerDriverSpecFieldVerification     = "verification"
 	KontainerDriverSpecFieldWhitelistDomains = "whitelistDomains"
 )
 
 type KontainerDriverSpec struct {
-	Active           bool     `json:"active,omitempty" yaml:"active,omitempty"`
-	BuiltIn          bool     `json:"builtIn,omitempty" yaml:"builtIn,omitempty"`
-	Checksum         string   `json:"checksum,omitempty" yaml:"checksum,omitempty"`
-	UIURL            string   `json:"uiUrl,omitempty" yaml:"uiUrl,omitempty"`
-	URL              string   `json:"url,omitempty" yaml:"url,omitempty"`
-	WhitelistDomains []string `json:"whitelistDomains,omitempty" yaml:"whitelistDomains,omitempty"`
+	Active           bool                `json:"active,omitempty" yaml:"active,omitempty"`
+	BuiltIn          bool                `json:"builtIn,omitempty" yaml:"builtIn,omitempty"`
+	Checksum         string              `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	UIURL            string              `json:"uiUrl,omitempty" yaml:"uiUrl,omitempty"`
+	URL              string              `json:"url,omitempty" yaml:"url,omitempty"`
+	Verification     *DriverVerification `json:"verification,omitempty" yaml:"verification,omitempty"`
+	WhitelistDomains []string            `json:"whitelistDomains,omitempty" yaml:"whitelistDomains,omitempty"`
 }
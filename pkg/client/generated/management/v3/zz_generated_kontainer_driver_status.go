@@ -1,16 +1,18 @@
 package client
 
 const (
-	KontainerDriverStatusType                = "kontainerDriverStatus"
-	KontainerDriverStatusFieldActualURL      = "actualUrl"
-	KontainerDriverStatusFieldConditions     = "conditions"
-	KontainerDriverStatusFieldDisplayName    = "displayName"
-	KontainerDriverStatusFieldExecutablePath = "executablePath"
+	KontainerDriverStatusType                    = "kontainerDriverStatus"
+	KontainerDriverStatusFieldActualURL          = "actualUrl"
+	KontainerDriverStatusFieldConditions         = "conditions"
+	KontainerDriverStatusFieldDisplayName        = "displayName"
+	KontainerDriverStatusFieldExecutablePath     = "executablePath"
+	KontainerDriverStatusFieldVerificationResult = "verificationResult"
 )
 
 type KontainerDriverStatus struct {
-	ActualURL      string      `json:"actualUrl,omitempty" yaml:"actualUrl,omitempty"`
-	Conditions     []Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
-	DisplayName    string      `json:"displayName,omitempty" yaml:"displayName,omitempty"`
-	ExecutablePath string      `json:"executablePath,omitempty" yaml:"executablePath,omitempty"`
+	ActualURL          string                    `json:"actualUrl,omitempty" yaml:"actualUrl,omitempty"`
+	Conditions         []Condition               `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	DisplayName        string                    `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	ExecutablePath     string                    `json:"executablePath,omitempty" yaml:"executablePath,omitempty"`
+	VerificationResult *DriverVerificationStatus `json:"verificationResult,omitempty" yaml:"verificationResult,omitempty"`
 }
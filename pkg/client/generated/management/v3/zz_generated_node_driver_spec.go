@@ -11,18 +11,20 @@
 	NodeDriverSpecFieldExternalID         = "externalId"
 	NodeDriverSpecFieldUIURL              = "uiUrl"
 	NodeDriverSpecFieldURL                = "url"
+	NodeDriverSpecFieldVerification       = "verification"
 	NodeDriverSpecFieldWhitelistDomains   = "whitelistDomains"
 )
 
 type NodeDriverSpec struct {
-	Active             bool     `json:"active,omitempty" yaml:"active,omitempty"`
-	AddCloudCredential bool     `json:"addCloudCredential,omitempty" yaml:"addCloudCredential,omitempty"`
-	Builtin            bool     `json:"builtin,omitempty" yaml:"builtin,omitempty"`
-	Checksum           string   `json:"checksum,omitempty" yaml:"checksum,omitempty"`
-	Description        string   `json:"description,omitempty" yaml:"description,omitempty"`
-	DisplayName        string   `json:"displayName,omitempty" yaml:"displayName,omitempty"`
-	ExternalID         string   `json:"externalId,omitempty" yaml:"externalId,omitempty"`
-	UIURL              string   `json:"uiUrl,omitempty" yaml:"uiUrl,omitempty"`
-	URL                string   `json:"url,omitempty" yaml:"url,omitempty"`
-	WhitelistDomains   []string `json:"whitelistDomains,omitempty" yaml:"whitelistDomains,omitempty"`
+	Active             bool                `json:"active,omitempty" yaml:"active,omitempty"`
+	AddCloudCredential bool                `json:"addCloudCredential,omitempty" yaml:"addCloudCredential,omitempty"`
+	Builtin            bool                `json:"builtin,omitempty" yaml:"builtin,omitempty"`
+	Checksum           string              `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	Description        string              `json:"description,omitempty" yaml:"description,omitempty"`
+	DisplayName        string              `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	ExternalID         string              `json:"externalId,omitempty" yaml:"externalId,omitempty"`
+	UIURL              string              `json:"uiUrl,omitempty" yaml:"uiUrl,omitempty"`
+	URL                string              `json:"url,omitempty" yaml:"url,omitempty"`
+	Verification       *DriverVerification `json:"verification,omitempty" yaml:"verification,omitempty"`
+	WhitelistDomains   []string            `json:"whitelistDomains,omitempty" yaml:"whitelistDomains,omitempty"`
 }
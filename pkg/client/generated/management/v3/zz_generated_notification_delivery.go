@@ -0,0 +1,22 @@
+package client
+
+const (
+	NotificationDeliveryType                  = "notificationDelivery"
+	NotificationDeliveryFieldAttempts         = "attempts"
+	NotificationDeliveryFieldChannel          = "channel"
+	NotificationDeliveryFieldLastAttemptTime  = "lastAttemptTime"
+	NotificationDeliveryFieldLastDeliveryTime = "lastDeliveryTime"
+	NotificationDeliveryFieldMessage          = "message"
+	NotificationDeliveryFieldState            = "state"
+	NotificationDeliveryFieldSubscription     = "subscription"
+)
+
+type NotificationDelivery struct {
+	Attempts         int64  `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	Channel          string `json:"channel,omitempty" yaml:"channel,omitempty"`
+	LastAttemptTime  string `json:"lastAttemptTime,omitempty" yaml:"lastAttemptTime,omitempty"`
+	LastDeliveryTime string `json:"lastDeliveryTime,omitempty" yaml:"lastDeliveryTime,omitempty"`
+	Message          string `json:"message,omitempty" yaml:"message,omitempty"`
+	State            string `json:"state,omitempty" yaml:"state,omitempty"`
+	Subscription     string `json:"subscription,omitempty" yaml:"subscription,omitempty"`
+}
@@ -0,0 +1,24 @@
+package client
+
+const (
+	NotificationEventType                 = "notificationEvent"
+	NotificationEventFieldClusterName     = "clusterName"
+	NotificationEventFieldCount           = "count"
+	NotificationEventFieldFirstOccurrence = "firstOccurrence"
+	NotificationEventFieldLastOccurrence  = "lastOccurrence"
+	NotificationEventFieldObject          = "object"
+	NotificationEventFieldSeverity        = "severity"
+	NotificationEventFieldType            = "type"
+	NotificationEventFieldUserName        = "userName"
+)
+
+type NotificationEvent struct {
+	ClusterName     string `json:"clusterName,omitempty" yaml:"clusterName,omitempty"`
+	Count           int64  `json:"count,omitempty" yaml:"count,omitempty"`
+	FirstOccurrence string `json:"firstOccurrence,omitempty" yaml:"firstOccurrence,omitempty"`
+	LastOccurrence  string `json:"lastOccurrence,omitempty" yaml:"lastOccurrence,omitempty"`
+	Object          string `json:"object,omitempty" yaml:"object,omitempty"`
+	Severity        string `json:"severity,omitempty" yaml:"severity,omitempty"`
+	Type            string `json:"type,omitempty" yaml:"type,omitempty"`
+	UserName        string `json:"userName,omitempty" yaml:"userName,omitempty"`
+}
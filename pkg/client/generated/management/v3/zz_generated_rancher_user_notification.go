@@ -5,31 +5,41 @@
 )
 
 const (
-	RancherUserNotificationType                 = "rancherUserNotification"
-	RancherUserNotificationFieldAnnotations     = "annotations"
-	RancherUserNotificationFieldComponentName   = "componentName"
-	RancherUserNotificationFieldCreated         = "created"
-	RancherUserNotificationFieldCreatorID       = "creatorId"
-	RancherUserNotificationFieldLabels          = "labels"
-	RancherUserNotificationFieldMessage         = "message"
-	RancherUserNotificationFieldName            = "name"
-	RancherUserNotificationFieldOwnerReferences = "ownerReferences"
-	RancherUserNotificationFieldRemoved         = "removed"
-	RancherUserNotificationFieldUUID            = "uuid"
+	RancherUserNotificationType                      = "rancherUserNotification"
+	RancherUserNotificationFieldAnnotations          = "annotations"
+	RancherUserNotificationFieldComponentName        = "componentName"
+	RancherUserNotificationFieldCreated              = "created"
+	RancherUserNotificationFieldCreatorID            = "creatorId"
+	RancherUserNotificationFieldEvent                = "event"
+	RancherUserNotificationFieldLabels               = "labels"
+	RancherUserNotificationFieldMessage              = "message"
+	RancherUserNotificationFieldName                 = "name"
+	RancherUserNotificationFieldOwnerReferences      = "ownerReferences"
+	RancherUserNotificationFieldRemoved              = "removed"
+	RancherUserNotificationFieldState                = "state"
+	RancherUserNotificationFieldStatus               = "status"
+	RancherUserNotificationFieldTransitioning        = "transitioning"
+	RancherUserNotificationFieldTransitioningMessage = "transitioningMessage"
+	RancherUserNotificationFieldUUID                 = "uuid"
 )
 
 type RancherUserNotification struct {
 	types.Resource
-	Annotations     map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
-	ComponentName   string            `json:"componentName,omitempty" yaml:"componentName,omitempty"`
-	Created         string            `json:"created,omitempty" yaml:"created,omitempty"`
-	CreatorID       string            `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
-	Labels          map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
-	Message         string            `json:"message,omitempty" yaml:"message,omitempty"`
-	Name            string            `json:"name,omitempty" yaml:"name,omitempty"`
-	OwnerReferences []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
-	Removed         string            `json:"removed,omitempty" yaml:"removed,omitempty"`
-	UUID            string            `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	Annotations          map[string]string              `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	ComponentName        string                         `json:"componentName,omitempty" yaml:"componentName,omitempty"`
+	Created              string                         `json:"created,omitempty" yaml:"created,omitempty"`
+	CreatorID            string                         `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
+	Event                *NotificationEvent             `json:"event,omitempty" yaml:"event,omitempty"`
+	Labels               map[string]string              `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Message              string                         `json:"message,omitempty" yaml:"message,omitempty"`
+	Name                 string                         `json:"name,omitempty" yaml:"name,omitempty"`
+	OwnerReferences      []OwnerReference               `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
+	Removed              string                         `json:"removed,omitempty" yaml:"removed,omitempty"`
+	State                string                         `json:"state,omitempty" yaml:"state,omitempty"`
+	Status               *RancherUserNotificationStatus `json:"status,omitempty" yaml:"status,omitempty"`
+	Transitioning        string                         `json:"transitioning,omitempty" yaml:"transitioning,omitempty"`
+	TransitioningMessage string                         `json:"transitioningMessage,omitempty" yaml:"transitioningMessage,omitempty"`
+	UUID                 string                         `json:"uuid,omitempty" yaml:"uuid,omitempty"`
 }
 
 type RancherUserNotificationCollection struct {
@@ -0,0 +1,12 @@
+package client
+
+const (
+	RancherUserNotificationStatusType            = "rancherUserNotificationStatus"
+	RancherUserNotificationStatusFieldDeliveries = "deliveries"
+	RancherUserNotificationStatusFieldRecipients = "recipients"
+)
+
+type RancherUserNotificationStatus struct {
+	Deliveries []NotificationDelivery `json:"deliveries,omitempty" yaml:"deliveries,omitempty"`
+	Recipients []string               `json:"recipients,omitempty" yaml:"recipients,omitempty"`
+}
@@ -0,0 +1,460 @@
+// Package flowcontrol limits the rate and the concurrency of the requests proxied to downstream clusters, so that a
+// single user or token can't saturate the tunnel of a cluster. It is modelled on the Kubernetes API Priority and
+// Fairness: requests are classified by flow schemas into priority levels, and each priority level limits the rate and
+// the requests in flight to a cluster, and the rate and the requests in flight of every flow, that is every user or
+// token, to that cluster.
+package flowcontrol
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/rancher/rancher/pkg/metrics"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	// DistinguisherUser makes every user a separate flow of a priority level.
+	DistinguisherUser = "user"
+	// DistinguisherToken makes every token a separate flow of a priority level, so that the tokens of a user, such as
+	// the ones of different CI jobs, don't share their limits.
+	DistinguisherToken = "token"
+
+	ReasonRate               = "rate"
+	ReasonClusterRate        = "cluster-rate"
+	ReasonFlowConcurrency    = "flow-concurrency"
+	ReasonClusterConcurrency = "cluster-concurrency"
+
+	// idleFlowTimeout is how long the state of a flow, or of a cluster, without requests is kept.
+	idleFlowTimeout = 5 * time.Minute
+)
+
+// Config is the flow control configuration of the cluster proxy, stored as JSON in the cluster-proxy-flow-control
+// setting.
+type Config struct {
+	PriorityLevels []PriorityLevel `json:"priorityLevels,omitempty"`
+	// FlowSchemas classify the requests into priority levels. The first flow schema matching a request wins, and
+	// requests not matching any flow schema aren't limited.
+	FlowSchemas []FlowSchema `json:"flowSchemas,omitempty"`
+}
+
+// PriorityLevel holds the limits of a class of requests. A zero limit means unlimited.
+type PriorityLevel struct {
+	Name string `json:"name"`
+	// Exempt priority levels aren't limited, they are meant for the agents and controllers of the system.
+	Exempt bool `json:"exempt,omitempty"`
+	// ClusterMaxInFlight is the number of requests of the priority level that a cluster serves at once, shared by all
+	// the flows.
+	ClusterMaxInFlight int `json:"clusterMaxInFlight,omitempty"`
+	// ClusterRequestsPerSecond and ClusterBurst are the token bucket limiting the rate of the requests of the priority
+	// level to a cluster, shared by all the flows.
+	ClusterRequestsPerSecond float64 `json:"clusterRequestsPerSecond,omitempty"`
+	ClusterBurst             int     `json:"clusterBurst,omitempty"`
+	// FlowMaxInFlight is the number of requests that a flow sends to a cluster at once.
+	FlowMaxInFlight int `json:"flowMaxInFlight,omitempty"`
+	// FlowRequestsPerSecond and FlowBurst are the token bucket limiting the rate of the requests of a flow to a cluster.
+	FlowRequestsPerSecond float64 `json:"flowRequestsPerSecond,omitempty"`
+	FlowBurst             int     `json:"flowBurst,omitempty"`
+	// FlowDistinguisher is either user, the default, or token.
+	FlowDistinguisher string `json:"flowDistinguisher,omitempty"`
+}
+
+// FlowSchema assigns the requests of some users to a priority level. Users and groups are glob patterns, such as
+// system:*, and a flow schema without users and groups matches every request.
+type FlowSchema struct {
+	Name          string   `json:"name"`
+	PriorityLevel string   `json:"priorityLevel"`
+	Users         []string `json:"users,omitempty"`
+	Groups        []string `json:"groups,omitempty"`
+}
+
+// ParseConfig parses and validates a flow control configuration. An empty configuration doesn't limit anything.
+func ParseConfig(raw string) (*Config, error) {
+	config := &Config{}
+	if strings.TrimSpace(raw) == "" {
+		return config, nil
+	}
+	if err := json.Unmarshal([]byte(raw), config); err != nil {
+		return nil, fmt.Errorf("parsing flow control configuration: %w", err)
+	}
+
+	levels := map[string]bool{}
+	for _, level := range config.PriorityLevels {
+		if level.Name == "" {
+			return nil, fmt.Errorf("priority level without name")
+		}
+		if levels[level.Name] {
+			return nil, fmt.Errorf("duplicate priority level %s", level.Name)
+		}
+		levels[level.Name] = true
+		if level.ClusterMaxInFlight < 0 || level.ClusterRequestsPerSecond < 0 || level.ClusterBurst < 0 ||
+			level.FlowMaxInFlight < 0 || level.FlowRequestsPerSecond < 0 || level.FlowBurst < 0 {
+			return nil, fmt.Errorf("priority level %s has negative limits", level.Name)
+		}
+		switch level.FlowDistinguisher {
+		case "", DistinguisherUser, DistinguisherToken:
+		default:
+			return nil, fmt.Errorf("priority level %s has invalid flow distinguisher %q", level.Name, level.FlowDistinguisher)
+		}
+	}
+	for _, schema := range config.FlowSchemas {
+		if !levels[schema.PriorityLevel] {
+			return nil, fmt.Errorf("flow schema %s references unknown priority level %q", schema.Name, schema.PriorityLevel)
+		}
+		for _, pattern := range append(schema.Users, schema.Groups...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("flow schema %s has invalid pattern %q: %w", schema.Name, pattern, err)
+			}
+		}
+	}
+	return config, nil
+}
+
+func (s *FlowSchema) matches(u user.Info) bool {
+	if len(s.Users) == 0 && len(s.Groups) == 0 {
+		return true
+	}
+	for _, pattern := range s.Users {
+		if ok, _ := path.Match(pattern, u.GetName()); ok {
+			return true
+		}
+	}
+	for _, pattern := range s.Groups {
+		for _, group := range u.GetGroups() {
+			if ok, _ := path.Match(pattern, group); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// priorityLevel returns the priority level of the requests of a user, or nil if they aren't limited.
+func (c *Config) priorityLevel(u user.Info) *PriorityLevel {
+	for _, schema := range c.FlowSchemas {
+		if !schema.matches(u) {
+			continue
+		}
+		for i := range c.PriorityLevels {
+			if c.PriorityLevels[i].Name == schema.PriorityLevel {
+				return &c.PriorityLevels[i]
+			}
+		}
+	}
+	return nil
+}
+
+type clusterKey struct {
+	priorityLevel string
+	cluster       string
+}
+
+type flowKey struct {
+	clusterKey
+	flow string
+}
+
+// flow is the state of a flow, or of all the flows of a priority level to a cluster.
+type flow struct {
+	limiter  *rate.Limiter
+	inFlight int
+	lastSeen time.Time
+}
+
+// newRateLimiter returns a token bucket of the given rate, unlimited if it is zero. The burst defaults to one second of
+// requests.
+func newRateLimiter(requestsPerSecond float64, burst int) *rate.Limiter {
+	if requestsPerSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	if burst == 0 {
+		burst = max(1, int(math.Ceil(requestsPerSecond)))
+	}
+	return rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+}
+
+// reserve takes a token from the bucket of a limiter. If there is none, it returns false and the number of seconds
+// after which a request can be retried.
+func reserve(limiter *rate.Limiter, now time.Time) (*rate.Reservation, int, bool) {
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() {
+		return nil, 1, false
+	}
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return nil, int(math.Ceil(delay.Seconds())), false
+	}
+	return reservation, 0, true
+}
+
+// rejection describes why a request was rejected and when it can be retried.
+type rejection struct {
+	reason     string
+	retryAfter int
+}
+
+// Limiter enforces a flow control configuration.
+type Limiter struct {
+	getConfig func() string
+	now       func() time.Time
+
+	mu       sync.Mutex
+	raw      string
+	config   *Config
+	clusters map[clusterKey]*flow
+	flows    map[flowKey]*flow
+	lastGC   time.Time
+}
+
+// NewLimiter returns a limiter enforcing the configuration returned by getConfig, which is parsed again whenever it
+// changes. An invalid configuration is logged and the previous one is kept.
+func NewLimiter(getConfig func() string) *Limiter {
+	return &Limiter{
+		getConfig: getConfig,
+		now:       time.Now,
+		config:    &Config{},
+		clusters:  map[clusterKey]*flow{},
+		flows:     map[flowKey]*flow{},
+	}
+}
+
+var defaultLimiter = NewLimiter(settings.ClusterProxyFlowControl.Get)
+
+// Admit admits a request to a downstream cluster using the limits of the cluster-proxy-flow-control setting, see
+// Limiter.Admit.
+func Admit(rw http.ResponseWriter, req *http.Request, clusterID string) (func(), bool) {
+	return defaultLimiter.Admit(rw, req, clusterID)
+}
+
+// Wrap limits the requests served by next using the limits of the cluster-proxy-flow-control setting.
+func Wrap(next http.Handler, clusterID func(*http.Request) string) http.Handler {
+	return defaultLimiter.Wrap(next, clusterID)
+}
+
+// Wrap limits the requests served by next, clusterID returns the downstream cluster of a request.
+func (l *Limiter) Wrap(next http.Handler, clusterID func(*http.Request) string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		release, ok := l.Admit(rw, req, clusterID(req))
+		if !ok {
+			return
+		}
+		defer release()
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// Admit decides whether a request of the authenticated user to a downstream cluster is served. If it isn't, a 429
+// response with a Retry-After header is written and false is returned. Otherwise, the returned func must be called
+// once the request is served.
+func (l *Limiter) Admit(rw http.ResponseWriter, req *http.Request, clusterID string) (func(), bool) {
+	u, ok := request.UserFrom(req.Context())
+	if !ok {
+		u = &user.DefaultInfo{}
+	}
+	release, priorityLevel, rejected := l.admit(clusterID, u, isLongRunning(req))
+	if rejected == nil {
+		return release, true
+	}
+
+	metrics.IncClusterProxyRejectedRequests(clusterID, priorityLevel, rejected.reason)
+	logrus.Debugf("[flowcontrol] rejected request %s %s of user %s to cluster %s: %s limit of priority level %s exceeded",
+		req.Method, req.URL.Path, u.GetName(), clusterID, rejected.reason, priorityLevel)
+
+	status := apierrors.NewTooManyRequests(fmt.Sprintf("too many requests to cluster %s, %s limit exceeded", clusterID, rejected.reason), rejected.retryAfter).ErrStatus
+	status.Kind = "Status"
+	status.APIVersion = "v1"
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Retry-After", strconv.Itoa(rejected.retryAfter))
+	rw.WriteHeader(http.StatusTooManyRequests)
+	if err := json.NewEncoder(rw).Encode(status); err != nil {
+		logrus.Debugf("[flowcontrol] failed to write response: %v", err)
+	}
+	return nil, false
+}
+
+// admit applies the limits of the priority level of the user. Long running requests, such as watches, only count
+// against the rate limit, since they would otherwise hold the concurrency limits for their whole duration.
+func (l *Limiter) admit(clusterID string, u user.Info, longRunning bool) (func(), string, *rejection) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.reload()
+	l.collectIdleFlows(now)
+
+	level := l.config.priorityLevel(u)
+	if level == nil || level.Exempt {
+		return func() {}, "", nil
+	}
+
+	ck := clusterKey{priorityLevel: level.Name, cluster: clusterID}
+	fk := flowKey{clusterKey: ck, flow: flowID(level, u)}
+	f := l.flows[fk]
+	if f == nil {
+		f = &flow{limiter: newRateLimiter(level.FlowRequestsPerSecond, level.FlowBurst)}
+		l.flows[fk] = f
+	}
+	f.lastSeen = now
+	c := l.clusters[ck]
+	if c == nil {
+		c = &flow{limiter: newRateLimiter(level.ClusterRequestsPerSecond, level.ClusterBurst)}
+		l.clusters[ck] = c
+	}
+	c.lastSeen = now
+
+	if !longRunning {
+		if level.FlowMaxInFlight > 0 && f.inFlight >= level.FlowMaxInFlight {
+			return nil, level.Name, &rejection{reason: ReasonFlowConcurrency, retryAfter: 1}
+		}
+		if level.ClusterMaxInFlight > 0 && c.inFlight >= level.ClusterMaxInFlight {
+			return nil, level.Name, &rejection{reason: ReasonClusterConcurrency, retryAfter: 1}
+		}
+	}
+	flowReservation, retryAfter, ok := reserve(f.limiter, now)
+	if !ok {
+		return nil, level.Name, &rejection{reason: ReasonRate, retryAfter: retryAfter}
+	}
+	// a request rejected by the rate of the cluster doesn't consume the rate of its flow
+	if _, retryAfter, ok := reserve(c.limiter, now); !ok {
+		flowReservation.CancelAt(now)
+		return nil, level.Name, &rejection{reason: ReasonClusterRate, retryAfter: retryAfter}
+	}
+
+	if longRunning {
+		return func() {}, level.Name, nil
+	}
+
+	f.inFlight++
+	c.inFlight++
+	metrics.SetClusterProxyInFlightRequests(clusterID, level.Name, c.inFlight)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			now := l.now()
+			f.inFlight--
+			f.lastSeen = now
+			c.inFlight--
+			c.lastSeen = now
+			metrics.SetClusterProxyInFlightRequests(clusterID, level.Name, c.inFlight)
+		})
+	}, level.Name, nil
+}
+
+// reload parses the configuration again if it changed. Only the state of the priority levels whose limits changed, or
+// which were removed, is dropped, and their requests in flight release the counters they were admitted with.
+func (l *Limiter) reload() {
+	raw := l.getConfig()
+	if raw == l.raw {
+		return
+	}
+	l.raw = raw
+	config, err := ParseConfig(raw)
+	if err != nil {
+		logrus.Errorf("[flowcontrol] invalid cluster proxy flow control configuration, keeping the previous one: %v", err)
+		return
+	}
+
+	unchanged := map[string]bool{}
+	for _, old := range l.config.PriorityLevels {
+		for _, level := range config.PriorityLevels {
+			if level == old {
+				unchanged[level.Name] = true
+			}
+		}
+	}
+	for key := range l.clusters {
+		if !unchanged[key.priorityLevel] {
+			delete(l.clusters, key)
+		}
+	}
+	for key := range l.flows {
+		if !unchanged[key.priorityLevel] {
+			delete(l.flows, key)
+		}
+	}
+	l.config = config
+}
+
+// collectIdleFlows forgets the flows and the clusters without requests for a while, at most once per idleFlowTimeout.
+func (l *Limiter) collectIdleFlows(now time.Time) {
+	if now.Sub(l.lastGC) < idleFlowTimeout {
+		return
+	}
+	l.lastGC = now
+	for key, f := range l.flows {
+		if f.inFlight == 0 && now.Sub(f.lastSeen) >= idleFlowTimeout {
+			delete(l.flows, key)
+		}
+	}
+	for key, c := range l.clusters {
+		if c.inFlight == 0 && now.Sub(c.lastSeen) >= idleFlowTimeout {
+			delete(l.clusters, key)
+		}
+	}
+}
+
+// flowID returns the flow of a user in a priority level. Requests authenticated without a token, if any, are
+// distinguished by user.
+func flowID(level *PriorityLevel, u user.Info) string {
+	if level.FlowDistinguisher == DistinguisherToken {
+		if tokenID := u.GetExtra()[common.ExtraRequestTokenID]; len(tokenID) > 0 && tokenID[0] != "" {
+			return "token:" + tokenID[0]
+		}
+	}
+	return "user:" + u.GetName()
+}
+
+var longRunningSubresources = map[string]bool{
+	"attach":      true,
+	"exec":        true,
+	"portforward": true,
+	"proxy":       true,
+}
+
+// isLongRunning returns whether a request streams for an unbounded time, such as watches, followed logs, exec
+// sessions and websocket connections.
+func isLongRunning(req *http.Request) bool {
+	if req.Header.Get("Upgrade") != "" {
+		return true
+	}
+	query := req.URL.Query()
+	for _, key := range []string{"watch", "follow"} {
+		if value := query.Get(key); value == "true" || value == "1" {
+			return true
+		}
+	}
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i, part := range parts {
+		// legacy watch paths, such as /api/v1/watch/pods
+		if part == "watch" && i > 0 && isVersion(parts[i-1]) {
+			return true
+		}
+		// subresources of pods, services and nodes, such as /api/v1/namespaces/default/pods/nginx/exec
+		if longRunningSubresources[part] && i > 1 {
+			switch parts[i-2] {
+			case "pods", "services", "nodes":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isVersion(s string) bool {
+	return len(s) > 1 && s[0] == 'v' && s[1] >= '0' && s[1] <= '9'
+}
@@ -0,0 +1,292 @@
+package flowcontrol
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const testConfig = `{
+	"priorityLevels": [
+		{"name": "exempt", "exempt": true},
+		{"name": "workload", "clusterMaxInFlight": 3, "flowMaxInFlight": 2, "flowRequestsPerSecond": 1, "flowBurst": 2},
+		{"name": "automation", "flowRequestsPerSecond": 1, "flowBurst": 1, "flowDistinguisher": "token"}
+	],
+	"flowSchemas": [
+		{"name": "system", "priorityLevel": "exempt", "users": ["system:*"]},
+		{"name": "ci", "priorityLevel": "automation", "groups": ["ci"]},
+		{"name": "default", "priorityLevel": "workload"}
+	]
+}`
+
+func newTestLimiter(config string) (*Limiter, *time.Time) {
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	l := NewLimiter(func() string { return config })
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func newRequest(target string, u user.Info) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	return req.WithContext(request.WithUser(req.Context(), u))
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{name: "empty", raw: ""},
+		{name: "valid", raw: testConfig},
+		{name: "invalid json", raw: "{", wantErr: "parsing flow control configuration"},
+		{name: "unknown priority level", raw: `{"flowSchemas":[{"name":"a","priorityLevel":"b"}]}`, wantErr: `unknown priority level "b"`},
+		{name: "duplicate priority level", raw: `{"priorityLevels":[{"name":"a"},{"name":"a"}]}`, wantErr: "duplicate priority level a"},
+		{name: "negative limit", raw: `{"priorityLevels":[{"name":"a","flowBurst":-1}]}`, wantErr: "negative limits"},
+		{name: "negative cluster rate", raw: `{"priorityLevels":[{"name":"a","clusterRequestsPerSecond":-1}]}`, wantErr: "negative limits"},
+		{name: "invalid distinguisher", raw: `{"priorityLevels":[{"name":"a","flowDistinguisher":"ip"}]}`, wantErr: "invalid flow distinguisher"},
+		{name: "invalid pattern", raw: `{"priorityLevels":[{"name":"a"}],"flowSchemas":[{"name":"a","priorityLevel":"a","users":["["]}]}`, wantErr: "invalid pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig(tt.raw)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAdmitExempt(t *testing.T) {
+	l, _ := newTestLimiter(testConfig)
+	u := &user.DefaultInfo{Name: "system:serviceaccount:cattle-system:rancher"}
+	for i := 0; i < 10; i++ {
+		_, _, rejected := l.admit("c-abcde", u, false)
+		require.Nil(t, rejected)
+	}
+}
+
+func TestAdmitRateLimit(t *testing.T) {
+	l, now := newTestLimiter(testConfig)
+	u := &user.DefaultInfo{Name: "u-abcde"}
+
+	for i := 0; i < 2; i++ {
+		release, ok := l.Admit(httptest.NewRecorder(), newRequest("/k8s/clusters/c-abcde/api/v1/pods", u), "c-abcde")
+		require.True(t, ok)
+		release()
+	}
+
+	rw := httptest.NewRecorder()
+	_, ok := l.Admit(rw, newRequest("/k8s/clusters/c-abcde/api/v1/pods", u), "c-abcde")
+	require.False(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.Equal(t, "1", rw.Header().Get("Retry-After"))
+	var status metav1.Status
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &status))
+	assert.Equal(t, metav1.StatusReasonTooManyRequests, status.Reason)
+	assert.Contains(t, status.Message, ReasonRate)
+
+	// other users and clusters have their own flows
+	_, _, rejected := l.admit("c-abcde", &user.DefaultInfo{Name: "u-fghij"}, false)
+	assert.Nil(t, rejected)
+	_, _, rejected = l.admit("c-fghij", u, false)
+	assert.Nil(t, rejected)
+
+	*now = now.Add(time.Second)
+	_, _, rejected = l.admit("c-abcde", u, false)
+	assert.Nil(t, rejected)
+}
+
+func TestAdmitClusterRateLimit(t *testing.T) {
+	l, now := newTestLimiter(`{
+		"priorityLevels": [{"name": "workload", "clusterRequestsPerSecond": 1, "clusterBurst": 2, "flowRequestsPerSecond": 0.1, "flowBurst": 1}],
+		"flowSchemas": [{"name": "default", "priorityLevel": "workload"}]
+	}`)
+	alice := &user.DefaultInfo{Name: "alice"}
+	bob := &user.DefaultInfo{Name: "bob"}
+	carol := &user.DefaultInfo{Name: "carol"}
+
+	_, _, rejected := l.admit("c-abcde", alice, false)
+	require.Nil(t, rejected)
+	_, _, rejected = l.admit("c-abcde", bob, false)
+	require.Nil(t, rejected)
+
+	// the rate of the cluster is shared by all the flows
+	_, level, rejected := l.admit("c-abcde", carol, false)
+	require.NotNil(t, rejected)
+	assert.Equal(t, ReasonClusterRate, rejected.reason)
+	assert.Equal(t, "workload", level)
+	assert.Equal(t, 1, rejected.retryAfter)
+	_, _, rejected = l.admit("c-abcde", carol, true)
+	require.NotNil(t, rejected, "long running requests count against the rate of the cluster")
+
+	// other clusters have their own rate
+	_, _, rejected = l.admit("c-fghij", carol, false)
+	assert.Nil(t, rejected)
+
+	// the rejected requests didn't consume the rate of their flow
+	*now = now.Add(time.Second)
+	_, _, rejected = l.admit("c-abcde", carol, false)
+	assert.Nil(t, rejected)
+}
+
+func TestAdmitConcurrencyLimits(t *testing.T) {
+	l, now := newTestLimiter(`{
+		"priorityLevels": [{"name": "workload", "clusterMaxInFlight": 3, "flowMaxInFlight": 2}],
+		"flowSchemas": [{"name": "default", "priorityLevel": "workload"}]
+	}`)
+	alice := &user.DefaultInfo{Name: "alice"}
+	bob := &user.DefaultInfo{Name: "bob"}
+
+	releaseAlice, _, rejected := l.admit("c-abcde", alice, false)
+	require.Nil(t, rejected)
+	_, _, rejected = l.admit("c-abcde", alice, false)
+	require.Nil(t, rejected)
+	_, level, rejected := l.admit("c-abcde", alice, false)
+	require.NotNil(t, rejected)
+	assert.Equal(t, ReasonFlowConcurrency, rejected.reason)
+	assert.Equal(t, "workload", level)
+
+	_, _, rejected = l.admit("c-abcde", bob, false)
+	require.Nil(t, rejected)
+	_, _, rejected = l.admit("c-abcde", bob, false)
+	require.NotNil(t, rejected)
+	assert.Equal(t, ReasonClusterConcurrency, rejected.reason)
+
+	// long running requests aren't counted against concurrency limits
+	_, _, rejected = l.admit("c-abcde", bob, true)
+	assert.Nil(t, rejected)
+
+	releaseAlice()
+	releaseAlice()
+	_, _, rejected = l.admit("c-abcde", bob, false)
+	assert.Nil(t, rejected)
+	_, _, rejected = l.admit("c-abcde", bob, false)
+	require.NotNil(t, rejected)
+
+	// idle flows are forgotten, flows with requests in flight aren't
+	*now = now.Add(idleFlowTimeout)
+	l.mu.Lock()
+	l.collectIdleFlows(*now)
+	assert.Len(t, l.flows, 2)
+	l.mu.Unlock()
+}
+
+func TestAdmitTokenDistinguisher(t *testing.T) {
+	l, _ := newTestLimiter(testConfig)
+	token := func(id string) user.Info {
+		return &user.DefaultInfo{Name: "u-abcde", Groups: []string{"ci"}, Extra: map[string][]string{common.ExtraRequestTokenID: {id}}}
+	}
+
+	_, level, rejected := l.admit("c-abcde", token("token-1"), false)
+	require.Nil(t, rejected)
+	assert.Equal(t, "automation", level)
+	_, _, rejected = l.admit("c-abcde", token("token-1"), false)
+	require.NotNil(t, rejected)
+	assert.Equal(t, ReasonRate, rejected.reason)
+
+	_, _, rejected = l.admit("c-abcde", token("token-2"), false)
+	assert.Nil(t, rejected)
+}
+
+func TestAdmitReloadsConfig(t *testing.T) {
+	config := `{"priorityLevels":[{"name":"a","flowRequestsPerSecond":1,"flowBurst":1}],"flowSchemas":[{"name":"a","priorityLevel":"a"}]}`
+	l := NewLimiter(func() string { return config })
+	u := &user.DefaultInfo{Name: "u-abcde"}
+
+	_, _, rejected := l.admit("c-abcde", u, false)
+	require.Nil(t, rejected)
+	_, _, rejected = l.admit("c-abcde", u, false)
+	require.NotNil(t, rejected)
+
+	config = "{"
+	_, _, rejected = l.admit("c-abcde", u, false)
+	assert.NotNil(t, rejected, "an invalid configuration keeps the previous one")
+
+	config = ""
+	_, _, rejected = l.admit("c-abcde", u, false)
+	assert.Nil(t, rejected)
+}
+
+func TestAdmitReloadKeepsUnchangedPriorityLevels(t *testing.T) {
+	config := `{
+		"priorityLevels": [
+			{"name": "workload", "clusterMaxInFlight": 1},
+			{"name": "automation", "clusterMaxInFlight": 1}
+		],
+		"flowSchemas": [
+			{"name": "ci", "priorityLevel": "automation", "groups": ["ci"]},
+			{"name": "default", "priorityLevel": "workload"}
+		]
+	}`
+	l := NewLimiter(func() string { return config })
+	u := &user.DefaultInfo{Name: "u-abcde"}
+	ci := &user.DefaultInfo{Name: "u-fghij", Groups: []string{"ci"}}
+
+	release, _, rejected := l.admit("c-abcde", u, false)
+	require.Nil(t, rejected)
+	_, _, rejected = l.admit("c-abcde", ci, false)
+	require.Nil(t, rejected)
+
+	// changing the limits of a priority level keeps the requests in flight of the others
+	config = `{
+		"priorityLevels": [
+			{"name": "workload", "clusterMaxInFlight": 1},
+			{"name": "automation", "clusterMaxInFlight": 2}
+		],
+		"flowSchemas": [
+			{"name": "ci", "priorityLevel": "automation", "groups": ["ci"]},
+			{"name": "default", "priorityLevel": "workload"}
+		]
+	}`
+	_, _, rejected = l.admit("c-abcde", u, false)
+	require.NotNil(t, rejected)
+	assert.Equal(t, ReasonClusterConcurrency, rejected.reason)
+	_, _, rejected = l.admit("c-abcde", ci, false)
+	require.Nil(t, rejected)
+	_, _, rejected = l.admit("c-abcde", ci, false)
+	require.Nil(t, rejected, "the state of a changed priority level is dropped")
+	_, _, rejected = l.admit("c-abcde", ci, false)
+	require.NotNil(t, rejected)
+
+	release()
+	_, _, rejected = l.admit("c-abcde", u, false)
+	assert.Nil(t, rejected)
+}
+
+func TestIsLongRunning(t *testing.T) {
+	tests := []struct {
+		target  string
+		upgrade bool
+		want    bool
+	}{
+		{target: "/k8s/clusters/c-abcde/api/v1/pods"},
+		{target: "/k8s/clusters/c-abcde/api/v1/pods?watch=true", want: true},
+		{target: "/k8s/clusters/c-abcde/api/v1/watch/pods", want: true},
+		{target: "/k8s/clusters/c-abcde/api/v1/namespaces/watch/pods"},
+		{target: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods/nginx/log?follow=true", want: true},
+		{target: "/k8s/clusters/c-abcde/api/v1/namespaces/default/pods/nginx/exec?command=sh", want: true},
+		{target: "/k8s/clusters/c-abcde/api/v1/namespaces/proxy/pods"},
+		{target: "/k8s/clusters/c-abcde/v1/subscribe", upgrade: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.upgrade {
+				req.Header.Set("Upgrade", "websocket")
+			}
+			assert.Equal(t, tt.want, isLongRunning(req))
+		})
+	}
+}
@@ -11,6 +11,7 @@
 	"github.com/rancher/rancher/pkg/controllers/capr/machinedrain"
 	"github.com/rancher/rancher/pkg/controllers/capr/machinenodelookup"
 	"github.com/rancher/rancher/pkg/controllers/capr/machineprovision"
+	"github.com/rancher/rancher/pkg/controllers/capr/machinesshhostkey"
 	"github.com/rancher/rancher/pkg/controllers/capr/managesystemagent"
 	plannercontroller "github.com/rancher/rancher/pkg/controllers/capr/planner"
 	"github.com/rancher/rancher/pkg/controllers/capr/plansecret"
@@ -45,6 +46,7 @@ func Register(ctx context.Context, clients *wrangler.CAPIContext, kubeconfigMana
 	})
 	if features.MCM.Enabled() {
 		machineprovision.Register(ctx, clients, kubeconfigManager)
+		machinesshhostkey.Register(ctx, clients)
 		autoscaler.Register(ctx, clients)
 	}
 	rkecluster.Register(ctx, clients)
@@ -0,0 +1,127 @@
+// Package machinesshhostkey pins the SSH host keys of provisioned machines. The system agent of each machine provisioned
+// by a machine driver reports the host keys of its SSH server through the output of a periodic instruction of its plan,
+// which are then recorded in the machine state secret and enforced on every SSH connection to the machine. As the
+// instruction is added to the plans of machines that were provisioned before, their host keys are pinned once their
+// system agent applies it.
+//
+// The first reported host keys stay pinned: if different keys are reported later, the mismatch is flagged on the
+// machine state secret and the keys are only pinned again once an administrator sets the re-pin annotation on it, or
+// removes the pinned keys.
+package machinesshhostkey
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/capr/machinessh"
+	"github.com/rancher/rancher/pkg/capr/planner"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1beta2"
+	"github.com/rancher/rancher/pkg/wrangler"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+type handler struct {
+	secrets       corecontrollers.SecretClient
+	secretCache   corecontrollers.SecretCache
+	machinesCache capicontrollers.MachineCache
+}
+
+func Register(ctx context.Context, clients *wrangler.CAPIContext) {
+	h := &handler{
+		secrets:       clients.Core.Secret(),
+		secretCache:   clients.Core.Secret().Cache(),
+		machinesCache: clients.CAPI.Machine().Cache(),
+	}
+
+	clients.Core.Secret().OnChange(ctx, "machine-ssh-host-key", h.OnChange)
+}
+
+// OnChange pins the host keys reported in the periodic output of a machine plan secret in the machine state secret of
+// the machine.
+func (h *handler) OnChange(_ string, secret *corev1.Secret) (*corev1.Secret, error) {
+	if secret == nil || secret.DeletionTimestamp != nil || secret.Type != capr.SecretTypeMachinePlan ||
+		secret.Labels[capr.MachineNameLabel] == "" || len(secret.Data["applied-periodic-output"]) == 0 {
+		return secret, nil
+	}
+
+	node, err := planner.SecretToNode(secret)
+	if err != nil || node == nil {
+		return secret, err
+	}
+	output, ok := node.PeriodicOutput[machinessh.HostKeysInstructionName]
+	if !ok || output.ExitCode != 0 || output.LastSuccessfulRunTime == "" {
+		return secret, nil
+	}
+
+	machine, err := h.machinesCache.Get(secret.Namespace, secret.Labels[capr.MachineNameLabel])
+	if apierrors.IsNotFound(err) {
+		return secret, nil
+	} else if err != nil {
+		return secret, err
+	}
+	if machine.Spec.InfrastructureRef.APIGroup != capr.RKEMachineAPIGroup {
+		return secret, nil
+	}
+
+	hostKeys, err := machinessh.ParseHostKeys(output.Stdout)
+	if err != nil || len(hostKeys) == 0 {
+		logrus.Warnf("[machinesshhostkey] Machine %s/%s reported invalid SSH host keys: %v", machine.Namespace, machine.Name, err)
+		return secret, nil
+	}
+
+	state, err := h.secretCache.Get(machine.Namespace, capr.MachineStateSecretName(machine.Spec.InfrastructureRef.Name))
+	if apierrors.IsNotFound(err) {
+		return secret, nil
+	} else if err != nil {
+		return secret, err
+	}
+
+	reported := machinessh.MarshalHostKeys(hostKeys)
+	pinned := state.Data[machinessh.HostKeyField]
+	repin := state.Annotations[machinessh.HostKeyRepinAnnotation] == "true"
+	if bytes.Equal(pinned, reported) {
+		if state.Annotations[machinessh.HostKeyMismatchAnnotation] == "" && state.Annotations[machinessh.HostKeyRepinAnnotation] == "" {
+			return secret, nil
+		}
+		// the node reports the pinned keys again, the mismatch is resolved
+		state = state.DeepCopy()
+		delete(state.Annotations, machinessh.HostKeyMismatchAnnotation)
+		delete(state.Annotations, machinessh.HostKeyRepinAnnotation)
+		_, err = h.secrets.Update(state)
+		return secret, err
+	}
+
+	state = state.DeepCopy()
+	if len(pinned) > 0 && !repin {
+		fingerprints := machinessh.FingerprintHostKeys(hostKeys)
+		if state.Annotations[machinessh.HostKeyMismatchAnnotation] == fingerprints {
+			return secret, nil
+		}
+		logrus.Warnf("[machinesshhostkey] Machine %s/%s reported SSH host keys %s that differ from the keys pinned in secret %s/%s, keeping the pinned keys until annotation %s is set to true",
+			machine.Namespace, machine.Name, fingerprints, state.Namespace, state.Name, machinessh.HostKeyRepinAnnotation)
+		if state.Annotations == nil {
+			state.Annotations = map[string]string{}
+		}
+		state.Annotations[machinessh.HostKeyMismatchAnnotation] = fingerprints
+		_, err = h.secrets.Update(state)
+		return secret, err
+	}
+
+	if len(pinned) > 0 {
+		logrus.Infof("[machinesshhostkey] Re-pinning host keys of machine %s/%s in secret %s/%s as requested", machine.Namespace, machine.Name, state.Namespace, state.Name)
+	} else {
+		logrus.Infof("[machinesshhostkey] Pinning host keys of machine %s/%s in secret %s/%s", machine.Namespace, machine.Name, state.Namespace, state.Name)
+	}
+	if state.Data == nil {
+		state.Data = map[string][]byte{}
+	}
+	state.Data[machinessh.HostKeyField] = reported
+	delete(state.Annotations, machinessh.HostKeyMismatchAnnotation)
+	delete(state.Annotations, machinessh.HostKeyRepinAnnotation)
+	_, err = h.secrets.Update(state)
+	return secret, err
+}
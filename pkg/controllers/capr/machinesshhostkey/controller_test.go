@@ -0,0 +1,223 @@
+package machinesshhostkey
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/capr/machinessh"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	capi "sigs.k8s.io/cluster-api/api/core/v1beta2"
+)
+
+const (
+	hostKey      = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIE2vTuCqhZ3M1jd5p/sJyfDuoHBq4NZP8eP0GmbVLb1M\n"
+	otherHostKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIN+sUdolXnL9tNRyl3DLAPuTLg2Sm7mnp8e7p1a0YF6U\n"
+)
+
+// newPlanSecret returns the plan secret of the machine "test" holding the given output of the host keys instruction.
+func newPlanSecret(t *testing.T, output plan.PeriodicInstructionOutput) *corev1.Secret {
+	data, err := json.Marshal(map[string]plan.PeriodicInstructionOutput{machinessh.HostKeysInstructionName: output})
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	_, err = gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "fleet-default",
+			Name:      "test-machine-plan",
+			Labels:    map[string]string{capr.MachineNameLabel: "test"},
+		},
+		Type: capr.SecretTypeMachinePlan,
+		Data: map[string][]byte{"plan": []byte("{}"), "applied-periodic-output": buf.Bytes()},
+	}
+}
+
+func newMachine(apiGroup string) *capi.Machine {
+	return &capi.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test"},
+		Spec: capi.MachineSpec{
+			InfrastructureRef: capi.ContractVersionedObjectReference{APIGroup: apiGroup, Name: "test-infra"},
+		},
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	reported := plan.PeriodicInstructionOutput{Name: machinessh.HostKeysInstructionName, Stdout: []byte(hostKey + "\n"), LastSuccessfulRunTime: "now"}
+	state := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: capr.MachineStateSecretName("test-infra")},
+		Type:       machinessh.SecretType,
+		Data:       map[string][]byte{machinessh.ConfigField: []byte("config")},
+	}
+
+	t.Run("pins reported host keys", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		secrets := fake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+		secretCache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+		machines := fake.NewMockCacheInterface[*capi.Machine](ctrl)
+
+		machines.EXPECT().Get("fleet-default", "test").Return(newMachine(capr.RKEMachineAPIGroup), nil)
+		secretCache.EXPECT().Get("fleet-default", state.Name).Return(state, nil)
+		secrets.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+			assert.Equal(t, state.Name, secret.Name)
+			assert.Equal(t, hostKey, string(secret.Data[machinessh.HostKeyField]))
+			assert.Equal(t, "config", string(secret.Data[machinessh.ConfigField]))
+			return secret, nil
+		})
+
+		h := handler{secrets: secrets, secretCache: secretCache, machinesCache: machines}
+		_, err := h.OnChange("", newPlanSecret(t, reported))
+		require.NoError(t, err)
+		assert.Empty(t, state.Data[machinessh.HostKeyField], "cached secret must not be modified")
+	})
+
+	t.Run("keeps pinned host keys", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		secretCache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+		machines := fake.NewMockCacheInterface[*capi.Machine](ctrl)
+
+		pinned := state.DeepCopy()
+		pinned.Data[machinessh.HostKeyField] = []byte(hostKey)
+		machines.EXPECT().Get("fleet-default", "test").Return(newMachine(capr.RKEMachineAPIGroup), nil)
+		secretCache.EXPECT().Get("fleet-default", state.Name).Return(pinned, nil)
+
+		h := handler{secretCache: secretCache, machinesCache: machines}
+		_, err := h.OnChange("", newPlanSecret(t, reported))
+		require.NoError(t, err)
+	})
+
+	changed := plan.PeriodicInstructionOutput{Name: machinessh.HostKeysInstructionName, Stdout: []byte(otherHostKey), LastSuccessfulRunTime: "now"}
+	changedFingerprint := fingerprint(t, otherHostKey)
+
+	t.Run("flags changed host keys and keeps the pinned ones", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		secrets := fake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+		secretCache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+		machines := fake.NewMockCacheInterface[*capi.Machine](ctrl)
+
+		pinned := state.DeepCopy()
+		pinned.Data[machinessh.HostKeyField] = []byte(hostKey)
+		machines.EXPECT().Get("fleet-default", "test").Return(newMachine(capr.RKEMachineAPIGroup), nil)
+		secretCache.EXPECT().Get("fleet-default", state.Name).Return(pinned, nil)
+		secrets.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+			assert.Equal(t, hostKey, string(secret.Data[machinessh.HostKeyField]))
+			assert.Equal(t, changedFingerprint, secret.Annotations[machinessh.HostKeyMismatchAnnotation])
+			return secret, nil
+		})
+
+		h := handler{secrets: secrets, secretCache: secretCache, machinesCache: machines}
+		_, err := h.OnChange("", newPlanSecret(t, changed))
+		require.NoError(t, err)
+	})
+
+	t.Run("does not flag the same mismatch again", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		secretCache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+		machines := fake.NewMockCacheInterface[*capi.Machine](ctrl)
+
+		flagged := state.DeepCopy()
+		flagged.Data[machinessh.HostKeyField] = []byte(hostKey)
+		flagged.Annotations = map[string]string{machinessh.HostKeyMismatchAnnotation: changedFingerprint}
+		machines.EXPECT().Get("fleet-default", "test").Return(newMachine(capr.RKEMachineAPIGroup), nil)
+		secretCache.EXPECT().Get("fleet-default", state.Name).Return(flagged, nil)
+
+		h := handler{secretCache: secretCache, machinesCache: machines}
+		_, err := h.OnChange("", newPlanSecret(t, changed))
+		require.NoError(t, err)
+	})
+
+	t.Run("re-pins changed host keys when requested", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		secrets := fake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+		secretCache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+		machines := fake.NewMockCacheInterface[*capi.Machine](ctrl)
+
+		repin := state.DeepCopy()
+		repin.Data[machinessh.HostKeyField] = []byte(hostKey)
+		repin.Annotations = map[string]string{
+			machinessh.HostKeyMismatchAnnotation: changedFingerprint,
+			machinessh.HostKeyRepinAnnotation:    "true",
+		}
+		machines.EXPECT().Get("fleet-default", "test").Return(newMachine(capr.RKEMachineAPIGroup), nil)
+		secretCache.EXPECT().Get("fleet-default", state.Name).Return(repin, nil)
+		secrets.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+			assert.Equal(t, otherHostKey, string(secret.Data[machinessh.HostKeyField]))
+			assert.Empty(t, secret.Annotations)
+			return secret, nil
+		})
+
+		h := handler{secrets: secrets, secretCache: secretCache, machinesCache: machines}
+		_, err := h.OnChange("", newPlanSecret(t, changed))
+		require.NoError(t, err)
+	})
+
+	t.Run("clears the mismatch when the pinned keys are reported again", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		secrets := fake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+		secretCache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+		machines := fake.NewMockCacheInterface[*capi.Machine](ctrl)
+
+		flagged := state.DeepCopy()
+		flagged.Data[machinessh.HostKeyField] = []byte(hostKey)
+		flagged.Annotations = map[string]string{machinessh.HostKeyMismatchAnnotation: changedFingerprint}
+		machines.EXPECT().Get("fleet-default", "test").Return(newMachine(capr.RKEMachineAPIGroup), nil)
+		secretCache.EXPECT().Get("fleet-default", state.Name).Return(flagged, nil)
+		secrets.EXPECT().Update(gomock.Any()).DoAndReturn(func(secret *corev1.Secret) (*corev1.Secret, error) {
+			assert.Equal(t, hostKey, string(secret.Data[machinessh.HostKeyField]))
+			assert.Empty(t, secret.Annotations)
+			return secret, nil
+		})
+
+		h := handler{secrets: secrets, secretCache: secretCache, machinesCache: machines}
+		_, err := h.OnChange("", newPlanSecret(t, reported))
+		require.NoError(t, err)
+	})
+
+	t.Run("ignores custom machines", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		machines := fake.NewMockCacheInterface[*capi.Machine](ctrl)
+		machines.EXPECT().Get("fleet-default", "test").Return(newMachine(""), nil)
+
+		h := handler{machinesCache: machines}
+		_, err := h.OnChange("", newPlanSecret(t, reported))
+		require.NoError(t, err)
+	})
+
+	t.Run("ignores failed instruction", func(t *testing.T) {
+		h := handler{}
+		_, err := h.OnChange("", newPlanSecret(t, plan.PeriodicInstructionOutput{Name: machinessh.HostKeysInstructionName, ExitCode: 1}))
+		require.NoError(t, err)
+	})
+
+	t.Run("ignores deleted machines", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		machines := fake.NewMockCacheInterface[*capi.Machine](ctrl)
+		machines.EXPECT().Get("fleet-default", "test").Return(nil, apierrors.NewNotFound(schema.GroupResource{}, "test"))
+
+		h := handler{machinesCache: machines}
+		_, err := h.OnChange("", newPlanSecret(t, reported))
+		require.NoError(t, err)
+	})
+}
+
+// fingerprint returns the SHA256 fingerprint of a host key in the authorized_keys format.
+func fingerprint(t *testing.T, key string) string {
+	keys, err := machinessh.ParseHostKeys([]byte(key))
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	return ssh.FingerprintSHA256(keys[0])
+}
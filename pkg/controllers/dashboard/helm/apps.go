@@ -8,6 +8,7 @@
 	"github.com/rancher/lasso/pkg/client"
 	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/catalogv2/helm"
+	"github.com/rancher/rancher/pkg/catalogv2/verify"
 	catalogv1 "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
 	corev1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
 	"github.com/rancher/wrangler/v3/pkg/apply"
@@ -81,6 +82,10 @@ func (a *appHandler) appStatus(app *v1.App, status v1.ReleaseStatus) (v1.Release
 
 	status.Summary = summary
 	status.ObservedGeneration = app.Generation
+	status.VerifiedSigner = ""
+	if app.Spec.Chart != nil && app.Spec.Chart.Metadata != nil {
+		status.VerifiedSigner = app.Spec.Chart.Metadata.Annotations[verify.VerifiedSignerAnnotation]
+	}
 
 	return status, nil
 }
@@ -5,7 +5,9 @@
 	"fmt"
 
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/notifications"
 	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/v3/pkg/kstatus"
 	"github.com/rancher/wrangler/v3/pkg/relatedresource"
@@ -25,18 +27,23 @@ type operationHandler struct {
 	pods            corecontrollers.PodCache
 	k8s             kubernetes.Interface
 	operationsCache catalogcontrollers.OperationCache
+	publisher       *notifications.Publisher
 }
 
+// RegisterOperations registers the handlers of Helm operations. The failed operations are published with the
+// publisher, which is nil outside of Rancher.
 func RegisterOperations(ctx context.Context,
 	k8s kubernetes.Interface,
 	pods corecontrollers.PodController,
-	operations catalogcontrollers.OperationController) {
+	operations catalogcontrollers.OperationController,
+	publisher *notifications.Publisher) {
 
 	o := operationHandler{
 		ctx:             ctx,
 		k8s:             k8s,
 		pods:            pods.Cache(),
 		operationsCache: operations.Cache(),
+		publisher:       publisher,
 	}
 
 	operations.Cache().AddIndexer(podIndex, indexOperationsByPod)
@@ -90,6 +97,7 @@ func (o *operationHandler) onOperationChange(operation *catalog.Operation, statu
 					fmt.Sprintf("%s exit code: %d",
 						container.State.Terminated.Message,
 						container.State.Terminated.ExitCode))
+				o.publishFailure(operation, status, container.State.Terminated.ExitCode)
 			}
 			if err := o.cleanup(pod); err != nil {
 				return status, err
@@ -104,6 +112,19 @@ func (o *operationHandler) onOperationChange(operation *catalog.Operation, statu
 	return status, nil
 }
 
+// publishFailure publishes the event of a Helm operation failing, once per operation.
+func (o *operationHandler) publishFailure(operation *catalog.Operation, status catalog.OperationStatus, exitCode int32) {
+	o.publisher.PublishOnce(notifications.Event{
+		Type:        v3.NotificationEventHelmOperationFailed,
+		Severity:    v3.NotificationSeverityError,
+		Key:         operation.Namespace + "/" + operation.Name,
+		ClusterName: "local",
+		Object:      operation.Namespace + "/" + operation.Name,
+		Message: fmt.Sprintf("Helm %s of release %s/%s (chart %s %s) failed with exit code %d",
+			status.Action, status.Namespace, status.Release, status.Chart, status.Version, exitCode),
+	})
+}
+
 func (o *operationHandler) cleanup(pod *corev1.Pod) error {
 	running := false
 	success := false
@@ -3,10 +3,16 @@
 import (
 	"context"
 
+	"github.com/rancher/rancher/pkg/features"
+	"github.com/rancher/rancher/pkg/notifications"
 	"github.com/rancher/rancher/pkg/wrangler"
 )
 
 func Register(ctx context.Context, wrangler *wrangler.Context) {
+	var publisher *notifications.Publisher
+	if features.MCM.Enabled() {
+		publisher = notifications.NewPublisher(wrangler.Mgmt.RancherUserNotification())
+	}
 	RegisterRepos(ctx,
 		wrangler.Apply,
 		wrangler.Core.Secret().Cache(),
@@ -27,5 +33,6 @@ func Register(ctx context.Context, wrangler *wrangler.Context) {
 	RegisterOperations(ctx,
 		wrangler.K8s,
 		wrangler.Core.Pod(),
-		wrangler.Catalog.Operation())
+		wrangler.Catalog.Operation(),
+		publisher)
 }
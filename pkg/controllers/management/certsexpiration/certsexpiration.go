@@ -9,6 +9,7 @@
 
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/notifications"
 	"github.com/rancher/rancher/pkg/rkecerts"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/sirupsen/logrus"
@@ -40,6 +41,7 @@ func Register(ctx context.Context, management *config.ManagementContext) {
 	c := &certsExpiration{
 		clusters:  management.Management.Clusters(""),
 		k8sClient: management.K8sClient,
+		publisher: notifications.NewPublisher(management.Wrangler.Mgmt.RancherUserNotification()),
 	}
 	management.Management.Clusters("").AddHandler(ctx, "certificate-expiration", c.sync)
 }
@@ -47,6 +49,7 @@ func Register(ctx context.Context, management *config.ManagementContext) {
 type certsExpiration struct {
 	clusters  v3.ClusterInterface
 	k8sClient kubernetes.Interface
+	publisher *notifications.Publisher
 }
 
 func (c *certsExpiration) sync(key string, cluster *v3.Cluster) (runtime.Object, error) {
@@ -72,7 +75,7 @@ func (c *certsExpiration) sync(key string, cluster *v3.Cluster) (runtime.Object,
 			continue
 		}
 		certsExpInfo[certName] = info
-		err = logCertExpirationWarning(certName, info)
+		err = c.logCertExpirationWarning(certName, info)
 		if err != nil {
 			logrus.Warnf("certificate [%s] from local cluster has or will expire and date is corrupted: %v", certName, err)
 			continue
@@ -86,15 +89,27 @@ func (c *certsExpiration) sync(key string, cluster *v3.Cluster) (runtime.Object,
 	return cluster, nil
 }
 
-func logCertExpirationWarning(name string, certExp v32.CertExpiration) error {
+func (c *certsExpiration) logCertExpirationWarning(name string, certExp v32.CertExpiration) error {
 	date, err := time.Parse(time.RFC3339, certExp.ExpirationDate)
 	if err != nil {
 		return err
 	}
+	event := notifications.Event{
+		Type:        v32.NotificationEventCertificateExpiring,
+		Key:         "local/" + name,
+		ClusterName: "local",
+		Object:      name,
+	}
 	if time.Now().UTC().After(date) { // warn if expired
 		logrus.Warnf("Certificate from local cluster has expired: %s", name)
+		event.Severity = v32.NotificationSeverityError
+		event.Message = fmt.Sprintf("Certificate %s of the local cluster expired on %s", name, certExp.ExpirationDate)
+		c.publisher.Publish(event)
 	} else if time.Now().UTC().AddDate(0, 1, 0).After(date) { // warn if within a month
 		logrus.Warnf("Certificate from local cluster will expire soon: %s", name)
+		event.Severity = v32.NotificationSeverityWarning
+		event.Message = fmt.Sprintf("Certificate %s of the local cluster will expire on %s", name, certExp.ExpirationDate)
+		c.publisher.Publish(event)
 	}
 	return nil
 }
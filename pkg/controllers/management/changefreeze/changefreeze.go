@@ -0,0 +1,72 @@
+// Package changefreeze reports the change freeze of clusters as their ChangeFrozen condition, updated whenever a
+// scheduled freeze window starts or ends.
+package changefreeze
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	freeze "github.com/rancher/rancher/pkg/changefreeze"
+	managementcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/wrangler"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	reasonFrozen    = "Frozen"
+	reasonScheduled = "Scheduled"
+	reasonUnfrozen  = "Unfrozen"
+)
+
+type handler struct {
+	clusters managementcontrollers.ClusterController
+	now      func() time.Time
+}
+
+func Register(ctx context.Context, wrangler *wrangler.Context) {
+	h := &handler{
+		clusters: wrangler.Mgmt.Cluster(),
+		now:      time.Now,
+	}
+	wrangler.Mgmt.Cluster().OnChange(ctx, "cluster-change-freeze", h.onChange)
+}
+
+func (h *handler) onChange(_ string, cluster *v3.Cluster) (*v3.Cluster, error) {
+	if cluster == nil || cluster.DeletionTimestamp != nil {
+		return cluster, nil
+	}
+	freezeSpec := cluster.Spec.ChangeFreeze
+	if freezeSpec == nil && v3.ClusterConditionChangeFrozen.GetStatus(cluster) == "" {
+		return cluster, nil
+	}
+
+	now := h.now()
+	active, transition := freeze.Active(freezeSpec, now)
+	if !transition.IsZero() {
+		h.clusters.EnqueueAfter(cluster.Name, transition.Sub(now))
+	}
+
+	status, reason, message := corev1.ConditionFalse, reasonUnfrozen, ""
+	switch {
+	case active:
+		status, reason = corev1.ConditionTrue, reasonFrozen
+		message = (&freeze.FrozenError{ClusterID: cluster.Name, Reason: freezeSpec.Reason, Until: transition}).Error()
+	case !transition.IsZero():
+		reason = reasonScheduled
+		message = fmt.Sprintf("change freeze starts at %s", transition.UTC().Format(time.RFC3339))
+	}
+
+	if v3.ClusterConditionChangeFrozen.GetStatus(cluster) == string(status) &&
+		v3.ClusterConditionChangeFrozen.GetReason(cluster) == reason &&
+		v3.ClusterConditionChangeFrozen.GetMessage(cluster) == message {
+		return cluster, nil
+	}
+
+	cluster = cluster.DeepCopy()
+	v3.ClusterConditionChangeFrozen.SetStatus(cluster, string(status))
+	v3.ClusterConditionChangeFrozen.Reason(cluster, reason)
+	v3.ClusterConditionChangeFrozen.Message(cluster, message)
+	return h.clusters.UpdateStatus(cluster)
+}
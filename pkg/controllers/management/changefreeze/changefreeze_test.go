@@ -0,0 +1,91 @@
+package changefreeze
+
+import (
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOnChange(t *testing.T) {
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	window := func(start, end time.Duration) []v3.ChangeFreezeWindow {
+		return []v3.ChangeFreezeWindow{{Start: metav1.NewTime(now.Add(start)), End: metav1.NewTime(now.Add(end))}}
+	}
+	tests := []struct {
+		name        string
+		freeze      *v3.ClusterChangeFreeze
+		wantStatus  string
+		wantReason  string
+		wantMessage string
+		wantEnqueue time.Duration
+	}{
+		{
+			name:        "enabled",
+			freeze:      &v3.ClusterChangeFreeze{Enabled: true, Reason: "incident"},
+			wantStatus:  "True",
+			wantReason:  reasonFrozen,
+			wantMessage: "cluster c-abcde is in a change freeze: incident",
+		},
+		{
+			name:        "in window",
+			freeze:      &v3.ClusterChangeFreeze{Windows: window(-time.Hour, time.Hour)},
+			wantStatus:  "True",
+			wantReason:  reasonFrozen,
+			wantMessage: "cluster c-abcde is in a change freeze until 2024-01-08T21:00:00Z, changes are rejected",
+			wantEnqueue: time.Hour,
+		},
+		{
+			name:        "scheduled",
+			freeze:      &v3.ClusterChangeFreeze{Windows: window(2*time.Hour, 3*time.Hour)},
+			wantStatus:  "False",
+			wantReason:  reasonScheduled,
+			wantMessage: "change freeze starts at 2024-01-08T22:00:00Z",
+			wantEnqueue: 2 * time.Hour,
+		},
+		{
+			name:       "unfrozen",
+			freeze:     &v3.ClusterChangeFreeze{},
+			wantStatus: "False",
+			wantReason: reasonUnfrozen,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			clusters := fake.NewMockNonNamespacedControllerInterface[*v3.Cluster, *v3.ClusterList](ctrl)
+			if tt.wantEnqueue != 0 {
+				clusters.EXPECT().EnqueueAfter("c-abcde", tt.wantEnqueue).Times(2)
+			}
+			clusters.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(cluster *v3.Cluster) (*v3.Cluster, error) {
+				return cluster, nil
+			})
+			h := &handler{clusters: clusters, now: func() time.Time { return now }}
+
+			cluster := &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-abcde"}, Spec: v3.ClusterSpec{ChangeFreeze: tt.freeze}}
+			updated, err := h.onChange("", cluster)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, v3.ClusterConditionChangeFrozen.GetStatus(updated))
+			assert.Equal(t, tt.wantReason, v3.ClusterConditionChangeFrozen.GetReason(updated))
+			assert.Equal(t, tt.wantMessage, v3.ClusterConditionChangeFrozen.GetMessage(updated))
+
+			// the condition is only updated when it changes
+			_, err = h.onChange("", updated)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestOnChangeNoFreeze(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	h := &handler{clusters: fake.NewMockNonNamespacedControllerInterface[*v3.Cluster, *v3.ClusterList](ctrl), now: time.Now}
+	cluster := &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c-abcde"}}
+	updated, err := h.onChange("", cluster)
+	require.NoError(t, err)
+	assert.Same(t, cluster, updated)
+}
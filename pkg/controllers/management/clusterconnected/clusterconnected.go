@@ -11,6 +11,7 @@
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/capr"
 	managementcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/notifications"
 	"github.com/rancher/rancher/pkg/wrangler"
 	"github.com/rancher/remotedialer"
 	"github.com/rancher/wrangler/v3/pkg/condition"
@@ -30,6 +31,7 @@ func Register(ctx context.Context, wrangler *wrangler.Context) {
 		clusterCache: wrangler.Mgmt.Cluster().Cache(),
 		clusters:     wrangler.Mgmt.Cluster(),
 		tunnelServer: wrangler.TunnelServer,
+		publisher:    notifications.NewPublisher(wrangler.Mgmt.RancherUserNotification()),
 	}
 
 	go func() {
@@ -45,6 +47,7 @@ type checker struct {
 	clusterCache managementcontrollers.ClusterCache
 	clusters     managementcontrollers.ClusterClient
 	tunnelServer *remotedialer.Server
+	publisher    *notifications.Publisher
 }
 
 func (c *checker) check() error {
@@ -120,6 +123,7 @@ func (c *checker) updateClusterConnectedCondition(cluster *v3.Cluster, connected
 	if cluster == nil {
 		return fmt.Errorf("cluster cannot be nil")
 	}
+	wasConnected := Connected.IsTrue(cluster)
 	for i := 0; i < 3; i++ {
 		cluster = cluster.DeepCopy()
 		Connected.SetStatusBool(cluster, connected)
@@ -137,6 +141,15 @@ func (c *checker) updateClusterConnectedCondition(cluster *v3.Cluster, connected
 			}
 			continue
 		}
+		if err == nil && wasConnected && !connected {
+			c.publisher.Publish(notifications.Event{
+				Type:        v3.NotificationEventClusterDisconnected,
+				Severity:    v3.NotificationSeverityError,
+				Key:         cluster.Name,
+				ClusterName: cluster.Name,
+				Message:     fmt.Sprintf("The agent of cluster %s (%s) disconnected", cluster.Spec.DisplayName, cluster.Name),
+			})
+		}
 		return err
 	}
 	return fmt.Errorf("unable to update cluster connected condition")
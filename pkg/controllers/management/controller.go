@@ -7,6 +7,7 @@
 	"github.com/rancher/rancher/pkg/controllers/management/agentupgrade"
 	"github.com/rancher/rancher/pkg/controllers/management/auth"
 	"github.com/rancher/rancher/pkg/controllers/management/certsexpiration"
+	"github.com/rancher/rancher/pkg/controllers/management/changefreeze"
 	"github.com/rancher/rancher/pkg/controllers/management/cloudcredential"
 	"github.com/rancher/rancher/pkg/controllers/management/cluster"
 	"github.com/rancher/rancher/pkg/controllers/management/clusterdeploy"
@@ -17,6 +18,7 @@
 	"github.com/rancher/rancher/pkg/controllers/management/drivers/kontainerdriver"
 	"github.com/rancher/rancher/pkg/controllers/management/drivers/nodedriver"
 	"github.com/rancher/rancher/pkg/controllers/management/node"
+	"github.com/rancher/rancher/pkg/controllers/management/notifications"
 	"github.com/rancher/rancher/pkg/controllers/management/secretmigrator"
 	"github.com/rancher/rancher/pkg/controllers/management/settings"
 	"github.com/rancher/rancher/pkg/controllers/management/usercontrollers"
@@ -34,6 +36,7 @@ func Register(ctx context.Context, management *config.ManagementContext, manager
 	// a-z
 	agentupgrade.Register(ctx, management)
 	certsexpiration.Register(ctx, management)
+	changefreeze.Register(ctx, wrangler)
 	cluster.Register(ctx, management)
 	clusterdeploy.Register(ctx, management, manager)
 	clustergc.Register(ctx, management)
@@ -44,6 +47,7 @@ func Register(ctx context.Context, management *config.ManagementContext, manager
 	nodedriver.Register(ctx, management)
 	cloudcredential.Register(ctx, management, wrangler)
 	node.Register(ctx, management, manager)
+	notifications.Register(ctx, wrangler)
 
 	secretmigrator.Register(ctx, management)
 	settings.Register(ctx, management)
@@ -19,6 +19,7 @@
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/rancher/rancher/pkg/settings"
 	"github.com/sirupsen/logrus"
 )
 
@@ -28,6 +29,12 @@ type BaseDriver struct {
 	DriverHash   string
 	DriverName   string
 	BinaryPrefix string
+	// Verification, when set, must succeed before a downloaded binary is
+	// installed.
+	Verification *SignatureVerification
+	// VerifiedKey is the name of the public key which verified the
+	// signature of the staged binary.
+	VerifiedKey string
 }
 
 func (d *BaseDriver) Name() string {
@@ -60,6 +67,7 @@ func (d *BaseDriver) Remove() error {
 	dest := path.Join(binDir(), string(content))
 	_ = os.Remove(dest)
 	_ = os.Remove(cacheFilePrefix + "-" + string(content))
+	_ = os.Remove(cacheFilePrefix + ".verified")
 	_ = os.Remove(cacheFilePrefix)
 
 	return nil
@@ -89,6 +97,10 @@ func (d *BaseDriver) getError() error {
 	if content, err := os.ReadFile(errFile); err == nil {
 		logrus.Errorf("Returning previous error: %s", content)
 		d.ClearError()
+		// keep verification failures wrapped so that callers can still tell them apart on retries
+		if message, ok := strings.CutPrefix(string(content), ErrNotVerified.Error()+": "); ok {
+			return fmt.Errorf("%w: %s", ErrNotVerified, message)
+		}
 		return errors.New(string(content))
 	}
 
@@ -107,9 +119,16 @@ func (d *BaseDriver) stage(forceUpdate bool) error {
 
 	cacheFilePrefix := d.cacheFile()
 
+	if d.Verification == nil && settings.DriverSignatureRequired.Get() == "true" {
+		return fmt.Errorf("%w: driver %s does not specify a signature and %s is enabled", ErrNotVerified, d.URL, settings.DriverSignatureRequired.Name)
+	}
+
 	driverName, err := isInstalled(cacheFilePrefix)
 	if !forceUpdate && err != nil || driverName != "" {
 		d.DriverName = driverName
+		if err == nil && d.Verification != nil {
+			d.VerifiedKey, err = isVerified(cacheFilePrefix)
+		}
 		return err
 	}
 
@@ -142,12 +161,28 @@ func (d *BaseDriver) stage(forceUpdate bool) error {
 		return err
 	}
 
+	verifiedKey := ""
+	if d.Verification != nil {
+		verifiedKey, err = d.Verification.Verify(tempFile.Name())
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrNotVerified, err)
+		}
+		logrus.Infof("Driver %s verified with key %s", d.URL, verifiedKey)
+	}
+
 	driverName, err = d.copyBinary(cacheFilePrefix, tempFile.Name())
 	if err != nil {
 		return err
 	}
 
+	if d.Verification != nil {
+		if err := os.WriteFile(cacheFilePrefix+".verified", []byte(verifiedKey), 0644); err != nil {
+			return err
+		}
+	}
+
 	d.DriverName = driverName
+	d.VerifiedKey = verifiedKey
 	return nil
 }
 
@@ -339,6 +374,11 @@ func (d *BaseDriver) download(dest io.Writer) error {
 
 func (d *BaseDriver) cacheFile() string {
 	key := sha256Bytes([]byte(d.URL + d.DriverHash))
+	if d.Verification != nil {
+		// a binary staged without verification must not be reused once a
+		// signature is required
+		key = sha256Bytes([]byte(d.URL + d.DriverHash + d.Verification.Format + d.Verification.SignatureURL))
+	}
 
 	base := os.Getenv("CATTLE_HOME")
 	if base == "" {
@@ -356,6 +396,15 @@ func isInstalled(file string) (string, error) {
 	return strings.ToLower(strings.TrimSpace(string(content))), err
 }
 
+// isVerified returns the name of the key which verified the cached binary.
+func isVerified(cacheFile string) (string, error) {
+	content, err := os.ReadFile(cacheFile + ".verified")
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("%w: cached driver %s has no verification record", ErrNotVerified, cacheFile)
+	}
+	return strings.TrimSpace(string(content)), err
+}
+
 func sha256Bytes(content []byte) string {
 	hash := sha256.New()
 	_, _ = io.Copy(hash, bytes.NewBuffer(content))
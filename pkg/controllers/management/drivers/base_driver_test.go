@@ -0,0 +1,25 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetError(t *testing.T) {
+	t.Setenv("CATTLE_HOME", t.TempDir())
+	d := &BaseDriver{URL: "https://example.com/docker-machine-driver-test"}
+
+	_ = d.setError(fmt.Errorf("%w: no valid signature for %s", ErrNotVerified, d.URL))
+	err := d.getError()
+	assert.True(t, errors.Is(err, ErrNotVerified))
+	assert.Equal(t, "signature verification failed: no valid signature for "+d.URL, err.Error())
+	assert.NoError(t, d.getError(), "the error is only returned once")
+
+	_ = d.setError(errors.New("download failed"))
+	err = d.getError()
+	assert.False(t, errors.Is(err, ErrNotVerified))
+	assert.EqualError(t, err, "download failed")
+}
@@ -37,6 +37,7 @@ func Register(ctx context.Context, management *config.ManagementContext) {
 		namespaces:           management.Core.Namespaces(""),
 		coreV1:               management.Core,
 		clusterClient:        management.Management.Clusters(""),
+		secretLister:         management.Core.Secrets("").Controller().Lister(),
 	}
 
 	management.Management.KontainerDrivers("").AddLifecycle(ctx, "mgmt-kontainer-driver-lifecycle", lifecycle)
@@ -48,6 +49,7 @@ type Lifecycle struct {
 	namespaces           corev1.NamespaceInterface
 	coreV1               corev1.Interface
 	clusterClient        v3.ClusterInterface
+	secretLister         corev1.SecretLister
 }
 
 func (l *Lifecycle) Create(obj *v3.KontainerDriver) (runtime.Object, error) {
@@ -77,17 +79,55 @@ func (l *Lifecycle) Create(obj *v3.KontainerDriver) (runtime.Object, error) {
 	return obj, nil
 }
 
+func (l *Lifecycle) newDriver(obj *v3.KontainerDriver) (*drivers.KontainerDriver, error) {
+	driver := drivers.NewKontainerDriver(obj.Spec.BuiltIn, obj.Status.DisplayName, obj.Spec.URL, obj.Spec.Checksum)
+	if obj.Spec.BuiltIn {
+		return driver, nil
+	}
+
+	verification, err := drivers.NewSignatureVerification(obj.Spec.Verification, l.secretLister)
+	if err != nil {
+		return nil, err
+	}
+	driver.Verification = verification
+
+	return driver, nil
+}
+
 func (l *Lifecycle) driverExists(obj *v3.KontainerDriver) bool {
-	return drivers.NewKontainerDriver(obj.Spec.BuiltIn, obj.Status.DisplayName, obj.Spec.URL, obj.Spec.Checksum).Exists()
+	driver, err := l.newDriver(obj)
+	if err != nil {
+		return false
+	}
+	return driver.Exists()
 }
 
 func (l *Lifecycle) download(obj *v3.KontainerDriver) (*v3.KontainerDriver, error) {
-	driver := drivers.NewKontainerDriver(obj.Spec.BuiltIn, obj.Status.DisplayName, obj.Spec.URL, obj.Spec.Checksum)
-	err := driver.Stage(false)
+	driver, err := l.newDriver(obj)
+	if err != nil {
+		v32.KontainerDriverConditionVerified.False(obj)
+		v32.KontainerDriverConditionVerified.Message(obj, err.Error())
+		return obj, err
+	}
+
+	err = driver.Stage(false)
+	obj.Status.VerificationResult = driver.VerificationStatus(err)
+	if errorsutil.Is(err, drivers.ErrNotVerified) {
+		// keep the failed verification visible on the driver, it will not
+		// be installed until a valid signature is provided
+		v32.KontainerDriverConditionVerified.False(obj)
+		v32.KontainerDriverConditionVerified.Message(obj, err.Error())
+		return obj, err
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	if obj.Status.VerificationResult != nil {
+		v32.KontainerDriverConditionVerified.True(obj)
+		v32.KontainerDriverConditionVerified.Message(obj, "verified with key "+driver.VerifiedKey)
+	}
+
 	v32.KontainerDriverConditionDownloaded.True(obj)
 
 	path, err := driver.Install()
@@ -381,6 +421,7 @@ func (l *Lifecycle) Updated(obj *v3.KontainerDriver) (runtime.Object, error) {
 	case !v32.KontainerDriverConditionDownloaded.IsUnknown(obj) &&
 		(obj.Spec.URL != obj.Status.ActualURL ||
 			v32.KontainerDriverConditionDownloaded.IsFalse(obj) ||
+			drivers.VerificationOutdated(obj.Spec.Verification, obj.Status.VerificationResult) ||
 			!l.driverExists(obj)):
 		v32.KontainerDriverConditionDownloaded.Unknown(obj)
 
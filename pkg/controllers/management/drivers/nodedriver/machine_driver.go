@@ -46,6 +46,7 @@ func Register(ctx context.Context, management *config.ManagementContext) {
 		schemaClient:     management.Management.DynamicSchemas(""),
 		schemaLister:     management.Management.DynamicSchemas("").Controller().Lister(),
 		secretStore:      management.Core.Secrets(""),
+		secretLister:     management.Core.Secrets("").Controller().Lister(),
 		nsStore:          management.Core.Namespaces(""),
 		schemas:          management.Schemas,
 	}
@@ -64,6 +65,7 @@ type Lifecycle struct {
 	schemaClient         v3.DynamicSchemaInterface
 	schemaLister         v3.DynamicSchemaLister
 	secretStore          v1.SecretInterface
+	secretLister         v1.SecretLister
 	nsStore              v1.NamespaceInterface
 	schemas              *types.Schemas
 	dockerMachineVersion string
@@ -90,6 +92,15 @@ func (m *Lifecycle) download(obj *v32.NodeDriver) (*v32.NodeDriver, error) {
 	err := errs.New("not found")
 	// if node driver was created, we also activate the driver by default
 	driver := drivers.NewDynamicDriver(obj.Spec.Builtin, obj.Spec.DisplayName, obj.Spec.URL, obj.Spec.Checksum)
+	if !obj.Spec.Builtin {
+		verification, verr := drivers.NewSignatureVerification(obj.Spec.Verification, m.secretLister)
+		if verr != nil {
+			v32.NodeDriverConditionVerified.False(obj)
+			v32.NodeDriverConditionVerified.Message(obj, verr.Error())
+			return obj, verr
+		}
+		driver.Verification = verification
+	}
 	schemaName := obj.Spec.DisplayName + "config"
 	var existingSchema *v32.DynamicSchema
 	if obj.Spec.DisplayName != "" {
@@ -127,6 +138,7 @@ func (m *Lifecycle) download(obj *v32.NodeDriver) (*v32.NodeDriver, error) {
 		v32.NodeDriverConditionInstalled.Unknown(obj)
 	}
 
+	staged := false
 	newObj, err := v32.NodeDriverConditionDownloaded.Once(obj, func() (runtime.Object, error) {
 		// update status
 		obj, err = m.nodeDriverClient.Update(obj)
@@ -137,13 +149,27 @@ func (m *Lifecycle) download(obj *v32.NodeDriver) (*v32.NodeDriver, error) {
 		if err := driver.Stage(forceUpdate); err != nil {
 			return nil, err
 		}
+		staged = true
 		return obj, nil
 	})
+	if errs.Is(err, drivers.ErrNotVerified) {
+		obj.Status.VerificationResult = driver.VerificationStatus(err)
+		v32.NodeDriverConditionVerified.False(obj)
+		v32.NodeDriverConditionVerified.Message(obj, err.Error())
+	}
 	if err != nil {
 		return obj, err
 	}
 
 	obj = newObj.(*v32.NodeDriver)
+	if staged {
+		obj.Status.VerificationResult = driver.VerificationStatus(nil)
+		if obj.Status.VerificationResult != nil {
+			v32.NodeDriverConditionVerified.True(obj)
+			v32.NodeDriverConditionVerified.Message(obj, "verified with key "+driver.VerifiedKey)
+		}
+	}
+
 	newObj, err = v32.NodeDriverConditionInstalled.Once(obj, func() (runtime.Object, error) {
 		if err := driver.Install(); err != nil {
 			return nil, err
@@ -307,7 +333,7 @@ func (m *Lifecycle) checkDriverVersion(obj *v32.NodeDriver) bool {
 		return true
 	}
 
-	return false
+	return drivers.VerificationOutdated(obj.Spec.Verification, obj.Status.VerificationResult)
 }
 
 func (m *Lifecycle) addVersionInfo(obj *v32.NodeDriver) *v32.NodeDriver {
@@ -0,0 +1,295 @@
+package drivers
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/blake2b"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrNotVerified is returned when the signature of a driver binary could not
+// be verified.
+var ErrNotVerified = errors.New("signature verification failed")
+
+const (
+	minisignAlgLegacy    = "Ed"
+	minisignAlgPrehashed = "ED"
+	minisignKeyIDLen     = 8
+
+	// maxSignatureSize bounds the size of a downloaded signature, which
+	// is at most a few hundred bytes for both supported formats.
+	maxSignatureSize = 64 * 1024
+)
+
+// SecretGetter is satisfied by both the norman secret lister and the
+// wrangler secret cache.
+type SecretGetter interface {
+	Get(namespace, name string) (*corev1.Secret, error)
+}
+
+// SignatureVerification describes how a downloaded driver binary must be
+// authenticated before it is installed.
+type SignatureVerification struct {
+	Format       string
+	SignatureURL string
+	// PublicKeys maps the name of each trusted key to its content.
+	PublicKeys map[string][]byte
+}
+
+// NewSignatureVerification builds the SignatureVerification for a driver
+// from its spec, loading the trusted public keys from the referenced secret.
+// It returns nil if the spec does not request a verification.
+func NewSignatureVerification(spec *v32.DriverVerification, secrets SecretGetter) (*SignatureVerification, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	switch spec.Format {
+	case v32.DriverSignatureFormatCosign, v32.DriverSignatureFormatMinisign:
+	default:
+		return nil, fmt.Errorf("unsupported signature format %q", spec.Format)
+	}
+
+	if spec.SignatureURL == "" {
+		return nil, fmt.Errorf("signature URL must be set")
+	}
+
+	namespace, name, ok := strings.Cut(spec.PublicKeysSecretName, ":")
+	if !ok || namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid public keys secret name %q, must be of the form namespace:name", spec.PublicKeysSecretName)
+	}
+
+	secret, err := secrets.Get(namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get public keys secret %s: %w", spec.PublicKeysSecretName, err)
+	}
+	if len(secret.Data) == 0 {
+		return nil, fmt.Errorf("public keys secret %s has no keys", spec.PublicKeysSecretName)
+	}
+
+	return &SignatureVerification{
+		Format:       spec.Format,
+		SignatureURL: spec.SignatureURL,
+		PublicKeys:   secret.Data,
+	}, nil
+}
+
+// VerificationOutdated returns true if the driver must be staged again to
+// satisfy the verification requested in its spec.
+func VerificationOutdated(spec *v32.DriverVerification, status *v32.DriverVerificationStatus) bool {
+	if spec == nil {
+		return false
+	}
+	return status == nil || !status.Verified || status.Format != spec.Format || status.SignatureURL != spec.SignatureURL
+}
+
+// VerificationStatus returns the status to report for a driver whose staging
+// completed with the given error.
+func (d *BaseDriver) VerificationStatus(err error) *v32.DriverVerificationStatus {
+	if d.Verification == nil {
+		return nil
+	}
+
+	status := &v32.DriverVerificationStatus{
+		Format:       d.Verification.Format,
+		SignatureURL: d.Verification.SignatureURL,
+	}
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+
+	status.Verified = d.VerifiedKey != ""
+	status.Key = d.VerifiedKey
+	return status
+}
+
+// Verify checks the signature of the file at path against the trusted
+// public keys and returns the name of the key which verified it.
+func (s *SignatureVerification) Verify(path string) (string, error) {
+	signature, err := downloadSignature(s.SignatureURL)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var verify func(key, signature, content []byte) error
+	switch s.Format {
+	case v32.DriverSignatureFormatCosign:
+		verify = verifyCosign
+	case v32.DriverSignatureFormatMinisign:
+		verify = verifyMinisign
+	default:
+		return "", fmt.Errorf("unsupported signature format %q", s.Format)
+	}
+
+	names := make([]string, 0, len(s.PublicKeys))
+	for name := range s.PublicKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := verify(s.PublicKeys[name], signature, content); err != nil {
+			logrus.Debugf("Signature %s not verified by key %s: %v", s.SignatureURL, name, err)
+			continue
+		}
+		return name, nil
+	}
+
+	return "", fmt.Errorf("signature %s is not valid for any of the %d trusted public keys", s.SignatureURL, len(names))
+}
+
+func downloadSignature(url string) ([]byte, error) {
+	logrus.Infof("Download signature %s", url)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download signature %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize))
+}
+
+// verifyCosign verifies a detached signature as produced by
+// "cosign sign-blob", which is the base64 encoding of the signature of the
+// sha256 digest of the content.
+func verifyCosign(key, signature, content []byte) error {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return fmt.Errorf("public key is not PEM encoded")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		// cosign can also write the raw signature with --output-signature
+		sig = signature
+	}
+
+	digest := sha256.Sum256(content)
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return fmt.Errorf("invalid ecdsa signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("invalid rsa signature: %w", err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, content, sig) {
+			return fmt.Errorf("invalid ed25519 signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	return nil
+}
+
+// verifyMinisign verifies a signature as produced by "minisign -S", checking
+// both the signature of the content and the global signature covering the
+// trusted comment.
+func verifyMinisign(key, signature, content []byte) error {
+	keyLine, _, err := minisignLines(key, 1)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %w", err)
+	}
+	pub, err := base64.StdEncoding.DecodeString(keyLine[0])
+	if err != nil || len(pub) != 2+minisignKeyIDLen+ed25519.PublicKeySize || string(pub[:2]) != minisignAlgLegacy {
+		return fmt.Errorf("invalid minisign public key")
+	}
+	keyID, publicKey := pub[2:2+minisignKeyIDLen], ed25519.PublicKey(pub[2+minisignKeyIDLen:])
+
+	sigLines, trustedComment, err := minisignLines(signature, 2)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(sigLines[0])
+	if err != nil || len(sig) != 2+minisignKeyIDLen+ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(sigLines[1])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+
+	alg, sigKeyID, sig := string(sig[:2]), sig[2:2+minisignKeyIDLen], sig[2+minisignKeyIDLen:]
+	if !bytes.Equal(keyID, sigKeyID) {
+		return fmt.Errorf("signature key id %X does not match public key id %X", sigKeyID, keyID)
+	}
+
+	message := content
+	switch alg {
+	case minisignAlgLegacy:
+	case minisignAlgPrehashed:
+		sum := blake2b.Sum512(content)
+		message = sum[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", alg)
+	}
+
+	if !ed25519.Verify(publicKey, message, sig) {
+		return fmt.Errorf("invalid minisign signature")
+	}
+	signed := make([]byte, 0, len(sig)+len(trustedComment))
+	signed = append(append(signed, sig...), trustedComment...)
+	if !ed25519.Verify(publicKey, signed, globalSig) {
+		return fmt.Errorf("invalid minisign global signature")
+	}
+
+	return nil
+}
+
+// minisignLines returns the first n base64 lines of a minisign file, skipping
+// comments, along with the content of the trusted comment if there is one.
+func minisignLines(data []byte, n int) ([]string, string, error) {
+	var (
+		lines          []string
+		trustedComment string
+	)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "", strings.HasPrefix(line, "untrusted comment:"):
+		case strings.HasPrefix(line, "trusted comment: "):
+			trustedComment = strings.TrimPrefix(line, "trusted comment: ")
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < n {
+		return nil, "", fmt.Errorf("expected %d lines, found %d", n, len(lines))
+	}
+	return lines[:n], trustedComment, nil
+}
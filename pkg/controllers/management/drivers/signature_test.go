@@ -0,0 +1,181 @@
+package drivers
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestVerifyCosign(t *testing.T) {
+	content := []byte("docker-machine-driver-test")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	pub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	digest := sha256.Sum256(content)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	encoded := []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherDer, err := x509.MarshalPKIXPublicKey(&otherKey.PublicKey)
+	require.NoError(t, err)
+	otherPub := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: otherDer})
+
+	tests := []struct {
+		name      string
+		key       []byte
+		signature []byte
+		content   []byte
+		wantErr   bool
+	}{
+		{
+			name:      "valid base64 signature",
+			key:       pub,
+			signature: encoded,
+			content:   content,
+		},
+		{
+			name:      "valid raw signature",
+			key:       pub,
+			signature: sig,
+			content:   content,
+		},
+		{
+			name:      "tampered content",
+			key:       pub,
+			signature: encoded,
+			content:   []byte("docker-machine-driver-evil"),
+			wantErr:   true,
+		},
+		{
+			name:      "untrusted key",
+			key:       otherPub,
+			signature: encoded,
+			content:   content,
+			wantErr:   true,
+		},
+		{
+			name:      "key not PEM encoded",
+			key:       []byte("not a key"),
+			signature: encoded,
+			content:   content,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyCosign(tt.key, tt.signature, tt.content)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	content := []byte("kontainer-engine-driver-test")
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicKey := minisignPublicKey(keyID, pub)
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		key       []byte
+		signature []byte
+		wantErr   bool
+	}{
+		{
+			name:      "valid prehashed signature",
+			key:       publicKey,
+			signature: minisignSignature(priv, keyID, minisignAlgPrehashed, content, "timestamp:1"),
+		},
+		{
+			name:      "valid legacy signature",
+			key:       publicKey,
+			signature: minisignSignature(priv, keyID, minisignAlgLegacy, content, "timestamp:1"),
+		},
+		{
+			name:      "signature of other content",
+			key:       publicKey,
+			signature: minisignSignature(priv, keyID, minisignAlgPrehashed, []byte("other"), "timestamp:1"),
+			wantErr:   true,
+		},
+		{
+			name:      "mismatched key id",
+			key:       minisignPublicKey([]byte{8, 7, 6, 5, 4, 3, 2, 1}, pub),
+			signature: minisignSignature(priv, keyID, minisignAlgPrehashed, content, "timestamp:1"),
+			wantErr:   true,
+		},
+		{
+			name:      "untrusted key",
+			key:       minisignPublicKey(keyID, otherPub),
+			signature: minisignSignature(priv, keyID, minisignAlgPrehashed, content, "timestamp:1"),
+			wantErr:   true,
+		},
+		{
+			name:      "tampered trusted comment",
+			key:       publicKey,
+			signature: tamperTrustedComment(minisignSignature(priv, keyID, minisignAlgPrehashed, content, "timestamp:1")),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyMinisign(tt.key, tt.signature, content)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func minisignPublicKey(keyID []byte, pub ed25519.PublicKey) []byte {
+	raw := append(append([]byte(minisignAlgLegacy), keyID...), pub...)
+	return []byte(fmt.Sprintf("untrusted comment: minisign public key\n%s\n", base64.StdEncoding.EncodeToString(raw)))
+}
+
+func minisignSignature(priv ed25519.PrivateKey, keyID []byte, alg string, content []byte, trustedComment string) []byte {
+	message := content
+	if alg == minisignAlgPrehashed {
+		sum := blake2b.Sum512(content)
+		message = sum[:]
+	}
+	sig := ed25519.Sign(priv, message)
+	globalSig := ed25519.Sign(priv, append(append([]byte{}, sig...), trustedComment...))
+	raw := append(append([]byte(alg), keyID...), sig...)
+
+	return []byte(fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trustedComment, base64.StdEncoding.EncodeToString(globalSig)))
+}
+
+func tamperTrustedComment(signature []byte) []byte {
+	return bytes.Replace(signature, []byte("trusted comment: timestamp:1"), []byte("trusted comment: timestamp:2"), 1)
+}
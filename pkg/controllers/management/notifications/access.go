@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"strings"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/apply"
+	"github.com/rancher/wrangler/v3/pkg/name"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// authenticatedGroup is the group of all the users authenticated by Rancher.
+const authenticatedGroup = "system:cattle:authenticated"
+
+// accessHandler grants read access to each notification to its readers only: the recipients of the notification of an
+// event, as events can be about other users' tokens or clusters, or all the users for the other notifications, such as
+// banners. The role and binding granting it are owned by the notification, and deleted with it.
+type accessHandler struct {
+	apply apply.Apply
+}
+
+func (a *accessHandler) onChange(_ string, notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+	if notification == nil || notification.DeletionTimestamp != nil {
+		return notification, nil
+	}
+	return notification, a.apply.WithOwner(notification).ApplyObjects(readers(notification)...)
+}
+
+// readers returns the role granting read access to a notification and its binding to the readers of the notification.
+// The binding of a notification of an event without recipients has no subject.
+func readers(notification *v3.RancherUserNotification) []runtime.Object {
+	roleName := name.SafeConcatName(notification.Name, "reader")
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: roleName},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{v3.SchemeGroupVersion.Group},
+			Resources:     []string{v3.RancherUserNotificationResourceName},
+			ResourceNames: []string{notification.Name},
+			Verbs:         []string{"get", "list", "watch"},
+		}},
+	}
+
+	var subjects []rbacv1.Subject
+	if notification.Event == nil {
+		subjects = append(subjects, rbacv1.Subject{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: authenticatedGroup})
+	} else {
+		for _, recipient := range notification.Status.Recipients {
+			// users are targeted by their name, groups by their principal ID, such as github_team://1234
+			kind := rbacv1.UserKind
+			if strings.Contains(recipient, "://") {
+				kind = rbacv1.GroupKind
+			}
+			subjects = append(subjects, rbacv1.Subject{Kind: kind, APIGroup: rbacv1.GroupName, Name: recipient})
+		}
+	}
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: roleName},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: roleName},
+		Subjects:   subjects,
+	}
+
+	return []runtime.Object{role, binding}
+}
@@ -0,0 +1,84 @@
+package notifications
+
+import (
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	fapply "github.com/rancher/wrangler/v3/pkg/apply/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReaders(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification *v3.RancherUserNotification
+		want         []rbacv1.Subject
+	}{
+		{
+			name: "event recipients",
+			notification: &v3.RancherUserNotification{
+				ObjectMeta: metav1.ObjectMeta{Name: "event-tokenexpiring-0123456789abcdef"},
+				Event:      &v3.NotificationEvent{Type: v3.NotificationEventTokenExpiring, UserName: "u-owner"},
+				Status:     v3.RancherUserNotificationStatus{Recipients: []string{"github_team://1", "u-owner"}},
+			},
+			want: []rbacv1.Subject{
+				{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: "github_team://1"},
+				{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "u-owner"},
+			},
+		},
+		{
+			name: "event without recipients",
+			notification: &v3.RancherUserNotification{
+				ObjectMeta: metav1.ObjectMeta{Name: "event-userdisabled-0123456789abcdef"},
+				Event:      &v3.NotificationEvent{Type: v3.NotificationEventUserDisabled, UserName: "u-owner"},
+			},
+		},
+		{
+			name: "banner",
+			notification: &v3.RancherUserNotification{
+				ObjectMeta: metav1.ObjectMeta{Name: "maintenance"},
+				Message:    "Rancher will be upgraded tonight",
+			},
+			want: []rbacv1.Subject{{Kind: rbacv1.GroupKind, APIGroup: rbacv1.GroupName, Name: authenticatedGroup}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objs := readers(tt.notification)
+			require.Len(t, objs, 2)
+
+			role, ok := objs[0].(*rbacv1.ClusterRole)
+			require.True(t, ok)
+			require.Len(t, role.Rules, 1)
+			assert.Equal(t, []string{tt.notification.Name}, role.Rules[0].ResourceNames)
+			assert.Equal(t, []string{v3.RancherUserNotificationResourceName}, role.Rules[0].Resources)
+			assert.Equal(t, []string{"get", "list", "watch"}, role.Rules[0].Verbs)
+
+			binding, ok := objs[1].(*rbacv1.ClusterRoleBinding)
+			require.True(t, ok)
+			assert.Equal(t, role.Name, binding.RoleRef.Name)
+			assert.Equal(t, tt.want, binding.Subjects)
+		})
+	}
+}
+
+func TestAccessOnChange(t *testing.T) {
+	apply := &fapply.FakeApply{}
+	a := &accessHandler{apply: apply}
+
+	notification := &v3.RancherUserNotification{ObjectMeta: metav1.ObjectMeta{Name: "maintenance"}}
+	_, err := a.onChange("", notification)
+	require.NoError(t, err)
+	assert.Equal(t, 1, apply.Count)
+
+	// the role and binding are deleted with the notification
+	now := metav1.Now()
+	notification.DeletionTimestamp = &now
+	_, err = a.onChange("", notification)
+	require.NoError(t, err)
+	assert.Equal(t, 1, apply.Count)
+}
@@ -0,0 +1,288 @@
+// Package notifications delivers the notifications of platform events to the users and groups of the subscriptions
+// matching them through their channels, recording the recipients and deliveries in the status of the notifications.
+// Deliveries are recorded as being sent before sending them, so that failing to record their outcome doesn't send them
+// again. Only the recipients of a notification of an event can read it. It also publishes the events of etcd snapshots
+// failing.
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	provcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/notifications"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/wrangler"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// retention is how long the notification of an event is kept after its last occurrence.
+	retention = 30 * 24 * time.Hour
+	// maxAttempts is the number of attempts to deliver a notification through a channel before giving up, until the
+	// event occurs again.
+	maxAttempts = 5
+	// retryInterval is the interval between two attempts, multiplied by the number of failed attempts.
+	retryInterval = time.Minute
+	// attemptTimeout bounds the time an attempt to deliver a notification through a channel takes. A delivery still
+	// being sent after that is considered failed, as recording its outcome failed.
+	attemptTimeout = time.Minute
+)
+
+type handler struct {
+	ctx           context.Context
+	notifications mgmtcontrollers.RancherUserNotificationController
+	secrets       corecontrollers.SecretCache
+	getConfig     func() string
+	newChannel    func(config notifications.ChannelConfig, secret map[string][]byte) (notifications.Channel, error)
+	now           func() time.Time
+}
+
+func Register(ctx context.Context, wrangler *wrangler.Context) {
+	h := &handler{
+		ctx:           ctx,
+		notifications: wrangler.Mgmt.RancherUserNotification(),
+		secrets:       wrangler.Core.Secret().Cache(),
+		getConfig:     settings.NotificationConfig.Get,
+		newChannel:    notifications.NewChannel,
+		now:           time.Now,
+	}
+	wrangler.Mgmt.RancherUserNotification().OnChange(ctx, "notification-delivery", h.onChange)
+
+	a := &accessHandler{
+		apply: wrangler.Apply.
+			WithSetID("notification-access").
+			WithSetOwnerReference(true, false).
+			WithCacheTypes(wrangler.RBAC.ClusterRole(), wrangler.RBAC.ClusterRoleBinding()),
+	}
+	wrangler.Mgmt.RancherUserNotification().OnChange(ctx, "notification-access", a.onChange)
+
+	s := &snapshotSource{
+		clusters:  wrangler.Provisioning.Cluster().Cache(),
+		publisher: notifications.NewPublisher(wrangler.Mgmt.RancherUserNotification()),
+	}
+	wrangler.RKE.ETCDSnapshot().OnChange(ctx, "notification-etcd-snapshot-failed", s.onChange)
+}
+
+func (h *handler) onChange(_ string, notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+	if notification == nil || notification.DeletionTimestamp != nil || notification.Event == nil {
+		return notification, nil
+	}
+
+	now := h.now()
+	expiry := notification.Event.LastOccurrence.Add(retention)
+	if !now.Before(expiry) {
+		err := h.notifications.Delete(notification.Name, &metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			err = nil
+		}
+		return notification, err
+	}
+	requeue := expiry.Sub(now)
+
+	config, err := notifications.ParseConfig(h.getConfig())
+	if err != nil {
+		// the configuration is retried when the notification changes, there is no point in retrying it before
+		logrus.Errorf("[notifications] Invalid notification configuration: %v", err)
+		return notification, nil
+	}
+
+	var (
+		status v3.RancherUserNotificationStatus
+		due    []int
+	)
+	for i := range config.Subscriptions {
+		sub := &config.Subscriptions[i]
+		if !sub.Matches(notification.Event) {
+			continue
+		}
+		status.Recipients = append(status.Recipients, sub.Recipients(notification.Event)...)
+		for _, channel := range sub.Channels {
+			delivery := v3.NotificationDelivery{Subscription: sub.Name, Channel: channel}
+			if i := slices.IndexFunc(notification.Status.Deliveries, func(d v3.NotificationDelivery) bool {
+				return d.Subscription == sub.Name && d.Channel == channel
+			}); i >= 0 {
+				delivery = *notification.Status.Deliveries[i].DeepCopy()
+			}
+			send, wait := h.due(sub, notification.Event, &delivery, now)
+			if wait > 0 && wait < requeue {
+				requeue = wait
+			}
+			if send {
+				due = append(due, len(status.Deliveries))
+			}
+			status.Deliveries = append(status.Deliveries, delivery)
+		}
+	}
+	slices.Sort(status.Recipients)
+	status.Recipients = slices.Compact(status.Recipients)
+	h.notifications.EnqueueAfter(notification.Name, requeue)
+
+	if equality.Semantic.DeepEqual(notification.Status, status) {
+		return notification, nil
+	}
+	// the deliveries being sent are recorded first, nothing is sent if recording them fails
+	notification = notification.DeepCopy()
+	notification.Status = status
+	if notification, err = h.notifications.Update(notification); err != nil || len(due) == 0 {
+		return notification, err
+	}
+
+	msg := notifications.NewMessage(notification)
+	var outcomes []v3.NotificationDelivery
+	for _, i := range due {
+		delivery := status.Deliveries[i]
+		if wait := h.deliver(config, msg, &delivery); wait > 0 {
+			h.notifications.EnqueueAfter(notification.Name, wait)
+		}
+		outcomes = append(outcomes, delivery)
+	}
+	return h.recordOutcomes(notification, outcomes)
+}
+
+// due returns whether a notification is due to be delivered through a channel: when its event occurred since it was
+// last delivered, unless delivering it again is throttled, or when the previous attempt failed. A due delivery is
+// marked as being sent, otherwise due updates the state of the delivery and returns when it should be checked again.
+func (h *handler) due(sub *notifications.Subscription, event *v3.NotificationEvent, delivery *v3.NotificationDelivery, now time.Time) (bool, time.Duration) {
+	occurred := delivery.LastDeliveryTime == nil || delivery.LastDeliveryTime.Before(&event.LastOccurrence)
+	if !occurred {
+		return false, 0
+	}
+	if delivery.State == v3.NotificationDeliverySending {
+		if timeout := delivery.LastAttemptTime.Add(attemptTimeout); now.Before(timeout) {
+			return false, timeout.Sub(now)
+		}
+		delivery.State = v3.NotificationDeliveryFailed
+		delivery.Attempts++
+		delivery.Message = "the outcome of the delivery was not recorded"
+	}
+	if delivery.State == v3.NotificationDeliveryFailed {
+		if event.LastOccurrence.After(delivery.LastAttemptTime.Time) {
+			delivery.Attempts = 0
+		} else if delivery.Attempts >= maxAttempts {
+			return false, 0
+		} else if retry := delivery.LastAttemptTime.Add(time.Duration(delivery.Attempts) * retryInterval); now.Before(retry) {
+			return false, retry.Sub(now)
+		}
+	}
+	if delivery.LastDeliveryTime != nil {
+		if next := delivery.LastDeliveryTime.Add(sub.ThrottleInterval()); now.Before(next) {
+			delivery.State = v3.NotificationDeliveryThrottled
+			return false, next.Sub(now)
+		}
+	}
+
+	delivery.State = v3.NotificationDeliverySending
+	delivery.LastAttemptTime = metav1.NewTime(now)
+	return true, 0
+}
+
+// deliver sends a notification through the channel of a delivery being sent and records the outcome in the delivery.
+// It returns when the delivery should be attempted again, if it failed.
+func (h *handler) deliver(config *notifications.Config, msg *notifications.Message, delivery *v3.NotificationDelivery) time.Duration {
+	if err := h.send(config, delivery.Channel, msg); err != nil {
+		logrus.Warnf("[notifications] Failed to deliver notification %s through channel %s: %v", msg.Name, delivery.Channel, err)
+		delivery.State = v3.NotificationDeliveryFailed
+		delivery.Attempts++
+		delivery.Message = err.Error()
+		if delivery.Attempts >= maxAttempts {
+			return 0
+		}
+		return time.Duration(delivery.Attempts) * retryInterval
+	}
+	delivery.State = v3.NotificationDeliveryDelivered
+	delivery.Attempts = 0
+	delivery.LastDeliveryTime = &delivery.LastAttemptTime
+	delivery.Message = ""
+	return 0
+}
+
+func (h *handler) send(config *notifications.Config, channelName string, msg *notifications.Message) error {
+	channelConfig, ok := config.Channel(channelName)
+	if !ok {
+		return fmt.Errorf("unknown channel %s", channelName)
+	}
+	var secret map[string][]byte
+	if channelConfig.SecretName != "" {
+		s, err := h.secrets.Get(namespace.GlobalNamespace, channelConfig.SecretName)
+		if err != nil {
+			return fmt.Errorf("getting the secret of channel %s: %w", channelName, err)
+		}
+		secret = s.Data
+	}
+	channel, err := h.newChannel(channelConfig, secret)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(h.ctx, attemptTimeout)
+	defer cancel()
+	return channel.Send(ctx, msg)
+}
+
+// recordOutcomes records the outcomes of deliveries in the status of the notification. Conflicts are retried against
+// the latest notification, as failing to record the outcomes would lead to sending the notification again.
+func (h *handler) recordOutcomes(notification *v3.RancherUserNotification, outcomes []v3.NotificationDelivery) (*v3.RancherUserNotification, error) {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		updated := notification.DeepCopy()
+		for _, outcome := range outcomes {
+			for i, delivery := range updated.Status.Deliveries {
+				if delivery.Subscription == outcome.Subscription && delivery.Channel == outcome.Channel {
+					updated.Status.Deliveries[i] = outcome
+				}
+			}
+		}
+		updated, err := h.notifications.Update(updated)
+		if apierrors.IsConflict(err) {
+			if latest, getErr := h.notifications.Get(notification.Name, metav1.GetOptions{}); getErr == nil {
+				notification = latest
+			}
+			return err
+		}
+		if err == nil {
+			notification = updated
+		}
+		return err
+	})
+	return notification, err
+}
+
+// snapshotSource publishes the events of etcd snapshots failing.
+type snapshotSource struct {
+	clusters  provcontrollers.ClusterCache
+	publisher *notifications.Publisher
+}
+
+func (s *snapshotSource) onChange(_ string, snapshot *rkev1.ETCDSnapshot) (*rkev1.ETCDSnapshot, error) {
+	if snapshot == nil || snapshot.DeletionTimestamp != nil || snapshot.SnapshotFile.Status != "failed" {
+		return snapshot, nil
+	}
+	// subscriptions select the clusters by their management cluster name, like the other events
+	clusterName := snapshot.Spec.ClusterName
+	if cluster, err := s.clusters.Get(snapshot.Namespace, snapshot.Spec.ClusterName); err == nil && cluster.Status.ClusterName != "" {
+		clusterName = cluster.Status.ClusterName
+	}
+	message := fmt.Sprintf("etcd snapshot %s of cluster %s failed", snapshot.SnapshotFile.Name, snapshot.Spec.ClusterName)
+	if snapshot.SnapshotFile.Message != "" {
+		message += ": " + snapshot.SnapshotFile.Message
+	}
+	s.publisher.PublishOnce(notifications.Event{
+		Type:        v3.NotificationEventETCDSnapshotFailed,
+		Severity:    v3.NotificationSeverityError,
+		Key:         snapshot.Namespace + "/" + snapshot.Name,
+		ClusterName: clusterName,
+		Object:      snapshot.Namespace + "/" + snapshot.Name,
+		Message:     message,
+	})
+	return snapshot, nil
+}
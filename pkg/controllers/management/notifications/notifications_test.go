@@ -0,0 +1,259 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/notifications"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const config = `{
+	"channels": [{"name": "ops", "type": "webhook", "secretName": "ops-webhook"}],
+	"subscriptions": [
+		{"name": "prod", "events": ["ClusterDisconnected"], "users": ["u-admin"], "groups": ["github_team://1"],
+		 "channels": ["ops"], "throttle": "10m"},
+		{"name": "all", "users": ["u-admin"], "channels": ["ops"]},
+		{"name": "tokens", "events": ["TokenExpiring"], "users": ["u-other"], "notifyAffectedUser": true, "channels": ["ops"]}
+	]}`
+
+type channelFunc func(msg *notifications.Message) error
+
+func (f channelFunc) Send(_ context.Context, msg *notifications.Message) error {
+	return f(msg)
+}
+
+func TestOnChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationsController := fake.NewMockNonNamespacedControllerInterface[*v3.RancherUserNotification, *v3.RancherUserNotificationList](ctrl)
+	secrets := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+	secrets.EXPECT().Get("cattle-global-data", "ops-webhook").Return(&corev1.Secret{
+		Data: map[string][]byte{"url": []byte("https://hooks.example.com/1")},
+	}, nil).AnyTimes()
+	notificationsController.EXPECT().EnqueueAfter(gomock.Any(), gomock.Any()).AnyTimes()
+	var updated *v3.RancherUserNotification
+	notificationsController.EXPECT().Update(gomock.Any()).DoAndReturn(func(notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+		updated = notification
+		return notification, nil
+	}).AnyTimes()
+
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	var (
+		sent    []*notifications.Message
+		sendErr error
+	)
+	h := &handler{
+		ctx:           context.Background(),
+		notifications: notificationsController,
+		secrets:       secrets,
+		getConfig:     func() string { return config },
+		newChannel: func(config notifications.ChannelConfig, secret map[string][]byte) (notifications.Channel, error) {
+			assert.Equal(t, "https://hooks.example.com/1", string(secret["url"]))
+			return channelFunc(func(msg *notifications.Message) error {
+				// the delivery is recorded as being sent before sending it
+				require.NotNil(t, updated)
+				for _, delivery := range updated.Status.Deliveries {
+					if delivery.State == v3.NotificationDeliverySending {
+						assert.Equal(t, metav1.NewTime(now), delivery.LastAttemptTime)
+					}
+				}
+				assert.True(t, slices.ContainsFunc(updated.Status.Deliveries, func(d v3.NotificationDelivery) bool {
+					return d.State == v3.NotificationDeliverySending
+				}))
+				sent = append(sent, msg)
+				return sendErr
+			}), nil
+		},
+		now: func() time.Time { return now },
+	}
+
+	notification := &v3.RancherUserNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "event-clusterdisconnected-0123456789abcdef"},
+		Message:    "The agent of cluster prod (c-abcde) disconnected",
+		Event: &v3.NotificationEvent{
+			Type:            v3.NotificationEventClusterDisconnected,
+			Severity:        v3.NotificationSeverityError,
+			ClusterName:     "c-abcde",
+			FirstOccurrence: metav1.NewTime(now),
+			LastOccurrence:  metav1.NewTime(now),
+			Count:           1,
+		},
+	}
+
+	// delivered through the channel of each matching subscription
+	notification, err := h.onChange("", notification)
+	require.NoError(t, err)
+	assert.Len(t, sent, 2)
+	// the recipients of the matching subscriptions are recorded and sent, without duplicates
+	assert.Equal(t, []string{"github_team://1", "u-admin"}, notification.Status.Recipients)
+	for _, msg := range sent {
+		assert.Equal(t, []string{"github_team://1", "u-admin"}, msg.Recipients)
+	}
+	require.Len(t, notification.Status.Deliveries, 2)
+	for _, delivery := range notification.Status.Deliveries {
+		assert.Equal(t, v3.NotificationDeliveryDelivered, delivery.State)
+		assert.Equal(t, metav1.NewTime(now), *delivery.LastDeliveryTime)
+	}
+
+	// not delivered again until the event occurs again
+	sent = nil
+	now = now.Add(time.Minute)
+	notification, err = h.onChange("", notification)
+	require.NoError(t, err)
+	assert.Empty(t, sent)
+
+	// a new occurrence is throttled
+	notification.Event.LastOccurrence = metav1.NewTime(now)
+	notification.Event.Count++
+	notification, err = h.onChange("", notification)
+	require.NoError(t, err)
+	assert.Empty(t, sent)
+	assert.Equal(t, v3.NotificationDeliveryThrottled, notification.Status.Deliveries[0].State)
+	assert.Equal(t, v3.NotificationDeliveryThrottled, notification.Status.Deliveries[1].State)
+
+	// the prod subscription throttles for 10 minutes, the all one for the default hour
+	now = now.Add(10 * time.Minute)
+	sendErr = errors.New("connection refused")
+	notification, err = h.onChange("", notification)
+	require.NoError(t, err)
+	assert.Len(t, sent, 1)
+	prod := notification.Status.Deliveries[0]
+	assert.Equal(t, "prod", prod.Subscription)
+	assert.Equal(t, v3.NotificationDeliveryFailed, prod.State)
+	assert.Equal(t, 1, prod.Attempts)
+	assert.Equal(t, "connection refused", prod.Message)
+	assert.Equal(t, v3.NotificationDeliveryThrottled, notification.Status.Deliveries[1].State)
+
+	// failed deliveries are retried with a backoff
+	sent = nil
+	sendErr = nil
+	notification, err = h.onChange("", notification)
+	require.NoError(t, err)
+	assert.Empty(t, sent)
+
+	now = now.Add(time.Minute)
+	notification, err = h.onChange("", notification)
+	require.NoError(t, err)
+	assert.Len(t, sent, 1)
+	prod = notification.Status.Deliveries[0]
+	assert.Equal(t, v3.NotificationDeliveryDelivered, prod.State)
+	assert.Zero(t, prod.Attempts)
+	assert.Empty(t, prod.Message)
+}
+
+func TestOnChangeExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationsController := fake.NewMockNonNamespacedControllerInterface[*v3.RancherUserNotification, *v3.RancherUserNotificationList](ctrl)
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	h := &handler{notifications: notificationsController, now: func() time.Time { return now }}
+
+	notificationsController.EXPECT().Delete("event-old", &metav1.DeleteOptions{}).Return(nil)
+	_, err := h.onChange("", &v3.RancherUserNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "event-old"},
+		Event:      &v3.NotificationEvent{LastOccurrence: metav1.NewTime(now.Add(-retention))},
+	})
+	require.NoError(t, err)
+
+	// notifications without an event are only displayed
+	_, err = h.onChange("", &v3.RancherUserNotification{ObjectMeta: metav1.ObjectMeta{Name: "banner"}})
+	require.NoError(t, err)
+}
+
+func TestOnChangeUpdateFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	notificationsController := fake.NewMockNonNamespacedControllerInterface[*v3.RancherUserNotification, *v3.RancherUserNotificationList](ctrl)
+	secrets := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+	secrets.EXPECT().Get("cattle-global-data", "ops-webhook").Return(&corev1.Secret{}, nil).AnyTimes()
+	notificationsController.EXPECT().EnqueueAfter(gomock.Any(), gomock.Any()).AnyTimes()
+
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	var sent int
+	h := &handler{
+		ctx:           context.Background(),
+		notifications: notificationsController,
+		secrets:       secrets,
+		getConfig:     func() string { return config },
+		newChannel: func(config notifications.ChannelConfig, secret map[string][]byte) (notifications.Channel, error) {
+			return channelFunc(func(msg *notifications.Message) error {
+				sent++
+				return nil
+			}), nil
+		},
+		now: func() time.Time { return now },
+	}
+	notification := &v3.RancherUserNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "event-clusterdisconnected-0123456789abcdef"},
+		Event: &v3.NotificationEvent{
+			Type:           v3.NotificationEventClusterDisconnected,
+			LastOccurrence: metav1.NewTime(now),
+		},
+	}
+	conflict := apierrors.NewConflict(schema.GroupResource{Group: "management.cattle.io", Resource: "rancherusernotifications"}, notification.Name, errors.New("the object has been modified"))
+
+	// nothing is sent when recording the deliveries being sent fails
+	notificationsController.EXPECT().Update(gomock.Any()).Return(nil, conflict)
+	_, err := h.onChange("", notification)
+	require.Error(t, err)
+	assert.Zero(t, sent)
+
+	// the outcomes are recorded against the latest notification when recording them conflicts
+	var sending *v3.RancherUserNotification
+	gomock.InOrder(
+		notificationsController.EXPECT().Update(gomock.Any()).DoAndReturn(func(notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+			sending = notification
+			return notification, nil
+		}),
+		notificationsController.EXPECT().Update(gomock.Any()).Return(nil, conflict),
+		notificationsController.EXPECT().Get(notification.Name, metav1.GetOptions{}).DoAndReturn(func(string, metav1.GetOptions) (*v3.RancherUserNotification, error) {
+			latest := sending.DeepCopy()
+			latest.Message = "updated"
+			return latest, nil
+		}),
+		notificationsController.EXPECT().Update(gomock.Any()).DoAndReturn(func(notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+			return notification, nil
+		}),
+	)
+	notification, err = h.onChange("", notification)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, "updated", notification.Message)
+	for _, delivery := range notification.Status.Deliveries {
+		assert.Equal(t, v3.NotificationDeliveryDelivered, delivery.State)
+	}
+
+	// a delivery whose outcome wasn't recorded is retried as a failed attempt once it timed out
+	sent = 0
+	sending.Status.Deliveries[0].LastAttemptTime = metav1.NewTime(now)
+	_, err = h.onChange("", sending)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+
+	now = now.Add(attemptTimeout)
+	var retried *v3.RancherUserNotification
+	gomock.InOrder(
+		notificationsController.EXPECT().Update(gomock.Any()).DoAndReturn(func(notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+			retried = notification.DeepCopy()
+			return notification, nil
+		}),
+		notificationsController.EXPECT().Update(gomock.Any()).DoAndReturn(func(notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+			return notification, nil
+		}),
+	)
+	_, err = h.onChange("", sending)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Equal(t, v3.NotificationDeliverySending, retried.Status.Deliveries[0].State)
+	assert.Equal(t, 1, retried.Status.Deliveries[0].Attempts)
+	assert.Equal(t, "the outcome of the delivery was not recorded", retried.Status.Deliveries[0].Message)
+}
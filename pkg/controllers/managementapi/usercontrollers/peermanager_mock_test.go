@@ -78,6 +78,20 @@ func (mr *MockPeerManagerMockRecorder) Leader() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Leader", reflect.TypeOf((*MockPeerManager)(nil).Leader))
 }
 
+// Peers mocks base method.
+func (m *MockPeerManager) Peers() peermanager.Peers {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Peers")
+	ret0, _ := ret[0].(peermanager.Peers)
+	return ret0
+}
+
+// Peers indicates an expected call of Peers.
+func (mr *MockPeerManagerMockRecorder) Peers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Peers", reflect.TypeOf((*MockPeerManager)(nil).Peers))
+}
+
 // RemoveListener mocks base method.
 func (m *MockPeerManager) RemoveListener(l chan<- peermanager.Peers) {
 	m.ctrl.T.Helper()
@@ -19,6 +19,8 @@
 	"github.com/rancher/rancher/pkg/controllers/managementuser/rkecontrolplanecondition"
 	"github.com/rancher/rancher/pkg/controllers/managementuser/secret"
 	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotbackpopulate"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotreplication"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotverification"
 	"github.com/rancher/rancher/pkg/controllers/managementuser/windows"
 	"github.com/rancher/rancher/pkg/controllers/managementuserlegacy"
 	"github.com/rancher/rancher/pkg/features"
@@ -99,6 +101,8 @@ func registerProvV2(ctx context.Context, cluster *config.UserContext, capi *wran
 			cluster.K3s = k3s.New(cluster.ControllerFactory)
 			snapshotbackpopulate.Register(ctx, cluster, capi)
 		}
+		snapshotverification.Register(ctx, cluster)
+		snapshotreplication.Register(ctx, cluster)
 		cluster.Plan = upgrade.New(cluster.ControllerFactory)
 		rkecontrolplanecondition.Register(ctx,
 			cluster.ClusterName,
@@ -0,0 +1,315 @@
+package healthsyncer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/norman/condition"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// tunnelLatencyThreshold is the round trip latency above which the cluster agent tunnel is reported unhealthy.
+	tunnelLatencyThreshold = 2 * time.Second
+	// etcdMetricsPort is the port etcd serves its metrics on, when they are exposed beyond the loopback interface.
+	etcdMetricsPort = "2381"
+	// leaderChangesWindow and maxLeaderChanges bound the number of etcd leader elections of a stable etcd cluster.
+	leaderChangesWindow = 10 * time.Minute
+	maxLeaderChanges    = 3
+)
+
+// cniDaemonSets are the names of the daemon sets of the container network plugins Rancher knows about.
+var cniDaemonSets = []string{
+	"antrea-agent",
+	"aws-node",
+	"calico-node",
+	"canal",
+	"cilium",
+	"kube-flannel-ds",
+	"rke2-canal",
+	"weave-net",
+}
+
+// healthConditions are the conditions set from the health checks, in the order they are checked.
+var healthConditions = []condition.Cond{
+	v32.ClusterConditionAPIServerHealthy,
+	v32.ClusterConditionEtcdHealthy,
+	v32.ClusterConditionNodesHealthy,
+	v32.ClusterConditionDNSReady,
+	v32.ClusterConditionCNIReady,
+	v32.ClusterConditionAgentTunnelHealthy,
+}
+
+// checkResult is the outcome of a health check, which sets a health condition of the cluster.
+type checkResult struct {
+	cond    condition.Cond
+	status  v1.ConditionStatus
+	reason  string
+	message string
+	// critical results make the cluster not ready
+	critical bool
+}
+
+func healthy(cond condition.Cond) checkResult {
+	return checkResult{cond: cond, status: v1.ConditionTrue}
+}
+
+func unhealthy(cond condition.Cond, critical bool, reason, format string, args ...any) checkResult {
+	return checkResult{cond: cond, status: v1.ConditionFalse, reason: reason, message: fmt.Sprintf(format, args...), critical: critical}
+}
+
+func unknown(cond condition.Cond, reason, format string, args ...any) checkResult {
+	return checkResult{cond: cond, status: v1.ConditionUnknown, reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// probeResult holds the individual checks of a livez or readyz endpoint of the API server.
+type probeResult struct {
+	checks map[string]bool
+	failed []string
+}
+
+// probe queries the verbose livez or readyz endpoint of the API server. Failed probes answer with an error status and
+// the verbose list of checks, which is parsed all the same.
+func (h *HealthSyncer) probe(ctx context.Context, path string) (probeResult, error) {
+	body, err := h.get(ctx, path)
+	result := parseProbe(body)
+	if len(result.checks) == 0 {
+		if err == nil {
+			err = fmt.Errorf("no checks reported by %s", path)
+		}
+		return result, err
+	}
+	return result, nil
+}
+
+// parseProbe parses the verbose output of the livez and readyz endpoints, which has one line per check such as
+// "[+]ping ok" or "[-]etcd failed: reason withheld".
+func parseProbe(body []byte) probeResult {
+	result := probeResult{checks: map[string]bool{}}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		var ok bool
+		switch {
+		case strings.HasPrefix(line, "[+]"):
+			ok = true
+		case strings.HasPrefix(line, "[-]"):
+		default:
+			continue
+		}
+		name, _, _ := strings.Cut(line[3:], " ")
+		result.checks[name] = ok
+		if !ok {
+			result.failed = append(result.failed, name)
+		}
+	}
+	return result
+}
+
+// checkAPIServer checks the livez and readyz endpoints of the API server, and measures the round trip latency through
+// the cluster agent tunnel with the livez request. The readyz checks are returned to be reported as component statuses.
+func (h *HealthSyncer) checkAPIServer(ctx context.Context) (api, tunnel checkResult, readyz probeResult) {
+	start := time.Now()
+	livez, err := h.probe(ctx, "/livez")
+	latency := time.Since(start)
+	if err != nil {
+		return unhealthy(v32.ClusterConditionAPIServerHealthy, true, "Unreachable", "Failed to communicate with API server: %v", err),
+			unhealthy(v32.ClusterConditionAgentTunnelHealthy, false, "Unreachable", "Failed to communicate with API server through the cluster agent tunnel"),
+			readyz
+	}
+
+	tunnel = healthy(v32.ClusterConditionAgentTunnelHealthy)
+	if latency > tunnelLatencyThreshold {
+		tunnel = unhealthy(v32.ClusterConditionAgentTunnelHealthy, false, "HighLatency", "Round trip latency through the cluster agent tunnel exceeds %s", tunnelLatencyThreshold)
+	}
+
+	if len(livez.failed) > 0 {
+		return unhealthy(v32.ClusterConditionAPIServerHealthy, true, "LivezCheckFailed", "API server livez checks failed: %s", strings.Join(livez.failed, ", ")), tunnel, readyz
+	}
+	readyz, err = h.probe(ctx, "/readyz")
+	if err != nil {
+		return unhealthy(v32.ClusterConditionAPIServerHealthy, true, "Unreachable", "Failed to communicate with API server: %v", err), tunnel, readyz
+	}
+	if len(readyz.failed) > 0 {
+		return unhealthy(v32.ClusterConditionAPIServerHealthy, true, "ReadyzCheckFailed", "API server readyz checks failed: %s", strings.Join(readyz.failed, ", ")), tunnel, readyz
+	}
+	return healthy(v32.ClusterConditionAPIServerHealthy), tunnel, readyz
+}
+
+// checkEtcd checks the etcd readyz checks of the API server, the readiness of the etcd member pods, and the leader of
+// etcd when its metrics are exposed. Hosted clusters, whose etcd is managed by their provider, only have the readyz
+// checks.
+func (h *HealthSyncer) checkEtcd(ctx context.Context, readyz probeResult, now time.Time) checkResult {
+	for _, name := range readyz.failed {
+		if strings.HasPrefix(name, "etcd") {
+			return unhealthy(v32.ClusterConditionEtcdHealthy, true, "EtcdCheckFailed", "API server etcd check %s failed", name)
+		}
+	}
+
+	pods, err := h.k8s.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{LabelSelector: "component=etcd", ResourceVersion: "0"})
+	if err != nil {
+		return unknown(v32.ClusterConditionEtcdHealthy, "ListFailed", "Failed to list etcd pods: %v", err)
+	}
+	var notReady []string
+	for i := range pods.Items {
+		if !podReady(&pods.Items[i]) {
+			notReady = append(notReady, pods.Items[i].Spec.NodeName)
+		}
+	}
+	if len(notReady) > 0 {
+		slices.Sort(notReady)
+		return unhealthy(v32.ClusterConditionEtcdHealthy, len(notReady) > len(pods.Items)/2, "MemberNotReady", "etcd members not ready on nodes: %s", strings.Join(notReady, ", "))
+	}
+
+	for i := range pods.Items {
+		metrics, err := h.k8s.CoreV1().Pods("kube-system").ProxyGet("http", pods.Items[i].Name, etcdMetricsPort, "metrics", nil).DoRaw(ctx)
+		if err != nil {
+			// etcd metrics are only served on the loopback interface by default
+			continue
+		}
+		if hasLeader, ok := metricValue(metrics, "etcd_server_has_leader"); ok && hasLeader == 0 {
+			return unhealthy(v32.ClusterConditionEtcdHealthy, true, "NoLeader", "etcd member on node %s has no leader", pods.Items[i].Spec.NodeName)
+		}
+		if changes, ok := metricValue(metrics, "etcd_server_leader_changes_seen_total"); ok {
+			if n := h.leaderChanges.observe(pods.Items[i].Name, changes, now); n > maxLeaderChanges {
+				return unhealthy(v32.ClusterConditionEtcdHealthy, false, "LeaderUnstable", "etcd leader changed %d times in the last %s", n, leaderChangesWindow)
+			}
+		}
+	}
+	return healthy(v32.ClusterConditionEtcdHealthy)
+}
+
+// checkNodes checks the pressure conditions of the nodes of the cluster.
+func (h *HealthSyncer) checkNodes(ctx context.Context) checkResult {
+	nodes, err := h.k8s.CoreV1().Nodes().List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		return unknown(v32.ClusterConditionNodesHealthy, "ListFailed", "Failed to list nodes: %v", err)
+	}
+	pressure := map[v1.NodeConditionType][]string{}
+	for _, node := range nodes.Items {
+		for _, cond := range node.Status.Conditions {
+			switch cond.Type {
+			case v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure:
+				if cond.Status == v1.ConditionTrue {
+					pressure[cond.Type] = append(pressure[cond.Type], node.Name)
+				}
+			}
+		}
+	}
+	for _, condType := range []v1.NodeConditionType{v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure} {
+		if names := pressure[condType]; len(names) > 0 {
+			slices.Sort(names)
+			return unhealthy(v32.ClusterConditionNodesHealthy, false, string(condType), "%s on nodes: %s", condType, strings.Join(names, ", "))
+		}
+	}
+	return healthy(v32.ClusterConditionNodesHealthy)
+}
+
+// checkDNS checks the readiness of the cluster DNS pods. The cluster isn't ready when none of them is.
+func (h *HealthSyncer) checkDNS(ctx context.Context) checkResult {
+	pods, err := h.k8s.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{LabelSelector: "k8s-app=kube-dns", ResourceVersion: "0"})
+	if err != nil {
+		return unknown(v32.ClusterConditionDNSReady, "ListFailed", "Failed to list DNS pods: %v", err)
+	}
+	if len(pods.Items) == 0 {
+		return unknown(v32.ClusterConditionDNSReady, "NotDetected", "No cluster DNS pods found")
+	}
+	ready := 0
+	for i := range pods.Items {
+		if podReady(&pods.Items[i]) {
+			ready++
+		}
+	}
+	if ready == 0 {
+		return unhealthy(v32.ClusterConditionDNSReady, true, "NoReadyPods", "None of the %d cluster DNS pods is ready", len(pods.Items))
+	}
+	if ready < len(pods.Items) {
+		return unhealthy(v32.ClusterConditionDNSReady, false, "PodsNotReady", "%d of the %d cluster DNS pods are ready", ready, len(pods.Items))
+	}
+	return healthy(v32.ClusterConditionDNSReady)
+}
+
+// checkCNI checks the readiness of the daemon sets of the container network plugin. The cluster isn't ready when none
+// of their pods is. Plugins embedded in the Kubernetes distribution, such as the flannel of K3s, aren't detected.
+func (h *HealthSyncer) checkCNI(ctx context.Context) checkResult {
+	daemonSets, err := h.k8s.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	if err != nil {
+		return unknown(v32.ClusterConditionCNIReady, "ListFailed", "Failed to list daemon sets: %v", err)
+	}
+	found := false
+	for _, ds := range daemonSets.Items {
+		if !slices.Contains(cniDaemonSets, ds.Name) {
+			continue
+		}
+		found = true
+		desired, ready := ds.Status.DesiredNumberScheduled, ds.Status.NumberReady
+		if desired > 0 && ready == 0 {
+			return unhealthy(v32.ClusterConditionCNIReady, true, "NoReadyPods", "None of the pods of daemon set %s/%s is ready", ds.Namespace, ds.Name)
+		}
+		if ready < desired {
+			return unhealthy(v32.ClusterConditionCNIReady, false, "PodsNotReady", "%d of the %d pods of daemon set %s/%s are ready", ready, desired, ds.Namespace, ds.Name)
+		}
+	}
+	if !found {
+		return unknown(v32.ClusterConditionCNIReady, "NotDetected", "No known container network plugin found")
+	}
+	return healthy(v32.ClusterConditionCNIReady)
+}
+
+func podReady(pod *v1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// metricValue returns the value of an unlabelled metric from metrics in the Prometheus text format.
+func metricValue(metrics []byte, name string) (float64, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(metrics))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != name {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		return value, err == nil
+	}
+	return 0, false
+}
+
+// leaderChanges tracks the etcd leader changes seen by each etcd member over the last leaderChangesWindow.
+type leaderChanges struct {
+	last    map[string]float64
+	changes map[string][]time.Time
+}
+
+// observe records the total leader changes seen by a member and returns the number of changes within the window.
+func (l *leaderChanges) observe(member string, total float64, now time.Time) int {
+	if l.last == nil {
+		l.last, l.changes = map[string]float64{}, map[string][]time.Time{}
+	}
+	previous, seen := l.last[member]
+	l.last[member] = total
+	if seen && total > previous {
+		for i := 0; i < int(total-previous); i++ {
+			l.changes[member] = append(l.changes[member], now)
+		}
+	}
+
+	changes := l.changes[member]
+	for len(changes) > 0 && now.Sub(changes[0]) > leaderChangesWindow {
+		changes = changes[1:]
+	}
+	l.changes[member] = changes
+	return len(changes)
+}
@@ -2,21 +2,17 @@
 
 import (
 	"context"
-	"fmt"
 	"reflect"
-	"regexp"
+	"slices"
 	"sort"
 	"time"
 
-	"github.com/blang/semver"
 	"github.com/pkg/errors"
 	"github.com/rancher/norman/condition"
-	"github.com/rancher/norman/types/slice"
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/controllers/management/clusterconnected"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/types/config"
-	"github.com/rancher/wrangler/v3/pkg/generic"
 	"github.com/rancher/wrangler/v3/pkg/ticker"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
@@ -29,41 +25,38 @@
 
 const (
 	syncInterval = 15 * time.Second
+	// healthHistoryLimit is the number of health condition transitions kept in the status of the cluster.
+	healthHistoryLimit = 50
 )
 
-// kubernetes/apimachinery/pkg/util/version/version.go
-var versionMatchRE = regexp.MustCompile(`^\s*v?([0-9]+(?:\.[0-9]+)*)(.*)*$`)
-
-var excludedComponentMap = map[string][]string{
-	"aks":     {"controller-manager", "scheduler"},
-	"tencent": {"controller-manager", "scheduler"},
-	"lke":     {"controller-manager", "scheduler"},
-}
-
-// ComponentStatus is disabled with k8s v1.22
-var componentStatusDisabledRange, _ = semver.ParseRange(">=1.22.0")
-
 type ClusterControllerLifecycle interface {
 	Stop(cluster *v3.Cluster)
 }
 
 type HealthSyncer struct {
-	ctx               context.Context
-	clusterName       string
-	clusterLister     v3.ClusterLister
-	clusters          v3.ClusterInterface
-	componentStatuses generic.NonNamespacedClientInterface[*v1.ComponentStatus, *v1.ComponentStatusList]
-	k8s               kubernetes.Interface
+	ctx           context.Context
+	clusterName   string
+	clusterLister v3.ClusterLister
+	clusters      v3.ClusterInterface
+	k8s           kubernetes.Interface
+	// get requests the verbose output of a health endpoint of the API server of the cluster, returning the response
+	// body even if the request fails.
+	get           func(ctx context.Context, path string) ([]byte, error)
+	leaderChanges leaderChanges
+	now           func() time.Time
 }
 
 func Register(ctx context.Context, workload *config.UserContext) {
 	h := &HealthSyncer{
-		ctx:               ctx,
-		clusterName:       workload.ClusterName,
-		clusterLister:     workload.Management.Management.Clusters("").Controller().Lister(),
-		clusters:          workload.Management.Management.Clusters(""),
-		componentStatuses: workload.Corew.ComponentStatus(),
-		k8s:               workload.K8sClient,
+		ctx:           ctx,
+		clusterName:   workload.ClusterName,
+		clusterLister: workload.Management.Management.Clusters("").Controller().Lister(),
+		clusters:      workload.Management.Management.Clusters(""),
+		k8s:           workload.K8sClient,
+		get: func(ctx context.Context, path string) ([]byte, error) {
+			return workload.K8sClient.Discovery().RESTClient().Get().AbsPath(path).Param("verbose", "").DoRaw(ctx)
+		},
+		now: time.Now,
 	}
 
 	go h.syncHealth(ctx, syncInterval)
@@ -78,53 +71,43 @@ func (h *HealthSyncer) syncHealth(ctx context.Context, syncHealth time.Duration)
 	}
 }
 
-func (h *HealthSyncer) getComponentStatus(cluster *v3.Cluster) error {
-	// Prior to k8s v1.14, we only needed to list the ComponentStatuses from the user cluster.
-	// As of k8s v1.14, kubeapi returns a successful ComponentStatuses response even if etcd is not available.
-	// To work around this, now we try to get a namespace from the API, even if not found, it means the API is up.
-	if err := IsAPIUp(h.ctx, h.k8s.CoreV1().Namespaces()); err != nil {
-		return condition.Error("ComponentStatusFetchingFailure", errors.Wrap(err, "Failed to communicate with API server during namespace check"))
-	}
+// getHealth runs the health checks of the cluster, sets its health conditions, and reports the readyz checks of the
+// API server as its component statuses. It returns an error for the first failed check that makes the cluster not
+// ready.
+func (h *HealthSyncer) getHealth(cluster *v3.Cluster) error {
+	ctx, cancel := context.WithTimeout(h.ctx, 10*time.Second)
+	defer cancel()
 
-	cluster.Status.ComponentStatuses = []v32.ClusterComponentStatus{}
-	if cluster.Status.Version == nil {
-		return nil
-	}
-	parts := versionMatchRE.FindStringSubmatch(cluster.Status.Version.String())
-	if len(parts) < 2 {
-		return condition.Error("ComponentStatusFetchingFailure", fmt.Errorf("Failed to parse cluster status version %s",
-			cluster.Status.Version.String()))
-	}
-	k8sVersion, err := semver.Parse(parts[1])
-	if err != nil {
-		return condition.Error("ComponentStatusFetchingFailure", fmt.Errorf("Failed to parse cluster k8s version %s",
-			cluster.Status.Version.String()))
+	api, tunnel, readyz := h.checkAPIServer(ctx)
+	results := []checkResult{api}
+	// the other checks need the API server to answer, even if some of its readyz checks fail
+	if len(readyz.checks) > 0 {
+		results = append(results, h.checkEtcd(ctx, readyz, h.now()), h.checkNodes(ctx), h.checkDNS(ctx), h.checkCNI(ctx))
+	} else {
+		for _, cond := range healthConditions[1 : len(healthConditions)-1] {
+			results = append(results, unknown(cond, "APIServerUnreachable", "API server is unreachable"))
+		}
 	}
+	results = append(results, tunnel)
 
-	if componentStatusDisabledRange(k8sVersion) {
-		return nil
-	}
-	cses, err := h.componentStatuses.List(metav1.ListOptions{})
-	if err != nil {
-		return condition.Error("ComponentStatusFetchingFailure", errors.Wrap(err, "Failed to communicate with API server"))
-	}
-	clusterType := cluster.Status.Provider // the provider detector is more accurate but we can fall back on the driver in some cases
-	if clusterType == "" {
-		clusterType = cluster.Status.Driver
-	}
-	excludedComponents, ok := excludedComponentMap[clusterType]
-	for _, cs := range cses.Items {
-		if ok && slice.ContainsString(excludedComponents, cs.Name) {
-			continue
+	var err error
+	for _, result := range results {
+		result.cond.SetStatus(cluster, string(result.status))
+		result.cond.Reason(cluster, result.reason)
+		result.cond.Message(cluster, result.message)
+		if err == nil && result.critical {
+			err = condition.Error(result.reason, errors.New(result.message))
 		}
-		clusterCS := convertToClusterComponentStatus(&cs)
-		cluster.Status.ComponentStatuses = append(cluster.Status.ComponentStatuses, *clusterCS)
+	}
+
+	cluster.Status.ComponentStatuses = []v32.ClusterComponentStatus{}
+	for name, ok := range readyz.checks {
+		cluster.Status.ComponentStatuses = append(cluster.Status.ComponentStatuses, convertToClusterComponentStatus(name, ok))
 	}
 	sort.Slice(cluster.Status.ComponentStatuses, func(i, j int) bool {
 		return cluster.Status.ComponentStatuses[i].Name < cluster.Status.ComponentStatuses[j].Name
 	})
-
-	return nil
+	return err
 }
 
 // IsAPIUp checks if the Kubernetes API server is up and etcd is available.
@@ -159,7 +142,7 @@ func (h *HealthSyncer) updateClusterHealth() error {
 
 	newObj, err := v32.ClusterConditionReady.Do(cluster, func() (runtime.Object, error) {
 		for i := 0; ; i++ {
-			err := h.getComponentStatus(cluster)
+			err := h.getHealth(cluster)
 			if err == nil || i > 1 {
 				return cluster, errors.Wrap(err, "cluster health check failed")
 			}
@@ -175,6 +158,7 @@ func (h *HealthSyncer) updateClusterHealth() error {
 		v32.ClusterConditionWaiting.True(newObj)
 		v32.ClusterConditionWaiting.Message(newObj, "")
 	}
+	recordTransitions(oldCluster, newObj.(*v3.Cluster), h.now())
 
 	if !reflect.DeepEqual(oldCluster, newObj) {
 		logrus.Tracef("[healthSyncer] update cluster %s", cluster.Name)
@@ -192,9 +176,34 @@ func (h *HealthSyncer) getCluster() (*v3.Cluster, error) {
 	return h.clusterLister.Get("", h.clusterName)
 }
 
-func convertToClusterComponentStatus(cs *v1.ComponentStatus) *v32.ClusterComponentStatus {
-	return &v32.ClusterComponentStatus{
-		Name:       cs.Name,
-		Conditions: cs.Conditions,
+func convertToClusterComponentStatus(name string, ok bool) v32.ClusterComponentStatus {
+	cond := v1.ComponentCondition{Type: v1.ComponentHealthy, Status: v1.ConditionTrue, Message: "ok"}
+	if !ok {
+		cond.Status, cond.Message = v1.ConditionFalse, "check failed"
+	}
+	return v32.ClusterComponentStatus{
+		Name:       name,
+		Conditions: []v1.ComponentCondition{cond},
+	}
+}
+
+// recordTransitions appends the transitions of the ready and health conditions of the cluster to its health history,
+// dropping the oldest ones beyond the limit.
+func recordTransitions(oldCluster, cluster *v3.Cluster, now time.Time) {
+	for _, cond := range append([]condition.Cond{v32.ClusterConditionReady}, healthConditions...) {
+		status := cond.GetStatus(cluster)
+		if status == "" || status == cond.GetStatus(oldCluster) {
+			continue
+		}
+		cluster.Status.HealthHistory = append(cluster.Status.HealthHistory, v32.ClusterHealthTransition{
+			Type:    v32.ClusterConditionType(cond),
+			Status:  v1.ConditionStatus(status),
+			Time:    now.UTC().Format(time.RFC3339),
+			Reason:  cond.GetReason(cluster),
+			Message: cond.GetMessage(cluster),
+		})
+	}
+	if extra := len(cluster.Status.HealthHistory) - healthHistoryLimit; extra > 0 {
+		cluster.Status.HealthHistory = slices.Clone(cluster.Status.HealthHistory[extra:])
 	}
 }
@@ -3,13 +3,22 @@
 import (
 	"context"
 	"net"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/rancher/norman/condition"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/watch"
 	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
 	typedv1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
@@ -116,3 +125,167 @@ func (m mockNamespaces) ApplyStatus(ctx context.Context, namespace *applycorev1.
 func (m mockNamespaces) Finalize(ctx context.Context, item *v1.Namespace, opts metav1.UpdateOptions) (*v1.Namespace, error) {
 	panic("implement me")
 }
+
+func TestParseProbe(t *testing.T) {
+	result := parseProbe([]byte("[+]ping ok\n[+]log ok\n[-]etcd failed: reason withheld\n[+]poststarthook/start-informers ok\nreadyz check failed\n"))
+
+	assert.Equal(t, map[string]bool{"ping": true, "log": true, "etcd": false, "poststarthook/start-informers": true}, result.checks)
+	assert.Equal(t, []string{"etcd"}, result.failed)
+	assert.Empty(t, parseProbe([]byte("connection refused")).checks)
+}
+
+func newPod(name, label string, ready bool) *v1.Pod {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	key, value, _ := strings.Cut(label, "=")
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: name, Labels: map[string]string{key: value}},
+		Spec:       v1.PodSpec{NodeName: name},
+		Status:     v1.PodStatus{Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}}},
+	}
+}
+
+func TestGetHealth(t *testing.T) {
+	const readyz = "[+]ping ok\n[+]etcd ok\nreadyz check passed\n"
+	tests := []struct {
+		name      string
+		readyz    string
+		objects   []k8sruntime.Object
+		wantErr   string
+		wantConds map[condition.Cond]v1.ConditionStatus
+	}{
+		{
+			name:   "healthy",
+			readyz: readyz,
+			objects: []k8sruntime.Object{
+				newPod("coredns-1", "k8s-app=kube-dns", true),
+				&appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "rke2-canal"}, Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, NumberReady: 1}},
+			},
+			wantConds: map[condition.Cond]v1.ConditionStatus{
+				v32.ClusterConditionAPIServerHealthy:   v1.ConditionTrue,
+				v32.ClusterConditionEtcdHealthy:        v1.ConditionTrue,
+				v32.ClusterConditionNodesHealthy:       v1.ConditionTrue,
+				v32.ClusterConditionDNSReady:           v1.ConditionTrue,
+				v32.ClusterConditionCNIReady:           v1.ConditionTrue,
+				v32.ClusterConditionAgentTunnelHealthy: v1.ConditionTrue,
+			},
+		},
+		{
+			name:    "dns down",
+			readyz:  readyz,
+			objects: []k8sruntime.Object{newPod("coredns-1", "k8s-app=kube-dns", false), newPod("coredns-2", "k8s-app=kube-dns", false)},
+			wantErr: "None of the 2 cluster DNS pods is ready",
+			wantConds: map[condition.Cond]v1.ConditionStatus{
+				v32.ClusterConditionDNSReady: v1.ConditionFalse,
+				v32.ClusterConditionCNIReady: v1.ConditionUnknown,
+			},
+		},
+		{
+			name:    "etcd readyz check failed",
+			readyz:  "[+]ping ok\n[-]etcd failed: reason withheld\nreadyz check failed\n",
+			wantErr: "API server readyz checks failed: etcd",
+			wantConds: map[condition.Cond]v1.ConditionStatus{
+				v32.ClusterConditionAPIServerHealthy: v1.ConditionFalse,
+				v32.ClusterConditionEtcdHealthy:      v1.ConditionFalse,
+			},
+		},
+		{
+			name:    "etcd member not ready",
+			readyz:  readyz,
+			objects: []k8sruntime.Object{newPod("etcd-a", "component=etcd", true), newPod("etcd-b", "component=etcd", false), newPod("etcd-c", "component=etcd", true)},
+			wantConds: map[condition.Cond]v1.ConditionStatus{
+				v32.ClusterConditionEtcdHealthy: v1.ConditionFalse,
+			},
+		},
+		{
+			name: "node pressure",
+			objects: []k8sruntime.Object{&v1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "worker"},
+				Status:     v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeDiskPressure, Status: v1.ConditionTrue}}},
+			}},
+			readyz: readyz,
+			wantConds: map[condition.Cond]v1.ConditionStatus{
+				v32.ClusterConditionNodesHealthy: v1.ConditionFalse,
+			},
+		},
+		{
+			name:    "api server down",
+			wantErr: "Failed to communicate with API server",
+			wantConds: map[condition.Cond]v1.ConditionStatus{
+				v32.ClusterConditionAPIServerHealthy:   v1.ConditionFalse,
+				v32.ClusterConditionDNSReady:           v1.ConditionUnknown,
+				v32.ClusterConditionAgentTunnelHealthy: v1.ConditionFalse,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &HealthSyncer{
+				ctx: context.Background(),
+				k8s: fake.NewSimpleClientset(tt.objects...),
+				get: func(_ context.Context, path string) ([]byte, error) {
+					if tt.readyz == "" {
+						return nil, net.UnknownNetworkError("unknown network error")
+					}
+					if path == "/livez" {
+						return []byte("[+]ping ok\nlivez check passed\n"), nil
+					}
+					return []byte(tt.readyz), nil
+				},
+				now: time.Now,
+			}
+			cluster := &v32.Cluster{}
+
+			err := h.getHealth(cluster)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			for cond, status := range tt.wantConds {
+				assert.Equal(t, string(status), cond.GetStatus(cluster), cond)
+			}
+		})
+	}
+}
+
+func TestRecordTransitions(t *testing.T) {
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	oldCluster := &v32.Cluster{}
+	v32.ClusterConditionReady.True(oldCluster)
+	v32.ClusterConditionDNSReady.True(oldCluster)
+	for i := 0; i < healthHistoryLimit; i++ {
+		oldCluster.Status.HealthHistory = append(oldCluster.Status.HealthHistory, v32.ClusterHealthTransition{Type: "Ready", Status: v1.ConditionTrue})
+	}
+
+	cluster := oldCluster.DeepCopy()
+	v32.ClusterConditionReady.False(cluster)
+	v32.ClusterConditionDNSReady.False(cluster)
+	v32.ClusterConditionDNSReady.Reason(cluster, "NoReadyPods")
+	v32.ClusterConditionCNIReady.True(cluster)
+	recordTransitions(oldCluster, cluster, now)
+
+	require.Len(t, cluster.Status.HealthHistory, healthHistoryLimit)
+	assert.Equal(t, []v32.ClusterHealthTransition{
+		{Type: "Ready", Status: v1.ConditionFalse, Time: "2024-01-08T20:00:00Z"},
+		{Type: "DNSReady", Status: v1.ConditionFalse, Time: "2024-01-08T20:00:00Z", Reason: "NoReadyPods"},
+		{Type: "CNIReady", Status: v1.ConditionTrue, Time: "2024-01-08T20:00:00Z"},
+	}, cluster.Status.HealthHistory[healthHistoryLimit-3:])
+}
+
+func TestLeaderChanges(t *testing.T) {
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	var l leaderChanges
+
+	assert.Equal(t, 0, l.observe("etcd-a", 10, now), "the first observation is the baseline")
+	assert.Equal(t, 2, l.observe("etcd-a", 12, now.Add(time.Minute)))
+	assert.Equal(t, 4, l.observe("etcd-a", 14, now.Add(2*time.Minute)))
+	assert.Equal(t, 2, l.observe("etcd-a", 14, now.Add(12*time.Minute)))
+	assert.Equal(t, 0, l.observe("etcd-a", 14, now.Add(13*time.Minute)))
+
+	value, ok := metricValue([]byte("# HELP etcd_server_has_leader\netcd_server_has_leader 1\netcd_server_leader_changes_seen_total 3\n"), "etcd_server_leader_changes_seen_total")
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, value)
+}
@@ -21,16 +21,18 @@
 	"k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
 )
 
-func newProjectLifecycle(r *manager, secretClient wcorev1.SecretClient) *pLifecycle {
+func newProjectLifecycle(r *manager, secretClient wcorev1.SecretClient, configMapClient wcorev1.ConfigMapClient) *pLifecycle {
 	return &pLifecycle{
-		m:            r,
-		secretClient: secretClient,
+		m:               r,
+		secretClient:    secretClient,
+		configMapClient: configMapClient,
 	}
 }
 
 type pLifecycle struct {
-	m            *manager
-	secretClient wcorev1.SecretClient
+	m               *manager
+	secretClient    wcorev1.SecretClient
+	configMapClient wcorev1.ConfigMapClient
 }
 
 func (p *pLifecycle) Create(project *v3.Project) (runtime.Object, error) {
@@ -109,6 +111,19 @@ func (p *pLifecycle) Remove(project *v3.Project) (runtime.Object, error) {
 				returnErrors = errors.Join(returnErrors, err)
 			}
 		}
+
+		// remove project scoped config maps if they exist
+		configMaps, err := p.configMapClient.List(namespace.Name, metav1.ListOptions{LabelSelector: secret.ProjectScopedConfigMapLabel + "=" + project.Name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list project scoped config maps: %w", err)
+		}
+		for _, configMap := range configMaps.Items {
+			err := p.configMapClient.Delete(namespace.Name, configMap.Name, &metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				logrus.Errorf("failed to delete project scoped config map %s/%s: %v", namespace.Name, configMap.Name, err)
+				returnErrors = errors.Join(returnErrors, err)
+			}
+		}
 	}
 
 	return nil, returnErrors
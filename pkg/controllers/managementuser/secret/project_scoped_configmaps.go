@@ -0,0 +1,114 @@
+package secret
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/relatedresource"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	configMapNamespaceEnqueuerName = "project-scoped-configmap-namespace-enqueuer"
+	configMapCopiesHandler         = "project-scoped-configmap-copies"
+	configMapEnqueuerName          = "project-scoped-configmap-enqueuer"
+	ProjectScopedConfigMapLabel    = "management.cattle.io/project-scoped-configmap"
+	pscmCopyAnnotation             = "management.cattle.io/project-scoped-configmap-copy"
+)
+
+// getProjectScopedConfigMapsFromNamespace gets all project scoped config maps from a project namespace.
+func (n *namespaceHandler) getProjectScopedConfigMapsFromNamespace(project *v3.Project) ([]*corev1.ConfigMap, error) {
+	backingNamespace := project.GetProjectBackingNamespace()
+
+	r, err := labels.NewRequirement(ProjectScopedConfigMapLabel, selection.Equals, []string{project.Name})
+	if err != nil {
+		return nil, err
+	}
+	return n.managementConfigMapCache.List(backingNamespace, labels.NewSelector().Add(*r))
+}
+
+// removeUndesiredProjectScopedConfigMaps removes project scoped config maps from the namespace that are not in the desiredConfigMaps set.
+func (n *namespaceHandler) removeUndesiredProjectScopedConfigMaps(namespace *corev1.Namespace, desiredConfigMaps sets.Set[types.NamespacedName]) error {
+	downstreamProjectScopedConfigMaps, err := n.configMapClient.List(namespace.Name, metav1.ListOptions{
+		LabelSelector: ProjectScopedConfigMapLabel,
+	})
+	if err != nil {
+		return err
+	}
+
+	allConfigMaps := sets.New[types.NamespacedName]()
+	for _, configMap := range downstreamProjectScopedConfigMaps.Items {
+		// only remove config maps that are copies
+		if configMap.Annotations[pscmCopyAnnotation] == "true" {
+			allConfigMaps.Insert(client.ObjectKeyFromObject(&configMap))
+		}
+	}
+
+	var errs error
+	for _, configMap := range allConfigMaps.Difference(desiredConfigMaps).UnsortedList() {
+		logrus.Infof("Cleaning project scoped config map %s from namespace %s", configMap.Name, configMap.Namespace)
+		errs = errors.Join(errs, n.configMapClient.Delete(namespace.Name, configMap.Name, &metav1.DeleteOptions{}))
+	}
+	return errs
+}
+
+// configMapEnqueueNamespace enqueues all the project namespaces of a project scoped config map.
+func (n *namespaceHandler) configMapEnqueueNamespace(_, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		logrus.Errorf("unable to convert object: %[1]v, type: %[1]T to a config map", obj)
+		return nil, nil
+	}
+
+	project, err := n.getProjectFromSource(configMap, ProjectScopedConfigMapLabel)
+	if err != nil || project == nil {
+		return nil, err
+	}
+	namespaces, err := n.getProjectNamespaces(project)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaceKeys := make([]relatedresource.Key, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		namespaceKeys = append(namespaceKeys, relatedresource.Key{Name: namespace.Name})
+	}
+	return namespaceKeys, nil
+}
+
+// getNamespacedConfigMap copies a project scoped config map and replaces the namespace with the passed in namespace.
+func getNamespacedConfigMap(obj *corev1.ConfigMap, namespace string) *corev1.ConfigMap {
+	namespacedConfigMap := &corev1.ConfigMap{}
+	namespacedConfigMap.Name = obj.Name
+	namespacedConfigMap.Kind = obj.Kind
+	namespacedConfigMap.Data = obj.Data
+	namespacedConfigMap.BinaryData = obj.BinaryData
+	namespacedConfigMap.Namespace = namespace
+	namespacedConfigMap.Annotations = make(map[string]string)
+	namespacedConfigMap.Labels = make(map[string]string)
+	maps.Copy(namespacedConfigMap.Annotations, obj.Annotations)
+	maps.Copy(namespacedConfigMap.Labels, obj.Labels)
+	delete(namespacedConfigMap.Annotations, copiedNamespacesAnnotation)
+	namespacedConfigMap.Annotations[pscmCopyAnnotation] = "true"
+	return namespacedConfigMap
+}
+
+func areConfigMapsSame(c1, c2 *corev1.ConfigMap) (bool, *corev1.ConfigMap) {
+	return reflect.DeepEqual(c1.Data, c2.Data) &&
+		reflect.DeepEqual(c1.BinaryData, c2.BinaryData) &&
+		c1.Labels[ProjectScopedConfigMapLabel] == c2.Labels[ProjectScopedConfigMapLabel] &&
+		c1.Annotations[pscmCopyAnnotation] == c2.Annotations[pscmCopyAnnotation], c2
+}
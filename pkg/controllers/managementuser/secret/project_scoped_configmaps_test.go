@@ -0,0 +1,108 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func Test_namespaceHandler_removeUndesiredProjectScopedConfigMaps(t *testing.T) {
+	copies := &corev1.ConfigMapList{
+		Items: []corev1.ConfigMap{
+			{ObjectMeta: metav1.ObjectMeta{Name: "cm1", Namespace: "ns1", Annotations: map[string]string{pscmCopyAnnotation: "true"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "cm2", Namespace: "ns1", Annotations: map[string]string{pscmCopyAnnotation: "true"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "ns1"}},
+		},
+	}
+	tests := []struct {
+		name                 string
+		desiredConfigMaps    sets.Set[types.NamespacedName]
+		setupConfigMapClient func(*fake.MockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList])
+		wantErr              bool
+	}{
+		{
+			name:              "error listing config maps",
+			desiredConfigMaps: sets.New[types.NamespacedName](),
+			setupConfigMapClient: func(f *fake.MockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList]) {
+				f.EXPECT().List("ns1", metav1.ListOptions{LabelSelector: ProjectScopedConfigMapLabel}).Return(nil, errDefault)
+			},
+			wantErr: true,
+		},
+		{
+			name:              "desired config maps match existing copies, no deletion",
+			desiredConfigMaps: sets.New(types.NamespacedName{Name: "cm1", Namespace: "ns1"}, types.NamespacedName{Name: "cm2", Namespace: "ns1"}),
+			setupConfigMapClient: func(f *fake.MockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList]) {
+				f.EXPECT().List("ns1", metav1.ListOptions{LabelSelector: ProjectScopedConfigMapLabel}).Return(copies, nil)
+			},
+		},
+		{
+			name:              "remove undesired copies only",
+			desiredConfigMaps: sets.New(types.NamespacedName{Name: "cm1", Namespace: "ns1"}),
+			setupConfigMapClient: func(f *fake.MockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList]) {
+				f.EXPECT().List("ns1", metav1.ListOptions{LabelSelector: ProjectScopedConfigMapLabel}).Return(copies, nil)
+				f.EXPECT().Delete("ns1", "cm2", &metav1.DeleteOptions{}).Return(nil)
+			},
+		},
+		{
+			name:              "error deleting config maps",
+			desiredConfigMaps: sets.New(types.NamespacedName{Name: "cm1", Namespace: "ns1"}),
+			setupConfigMapClient: func(f *fake.MockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList]) {
+				f.EXPECT().List("ns1", metav1.ListOptions{LabelSelector: ProjectScopedConfigMapLabel}).Return(copies, nil)
+				f.EXPECT().Delete("ns1", "cm2", &metav1.DeleteOptions{}).Return(errDefault)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+			tt.setupConfigMapClient(configMapClient)
+
+			n := &namespaceHandler{
+				configMapClient: configMapClient,
+			}
+			err := n.removeUndesiredProjectScopedConfigMaps(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}}, tt.desiredConfigMaps)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_getNamespacedConfigMap(t *testing.T) {
+	source := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ca-bundle",
+			Namespace: backingNS,
+			Labels:    map[string]string{ProjectScopedConfigMapLabel: projectName},
+			Annotations: map[string]string{
+				namespaceSelectorAnnotation: "team=a",
+				copiedNamespacesAnnotation:  "ns1",
+			},
+		},
+		Data: map[string]string{"ca.crt": "cert"},
+	}
+
+	got := getNamespacedConfigMap(source, "ns2")
+	assert.Equal(t, "ns2", got.Namespace)
+	assert.Equal(t, source.Data, got.Data)
+	assert.Equal(t, source.Labels, got.Labels)
+	assert.Equal(t, map[string]string{namespaceSelectorAnnotation: "team=a", pscmCopyAnnotation: "true"}, got.Annotations)
+	assert.Equal(t, "ns1", source.Annotations[copiedNamespacesAnnotation], "the source is not modified")
+
+	same, _ := areConfigMapsSame(got, getNamespacedConfigMap(source, "ns2"))
+	assert.True(t, same)
+	changed := source.DeepCopy()
+	changed.Data["ca.crt"] = "other"
+	same, _ = areConfigMapsSame(got, getNamespacedConfigMap(changed, "ns2"))
+	assert.False(t, same)
+}
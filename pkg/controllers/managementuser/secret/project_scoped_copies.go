@@ -0,0 +1,208 @@
+package secret
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/relatedresource"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// matchesNamespaceSelector returns whether a project scoped resource is copied into a namespace of its project.
+// Resources without a namespace selector are copied into all the namespaces of the project, and resources with an
+// invalid one into none of them.
+func matchesNamespaceSelector(obj metav1.Object, namespace *corev1.Namespace) bool {
+	value, ok := obj.GetAnnotations()[namespaceSelectorAnnotation]
+	if !ok {
+		return true
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		logrus.Warnf("Project scoped resource %s/%s has an invalid namespace selector %q, not copying: %v", obj.GetNamespace(), obj.GetName(), value, err)
+		return false
+	}
+	return selector.Matches(labels.Set(namespace.Labels))
+}
+
+// syncImagePullSecrets adds the registry credentials copied into a namespace to the image pull secrets of its default
+// service account, and removes those it previously added which are no longer copied. The image pull secrets set by
+// users are left untouched.
+func (n *namespaceHandler) syncImagePullSecrets(namespace *corev1.Namespace, pullSecrets []string) error {
+	serviceAccount, err := n.serviceAccountCache.Get(namespace.Name, defaultServiceAccount)
+	if apierrors.IsNotFound(err) {
+		if len(pullSecrets) > 0 {
+			// the service account is created by the service account controller shortly after the namespace
+			n.namespaceEnqueuer.EnqueueAfter(namespace.Name, serviceAccountRetryDelay)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	desired := sets.New(pullSecrets...)
+	managed := sets.New[string]()
+	if value := serviceAccount.Annotations[pullSecretsAnnotation]; value != "" {
+		managed.Insert(strings.Split(value, ",")...)
+	}
+
+	var refs []corev1.LocalObjectReference
+	referenced := sets.New[string]()
+	for _, ref := range serviceAccount.ImagePullSecrets {
+		if managed.Has(ref.Name) && !desired.Has(ref.Name) {
+			continue
+		}
+		refs = append(refs, ref)
+		referenced.Insert(ref.Name)
+	}
+	added := managed.Intersection(desired)
+	for _, name := range sets.List(desired) {
+		if !referenced.Has(name) {
+			refs = append(refs, corev1.LocalObjectReference{Name: name})
+			added.Insert(name)
+		}
+	}
+
+	annotation := strings.Join(sets.List(added), ",")
+	if reflect.DeepEqual(refs, serviceAccount.ImagePullSecrets) && serviceAccount.Annotations[pullSecretsAnnotation] == annotation {
+		return nil
+	}
+	serviceAccount = serviceAccount.DeepCopy()
+	serviceAccount.ImagePullSecrets = refs
+	if annotation == "" {
+		delete(serviceAccount.Annotations, pullSecretsAnnotation)
+	} else {
+		if serviceAccount.Annotations == nil {
+			serviceAccount.Annotations = map[string]string{}
+		}
+		serviceAccount.Annotations[pullSecretsAnnotation] = annotation
+	}
+	_, err = n.serviceAccountClient.Update(serviceAccount)
+	return err
+}
+
+// onSecretChange reports the namespaces a project scoped secret was copied into.
+func (n *namespaceHandler) onSecretChange(_ string, secret *corev1.Secret) (*corev1.Secret, error) {
+	if secret == nil || secret.DeletionTimestamp != nil {
+		return secret, nil
+	}
+	project, err := n.getProjectFromSource(secret, ProjectScopedSecretLabel)
+	if err != nil || project == nil {
+		return secret, err
+	}
+
+	value, err := n.copiedNamespaces(project, secret)
+	if err != nil {
+		return secret, err
+	}
+	if secret.Annotations[copiedNamespacesAnnotation] == value {
+		return secret, nil
+	}
+	secret = secret.DeepCopy()
+	setCopiedNamespaces(secret, value)
+	return n.managementSecretClient.Update(secret)
+}
+
+// onConfigMapChange reports the namespaces a project scoped config map was copied into.
+func (n *namespaceHandler) onConfigMapChange(_ string, configMap *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	if configMap == nil || configMap.DeletionTimestamp != nil {
+		return configMap, nil
+	}
+	project, err := n.getProjectFromSource(configMap, ProjectScopedConfigMapLabel)
+	if err != nil || project == nil {
+		return configMap, err
+	}
+
+	value, err := n.copiedNamespaces(project, configMap)
+	if err != nil {
+		return configMap, err
+	}
+	if configMap.Annotations[copiedNamespacesAnnotation] == value {
+		return configMap, nil
+	}
+	configMap = configMap.DeepCopy()
+	setCopiedNamespaces(configMap, value)
+	return n.managementConfigMapClient.Update(configMap)
+}
+
+// namespaceEnqueueSecrets enqueues the project scoped secrets of the project of a namespace, so that the copies are
+// reported again when the namespace is created, relabeled or deleted.
+func (n *namespaceHandler) namespaceEnqueueSecrets(_, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	namespace, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return nil, nil
+	}
+	project, err := n.getProjectFromNamespace(namespace)
+	if err != nil || project == nil || project.Spec.ClusterName != n.clusterName {
+		return nil, err
+	}
+	secrets, err := n.getProjectScopedSecretsFromNamespace(project)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]relatedresource.Key, 0, len(secrets))
+	for _, secret := range secrets {
+		keys = append(keys, relatedresource.Key{Namespace: secret.Namespace, Name: secret.Name})
+	}
+	return keys, nil
+}
+
+// namespaceEnqueueConfigMaps enqueues the project scoped config maps of the project of a namespace, so that the copies
+// are reported again when the namespace is created, relabeled or deleted.
+func (n *namespaceHandler) namespaceEnqueueConfigMaps(_, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+	namespace, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return nil, nil
+	}
+	project, err := n.getProjectFromNamespace(namespace)
+	if err != nil || project == nil || project.Spec.ClusterName != n.clusterName {
+		return nil, err
+	}
+	configMaps, err := n.getProjectScopedConfigMapsFromNamespace(project)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]relatedresource.Key, 0, len(configMaps))
+	for _, configMap := range configMaps {
+		keys = append(keys, relatedresource.Key{Namespace: configMap.Namespace, Name: configMap.Name})
+	}
+	return keys, nil
+}
+
+// copiedNamespaces returns the value of the annotation reporting the namespaces of a project a project scoped resource
+// is copied into. They are the namespaces matching its namespace selector, read from the namespace cache rather than
+// by listing the copies, as the secrets of downstream clusters aren't cached.
+func (n *namespaceHandler) copiedNamespaces(project *v3.Project, obj metav1.Object) (string, error) {
+	projectNamespaces, err := n.getProjectNamespaces(project)
+	if err != nil {
+		return "", err
+	}
+	var namespaces []string
+	for _, namespace := range projectNamespaces {
+		if namespace.DeletionTimestamp == nil && matchesNamespaceSelector(obj, namespace) {
+			namespaces = append(namespaces, namespace.Name)
+		}
+	}
+	slices.Sort(namespaces)
+	return strings.Join(namespaces, ","), nil
+}
+
+func setCopiedNamespaces(obj metav1.Object, value string) {
+	annotations := obj.GetAnnotations()
+	if value == "" {
+		delete(annotations, copiedNamespacesAnnotation)
+		return
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[copiedNamespacesAnnotation] = value
+	obj.SetAnnotations(annotations)
+}
@@ -0,0 +1,245 @@
+package secret
+
+import (
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_matchesNamespaceSelector(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"team": "a"}}}
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{
+		{
+			name: "no selector",
+			want: true,
+		},
+		{
+			name:        "matching selector",
+			annotations: map[string]string{namespaceSelectorAnnotation: "team in (a,b)"},
+			want:        true,
+		},
+		{
+			name:        "selector not matching",
+			annotations: map[string]string{namespaceSelectorAnnotation: "team=b"},
+			want:        false,
+		},
+		{
+			name:        "invalid selector",
+			annotations: map[string]string{namespaceSelectorAnnotation: "team in a"},
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "s1", Annotations: tt.annotations}}
+			assert.Equal(t, tt.want, matchesNamespaceSelector(secret, namespace))
+		})
+	}
+}
+
+func Test_namespaceHandler_syncImagePullSecrets(t *testing.T) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1"}}
+	tests := []struct {
+		name           string
+		serviceAccount *corev1.ServiceAccount
+		pullSecrets    []string
+		want           *corev1.ServiceAccount
+		wantRetry      bool
+	}{
+		{
+			name: "add pull secrets after the ones set by users",
+			serviceAccount: &corev1.ServiceAccount{
+				ObjectMeta:       metav1.ObjectMeta{Name: defaultServiceAccount, Namespace: "ns1"},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "user"}},
+			},
+			pullSecrets: []string{"registry2", "registry1"},
+			want: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        defaultServiceAccount,
+					Namespace:   "ns1",
+					Annotations: map[string]string{pullSecretsAnnotation: "registry1,registry2"},
+				},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "user"}, {Name: "registry1"}, {Name: "registry2"}},
+			},
+		},
+		{
+			name: "remove pull secrets no longer copied",
+			serviceAccount: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        defaultServiceAccount,
+					Namespace:   "ns1",
+					Annotations: map[string]string{pullSecretsAnnotation: "registry1,registry2"},
+				},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "user"}, {Name: "registry1"}, {Name: "registry2"}},
+			},
+			pullSecrets: []string{"registry2"},
+			want: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        defaultServiceAccount,
+					Namespace:   "ns1",
+					Annotations: map[string]string{pullSecretsAnnotation: "registry2"},
+				},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "user"}, {Name: "registry2"}},
+			},
+		},
+		{
+			name: "pull secrets set by users are not managed",
+			serviceAccount: &corev1.ServiceAccount{
+				ObjectMeta:       metav1.ObjectMeta{Name: defaultServiceAccount, Namespace: "ns1"},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry1"}},
+			},
+			pullSecrets: []string{"registry1"},
+		},
+		{
+			name: "up to date",
+			serviceAccount: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        defaultServiceAccount,
+					Namespace:   "ns1",
+					Annotations: map[string]string{pullSecretsAnnotation: "registry1"},
+				},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry1"}},
+			},
+			pullSecrets: []string{"registry1"},
+		},
+		{
+			name:        "service account not created yet",
+			pullSecrets: []string{"registry1"},
+			wantRetry:   true,
+		},
+		{
+			name: "service account not created yet, no pull secrets",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			serviceAccountCache := fake.NewMockCacheInterface[*corev1.ServiceAccount](ctrl)
+			serviceAccountClient := fake.NewMockClientInterface[*corev1.ServiceAccount, *corev1.ServiceAccountList](ctrl)
+			namespaces := fake.NewMockNonNamespacedControllerInterface[*corev1.Namespace, *corev1.NamespaceList](ctrl)
+			if tt.serviceAccount != nil {
+				serviceAccountCache.EXPECT().Get("ns1", defaultServiceAccount).Return(tt.serviceAccount, nil)
+			} else {
+				serviceAccountCache.EXPECT().Get("ns1", defaultServiceAccount).Return(nil, errNotFound)
+			}
+			if tt.want != nil {
+				serviceAccountClient.EXPECT().Update(tt.want).Return(tt.want, nil)
+			}
+			if tt.wantRetry {
+				namespaces.EXPECT().EnqueueAfter("ns1", serviceAccountRetryDelay)
+			}
+
+			n := &namespaceHandler{
+				serviceAccountCache:  serviceAccountCache,
+				serviceAccountClient: serviceAccountClient,
+				namespaceEnqueuer:    namespaces,
+			}
+			assert.NoError(t, n.syncImagePullSecrets(namespace, tt.pullSecrets))
+		})
+	}
+}
+
+func Test_namespaceHandler_onSecretChange(t *testing.T) {
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "s1",
+			Namespace: backingNS,
+			Labels:    map[string]string{ProjectScopedSecretLabel: projectName},
+		},
+	}
+	namespace := func(name string, labels map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	tests := []struct {
+		name       string
+		secret     *corev1.Secret
+		namespaces []*corev1.Namespace
+		want       *corev1.Secret
+	}{
+		{
+			name:       "report copied namespaces",
+			secret:     source,
+			namespaces: []*corev1.Namespace{namespace("ns2", nil), namespace("ns1", nil)},
+			want: func() *corev1.Secret {
+				secret := source.DeepCopy()
+				secret.Annotations = map[string]string{copiedNamespacesAnnotation: "ns1,ns2"}
+				return secret
+			}(),
+		},
+		{
+			name: "namespaces not matching the selector or being deleted",
+			secret: func() *corev1.Secret {
+				secret := source.DeepCopy()
+				secret.Annotations = map[string]string{namespaceSelectorAnnotation: "env=prod"}
+				return secret
+			}(),
+			namespaces: []*corev1.Namespace{
+				namespace("ns1", map[string]string{"env": "prod"}),
+				namespace("ns2", map[string]string{"env": "dev"}),
+				func() *corev1.Namespace {
+					ns := namespace("ns3", map[string]string{"env": "prod"})
+					ns.DeletionTimestamp = &metav1.Time{}
+					return ns
+				}(),
+			},
+			want: func() *corev1.Secret {
+				secret := source.DeepCopy()
+				secret.Annotations = map[string]string{namespaceSelectorAnnotation: "env=prod", copiedNamespacesAnnotation: "ns1"}
+				return secret
+			}(),
+		},
+		{
+			name: "copied namespaces up to date",
+			secret: func() *corev1.Secret {
+				secret := source.DeepCopy()
+				secret.Annotations = map[string]string{copiedNamespacesAnnotation: "ns1"}
+				return secret
+			}(),
+			namespaces: []*corev1.Namespace{namespace("ns1", nil)},
+		},
+		{
+			name: "no copies left",
+			secret: func() *corev1.Secret {
+				secret := source.DeepCopy()
+				secret.Annotations = map[string]string{copiedNamespacesAnnotation: "ns1", "other": "val"}
+				return secret
+			}(),
+			want: func() *corev1.Secret {
+				secret := source.DeepCopy()
+				secret.Annotations = map[string]string{"other": "val"}
+				return secret
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			projectCache := fake.NewMockCacheInterface[*v3.Project](ctrl)
+			projectCache.EXPECT().Get(clusterName, projectName).Return(testProject, nil)
+			namespaceCache := fake.NewMockNonNamespacedCacheInterface[*corev1.Namespace](ctrl)
+			namespaceCache.EXPECT().List(gomock.Any()).Return(tt.namespaces, nil)
+			managementSecretClient := fake.NewMockClientInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+			if tt.want != nil {
+				managementSecretClient.EXPECT().Update(tt.want).Return(tt.want, nil)
+			}
+
+			n := &namespaceHandler{
+				projectCache:           projectCache,
+				clusterNamespaceCache:  namespaceCache,
+				managementSecretClient: managementSecretClient,
+				clusterName:            clusterName,
+			}
+			_, err := n.onSecretChange("", tt.secret)
+			assert.NoError(t, err)
+		})
+	}
+}
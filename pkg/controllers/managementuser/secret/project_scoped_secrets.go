@@ -8,6 +8,7 @@
 	"reflect"
 	"slices"
 	"strings"
+	"time"
 
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	mgmtv3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
@@ -34,6 +35,20 @@
 	projectIDLabel           = "field.cattle.io/projectId"
 	ProjectScopedSecretLabel = "management.cattle.io/project-scoped-secret"
 	pssCopyAnnotation        = "management.cattle.io/project-scoped-secret-copy"
+	secretCopiesHandler      = "project-scoped-secret-copies"
+	secretEnqueuerName       = "project-scoped-secret-enqueuer"
+
+	// namespaceSelectorAnnotation holds a label selector restricting the namespaces of the project a project scoped
+	// resource is copied into.
+	namespaceSelectorAnnotation = "management.cattle.io/project-scoped-namespace-selector"
+	// copiedNamespacesAnnotation reports the namespaces a project scoped resource was copied into.
+	copiedNamespacesAnnotation = "management.cattle.io/project-scoped-copied-namespaces"
+	// pullSecretsAnnotation records the image pull secrets added to the default service account of a namespace.
+	pullSecretsAnnotation = "management.cattle.io/project-scoped-image-pull-secrets"
+	defaultServiceAccount = "default"
+	// serviceAccountRetryDelay is the delay after which a namespace is synced again when its default service account
+	// doesn't exist yet.
+	serviceAccountRetryDelay = 5 * time.Second
 )
 
 // previous controller label, annotations and finalizer
@@ -44,25 +59,51 @@
 )
 
 type namespaceHandler struct {
-	managementSecretCache  wcorev1.SecretCache
-	managementSecretClient wcorev1.SecretClient
-	clusterNamespaceCache  wcorev1.NamespaceCache
-	projectCache           mgmtv3.ProjectCache
-	secretClient           wcorev1.SecretClient
-	clusterName            string
+	managementSecretCache     wcorev1.SecretCache
+	managementSecretClient    wcorev1.SecretClient
+	managementConfigMapCache  wcorev1.ConfigMapCache
+	managementConfigMapClient wcorev1.ConfigMapClient
+	clusterNamespaceCache     wcorev1.NamespaceCache
+	namespaceEnqueuer         namespaceEnqueuer
+	projectCache              mgmtv3.ProjectCache
+	secretClient              wcorev1.SecretClient
+	configMapClient           wcorev1.ConfigMapClient
+	serviceAccountCache       wcorev1.ServiceAccountCache
+	serviceAccountClient      wcorev1.ServiceAccountClient
+	clusterName               string
+}
+
+// namespaceEnqueuer enqueues namespaces after a delay, it is implemented by the namespace controller.
+type namespaceEnqueuer interface {
+	EnqueueAfter(name string, duration time.Duration)
 }
 
 func RegisterProjectScopedSecretHandler(ctx context.Context, cluster *config.UserContext) {
+	managementSecrets := cluster.Management.Wrangler.Core.Secret()
+	managementConfigMaps := cluster.Management.Wrangler.Core.ConfigMap()
 	n := &namespaceHandler{
-		secretClient:           cluster.Corew.Secret(),
-		managementSecretCache:  cluster.Management.Wrangler.Core.Secret().Cache(),
-		managementSecretClient: cluster.Management.Wrangler.Core.Secret(),
-		projectCache:           cluster.Management.Wrangler.Mgmt.Project().Cache(),
-		clusterNamespaceCache:  cluster.Corew.Namespace().Cache(),
-		clusterName:            cluster.ClusterName,
+		secretClient:              cluster.Corew.Secret(),
+		configMapClient:           cluster.Corew.ConfigMap(),
+		serviceAccountCache:       cluster.Corew.ServiceAccount().Cache(),
+		serviceAccountClient:      cluster.Corew.ServiceAccount(),
+		managementSecretCache:     managementSecrets.Cache(),
+		managementSecretClient:    managementSecrets,
+		managementConfigMapCache:  managementConfigMaps.Cache(),
+		managementConfigMapClient: managementConfigMaps,
+		projectCache:              cluster.Management.Wrangler.Mgmt.Project().Cache(),
+		clusterNamespaceCache:     cluster.Corew.Namespace().Cache(),
+		namespaceEnqueuer:         cluster.Corew.Namespace(),
+		clusterName:               cluster.ClusterName,
 	}
 	cluster.Corew.Namespace().OnChange(ctx, namespaceChangeHandler, n.OnChange)
-	relatedresource.WatchClusterScoped(ctx, namespaceEnqueuerName, n.secretEnqueueNamespace, cluster.Corew.Namespace(), cluster.Management.Wrangler.Core.Secret())
+	relatedresource.WatchClusterScoped(ctx, namespaceEnqueuerName, n.secretEnqueueNamespace, cluster.Corew.Namespace(), managementSecrets)
+	relatedresource.WatchClusterScoped(ctx, configMapNamespaceEnqueuerName, n.configMapEnqueueNamespace, cluster.Corew.Namespace(), managementConfigMaps)
+
+	// the copies are reported on the sources, which are shared by all the clusters
+	managementSecrets.OnChange(ctx, secretCopiesHandler+"-"+cluster.ClusterName, n.onSecretChange)
+	managementConfigMaps.OnChange(ctx, configMapCopiesHandler+"-"+cluster.ClusterName, n.onConfigMapChange)
+	relatedresource.Watch(ctx, secretEnqueuerName+"-"+cluster.ClusterName, n.namespaceEnqueueSecrets, managementSecrets, cluster.Corew.Namespace())
+	relatedresource.Watch(ctx, configMapEnqueuerName+"-"+cluster.ClusterName, n.namespaceEnqueueConfigMaps, managementConfigMaps, cluster.Corew.Namespace())
 }
 
 func (n *namespaceHandler) OnChange(_ string, namespace *corev1.Namespace) (*corev1.Namespace, error) {
@@ -88,22 +129,52 @@ func (n *namespaceHandler) OnChange(_ string, namespace *corev1.Namespace) (*cor
 		return nil, err
 	}
 
+	configMaps, err := n.getProjectScopedConfigMapsFromNamespace(project)
+	if err != nil {
+		return nil, err
+	}
+
 	var errs error
 	desiredSecrets := sets.New[types.NamespacedName]()
+	var pullSecrets []string
 
 	// create/update project scoped secrets
 	for _, secret := range secrets {
+		if !matchesNamespaceSelector(secret, namespace) {
+			continue
+		}
 		secretCopy := getNamespacedSecret(secret, namespace.Name)
 
 		err := rbac.CreateOrUpdateNamespacedResource(secretCopy, n.secretClient, areSecretsSame)
 		desiredSecrets.Insert(client.ObjectKeyFromObject(secretCopy))
+		if secret.Type == corev1.SecretTypeDockerConfigJson {
+			pullSecrets = append(pullSecrets, secret.Name)
+		}
+		errs = errors.Join(errs, err)
+	}
+
+	// create/update project scoped config maps
+	desiredConfigMaps := sets.New[types.NamespacedName]()
+	for _, configMap := range configMaps {
+		if !matchesNamespaceSelector(configMap, namespace) {
+			continue
+		}
+		configMapCopy := getNamespacedConfigMap(configMap, namespace.Name)
+
+		err := rbac.CreateOrUpdateNamespacedResource(configMapCopy, n.configMapClient, areConfigMapsSame)
+		desiredConfigMaps.Insert(client.ObjectKeyFromObject(configMapCopy))
 		errs = errors.Join(errs, err)
 	}
 	if errs != nil {
 		return nil, errs
 	}
 
-	return namespace, n.removeUndesiredProjectScopedSecrets(namespace, desiredSecrets)
+	errs = errors.Join(
+		n.removeUndesiredProjectScopedSecrets(namespace, desiredSecrets),
+		n.removeUndesiredProjectScopedConfigMaps(namespace, desiredConfigMaps),
+		n.syncImagePullSecrets(namespace, pullSecrets),
+	)
+	return namespace, errs
 }
 
 // migrateExistingProjectScopedSecrets migrates existing project scoped secrets.
@@ -232,12 +303,18 @@ func (n *namespaceHandler) secretEnqueueNamespace(_, _ string, obj runtime.Objec
 
 // getNamespacesFromSecret returns a slice of project namespaces from a project scoped secret.
 func (n *namespaceHandler) getNamespacesFromSecret(secret *corev1.Secret) ([]*corev1.Namespace, error) {
-	if secret.Labels == nil {
-		return nil, nil
+	project, err := n.getProjectFromSource(secret, ProjectScopedSecretLabel)
+	if err != nil || project == nil {
+		return nil, err
 	}
+	return n.getProjectNamespaces(project)
+}
 
-	// we only care about project scoped secrets
-	projectName, ok := secret.Labels[ProjectScopedSecretLabel]
+// getProjectFromSource returns the project of a project scoped resource labeled with the given label, if the project
+// belongs to this cluster and the resource is in its backing namespace.
+func (n *namespaceHandler) getProjectFromSource(obj metav1.Object, label string) (*v3.Project, error) {
+	// we only care about project scoped resources
+	projectName, ok := obj.GetLabels()[label]
 	if !ok {
 		return nil, nil
 	}
@@ -248,11 +325,15 @@ func (n *namespaceHandler) getNamespacesFromSecret(secret *corev1.Secret) ([]*co
 	} else if err != nil {
 		return nil, err
 	}
-	if project.GetProjectBackingNamespace() != secret.Namespace {
-		logrus.Tracef("Secret [%s] not in the project namespace, not copying", secret.Name)
+	if project.GetProjectBackingNamespace() != obj.GetNamespace() {
+		logrus.Tracef("Resource [%s] not in the project namespace, not copying", obj.GetName())
 		return nil, nil
 	}
+	return project, nil
+}
 
+// getProjectNamespaces returns the namespaces of a project.
+func (n *namespaceHandler) getProjectNamespaces(project *v3.Project) ([]*corev1.Namespace, error) {
 	r, err := labels.NewRequirement(projectIDLabel, selection.Equals, []string{project.Name})
 	if err != nil {
 		return nil, err
@@ -273,6 +354,7 @@ func getNamespacedSecret(obj *corev1.Secret, namespace string) *corev1.Secret {
 	namespacedSecret.Labels = make(map[string]string)
 	maps.Copy(namespacedSecret.Annotations, obj.Annotations)
 	maps.Copy(namespacedSecret.Labels, obj.Labels)
+	delete(namespacedSecret.Annotations, copiedNamespacesAnnotation)
 	namespacedSecret.Annotations[userSecretAnnotation] = "true"
 	namespacedSecret.Annotations[pssCopyAnnotation] = "true"
 	return namespacedSecret
@@ -0,0 +1,56 @@
+package snapshotjob
+
+import (
+	"time"
+
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr/etcds3"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+)
+
+// PresignExpiry is how long the presigned URLs handed to a job are valid, which bounds how long the job can wait before
+// using them.
+const PresignExpiry = time.Hour
+
+// PresignedURL is a presigned URL of a snapshot file in S3, along with the TLS settings of the S3 endpoint.
+type PresignedURL struct {
+	URL           string
+	CA            []byte
+	SkipSSLVerify bool
+}
+
+// AddTo adds the URL and the CA of the S3 endpoint, if any, to the data of a job secret, under the keys prefix+"url"
+// and prefix+"ca.crt".
+func (u *PresignedURL) AddTo(data map[string][]byte, prefix string) {
+	data[prefix+"url"] = []byte(u.URL)
+	if len(u.CA) > 0 {
+		data[prefix+"ca.crt"] = u.CA
+	}
+}
+
+// PresignGet generates a presigned URL to download the given S3 snapshot file. The S3 configuration the snapshot was
+// taken with takes precedence over the S3 configuration of the etcd config of the cluster, which provides the cloud
+// credential.
+func PresignGet(secretCache corecontrollers.SecretCache, cluster *provv1.Cluster, snapshot *rkev1.ETCDSnapshot) (*PresignedURL, error) {
+	bucket, key, err := etcds3.ParseLocation(snapshot.SnapshotFile.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := etcds3.New(secretCache, cluster.Namespace, snapshot.SnapshotFile.S3, cluster.Spec.RKEConfig.ETCD.S3)
+	if err != nil {
+		return nil, err
+	}
+	client.Bucket = bucket
+
+	url, err := client.PresignGet(key, PresignExpiry)
+	if err != nil {
+		return nil, err
+	}
+	return &PresignedURL{
+		URL:           url,
+		CA:            client.CA,
+		SkipSSLVerify: client.SkipSSLVerify,
+	}, nil
+}
@@ -0,0 +1,235 @@
+// Package snapshotjob runs jobs in downstream clusters processing the files of etcd snapshots, shared by the etcd
+// snapshot verification and replication controllers.
+package snapshotjob
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/image"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+	"github.com/rancher/wrangler/v3/pkg/name"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// Namespace is the downstream namespace the jobs run in.
+	Namespace = namespace.System
+
+	// PollInterval is how often a running job is checked for completion.
+	PollInterval = 30 * time.Second
+
+	// SnapshotAnnotation is set on a job to the namespaced name of the snapshot it processes.
+	SnapshotAnnotation = "rke.cattle.io/etcd-snapshot"
+
+	// WorkDir is the directory shared by the containers of a job.
+	WorkDir = "/work"
+	// SnapshotsDir is the directory the snapshot directory of the node is mounted at for local snapshots.
+	SnapshotsDir = "/snapshots"
+	// SecretDir is the directory the secret of a job, holding the presigned URLs it uses, is mounted at.
+	SecretDir = "/secret"
+
+	snapshotSuccessful = "successful"
+)
+
+// Spec describes the containers and limits of a job processing a snapshot file.
+type Spec struct {
+	// Annotations are added to the job, along with the SnapshotAnnotation.
+	Annotations map[string]string
+	// BackoffLimit is the number of retries of the job.
+	BackoffLimit int32
+	// ActiveDeadlineSeconds bounds how long the job runs.
+	ActiveDeadlineSeconds int64
+	// Download denotes that the snapshot file is downloaded through a presigned URL of the job secret rather than read
+	// from the snapshot directory of the node that took it.
+	Download bool
+	// Secret mounts the job secret created by Create at SecretDir. It is implied by Download.
+	Secret bool
+	// Fetch is the container reading the snapshot file into WorkDir. It runs the shell image as root, with the name of
+	// the snapshot file in the SNAPSHOT_FILE environment variable. When Containers are given, it runs as their init
+	// container.
+	Fetch corev1.Container
+	// Containers process the snapshot file fetched into WorkDir.
+	Containers []corev1.Container
+}
+
+// Name returns the name of a job processing the given snapshot at the given time, prefixed with prefix. The given
+// qualifiers tell apart jobs processing the same snapshot at the same time.
+func Name(prefix string, snapshot *rkev1.ETCDSnapshot, now time.Time, qualifiers ...string) string {
+	key := snapshot.Namespace + "/" + snapshot.Name
+	for _, qualifier := range qualifiers {
+		key += "/" + qualifier
+	}
+	return name.SafeConcatName(prefix, name.Hex(key+"/"+strconv.FormatInt(now.Unix(), 10), 8))
+}
+
+// New renders the job processing the given snapshot. Local snapshots are read from the snapshot directory of the node
+// that took them, so the job is scheduled on that node, while S3 snapshots are downloaded by the fetch container.
+func New(jobName string, snapshot *rkev1.ETCDSnapshot, cluster *v3.Cluster, spec Spec) (*batchv1.Job, error) {
+	location, err := url.Parse(snapshot.SnapshotFile.Location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot location %q: %w", snapshot.SnapshotFile.Location, err)
+	}
+
+	volumes := []corev1.Volume{
+		{Name: "work", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	mounts := []corev1.VolumeMount{
+		{Name: "work", MountPath: WorkDir},
+	}
+	if spec.Download || spec.Secret {
+		volumes = append(volumes, corev1.Volume{Name: "secret", VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: jobName},
+		}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "secret", MountPath: SecretDir, ReadOnly: true})
+	}
+
+	var nodeName string
+	switch {
+	case spec.Download:
+	case location.Scheme == "file":
+		if snapshot.SnapshotFile.NodeName == "" {
+			return nil, fmt.Errorf("node of local snapshot %s is unknown", snapshot.Name)
+		}
+		nodeName = snapshot.SnapshotFile.NodeName
+		volumes = append(volumes, corev1.Volume{Name: "snapshots", VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{Path: path.Dir(location.Path), Type: ptr.To(corev1.HostPathDirectory)},
+		}})
+		mounts = append(mounts, corev1.VolumeMount{Name: "snapshots", MountPath: SnapshotsDir, ReadOnly: true})
+	default:
+		return nil, fmt.Errorf("unsupported snapshot location %q", snapshot.SnapshotFile.Location)
+	}
+
+	fetch := spec.Fetch
+	fetch.Image = image.ResolveWithCluster(settings.ShellImage.Get(), cluster)
+	fetch.Env = append([]corev1.EnvVar{{Name: "SNAPSHOT_FILE", Value: path.Base(location.Path)}}, fetch.Env...)
+	fetch.VolumeMounts = append(mounts, fetch.VolumeMounts...)
+	fetch.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+	fetch.SecurityContext = &corev1.SecurityContext{
+		RunAsUser: ptr.To[int64](0),
+	}
+
+	podSpec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		NodeName:      nodeName,
+		Tolerations: []corev1.Toleration{
+			{Operator: corev1.TolerationOpExists},
+		},
+		NodeSelector: map[string]string{
+			corev1.LabelOSStable: "linux",
+		},
+		Volumes:    volumes,
+		Containers: []corev1.Container{fetch},
+	}
+	if len(spec.Containers) > 0 {
+		podSpec.InitContainers = []corev1.Container{fetch}
+		podSpec.Containers = nil
+		for _, container := range spec.Containers {
+			container.VolumeMounts = append([]corev1.VolumeMount{{Name: "work", MountPath: WorkDir}}, container.VolumeMounts...)
+			podSpec.Containers = append(podSpec.Containers, container)
+		}
+	}
+
+	annotations := map[string]string{
+		SnapshotAnnotation: snapshot.Namespace + "/" + snapshot.Name,
+	}
+	for k, v := range spec.Annotations {
+		annotations[k] = v
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        jobName,
+			Namespace:   Namespace,
+			Annotations: annotations,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            ptr.To(spec.BackoffLimit),
+			ActiveDeadlineSeconds:   ptr.To(spec.ActiveDeadlineSeconds),
+			TTLSecondsAfterFinished: ptr.To[int32](86400),
+			Template: corev1.PodTemplateSpec{
+				Spec: podSpec,
+			},
+		},
+	}, nil
+}
+
+// Create creates the given job in the downstream cluster, then its secret holding the given data, unless there is
+// none. The secret shares the name of the job and is owned by it so that it is removed along with it.
+func Create(ctx context.Context, downstream kubernetes.Interface, job *batchv1.Job, data map[string][]byte) (*batchv1.Job, error) {
+	job, err := downstream.BatchV1().Jobs(job.Namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil || len(data) == 0 {
+		return job, err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      job.Name,
+			Namespace: job.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "batch/v1",
+					Kind:       "Job",
+					Name:       job.Name,
+					UID:        job.UID,
+				},
+			},
+		},
+		Data: data,
+	}
+	if _, err := downstream.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Finished returns whether the given job completed or failed.
+func Finished(job *batchv1.Job) bool {
+	return condition.Cond("Complete").IsTrue(job) || condition.Cond("Failed").IsTrue(job)
+}
+
+// Pods returns the pods of the given job.
+func Pods(ctx context.Context, downstream kubernetes.Interface, job *batchv1.Job) ([]corev1.Pod, error) {
+	pods, err := downstream.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{batchv1.JobNameLabel: job.Name}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// TerminationMessage returns the termination message of a container, falling back to the reason it terminated.
+func TerminationMessage(terminated *corev1.ContainerStateTerminated) string {
+	if terminated.Message != "" {
+		return terminated.Message
+	}
+	return terminated.Reason
+}
+
+// LatestSnapshot returns the index of the most recently created successful snapshot, or -1 if there is none.
+func LatestSnapshot(snapshots []*rkev1.ETCDSnapshot) int {
+	latest := -1
+	for i, snapshot := range snapshots {
+		if snapshot.DeletionTimestamp != nil || snapshot.SnapshotFile.Status != snapshotSuccessful || snapshot.SnapshotFile.CreatedAt == nil {
+			continue
+		}
+		if latest < 0 || snapshot.SnapshotFile.CreatedAt.After(snapshots[latest].SnapshotFile.CreatedAt.Time) {
+			latest = i
+		}
+	}
+	return latest
+}
@@ -0,0 +1,97 @@
+package snapshotjob
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newSnapshot(name string, createdAt time.Time, status string) *rkev1.ETCDSnapshot {
+	return &rkev1.ETCDSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: name},
+		SnapshotFile: rkev1.ETCDSnapshotFile{
+			Location:  "file:///var/lib/rancher/rke2/server/db/snapshots/" + name + ".zip",
+			NodeName:  "node-1",
+			CreatedAt: &metav1.Time{Time: createdAt},
+			Status:    status,
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	snapshot := newSnapshot("local", time.Now(), snapshotSuccessful)
+
+	t.Run("local snapshot with processing container", func(t *testing.T) {
+		job, err := New("job", snapshot, &v3.Cluster{}, Spec{
+			Annotations: map[string]string{"a": "b"},
+			Fetch:       corev1.Container{Name: "fetch"},
+			Containers:  []corev1.Container{{Name: "process"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{SnapshotAnnotation: "fleet-default/local", "a": "b"}, job.Annotations)
+
+		spec := job.Spec.Template.Spec
+		assert.Equal(t, "node-1", spec.NodeName)
+		require.Len(t, spec.Volumes, 2)
+		assert.Equal(t, "/var/lib/rancher/rke2/server/db/snapshots", spec.Volumes[1].HostPath.Path)
+		require.Len(t, spec.InitContainers, 1)
+		assert.Contains(t, spec.InitContainers[0].Env, corev1.EnvVar{Name: "SNAPSHOT_FILE", Value: "local.zip"})
+		assert.Len(t, spec.InitContainers[0].VolumeMounts, 2)
+		require.Len(t, spec.Containers, 1)
+		assert.Equal(t, []corev1.VolumeMount{{Name: "work", MountPath: WorkDir}}, spec.Containers[0].VolumeMounts)
+	})
+
+	t.Run("downloaded snapshot", func(t *testing.T) {
+		remote := snapshot.DeepCopy()
+		remote.SnapshotFile.Location = "s3://bucket/folder/remote"
+		job, err := New("job", remote, &v3.Cluster{}, Spec{Download: true, Fetch: corev1.Container{Name: "fetch"}})
+		require.NoError(t, err)
+
+		spec := job.Spec.Template.Spec
+		assert.Empty(t, spec.NodeName)
+		require.Len(t, spec.Volumes, 2)
+		assert.Equal(t, "job", spec.Volumes[1].Secret.SecretName)
+		assert.Empty(t, spec.InitContainers)
+		assert.Equal(t, "fetch", spec.Containers[0].Name)
+
+		_, err = New("job", remote, &v3.Cluster{}, Spec{Fetch: corev1.Container{Name: "fetch"}})
+		assert.EqualError(t, err, `unsupported snapshot location "s3://bucket/folder/remote"`)
+	})
+}
+
+func TestCreate(t *testing.T) {
+	downstream := k8sfake.NewSimpleClientset()
+	job, err := New("job", newSnapshot("local", time.Now(), snapshotSuccessful), &v3.Cluster{}, Spec{Secret: true})
+	require.NoError(t, err)
+
+	_, err = Create(context.TODO(), downstream, job, map[string][]byte{"url": []byte("https://bucket/get")})
+	require.NoError(t, err)
+
+	secret, err := downstream.CoreV1().Secrets(Namespace).Get(context.TODO(), "job", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "job", secret.OwnerReferences[0].Name)
+	assert.Equal(t, "https://bucket/get", string(secret.Data["url"]))
+}
+
+func TestLatestSnapshot(t *testing.T) {
+	now := time.Now()
+	deleted := newSnapshot("deleted", now, snapshotSuccessful)
+	deleted.DeletionTimestamp = &metav1.Time{Time: now}
+
+	snapshots := []*rkev1.ETCDSnapshot{
+		newSnapshot("older", now.Add(-2*time.Hour), snapshotSuccessful),
+		newSnapshot("latest", now.Add(-time.Hour), snapshotSuccessful),
+		newSnapshot("failed", now.Add(-time.Minute), "failed"),
+		deleted,
+	}
+	assert.Equal(t, 1, LatestSnapshot(snapshots))
+	assert.Equal(t, -1, LatestSnapshot(snapshots[2:]))
+}
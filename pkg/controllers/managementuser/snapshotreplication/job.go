@@ -0,0 +1,111 @@
+package snapshotreplication
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotjob"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	replicateContainerName = "replicate"
+
+	targetAnnotation = "rke.cattle.io/etcd-snapshot-target"
+	pruneAnnotation  = "rke.cattle.io/etcd-snapshot-prune"
+
+	sourcePrefix = "source-"
+	targetPrefix = "target-"
+	targetURLKey = targetPrefix + "url"
+	pruneURLsKey = "prune-urls"
+)
+
+// replicateScript copies the snapshot file from the snapshot directory of the node, or downloads it from the presigned
+// source URL, uploads it to the presigned target URL, and deletes the snapshot files of the target beyond its retention.
+const replicateScript = `set -e
+if [ -f /secret/source-url ]; then
+  if [ -f /secret/source-ca.crt ]; then SOURCE_ARGS="--cacert /secret/source-ca.crt"; fi
+  if [ "${SOURCE_SKIP_SSL_VERIFY}" = "true" ]; then SOURCE_ARGS="-k"; fi
+  curl -fsS ${SOURCE_ARGS} -o /work/snapshot "$(cat /secret/source-url)"
+else
+  cp "/snapshots/${SNAPSHOT_FILE}" /work/snapshot
+fi
+if [ -f /secret/target-ca.crt ]; then TARGET_ARGS="--cacert /secret/target-ca.crt"; fi
+if [ "${TARGET_SKIP_SSL_VERIFY}" = "true" ]; then TARGET_ARGS="-k"; fi
+curl -fsS ${TARGET_ARGS} -T /work/snapshot "$(cat /secret/target-url)"
+if [ -f /secret/prune-urls ]; then
+  while read -r url; do
+    if [ -n "${url}" ]; then curl -fsS ${TARGET_ARGS} -X DELETE "${url}"; fi
+  done < /secret/prune-urls
+fi
+`
+
+// replication holds the presigned URLs handed to the job replicating a snapshot file to a target.
+type replication struct {
+	// source is the URL to download the snapshot file from, nil for local snapshots.
+	source *snapshotjob.PresignedURL
+	// target is the URL to upload the snapshot file to.
+	target *snapshotjob.PresignedURL
+	// prune maps the snapshots removed from the target by its retention to the URLs deleting their files.
+	prune map[string]string
+}
+
+// jobName returns the name of the job replicating the given snapshot to the given target at the given time.
+func jobName(snapshot *rkev1.ETCDSnapshot, target string, now time.Time) string {
+	return snapshotjob.Name("etcd-snapshot-replication", snapshot, now, target)
+}
+
+// newJob renders the job replicating the given snapshot to the given target. All URLs are read from the secret of the
+// job.
+func newJob(name string, snapshot *rkev1.ETCDSnapshot, target string, cluster *v3.Cluster, r *replication) (*batchv1.Job, error) {
+	env := []corev1.EnvVar{
+		{Name: "TARGET_SKIP_SSL_VERIFY", Value: strconv.FormatBool(r.target.SkipSSLVerify)},
+	}
+	if r.source != nil {
+		env = append(env, corev1.EnvVar{Name: "SOURCE_SKIP_SSL_VERIFY", Value: strconv.FormatBool(r.source.SkipSSLVerify)})
+	}
+
+	annotations := map[string]string{
+		targetAnnotation: target,
+	}
+	if len(r.prune) > 0 {
+		annotations[pruneAnnotation] = strings.Join(slices.Sorted(maps.Keys(r.prune)), ",")
+	}
+
+	return snapshotjob.New(name, snapshot, cluster, snapshotjob.Spec{
+		Annotations:           annotations,
+		BackoffLimit:          2,
+		ActiveDeadlineSeconds: 3600,
+		Download:              r.source != nil,
+		Secret:                true,
+		Fetch: corev1.Container{
+			Name:    replicateContainerName,
+			Command: []string{"sh", "-c", replicateScript},
+			Env:     env,
+		},
+	})
+}
+
+// secretData returns the data of the secret of the job replicating a snapshot, holding the presigned URLs of the
+// replication.
+func secretData(r *replication) map[string][]byte {
+	data := map[string][]byte{}
+	r.target.AddTo(data, targetPrefix)
+	if r.source != nil {
+		r.source.AddTo(data, sourcePrefix)
+	}
+	if len(r.prune) > 0 {
+		var urls []string
+		for _, name := range slices.Sorted(maps.Keys(r.prune)) {
+			urls = append(urls, r.prune[name])
+		}
+		data[pruneURLsKey] = []byte(strings.Join(urls, "\n") + "\n")
+	}
+	return data
+}
@@ -0,0 +1,54 @@
+package snapshotreplication
+
+import (
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr/etcds3"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotjob"
+)
+
+// presign generates the presigned URLs to replicate the given snapshot to the given target and to delete the files of
+// the given snapshots from it, and returns the location of the snapshot file in the target. S3 snapshots are downloaded
+// with the S3 configuration they were taken with, falling back to the S3 configuration of the etcd config of the
+// cluster. The target is only accessed with its own S3 configuration.
+func (h *handler) presign(cluster *provv1.Cluster, snapshot *rkev1.ETCDSnapshot, target *rkev1.ETCDSnapshotTarget, prune []*rkev1.ETCDSnapshot) (*replication, string, error) {
+	r := &replication{}
+
+	if snapshot.SnapshotFile.S3 != nil {
+		source, err := snapshotjob.PresignGet(h.secretCache, cluster, snapshot)
+		if err != nil {
+			return nil, "", err
+		}
+		r.source = source
+	}
+
+	client, err := etcds3.New(h.secretCache, cluster.Namespace, &target.S3)
+	if err != nil {
+		return nil, "", err
+	}
+	key := client.Key(snapshot.SnapshotFile.Name)
+	url, err := client.PresignPut(key, snapshotjob.PresignExpiry)
+	if err != nil {
+		return nil, "", err
+	}
+	r.target = &snapshotjob.PresignedURL{URL: url, CA: client.CA, SkipSSLVerify: client.SkipSSLVerify}
+
+	for _, pruned := range prune {
+		status := targetStatus(pruned, target.Name)
+		bucket, key, err := etcds3.ParseLocation(status.Location)
+		if err != nil || bucket != client.Bucket {
+			// the target was moved to another bucket since the snapshot was replicated
+			continue
+		}
+		url, err := client.PresignDelete(key, snapshotjob.PresignExpiry)
+		if err != nil {
+			return nil, "", err
+		}
+		if r.prune == nil {
+			r.prune = map[string]string{}
+		}
+		r.prune[pruned.Name] = url
+	}
+
+	return r, client.Location(key), nil
+}
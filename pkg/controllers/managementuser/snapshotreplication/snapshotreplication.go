@@ -0,0 +1,343 @@
+package snapshotreplication
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotjob"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	rkev1controllers "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/types/config"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/relatedresource"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// defaultRetention is the number of snapshots retained in a target when neither the target nor the cluster
+	// configure a retention, matching the default of the distributions.
+	defaultRetention = 5
+)
+
+type handler struct {
+	clusterName        string
+	clusters           provisioningcontrollers.ClusterController
+	mgmtClusterCache   mgmtcontrollers.ClusterCache
+	etcdSnapshotCache  rkev1controllers.ETCDSnapshotCache
+	etcdSnapshots      rkev1controllers.ETCDSnapshotClient
+	secretCache        corecontrollers.SecretCache
+	downstream         kubernetes.Interface
+	now                func() time.Time
+	presignReplication func(cluster *provv1.Cluster, snapshot *rkev1.ETCDSnapshot, target *rkev1.ETCDSnapshotTarget, prune []*rkev1.ETCDSnapshot) (*replication, string, error)
+}
+
+// Register sets up the etcd snapshot replication controller. For each snapshot target configured in the etcd config of
+// the provisioning cluster, it runs a job in the downstream cluster copying the latest successful snapshot to the
+// target, either after each snapshot or on the schedule of the target, and prunes the target to its retention. The
+// state of each replication is recorded in the status of the corresponding etcd snapshot object.
+func Register(ctx context.Context, userContext *config.UserContext) {
+	h := handler{
+		clusterName:       userContext.ClusterName,
+		clusters:          userContext.Management.Wrangler.Provisioning.Cluster(),
+		mgmtClusterCache:  userContext.Management.Wrangler.Mgmt.Cluster().Cache(),
+		etcdSnapshotCache: userContext.Management.Wrangler.RKE.ETCDSnapshot().Cache(),
+		etcdSnapshots:     userContext.Management.Wrangler.RKE.ETCDSnapshot(),
+		secretCache:       userContext.Management.Wrangler.Core.Secret().Cache(),
+		downstream:        userContext.K8sClient,
+		now:               time.Now,
+	}
+	h.presignReplication = h.presign
+
+	relatedresource.Watch(ctx, "etcd-snapshot-replication-trigger", func(namespace, _ string, obj runtime.Object) ([]relatedresource.Key, error) {
+		if snapshot, ok := obj.(*rkev1.ETCDSnapshot); ok && snapshot.Spec.ClusterName != "" {
+			return []relatedresource.Key{{
+				Namespace: namespace,
+				Name:      snapshot.Spec.ClusterName,
+			}}, nil
+		}
+		return nil, nil
+	}, userContext.Management.Wrangler.Provisioning.Cluster(), userContext.Management.Wrangler.RKE.ETCDSnapshot())
+
+	userContext.Management.Wrangler.Provisioning.Cluster().OnChange(ctx, "etcd-snapshot-replication", h.OnChange)
+}
+
+func (h *handler) OnChange(_ string, cluster *provv1.Cluster) (*provv1.Cluster, error) {
+	if cluster == nil || cluster.DeletionTimestamp != nil || cluster.Status.ClusterName != h.clusterName {
+		return cluster, nil
+	}
+
+	targets := getTargets(cluster)
+	if len(targets) == 0 {
+		return cluster, nil
+	}
+
+	snapshots, err := h.etcdSnapshotCache.List(cluster.Namespace, labels.SelectorFromSet(labels.Set{
+		capr.ClusterNameLabel: cluster.Name,
+	}))
+	if err != nil {
+		return cluster, err
+	}
+
+	var requeue time.Duration
+	for i := range targets {
+		after, err := h.reconcileTarget(cluster, &targets[i], snapshots)
+		if err != nil {
+			return cluster, err
+		}
+		if after > 0 && (requeue == 0 || after < requeue) {
+			requeue = after
+		}
+	}
+	if requeue > 0 {
+		h.clusters.EnqueueAfter(cluster.Namespace, cluster.Name, requeue)
+	}
+	return cluster, nil
+}
+
+// reconcileTarget checks the running replication to the given target, if any, and otherwise starts replicating the
+// latest successful snapshot when it is due. It returns how long to wait before reconciling the target again, zero if
+// only a change of the snapshots can make progress. Updated snapshots are replaced in the given slice.
+func (h *handler) reconcileTarget(cluster *provv1.Cluster, target *rkev1.ETCDSnapshotTarget, snapshots []*rkev1.ETCDSnapshot) (time.Duration, error) {
+	lastRun := cluster.CreationTimestamp.Time
+	for i, snapshot := range snapshots {
+		status := targetStatus(snapshot, target.Name)
+		if status == nil {
+			continue
+		}
+		if status.Phase == rkev1.ETCDSnapshotTargetPhaseReplicating {
+			if err := h.checkReplication(cluster, target, snapshots, i); err != nil {
+				return 0, err
+			}
+			return snapshotjob.PollInterval, nil
+		}
+		if status.UpdatedAt != nil && status.UpdatedAt.After(lastRun) {
+			lastRun = status.UpdatedAt.Time
+		}
+	}
+
+	latest := snapshotjob.LatestSnapshot(snapshots)
+	if latest < 0 {
+		return 0, nil
+	}
+	status := targetStatus(snapshots[latest], target.Name)
+
+	if target.ScheduleCron == "" {
+		// failed replications are not retried, the next snapshot is replicated instead
+		if status != nil {
+			return 0, nil
+		}
+	} else {
+		schedule, err := cron.ParseStandard(target.ScheduleCron)
+		if err != nil {
+			logrus.Errorf("[snapshotreplication] cluster %s/%s: invalid schedule %q of etcd snapshot target %s: %v", cluster.Namespace, cluster.Name, target.ScheduleCron, target.Name, err)
+			return 0, nil
+		}
+		now := h.now()
+		if next := schedule.Next(lastRun); now.Before(next) {
+			return next.Sub(now), nil
+		}
+		if status != nil && status.Phase == rkev1.ETCDSnapshotTargetPhaseReplicated {
+			return schedule.Next(now).Sub(now), nil
+		}
+	}
+
+	return snapshotjob.PollInterval, h.startReplication(cluster, target, snapshots, latest)
+}
+
+// startReplication creates the downstream job replicating the snapshot at index i to the given target, and marks its
+// replication as running.
+func (h *handler) startReplication(cluster *provv1.Cluster, target *rkev1.ETCDSnapshotTarget, snapshots []*rkev1.ETCDSnapshot, i int) error {
+	snapshot := snapshots[i]
+	prune := pruneCandidates(snapshots, snapshot, target.Name, retention(cluster, target))
+
+	mgmtCluster, err := h.mgmtClusterCache.Get(h.clusterName)
+	if err != nil {
+		return err
+	}
+
+	r, location, err := h.presignReplication(cluster, snapshot, target, prune)
+	if err != nil {
+		return h.recordResult(cluster, target, snapshots, i, &rkev1.ETCDSnapshotTargetStatus{Name: target.Name}, nil, fmt.Errorf("unable to presign snapshot file URLs: %w", err))
+	}
+
+	job, err := newJob(jobName(snapshot, target.Name, h.now()), snapshot, target.Name, mgmtCluster, r)
+	if err != nil {
+		return h.recordResult(cluster, target, snapshots, i, &rkev1.ETCDSnapshotTargetStatus{Name: target.Name, Location: location}, nil, err)
+	}
+
+	job, err = snapshotjob.Create(context.TODO(), h.downstream, job, secretData(r))
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("[snapshotreplication] cluster %s/%s: replicating etcd snapshot %s to target %s", cluster.Namespace, cluster.Name, snapshot.Name, target.Name)
+
+	snapshots[i], err = h.setTargetStatus(snapshot, rkev1.ETCDSnapshotTargetStatus{
+		Name:      target.Name,
+		Phase:     rkev1.ETCDSnapshotTargetPhaseReplicating,
+		Location:  location,
+		JobName:   job.Name,
+		UpdatedAt: &metav1.Time{Time: h.now()},
+	})
+	return err
+}
+
+// checkReplication checks whether the job of the running replication of the snapshot at index i completed, recording
+// its outcome if it did.
+func (h *handler) checkReplication(cluster *provv1.Cluster, target *rkev1.ETCDSnapshotTarget, snapshots []*rkev1.ETCDSnapshot, i int) error {
+	status := targetStatus(snapshots[i], target.Name).DeepCopy()
+
+	job, err := h.downstream.BatchV1().Jobs(snapshotjob.Namespace).Get(context.TODO(), status.JobName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return h.recordResult(cluster, target, snapshots, i, status, nil, fmt.Errorf("replication job %s was deleted", status.JobName))
+	} else if err != nil {
+		return err
+	}
+
+	switch {
+	case condition.Cond("Complete").IsTrue(job):
+		var pruned []string
+		if value := job.Annotations[pruneAnnotation]; value != "" {
+			pruned = strings.Split(value, ",")
+		}
+		return h.recordResult(cluster, target, snapshots, i, status, pruned, nil)
+	case condition.Cond("Failed").IsTrue(job):
+		return h.recordResult(cluster, target, snapshots, i, status, nil, h.jobFailure(job))
+	}
+	return nil
+}
+
+// jobFailure returns the reason the given replication job failed, taken from the termination message of its last pod.
+func (h *handler) jobFailure(job *batchv1.Job) error {
+	pods, err := snapshotjob.Pods(context.TODO(), h.downstream, job)
+	if err != nil {
+		return fmt.Errorf("replication job %s failed", job.Name)
+	}
+	for j := len(pods) - 1; j >= 0; j-- {
+		for _, container := range pods[j].Status.ContainerStatuses {
+			if terminated := container.State.Terminated; terminated != nil && terminated.ExitCode != 0 && terminated.Message != "" {
+				return fmt.Errorf("replication job %s failed: %s", job.Name, strings.TrimSpace(terminated.Message))
+			}
+		}
+	}
+	return fmt.Errorf("replication job %s failed: %s", job.Name, condition.Cond("Failed").GetMessage(job))
+}
+
+// recordResult completes the replication of the snapshot at index i, failing it if err is not nil. Once replicated,
+// the snapshots whose files were pruned from the target are marked as such.
+func (h *handler) recordResult(cluster *provv1.Cluster, target *rkev1.ETCDSnapshotTarget, snapshots []*rkev1.ETCDSnapshot, i int, status *rkev1.ETCDSnapshotTargetStatus, pruned []string, err error) error {
+	now := &metav1.Time{Time: h.now()}
+	status.UpdatedAt = now
+	if err != nil {
+		status.Phase = rkev1.ETCDSnapshotTargetPhaseFailed
+		status.Message = err.Error()
+		logrus.Warnf("[snapshotreplication] cluster %s/%s: replication of etcd snapshot %s to target %s failed: %v", cluster.Namespace, cluster.Name, snapshots[i].Name, target.Name, err)
+	} else {
+		status.Phase = rkev1.ETCDSnapshotTargetPhaseReplicated
+		status.Message = ""
+		logrus.Infof("[snapshotreplication] cluster %s/%s: replicated etcd snapshot %s to target %s", cluster.Namespace, cluster.Name, snapshots[i].Name, target.Name)
+	}
+
+	updated, err := h.setTargetStatus(snapshots[i], *status)
+	if err != nil {
+		return err
+	}
+	snapshots[i] = updated
+
+	for j, snapshot := range snapshots {
+		if !slices.Contains(pruned, snapshot.Name) {
+			continue
+		}
+		status := targetStatus(snapshot, target.Name)
+		if status == nil {
+			continue
+		}
+		status = status.DeepCopy()
+		status.Phase = rkev1.ETCDSnapshotTargetPhasePruned
+		status.UpdatedAt = now
+		if snapshots[j], err = h.setTargetStatus(snapshot, *status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTargetStatus sets the given target status on the snapshot, replacing the previous status of the target.
+func (h *handler) setTargetStatus(snapshot *rkev1.ETCDSnapshot, status rkev1.ETCDSnapshotTargetStatus) (*rkev1.ETCDSnapshot, error) {
+	snapshot = snapshot.DeepCopy()
+	for i := range snapshot.Status.Targets {
+		if snapshot.Status.Targets[i].Name == status.Name {
+			snapshot.Status.Targets[i] = status
+			return h.etcdSnapshots.UpdateStatus(snapshot)
+		}
+	}
+	snapshot.Status.Targets = append(snapshot.Status.Targets, status)
+	return h.etcdSnapshots.UpdateStatus(snapshot)
+}
+
+// pruneCandidates returns the snapshots replicated to the given target that fall beyond its retention once the given
+// snapshot is replicated to it, newest snapshots being retained first.
+func pruneCandidates(snapshots []*rkev1.ETCDSnapshot, replicating *rkev1.ETCDSnapshot, target string, retention int) []*rkev1.ETCDSnapshot {
+	var replicated []*rkev1.ETCDSnapshot
+	for _, snapshot := range snapshots {
+		if snapshot.Name == replicating.Name || snapshot.SnapshotFile.CreatedAt == nil {
+			continue
+		}
+		if status := targetStatus(snapshot, target); status != nil && status.Phase == rkev1.ETCDSnapshotTargetPhaseReplicated {
+			replicated = append(replicated, snapshot)
+		}
+	}
+	sort.Slice(replicated, func(i, j int) bool {
+		return replicated[i].SnapshotFile.CreatedAt.After(replicated[j].SnapshotFile.CreatedAt.Time)
+	})
+	// the snapshot being replicated counts towards the retention
+	if len(replicated) < retention {
+		return nil
+	}
+	return replicated[max(retention-1, 0):]
+}
+
+// retention returns the number of snapshots retained in the given target.
+func retention(cluster *provv1.Cluster, target *rkev1.ETCDSnapshotTarget) int {
+	if target.S3.Retention > 0 {
+		return target.S3.Retention
+	}
+	if cluster.Spec.RKEConfig.ETCD.SnapshotRetention > 0 {
+		return cluster.Spec.RKEConfig.ETCD.SnapshotRetention
+	}
+	return defaultRetention
+}
+
+// targetStatus returns the status of the replication of the given snapshot to the given target, or nil if it was never
+// replicated to it.
+func targetStatus(snapshot *rkev1.ETCDSnapshot, target string) *rkev1.ETCDSnapshotTargetStatus {
+	for i := range snapshot.Status.Targets {
+		if snapshot.Status.Targets[i].Name == target {
+			return &snapshot.Status.Targets[i]
+		}
+	}
+	return nil
+}
+
+func getTargets(cluster *provv1.Cluster) []rkev1.ETCDSnapshotTarget {
+	if cluster.Spec.RKEConfig == nil || cluster.Spec.RKEConfig.ETCD == nil {
+		return nil
+	}
+	return cluster.Spec.RKEConfig.ETCD.Targets
+}
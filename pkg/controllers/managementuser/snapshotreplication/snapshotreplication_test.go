@@ -0,0 +1,272 @@
+package snapshotreplication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotjob"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newSnapshot(name string, createdAt time.Time, targets ...rkev1.ETCDSnapshotTargetStatus) *rkev1.ETCDSnapshot {
+	return &rkev1.ETCDSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: name},
+		SnapshotFile: rkev1.ETCDSnapshotFile{
+			Name:      name,
+			Location:  "file:///var/lib/rancher/rke2/server/db/snapshots/" + name,
+			NodeName:  "node-1",
+			CreatedAt: &metav1.Time{Time: createdAt},
+			Status:    "successful",
+		},
+		Status: rkev1.ETCDSnapshotStatus{Targets: targets},
+	}
+}
+
+func newCluster(now time.Time, targets ...rkev1.ETCDSnapshotTarget) *provv1.Cluster {
+	return &provv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test", CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour))},
+		Spec: provv1.ClusterSpec{
+			RKEConfig: &provv1.RKEConfig{
+				ClusterConfiguration: rkev1.ClusterConfiguration{
+					ETCD: &rkev1.ETCD{SnapshotRetention: 2, Targets: targets},
+				},
+			},
+		},
+		Status: provv1.ClusterStatus{ClusterName: "c-m-test"},
+	}
+}
+
+func replicated(target string, updatedAt time.Time) rkev1.ETCDSnapshotTargetStatus {
+	return rkev1.ETCDSnapshotTargetStatus{
+		Name:      target,
+		Phase:     rkev1.ETCDSnapshotTargetPhaseReplicated,
+		Location:  "s3://dr/" + target,
+		UpdatedAt: &metav1.Time{Time: updatedAt},
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	now := time.Date(2024, 1, 8, 3, 5, 0, 0, time.UTC)
+
+	t.Run("replicates every new snapshot to targets without schedule and prunes beyond retention", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*provv1.Cluster, *provv1.ClusterList](ctrl)
+		mgmtClusters := fake.NewMockNonNamespacedCacheInterface[*v3.Cluster](ctrl)
+		snapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+		snapshots := fake.NewMockClientInterface[*rkev1.ETCDSnapshot, *rkev1.ETCDSnapshotList](ctrl)
+		downstream := k8sfake.NewSimpleClientset()
+
+		cluster := newCluster(now, rkev1.ETCDSnapshotTarget{Name: "dr"})
+		oldest := newSnapshot("oldest", now.Add(-3*time.Hour), replicated("dr", now.Add(-3*time.Hour)))
+		older := newSnapshot("older", now.Add(-2*time.Hour), replicated("dr", now.Add(-2*time.Hour)))
+		latest := newSnapshot("latest", now.Add(-time.Minute))
+
+		snapshotCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*rkev1.ETCDSnapshot{oldest, older, latest}, nil)
+		mgmtClusters.EXPECT().Get("c-m-test").Return(&v3.Cluster{}, nil)
+		snapshots.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(snapshot *rkev1.ETCDSnapshot) (*rkev1.ETCDSnapshot, error) {
+			assert.Equal(t, "latest", snapshot.Name)
+			require.Len(t, snapshot.Status.Targets, 1)
+			assert.Equal(t, rkev1.ETCDSnapshotTargetPhaseReplicating, snapshot.Status.Targets[0].Phase)
+			assert.Equal(t, "s3://dr/folder/latest", snapshot.Status.Targets[0].Location)
+			assert.NotEmpty(t, snapshot.Status.Targets[0].JobName)
+			return snapshot, nil
+		})
+		clusters.EXPECT().EnqueueAfter("fleet-default", "test", snapshotjob.PollInterval)
+
+		h := handler{
+			clusterName:       "c-m-test",
+			clusters:          clusters,
+			mgmtClusterCache:  mgmtClusters,
+			etcdSnapshotCache: snapshotCache,
+			etcdSnapshots:     snapshots,
+			downstream:        downstream,
+			now:               func() time.Time { return now },
+			presignReplication: func(_ *provv1.Cluster, snapshot *rkev1.ETCDSnapshot, _ *rkev1.ETCDSnapshotTarget, prune []*rkev1.ETCDSnapshot) (*replication, string, error) {
+				r := &replication{target: &snapshotjob.PresignedURL{URL: "https://dr/put"}, prune: map[string]string{}}
+				for _, pruned := range prune {
+					r.prune[pruned.Name] = "https://dr/delete/" + pruned.Name
+				}
+				return r, "s3://dr/folder/" + snapshot.Name, nil
+			},
+		}
+
+		_, err := h.OnChange("", cluster)
+		require.NoError(t, err)
+
+		jobs, err := downstream.BatchV1().Jobs(snapshotjob.Namespace).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, jobs.Items, 1)
+		assert.Equal(t, "oldest", jobs.Items[0].Annotations[pruneAnnotation])
+		assert.Equal(t, "dr", jobs.Items[0].Annotations[targetAnnotation])
+
+		secret, err := downstream.CoreV1().Secrets(snapshotjob.Namespace).Get(context.TODO(), jobs.Items[0].Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "https://dr/put", string(secret.Data[targetURLKey]))
+		assert.Equal(t, "https://dr/delete/oldest\n", string(secret.Data[pruneURLsKey]))
+	})
+
+	t.Run("does not replicate again to targets without schedule", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		snapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+
+		failed := rkev1.ETCDSnapshotTargetStatus{Name: "dr", Phase: rkev1.ETCDSnapshotTargetPhaseFailed}
+		snapshotCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*rkev1.ETCDSnapshot{
+			newSnapshot("latest", now.Add(-time.Minute), failed),
+		}, nil)
+
+		h := handler{
+			clusterName:       "c-m-test",
+			etcdSnapshotCache: snapshotCache,
+			now:               func() time.Time { return now },
+		}
+
+		_, err := h.OnChange("", newCluster(now, rkev1.ETCDSnapshotTarget{Name: "dr"}))
+		require.NoError(t, err)
+	})
+
+	t.Run("waits for the schedule of the target", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*provv1.Cluster, *provv1.ClusterList](ctrl)
+		snapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+
+		snapshotCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*rkev1.ETCDSnapshot{
+			newSnapshot("older", now.Add(-2*time.Hour), replicated("dr", now.Add(-5*time.Minute))),
+			newSnapshot("latest", now.Add(-time.Minute)),
+		}, nil)
+		clusters.EXPECT().EnqueueAfter("fleet-default", "test", 24*time.Hour-5*time.Minute)
+
+		h := handler{
+			clusterName:       "c-m-test",
+			clusters:          clusters,
+			etcdSnapshotCache: snapshotCache,
+			now:               func() time.Time { return now },
+		}
+
+		_, err := h.OnChange("", newCluster(now, rkev1.ETCDSnapshotTarget{Name: "dr", ScheduleCron: "0 3 * * *"}))
+		require.NoError(t, err)
+	})
+
+	t.Run("records completed replication and pruned snapshots", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*provv1.Cluster, *provv1.ClusterList](ctrl)
+		snapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+		snapshots := fake.NewMockClientInterface[*rkev1.ETCDSnapshot, *rkev1.ETCDSnapshotList](ctrl)
+		downstream := k8sfake.NewSimpleClientset(&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "replication",
+				Namespace:   snapshotjob.Namespace,
+				Annotations: map[string]string{pruneAnnotation: "oldest"},
+			},
+			Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+			}},
+		})
+
+		running := rkev1.ETCDSnapshotTargetStatus{Name: "dr", Phase: rkev1.ETCDSnapshotTargetPhaseReplicating, JobName: "replication"}
+		snapshotCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*rkev1.ETCDSnapshot{
+			newSnapshot("oldest", now.Add(-3*time.Hour), replicated("dr", now.Add(-3*time.Hour))),
+			newSnapshot("latest", now.Add(-time.Minute), running),
+		}, nil)
+		phases := map[string]rkev1.ETCDSnapshotTargetPhase{}
+		snapshots.EXPECT().UpdateStatus(gomock.Any()).Times(2).DoAndReturn(func(snapshot *rkev1.ETCDSnapshot) (*rkev1.ETCDSnapshot, error) {
+			require.Len(t, snapshot.Status.Targets, 1)
+			phases[snapshot.Name] = snapshot.Status.Targets[0].Phase
+			return snapshot, nil
+		})
+		clusters.EXPECT().EnqueueAfter("fleet-default", "test", snapshotjob.PollInterval)
+
+		h := handler{
+			clusterName:       "c-m-test",
+			clusters:          clusters,
+			etcdSnapshotCache: snapshotCache,
+			etcdSnapshots:     snapshots,
+			downstream:        downstream,
+			now:               func() time.Time { return now },
+		}
+
+		_, err := h.OnChange("", newCluster(now, rkev1.ETCDSnapshotTarget{Name: "dr"}))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]rkev1.ETCDSnapshotTargetPhase{
+			"latest": rkev1.ETCDSnapshotTargetPhaseReplicated,
+			"oldest": rkev1.ETCDSnapshotTargetPhasePruned,
+		}, phases)
+	})
+}
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Now()
+	snapshots := []*rkev1.ETCDSnapshot{
+		newSnapshot("a", now.Add(-4*time.Hour), replicated("dr", now)),
+		newSnapshot("b", now.Add(-3*time.Hour), replicated("dr", now)),
+		newSnapshot("c", now.Add(-2*time.Hour), rkev1.ETCDSnapshotTargetStatus{Name: "dr", Phase: rkev1.ETCDSnapshotTargetPhasePruned}),
+		newSnapshot("d", now.Add(-time.Hour), replicated("other", now)),
+		newSnapshot("e", now),
+	}
+
+	names := func(snapshots []*rkev1.ETCDSnapshot) []string {
+		var names []string
+		for _, snapshot := range snapshots {
+			names = append(names, snapshot.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"b", "a"}, names(pruneCandidates(snapshots, snapshots[4], "dr", 1)))
+	assert.Equal(t, []string{"a"}, names(pruneCandidates(snapshots, snapshots[4], "dr", 2)))
+	assert.Empty(t, pruneCandidates(snapshots, snapshots[4], "dr", 3))
+}
+
+func TestNewJob(t *testing.T) {
+	t.Run("local snapshot", func(t *testing.T) {
+		snapshot := newSnapshot("local", time.Now())
+		job, err := newJob("job", snapshot, "dr", &v3.Cluster{}, &replication{target: &snapshotjob.PresignedURL{URL: "https://dr/put"}})
+		require.NoError(t, err)
+
+		spec := job.Spec.Template.Spec
+		assert.Equal(t, "node-1", spec.NodeName)
+		require.Len(t, spec.Volumes, 3)
+		assert.Equal(t, "job", spec.Volumes[1].Secret.SecretName)
+		assert.Equal(t, "/var/lib/rancher/rke2/server/db/snapshots", spec.Volumes[2].HostPath.Path)
+		assert.NotContains(t, job.Annotations, pruneAnnotation)
+	})
+
+	t.Run("S3 snapshot", func(t *testing.T) {
+		snapshot := newSnapshot("s3", time.Now())
+		snapshot.SnapshotFile.Location = "s3://primary/folder/s3"
+		snapshot.SnapshotFile.NodeName = "s3"
+		r := &replication{
+			source: &snapshotjob.PresignedURL{URL: "https://primary/get", CA: []byte("ca"), SkipSSLVerify: true},
+			target: &snapshotjob.PresignedURL{URL: "https://dr/put"},
+		}
+		job, err := newJob("job", snapshot, "dr", &v3.Cluster{}, r)
+		require.NoError(t, err)
+
+		spec := job.Spec.Template.Spec
+		assert.Empty(t, spec.NodeName)
+		assert.Len(t, spec.Volumes, 2)
+		assert.Contains(t, spec.Containers[0].Env, corev1.EnvVar{Name: "SOURCE_SKIP_SSL_VERIFY", Value: "true"})
+
+		data := secretData(r)
+		assert.Equal(t, "https://primary/get", string(data["source-url"]))
+		assert.Equal(t, "ca", string(data["source-ca.crt"]))
+		assert.NotContains(t, data, "target-ca.crt")
+	})
+
+	t.Run("unsupported location", func(t *testing.T) {
+		snapshot := newSnapshot("unsupported", time.Now())
+		snapshot.SnapshotFile.Location = "gs://bucket/unsupported"
+		_, err := newJob("job", snapshot, "dr", &v3.Cluster{}, &replication{target: &snapshotjob.PresignedURL{}})
+		assert.Error(t, err)
+	})
+}
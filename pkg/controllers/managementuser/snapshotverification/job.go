@@ -0,0 +1,98 @@
+package snapshotverification
+
+import (
+	"strconv"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotjob"
+	"github.com/rancher/rancher/pkg/image"
+	"github.com/rancher/rancher/pkg/settings"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	fetchContainerName  = "fetch"
+	verifyContainerName = "verify"
+)
+
+// fetchScript copies the snapshot file from the snapshot directory of the node, or downloads it from the presigned S3
+// URL, and records its size and checksum before decompressing it.
+const fetchScript = `set -e
+if [ -f /secret/url ]; then
+  if [ -f /secret/ca.crt ]; then CURL_ARGS="--cacert /secret/ca.crt"; fi
+  if [ "${SKIP_SSL_VERIFY}" = "true" ]; then CURL_ARGS="-k"; fi
+  curl -fsS ${CURL_ARGS} -o /work/snapshot "$(cat /secret/url)"
+else
+  cp "/snapshots/${SNAPSHOT_FILE}" /work/snapshot
+fi
+stat -c %s /work/snapshot > /work/size
+sha256sum /work/snapshot | cut -d ' ' -f 1 > /work/sha256
+case "${SNAPSHOT_FILE}" in
+  *.zip) unzip -p /work/snapshot > /work/snapshot.db ;;
+  *) mv /work/snapshot /work/snapshot.db ;;
+esac
+`
+
+// verifyScript checks the integrity of the snapshot with etcdutl, optionally restores it into a throwaway etcd to count
+// its keys, and reports the outcome as a JSON termination message.
+const verifyScript = `fail() { printf '{"message":"%s"}' "$1" > /dev/termination-log; exit 1; }
+status=$(etcdutl snapshot status /work/snapshot.db -w json) || fail "etcdutl snapshot status failed"
+restore=null
+if [ "${RESTORE}" = "true" ]; then
+  etcdutl snapshot restore /work/snapshot.db --data-dir /work/restore > /dev/null 2>&1 || fail "etcdutl snapshot restore failed"
+  etcd --data-dir /work/restore > /dev/null 2>&1 &
+  pid=$!
+  for i in $(seq 1 30); do etcdctl endpoint health > /dev/null 2>&1 && break; sleep 2; done
+  restore=$(etcdctl get "" --prefix --count-only -w json) || fail "unable to count the keys of the restored snapshot"
+  kill ${pid}
+fi
+printf '{"size":%s,"sha256":"%s","status":%s,"restore":%s}' "$(cat /work/size)" "$(cat /work/sha256)" "${status}" "${restore}" > /dev/termination-log
+`
+
+// jobName returns the name of the job verifying the given snapshot at the given time.
+func jobName(snapshot *rkev1.ETCDSnapshot, now time.Time) string {
+	return snapshotjob.Name("etcd-snapshot-verification", snapshot, now)
+}
+
+// newJob renders the job verifying the given snapshot. S3 snapshots are downloaded through the given presigned URL,
+// stored in the secret of the job.
+func newJob(name string, snapshot *rkev1.ETCDSnapshot, cluster *v3.Cluster, restore bool, download *snapshotjob.PresignedURL) (*batchv1.Job, error) {
+	fetch := corev1.Container{
+		Name:    fetchContainerName,
+		Command: []string{"sh", "-c", fetchScript},
+	}
+	if download != nil {
+		fetch.Env = append(fetch.Env, corev1.EnvVar{Name: "SKIP_SSL_VERIFY", Value: strconv.FormatBool(download.SkipSSLVerify)})
+	}
+
+	return snapshotjob.New(name, snapshot, cluster, snapshotjob.Spec{
+		BackoffLimit:          0,
+		ActiveDeadlineSeconds: 1800,
+		Download:              download != nil,
+		Fetch:                 fetch,
+		Containers: []corev1.Container{
+			{
+				Name:    verifyContainerName,
+				Image:   image.ResolveWithCluster(settings.EtcdSnapshotVerificationImage.Get(), cluster),
+				Command: []string{"sh", "-c", verifyScript},
+				Env: []corev1.EnvVar{
+					{Name: "RESTORE", Value: strconv.FormatBool(restore)},
+				},
+			},
+		},
+	})
+}
+
+// secretData returns the data of the secret of the job verifying a snapshot, holding the presigned URL of S3
+// snapshot files.
+func secretData(download *snapshotjob.PresignedURL) map[string][]byte {
+	if download == nil {
+		return nil
+	}
+	data := map[string][]byte{}
+	download.AddTo(data, "")
+	return data
+}
@@ -0,0 +1,315 @@
+package snapshotverification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/capr"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotjob"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	rkev1controllers "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/metrics"
+	"github.com/rancher/rancher/pkg/types/config"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+type handler struct {
+	clusterName         string
+	clusters            provisioningcontrollers.ClusterController
+	mgmtClusterCache    mgmtcontrollers.ClusterCache
+	etcdSnapshotCache   rkev1controllers.ETCDSnapshotCache
+	etcdSnapshots       rkev1controllers.ETCDSnapshotClient
+	secretCache         corecontrollers.SecretCache
+	downstream          kubernetes.Interface
+	now                 func() time.Time
+	presignSnapshotFile func(cluster *provv1.Cluster, snapshot *rkev1.ETCDSnapshot) (*snapshotjob.PresignedURL, error)
+}
+
+// Register sets up the etcd snapshot verification controller. On the schedule configured in the etcd config of the
+// provisioning cluster, it runs a job in the downstream cluster checking the integrity of the latest successful snapshot,
+// and records the outcome in the status of the corresponding etcd snapshot object.
+func Register(ctx context.Context, userContext *config.UserContext) {
+	h := handler{
+		clusterName:       userContext.ClusterName,
+		clusters:          userContext.Management.Wrangler.Provisioning.Cluster(),
+		mgmtClusterCache:  userContext.Management.Wrangler.Mgmt.Cluster().Cache(),
+		etcdSnapshotCache: userContext.Management.Wrangler.RKE.ETCDSnapshot().Cache(),
+		etcdSnapshots:     userContext.Management.Wrangler.RKE.ETCDSnapshot(),
+		secretCache:       userContext.Management.Wrangler.Core.Secret().Cache(),
+		downstream:        userContext.K8sClient,
+		now:               time.Now,
+	}
+	h.presignSnapshotFile = func(cluster *provv1.Cluster, snapshot *rkev1.ETCDSnapshot) (*snapshotjob.PresignedURL, error) {
+		return snapshotjob.PresignGet(h.secretCache, cluster, snapshot)
+	}
+
+	userContext.Management.Wrangler.Provisioning.Cluster().OnChange(ctx, "etcd-snapshot-verification", h.OnChange)
+}
+
+func (h *handler) OnChange(_ string, cluster *provv1.Cluster) (*provv1.Cluster, error) {
+	if cluster == nil || cluster.DeletionTimestamp != nil || cluster.Status.ClusterName != h.clusterName {
+		return cluster, nil
+	}
+
+	verification := getVerification(cluster)
+	if verification == nil || verification.ScheduleCron == "" {
+		return cluster, nil
+	}
+
+	schedule, err := cron.ParseStandard(verification.ScheduleCron)
+	if err != nil {
+		logrus.Errorf("[snapshotverification] cluster %s/%s: invalid etcd snapshot verification schedule %q: %v", cluster.Namespace, cluster.Name, verification.ScheduleCron, err)
+		return cluster, nil
+	}
+
+	snapshots, err := h.etcdSnapshotCache.List(cluster.Namespace, labels.SelectorFromSet(labels.Set{
+		capr.ClusterNameLabel: cluster.Name,
+	}))
+	if err != nil {
+		return cluster, err
+	}
+
+	now := h.now()
+	lastRun := cluster.CreationTimestamp.Time
+	for _, snapshot := range snapshots {
+		status := snapshot.Status.Verification
+		if status == nil || status.StartedAt == nil {
+			continue
+		}
+		if status.Phase == rkev1.ETCDSnapshotVerificationPhaseRunning {
+			if running, err := h.checkVerification(cluster, snapshot, verification.Restore); err != nil {
+				return cluster, err
+			} else if running {
+				h.clusters.EnqueueAfter(cluster.Namespace, cluster.Name, snapshotjob.PollInterval)
+				return cluster, nil
+			}
+		}
+		if status.StartedAt.After(lastRun) {
+			lastRun = status.StartedAt.Time
+		}
+	}
+
+	if next := schedule.Next(lastRun); now.Before(next) {
+		h.clusters.EnqueueAfter(cluster.Namespace, cluster.Name, next.Sub(now))
+		return cluster, nil
+	}
+
+	latest := snapshotjob.LatestSnapshot(snapshots)
+	if latest < 0 {
+		logrus.Debugf("[snapshotverification] cluster %s/%s: no successful etcd snapshot to verify", cluster.Namespace, cluster.Name)
+		h.clusters.EnqueueAfter(cluster.Namespace, cluster.Name, schedule.Next(now).Sub(now))
+		return cluster, nil
+	}
+
+	if err := h.startVerification(cluster, snapshots[latest], verification.Restore); err != nil {
+		return cluster, err
+	}
+	h.clusters.EnqueueAfter(cluster.Namespace, cluster.Name, snapshotjob.PollInterval)
+	return cluster, nil
+}
+
+// startVerification creates the downstream job verifying the given snapshot and marks its verification as running.
+func (h *handler) startVerification(cluster *provv1.Cluster, snapshot *rkev1.ETCDSnapshot, restore bool) error {
+	mgmtCluster, err := h.mgmtClusterCache.Get(h.clusterName)
+	if err != nil {
+		return err
+	}
+
+	var download *snapshotjob.PresignedURL
+	if snapshot.SnapshotFile.S3 != nil {
+		if download, err = h.presignSnapshotFile(cluster, snapshot); err != nil {
+			return h.recordResult(cluster, snapshot, &rkev1.ETCDSnapshotVerificationStatus{
+				StartedAt: &metav1.Time{Time: h.now()},
+			}, fmt.Errorf("unable to download snapshot file: %w", err))
+		}
+	}
+
+	job, err := newJob(jobName(snapshot, h.now()), snapshot, mgmtCluster, restore, download)
+	if err != nil {
+		return h.recordResult(cluster, snapshot, &rkev1.ETCDSnapshotVerificationStatus{
+			StartedAt: &metav1.Time{Time: h.now()},
+		}, err)
+	}
+
+	job, err = snapshotjob.Create(context.TODO(), h.downstream, job, secretData(download))
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("[snapshotverification] cluster %s/%s: verifying etcd snapshot %s", cluster.Namespace, cluster.Name, snapshot.Name)
+
+	snapshot = snapshot.DeepCopy()
+	status := &rkev1.ETCDSnapshotVerificationStatus{
+		Phase:     rkev1.ETCDSnapshotVerificationPhaseRunning,
+		JobName:   job.Name,
+		StartedAt: &metav1.Time{Time: h.now()},
+	}
+	if snapshot.Status.Verification != nil {
+		// keep the checksum of the previous verification to detect changes of the snapshot file
+		status.SHA256 = snapshot.Status.Verification.SHA256
+	}
+	snapshot.Status.Verification = status
+	_, err = h.etcdSnapshots.UpdateStatus(snapshot)
+	return err
+}
+
+// checkVerification checks whether the job of a running verification completed, recording its outcome if it did.
+func (h *handler) checkVerification(cluster *provv1.Cluster, snapshot *rkev1.ETCDSnapshot, restore bool) (bool, error) {
+	status := snapshot.Status.Verification.DeepCopy()
+
+	job, err := h.downstream.BatchV1().Jobs(snapshotjob.Namespace).Get(context.TODO(), status.JobName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, h.recordResult(cluster, snapshot, status, fmt.Errorf("verification job %s was deleted", status.JobName))
+	} else if err != nil {
+		return false, err
+	}
+
+	if !snapshotjob.Finished(job) {
+		return true, nil
+	}
+
+	pods, err := snapshotjob.Pods(context.TODO(), h.downstream, job)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := getResult(pods)
+	if err == nil {
+		err = evaluate(snapshot, status, result, restore)
+	}
+	return false, h.recordResult(cluster, snapshot, status, err)
+}
+
+// recordResult completes the verification of the given snapshot, failing it if err is not nil, and reports the outcome.
+func (h *handler) recordResult(cluster *provv1.Cluster, snapshot *rkev1.ETCDSnapshot, status *rkev1.ETCDSnapshotVerificationStatus, err error) error {
+	now := h.now()
+	status.CompletedAt = &metav1.Time{Time: now}
+	if err != nil {
+		status.Phase = rkev1.ETCDSnapshotVerificationPhaseFailed
+		status.Message = err.Error()
+		logrus.Warnf("[snapshotverification] cluster %s/%s: verification of etcd snapshot %s failed: %v", cluster.Namespace, cluster.Name, snapshot.Name, err)
+	} else {
+		status.Phase = rkev1.ETCDSnapshotVerificationPhasePassed
+		status.Message = ""
+		logrus.Infof("[snapshotverification] cluster %s/%s: verification of etcd snapshot %s passed", cluster.Namespace, cluster.Name, snapshot.Name)
+	}
+	metrics.SetETCDSnapshotVerification(h.clusterName, err == nil, now)
+
+	snapshot = snapshot.DeepCopy()
+	snapshot.Status.Verification = status
+	_, err = h.etcdSnapshots.UpdateStatus(snapshot)
+	return err
+}
+
+// verificationResult is the termination message written by the verification container.
+type verificationResult struct {
+	Size    int64           `json:"size"`
+	SHA256  string          `json:"sha256"`
+	Message string          `json:"message"`
+	Status  *snapshotStatus `json:"status"`
+	Restore *keyCount       `json:"restore"`
+}
+
+// snapshotStatus is the output of `etcdutl snapshot status -w json`.
+type snapshotStatus struct {
+	Hash      int64  `json:"hash"`
+	Revision  int64  `json:"revision"`
+	TotalKey  int64  `json:"totalKey"`
+	TotalSize int64  `json:"totalSize"`
+	Version   string `json:"version"`
+}
+
+// keyCount is the output of `etcdctl get --count-only -w json`.
+type keyCount struct {
+	Count int64 `json:"count"`
+}
+
+// getResult extracts the result of a verification from the terminated containers of its job pods.
+func getResult(pods []corev1.Pod) (*verificationResult, error) {
+	for _, pod := range pods {
+		for _, container := range pod.Status.InitContainerStatuses {
+			if terminated := container.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+				return nil, fmt.Errorf("unable to fetch snapshot file: %s", snapshotjob.TerminationMessage(terminated))
+			}
+		}
+		for _, container := range pod.Status.ContainerStatuses {
+			terminated := container.State.Terminated
+			if container.Name != verifyContainerName || terminated == nil {
+				continue
+			}
+			result := &verificationResult{}
+			if err := json.Unmarshal([]byte(terminated.Message), result); err != nil {
+				return nil, fmt.Errorf("verification exited with code %d: %s", terminated.ExitCode, snapshotjob.TerminationMessage(terminated))
+			}
+			if result.Message != "" {
+				return nil, fmt.Errorf("verification failed: %s", result.Message)
+			}
+			if terminated.ExitCode != 0 {
+				return nil, fmt.Errorf("verification exited with code %d", terminated.ExitCode)
+			}
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("verification job did not report a result")
+}
+
+// evaluate checks the result of a verification against the snapshot it verified, and records the evidence in status.
+func evaluate(snapshot *rkev1.ETCDSnapshot, status *rkev1.ETCDSnapshotVerificationStatus, result *verificationResult, restore bool) error {
+	previousSHA256 := status.SHA256
+	status.SHA256 = result.SHA256
+	status.Size = result.Size
+	status.RestoredKeys = nil
+	if result.Status != nil {
+		status.Hash = result.Status.Hash
+		status.Revision = result.Status.Revision
+		status.TotalKeys = result.Status.TotalKey
+	}
+	if result.Restore != nil {
+		status.RestoredKeys = &result.Restore.Count
+	}
+
+	switch {
+	case result.SHA256 == "":
+		return fmt.Errorf("no checksum was reported for the snapshot file")
+	case previousSHA256 != "" && previousSHA256 != result.SHA256:
+		return fmt.Errorf("checksum of the snapshot file changed from %s to %s since its last verification", previousSHA256, result.SHA256)
+	case snapshot.SnapshotFile.Size > 0 && snapshot.SnapshotFile.Size != result.Size:
+		return fmt.Errorf("size of the snapshot file is %d bytes, expected %d bytes", result.Size, snapshot.SnapshotFile.Size)
+	case result.Status == nil:
+		return fmt.Errorf("no snapshot status was reported")
+	case result.Status.TotalKey == 0:
+		return fmt.Errorf("snapshot contains no keys")
+	}
+
+	if !restore {
+		return nil
+	}
+	switch {
+	case result.Restore == nil:
+		return fmt.Errorf("no key count was reported for the restored snapshot")
+	case result.Restore.Count == 0:
+		return fmt.Errorf("restored snapshot contains no keys")
+	case result.Restore.Count > result.Status.TotalKey:
+		return fmt.Errorf("restored snapshot contains %d keys, more than the %d keys reported by the snapshot status", result.Restore.Count, result.Status.TotalKey)
+	}
+	return nil
+}
+
+func getVerification(cluster *provv1.Cluster) *rkev1.ETCDSnapshotVerification {
+	if cluster.Spec.RKEConfig == nil || cluster.Spec.RKEConfig.ETCD == nil {
+		return nil
+	}
+	return cluster.Spec.RKEConfig.ETCD.SnapshotVerification
+}
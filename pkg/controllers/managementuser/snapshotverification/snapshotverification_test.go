@@ -0,0 +1,277 @@
+package snapshotverification
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/managementuser/snapshotjob"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newSnapshot(name string, createdAt time.Time, location string) *rkev1.ETCDSnapshot {
+	return &rkev1.ETCDSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: name},
+		SnapshotFile: rkev1.ETCDSnapshotFile{
+			Location:  location,
+			NodeName:  "node-1",
+			CreatedAt: &metav1.Time{Time: createdAt},
+			Status:    "successful",
+			Size:      1024,
+		},
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	now := time.Date(2024, 1, 8, 3, 5, 0, 0, time.UTC)
+	cluster := &provv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test", CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour))},
+		Spec: provv1.ClusterSpec{
+			RKEConfig: &provv1.RKEConfig{
+				ClusterConfiguration: rkev1.ClusterConfiguration{
+					ETCD: &rkev1.ETCD{
+						SnapshotVerification: &rkev1.ETCDSnapshotVerification{ScheduleCron: "0 3 * * *"},
+					},
+				},
+			},
+		},
+		Status: provv1.ClusterStatus{ClusterName: "c-m-test"},
+	}
+
+	older := newSnapshot("older", now.Add(-2*time.Hour), "file:///var/lib/rancher/rke2/server/db/snapshots/older")
+	latest := newSnapshot("latest", now.Add(-time.Hour), "file:///var/lib/rancher/rke2/server/db/snapshots/latest")
+	failed := newSnapshot("failed", now.Add(-time.Minute), "file:///var/lib/rancher/rke2/server/db/snapshots/failed")
+	failed.SnapshotFile.Status = "failed"
+
+	t.Run("starts verification of the latest successful snapshot when due", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*provv1.Cluster, *provv1.ClusterList](ctrl)
+		mgmtClusters := fake.NewMockNonNamespacedCacheInterface[*v3.Cluster](ctrl)
+		snapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+		snapshots := fake.NewMockClientInterface[*rkev1.ETCDSnapshot, *rkev1.ETCDSnapshotList](ctrl)
+		downstream := k8sfake.NewSimpleClientset()
+
+		snapshotCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*rkev1.ETCDSnapshot{older, latest, failed}, nil)
+		mgmtClusters.EXPECT().Get("c-m-test").Return(&v3.Cluster{}, nil)
+		snapshots.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(snapshot *rkev1.ETCDSnapshot) (*rkev1.ETCDSnapshot, error) {
+			assert.Equal(t, "latest", snapshot.Name)
+			require.NotNil(t, snapshot.Status.Verification)
+			assert.Equal(t, rkev1.ETCDSnapshotVerificationPhaseRunning, snapshot.Status.Verification.Phase)
+			assert.NotEmpty(t, snapshot.Status.Verification.JobName)
+			return snapshot, nil
+		})
+		clusters.EXPECT().EnqueueAfter("fleet-default", "test", snapshotjob.PollInterval)
+
+		h := handler{
+			clusterName:       "c-m-test",
+			clusters:          clusters,
+			mgmtClusterCache:  mgmtClusters,
+			etcdSnapshotCache: snapshotCache,
+			etcdSnapshots:     snapshots,
+			downstream:        downstream,
+			now:               func() time.Time { return now },
+		}
+		_, err := h.OnChange("", cluster)
+		require.NoError(t, err)
+
+		jobs, err := downstream.BatchV1().Jobs(snapshotjob.Namespace).List(context.TODO(), metav1.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, jobs.Items, 1)
+		assert.Equal(t, "node-1", jobs.Items[0].Spec.Template.Spec.NodeName)
+		assert.Equal(t, "fleet-default/latest", jobs.Items[0].Annotations[snapshotjob.SnapshotAnnotation])
+	})
+
+	t.Run("waits for the next scheduled run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*provv1.Cluster, *provv1.ClusterList](ctrl)
+		snapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+
+		verified := latest.DeepCopy()
+		verified.Status.Verification = &rkev1.ETCDSnapshotVerificationStatus{
+			Phase:     rkev1.ETCDSnapshotVerificationPhasePassed,
+			StartedAt: &metav1.Time{Time: now.Add(-5 * time.Minute)},
+		}
+		snapshotCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*rkev1.ETCDSnapshot{older, verified}, nil)
+		clusters.EXPECT().EnqueueAfter("fleet-default", "test", 24*time.Hour-5*time.Minute)
+
+		h := handler{
+			clusterName:       "c-m-test",
+			clusters:          clusters,
+			etcdSnapshotCache: snapshotCache,
+			now:               func() time.Time { return now },
+		}
+		_, err := h.OnChange("", cluster)
+		require.NoError(t, err)
+	})
+
+	t.Run("records the result of a completed job", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*provv1.Cluster, *provv1.ClusterList](ctrl)
+		snapshotCache := fake.NewMockCacheInterface[*rkev1.ETCDSnapshot](ctrl)
+		snapshots := fake.NewMockClientInterface[*rkev1.ETCDSnapshot, *rkev1.ETCDSnapshotList](ctrl)
+		downstream := k8sfake.NewSimpleClientset(
+			&batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Namespace: snapshotjob.Namespace, Name: "job"},
+				Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+					{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+				}},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: snapshotjob.Namespace, Name: "job-abcde", Labels: map[string]string{batchv1.JobNameLabel: "job"}},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+					{Name: verifyContainerName, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						Message: `{"size":1024,"sha256":"abc","status":{"hash":1,"revision":2,"totalKey":3,"totalSize":4096,"version":"3.5.0"},"restore":null}`,
+					}}},
+				}},
+			},
+		)
+
+		running := latest.DeepCopy()
+		running.Status.Verification = &rkev1.ETCDSnapshotVerificationStatus{
+			Phase:     rkev1.ETCDSnapshotVerificationPhaseRunning,
+			JobName:   "job",
+			StartedAt: &metav1.Time{Time: now.Add(-5 * time.Minute)},
+		}
+		snapshotCache.EXPECT().List("fleet-default", gomock.Any()).Return([]*rkev1.ETCDSnapshot{running}, nil)
+		snapshots.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(snapshot *rkev1.ETCDSnapshot) (*rkev1.ETCDSnapshot, error) {
+			assert.Equal(t, rkev1.ETCDSnapshotVerificationPhasePassed, snapshot.Status.Verification.Phase)
+			assert.Equal(t, "abc", snapshot.Status.Verification.SHA256)
+			assert.Equal(t, int64(3), snapshot.Status.Verification.TotalKeys)
+			assert.NotNil(t, snapshot.Status.Verification.CompletedAt)
+			return snapshot, nil
+		})
+		clusters.EXPECT().EnqueueAfter("fleet-default", "test", 24*time.Hour-5*time.Minute)
+
+		h := handler{
+			clusterName:       "c-m-test",
+			clusters:          clusters,
+			etcdSnapshotCache: snapshotCache,
+			etcdSnapshots:     snapshots,
+			downstream:        downstream,
+			now:               func() time.Time { return now },
+		}
+		_, err := h.OnChange("", cluster)
+		require.NoError(t, err)
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	result := func() *verificationResult {
+		return &verificationResult{Size: 1024, SHA256: "abc", Status: &snapshotStatus{TotalKey: 10}}
+	}
+	restored := func(count int64) *verificationResult {
+		r := result()
+		r.Restore = &keyCount{Count: count}
+		return r
+	}
+
+	tests := []struct {
+		name           string
+		previousSHA256 string
+		result         *verificationResult
+		restore        bool
+		wantErr        string
+	}{
+		{
+			name:   "passed",
+			result: result(),
+		},
+		{
+			name:           "checksum changed",
+			previousSHA256: "def",
+			result:         result(),
+			wantErr:        "checksum of the snapshot file changed from def to abc since its last verification",
+		},
+		{
+			name: "size mismatch",
+			result: func() *verificationResult {
+				r := result()
+				r.Size = 512
+				return r
+			}(),
+			wantErr: "size of the snapshot file is 512 bytes, expected 1024 bytes",
+		},
+		{
+			name:    "restore not reported",
+			result:  result(),
+			restore: true,
+			wantErr: "no key count was reported for the restored snapshot",
+		},
+		{
+			name:    "restore passed",
+			result:  restored(7),
+			restore: true,
+		},
+		{
+			name:    "restore with more keys than the snapshot",
+			result:  restored(11),
+			restore: true,
+			wantErr: "restored snapshot contains 11 keys, more than the 10 keys reported by the snapshot status",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshot := newSnapshot("snapshot", time.Now(), "file:///snapshots/snapshot")
+			status := &rkev1.ETCDSnapshotVerificationStatus{SHA256: tt.previousSHA256}
+			err := evaluate(snapshot, status, tt.result, tt.restore)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, "abc", status.SHA256)
+		})
+	}
+}
+
+func TestGetResult(t *testing.T) {
+	_, err := getResult([]corev1.Pod{{
+		Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{
+			{Name: fetchContainerName, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "curl: (22) 403"}}},
+		}},
+	}})
+	assert.EqualError(t, err, "unable to fetch snapshot file: curl: (22) 403")
+
+	_, err = getResult([]corev1.Pod{{
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: verifyContainerName, State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: `{"message":"etcdutl snapshot status failed"}`}}},
+		}},
+	}})
+	assert.EqualError(t, err, "verification failed: etcdutl snapshot status failed")
+
+	_, err = getResult(nil)
+	assert.EqualError(t, err, "verification job did not report a result")
+}
+
+func TestNewJob(t *testing.T) {
+	local := newSnapshot("local", time.Now(), "file:///var/lib/rancher/k3s/server/db/snapshots/etcd-snapshot-node-1-1700000000.zip")
+	job, err := newJob("job", local, &v3.Cluster{}, true, nil)
+	require.NoError(t, err)
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "node-1", podSpec.NodeName)
+	assert.Equal(t, "/var/lib/rancher/k3s/server/db/snapshots", podSpec.Volumes[1].HostPath.Path)
+	assert.Contains(t, podSpec.InitContainers[0].Env, corev1.EnvVar{Name: "SNAPSHOT_FILE", Value: "etcd-snapshot-node-1-1700000000.zip"})
+	assert.Contains(t, podSpec.Containers[0].Env, corev1.EnvVar{Name: "RESTORE", Value: "true"})
+
+	remote := newSnapshot("remote", time.Now(), "s3://bucket/folder/etcd-snapshot-node-1-1700000000")
+	remote.SnapshotFile.S3 = &rkev1.ETCDSnapshotS3{Bucket: "bucket", Folder: "folder"}
+	job, err = newJob("job", remote, &v3.Cluster{}, false, &snapshotjob.PresignedURL{})
+	require.NoError(t, err)
+	podSpec = job.Spec.Template.Spec
+	assert.Empty(t, podSpec.NodeName)
+	assert.Equal(t, "job", podSpec.Volumes[1].Secret.SecretName)
+
+	_, err = newJob("job", remote, &v3.Cluster{}, false, nil)
+	assert.EqualError(t, err, `unsupported snapshot location "s3://bucket/folder/etcd-snapshot-node-1-1700000000"`)
+}
@@ -11,8 +11,15 @@
 
 var leader = atomic.Bool{}
 
+type handler struct {
+	secrets drivers.SecretGetter
+}
+
 func Register(ctx context.Context, wrangler *wrangler.Context) {
-	wrangler.Mgmt.NodeDriver().OnChange(ctx, "custom-node-driver-handler", onChange)
+	h := &handler{
+		secrets: wrangler.Core.Secret().Cache(),
+	}
+	wrangler.Mgmt.NodeDriver().OnChange(ctx, "custom-node-driver-handler", h.onChange)
 
 	// when this pod becomes the leader, do not run the onChange code
 	wrangler.OnLeaderOrDie("nodedriver-register", func(ctx context.Context) error {
@@ -21,7 +28,7 @@ func Register(ctx context.Context, wrangler *wrangler.Context) {
 	})
 }
 
-func onChange(_ string, obj *v3.NodeDriver) (*v3.NodeDriver, error) {
+func (h *handler) onChange(_ string, obj *v3.NodeDriver) (*v3.NodeDriver, error) {
 	if obj == nil {
 		return obj, nil
 	}
@@ -41,6 +48,13 @@ func onChange(_ string, obj *v3.NodeDriver) (*v3.NodeDriver, error) {
 	}
 
 	driver := drivers.NewDynamicDriver(obj.Spec.Builtin, obj.Spec.DisplayName, obj.Spec.URL, obj.Spec.Checksum)
+	if !obj.Spec.Builtin {
+		verification, err := drivers.NewSignatureVerification(obj.Spec.Verification, h.secrets)
+		if err != nil {
+			return obj, err
+		}
+		driver.Verification = verification
+	}
 	if driver.Exists() {
 		return obj, nil
 	}
@@ -32,13 +32,29 @@ func TestOnChange(t *testing.T) {
 			expected:  &v3.NodeDriver{Spec: v3.NodeDriverSpec{Builtin: true}},
 			expectErr: false,
 		},
+		{
+			name: "active builtin with verification",
+			driver: &v3.NodeDriver{Spec: v3.NodeDriverSpec{Active: true, Builtin: true, Verification: &v3.DriverVerification{
+				Format:               "cosign",
+				SignatureURL:         "https://example.com/driver.sig",
+				PublicKeysSecretName: "cattle-global-data:driver-keys",
+			}}},
+			expected: &v3.NodeDriver{Spec: v3.NodeDriverSpec{Active: true, Builtin: true, Verification: &v3.DriverVerification{
+				Format:               "cosign",
+				SignatureURL:         "https://example.com/driver.sig",
+				PublicKeysSecretName: "cattle-global-data:driver-keys",
+			}}},
+			expectErr: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := onChange("", tt.driver)
+			result, err := (&handler{}).onChange("", tt.driver)
 			if tt.expectErr {
 				assert.NotNil(t, err)
+			} else {
+				assert.NoError(t, err)
 			}
 			assert.Equal(t, tt.expected, result)
 		})
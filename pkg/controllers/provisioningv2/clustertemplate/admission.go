@@ -0,0 +1,216 @@
+package clustertemplate
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/provisioningv2/clustertemplate"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/v3/pkg/apply"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+)
+
+// policyName is the name of the admission policy enforcing cluster templates, and of its binding.
+const policyName = "rancher-cluster-template-enforcement"
+
+var (
+	identifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// reserved are the CEL keywords, which are escaped in the names of the fields of the schema.
+	reserved = map[string]bool{
+		"true": true, "false": true, "null": true, "in": true, "as": true, "break": true, "const": true,
+		"continue": true, "else": true, "for": true, "function": true, "if": true, "import": true, "let": true,
+		"loop": true, "package": true, "namespace": true, "return": true, "var": true, "void": true, "while": true,
+	}
+)
+
+// admissionHandler enforces the cluster templates for the principals of the cluster-template-enforced-principals
+// setting with a ValidatingAdmissionPolicy, so that they are enforced whichever API the clusters are created or updated
+// with: enforced principals can't create clusters without a template, unbind them from it, or change the fields owned
+// by the revision they are bound to. The Steve store of clusters checks the same rules to report clearer errors.
+type admissionHandler struct {
+	apply         apply.Apply
+	settingCache  mgmtcontrollers.SettingCache
+	revisionCache provisioningcontrollers.ClusterTemplateRevisionCache
+}
+
+func (h *admissionHandler) onSetting(_ string, setting *v3.Setting) (*v3.Setting, error) {
+	if setting == nil || setting.Name != settings.ClusterTemplateEnforcedPrincipals.Name {
+		return setting, nil
+	}
+	return setting, h.sync()
+}
+
+func (h *admissionHandler) onRevision(_ string, revision *rancherv1.ClusterTemplateRevision) (*rancherv1.ClusterTemplateRevision, error) {
+	return revision, h.sync()
+}
+
+// sync applies the policy for the current enforced principals and revisions, or deletes it if no principal is enforced.
+func (h *admissionHandler) sync() error {
+	value := settings.ClusterTemplateEnforcedPrincipals.Default
+	setting, err := h.settingCache.Get(settings.ClusterTemplateEnforcedPrincipals.Name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	} else if err == nil && setting.Value != "" {
+		value = setting.Value
+	}
+	revisions, err := h.revisionCache.List("", labels.Everything())
+	if err != nil {
+		return err
+	}
+	objs, err := policy(clustertemplate.Principals(value), revisions)
+	if err != nil {
+		return err
+	}
+	return h.apply.ApplyObjects(objs...)
+}
+
+// policy returns the admission policy enforcing the cluster templates for the given principals and its binding, none if
+// there is no principal to enforce them for.
+func policy(principals []string, revisions []*rancherv1.ClusterTemplateRevision) ([]runtime.Object, error) {
+	if len(principals) == 0 {
+		return nil, nil
+	}
+
+	quoted := make([]string, 0, len(principals))
+	for _, principal := range principals {
+		quoted = append(quoted, strconv.Quote(principal))
+	}
+	list := "[" + strings.Join(quoted, ", ") + "]"
+
+	validations := []admissionregistrationv1.Validation{
+		{
+			Expression: "request.operation != 'CREATE' || has(object.spec.clusterTemplate)",
+			Message:    "clusters must be created from a cluster template",
+			Reason:     ptr.To(metav1.StatusReasonForbidden),
+		},
+		{
+			Expression: "request.operation != 'UPDATE' || !has(oldObject.spec.clusterTemplate) || " +
+				"has(object.spec.clusterTemplate) && object.spec.clusterTemplate.name == oldObject.spec.clusterTemplate.name",
+			Message: "clusters can not be unbound from their cluster template",
+			Reason:  ptr.To(metav1.StatusReasonForbidden),
+		},
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Namespace+"/"+revisions[i].Name < revisions[j].Namespace+"/"+revisions[j].Name
+	})
+	for _, revision := range revisions {
+		validation, err := ownedFieldsValidation(revision)
+		if err != nil {
+			return nil, err
+		}
+		if validation != nil {
+			validations = append(validations, *validation)
+		}
+	}
+
+	policy := &admissionregistrationv1.ValidatingAdmissionPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: policyName},
+		Spec: admissionregistrationv1.ValidatingAdmissionPolicySpec{
+			FailurePolicy: ptr.To(admissionregistrationv1.Fail),
+			MatchConstraints: &admissionregistrationv1.MatchResources{
+				ResourceRules: []admissionregistrationv1.NamedRuleWithOperations{{
+					RuleWithOperations: admissionregistrationv1.RuleWithOperations{
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{rancherv1.SchemeGroupVersion.Group},
+							APIVersions: []string{rancherv1.SchemeGroupVersion.Version},
+							Resources:   []string{"clusters"},
+						},
+					},
+				}},
+			},
+			MatchConditions: []admissionregistrationv1.MatchCondition{{
+				Name: "enforced-principal",
+				Expression: fmt.Sprintf("request.userInfo.username in %[1]s || request.userInfo.groups.exists(g, g in %[1]s) || "+
+					"'principalid' in request.userInfo.extra && request.userInfo.extra['principalid'].exists(p, p in %[1]s)", list),
+			}},
+			Variables: []admissionregistrationv1.Variable{
+				{
+					// the revision the update keeps the cluster bound to, none if it binds the cluster to another
+					// revision or answers its questions differently, which applies the revision again
+					Name: "boundRevision",
+					Expression: "request.operation == 'UPDATE' && has(oldObject.spec.clusterTemplate) && has(object.spec.clusterTemplate) && " +
+						"object.spec.clusterTemplate == oldObject.spec.clusterTemplate && has(oldObject.status) && " +
+						"has(oldObject.status.clusterTemplate) && has(oldObject.status.clusterTemplate.appliedRevision) ? " +
+						"object.metadata.namespace + '/' + oldObject.status.clusterTemplate.appliedRevision : ''",
+				},
+				{
+					// the fields which already drifted from the template aren't changed by the update
+					Name: "drift",
+					Expression: "request.operation == 'UPDATE' && has(oldObject.status) && has(oldObject.status.clusterTemplate) && " +
+						"has(oldObject.status.clusterTemplate.drift) ? oldObject.status.clusterTemplate.drift : []",
+				},
+			},
+			Validations: validations,
+		},
+	}
+	binding := &admissionregistrationv1.ValidatingAdmissionPolicyBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: policyName},
+		Spec: admissionregistrationv1.ValidatingAdmissionPolicyBindingSpec{
+			PolicyName:        policyName,
+			ValidationActions: []admissionregistrationv1.ValidationAction{admissionregistrationv1.Deny},
+		},
+	}
+	return []runtime.Object{policy, binding}, nil
+}
+
+// ownedFieldsValidation returns the validation rejecting the changes to the fields owned by a revision of the clusters
+// bound to it, nil if the revision doesn't own any field.
+func ownedFieldsValidation(revision *rancherv1.ClusterTemplateRevision) (*admissionregistrationv1.Validation, error) {
+	owned, optional, err := clustertemplate.OwnedFields(revision)
+	if err != nil {
+		return nil, fmt.Errorf("revision %s/%s: %w", revision.Namespace, revision.Name, err)
+	}
+	if len(owned) == 0 && len(optional) == 0 {
+		return nil, nil
+	}
+
+	var unchanged []string
+	for _, path := range owned {
+		unchanged = append(unchanged, fmt.Sprintf("(%s in variables.drift || %s == %s)",
+			strconv.Quote(path), selector("object.spec", path), selector("oldObject.spec", path)))
+	}
+	for _, path := range optional {
+		unchanged = append(unchanged, fmt.Sprintf("(!has(object.spec.clusterTemplate.answers) || !(%[1]s in object.spec.clusterTemplate.answers) || %[1]s in variables.drift || %[2]s == %[3]s)",
+			strconv.Quote(path), selector("object.spec", path), selector("oldObject.spec", path)))
+	}
+	return &admissionregistrationv1.Validation{
+		Expression: fmt.Sprintf("variables.boundRevision != %s || %s",
+			strconv.Quote(revision.Namespace+"/"+revision.Name), strings.Join(unchanged, " && ")),
+		Message: fmt.Sprintf("fields owned by cluster template %s can not be changed", revision.Spec.ClusterTemplateName),
+		Reason:  ptr.To(metav1.StatusReasonForbidden),
+	}, nil
+}
+
+// selector returns the CEL expression selecting the field at the given dotted path of an object as an optional value,
+// which is absent if any field along the path is. Numeric segments index lists, and the keys of maps which aren't
+// identifiers are selected by index.
+func selector(root, path string) string {
+	var b strings.Builder
+	b.WriteString(root)
+	for _, segment := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(segment); err == nil {
+			fmt.Fprintf(&b, "[?%s]", segment)
+		} else if !identifier.MatchString(segment) {
+			fmt.Fprintf(&b, "[?%s]", strconv.Quote(segment))
+		} else if reserved[segment] {
+			fmt.Fprintf(&b, ".?__%s__", segment)
+		} else {
+			b.WriteString(".?" + segment)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,181 @@
+package clustertemplate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// admit evaluates the policy against a request the way the API server does, returning the message of the first
+// failed validation, or an empty string if the request is admitted.
+func admit(t *testing.T, policy *admissionregistrationv1.ValidatingAdmissionPolicy, request map[string]any, obj, oldObj *rancherv1.Cluster) string {
+	t.Helper()
+	env, err := cel.NewEnv(
+		cel.OptionalTypes(),
+		cel.Variable("request", cel.DynType),
+		cel.Variable("object", cel.DynType),
+		cel.Variable("oldObject", cel.DynType),
+		cel.Variable("variables", cel.DynType),
+	)
+	require.NoError(t, err)
+	eval := func(expression string, vars map[string]any) any {
+		ast, issues := env.Compile(expression)
+		require.NoError(t, issues.Err(), expression)
+		program, err := env.Program(ast)
+		require.NoError(t, err)
+		out, _, err := program.Eval(vars)
+		require.NoError(t, err, expression)
+		return out.Value()
+	}
+
+	vars := map[string]any{
+		"request":   request,
+		"object":    toObject(t, obj),
+		"oldObject": toObject(t, oldObj),
+		"variables": map[string]any{},
+	}
+	for _, condition := range policy.Spec.MatchConditions {
+		if eval(condition.Expression, vars) != true {
+			return ""
+		}
+	}
+	variables := map[string]any{}
+	for _, variable := range policy.Spec.Variables {
+		variables[variable.Name] = eval(variable.Expression, vars)
+	}
+	vars["variables"] = variables
+	for _, validation := range policy.Spec.Validations {
+		if eval(validation.Expression, vars) != true {
+			return validation.Message
+		}
+	}
+	return ""
+}
+
+func toObject(t *testing.T, cluster *rancherv1.Cluster) any {
+	if cluster == nil {
+		return types.NullValue
+	}
+	data, err := json.Marshal(cluster)
+	require.NoError(t, err)
+	obj := map[string]any{}
+	require.NoError(t, json.Unmarshal(data, &obj))
+	return obj
+}
+
+func userRequest(operation, name string, groups ...string) map[string]any {
+	if groups == nil {
+		groups = []string{}
+	}
+	return map[string]any{
+		"operation": operation,
+		"userInfo": map[string]any{
+			"username": name,
+			"groups":   groups,
+			"extra":    map[string]any{"principalid": []string{"local://" + name}},
+		},
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	objs, err := policy(nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, objs, "no policy without enforced principals")
+
+	revision := &rancherv1.ClusterTemplateRevision{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "rke2-v1"},
+		Spec: rancherv1.ClusterTemplateRevisionSpec{
+			ClusterTemplateName: "rke2",
+			Questions:           []v3.Question{{Variable: "rkeConfig.machinePools.0.quantity", Type: "int"}},
+			ClusterConfig: rancherv1.ClusterSpec{
+				KubernetesVersion: "v1.33.1+rke2r1",
+				RKEConfig: &rancherv1.RKEConfig{
+					MachinePools: []rancherv1.RKEMachinePool{{Name: "pool", Quantity: ptr.To[int32](1)}},
+				},
+			},
+		},
+	}
+	objs, err = policy([]string{"u-enforced", "okta_group://developers"}, []*rancherv1.ClusterTemplateRevision{revision})
+	require.NoError(t, err)
+	require.Len(t, objs, 2)
+	vap, ok := objs[0].(*admissionregistrationv1.ValidatingAdmissionPolicy)
+	require.True(t, ok)
+	binding, ok := objs[1].(*admissionregistrationv1.ValidatingAdmissionPolicyBinding)
+	require.True(t, ok)
+	assert.Equal(t, vap.Name, binding.Spec.PolicyName)
+
+	unbound := &rancherv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "prod"},
+		Spec:       rancherv1.ClusterSpec{KubernetesVersion: "v1.33.1+rke2r1"},
+	}
+	bound := unbound.DeepCopy()
+	bound.Spec.ClusterTemplate = &rancherv1.ClusterTemplateBinding{Name: "rke2"}
+	bound.Spec.RKEConfig = &rancherv1.RKEConfig{
+		MachinePools: []rancherv1.RKEMachinePool{{Name: "pool", Quantity: ptr.To[int32](1)}},
+	}
+	bound.Status.ClusterTemplate = &rancherv1.ClusterTemplateStatus{AppliedRevision: "rke2-v1"}
+
+	// requests made directly to the Kubernetes API, with kubectl or through /k8s/clusters/local, don't go through the
+	// Steve store and are only checked by the policy
+	t.Run("create without template", func(t *testing.T) {
+		assert.Equal(t, "clusters must be created from a cluster template", admit(t, vap, userRequest("CREATE", "u-enforced"), unbound, nil))
+		assert.Equal(t, "clusters must be created from a cluster template", admit(t, vap, userRequest("CREATE", "u-other", "okta_group://developers"), unbound, nil))
+		assert.Empty(t, admit(t, vap, userRequest("CREATE", "u-other"), unbound, nil), "principals which aren't enforced")
+	})
+	t.Run("create from template", func(t *testing.T) {
+		assert.Empty(t, admit(t, vap, userRequest("CREATE", "u-enforced"), bound, nil))
+	})
+	t.Run("unbind", func(t *testing.T) {
+		updated := bound.DeepCopy()
+		updated.Spec.ClusterTemplate = nil
+		assert.Equal(t, "clusters can not be unbound from their cluster template", admit(t, vap, userRequest("UPDATE", "u-enforced"), updated, bound))
+
+		updated.Spec.ClusterTemplate = &rancherv1.ClusterTemplateBinding{Name: "other"}
+		assert.Equal(t, "clusters can not be unbound from their cluster template", admit(t, vap, userRequest("UPDATE", "u-enforced"), updated, bound))
+	})
+	t.Run("change owned fields", func(t *testing.T) {
+		updated := bound.DeepCopy()
+		updated.Spec.KubernetesVersion = "v1.34.1+rke2r1"
+		assert.Equal(t, "fields owned by cluster template rke2 can not be changed", admit(t, vap, userRequest("UPDATE", "u-enforced"), updated, bound))
+		assert.Empty(t, admit(t, vap, userRequest("UPDATE", "u-other"), updated, bound), "principals which aren't enforced")
+
+		updated = bound.DeepCopy()
+		updated.Spec.RKEConfig.MachinePools[0].Quantity = ptr.To[int32](3)
+		assert.Equal(t, "fields owned by cluster template rke2 can not be changed", admit(t, vap, userRequest("UPDATE", "u-enforced"), updated, bound))
+	})
+	t.Run("change drifted fields", func(t *testing.T) {
+		drifted := bound.DeepCopy()
+		drifted.Spec.KubernetesVersion = "v1.32.1+rke2r1"
+		drifted.Status.ClusterTemplate.Drift = []string{"kubernetesVersion"}
+		updated := drifted.DeepCopy()
+		updated.Spec.KubernetesVersion = "v1.33.1+rke2r1"
+		assert.Empty(t, admit(t, vap, userRequest("UPDATE", "u-enforced"), updated, drifted))
+	})
+	t.Run("change other fields", func(t *testing.T) {
+		updated := bound.DeepCopy()
+		updated.Spec.DefaultClusterRoleForProjectMembers = "project-member"
+		assert.Empty(t, admit(t, vap, userRequest("UPDATE", "u-enforced"), updated, bound))
+	})
+	t.Run("upgrade", func(t *testing.T) {
+		updated := bound.DeepCopy()
+		updated.Spec.ClusterTemplate.Revision = "rke2-v2"
+		updated.Spec.KubernetesVersion = "v1.34.1+rke2r1"
+		assert.Empty(t, admit(t, vap, userRequest("UPDATE", "u-enforced"), updated, bound))
+	})
+}
+
+func TestSelector(t *testing.T) {
+	assert.Equal(t, "object.spec.?kubernetesVersion", selector("object.spec", "kubernetesVersion"))
+	assert.Equal(t, "object.spec.?rkeConfig.?machinePools[?0].?quantity", selector("object.spec", "rkeConfig.machinePools.0.quantity"))
+	assert.Equal(t, `object.spec.?rkeConfig.?chartValues[?"rke2-cilium"]`, selector("object.spec", "rkeConfig.chartValues.rke2-cilium"))
+	assert.Equal(t, "object.spec.?__namespace__", selector("object.spec", "namespace"))
+}
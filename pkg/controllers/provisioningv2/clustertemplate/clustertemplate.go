@@ -0,0 +1,158 @@
+// Package clustertemplate applies the revisions of cluster templates to the provisioning clusters bound to them. The
+// rendered configuration of the revision is written to the spec of the cluster whenever it changes, when the cluster
+// is upgraded to another revision or the answers of the cluster change for instance, and the fields of the spec that
+// differ from it in between are reported as drift. The templates are enforced for the principals of the
+// cluster-template-enforced-principals setting by an admission policy.
+package clustertemplate
+
+import (
+	"context"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/provisioningv2/clustertemplate"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/v3/pkg/relatedresource"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const byTemplate = "byClusterTemplate"
+
+type handler struct {
+	clusters      provisioningcontrollers.ClusterController
+	clusterCache  provisioningcontrollers.ClusterCache
+	templateCache provisioningcontrollers.ClusterTemplateCache
+	revisionCache provisioningcontrollers.ClusterTemplateRevisionCache
+}
+
+func Register(ctx context.Context, clients *wrangler.CAPIContext) {
+	h := &handler{
+		clusters:      clients.Provisioning.Cluster(),
+		clusterCache:  clients.Provisioning.Cluster().Cache(),
+		templateCache: clients.Provisioning.ClusterTemplate().Cache(),
+		revisionCache: clients.Provisioning.ClusterTemplateRevision().Cache(),
+	}
+
+	clients.Provisioning.Cluster().Cache().AddIndexer(byTemplate, byTemplateIndex)
+	clients.Provisioning.Cluster().OnChange(ctx, "cluster-template", h.OnChange)
+	relatedresource.Watch(ctx, "cluster-template-trigger", h.resolveClusters,
+		clients.Provisioning.Cluster(),
+		clients.Provisioning.ClusterTemplate(),
+		clients.Provisioning.ClusterTemplateRevision())
+
+	a := &admissionHandler{
+		apply:         clients.Apply.WithSetID("cluster-template-admission"),
+		settingCache:  clients.Mgmt.Setting().Cache(),
+		revisionCache: clients.Provisioning.ClusterTemplateRevision().Cache(),
+	}
+	clients.Mgmt.Setting().OnChange(ctx, "cluster-template-admission", a.onSetting)
+	clients.Provisioning.ClusterTemplateRevision().OnChange(ctx, "cluster-template-admission", a.onRevision)
+}
+
+func byTemplateIndex(cluster *rancherv1.Cluster) ([]string, error) {
+	if cluster.Spec.ClusterTemplate == nil {
+		return nil, nil
+	}
+	return []string{cluster.Namespace + "/" + cluster.Spec.ClusterTemplate.Name}, nil
+}
+
+// resolveClusters enqueues the clusters bound to a changed template, or to the template of a changed revision.
+func (h *handler) resolveClusters(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+	template := name
+	switch obj := obj.(type) {
+	case *rancherv1.ClusterTemplate:
+	case *rancherv1.ClusterTemplateRevision:
+		template = obj.Spec.ClusterTemplateName
+	default:
+		return nil, nil
+	}
+
+	clusters, err := h.clusterCache.GetByIndex(byTemplate, namespace+"/"+template)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]relatedresource.Key, 0, len(clusters))
+	for _, cluster := range clusters {
+		keys = append(keys, relatedresource.Key{Namespace: cluster.Namespace, Name: cluster.Name})
+	}
+	return keys, nil
+}
+
+// OnChange applies the revision of the template of the cluster to its spec if its rendered configuration changed
+// since it was last applied, and records the fields of the spec that drifted from it.
+func (h *handler) OnChange(_ string, cluster *rancherv1.Cluster) (*rancherv1.Cluster, error) {
+	if cluster == nil || cluster.DeletionTimestamp != nil {
+		return cluster, nil
+	}
+	if cluster.Spec.ClusterTemplate == nil {
+		if cluster.Status.ClusterTemplate == nil {
+			return cluster, nil
+		}
+		cluster = cluster.DeepCopy()
+		cluster.Status.ClusterTemplate = nil
+		return h.clusters.UpdateStatus(cluster)
+	}
+
+	status := rancherv1.ClusterTemplateStatus{}
+	if cluster.Status.ClusterTemplate != nil {
+		status.AppliedRevision = cluster.Status.ClusterTemplate.AppliedRevision
+		status.AppliedHash = cluster.Status.ClusterTemplate.AppliedHash
+	}
+
+	updated, err := h.apply(cluster, &status)
+	if err != nil {
+		return cluster, err
+	}
+	if !equality.Semantic.DeepEqual(updated.Status.ClusterTemplate, &status) {
+		updated = updated.DeepCopy()
+		updated.Status.ClusterTemplate = &status
+		if updated, err = h.clusters.UpdateStatus(updated); err != nil {
+			return cluster, err
+		}
+	}
+	return updated, nil
+}
+
+// apply renders the revision of the cluster and applies it to the spec of the cluster if needed. The reasons the
+// revision can't be applied are recorded in the status rather than returned, as they are fixed by changing the
+// template, which requeues the cluster.
+func (h *handler) apply(cluster *rancherv1.Cluster, status *rancherv1.ClusterTemplateStatus) (*rancherv1.Cluster, error) {
+	binding := cluster.Spec.ClusterTemplate
+	revision, err := clustertemplate.Resolve(h.templateCache, h.revisionCache, cluster.Namespace, binding)
+	if err != nil {
+		status.Message = err.Error()
+		return cluster, nil
+	}
+	config, err := clustertemplate.Render(revision, binding.Answers)
+	if err != nil {
+		status.Message = "revision " + revision.Name + ": " + err.Error()
+		return cluster, nil
+	}
+
+	hash := config.Hash()
+	if status.AppliedRevision != revision.Name || status.AppliedHash != hash {
+		if status.AppliedRevision != revision.Name && !clustertemplate.Enabled(revision) {
+			status.Message = "revision " + revision.Name + " is disabled"
+			status.Drift, err = config.Drift(cluster.Spec)
+			return cluster, err
+		}
+
+		updated := cluster.DeepCopy()
+		if err := config.Apply(&updated.Spec); err != nil {
+			return cluster, err
+		}
+		if !equality.Semantic.DeepEqual(updated.Spec, cluster.Spec) {
+			logrus.Infof("[clustertemplate] cluster %s/%s: applying revision %s of cluster template %s", cluster.Namespace, cluster.Name, revision.Name, binding.Name)
+			if cluster, err = h.clusters.Update(updated); err != nil {
+				return nil, err
+			}
+		}
+		status.AppliedRevision = revision.Name
+		status.AppliedHash = hash
+	}
+
+	status.Drift, err = config.Drift(cluster.Spec)
+	return cluster, err
+}
@@ -4,10 +4,12 @@
 	"context"
 
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/cluster"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/clustertemplate"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/fleetcluster"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/fleetworkspace"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/harvestercleanup"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/machineconfigcleanup"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/machinepoolscaling"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/managedchart"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/provisioningcluster"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/provisioninglog"
@@ -32,6 +34,8 @@ func Register(ctx context.Context, clients *wrangler.CAPIContext, kubeconfigMana
 		secret.Register(ctx, clients)
 	}
 	provisioningcluster.Register(ctx, clients)
+	clustertemplate.Register(ctx, clients)
+	machinepoolscaling.Register(ctx, clients)
 	provisioninglog.Register(ctx, clients)
 	machineconfigcleanup.Register(ctx, clients)
 
@@ -0,0 +1,191 @@
+// Package machinepoolscaling applies the scheduled scaling rules of machine pools. At each scheduled time of a rule, the
+// quantity and autoscaler bounds set by the rule are written to the machine pool of the provisioning cluster, which
+// propagates them to the corresponding machine deployment.
+package machinepoolscaling
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/robfig/cron"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+// lookbacks bound the search for the previous scheduled time of a rule. Standard cron schedules fire at least once a
+// year, except for those only matching leap days, which are then only considered once they fire.
+var lookbacks = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 31 * 24 * time.Hour, 366 * 24 * time.Hour}
+
+type handler struct {
+	clusters provisioningcontrollers.ClusterController
+	now      func() time.Time
+}
+
+func Register(ctx context.Context, clients *wrangler.CAPIContext) {
+	h := &handler{
+		clusters: clients.Provisioning.Cluster(),
+		now:      time.Now,
+	}
+
+	clients.Provisioning.Cluster().OnChange(ctx, "machine-pool-scaling", h.OnChange)
+}
+
+// OnChange scales each machine pool of the cluster according to the rule that was most recently scheduled, unless the
+// machine pool was already scaled at that time, so that manual changes are kept until the next scheduled time. The last
+// and next scaling of each machine pool are recorded in the status of the cluster.
+func (h *handler) OnChange(_ string, cluster *rancherv1.Cluster) (*rancherv1.Cluster, error) {
+	if cluster == nil || cluster.DeletionTimestamp != nil || cluster.Spec.RKEConfig == nil {
+		return cluster, nil
+	}
+
+	now := h.now()
+	updated := cluster.DeepCopy()
+	var (
+		statuses []rancherv1.RKEMachinePoolScalingStatus
+		requeue  time.Duration
+	)
+	for i := range updated.Spec.RKEConfig.MachinePools {
+		pool := &updated.Spec.RKEConfig.MachinePools[i]
+		if len(pool.ScalingRules) == 0 {
+			continue
+		}
+
+		status := rancherv1.RKEMachinePoolScalingStatus{Name: pool.Name}
+		if previous := poolStatus(cluster, pool.Name); previous != nil {
+			status.LastRule = previous.LastRule
+			status.LastScaleTime = previous.LastScaleTime
+		}
+
+		s, err := evaluate(pool.ScalingRules, now)
+		if err != nil {
+			status.Message = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+
+		if s.last != nil && (status.LastScaleTime == nil || s.lastTime.After(status.LastScaleTime.Time)) {
+			scale(pool, s.last)
+			status.LastRule = s.last.Name
+			status.LastScaleTime = &metav1.Time{Time: s.lastTime}
+			logrus.Infof("[machinepoolscaling] cluster %s/%s: scaling machine pool %s according to rule %s scheduled at %s", cluster.Namespace, cluster.Name, pool.Name, s.last.Name, s.lastTime.Format(time.RFC3339))
+		}
+		if s.next != nil {
+			status.NextRule = s.next.Name
+			status.NextScaleTime = &metav1.Time{Time: s.nextTime}
+			if after := s.nextTime.Sub(now); requeue == 0 || after < requeue {
+				requeue = after
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	var err error
+	if !equality.Semantic.DeepEqual(updated.Spec, cluster.Spec) {
+		if updated, err = h.clusters.Update(updated); err != nil {
+			return cluster, err
+		}
+	}
+	if !equality.Semantic.DeepEqual(updated.Status.MachinePoolScaling, statuses) {
+		updated = updated.DeepCopy()
+		updated.Status.MachinePoolScaling = statuses
+		if updated, err = h.clusters.UpdateStatus(updated); err != nil {
+			return cluster, err
+		}
+	}
+
+	if requeue > 0 {
+		h.clusters.EnqueueAfter(cluster.Namespace, cluster.Name, requeue)
+	}
+	return updated, nil
+}
+
+// schedule holds the rules of a machine pool that were scheduled last and will be scheduled next.
+type schedule struct {
+	last     *rancherv1.RKEMachinePoolScalingRule
+	lastTime time.Time
+	next     *rancherv1.RKEMachinePoolScalingRule
+	nextTime time.Time
+}
+
+// evaluate returns the rules that were scheduled most recently and will be scheduled next at the given time. Of rules
+// scheduled at the same time, the last one in the list wins.
+func evaluate(rules []rancherv1.RKEMachinePoolScalingRule, now time.Time) (schedule, error) {
+	var s schedule
+	for i := range rules {
+		rule := &rules[i]
+
+		location := time.UTC
+		if rule.TimeZone != "" {
+			var err error
+			if location, err = time.LoadLocation(rule.TimeZone); err != nil {
+				return s, fmt.Errorf("invalid time zone %q of rule %s: %w", rule.TimeZone, rule.Name, err)
+			}
+		}
+		cronSchedule, err := cron.ParseStandard(rule.Schedule)
+		if err != nil {
+			return s, fmt.Errorf("invalid schedule %q of rule %s: %w", rule.Schedule, rule.Name, err)
+		}
+
+		local := now.In(location)
+		if last, ok := lastScheduled(cronSchedule, local); ok && (s.last == nil || !last.Before(s.lastTime)) {
+			s.last, s.lastTime = rule, last.UTC()
+		}
+		if next := cronSchedule.Next(local); !next.IsZero() && (s.next == nil || next.Before(s.nextTime)) {
+			s.next, s.nextTime = rule, next.UTC()
+		}
+	}
+	return s, nil
+}
+
+// lastScheduled returns the latest time the given schedule fired at or before now.
+func lastScheduled(schedule cron.Schedule, now time.Time) (time.Time, bool) {
+	for _, lookback := range lookbacks {
+		t := schedule.Next(now.Add(-lookback))
+		if t.IsZero() || t.After(now) {
+			continue
+		}
+		for {
+			next := schedule.Next(t)
+			if next.IsZero() || next.After(now) {
+				return t, true
+			}
+			t = next
+		}
+	}
+	return time.Time{}, false
+}
+
+// scale writes the quantity and autoscaler bounds set by the given rule to the machine pool, keeping its quantity
+// within its autoscaler bounds.
+func scale(pool *rancherv1.RKEMachinePool, rule *rancherv1.RKEMachinePoolScalingRule) {
+	if rule.Quantity != nil {
+		pool.Quantity = ptr.To(*rule.Quantity)
+	}
+	if rule.AutoscalingMinSize != nil && rule.AutoscalingMaxSize != nil {
+		pool.AutoscalingMinSize = ptr.To(*rule.AutoscalingMinSize)
+		pool.AutoscalingMaxSize = ptr.To(*rule.AutoscalingMaxSize)
+	}
+	if pool.Quantity == nil || pool.AutoscalingMinSize == nil || pool.AutoscalingMaxSize == nil {
+		return
+	}
+	if *pool.Quantity < *pool.AutoscalingMinSize {
+		pool.Quantity = ptr.To(*pool.AutoscalingMinSize)
+	} else if *pool.Quantity > *pool.AutoscalingMaxSize {
+		pool.Quantity = ptr.To(*pool.AutoscalingMaxSize)
+	}
+}
+
+func poolStatus(cluster *rancherv1.Cluster, name string) *rancherv1.RKEMachinePoolScalingStatus {
+	for i := range cluster.Status.MachinePoolScaling {
+		if cluster.Status.MachinePoolScaling[i].Name == name {
+			return &cluster.Status.MachinePoolScaling[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package machinepoolscaling
+
+import (
+	"testing"
+	"time"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/robfig/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+var (
+	// Monday, 2024-01-08 20:00 UTC
+	monday = time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+
+	nightly = []rancherv1.RKEMachinePoolScalingRule{
+		{Name: "scale-down", Schedule: "0 19 * * *", Quantity: ptr.To[int32](0)},
+		{Name: "scale-up", Schedule: "0 7 * * 1-5", Quantity: ptr.To[int32](3)},
+	}
+)
+
+func newCluster(quantity int32, rules []rancherv1.RKEMachinePoolScalingRule, status ...rancherv1.RKEMachinePoolScalingStatus) *rancherv1.Cluster {
+	return &rancherv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Name: "test"},
+		Spec: rancherv1.ClusterSpec{
+			RKEConfig: &rancherv1.RKEConfig{
+				MachinePools: []rancherv1.RKEMachinePool{
+					{Name: "workers", WorkerRole: true, Quantity: ptr.To(quantity), ScalingRules: rules},
+					{Name: "control-plane", ControlPlaneRole: true, EtcdRole: true, Quantity: ptr.To[int32](1)},
+				},
+			},
+		},
+		Status: rancherv1.ClusterStatus{MachinePoolScaling: status},
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	t.Run("scales machine pool according to the most recent rule", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*rancherv1.Cluster, *rancherv1.ClusterList](ctrl)
+
+		clusters.EXPECT().Update(gomock.Any()).DoAndReturn(func(cluster *rancherv1.Cluster) (*rancherv1.Cluster, error) {
+			assert.Equal(t, int32(0), *cluster.Spec.RKEConfig.MachinePools[0].Quantity)
+			assert.Equal(t, int32(1), *cluster.Spec.RKEConfig.MachinePools[1].Quantity)
+			return cluster, nil
+		})
+		clusters.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(cluster *rancherv1.Cluster) (*rancherv1.Cluster, error) {
+			require.Len(t, cluster.Status.MachinePoolScaling, 1)
+			status := cluster.Status.MachinePoolScaling[0]
+			assert.Equal(t, "workers", status.Name)
+			assert.Equal(t, "scale-down", status.LastRule)
+			assert.Equal(t, monday.Add(-time.Hour), status.LastScaleTime.Time)
+			assert.Equal(t, "scale-up", status.NextRule)
+			assert.Equal(t, monday.Add(11*time.Hour), status.NextScaleTime.Time)
+			return cluster, nil
+		})
+		clusters.EXPECT().EnqueueAfter("fleet-default", "test", 11*time.Hour)
+
+		h := handler{clusters: clusters, now: func() time.Time { return monday }}
+		_, err := h.OnChange("", newCluster(3, nightly))
+		require.NoError(t, err)
+	})
+
+	t.Run("keeps manual changes until the next scheduled time", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*rancherv1.Cluster, *rancherv1.ClusterList](ctrl)
+		clusters.EXPECT().EnqueueAfter("fleet-default", "test", 11*time.Hour)
+
+		status := rancherv1.RKEMachinePoolScalingStatus{
+			Name:          "workers",
+			LastRule:      "scale-down",
+			LastScaleTime: &metav1.Time{Time: monday.Add(-time.Hour)},
+			NextRule:      "scale-up",
+			NextScaleTime: &metav1.Time{Time: monday.Add(11 * time.Hour)},
+		}
+
+		h := handler{clusters: clusters, now: func() time.Time { return monday }}
+		_, err := h.OnChange("", newCluster(2, nightly, status))
+		require.NoError(t, err)
+	})
+
+	t.Run("records invalid rules", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		clusters := fake.NewMockControllerInterface[*rancherv1.Cluster, *rancherv1.ClusterList](ctrl)
+		clusters.EXPECT().UpdateStatus(gomock.Any()).DoAndReturn(func(cluster *rancherv1.Cluster) (*rancherv1.Cluster, error) {
+			require.Len(t, cluster.Status.MachinePoolScaling, 1)
+			assert.Contains(t, cluster.Status.MachinePoolScaling[0].Message, `invalid time zone "Mars/Olympus" of rule scale-down`)
+			return cluster, nil
+		})
+
+		rules := []rancherv1.RKEMachinePoolScalingRule{
+			{Name: "scale-down", Schedule: "0 19 * * *", TimeZone: "Mars/Olympus", Quantity: ptr.To[int32](0)},
+		}
+		h := handler{clusters: clusters, now: func() time.Time { return monday }}
+		_, err := h.OnChange("", newCluster(3, rules))
+		require.NoError(t, err)
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Run("time zone", func(t *testing.T) {
+		rules := []rancherv1.RKEMachinePoolScalingRule{
+			{Name: "scale-down", Schedule: "0 19 * * *", TimeZone: "America/New_York", Quantity: ptr.To[int32](0)},
+		}
+		s, err := evaluate(rules, monday)
+		require.NoError(t, err)
+		// 19:00 EST is midnight UTC
+		assert.Equal(t, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), s.lastTime)
+		assert.Equal(t, time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), s.nextTime)
+	})
+
+	t.Run("weekend", func(t *testing.T) {
+		saturday := time.Date(2024, 1, 13, 12, 0, 0, 0, time.UTC)
+		s, err := evaluate(nightly, saturday)
+		require.NoError(t, err)
+		assert.Equal(t, "scale-down", s.last.Name)
+		assert.Equal(t, "scale-down", s.next.Name)
+		assert.Equal(t, time.Date(2024, 1, 13, 19, 0, 0, 0, time.UTC), s.nextTime)
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		_, err := evaluate([]rancherv1.RKEMachinePoolScalingRule{{Name: "invalid", Schedule: "every day"}}, monday)
+		assert.ErrorContains(t, err, `invalid schedule "every day" of rule invalid`)
+	})
+}
+
+func TestLastScheduled(t *testing.T) {
+	tests := []struct {
+		schedule string
+		expected time.Time
+	}{
+		{schedule: "* * * * *", expected: monday},
+		{schedule: "30 * * * *", expected: monday.Add(-30 * time.Minute)},
+		{schedule: "0 3 1 * *", expected: time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 25 12 *", expected: time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			schedule, err := cron.ParseStandard(tt.schedule)
+			require.NoError(t, err)
+			last, ok := lastScheduled(schedule, monday)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, last)
+		})
+	}
+}
+
+func TestScale(t *testing.T) {
+	pool := &rancherv1.RKEMachinePool{
+		Quantity:           ptr.To[int32](5),
+		AutoscalingMinSize: ptr.To[int32](2),
+		AutoscalingMaxSize: ptr.To[int32](10),
+	}
+
+	scale(pool, &rancherv1.RKEMachinePoolScalingRule{AutoscalingMinSize: ptr.To[int32](0), AutoscalingMaxSize: ptr.To[int32](1)})
+	assert.Equal(t, int32(1), *pool.Quantity)
+	assert.Equal(t, int32(0), *pool.AutoscalingMinSize)
+	assert.Equal(t, int32(1), *pool.AutoscalingMaxSize)
+
+	scale(pool, &rancherv1.RKEMachinePoolScalingRule{Quantity: ptr.To[int32](4)})
+	assert.Equal(t, int32(1), *pool.Quantity)
+
+	scale(pool, &rancherv1.RKEMachinePoolScalingRule{Quantity: ptr.To[int32](4), AutoscalingMinSize: ptr.To[int32](3), AutoscalingMaxSize: ptr.To[int32](6)})
+	assert.Equal(t, int32(4), *pool.Quantity)
+}
@@ -576,6 +576,14 @@ func rkeControlPlane(cluster *rancherv1.Cluster) (*rkev1.RKEControlPlane, error)
 		logrus.Errorf("cluster: %s/%s : error while gz/b64 encoding cluster specification: %v", cluster.Namespace, cluster.Name, err)
 		return nil, err
 	}
+	annotations := map[string]string{
+		capr.ClusterSpecAnnotation: b64GZCluster,
+	}
+	for _, annotation := range []string{capr.PlanPreviewAnnotation, capr.PlanDryRunAnnotation} {
+		if cluster.Annotations[annotation] == "true" {
+			annotations[annotation] = "true"
+		}
+	}
 	rkeConfig := cluster.Spec.RKEConfig.DeepCopy()
 	return &rkev1.RKEControlPlane{
 		ObjectMeta: metav1.ObjectMeta{
@@ -584,9 +592,7 @@ func rkeControlPlane(cluster *rancherv1.Cluster) (*rkev1.RKEControlPlane, error)
 			Labels: map[string]string{
 				capr.InitNodeMachineIDLabel: cluster.Labels[capr.InitNodeMachineIDLabel],
 			},
-			Annotations: map[string]string{
-				capr.ClusterSpecAnnotation: b64GZCluster,
-			},
+			Annotations: annotations,
 		},
 		Spec: rkev1.RKEControlPlaneSpec{
 			ClusterConfiguration:     rkeConfig.ClusterConfiguration,
@@ -74,6 +74,8 @@ func BasicCRDs() []string {
 func ProvisioningV2CRDs() []string {
 	return []string{
 		"clusters.provisioning.cattle.io",
+		"clustertemplates.provisioning.cattle.io",
+		"clustertemplaterevisions.provisioning.cattle.io",
 	}
 }
 
@@ -221,6 +223,8 @@ func TelemetryCRDs() []string {
 	"clusters.cluster.x-k8s.io":                                       false,
 	"clusters.management.cattle.io":                                   false,
 	"clusters.provisioning.cattle.io":                                 true,
+	"clustertemplaterevisions.provisioning.cattle.io":                 true,
+	"clustertemplates.provisioning.cattle.io":                         true,
 	"clusteruserattributes.cluster.cattle.io":                         false,
 	"composeconfigs.management.cattle.io":                             false,
 	"custommachines.rke.cattle.io":                                    true,
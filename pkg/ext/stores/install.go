@@ -44,15 +44,25 @@ func InstallStores(
 	}
 	logrus.Infof("Successfully installed %s store", tokens.SingularName)
 
+	kubeconfigStore := kubeconfig.New(features.MCM.Enabled(), wranglerContext, server.GetAuthorizer())
 	if err := server.Install(
 		extv1.KubeconfigResourceName,
 		extv1.SchemeGroupVersion.WithKind(kubeconfig.Kind),
-		kubeconfig.New(features.MCM.Enabled(), wranglerContext, server.GetAuthorizer()),
+		kubeconfigStore,
 	); err != nil {
 		return fmt.Errorf("unable to install %s store: %w", kubeconfig.Singular, err)
 	}
 	logrus.Infof("Successfully installed %s store", kubeconfig.Singular)
 
+	if err := server.Install(
+		extv1.KubeconfigResourceName+"/"+kubeconfig.TokenSubresource,
+		tokens.GVK,
+		kubeconfig.NewTokenStore(kubeconfigStore),
+	); err != nil {
+		return fmt.Errorf("unable to install %s %s store: %w", kubeconfig.Singular, kubeconfig.TokenSubresource, err)
+	}
+	logrus.Infof("Successfully installed %s %s store", kubeconfig.Singular, kubeconfig.TokenSubresource)
+
 	if err = server.Install(
 		extv1.PasswordChangeRequestResourceName,
 		passwordchangerequest.GVK,
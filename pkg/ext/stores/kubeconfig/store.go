@@ -16,6 +16,7 @@
 	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
 	mgmt "github.com/rancher/rancher/pkg/apis/management.cattle.io"
 	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/audit/annotations"
 	"github.com/rancher/rancher/pkg/auth/providers/common"
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	extcommon "github.com/rancher/rancher/pkg/ext/common"
@@ -61,6 +62,7 @@
 	UIDAnnotation      = "cattle.io/uid"
 	namespace          = exttokens.TokenNamespace
 	unknownValue       = "<unknown>"
+	neverValue         = "<never>"
 	defaultClusterName = "rancher"
 	namePrefix         = Singular + "-"
 )
@@ -71,6 +73,7 @@
 	CurrentContextField   = "current-context"
 	DescriptionField      = "description"
 	TTLField              = "ttl"
+	ExecField             = "exec"
 	StatusConditionsField = "status-conditions"
 	StatusSummaryField    = "status-summary"
 	StatusTokensField     = "status-tokens"
@@ -90,6 +93,16 @@
 	FailedToCreateTokenCond      = "FailedToCreateToken"
 	FailedToListClusterNodesCond = "FailedToListClusterNodes"
 	FailedToGenerateCond         = "FailedToGenerate"
+	TokenRevokedCond             = "TokenRevoked"
+	FailedToRevokeTokenCond      = "FailedToRevokeToken"
+)
+
+// List of audit log annotations.
+const (
+	// AuditAnnotationRevokedTokens is the audit log annotation of the kubeconfig tokens revoked by a request.
+	AuditAnnotationRevokedTokens = "kubeconfig.cattle.io/revoked-tokens"
+	// AuditAnnotationFailedTokens is the audit log annotation of the kubeconfig tokens a request failed to revoke.
+	AuditAnnotationFailedTokens = "kubeconfig.cattle.io/failed-tokens"
 )
 
 var gvr = ext.SchemeGroupVersion.WithResource(ext.KubeconfigResourceName)
@@ -118,6 +131,7 @@ type Store struct {
 	tokenMgr            tokenCreator
 	getCACert           func() string
 	getDefaultTTL       func() (*int64, error)
+	getExecTTL          func() (*int64, error)
 	getServerURL        func() string
 	shouldGenerateToken func() bool
 	tableConverter      rest.TableConvertor
@@ -139,6 +153,7 @@ func New(mcmEnabled bool, wranglerContext *wrangler.Context, authorizer authoriz
 		authorizer:      authorizer,
 		getCACert:       settings.CACerts.Get,
 		getDefaultTTL:   tokens.GetKubeconfigDefaultTokenTTLInMilliSeconds,
+		getExecTTL:      tokens.GetKubeconfigExecTokenTTLInMilliSeconds,
 		getServerURL:    settings.ServerURL.Get,
 		shouldGenerateToken: func() bool {
 			return strings.EqualFold(settings.KubeconfigGenerateToken.Get(), "true")
@@ -263,17 +278,30 @@ func (s *Store) Create(
 	if err != nil {
 		return nil, fmt.Errorf("error getting default token TTL: %w", err)
 	}
-	defaultTTLSeconds := *defaultTTL / 1000
+
+	// The TTL of exec kubeconfigs applies to each short-lived token obtained by the exec credential plugin,
+	// and is capped by the exec token TTL rather than the default one.
+	maxTTL := *defaultTTL
+	if kubeconfig.Spec.Exec {
+		ttl, err := s.getExecTTL()
+		if err != nil {
+			return nil, fmt.Errorf("error getting exec token TTL: %w", err)
+		}
+		maxTTL = *ttl
+		if *defaultTTL > 0 {
+			maxTTL = min(maxTTL, *defaultTTL)
+		}
+	}
 
 	ttlMilliseconds := kubeconfig.Spec.TTL * 1000
 	switch {
 	case ttlMilliseconds < 0:
 		return nil, apierrors.NewBadRequest("spec.ttl can't be negative")
 	case ttlMilliseconds == 0:
-		ttlMilliseconds = *defaultTTL
-		kubeconfig.Spec.TTL = defaultTTLSeconds
-	case ttlMilliseconds > *defaultTTL:
-		return nil, apierrors.NewBadRequest(fmt.Sprintf("spec.ttl %d exceeds max ttl %d", kubeconfig.Spec.TTL, defaultTTLSeconds))
+		ttlMilliseconds = maxTTL
+		kubeconfig.Spec.TTL = maxTTL / 1000
+	case ttlMilliseconds > maxTTL:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("spec.ttl %d exceeds max ttl %d", kubeconfig.Spec.TTL, maxTTL/1000))
 	default: // Valid TTL.
 	}
 
@@ -374,7 +402,8 @@ func (s *Store) Create(
 	}
 
 	dryRun := options != nil && len(options.DryRun) > 0
-	generateToken := s.shouldGenerateToken()
+	// Exec kubeconfigs never embed tokens.
+	generateToken := s.shouldGenerateToken() && !kubeconfig.Spec.Exec
 
 	kubeconfigToStore := kubeconfig.DeepCopy()
 	kubeconfigToStore.Name = ""         // We generate the kubeconfig's name automatically.
@@ -486,10 +515,15 @@ func (s *Store) Create(
 			Server: "https://" + host,
 			Cert:   caCert,
 		})
-		data.Users = append(data.Users, kconfig.User{
+		defaultUser := kconfig.User{
 			Name:  defaultClusterName,
 			Token: sharedTokenKey,
-		})
+		}
+		if kubeconfig.Spec.Exec {
+			defaultUser.Host = host
+			defaultUser.KubeconfigID = kubeConfigID
+		}
+		data.Users = append(data.Users, defaultUser)
 		data.Contexts = append(data.Contexts, kconfig.Context{
 			Name:    defaultClusterName,
 			Cluster: defaultClusterName,
@@ -523,7 +557,8 @@ func (s *Store) Create(
 				kubeconfigToStore.Spec.CurrentContext = currentContext
 			}
 
-			if !cluster.Spec.LocalClusterAuthEndpoint.Enabled {
+			// Exec kubeconfigs use a cluster-scoped token for every cluster.
+			if !cluster.Spec.LocalClusterAuthEndpoint.Enabled && !kubeconfig.Spec.Exec {
 				data.Contexts = append(data.Contexts, kconfig.Context{
 					Name:    clusterName,
 					Cluster: clusterName,
@@ -570,10 +605,20 @@ func (s *Store) Create(
 				Cluster: clusterName,
 				User:    clusterName,
 			})
-			data.Users = append(data.Users, kconfig.User{
+			clusterUser := kconfig.User{
 				Name:  clusterName,
 				Token: tokenKey,
-			})
+			}
+			if kubeconfig.Spec.Exec {
+				clusterUser.Host = host
+				clusterUser.ClusterID = cluster.Name
+				clusterUser.KubeconfigID = kubeConfigID
+			}
+			data.Users = append(data.Users, clusterUser)
+
+			if !cluster.Spec.LocalClusterAuthEndpoint.Enabled {
+				continue
+			}
 
 			if s.mcmEnabled { // Nodes are only available if MCM is enabled.
 				// If the ACE cluster has a FQDN, add a single entry for it.
@@ -727,6 +772,9 @@ func (s *Store) toConfigMap(kubeconfig *ext.Kubeconfig) (*corev1.ConfigMap, erro
 	configMap.Data[CurrentContextField] = kubeconfig.Spec.CurrentContext
 	configMap.Data[DescriptionField] = kubeconfig.Spec.Description
 	configMap.Data[TTLField] = strconv.FormatInt(kubeconfig.Spec.TTL, 10)
+	if kubeconfig.Spec.Exec {
+		configMap.Data[ExecField] = "true"
+	}
 
 	// Note: Value should never be persisted!
 	configMap.Data[StatusSummaryField] = kubeconfig.Status.Summary
@@ -762,6 +810,7 @@ func (s *Store) fromConfigMap(configMap *corev1.ConfigMap) (*ext.Kubeconfig, err
 		Spec: ext.KubeconfigSpec{
 			Description:    configMap.Data[DescriptionField],
 			CurrentContext: configMap.Data[CurrentContextField],
+			Exec:           configMap.Data[ExecField] == "true",
 		},
 	}
 	kubeconfig.Namespace = ""            // Kubeconfig is not namespaced.
@@ -911,6 +960,10 @@ func (s *Store) Get(
 		return nil, apierrors.NewInternalError(fmt.Errorf("error converting configmap %s to kubeconfig: %w", name, err))
 	}
 
+	if err := s.setUsage(kubeconfig); err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("error getting usage of kubeconfig %s: %w", name, err))
+	}
+
 	return kubeconfig, nil
 }
 
@@ -945,6 +998,8 @@ func toListOptions(options *metainternalversion.ListOptions, userInfo k8suser.In
 }
 
 // List implements [rest.Lister].
+// In addition to the label selectors, the field selectors of [newFilter] are supported.
+// As they are applied to each page of ConfigMaps, pages may have fewer items than the requested limit.
 func (s *Store) List(
 	ctx context.Context,
 	options *metainternalversion.ListOptions,
@@ -959,6 +1014,11 @@ func (s *Store) List(
 		return nil, apierrors.NewInternalError(err)
 	}
 
+	filter, err := newFilter(listOptions, time.Now())
+	if err != nil {
+		return nil, err // The err is already an [apierrors.APIStatus].
+	}
+
 	configMapList, err := s.configMapClient.List(namespace, *listOptions)
 	if err != nil {
 		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) { // Continue token expired.
@@ -981,6 +1041,14 @@ func (s *Store) List(
 			return nil, apierrors.NewInternalError(fmt.Errorf("error converting configmap %s to kubeconfig: %w", configMap.Name, err))
 		}
 
+		if err := s.setUsage(kubeconfig); err != nil {
+			return nil, apierrors.NewInternalError(fmt.Errorf("error getting usage of kubeconfig %s: %w", configMap.Name, err))
+		}
+
+		if !filter.matches(kubeconfig) {
+			continue
+		}
+
 		list.Items = append(list.Items, *kubeconfig)
 	}
 
@@ -1139,6 +1207,7 @@ func printHandler(h printers.PrintHandler) {
 		{Name: "Tokens", Type: "string", Description: "Tokens is the number of tokens created for the Kubeconfig"},
 		{Name: "Status", Type: "string", Description: "Status is the most recently observed status of the Kubeconfig"},
 		{Name: "Age", Type: "string", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+		{Name: "Last Used", Type: "string", Description: "Last Used is the time since any of the Kubeconfig tokens was last used"},
 		{Name: "User", Type: "string", Priority: 1, Description: "User is the owner of the Kubeconfig"},
 		{Name: "Clusters", Type: "string", Priority: 1, Description: "Clusters is a list of clusters in the Kubeconfig"},
 		{Name: "Description", Type: "string", Priority: 1, Description: "Description is a human readable description of the Kubeconfig"},
@@ -1165,6 +1234,11 @@ func printKubeconfig(kubeconfig *ext.Kubeconfig, options printers.GenerateOption
 	}
 	tokens := strconv.Itoa(ownedTokenCount) + "/" + strconv.Itoa(allTokenCount)
 
+	lastUsed := neverValue
+	if kubeconfig.Status.LastUsedAt != nil {
+		lastUsed = translateTimestampSince(*kubeconfig.Status.LastUsedAt)
+	}
+
 	return []metav1.TableRow{{
 		Object: runtime.RawExtension{Object: kubeconfig},
 		Cells: []any{
@@ -1173,6 +1247,7 @@ func printKubeconfig(kubeconfig *ext.Kubeconfig, options printers.GenerateOption
 			tokens,
 			status,
 			translateTimestampSince(kubeconfig.CreationTimestamp),
+			lastUsed,
 			kubeconfig.Labels[UserIDLabel],
 			strings.Join(kubeconfig.Spec.Clusters, ","),
 			kubeconfig.Spec.Description,
@@ -1194,6 +1269,10 @@ func printKubeconfigList(kubeconfigList *ext.KubeconfigList, options printers.Ge
 }
 
 // DeleteCollection implements [rest.CollectionDeleter]
+// It revokes all the kubeconfigs matching the label and field selectors, see [Store.List], e.g. those of a user or
+// giving access to a cluster. The conditions of the returned kubeconfigs report the revocation of each of their tokens.
+// The kubeconfigs whose tokens can't all be revoked are kept, and an error is returned once the others are revoked,
+// so that revoking the collection again finds them.
 func (s *Store) DeleteCollection(
 	ctx context.Context,
 	deleteValidation rest.ValidateObjectFunc,
@@ -1210,6 +1289,11 @@ func (s *Store) DeleteCollection(
 		return nil, apierrors.NewInternalError(err)
 	}
 
+	filter, err := newFilter(lOptions, time.Now())
+	if err != nil {
+		return nil, err // The err is already an [apierrors.APIStatus].
+	}
+
 	configMapList, err := s.configMapClient.List(namespace, *lOptions)
 	if err != nil {
 		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) { // Continue token expired.
@@ -1227,23 +1311,52 @@ func (s *Store) DeleteCollection(
 		Items: make([]ext.Kubeconfig, 0, len(configMapList.Items)),
 	}
 
+	// The kubeconfigs whose tokens were revoked, even partially, are audited.
+	// A dry run doesn't revoke any token, so it isn't audited.
+	dryRun := options != nil && len(options.DryRun) > 0
+	var revoked []ext.Kubeconfig
+	defer func() {
+		if !dryRun {
+			auditRevocation(ctx, userInfo.GetName(), revoked)
+		}
+	}()
+
+	var errs error
+
 	for _, configMap := range configMapList.Items {
-		tokenSelector, err := tokenSelector(isAdmin, userInfo.GetName(), configMap.Name)
+		kubeconfig, err := s.fromConfigMap(&configMap)
 		if err != nil {
-			return nil, apierrors.NewInternalError(err)
+			return nil, apierrors.NewInternalError(fmt.Errorf("error converting configmap %s to kubeconfig: %w", configMap.Name, err))
 		}
 
-		obj, _, err := s.delete(ctx, &configMap, tokenSelector, deleteValidation, options)
-		if err != nil {
-			return nil, apierrors.NewInternalError(fmt.Errorf("error deleting kubeconfig %s: %w", configMap.Name, err))
+		if err := s.setUsage(kubeconfig); err != nil {
+			return nil, apierrors.NewInternalError(fmt.Errorf("error getting usage of kubeconfig %s: %w", configMap.Name, err))
 		}
 
-		kubeconfig, ok := obj.(*ext.Kubeconfig)
-		if !ok { // Sanity check.
-			return nil, apierrors.NewInternalError(fmt.Errorf("expected kubeconfig object, got %T", obj))
+		if !filter.matches(kubeconfig) {
+			continue
 		}
 
-		list.Items = append(list.Items, *kubeconfig)
+		tokenSelector, err := tokenSelector(isAdmin, userInfo.GetName(), configMap.Name)
+		if err != nil {
+			return nil, apierrors.NewInternalError(err)
+		}
+
+		obj, deleted, err := s.delete(ctx, &configMap, tokenSelector, deleteValidation, options)
+		if deletedKubeconfig, ok := obj.(*ext.Kubeconfig); ok {
+			deletedKubeconfig.Status.LastUsedAt = kubeconfig.Status.LastUsedAt
+			revoked = append(revoked, *deletedKubeconfig)
+			if deleted {
+				list.Items = append(list.Items, *deletedKubeconfig)
+			}
+		}
+		if err != nil && !apierrors.IsNotFound(err) { // The kubeconfig may have been deleted concurrently.
+			// Carry on with the other kubeconfigs, so that as many tokens as possible are revoked.
+			errs = errors.Join(errs, fmt.Errorf("error deleting kubeconfig %s: %w", configMap.Name, err))
+		}
+	}
+	if errs != nil {
+		return nil, apierrors.NewInternalError(errs)
 	}
 
 	return list, nil
@@ -1278,10 +1391,19 @@ func (s *Store) Delete(
 		return nil, false, apierrors.NewInternalError(err)
 	}
 
-	return s.delete(ctx, configMap, tokenSelector, deleteValidation, options)
+	obj, deleted, err := s.delete(ctx, configMap, tokenSelector, deleteValidation, options)
+	// A dry run doesn't revoke any token, so it isn't audited.
+	if kubeconfig, ok := obj.(*ext.Kubeconfig); ok && len(options.DryRun) == 0 {
+		auditRevocation(ctx, userInfo.GetName(), []ext.Kubeconfig{*kubeconfig})
+	}
+
+	return obj, deleted, err
 }
 
-// delete a kubeconfig's configmap and associated tokens.
+// delete a kubeconfig's associated tokens and configmap.
+// The tokens are revoked first, and the configmap is only deleted if all of them were, so that the kubeconfig can be
+// found to revoke the remaining ones again. The conditions of the returned kubeconfig report the revocation of each
+// token, including when some of them failed.
 func (s *Store) delete(
 	ctx context.Context,
 	configMap *corev1.ConfigMap,
@@ -1318,13 +1440,59 @@ func (s *Store) delete(
 		if options.Preconditions.UID != nil && *options.Preconditions.UID == kubeconfig.UID {
 			options.Preconditions.UID = &configMap.UID
 		}
+		// Check the preconditions before revoking the tokens, the configmap delete checks them again.
+		if (options.Preconditions.UID != nil && *options.Preconditions.UID != configMap.UID) ||
+			(options.Preconditions.ResourceVersion != nil && *options.Preconditions.ResourceVersion != configMap.ResourceVersion) {
+			return nil, false, apierrors.NewConflict(gvr.GroupResource(), configMap.Name, errors.New("the precondition for the delete does not match"))
+		}
+	}
+
+	var tokenNames []string
+	for _, token := range tokenList {
+		tokenNames = append(tokenNames, token.Name)
+	}
+
+	// Attempt to revoke all the tokens, reporting the result for each of them.
+	var errs error
+	for _, tokenName := range tokenNames {
+		delOptions := &metav1.DeleteOptions{
+			GracePeriodSeconds: options.GracePeriodSeconds,
+			PropagationPolicy:  options.PropagationPolicy,
+			DryRun:             options.DryRun, // Pass through the dry run flag instead of handling it explicitly.
+		}
+
+		err := s.tokens.Delete(tokenName, delOptions)
+		if err != nil && !apierrors.IsNotFound(err) {
+			kubeconfig.Status.Conditions = append(kubeconfig.Status.Conditions, metav1.Condition{
+				Type:               FailedToRevokeTokenCond,
+				Status:             metav1.ConditionTrue,
+				Reason:             FailedToRevokeTokenCond,
+				Message:            fmt.Sprintf("%s: %s", tokenName, err),
+				LastTransitionTime: metav1.NewTime(time.Now()),
+			})
+			errs = errors.Join(errs, fmt.Errorf("error deleting token %s for kubeconfig %s: %w", tokenName, configMap.Name, err))
+			continue
+		}
+
+		kubeconfig.Status.Conditions = append(kubeconfig.Status.Conditions, metav1.Condition{
+			Type:               TokenRevokedCond,
+			Status:             metav1.ConditionTrue,
+			Reason:             TokenRevokedCond,
+			Message:            tokenName,
+			LastTransitionTime: metav1.NewTime(time.Now()),
+		})
+	}
+	if errs != nil {
+		// Keep the kubeconfig, so that the tokens which weren't revoked can be found.
+		return kubeconfig, false, apierrors.NewInternalError(errs)
 	}
 
+	// The kubeconfig is returned along with the errors, as its tokens were revoked.
 	err = s.configMapClient.Delete(namespace, configMap.Name, options)
 	switch {
 	case err == nil:
 	case apierrors.IsNotFound(err):
-		return nil, false, apierrors.NewNotFound(gvr.GroupResource(), configMap.Name)
+		return kubeconfig, false, apierrors.NewNotFound(gvr.GroupResource(), configMap.Name)
 	case apierrors.IsConflict(err):
 		// Massage the err details to refer to Kubeconfigs instead of ConfigMaps.
 		var errMessage string
@@ -1335,30 +1503,37 @@ func (s *Store) delete(
 				errMessage = ""
 			}
 		}
-		return nil, false, apierrors.NewConflict(gvr.GroupResource(), configMap.Name, errors.New(errMessage))
+		return kubeconfig, false, apierrors.NewConflict(gvr.GroupResource(), configMap.Name, errors.New(errMessage))
 	default:
-		return nil, false, apierrors.NewInternalError(fmt.Errorf("error deleting configmap for kubeconfig %s: %w", configMap.Name, err))
+		return kubeconfig, false, apierrors.NewInternalError(fmt.Errorf("error deleting configmap for kubeconfig %s: %w", configMap.Name, err))
 	}
 
-	var tokenNames []string
-	for _, token := range tokenList {
-		tokenNames = append(tokenNames, token.Name)
-	}
-
-	for _, tokenName := range tokenNames {
-		delOptions := &metav1.DeleteOptions{
-			GracePeriodSeconds: options.GracePeriodSeconds,
-			PropagationPolicy:  options.PropagationPolicy,
-			DryRun:             options.DryRun, // Pass through the dry run flag instead of handling it explicitly.
-		}
+	return kubeconfig, true, nil
+}
 
-		err := s.tokens.Delete(tokenName, delOptions)
-		if err != nil && !apierrors.IsNotFound(err) {
-			return nil, false, apierrors.NewInternalError(fmt.Errorf("error deleting token %s for kubeconfig %s: %w", tokenName, configMap.Name, err))
+// auditRevocation logs the results of the revocation of the tokens of kubeconfigs, and adds them to the audit log.
+func auditRevocation(ctx context.Context, userName string, kubeconfigs []ext.Kubeconfig) {
+	var revoked, failed []string
+	for _, kubeconfig := range kubeconfigs {
+		for _, cond := range kubeconfig.Status.Conditions {
+			switch cond.Type {
+			case TokenRevokedCond:
+				logrus.Infof("kubeconfig: user %s revoked token %s of kubeconfig %s", userName, cond.Message, kubeconfig.Name)
+				revoked = append(revoked, kubeconfig.Name+"/"+cond.Message)
+			case FailedToRevokeTokenCond:
+				logrus.Errorf("kubeconfig: user %s failed to revoke token of kubeconfig %s: %s", userName, kubeconfig.Name, cond.Message)
+				tokenName, _, _ := strings.Cut(cond.Message, ":")
+				failed = append(failed, kubeconfig.Name+"/"+tokenName)
+			}
 		}
 	}
 
-	return kubeconfig, true, nil
+	if len(revoked) > 0 {
+		annotations.Add(ctx, AuditAnnotationRevokedTokens, strings.Join(revoked, ","))
+	}
+	if len(failed) > 0 {
+		annotations.Add(ctx, AuditAnnotationFailedTokens, strings.Join(failed, ","))
+	}
 }
 
 // Update implements [rest.Updater]
@@ -1423,6 +1598,9 @@ func (s *Store) Update(
 	if oldKubeconfig.Spec.TTL != newKubeconfig.Spec.TTL {
 		return nil, false, apierrors.NewBadRequest("spec.ttl is immutable")
 	}
+	if oldKubeconfig.Spec.Exec != newKubeconfig.Spec.Exec {
+		return nil, false, apierrors.NewBadRequest("spec.exec is immutable")
+	}
 
 	newKubeconfig.UID = oldKubeconfig.UID // Make sure UID is preserved.
 
@@ -1501,6 +1679,7 @@ func (s *Store) NamespaceScoped() bool {
 	pathCMCurrentContextField   = fieldpath.MakePathOrDie("data", "current-context")
 	pathCMDescriptionField      = fieldpath.MakePathOrDie("data", "description")
 	pathCMTTLField              = fieldpath.MakePathOrDie("data", "ttl")
+	pathCMExecField             = fieldpath.MakePathOrDie("data", "exec")
 	pathCMStatusConditionsField = fieldpath.MakePathOrDie("data", "status-conditions")
 	pathCMStatusSummaryField    = fieldpath.MakePathOrDie("data", "status-summary")
 	pathCMStatusTokensField     = fieldpath.MakePathOrDie("data", "status-tokens")
@@ -1511,6 +1690,7 @@ func (s *Store) NamespaceScoped() bool {
 	pathKConfigCurrentContextField = fieldpath.MakePathOrDie("spec", "currentContext")
 	pathKConfigDescriptionField    = fieldpath.MakePathOrDie("spec", "description")
 	pathKConfigTTLField            = fieldpath.MakePathOrDie("spec", "ttl")
+	pathKConfigExecField           = fieldpath.MakePathOrDie("spec", "exec")
 
 	mapFromConfigMap = extcommon.MapSpec{
 		pathCMData.String():                  nil,
@@ -1518,6 +1698,7 @@ func (s *Store) NamespaceScoped() bool {
 		pathCMCurrentContextField.String():   pathKConfigCurrentContextField,
 		pathCMDescriptionField.String():      pathKConfigDescriptionField,
 		pathCMTTLField.String():              pathKConfigTTLField,
+		pathCMExecField.String():             pathKConfigExecField,
 		pathCMStatusConditionsField.String(): nil,
 		pathCMStatusSummaryField.String():    nil,
 		pathCMStatusTokensField.String():     nil,
@@ -1529,5 +1710,6 @@ func (s *Store) NamespaceScoped() bool {
 		pathKConfigCurrentContextField.String(): pathCMCurrentContextField,
 		pathKConfigDescriptionField.String():    pathCMDescriptionField,
 		pathKConfigTTLField.String():            pathCMTTLField,
+		pathKConfigExecField.String():           pathCMExecField,
 	}
 )
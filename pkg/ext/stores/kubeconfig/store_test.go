@@ -22,6 +22,7 @@
 
 	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/audit/annotations"
 	"github.com/rancher/rancher/pkg/auth/providers/common"
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	"github.com/rancher/rancher/pkg/user"
@@ -35,6 +36,7 @@
 	"k8s.io/apimachinery/pkg/api/meta"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/uuid"
@@ -540,6 +542,92 @@ func TestStoreCreate(t *testing.T) {
 
 		assert.Equal(t, "downstream1", config.CurrentContext)
 	})
+	t.Run("user creates an exec kubeconfig", func(t *testing.T) {
+		authorizer := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+			return authorizer.DecisionAllow, "", nil
+		})
+
+		var configMap *corev1.ConfigMap
+		configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+		configMapClient.EXPECT().Create(gomock.Any()).DoAndReturn(func(obj *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+			configMap = obj.DeepCopy()
+			configMap.CreationTimestamp = metav1.Now()
+			configMap.Name = names.SimpleNameGenerator.GenerateName(configMap.GenerateName)
+			return configMap, nil
+		}).Times(1)
+		configMapClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(obj *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+			configMap = obj.DeepCopy()
+			return configMap, nil
+		}).Times(1)
+
+		tokenManager := &fakeTokenManager{} // Subtest specific instance.
+
+		execTTLSeconds := int64(3600)
+		store := &Store{
+			mcmEnabled:      true,
+			authorizer:      authorizer,
+			nsCache:         nsCache,
+			configMapClient: configMapClient,
+			userCache:       userCache,
+			tokenCache:      tokenCache,
+			clusterCache:    clusterCache,
+			nodeCache:       nodeCache,
+			tokenMgr:        tokenManager,
+			getCACert:       func() string { return rancherCACert },
+			getDefaultTTL:   getDefaultTTL,
+			getExecTTL: func() (*int64, error) {
+				millis := execTTLSeconds * 1000
+				return &millis, nil
+			},
+			getServerURL:        getServerURL,
+			shouldGenerateToken: shouldGenerateToken,
+		}
+
+		ctx := request.WithUser(context.Background(), &k8suser.DefaultInfo{
+			Name: userID,
+			Extra: map[string][]string{
+				common.ExtraRequestTokenID: {authTokenID},
+			},
+		})
+		kubeconfig := &ext.Kubeconfig{
+			Spec: ext.KubeconfigSpec{
+				Clusters: []string{downstream1, downstream2},
+				Exec:     true,
+			},
+		}
+
+		obj, err := store.Create(ctx, kubeconfig, nil, options)
+		require.NoError(t, err)
+
+		created := obj.(*ext.Kubeconfig)
+		assert.True(t, created.Spec.Exec)
+		assert.Equal(t, execTTLSeconds, created.Spec.TTL)
+		assert.Equal(t, StatusSummaryComplete, created.Status.Summary)
+		assert.Empty(t, created.Status.Tokens)
+		assert.Empty(t, tokenManager.tokens)
+		assert.Empty(t, tokenManager.clusterTokens)
+
+		require.NotNil(t, configMap)
+		assert.Equal(t, "true", configMap.Data[ExecField])
+		assert.Empty(t, configMap.OwnerReferences)
+
+		config, err := clientcmd.Load([]byte(created.Status.Value))
+		require.NoError(t, err)
+		require.Len(t, config.AuthInfos, 3)
+		for name, authInfo := range config.AuthInfos {
+			assert.Empty(t, authInfo.Token, name)
+			require.NotNil(t, authInfo.Exec, name)
+			assert.Equal(t, "rancher", authInfo.Exec.Command, name)
+			assert.Contains(t, authInfo.Exec.Args, "--kubeconfig-id="+created.Name, name)
+			assert.Contains(t, authInfo.Exec.Args, "--server=rancher.example.com", name)
+		}
+		assert.NotContains(t, strings.Join(config.AuthInfos[defaultClusterName].Exec.Args, " "), "--cluster=")
+		assert.Contains(t, config.AuthInfos["downstream1"].Exec.Args, "--cluster="+downstream1)
+		assert.Contains(t, config.AuthInfos["downstream2"].Exec.Args, "--cluster="+downstream2)
+
+		assert.Equal(t, "downstream1", config.Contexts["downstream1"].AuthInfo)
+		assert.Equal(t, "downstream2", config.Contexts["downstream2-cp"].AuthInfo)
+	})
 	t.Run("no cluster specified", func(t *testing.T) {
 		var configMap *corev1.ConfigMap
 		configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
@@ -1091,6 +1179,39 @@ func TestStoreCreate(t *testing.T) {
 		assert.True(t, apierrors.IsBadRequest(err))
 		assert.Contains(t, err.Error(), "exceeds max ttl")
 	})
+	t.Run("exec ttl exceeds the max", func(t *testing.T) {
+		store := &Store{
+			authorizer:    commonAuthorizer,
+			userCache:     userCache,
+			tokenCache:    tokenCache,
+			tokenMgr:      tokenManager,
+			getDefaultTTL: getDefaultTTL,
+			getExecTTL: func() (*int64, error) {
+				millis := int64(3600 * 1000)
+				return &millis, nil
+			},
+		}
+
+		ctx := request.WithUser(context.Background(), &k8suser.DefaultInfo{
+			Extra: map[string][]string{
+				common.ExtraRequestTokenID: {authTokenID},
+			},
+			Name: userID,
+		})
+		kubeconfig := &ext.Kubeconfig{
+			Spec: ext.KubeconfigSpec{
+				Clusters: []string{downstream1, downstream2},
+				TTL:      3601,
+				Exec:     true,
+			},
+		}
+
+		obj, err := store.Create(ctx, kubeconfig, nil, options)
+		require.Error(t, err)
+		assert.Nil(t, obj)
+		assert.True(t, apierrors.IsBadRequest(err))
+		assert.Contains(t, err.Error(), "spec.ttl 3601 exceeds max ttl 3600")
+	})
 }
 
 func generateCAKeyAndCert() (*ecdsa.PrivateKey, string, error) {
@@ -2339,6 +2460,46 @@ func TestStoreDelete(t *testing.T) {
 
 		assert.True(t, deleteValidationCalled)
 	})
+	t.Run("dry run isn't audited", func(t *testing.T) {
+		deleteOptions := &metav1.DeleteOptions{
+			DryRun: []string{metav1.DryRunAll},
+		}
+
+		configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+		configMapClient.EXPECT().Get(namespace, gomock.Any(), gomock.Any()).Return(configMap.DeepCopy(), nil).Times(1)
+		configMapClient.EXPECT().Delete(namespace, kubeconfigID, deleteOptions).Return(nil).Times(1)
+
+		tokenCache := fake.NewMockNonNamespacedCacheInterface[*v3.Token](ctrl)
+		tokenCache.EXPECT().List(gomock.Any()).Return([]*v3.Token{
+			{ObjectMeta: metav1.ObjectMeta{Name: "token-abcde"}},
+		}, nil).Times(1)
+		tokenClient := fake.NewMockNonNamespacedClientInterface[*v3.Token, *v3.TokenList](ctrl)
+		tokenClient.EXPECT().Delete("token-abcde", gomock.Any()).DoAndReturn(func(name string, options *metav1.DeleteOptions) error {
+			assert.Equal(t, deleteOptions.DryRun, options.DryRun)
+			return nil
+		}).Times(1)
+
+		store := &Store{
+			authorizer:      commonAuthorizer,
+			configMapClient: configMapClient,
+			tokenCache:      tokenCache,
+			tokens:          tokenClient,
+			userCache:       userCache,
+			tokenMgr:        tokenManager,
+		}
+
+		ctx := request.WithUser(context.Background(), &k8suser.DefaultInfo{
+			Name: adminID,
+		})
+		ctx = annotations.WithAnnotations(ctx)
+
+		obj, deleted, err := store.Delete(ctx, kubeconfigID, nil, deleteOptions)
+		require.NoError(t, err)
+		require.NotNil(t, obj)
+		assert.True(t, deleted)
+
+		assert.Empty(t, annotations.From(ctx))
+	})
 	t.Run("user can't delete other user's kubeconfig", func(t *testing.T) {
 		configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
 		configMapClient.EXPECT().Get(namespace, gomock.Any(), gomock.Any()).DoAndReturn(func(namespace, name string, options metav1.GetOptions) (*corev1.ConfigMap, error) {
@@ -2367,6 +2528,75 @@ func TestStoreDelete(t *testing.T) {
 		assert.Equal(t, ext.KubeconfigResourceName, statusErr.Status().Details.Kind)
 		assert.Equal(t, kubeconfigID, statusErr.Status().Details.Name)
 	})
+	t.Run("kubeconfig is kept when a token isn't revoked", func(t *testing.T) {
+		configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+		configMapClient.EXPECT().Get(namespace, gomock.Any(), gomock.Any()).Return(configMap.DeepCopy(), nil).Times(1)
+		configMapClient.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		tokenCache := fake.NewMockNonNamespacedCacheInterface[*v3.Token](ctrl)
+		tokenCache.EXPECT().List(gomock.Any()).Return([]*v3.Token{
+			{ObjectMeta: metav1.ObjectMeta{Name: "token-revoked"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "token-failing"}},
+		}, nil).Times(1)
+		tokenClient := fake.NewMockNonNamespacedClientInterface[*v3.Token, *v3.TokenList](ctrl)
+		tokenClient.EXPECT().Delete("token-revoked", gomock.Any()).Return(nil).Times(1)
+		tokenClient.EXPECT().Delete("token-failing", gomock.Any()).Return(fmt.Errorf("some error")).Times(1)
+
+		store := &Store{
+			authorizer:      commonAuthorizer,
+			configMapClient: configMapClient,
+			tokenCache:      tokenCache,
+			tokens:          tokenClient,
+			userCache:       userCache,
+			tokenMgr:        tokenManager,
+		}
+
+		ctx := request.WithUser(context.Background(), &k8suser.DefaultInfo{
+			Name: adminID,
+		})
+
+		obj, deleted, err := store.Delete(ctx, kubeconfigID, nil, &metav1.DeleteOptions{})
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInternalError(err))
+		assert.False(t, deleted)
+
+		kubeconfig, ok := obj.(*ext.Kubeconfig)
+		require.True(t, ok)
+		results := map[string]string{}
+		for _, cond := range kubeconfig.Status.Conditions {
+			results[cond.Type] = cond.Message
+		}
+		assert.Equal(t, "token-revoked", results[TokenRevokedCond])
+		assert.Equal(t, "token-failing: some error", results[FailedToRevokeTokenCond])
+	})
+	t.Run("tokens aren't revoked when the preconditions don't match", func(t *testing.T) {
+		configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+		configMapClient.EXPECT().Get(namespace, gomock.Any(), gomock.Any()).Return(configMap.DeepCopy(), nil).Times(1)
+
+		tokenCache := fake.NewMockNonNamespacedCacheInterface[*v3.Token](ctrl)
+		tokenCache.EXPECT().List(gomock.Any()).Return([]*v3.Token{
+			{ObjectMeta: metav1.ObjectMeta{Name: "token"}},
+		}, nil).Times(1)
+
+		store := &Store{
+			authorizer:      commonAuthorizer,
+			configMapClient: configMapClient,
+			tokenCache:      tokenCache,
+			userCache:       userCache,
+			tokenMgr:        tokenManager,
+		}
+
+		ctx := request.WithUser(context.Background(), &k8suser.DefaultInfo{
+			Name: adminID,
+		})
+
+		_, deleted, err := store.Delete(ctx, kubeconfigID, nil, &metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{ResourceVersion: ptr.To("outdated")},
+		})
+		require.Error(t, err)
+		assert.True(t, apierrors.IsConflict(err))
+		assert.False(t, deleted)
+	})
 }
 
 func TestStoreDeleteCollection(t *testing.T) {
@@ -2502,6 +2732,76 @@ func TestStoreDeleteCollection(t *testing.T) {
 
 		assert.Equal(t, deleteValidationCalledTimes, 1)
 	})
+	t.Run("admin revokes unused kubeconfigs of a cluster", func(t *testing.T) {
+		unusedID := "kubeconfig-7kp6c"
+		otherClusterID := "kubeconfig-n8wzl"
+		unused := configMap.DeepCopy()
+		unused.Name = unusedID
+		unused.Data[StatusTokensField] = `["token-used-long-ago"]`
+		recentlyUsed := configMap.DeepCopy()
+		recentlyUsed.Data[StatusTokensField] = `["token-used-recently"]`
+		otherCluster := configMap.DeepCopy()
+		otherCluster.Name = otherClusterID
+		otherCluster.Data[ClustersField] = `["c-m-bxn2p7w6"]`
+
+		listOptions := &metainternalversion.ListOptions{
+			FieldSelector: fields.SelectorFromSet(fields.Set{
+				ClusterFieldSelector:        "c-m-tbgzfbgf",
+				LastUsedBeforeFieldSelector: "720h",
+				"metadata.name":             unusedID,
+			}),
+		}
+
+		configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+		configMapClient.EXPECT().List(namespace, gomock.Any()).DoAndReturn(func(namespace string, options metav1.ListOptions) (*corev1.ConfigMapList, error) {
+			assert.Equal(t, "metadata.name="+unusedID, options.FieldSelector)
+			return &corev1.ConfigMapList{
+				Items: []corev1.ConfigMap{*unused, *recentlyUsed, *otherCluster},
+			}, nil
+		}).Times(1)
+		// The kubeconfig isn't deleted, as one of its tokens wasn't revoked.
+		configMapClient.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		tokenCache := fake.NewMockNonNamespacedCacheInterface[*v3.Token](ctrl)
+		tokenCache.EXPECT().Get(gomock.Any()).DoAndReturn(func(name string) (*v3.Token, error) {
+			lastUsedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+			if name == "token-used-long-ago" {
+				lastUsedAt = metav1.NewTime(time.Now().Add(-1000 * time.Hour))
+			}
+			return &v3.Token{ObjectMeta: metav1.ObjectMeta{Name: name}, LastUsedAt: &lastUsedAt}, nil
+		}).AnyTimes()
+		tokenCache.EXPECT().List(gomock.Any()).Return([]*v3.Token{
+			{ObjectMeta: metav1.ObjectMeta{Name: "token-used-long-ago"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "token-failing"}},
+		}, nil).Times(1)
+		tokenClient := fake.NewMockNonNamespacedClientInterface[*v3.Token, *v3.TokenList](ctrl)
+		tokenClient.EXPECT().Delete("token-used-long-ago", gomock.Any()).Return(nil).Times(1)
+		tokenClient.EXPECT().Delete("token-failing", gomock.Any()).Return(fmt.Errorf("some error")).Times(1)
+
+		store := &Store{
+			authorizer:      commonAuthorizer,
+			configMapClient: configMapClient,
+			tokenCache:      tokenCache,
+			tokens:          tokenClient,
+			userCache:       userCache,
+			tokenMgr:        tokenManager,
+		}
+
+		ctx := request.WithUser(context.Background(), &k8suser.DefaultInfo{
+			Name: adminID,
+		})
+		ctx = annotations.WithAnnotations(ctx)
+
+		obj, err := store.DeleteCollection(ctx, nil, &metav1.DeleteOptions{}, listOptions)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInternalError(err))
+		assert.Contains(t, err.Error(), "token-failing")
+		assert.Nil(t, obj)
+
+		audit := annotations.From(ctx)
+		assert.Equal(t, unusedID+"/token-used-long-ago", audit[AuditAnnotationRevokedTokens])
+		assert.Equal(t, unusedID+"/token-failing", audit[AuditAnnotationFailedTokens])
+	})
 }
 
 func TestPrintKubeconfig(t *testing.T) {
@@ -2535,6 +2835,7 @@ func TestPrintKubeconfig(t *testing.T) {
 				"kubeconfig-u-w7drcgc66",
 				"kubeconfig-u-w7drcd12fg",
 			},
+			LastUsedAt: ptr.To(metav1.NewTime(time.Now().Add(-5 * time.Minute))),
 		},
 	}
 
@@ -2543,15 +2844,16 @@ func TestPrintKubeconfig(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, rows, 1)
 		row := rows[0]
-		require.Len(t, row.Cells, 8)
+		require.Len(t, row.Cells, 9)
 		assert.Equal(t, kubeconfig.Name, row.Cells[0].(string))
 		assert.Equal(t, "12h", row.Cells[1].(string))
 		assert.Equal(t, "1/2", row.Cells[2].(string))
 		assert.Equal(t, "Complete", row.Cells[3].(string))
 		assert.Equal(t, "0s", row.Cells[4].(string))
-		assert.Equal(t, kubeconfig.Labels[UserIDLabel], row.Cells[5].(string))
-		assert.Equal(t, "c-m-tbgzfbgf,c-m-bxn2p7w6", row.Cells[6].(string))
-		assert.Equal(t, kubeconfig.Spec.Description, row.Cells[7].(string))
+		assert.Equal(t, "5m", row.Cells[5].(string))
+		assert.Equal(t, kubeconfig.Labels[UserIDLabel], row.Cells[6].(string))
+		assert.Equal(t, "c-m-tbgzfbgf,c-m-bxn2p7w6", row.Cells[7].(string))
+		assert.Equal(t, kubeconfig.Spec.Description, row.Cells[8].(string))
 	})
 	t.Run("missing age and status", func(t *testing.T) {
 		kubeconfig := kubeconfig.DeepCopy()
@@ -2562,8 +2864,9 @@ func TestPrintKubeconfig(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, rows, 1)
 		row := rows[0]
-		require.Len(t, row.Cells, 8)
+		require.Len(t, row.Cells, 9)
 		assert.Equal(t, unknownValue, row.Cells[3].(string))
 		assert.Equal(t, unknownValue, row.Cells[4].(string))
+		assert.Equal(t, neverValue, row.Cells[5].(string))
 	})
 }
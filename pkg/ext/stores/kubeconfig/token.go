@@ -0,0 +1,271 @@
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	mgmt "github.com/rancher/rancher/pkg/apis/management.cattle.io"
+	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	exttokens "github.com/rancher/rancher/pkg/ext/stores/tokens"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/util/retry"
+)
+
+// TokenSubresource is the name of the subresource used by the exec credential plugin to obtain tokens.
+const TokenSubresource = "token"
+
+// +k8s:openapi-gen=false
+// +k8s:deepcopy-gen=false
+// TokenStore implements the token subresource of [ext.Kubeconfig].
+// It issues short-lived tokens for exec kubeconfigs, which don't embed any.
+type TokenStore struct {
+	store *Store
+}
+
+// NewTokenStore creates a new instance of [TokenStore].
+func NewTokenStore(store *Store) *TokenStore {
+	return &TokenStore{store: store}
+}
+
+// New implements [rest.Storage].
+func (t *TokenStore) New() runtime.Object {
+	return &ext.Token{}
+}
+
+// Create implements [rest.NamedCreater].
+// The spec.clusterName of the token selects the cluster the token is scoped to, and spec.ttl, in milliseconds,
+// optionally shortens its time-to-live. All other fields are ignored.
+func (t *TokenStore) Create(
+	ctx context.Context,
+	name string,
+	obj runtime.Object,
+	createValidation rest.ValidateObjectFunc,
+	options *metav1.CreateOptions,
+) (runtime.Object, error) {
+	s := t.store
+
+	userInfo, _, isRancherUser, err := s.userFrom(ctx, "create")
+	if err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("error getting user info: %w", err))
+	}
+
+	if !isRancherUser {
+		return nil, apierrors.NewForbidden(gvr.GroupResource(), name, fmt.Errorf("user %s is not a Rancher user", userInfo.GetName()))
+	}
+
+	authTokenID := first(userInfo.GetExtra()[common.ExtraRequestTokenID])
+	if authTokenID == "" {
+		return nil, apierrors.NewForbidden(gvr.GroupResource(), name, fmt.Errorf("missing request token ID"))
+	}
+
+	authToken, err := s.tokenCache.Get(authTokenID)
+	if err != nil {
+		return nil, apierrors.NewForbidden(gvr.GroupResource(), name, fmt.Errorf("error getting request token %s: %w", authTokenID, err))
+	}
+
+	request, ok := obj.(*ext.Token)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid object type %T", obj))
+	}
+
+	if createValidation != nil {
+		if err := createValidation(ctx, obj); err != nil {
+			if _, ok := err.(apierrors.APIStatus); ok {
+				return nil, err
+			}
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("create validation failed for kubeconfig token: %s", err))
+		}
+	}
+
+	useCache := true
+	configMap, err := s.getConfigMap(name, &metav1.GetOptions{}, useCache)
+	if err != nil {
+		return nil, err // The err is already an [apierrors.APIStatus].
+	}
+
+	// Only the owner of a kubeconfig can obtain tokens for it.
+	// We return a NotFound error to avoid leaking information about other users' kubeconfigs.
+	if configMap.Labels[UserIDLabel] != userInfo.GetName() {
+		return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+	}
+
+	kubeconfig, err := s.fromConfigMap(configMap)
+	if err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("error converting configmap %s to kubeconfig: %w", name, err))
+	}
+
+	if !kubeconfig.Spec.Exec {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("kubeconfig %s doesn't use the exec credential plugin", name))
+	}
+
+	ttlMilliseconds := kubeconfig.Spec.TTL * 1000
+	switch {
+	case request.Spec.TTL < 0:
+		return nil, apierrors.NewBadRequest("spec.ttl can't be negative")
+	case request.Spec.TTL > ttlMilliseconds:
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("spec.ttl %d exceeds the kubeconfig ttl %d", request.Spec.TTL, ttlMilliseconds))
+	case request.Spec.TTL > 0:
+		ttlMilliseconds = request.Spec.TTL
+	default: // Use the kubeconfig TTL.
+	}
+
+	clusterID := request.Spec.ClusterName
+	if clusterID != "" {
+		if len(kubeconfig.Spec.Clusters) == 0 ||
+			(kubeconfig.Spec.Clusters[0] != "*" && !slices.Contains(kubeconfig.Spec.Clusters, clusterID)) {
+			return nil, apierrors.NewForbidden(gvr.GroupResource(), name, fmt.Errorf("cluster %s is not part of kubeconfig %s", clusterID, name))
+		}
+
+		cluster, err := s.clusterCache.Get(clusterID)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, apierrors.NewBadRequest(fmt.Sprintf("cluster %s not found", clusterID))
+			}
+			return nil, apierrors.NewInternalError(fmt.Errorf("error getting cluster %s: %w", clusterID, err))
+		}
+
+		// Access to the cluster is checked on every request, as it may have been revoked since the kubeconfig was created.
+		decision, _, err := s.authorizer.Authorize(ctx, &authorizer.AttributesRecord{
+			User:            userInfo,
+			Verb:            "get",
+			APIGroup:        mgmt.GroupName,
+			Resource:        apiv3.ClusterResourceName,
+			ResourceRequest: true,
+			Name:            cluster.Name,
+		})
+		if err != nil {
+			return nil, apierrors.NewInternalError(fmt.Errorf("error checking if user %s has access to cluster %s: %w", userInfo.GetName(), cluster.Name, err))
+		}
+		if decision != authorizer.DecisionAllow {
+			return nil, apierrors.NewForbidden(gvr.GroupResource(), name, fmt.Errorf("user %s is not allowed to access cluster %s", userInfo.GetName(), cluster.Name))
+		}
+	}
+
+	dryRun := options != nil && len(options.DryRun) > 0
+	if dryRun {
+		return &ext.Token{
+			Spec: ext.TokenSpec{
+				UserID:      userInfo.GetName(),
+				Kind:        KindLabelValue,
+				TTL:         ttlMilliseconds,
+				ClusterName: clusterID,
+			},
+		}, nil
+	}
+
+	var (
+		tokenKey string
+		token    runtime.Object
+	)
+	input := s.createTokenInput(name, userInfo.GetName(), authToken, &ttlMilliseconds)
+	if clusterID != "" {
+		tokenKey, token, err = s.tokenMgr.EnsureClusterToken(clusterID, input)
+	} else {
+		tokenKey, token, err = s.tokenMgr.EnsureToken(input)
+	}
+	if err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("error creating token for kubeconfig %s: %w", name, err))
+	}
+
+	tokenMeta, err := meta.Accessor(token)
+	if err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("error getting metadata of token for kubeconfig %s: %w", name, err))
+	}
+
+	// Record the token on the kubeconfig so that it's listed and revoked along with it.
+	// Unlike embedded tokens, these aren't owners of the kubeconfig, which must outlive them.
+	if err := t.recordToken(name, tokenMeta.GetName()); err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("error recording token for kubeconfig %s: %w", name, err))
+	}
+
+	return &ext.Token{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              tokenMeta.GetName(),
+			UID:               tokenMeta.GetUID(),
+			CreationTimestamp: tokenMeta.GetCreationTimestamp(),
+			Labels:            tokenMeta.GetLabels(),
+		},
+		Spec: ext.TokenSpec{
+			UserID:      userInfo.GetName(),
+			Kind:        KindLabelValue,
+			TTL:         ttlMilliseconds,
+			ClusterName: clusterID,
+		},
+		Status: ext.TokenStatus{
+			Value:       tokenKey,
+			BearerToken: tokenKey,
+			ExpiresAt:   time.Now().Add(time.Duration(ttlMilliseconds) * time.Millisecond).UTC().Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// recordToken adds a token to the status of a kubeconfig, dropping the tokens that no longer exist.
+func (t *TokenStore) recordToken(kubeconfigID, tokenName string) error {
+	s := t.store
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		useCache := false
+		configMap, err := s.getConfigMap(kubeconfigID, &metav1.GetOptions{}, useCache)
+		if err != nil {
+			return err
+		}
+
+		kubeconfig, err := s.fromConfigMap(configMap)
+		if err != nil {
+			return err
+		}
+
+		tokenIDs := []string{tokenName}
+		for _, id := range kubeconfig.Status.Tokens {
+			if id == tokenName {
+				continue
+			}
+			if _, err := s.tokenCache.Get(id); err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			tokenIDs = append(tokenIDs, id)
+		}
+		kubeconfig.Status.Tokens = tokenIDs
+
+		configMap, err = s.toConfigMap(kubeconfig)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.configMapClient.Update(configMap)
+		return err
+	})
+}
+
+// GroupVersionKind implements [rest.GroupVersionKindProvider].
+func (t *TokenStore) GroupVersionKind(gv schema.GroupVersion) schema.GroupVersionKind {
+	return exttokens.GVK
+}
+
+// Destroy implements [rest.Storage].
+func (t *TokenStore) Destroy() {}
+
+// NamespaceScoped implements [rest.Scoper].
+func (t *TokenStore) NamespaceScoped() bool {
+	return false
+}
+
+var (
+	_ rest.NamedCreater             = &TokenStore{}
+	_ rest.Storage                  = &TokenStore{}
+	_ rest.Scoper                   = &TokenStore{}
+	_ rest.GroupVersionKindProvider = &TokenStore{}
+)
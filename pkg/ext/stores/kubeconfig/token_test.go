@@ -0,0 +1,230 @@
+package kubeconfig
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/rancher/rancher/pkg/auth/tokens"
+	exttokens "github.com/rancher/rancher/pkg/ext/stores/tokens"
+	"github.com/rancher/rancher/pkg/user"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8suser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestTokenStoreNew(t *testing.T) {
+	obj := NewTokenStore(&Store{}).New()
+	require.NotNil(t, obj)
+	assert.IsType(t, &ext.Token{}, obj)
+}
+
+func TestTokenStoreGroupVersionKind(t *testing.T) {
+	assert.Equal(t, exttokens.GVK, NewTokenStore(&Store{}).GroupVersionKind(ext.SchemeGroupVersion))
+}
+
+func TestTokenStoreCreate(t *testing.T) {
+	authTokenID := "token-nh98r"
+	kubeconfigID := "kubeconfig-49d2m"
+	downstream1 := "c-m-tbgzfbgf"
+	downstream2 := "c-m-bxn2p7w6"
+	expiredToken := "token-x5xkt"
+
+	ctrl := gomock.NewController(t)
+	userCache := fake.NewMockNonNamespacedCacheInterface[*v3.User](ctrl)
+	userCache.EXPECT().Get(gomock.Any()).DoAndReturn(func(name string) (*v3.User, error) {
+		switch name {
+		case userID, adminID:
+			return &v3.User{}, nil
+		default:
+			return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+		}
+	}).AnyTimes()
+
+	tokenCache := fake.NewMockNonNamespacedCacheInterface[*v3.Token](ctrl)
+	tokenCache.EXPECT().Get(gomock.Any()).DoAndReturn(func(name string) (*v3.Token, error) {
+		if name == expiredToken {
+			return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+		}
+		return &v3.Token{ObjectMeta: metav1.ObjectMeta{Name: name}, UserID: userID}, nil
+	}).AnyTimes()
+
+	clusterCache := fake.NewMockNonNamespacedCacheInterface[*v3.Cluster](ctrl)
+	clusterCache.EXPECT().Get(gomock.Any()).DoAndReturn(func(name string) (*v3.Cluster, error) {
+		switch name {
+		case downstream1, downstream2:
+			return &v3.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+		default:
+			return nil, apierrors.NewNotFound(gvr.GroupResource(), name)
+		}
+	}).AnyTimes()
+
+	// Only downstream1 is accessible by the user.
+	clusterAuthorizer := authorizer.AuthorizerFunc(func(ctx context.Context, a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetResource() == v3.ClusterResourceName && a.GetName() == downstream1 {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return commonAuthorizer(ctx, a)
+	})
+
+	newConfigMap := func(owner string, exec bool, clusters []string, tokenIDs ...string) *corev1.ConfigMap {
+		store := &Store{}
+		configMap, err := store.toConfigMap(&ext.Kubeconfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   kubeconfigID,
+				Labels: map[string]string{UserIDLabel: owner},
+			},
+			Spec: ext.KubeconfigSpec{
+				Clusters: clusters,
+				TTL:      3600,
+				Exec:     exec,
+			},
+			Status: ext.KubeconfigStatus{
+				Summary: StatusSummaryComplete,
+				Tokens:  tokenIDs,
+			},
+		})
+		require.NoError(t, err)
+		return configMap
+	}
+
+	ctx := request.WithUser(context.Background(), &k8suser.DefaultInfo{
+		Name: userID,
+		Extra: map[string][]string{
+			common.ExtraRequestTokenID: {authTokenID},
+		},
+	})
+
+	tests := []struct {
+		desc        string
+		configMap   *corev1.ConfigMap
+		token       *ext.Token
+		wantErr     func(error) bool
+		wantCluster string
+		wantTTL     int64
+	}{
+		{
+			desc:        "cluster token",
+			configMap:   newConfigMap(userID, true, []string{downstream1, downstream2}, "token-abcde", expiredToken),
+			token:       &ext.Token{Spec: ext.TokenSpec{ClusterName: downstream1}},
+			wantCluster: downstream1,
+			wantTTL:     3600 * 1000,
+		},
+		{
+			desc:      "token for rancher with a shorter ttl",
+			configMap: newConfigMap(userID, true, []string{"*"}),
+			token:     &ext.Token{Spec: ext.TokenSpec{TTL: 60 * 1000}},
+			wantTTL:   60 * 1000,
+		},
+		{
+			desc:      "ttl exceeds the kubeconfig ttl",
+			configMap: newConfigMap(userID, true, []string{downstream1}),
+			token:     &ext.Token{Spec: ext.TokenSpec{TTL: 3601 * 1000}},
+			wantErr:   apierrors.IsBadRequest,
+		},
+		{
+			desc:      "kubeconfig embeds tokens",
+			configMap: newConfigMap(userID, false, []string{downstream1}),
+			token:     &ext.Token{Spec: ext.TokenSpec{ClusterName: downstream1}},
+			wantErr:   apierrors.IsBadRequest,
+		},
+		{
+			desc:      "kubeconfig of another user",
+			configMap: newConfigMap(adminID, true, []string{downstream1}),
+			token:     &ext.Token{Spec: ext.TokenSpec{ClusterName: downstream1}},
+			wantErr:   apierrors.IsNotFound,
+		},
+		{
+			desc:      "cluster not in the kubeconfig",
+			configMap: newConfigMap(userID, true, []string{downstream2}),
+			token:     &ext.Token{Spec: ext.TokenSpec{ClusterName: downstream1}},
+			wantErr:   apierrors.IsForbidden,
+		},
+		{
+			desc:      "access to the cluster revoked",
+			configMap: newConfigMap(userID, true, []string{"*"}),
+			token:     &ext.Token{Spec: ext.TokenSpec{ClusterName: downstream2}},
+			wantErr:   apierrors.IsForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			configMapCache := fake.NewMockCacheInterface[*corev1.ConfigMap](ctrl)
+			configMapCache.EXPECT().Get(namespace, kubeconfigID).Return(tt.configMap.DeepCopy(), nil)
+
+			var updated *corev1.ConfigMap
+			configMapClient := fake.NewMockClientInterface[*corev1.ConfigMap, *corev1.ConfigMapList](ctrl)
+			configMapClient.EXPECT().Get(namespace, kubeconfigID, metav1.GetOptions{}).Return(tt.configMap.DeepCopy(), nil).AnyTimes()
+			configMapClient.EXPECT().Update(gomock.Any()).DoAndReturn(func(obj *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+				updated = obj.DeepCopy()
+				return updated, nil
+			}).AnyTimes()
+
+			var input user.TokenInput
+			var clusterID string
+			tokenManager := &fakeTokenManager{}
+			tokenManager.ensureClusterTokenFunc = func(id string, in user.TokenInput) (string, runtime.Object, error) {
+				clusterID, input = id, in
+				tokenManager.ensureClusterTokenFunc = nil
+				return tokenManager.EnsureClusterToken(id, in)
+			}
+			tokenManager.ensureTokenFunc = func(in user.TokenInput) (string, runtime.Object, error) {
+				input = in
+				tokenManager.ensureTokenFunc = nil
+				return tokenManager.EnsureToken(in)
+			}
+
+			store := NewTokenStore(&Store{
+				authorizer:      clusterAuthorizer,
+				configMapCache:  configMapCache,
+				configMapClient: configMapClient,
+				userCache:       userCache,
+				tokenCache:      tokenCache,
+				clusterCache:    clusterCache,
+				tokenMgr:        tokenManager,
+			})
+
+			obj, err := store.Create(ctx, kubeconfigID, tt.token, nil, &metav1.CreateOptions{})
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.True(t, tt.wantErr(err), err)
+				assert.Nil(t, updated)
+				return
+			}
+			require.NoError(t, err)
+
+			token := obj.(*ext.Token)
+			assert.Equal(t, tt.wantCluster, token.Spec.ClusterName)
+			assert.Equal(t, tt.wantTTL, token.Spec.TTL)
+			assert.NotEmpty(t, token.Status.BearerToken)
+			assert.Equal(t, token.Status.BearerToken, token.Status.Value)
+			expiresAt, err := time.Parse(time.RFC3339, token.Status.ExpiresAt)
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(time.Duration(tt.wantTTL)*time.Millisecond), expiresAt, time.Minute)
+
+			assert.Equal(t, tt.wantCluster, clusterID)
+			require.NotNil(t, input.TTL)
+			assert.Equal(t, tt.wantTTL, *input.TTL)
+			assert.Equal(t, kubeconfigID, input.Labels[tokens.TokenKubeconfigIDLabel])
+
+			require.NotNil(t, updated)
+			recorded, err := (&Store{}).fromConfigMap(updated)
+			require.NoError(t, err)
+			require.NotEmpty(t, recorded.Status.Tokens)
+			assert.Equal(t, token.Name, recorded.Status.Tokens[0])
+			assert.NotContains(t, recorded.Status.Tokens, expiredToken)
+			assert.Empty(t, updated.OwnerReferences)
+		})
+	}
+}
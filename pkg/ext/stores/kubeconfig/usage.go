@@ -0,0 +1,136 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// List of the field selectors supported when listing and deleting kubeconfigs, in addition to those of ConfigMaps.
+// The timestamps are either RFC3339 timestamps or durations relative to now, e.g. "720h".
+const (
+	// ClusterFieldSelector selects the kubeconfigs giving access to a cluster, including those for all clusters.
+	ClusterFieldSelector = "spec.clusters"
+	// CreatedBeforeFieldSelector selects the kubeconfigs created before a timestamp.
+	CreatedBeforeFieldSelector = "metadata.createdBefore"
+	// LastUsedBeforeFieldSelector selects the kubeconfigs whose tokens weren't used since a timestamp,
+	// including those which were never used.
+	LastUsedBeforeFieldSelector = "status.lastUsedBefore"
+)
+
+// filter selects kubeconfigs by fields that can't be selected on the ConfigMaps holding them.
+type filter struct {
+	cluster        string
+	createdBefore  *time.Time
+	lastUsedBefore *time.Time
+}
+
+// newFilter extracts the kubeconfig specific requirements from the field selector of the list options,
+// leaving those that apply to the ConfigMaps.
+func newFilter(listOptions *metav1.ListOptions, now time.Time) (*filter, error) {
+	selector, err := fields.ParseSelector(listOptions.FieldSelector)
+	if err != nil {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid field selector: %s", err))
+	}
+
+	f := &filter{}
+	var remaining []fields.Selector
+	for _, requirement := range selector.Requirements() {
+		var timestamp **time.Time
+		switch requirement.Field {
+		case ClusterFieldSelector:
+		case CreatedBeforeFieldSelector:
+			timestamp = &f.createdBefore
+		case LastUsedBeforeFieldSelector:
+			timestamp = &f.lastUsedBefore
+		default: // Left to the ConfigMaps.
+			if requirement.Operator == selection.NotEquals {
+				remaining = append(remaining, fields.OneTermNotEqualSelector(requirement.Field, requirement.Value))
+			} else {
+				remaining = append(remaining, fields.OneTermEqualSelector(requirement.Field, requirement.Value))
+			}
+			continue
+		}
+
+		if requirement.Operator == selection.NotEquals {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("field selector %s only supports the = operator", requirement.Field))
+		}
+
+		if timestamp == nil {
+			f.cluster = requirement.Value
+			continue
+		}
+
+		if *timestamp, err = parseTimestamp(requirement.Value, now); err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid field selector %s: %s", requirement.Field, err))
+		}
+	}
+
+	listOptions.FieldSelector = fields.AndSelectors(remaining...).String()
+
+	return f, nil
+}
+
+// parseTimestamp parses either an RFC3339 timestamp or a duration before now.
+func parseTimestamp(value string, now time.Time) (*time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		t := now.Add(-d)
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a RFC3339 timestamp nor a duration", value)
+	}
+	return &t, nil
+}
+
+// matches returns true if the kubeconfig, with its usage set, matches the filter.
+func (f *filter) matches(kubeconfig *ext.Kubeconfig) bool {
+	if f.cluster != "" {
+		clusters := kubeconfig.Spec.Clusters
+		if len(clusters) == 0 || (clusters[0] != "*" && !slices.Contains(clusters, f.cluster)) {
+			return false
+		}
+	}
+
+	if f.createdBefore != nil && !kubeconfig.CreationTimestamp.Time.Before(*f.createdBefore) {
+		return false
+	}
+
+	if f.lastUsedBefore != nil && kubeconfig.Status.LastUsedAt != nil &&
+		!kubeconfig.Status.LastUsedAt.Time.Before(*f.lastUsedBefore) {
+		return false
+	}
+
+	return true
+}
+
+// setUsage sets the last time any of the kubeconfig tokens was used.
+// Tokens that no longer exist, e.g. expired ones, are ignored.
+func (s *Store) setUsage(kubeconfig *ext.Kubeconfig) error {
+	for _, name := range kubeconfig.Status.Tokens {
+		token, err := s.tokenCache.Get(name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("error getting token %s: %w", name, err)
+		}
+
+		if token.LastUsedAt == nil {
+			continue
+		}
+		if kubeconfig.Status.LastUsedAt == nil || token.LastUsedAt.After(kubeconfig.Status.LastUsedAt.Time) {
+			kubeconfig.Status.LastUsedAt = token.LastUsedAt.DeepCopy()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package kubeconfig
+
+import (
+	"testing"
+	"time"
+
+	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewFilter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("kubeconfig fields are extracted", func(t *testing.T) {
+		listOptions := &metav1.ListOptions{
+			FieldSelector: "spec.clusters=c-m-tbgzfbgf,metadata.createdBefore=2025-01-01T00:00:00Z,status.lastUsedBefore=24h,metadata.name!=kubeconfig-49d5p",
+		}
+
+		f, err := newFilter(listOptions, now)
+		require.NoError(t, err)
+		assert.Equal(t, "c-m-tbgzfbgf", f.cluster)
+		require.NotNil(t, f.createdBefore)
+		assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), *f.createdBefore)
+		require.NotNil(t, f.lastUsedBefore)
+		assert.Equal(t, now.Add(-24*time.Hour), *f.lastUsedBefore)
+		assert.Equal(t, "metadata.name!=kubeconfig-49d5p", listOptions.FieldSelector)
+	})
+	t.Run("no field selector", func(t *testing.T) {
+		listOptions := &metav1.ListOptions{}
+
+		f, err := newFilter(listOptions, now)
+		require.NoError(t, err)
+		assert.Equal(t, &filter{}, f)
+		assert.Empty(t, listOptions.FieldSelector)
+	})
+	t.Run("invalid timestamp", func(t *testing.T) {
+		_, err := newFilter(&metav1.ListOptions{FieldSelector: "status.lastUsedBefore=yesterday"}, now)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsBadRequest(err))
+	})
+	t.Run("unsupported operator", func(t *testing.T) {
+		_, err := newFilter(&metav1.ListOptions{FieldSelector: "spec.clusters!=c-m-tbgzfbgf"}, now)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsBadRequest(err))
+	})
+}
+
+func TestFilterMatches(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	before := now.Add(-time.Hour)
+
+	kubeconfig := func(clusters []string, created time.Time, lastUsed *time.Time) *ext.Kubeconfig {
+		kubeconfig := &ext.Kubeconfig{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)},
+			Spec:       ext.KubeconfigSpec{Clusters: clusters},
+		}
+		if lastUsed != nil {
+			kubeconfig.Status.LastUsedAt = &metav1.Time{Time: *lastUsed}
+		}
+		return kubeconfig
+	}
+
+	tests := []struct {
+		desc       string
+		filter     *filter
+		kubeconfig *ext.Kubeconfig
+		want       bool
+	}{
+		{
+			desc:       "empty filter",
+			filter:     &filter{},
+			kubeconfig: kubeconfig(nil, now, nil),
+			want:       true,
+		},
+		{
+			desc:       "listed cluster",
+			filter:     &filter{cluster: "c-m-tbgzfbgf"},
+			kubeconfig: kubeconfig([]string{"c-m-bxn2p7w6", "c-m-tbgzfbgf"}, now, nil),
+			want:       true,
+		},
+		{
+			desc:       "all clusters",
+			filter:     &filter{cluster: "c-m-tbgzfbgf"},
+			kubeconfig: kubeconfig([]string{"*"}, now, nil),
+			want:       true,
+		},
+		{
+			desc:       "other cluster",
+			filter:     &filter{cluster: "c-m-tbgzfbgf"},
+			kubeconfig: kubeconfig([]string{"c-m-bxn2p7w6"}, now, nil),
+		},
+		{
+			desc:       "no cluster",
+			filter:     &filter{cluster: "c-m-tbgzfbgf"},
+			kubeconfig: kubeconfig(nil, now, nil),
+		},
+		{
+			desc:       "created before",
+			filter:     &filter{createdBefore: &before},
+			kubeconfig: kubeconfig(nil, now.Add(-2*time.Hour), nil),
+			want:       true,
+		},
+		{
+			desc:       "created after",
+			filter:     &filter{createdBefore: &before},
+			kubeconfig: kubeconfig(nil, now, nil),
+		},
+		{
+			desc:       "never used",
+			filter:     &filter{lastUsedBefore: &before},
+			kubeconfig: kubeconfig(nil, now, nil),
+			want:       true,
+		},
+		{
+			desc:       "last used before",
+			filter:     &filter{lastUsedBefore: &before},
+			kubeconfig: kubeconfig(nil, now, &[]time.Time{now.Add(-2 * time.Hour)}[0]),
+			want:       true,
+		},
+		{
+			desc:       "used recently",
+			filter:     &filter{lastUsedBefore: &before},
+			kubeconfig: kubeconfig(nil, now, &now),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.matches(tt.kubeconfig))
+		})
+	}
+}
@@ -114,23 +114,24 @@ type Store struct {
 // words, it generally has access to all the tokens, in all ways.
 type SystemStore struct {
 	authorizer      authorizer.Authorizer
-	namespaceClient v1.NamespaceClient  // access to namespaces.
-	namespaceCache  v1.NamespaceCache   // quick access to namespaces.
-	secretClient    v1.SecretClient     // direct access to the backing secrets
-	secretCache     v1.SecretCache      // cached access to the backing secrets
-	userClient      v3.UserCache        // cached access to the v3.Users
-	v3TokenClient   v3.TokenCache       // cached access to v3.Tokens. See Fetch.
-	clusterCache    v3.ClusterCache     // cached access to cluster for presence checks
-	timer           timeHandler         // access to timestamp generation
-	hasher          hashHandler         // access to generation and hashing of secret values
-	auth            authHandler         // access to user retrieval from context
-	tableConverter  rest.TableConvertor // custom column formatting
+	namespaceClient v1.NamespaceClient    // access to namespaces.
+	namespaceCache  v1.NamespaceCache     // quick access to namespaces.
+	secretClient    v1.SecretClient       // direct access to the backing secrets
+	secretCache     v1.SecretCache        // cached access to the backing secrets
+	userClient      v3.UserCache          // cached access to the v3.Users
+	v3TokenClient   v3.TokenCache         // cached access to v3.Tokens. See Fetch.
+	clusterCache    v3.ClusterCache       // cached access to cluster for presence checks
+	timer           timeHandler           // access to timestamp generation
+	hasher          hashHandler           // access to generation and hashing of secret values
+	auth            authHandler           // access to user retrieval from context
+	tableConverter  rest.TableConvertor   // custom column formatting
+	sessionPolicies sessionPolicyResolver // resolution of the session timeout policies shown on the status
 }
 
 // NewFromWrangler is a convenience function for creating a token store.
 // It initializes the returned store from the provided wrangler context.
 func NewFromWrangler(wranglerContext *wrangler.Context, authorizer authorizer.Authorizer) *Store {
-	return New(
+	store := New(
 		authorizer,
 		wranglerContext.Core.Namespace(),
 		wranglerContext.Core.Namespace().Cache(),
@@ -142,6 +143,8 @@ func NewFromWrangler(wranglerContext *wrangler.Context, authorizer authorizer.Au
 		NewHashHandler(),
 		NewAuthHandler(),
 	)
+	store.sessionPolicies = tokens.NewSessionPolicyResolver(wranglerContext)
+	return store
 }
 
 // New is the main constructor for token stores. It is supplied with accessors
@@ -441,6 +444,7 @@ func (t *Store) Get(
 	token.Status.Current = token.Name == authTokenID
 	token.Status.Value = ""
 	token.Status.BearerToken = ""
+	t.setSessionPolicy(token)
 
 	return token, nil
 }
@@ -870,6 +874,30 @@ func (t *SystemStore) ListForUser(userName string) (*ext.TokenList, error) {
 	}, nil
 }
 
+// ListAll returns the tokens of all users. It is an internal call invoked by
+// other parts of Rancher
+func (t *SystemStore) ListAll() (*ext.TokenList, error) {
+	secrets, err := t.secretCache.List(TokenNamespace, labels.Everything())
+	if err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("failed to list tokens: %w", err))
+	}
+
+	var tokens []ext.Token
+	for _, secret := range secrets {
+		token, err := fromSecret(secret)
+		// ignore broken tokens
+		if err != nil {
+			continue
+		}
+
+		tokens = append(tokens, *token)
+	}
+
+	return &ext.TokenList{
+		Items: tokens,
+	}, nil
+}
+
 func (t *SystemStore) list(fullAccess bool, userName, authTokenID string, options *metav1.ListOptions) (*ext.TokenList, error) {
 	// Non-system requests always filter the tokens down to those of the current user.
 	// Merge our own selection request (user match!) into the caller's demands
@@ -897,6 +925,7 @@ func (t *SystemStore) list(fullAccess bool, userName, authTokenID string, option
 
 		// Filtering for users is done already, see above where the options are set up and/or merged.
 		token.Status.Current = token.Name == authTokenID
+		t.setSessionPolicy(token)
 		tokens = append(tokens, *token)
 	}
 
@@ -1162,6 +1191,7 @@ func (t *Store) watch(ctx context.Context, options *metav1.ListOptions) (watch.I
 					// ListOptionMerge above) takes care of only
 					// asking for owned tokens
 					token.Status.Current = token.Name == authTokenID
+					t.setSessionPolicy(token)
 					obj = token
 				default: // watch.Error
 					obj = event.Object
@@ -1295,6 +1325,36 @@ func (t *SystemStore) Fetch(tokenID string) (accessor.TokenAccessor, error) {
 	return nil, fmt.Errorf("unable to fetch unknown token %q", tokenID)
 }
 
+// setSessionPolicy shows the session timeout policies applying to the token on its status.
+// Errors are only logged, as the policies are enforced when authenticating, not here.
+func (t *SystemStore) setSessionPolicy(token *ext.Token) {
+	if t.sessionPolicies == nil {
+		return
+	}
+
+	policy, err := t.sessionPolicies.ResolveForToken(token)
+	if err != nil {
+		logrus.Errorf("tokens: error resolving session timeout policies for token %s: %s", token.Name, err)
+		return
+	}
+	if policy == nil {
+		return
+	}
+
+	token.Status.SessionPolicies = policy.Policies
+	token.Status.IdleTimeoutMinutes = int64(policy.IdleTimeout / time.Minute)
+	token.Status.AbsoluteTimeoutMinutes = int64(policy.AbsoluteTimeout / time.Minute)
+	if expiresAt := policy.ExpiresAt(token); !expiresAt.IsZero() {
+		token.Status.SessionExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	}
+}
+
+// sessionPolicyResolver is a helper interface hiding the resolution of the session
+// timeout policies from the store. This makes the operation mockable for store testing.
+type sessionPolicyResolver interface {
+	ResolveForToken(token accessor.TokenAccessor) (*tokens.SessionPolicy, error)
+}
+
 // timeHandler is a helper interface hiding the details of timestamp generation from
 // the store. This makes the operation mockable for store testing.
 type timeHandler interface {
@@ -11,6 +11,8 @@
 
 	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/accessor"
+	"github.com/rancher/rancher/pkg/auth/tokens"
 	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -616,6 +618,46 @@ func TestStoreGet(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, fieldToken, tok)
 	})
+
+	t.Run("ok, session timeout policy", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		secrets := fake.NewMockControllerInterface[*corev1.Secret, *corev1.SecretList](ctrl)
+		scache := fake.NewMockCacheInterface[*corev1.Secret](ctrl)
+		users := fake.NewMockNonNamespacedControllerInterface[*v3.User, *v3.UserList](ctrl)
+		auth := NewMockauthHandler(ctrl)
+
+		auth.EXPECT().SessionID(gomock.Any()).Return("", nil)
+		auth.EXPECT().UserName(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&mockUser{name: properUser}, false, true, nil)
+		users.EXPECT().Cache().Return(nil)
+		secrets.EXPECT().Cache().Return(scache)
+		scache.EXPECT().
+			Get("cattle-tokens", "bogus").
+			Return(&properSecret, nil)
+
+		store := New(nil, nil, nil, secrets, users, nil, nil, nil, nil, auth)
+		store.sessionPolicies = fakeSessionPolicyResolver(func(token accessor.TokenAccessor) (*tokens.SessionPolicy, error) {
+			return &tokens.SessionPolicy{
+				Policies:        []string{"admins"},
+				ClientType:      tokens.ClientTypeAPIToken,
+				AbsoluteTimeout: 8 * time.Hour,
+			}, nil
+		})
+		tok, err := store.Get(context.TODO(), "bogus", &metav1.GetOptions{})
+		require.NoError(t, err)
+
+		wantToken := properToken.DeepCopy()
+		wantToken.Status.SessionPolicies = []string{"admins"}
+		wantToken.Status.AbsoluteTimeoutMinutes = 480
+		wantToken.Status.SessionExpiresAt = properToken.CreationTimestamp.Add(8 * time.Hour).UTC().Format(time.RFC3339)
+		assert.Equal(t, wantToken, tok)
+	})
+}
+
+type fakeSessionPolicyResolver func(token accessor.TokenAccessor) (*tokens.SessionPolicy, error)
+
+func (f fakeSessionPolicyResolver) ResolveForToken(token accessor.TokenAccessor) (*tokens.SessionPolicy, error) {
+	return f(token)
 }
 
 // This test suite is a bit special, as it is not table driven like all other suites coming
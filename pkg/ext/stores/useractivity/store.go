@@ -43,6 +43,11 @@ type Store struct {
 	userCache                        v3.UserCache
 	extTokenStore                    *exttokenstore.SystemStore
 	getAuthUserSessionIdleTTLMinutes func() int
+	sessionPolicies                  sessionPolicyResolver
+}
+
+type sessionPolicyResolver interface {
+	ResolveForToken(token accessor.TokenAccessor) (*tokens.SessionPolicy, error)
 }
 
 var (
@@ -58,6 +63,7 @@ func New(wranglerCtx *wrangler.Context, authorizer authorizer.Authorizer) *Store
 		userCache:                        wranglerCtx.Mgmt.User().Cache(),
 		extTokenStore:                    exttokenstore.NewSystemFromWrangler(wranglerCtx),
 		getAuthUserSessionIdleTTLMinutes: settings.AuthUserSessionIdleTTLMinutes.GetInt,
+		sessionPolicies:                  tokens.NewSessionPolicyResolver(wranglerCtx),
 	}
 }
 
@@ -125,7 +131,10 @@ func (s *Store) Get(ctx context.Context,
 		return nil, err
 	}
 
-	idleTimeout := s.getAuthUserSessionIdleTTLMinutes()
+	idleTimeout, err := s.idleTimeout(token)
+	if err != nil {
+		return nil, apierrors.NewInternalError(fmt.Errorf("error getting idle timeout of token %s: %w", name, err))
+	}
 
 	userActivity, err := s.fromToken(token, idleTimeout)
 	if err != nil {
@@ -182,7 +191,10 @@ func (s *Store) Update(
 	}
 
 	// Maintain the same idle timeout value when reading and updating the UserActivity.
-	idleTimeout := s.getAuthUserSessionIdleTTLMinutes()
+	idleTimeout, err := s.idleTimeout(token)
+	if err != nil {
+		return nil, false, apierrors.NewInternalError(fmt.Errorf("error getting idle timeout of token %s: %w", name, err))
+	}
 	oldUserActivity, err := s.fromToken(token, idleTimeout)
 	if err != nil {
 		return nil, false, apierrors.NewInternalError(fmt.Errorf("error creating useractivity from token %s: %w", name, err))
@@ -223,8 +235,13 @@ func (s *Store) Update(
 		seen = userActivity.Spec.SeenAt.Time
 	}
 
-	shouldUpdate := true
 	lastSeen := token.GetLastActivitySeen()
+	// The session timeout policies may have shortened the idle timeout checked by validateToken.
+	if lastSeen != nil && idleTimeout > 0 && now.After(lastSeen.Add(time.Minute*time.Duration(idleTimeout))) {
+		return nil, false, apierrors.NewForbidden(gvr.GroupResource(), name, errors.New("session idle timeout expired"))
+	}
+
+	shouldUpdate := true
 	if lastSeen != nil && seen.Before(lastSeen.Time) {
 		// If the SeenAt provided is before the last activity we have recorded,
 		// we don't update the last activity time.
@@ -308,6 +325,28 @@ func (s *Store) fromToken(obj any, idleTimeout int) (*ext.UserActivity, error) {
 	return activity, nil
 }
 
+// idleTimeout returns the idle timeout of a session token in minutes,
+// shortened by the session timeout policies applying to it, if any.
+func (s *Store) idleTimeout(token accessor.TokenAccessor) (int, error) {
+	idleTimeout := s.getAuthUserSessionIdleTTLMinutes()
+	if s.sessionPolicies == nil {
+		return idleTimeout, nil
+	}
+
+	policy, err := s.sessionPolicies.ResolveForToken(token)
+	if err != nil {
+		return 0, err
+	}
+
+	if policy != nil && policy.IdleTimeout > 0 {
+		if minutes := int(policy.IdleTimeout / time.Minute); idleTimeout == 0 || minutes < idleTimeout {
+			idleTimeout = minutes
+		}
+	}
+
+	return idleTimeout, nil
+}
+
 // userFrom is a helper that extracts and validates the user info from the request's context.
 func (s *Store) userFrom(ctx context.Context, verb string) (k8suser.Info, bool, bool, error) {
 	userInfo, ok := request.UserFrom(ctx)
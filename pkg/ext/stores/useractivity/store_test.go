@@ -12,6 +12,7 @@
 	ext "github.com/rancher/rancher/pkg/apis/ext.cattle.io/v1"
 	mgmt "github.com/rancher/rancher/pkg/apis/management.cattle.io"
 	apiv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/accessor"
 	"github.com/rancher/rancher/pkg/auth/providers/common"
 	authTokens "github.com/rancher/rancher/pkg/auth/tokens"
 	exttokens "github.com/rancher/rancher/pkg/ext/stores/tokens"
@@ -687,3 +688,85 @@ func TestStoreGet(t *testing.T) {
 		})
 	}
 }
+
+type fakeSessionPolicyResolver func(token accessor.TokenAccessor) (*authTokens.SessionPolicy, error)
+
+func (f fakeSessionPolicyResolver) ResolveForToken(token accessor.TokenAccessor) (*authTokens.SessionPolicy, error) {
+	return f(token)
+}
+
+func TestStoreIdleTimeout(t *testing.T) {
+	token := &apiv3.Token{ObjectMeta: metav1.ObjectMeta{Name: tokenID}}
+
+	tests := []struct {
+		desc       string
+		settingTTL int
+		policy     *authTokens.SessionPolicy
+		policyErr  error
+		want       int
+		wantErr    bool
+		noResolver bool
+	}{
+		{
+			desc:       "no resolver",
+			settingTTL: defaultIdleTTL,
+			noResolver: true,
+			want:       defaultIdleTTL,
+		},
+		{
+			desc:       "no policy",
+			settingTTL: defaultIdleTTL,
+			want:       defaultIdleTTL,
+		},
+		{
+			desc:       "policy shortens the idle timeout",
+			settingTTL: defaultIdleTTL,
+			policy:     &authTokens.SessionPolicy{IdleTimeout: 15 * time.Minute},
+			want:       15,
+		},
+		{
+			desc:       "policy can't lengthen the idle timeout",
+			settingTTL: 10,
+			policy:     &authTokens.SessionPolicy{IdleTimeout: 15 * time.Minute},
+			want:       10,
+		},
+		{
+			desc:       "policy without idle timeout",
+			settingTTL: defaultIdleTTL,
+			policy:     &authTokens.SessionPolicy{AbsoluteTimeout: time.Hour},
+			want:       defaultIdleTTL,
+		},
+		{
+			desc:       "policy applies when the setting is disabled",
+			settingTTL: 0,
+			policy:     &authTokens.SessionPolicy{IdleTimeout: 15 * time.Minute},
+			want:       15,
+		},
+		{
+			desc:       "error resolving policies",
+			settingTTL: defaultIdleTTL,
+			policyErr:  errors.New("some error"),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			store := &Store{
+				getAuthUserSessionIdleTTLMinutes: func() int { return tt.settingTTL },
+			}
+			if !tt.noResolver {
+				store.sessionPolicies = fakeSessionPolicyResolver(func(accessor.TokenAccessor) (*authTokens.SessionPolicy, error) {
+					return tt.policy, tt.policyErr
+				})
+			}
+
+			got, err := store.idleTimeout(token)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
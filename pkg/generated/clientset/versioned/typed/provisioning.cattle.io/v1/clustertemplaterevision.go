@@ -0,0 +1,74 @@
+/*
+Copyright 2026 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package v1
+
+import (
+	context "context"
+
+	provisioningcattleiov1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	scheme "github.com/rancher/rancher/pkg/generated/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// ClusterTemplateRevisionsGetter has a method to return a ClusterTemplateRevisionInterface.
+// A group's client should implement this interface.
+type ClusterTemplateRevisionsGetter interface {
+	ClusterTemplateRevisions(namespace string) ClusterTemplateRevisionInterface
+}
+
+// ClusterTemplateRevisionInterface has methods to work with ClusterTemplateRevision resources.
+type ClusterTemplateRevisionInterface interface {
+	Create(ctx context.Context, clusterTemplateRevision *provisioningcattleiov1.ClusterTemplateRevision, opts metav1.CreateOptions) (*provisioningcattleiov1.ClusterTemplateRevision, error)
+	Update(ctx context.Context, clusterTemplateRevision *provisioningcattleiov1.ClusterTemplateRevision, opts metav1.UpdateOptions) (*provisioningcattleiov1.ClusterTemplateRevision, error)
+	// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+	UpdateStatus(ctx context.Context, clusterTemplateRevision *provisioningcattleiov1.ClusterTemplateRevision, opts metav1.UpdateOptions) (*provisioningcattleiov1.ClusterTemplateRevision, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*provisioningcattleiov1.ClusterTemplateRevision, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*provisioningcattleiov1.ClusterTemplateRevisionList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *provisioningcattleiov1.ClusterTemplateRevision, err error)
+	ClusterTemplateRevisionExpansion
+}
+
+// clusterTemplateRevisions implements ClusterTemplateRevisionInterface
+type clusterTemplateRevisions struct {
+	*gentype.ClientWithList[*provisioningcattleiov1.ClusterTemplateRevision, *provisioningcattleiov1.ClusterTemplateRevisionList]
+}
+
+// newClusterTemplateRevisions returns a ClusterTemplateRevisions
+func newClusterTemplateRevisions(c *ProvisioningV1Client, namespace string) *clusterTemplateRevisions {
+	return &clusterTemplateRevisions{
+		gentype.NewClientWithList[*provisioningcattleiov1.ClusterTemplateRevision, *provisioningcattleiov1.ClusterTemplateRevisionList](
+			"clustertemplaterevisions",
+			c.RESTClient(),
+			scheme.ParameterCodec,
+			namespace,
+			func() *provisioningcattleiov1.ClusterTemplateRevision {
+				return &provisioningcattleiov1.ClusterTemplateRevision{}
+			},
+			func() *provisioningcattleiov1.ClusterTemplateRevisionList {
+				return &provisioningcattleiov1.ClusterTemplateRevisionList{}
+			},
+		),
+	}
+}
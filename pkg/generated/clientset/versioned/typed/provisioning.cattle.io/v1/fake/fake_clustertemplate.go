@@ -0,0 +1,50 @@
+/*
+Copyright 2026 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package fake
+
+import (
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	provisioningcattleiov1 "github.com/rancher/rancher/pkg/generated/clientset/versioned/typed/provisioning.cattle.io/v1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeClusterTemplates implements ClusterTemplateInterface
+type fakeClusterTemplates struct {
+	*gentype.FakeClientWithList[*v1.ClusterTemplate, *v1.ClusterTemplateList]
+	Fake *FakeProvisioningV1
+}
+
+func newFakeClusterTemplates(fake *FakeProvisioningV1, namespace string) provisioningcattleiov1.ClusterTemplateInterface {
+	return &fakeClusterTemplates{
+		gentype.NewFakeClientWithList[*v1.ClusterTemplate, *v1.ClusterTemplateList](
+			fake.Fake,
+			namespace,
+			v1.SchemeGroupVersion.WithResource("clustertemplates"),
+			v1.SchemeGroupVersion.WithKind("ClusterTemplate"),
+			func() *v1.ClusterTemplate { return &v1.ClusterTemplate{} },
+			func() *v1.ClusterTemplateList { return &v1.ClusterTemplateList{} },
+			func(dst, src *v1.ClusterTemplateList) { dst.ListMeta = src.ListMeta },
+			func(list *v1.ClusterTemplateList) []*v1.ClusterTemplate { return gentype.ToPointerSlice(list.Items) },
+			func(list *v1.ClusterTemplateList, items []*v1.ClusterTemplate) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2026 Rancher Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by main. DO NOT EDIT.
+
+package fake
+
+import (
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	provisioningcattleiov1 "github.com/rancher/rancher/pkg/generated/clientset/versioned/typed/provisioning.cattle.io/v1"
+	gentype "k8s.io/client-go/gentype"
+)
+
+// fakeClusterTemplateRevisions implements ClusterTemplateRevisionInterface
+type fakeClusterTemplateRevisions struct {
+	*gentype.FakeClientWithList[*v1.ClusterTemplateRevision, *v1.ClusterTemplateRevisionList]
+	Fake *FakeProvisioningV1
+}
+
+func newFakeClusterTemplateRevisions(fake *FakeProvisioningV1, namespace string) provisioningcattleiov1.ClusterTemplateRevisionInterface {
+	return &fakeClusterTemplateRevisions{
+		gentype.NewFakeClientWithList[*v1.ClusterTemplateRevision, *v1.ClusterTemplateRevisionList](
+			fake.Fake,
+			namespace,
+			v1.SchemeGroupVersion.WithResource("clustertemplaterevisions"),
+			v1.SchemeGroupVersion.WithKind("ClusterTemplateRevision"),
+			func() *v1.ClusterTemplateRevision { return &v1.ClusterTemplateRevision{} },
+			func() *v1.ClusterTemplateRevisionList { return &v1.ClusterTemplateRevisionList{} },
+			func(dst, src *v1.ClusterTemplateRevisionList) { dst.ListMeta = src.ListMeta },
+			func(list *v1.ClusterTemplateRevisionList) []*v1.ClusterTemplateRevision {
+				return gentype.ToPointerSlice(list.Items)
+			},
+			func(list *v1.ClusterTemplateRevisionList, items []*v1.ClusterTemplateRevision) {
+				list.Items = gentype.FromPointerSlice(items)
+			},
+		),
+		fake,
+	}
+}
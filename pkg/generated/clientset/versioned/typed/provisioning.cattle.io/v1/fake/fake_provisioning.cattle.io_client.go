@@ -32,6 +32,14 @@ func (c *FakeProvisioningV1) Clusters(namespace string) v1.ClusterInterface {
 	return newFakeClusters(c, namespace)
 }
 
+func (c *FakeProvisioningV1) ClusterTemplates(namespace string) v1.ClusterTemplateInterface {
+	return newFakeClusterTemplates(c, namespace)
+}
+
+func (c *FakeProvisioningV1) ClusterTemplateRevisions(namespace string) v1.ClusterTemplateRevisionInterface {
+	return newFakeClusterTemplateRevisions(c, namespace)
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeProvisioningV1) RESTClient() rest.Interface {
@@ -19,3 +19,7 @@
 package v1
 
 type ClusterExpansion interface{}
+
+type ClusterTemplateExpansion interface{}
+
+type ClusterTemplateRevisionExpansion interface{}
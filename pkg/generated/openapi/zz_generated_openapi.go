@@ -416,6 +416,13 @@ func schema_pkg_apis_extcattleio_v1_KubeconfigSpec(ref common.ReferenceCallback)
 							Format:      "int64",
 						},
 					},
+					"exec": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Exec indicates that the kubeconfig doesn't embed tokens, but uses the exec credential plugin of the Rancher CLI to obtain short-lived, cluster-scoped tokens through the token subresource of the Kubeconfig. The TTL is then the time-to-live of each of these tokens, capped by kubeconfig-exec-token-ttl-minutes.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -475,6 +482,12 @@ func schema_pkg_apis_extcattleio_v1_KubeconfigStatus(ref common.ReferenceCallbac
 							},
 						},
 					},
+					"lastUsedAt": {
+						SchemaProps: spec.SchemaProps{
+							Description: "LastUsedAt is the timestamp of the last time any of the Kubeconfig tokens was used to authenticate.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
 					"value": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Value contains the generated content of the kubeconfig.",
@@ -486,7 +499,7 @@ func schema_pkg_apis_extcattleio_v1_KubeconfigStatus(ref common.ReferenceCallbac
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/apimachinery/pkg/apis/meta/v1.Condition"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Condition", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -1097,6 +1110,42 @@ func schema_pkg_apis_extcattleio_v1_TokenStatus(ref common.ReferenceCallback) co
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
 						},
 					},
+					"sessionPolicies": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SessionPolicies are the names of the session timeout policies applying to the token, if any.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"idleTimeoutMinutes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "IdleTimeoutMinutes is the shortest idle timeout of the session timeout policies applying to the token.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"absoluteTimeoutMinutes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AbsoluteTimeoutMinutes is the shortest absolute timeout of the session timeout policies applying to the token.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"sessionExpiresAt": {
+						SchemaProps: spec.SchemaProps{
+							Description: "SessionExpiresAt is the timestamp the token expires at under the session timeout policies applying to it, given its recorded activity.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"bearerToken": {
 						SchemaProps: spec.SchemaProps{
 							Description: "Fully formed bearer token that is ready to use in the Authorization header to authenticate to Rancher.",
@@ -0,0 +1,195 @@
+// Package bundle builds and publishes airgap bundles containing the images and charts used by Rancher. A bundle is an
+// OCI image layout, optionally packed as a single tar archive, which can be produced and pushed to a private registry
+// without a container runtime.
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	specv1 "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// DigestsFilename is the name of the file, at the root of a bundle, listing every image of the bundle pinned by
+	// the digest it had in its source registry.
+	DigestsFilename = "rancher-images-digests.txt"
+	// ChartsDir is the directory, at the root of a bundle, containing the chart archives.
+	ChartsDir = "charts"
+	// SourceDigestAnnotation records the digest of an image in its source registry. It differs from the digest of
+	// the manifest stored in the bundle when the platforms of an index were filtered.
+	SourceDigestAnnotation = "io.cattle.bundle.source-digest"
+)
+
+// Options configures the content and the format of a bundle.
+type Options struct {
+	// Platforms restricts the images of the bundle to the given platforms. All platforms are kept when empty.
+	Platforms []v1.Platform
+	// Tarball packs the bundle as a single tar archive instead of an OCI image layout directory.
+	Tarball bool
+	// RemoteOptions are used to pull the images from their source registries.
+	RemoteOptions []remote.Option
+}
+
+// Export pulls every image and copies every chart archive into a bundle written at output. Images are pinned by the
+// digest their tag resolves to at the time of the export.
+func Export(ctx context.Context, output string, images, chartArchives []string, opts Options) error {
+	dir := output
+	if opts.Tarball {
+		tmp, err := os.MkdirTemp("", "rancher-bundle")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	p, err := layout.Write(dir, empty.Index)
+	if err != nil {
+		return fmt.Errorf("failed to create OCI layout at %s: %w", dir, err)
+	}
+
+	var pinned []string
+	for _, image := range uniqueSorted(images) {
+		log.Printf("Adding image %s\n", image)
+		digest, err := exportImage(ctx, p, image, opts)
+		if err != nil {
+			return fmt.Errorf("failed to add image %s to bundle: %w", image, err)
+		}
+		if digest != "" {
+			pinned = append(pinned, digest)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, DigestsFilename), []byte(strings.Join(pinned, "\n")+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, ChartsDir), 0o755); err != nil {
+		return err
+	}
+	for _, archive := range uniqueSorted(chartArchives) {
+		log.Printf("Adding chart %s\n", archive)
+		if err := copyFile(archive, filepath.Join(dir, ChartsDir, filepath.Base(archive))); err != nil {
+			return fmt.Errorf("failed to add chart %s to bundle: %w", archive, err)
+		}
+	}
+
+	if opts.Tarball {
+		log.Printf("Creating %s\n", output)
+		return writeTarball(dir, output)
+	}
+	return nil
+}
+
+// exportImage appends the image to the layout and returns its reference pinned by digest, or an empty string if none
+// of its platforms were selected.
+func exportImage(ctx context.Context, p layout.Path, image string, opts Options) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+
+	desc, err := remote.Get(ref, append(opts.RemoteOptions, remote.WithContext(ctx))...)
+	if err != nil {
+		return "", err
+	}
+
+	annotations := map[string]string{
+		specv1.AnnotationRefName: image,
+		SourceDigestAnnotation:   desc.Digest.String(),
+	}
+	pinned := ref.Context().Name() + "@" + desc.Digest.String()
+
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return "", err
+		}
+		if len(opts.Platforms) > 0 {
+			idx = mutate.RemoveManifests(idx, func(d v1.Descriptor) bool {
+				return d.Platform != nil && !matchesPlatform(*d.Platform, opts.Platforms)
+			})
+			manifest, err := idx.IndexManifest()
+			if err != nil {
+				return "", err
+			}
+			if len(manifest.Manifests) == 0 {
+				log.Printf("Skipping image %s, no manifest matches the selected platforms\n", image)
+				return "", nil
+			}
+		}
+		return pinned, p.AppendIndex(idx, layout.WithAnnotations(annotations))
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return "", err
+	}
+	if len(opts.Platforms) > 0 {
+		config, err := img.ConfigFile()
+		if err != nil {
+			return "", err
+		}
+		// artifacts such as OCI charts have no platform and are always kept
+		if platform := config.Platform(); platform != nil && !matchesPlatform(*platform, opts.Platforms) {
+			log.Printf("Skipping image %s, platform %s/%s is not selected\n", image, config.OS, config.Architecture)
+			return "", nil
+		}
+	}
+	return pinned, p.AppendImage(img, layout.WithAnnotations(annotations))
+}
+
+func matchesPlatform(platform v1.Platform, platforms []v1.Platform) bool {
+	for _, spec := range platforms {
+		if platform.Satisfies(spec) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueSorted(values []string) []string {
+	set := make(map[string]struct{}, len(values))
+	var unique []string
+	for _, value := range values {
+		if _, ok := set[value]; ok || value == "" {
+			continue
+		}
+		set[value] = struct{}{}
+		unique = append(unique, value)
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,181 @@
+package bundle
+
+import (
+	"archive/tar"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAndPush(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	amd64 := v1.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := v1.Platform{OS: "linux", Architecture: "arm64"}
+	windows := v1.Platform{OS: "windows", Architecture: "amd64"}
+
+	multiArch := host + "/rancher/multi-arch:v1.0.0"
+	idx := mutate.AppendManifests(empty.Index,
+		platformManifest(t, amd64), platformManifest(t, arm64), platformManifest(t, windows))
+	require.NoError(t, remote.WriteIndex(mustParse(t, multiArch), idx))
+
+	single := host + "/rancher/single:v2.0.0"
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(mustParse(t, single), img))
+
+	tests := []struct {
+		name          string
+		platforms     []v1.Platform
+		tarball       bool
+		wantPlatforms []v1.Platform
+	}{
+		{
+			name:          "all platforms",
+			wantPlatforms: []v1.Platform{amd64, arm64, windows},
+		},
+		{
+			name:          "linux only as tarball",
+			platforms:     []v1.Platform{{OS: "linux"}},
+			tarball:       true,
+			wantPlatforms: []v1.Platform{amd64, arm64},
+		},
+		{
+			name:          "single platform",
+			platforms:     []v1.Platform{arm64},
+			wantPlatforms: []v1.Platform{arm64},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			output := filepath.Join(tmp, "bundle")
+			if tt.tarball {
+				output += ".tar"
+			}
+
+			err := Export(context.Background(), output, []string{single, multiArch, single}, nil,
+				Options{Platforms: tt.platforms, Tarball: tt.tarball})
+			require.NoError(t, err)
+
+			if tt.tarball {
+				info, err := os.Stat(output)
+				require.NoError(t, err)
+				assert.False(t, info.IsDir())
+			} else {
+				digests, err := os.ReadFile(filepath.Join(output, DigestsFilename))
+				require.NoError(t, err)
+				assert.Len(t, strings.Fields(string(digests)), 2)
+			}
+
+			target := host + "/" + strings.ReplaceAll(tt.name, " ", "-")
+			require.NoError(t, Push(context.Background(), output, target, PushOptions{Insecure: true}))
+
+			pushedIdx, err := remote.Index(mustParse(t, target+"/rancher/multi-arch:v1.0.0"))
+			require.NoError(t, err)
+			manifest, err := pushedIdx.IndexManifest()
+			require.NoError(t, err)
+			var platforms []v1.Platform
+			for _, desc := range manifest.Manifests {
+				platforms = append(platforms, *desc.Platform)
+			}
+			assert.ElementsMatch(t, tt.wantPlatforms, platforms)
+
+			// an image without an index is kept as the filter cannot be applied to it
+			pushed, err := remote.Image(mustParse(t, target+"/rancher/single:v2.0.0"))
+			require.NoError(t, err)
+			wantDigest, err := img.Digest()
+			require.NoError(t, err)
+			gotDigest, err := pushed.Digest()
+			require.NoError(t, err)
+			assert.Equal(t, wantDigest, gotDigest)
+		})
+	}
+}
+
+func TestExportAnnotations(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	image := strings.TrimPrefix(server.URL, "http://") + "/rancher/image:v1"
+
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(mustParse(t, image), img))
+	digest, err := img.Digest()
+	require.NoError(t, err)
+
+	output := filepath.Join(t.TempDir(), "bundle")
+	require.NoError(t, Export(context.Background(), output, []string{image}, nil, Options{}))
+
+	p, err := layout.FromPath(output)
+	require.NoError(t, err)
+	idx, err := p.ImageIndex()
+	require.NoError(t, err)
+	manifest, err := idx.IndexManifest()
+	require.NoError(t, err)
+	require.Len(t, manifest.Manifests, 1)
+	assert.Equal(t, image, manifest.Manifests[0].Annotations["org.opencontainers.image.ref.name"])
+	assert.Equal(t, digest.String(), manifest.Manifests[0].Annotations[SourceDigestAnnotation])
+}
+
+func TestExtractTarballRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "nested"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "nested", "file"), []byte("content"), 0o644))
+
+	archive := filepath.Join(dir, "bundle.tar")
+	require.NoError(t, writeTarball(src, archive))
+
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, os.MkdirAll(dest, 0o755))
+	require.NoError(t, extractTarball(archive, dest))
+	content, err := os.ReadFile(filepath.Join(dest, "nested", "file"))
+	require.NoError(t, err)
+	assert.Equal(t, "content", string(content))
+
+	evil := filepath.Join(dir, "evil.tar")
+	f, err := os.Create(evil)
+	require.NoError(t, err)
+	tw := tar.NewWriter(f)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../escaped", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4}))
+	_, err = tw.Write([]byte("evil"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, f.Close())
+
+	assert.Error(t, extractTarball(evil, dest))
+	assert.NoFileExists(t, filepath.Join(dir, "escaped"))
+}
+
+func platformManifest(t *testing.T, platform v1.Platform) mutate.IndexAddendum {
+	img, err := random.Image(64, 1)
+	require.NoError(t, err)
+	return mutate.IndexAddendum{
+		Add:        img,
+		Descriptor: v1.Descriptor{Platform: &platform},
+	}
+}
+
+func mustParse(t *testing.T, ref string) name.Reference {
+	r, err := name.ParseReference(ref)
+	require.NoError(t, err)
+	return r
+}
@@ -0,0 +1,168 @@
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	specv1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+// DefaultChartsRepository is the repository, relative to the target registry, charts are pushed to by default.
+const DefaultChartsRepository = "charts"
+
+// PushOptions configures how a bundle is pushed to a registry.
+type PushOptions struct {
+	// RemoteOptions are used to push the images to the target registry.
+	RemoteOptions []remote.Option
+	// ChartsRepository is the repository, relative to the target registry, charts are pushed to.
+	ChartsRepository string
+	// Insecure allows pushing to a registry served over plain HTTP.
+	Insecure bool
+	// ChartClientOptions are used to create the client pushing the charts, typically to configure authentication.
+	ChartClientOptions []registry.ClientOption
+}
+
+// Push publishes the images and charts of the bundle at bundlePath, either an OCI image layout directory or a tar
+// archive created by Export, to the given registry. Images keep their repository and tag, rebased on the registry.
+func Push(ctx context.Context, bundlePath, targetRegistry string, opts PushOptions) error {
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return err
+	}
+
+	dir := bundlePath
+	if !info.IsDir() {
+		tmp, err := os.MkdirTemp("", "rancher-bundle")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		if err := extractTarball(bundlePath, tmp); err != nil {
+			return fmt.Errorf("failed to extract bundle %s: %w", bundlePath, err)
+		}
+		dir = tmp
+	}
+
+	targetRegistry = strings.TrimSuffix(targetRegistry, "/")
+	if err := pushImages(ctx, dir, targetRegistry, opts); err != nil {
+		return err
+	}
+	return pushCharts(dir, targetRegistry, opts)
+}
+
+func pushImages(ctx context.Context, dir, targetRegistry string, opts PushOptions) error {
+	p, err := layout.FromPath(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read OCI layout at %s: %w", dir, err)
+	}
+	index, err := p.ImageIndex()
+	if err != nil {
+		return err
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	var nameOpts []name.Option
+	if opts.Insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+	remoteOpts := append(opts.RemoteOptions, remote.WithContext(ctx))
+
+	for _, desc := range manifest.Manifests {
+		source := desc.Annotations[specv1.AnnotationRefName]
+		if source == "" {
+			return fmt.Errorf("manifest %s has no %s annotation", desc.Digest, specv1.AnnotationRefName)
+		}
+		target, err := retarget(source, targetRegistry, nameOpts...)
+		if err != nil {
+			return fmt.Errorf("failed to retarget image %s: %w", source, err)
+		}
+
+		log.Printf("Pushing image %s\n", target)
+		if err := pushManifest(index, desc, target, remoteOpts); err != nil {
+			return fmt.Errorf("failed to push image %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+func pushManifest(index v1.ImageIndex, desc v1.Descriptor, target name.Reference, opts []remote.Option) error {
+	if desc.MediaType.IsIndex() {
+		idx, err := index.ImageIndex(desc.Digest)
+		if err != nil {
+			return err
+		}
+		return remote.WriteIndex(target, idx, opts...)
+	}
+	img, err := index.Image(desc.Digest)
+	if err != nil {
+		return err
+	}
+	return remote.Write(target, img, opts...)
+}
+
+// retarget rebases the repository and tag of image on the target registry.
+func retarget(image, targetRegistry string, opts ...name.Option) (name.Reference, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	target := targetRegistry + "/" + ref.Context().RepositoryStr()
+	if tag, ok := ref.(name.Tag); ok {
+		return name.NewTag(target+":"+tag.TagStr(), opts...)
+	}
+	return name.NewDigest(target+"@"+ref.Identifier(), opts...)
+}
+
+func pushCharts(dir, targetRegistry string, opts PushOptions) error {
+	archives, err := filepath.Glob(filepath.Join(dir, ChartsDir, "*.tgz"))
+	if err != nil || len(archives) == 0 {
+		return err
+	}
+
+	clientOpts := append([]registry.ClientOption{registry.ClientOptWriter(log.Writer())}, opts.ChartClientOptions...)
+	if opts.Insecure {
+		clientOpts = append(clientOpts, registry.ClientOptPlainHTTP())
+	}
+	client, err := registry.NewClient(clientOpts...)
+	if err != nil {
+		return err
+	}
+
+	repository := opts.ChartsRepository
+	if repository == "" {
+		repository = DefaultChartsRepository
+	}
+
+	for _, archive := range archives {
+		data, err := os.ReadFile(archive)
+		if err != nil {
+			return err
+		}
+		chart, err := loader.LoadArchive(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("failed to load chart %s: %w", archive, err)
+		}
+
+		ref := fmt.Sprintf("%s/%s/%s:%s", strings.TrimPrefix(targetRegistry, registry.OCIScheme+"://"),
+			strings.Trim(repository, "/"), chart.Metadata.Name, chart.Metadata.Version)
+		log.Printf("Pushing chart %s\n", ref)
+		if _, err := client.Push(data, ref); err != nil {
+			return fmt.Errorf("failed to push chart %s: %w", archive, err)
+		}
+	}
+	return nil
+}
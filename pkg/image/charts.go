@@ -7,6 +7,7 @@
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -45,11 +46,57 @@ func (c Charts) FetchImages(imagesSet map[string]map[string]struct{}) error {
 	if c.Config.ChartsPath == "" || c.Config.RancherVersion == "" {
 		return nil
 	}
-	index, err := repo.LoadIndexFile(filepath.Join(c.Config.ChartsPath, "index.yaml"))
+	filteredVersions, err := c.filteredVersions()
 	if err != nil {
 		return err
 	}
-	// Filter index entries based on their Rancher version constraint
+	// Find values.yaml files in the tgz files of each chart, and check for images to add to imageSet
+	for _, version := range filteredVersions {
+		tgzPath := filepath.Join(c.Config.ChartsPath, version.URLs[0])
+		versionValues, err := decodeValuesFilesInTgz(tgzPath)
+		if err != nil {
+			logrus.Info(err)
+			continue
+		}
+		tag := chartsToIgnoreTags[version.Name]
+		chartNameAndVersion := fmt.Sprintf("%s:%s", version.Name, version.Version)
+		for _, values := range versionValues {
+			if err = pickImagesFromValuesMap(imagesSet, values, chartNameAndVersion, c.Config.OsType, tag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FetchArchives returns the paths of the chart archives of all the charts in a Rancher charts repository which are
+// relevant to the configured Rancher version. The selection is the same as the one used by FetchImages.
+func (c Charts) FetchArchives() ([]string, error) {
+	if c.Config.ChartsPath == "" || c.Config.RancherVersion == "" {
+		return nil, nil
+	}
+	filteredVersions, err := c.filteredVersions()
+	if err != nil {
+		return nil, err
+	}
+	var archives []string
+	for _, version := range filteredVersions {
+		if len(version.URLs) == 0 {
+			continue
+		}
+		archives = append(archives, filepath.Join(c.Config.ChartsPath, version.URLs[0]))
+	}
+	sort.Strings(archives)
+	return archives, nil
+}
+
+// filteredVersions loads the index of the charts repository and filters its entries based on their Rancher version
+// constraint.
+func (c Charts) filteredVersions() (repo.ChartVersions, error) {
+	index, err := repo.LoadIndexFile(filepath.Join(c.Config.ChartsPath, "index.yaml"))
+	if err != nil {
+		return nil, err
+	}
 	var filteredVersions repo.ChartVersions
 	for _, versions := range index.Entries {
 		if len(versions) == 0 {
@@ -60,7 +107,7 @@ func (c Charts) FetchImages(imagesSet map[string]map[string]struct{}) error {
 		// sorting the versions in the index file in descending order correctly.
 		latestVersion := versions[0]
 		if isConstraintSatisfied, err := c.checkChartVersionConstraint(*latestVersion); err != nil {
-			return errors.Wrapf(err, "failed to check constraint of chart")
+			return nil, errors.Wrapf(err, "failed to check constraint of chart")
 		} else if isConstraintSatisfied {
 			filteredVersions = append(filteredVersions, latestVersion)
 		}
@@ -70,30 +117,14 @@ func (c Charts) FetchImages(imagesSet map[string]map[string]struct{}) error {
 		if _, ok := chartsToCheckConstraints[chartName]; ok {
 			for _, version := range versions[1:] {
 				if isConstraintSatisfied, err := c.checkChartVersionConstraint(*version); err != nil {
-					return errors.Wrapf(err, "failed to check constraint of chart")
+					return nil, errors.Wrapf(err, "failed to check constraint of chart")
 				} else if isConstraintSatisfied {
 					filteredVersions = append(filteredVersions, version)
 				}
 			}
 		}
 	}
-	// Find values.yaml files in the tgz files of each chart, and check for images to add to imageSet
-	for _, version := range filteredVersions {
-		tgzPath := filepath.Join(c.Config.ChartsPath, version.URLs[0])
-		versionValues, err := decodeValuesFilesInTgz(tgzPath)
-		if err != nil {
-			logrus.Info(err)
-			continue
-		}
-		tag := chartsToIgnoreTags[version.Name]
-		chartNameAndVersion := fmt.Sprintf("%s:%s", version.Name, version.Version)
-		for _, values := range versionValues {
-			if err = pickImagesFromValuesMap(imagesSet, values, chartNameAndVersion, c.Config.OsType, tag); err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	return filteredVersions, nil
 }
 
 // FetchOCICharts finds all OCI chart artifacts for charts in a github repository
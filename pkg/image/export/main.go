@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	img "github.com/rancher/rancher/pkg/image"
 	"github.com/rancher/rancher/pkg/image/appco"
+	"github.com/rancher/rancher/pkg/image/bundle"
 	"github.com/rancher/rancher/pkg/image/utilities"
 )
 
@@ -91,11 +96,73 @@ type imageTextLists struct {
 		if err != nil {
 			return err
 		}
+
+		// compare with the images of a previous release to only ship the delta on upgrades
+		if previousDir := os.Getenv("PREVIOUS_IMAGES_DIR"); previousDir != "" {
+			if err = utilities.ImagesDiffText(arch, previousDir, imageLists.imagesAndSources); err != nil {
+				return err
+			}
+		}
+	}
+
+	// create rancher-images-sbom.cdx.json, the inventory of all images and charts of the release
+	sbom := os.Getenv("GENERATE_SBOM") == "true"
+	output := os.Getenv("BUNDLE_OUTPUT")
+	if !sbom && output == "" {
+		return nil
+	}
+
+	chartArchives, err := utilities.GatherChartArchives(chartsPath, rancherVersion)
+	if err != nil {
+		return err
+	}
+
+	if sbom {
+		err = utilities.SBOMText(rancherVersion, map[string][]string{
+			"linux":   targetsAndSources.TargetLinuxArtifactsAndSources,
+			"windows": targetsAndSources.TargetWindowsArtifactsAndSources,
+		}, chartArchives)
+		if err != nil {
+			return err
+		}
+	}
+
+	if output != "" {
+		return exportBundle(output, chartArchives, append(targetsAndSources.TargetLinuxArtifacts, targetsAndSources.TargetWindowsArtifacts...))
 	}
 
 	return nil
 }
 
+// exportBundle writes the images and charts of the release into an airgap bundle. BUNDLE_FORMAT selects between a
+// single "tarball" and an "oci-layout" directory, and BUNDLE_PLATFORMS restricts the images to a comma separated list
+// of platforms such as "linux/amd64,linux/arm64".
+func exportBundle(output string, chartArchives, images []string) error {
+	opts := bundle.Options{
+		RemoteOptions: []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)},
+	}
+
+	switch format := os.Getenv("BUNDLE_FORMAT"); format {
+	case "", "tarball":
+		opts.Tarball = true
+	case "oci-layout":
+	default:
+		return fmt.Errorf("invalid BUNDLE_FORMAT %q, must be tarball or oci-layout", format)
+	}
+
+	if platforms := os.Getenv("BUNDLE_PLATFORMS"); platforms != "" {
+		for _, platform := range strings.Split(platforms, ",") {
+			p, err := v1.ParsePlatform(strings.TrimSpace(platform))
+			if err != nil {
+				return fmt.Errorf("invalid platform %q in BUNDLE_PLATFORMS: %w", platform, err)
+			}
+			opts.Platforms = append(opts.Platforms, *p)
+		}
+	}
+
+	return bundle.Export(context.Background(), output, images, chartArchives, opts)
+}
+
 func addSourceToImage(
 	imagesAndSources []string,
 	image string,
@@ -346,6 +346,12 @@ func GatherUnknownImages(allImages []string) string {
 	return unknownImages
 }
 
+// ImageOrigin returns the origin repository of an image used by Rancher, or an empty string if the image is not
+// included in the OriginMap.
+func ImageOrigin(image string) string {
+	return OriginMap[repoFromImage(image)]
+}
+
 // repoFromImage strips away the repository and version
 // of a given image.
 func repoFromImage(image string) string {
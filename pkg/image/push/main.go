@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/rancher/rancher/pkg/image/bundle"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		log.Fatal("\"main.go\" requires 2 arguments. Usage: go run main.go [BUNDLE] [REGISTRY]")
+	}
+
+	opts := bundle.PushOptions{
+		RemoteOptions:    []remote.Option{remote.WithAuthFromKeychain(authn.DefaultKeychain)},
+		ChartsRepository: os.Getenv("BUNDLE_CHARTS_REPOSITORY"),
+		Insecure:         strings.EqualFold(os.Getenv("BUNDLE_INSECURE_REGISTRY"), "true"),
+	}
+
+	if err := bundle.Push(context.Background(), os.Args[1], os.Args[2], opts); err != nil {
+		log.Fatal(err)
+	}
+}
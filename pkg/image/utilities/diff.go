@@ -0,0 +1,196 @@
+package utilities
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	img "github.com/rancher/rancher/pkg/image"
+)
+
+var (
+	diffFilenameMap = map[string]string{
+		"linux":   "rancher-images-diff.txt",
+		"windows": "rancher-windows-images-diff.txt",
+	}
+	deltaFilenameMap = map[string]string{
+		"linux":   "rancher-images-delta.txt",
+		"windows": "rancher-windows-images-delta.txt",
+	}
+)
+
+// ImageChange describes an image which differs between two Rancher releases.
+type ImageChange struct {
+	// Image is the image of the current release, or of the previous release if it was removed.
+	Image string
+	// PreviousImage is the image of the previous release which was retagged to Image.
+	PreviousImage string
+	Sources       []string
+	Origin        string
+}
+
+// ImageDiff holds the images which were added, removed or retagged between two Rancher releases.
+type ImageDiff struct {
+	Added    []ImageChange
+	Removed  []ImageChange
+	Retagged []ImageChange
+}
+
+// DiffImages compares the images of two Rancher releases, given in the "image source1,source2,..." format of
+// rancher-images-sources.txt. An image is retagged when its repository has a single tag in both releases and that tag
+// changed, any other difference is reported as an added or removed image.
+func DiffImages(previousImagesAndSources, currentImagesAndSources []string) ImageDiff {
+	previous := imageSourcesMap(previousImagesAndSources)
+	current := imageSourcesMap(currentImagesAndSources)
+	previousTags := tagsByRepository(previous)
+	currentTags := tagsByRepository(current)
+
+	var diff ImageDiff
+	for repository, tags := range currentTags {
+		if prevTags := previousTags[repository]; len(prevTags) == 1 && len(tags) == 1 && prevTags[0] != tags[0] {
+			image := repository + ":" + tags[0]
+			diff.Retagged = append(diff.Retagged, ImageChange{
+				Image:         image,
+				PreviousImage: repository + ":" + prevTags[0],
+				Sources:       current[image],
+				Origin:        img.ImageOrigin(image),
+			})
+			continue
+		}
+		for _, tag := range tags {
+			image := repository + ":" + tag
+			if _, ok := previous[image]; !ok {
+				diff.Added = append(diff.Added, ImageChange{Image: image, Sources: current[image], Origin: img.ImageOrigin(image)})
+			}
+		}
+	}
+	for repository, tags := range previousTags {
+		if curTags := currentTags[repository]; len(curTags) == 1 && len(tags) == 1 {
+			continue
+		}
+		for _, tag := range tags {
+			image := repository + ":" + tag
+			if _, ok := current[image]; !ok {
+				diff.Removed = append(diff.Removed, ImageChange{Image: image, Sources: previous[image], Origin: img.ImageOrigin(image)})
+			}
+		}
+	}
+
+	for _, changes := range [][]ImageChange{diff.Added, diff.Removed, diff.Retagged} {
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].Image < changes[j].Image
+		})
+	}
+	return diff
+}
+
+// ReadImagesAndSources reads a file in the format of rancher-images-sources.txt.
+func ReadImagesAndSources(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var imagesAndSources []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			imagesAndSources = append(imagesAndSources, line)
+		}
+	}
+	return imagesAndSources, scanner.Err()
+}
+
+// ImagesDiffText compares the images of the given arch with the rancher-images-sources.txt file of a previous release
+// found in previousDir. It writes the changes along with their sources and origin, and the list of images which must
+// be shipped on top of the previous release.
+func ImagesDiffText(arch, previousDir string, targetImagesAndSources []string) error {
+	previous, err := ReadImagesAndSources(filepath.Join(previousDir, sourcesFilenameMap[arch]))
+	if err != nil {
+		return fmt.Errorf("could not read images of previous release: %w", err)
+	}
+	diff := DiffImages(previous, saveImagesAndSources(targetImagesAndSources))
+
+	filename := diffFilenameMap[arch]
+	log.Printf("Creating %s\n", filename)
+	save, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer save.Close()
+
+	for _, change := range diff.Added {
+		fmt.Fprintf(save, "+ %s %s %s\n", change.Image, strings.Join(change.Sources, ","), change.Origin)
+	}
+	for _, change := range diff.Removed {
+		fmt.Fprintf(save, "- %s %s %s\n", change.Image, strings.Join(change.Sources, ","), change.Origin)
+	}
+	for _, change := range diff.Retagged {
+		fmt.Fprintf(save, "~ %s -> %s %s %s\n", change.PreviousImage, change.Image, strings.Join(change.Sources, ","), change.Origin)
+	}
+	if err := save.Close(); err != nil {
+		return err
+	}
+
+	filename = deltaFilenameMap[arch]
+	log.Printf("Creating %s\n", filename)
+	delta, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer delta.Close()
+
+	var images []string
+	for _, change := range append(diff.Added, diff.Retagged...) {
+		images = append(images, change.Image)
+	}
+	sort.Strings(images)
+	for _, image := range images {
+		fmt.Fprintln(delta, image)
+	}
+	return delta.Close()
+}
+
+// imageSourcesMap maps each image to its sources.
+func imageSourcesMap(imagesAndSources []string) map[string][]string {
+	images := make(map[string][]string, len(imagesAndSources))
+	for _, imageAndSources := range imagesAndSources {
+		image, sources, _ := strings.Cut(strings.TrimSpace(imageAndSources), " ")
+		if image == "" {
+			continue
+		}
+		images[image] = nil
+		if sources = strings.TrimSpace(sources); sources != "" {
+			images[image] = strings.Split(sources, ",")
+		}
+	}
+	return images
+}
+
+// tagsByRepository groups the tags of the images by repository.
+func tagsByRepository(images map[string][]string) map[string][]string {
+	tags := make(map[string][]string)
+	for image := range images {
+		repository, tag := splitImage(image)
+		tags[repository] = append(tags[repository], tag)
+	}
+	for _, t := range tags {
+		sort.Strings(t)
+	}
+	return tags
+}
+
+// splitImage splits an image into its repository and tag, the separator of the tag being the last colon after the
+// last slash so that registries with a port are supported.
+func splitImage(image string) (string, string) {
+	i := strings.LastIndex(image, ":")
+	if i <= strings.LastIndex(image, "/") {
+		return image, ""
+	}
+	return image[:i], image[i+1:]
+}
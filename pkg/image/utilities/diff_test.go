@@ -0,0 +1,92 @@
+package utilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		current  []string
+		want     ImageDiff
+	}{
+		{
+			name:     "unchanged",
+			previous: []string{"rancher/fleet:v0.1.0 fleet:103.0.0"},
+			current:  []string{"rancher/fleet:v0.1.0 fleet:104.0.0"},
+		},
+		{
+			name:     "retagged",
+			previous: []string{"rancher/fleet:v0.1.0 fleet:103.0.0"},
+			current:  []string{"rancher/fleet:v0.2.0 fleet:104.0.0"},
+			want: ImageDiff{
+				Retagged: []ImageChange{{
+					Image:         "rancher/fleet:v0.2.0",
+					PreviousImage: "rancher/fleet:v0.1.0",
+					Sources:       []string{"fleet:104.0.0"},
+					Origin:        "https://github.com/rancher/fleet",
+				}},
+			},
+		},
+		{
+			name:     "added and removed",
+			previous: []string{"rancher/shell:v0.1.0 rancher:2.12.0"},
+			current:  []string{"rancher/rancher-webhook:v0.5.0 rancher-webhook:105.0.0,rancher:2.13.0"},
+			want: ImageDiff{
+				Added: []ImageChange{{
+					Image:   "rancher/rancher-webhook:v0.5.0",
+					Sources: []string{"rancher-webhook:105.0.0", "rancher:2.13.0"},
+					Origin:  "https://github.com/rancher/webhook",
+				}},
+				Removed: []ImageChange{{
+					Image:   "rancher/shell:v0.1.0",
+					Sources: []string{"rancher:2.12.0"},
+					Origin:  "https://github.com/rancher/shell",
+				}},
+			},
+		},
+		{
+			name: "repository with multiple tags",
+			previous: []string{
+				"rancher/system-agent-installer-rke2:v1.31.0-rke2r1 kdm",
+				"rancher/system-agent-installer-rke2:v1.32.0-rke2r1 kdm",
+			},
+			current: []string{
+				"rancher/system-agent-installer-rke2:v1.32.0-rke2r1 kdm",
+				"rancher/system-agent-installer-rke2:v1.33.0-rke2r1 kdm",
+			},
+			want: ImageDiff{
+				Added: []ImageChange{{
+					Image:   "rancher/system-agent-installer-rke2:v1.33.0-rke2r1",
+					Sources: []string{"kdm"},
+					Origin:  "https://github.com/rancher/system-agent-installer-rke2",
+				}},
+				Removed: []ImageChange{{
+					Image:   "rancher/system-agent-installer-rke2:v1.31.0-rke2r1",
+					Sources: []string{"kdm"},
+					Origin:  "https://github.com/rancher/system-agent-installer-rke2",
+				}},
+			},
+		},
+		{
+			name:     "registry with port",
+			previous: []string{"registry.local:5000/rancher/fleet:v0.1.0"},
+			current:  []string{"registry.local:5000/rancher/fleet:v0.2.0"},
+			want: ImageDiff{
+				Retagged: []ImageChange{{
+					Image:         "registry.local:5000/rancher/fleet:v0.2.0",
+					PreviousImage: "registry.local:5000/rancher/fleet:v0.1.0",
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DiffImages(tt.previous, tt.current))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package utilities
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	img "github.com/rancher/rancher/pkg/image"
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+const (
+	sbomFilename = "rancher-images-sbom.cdx.json"
+
+	cycloneDXFormat      = "CycloneDX"
+	cycloneDXSpecVersion = "1.5"
+
+	sbomSourceProperty  = "cattle.io:source"
+	sbomOSProperty      = "cattle.io:os"
+	sbomArchiveProperty = "cattle.io:chart-archive"
+)
+
+// CycloneDXBOM is the subset of a CycloneDX bill of materials used to inventory the images and charts of a Rancher
+// release.
+type CycloneDXBOM struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Metadata    CycloneDXMetadata    `json:"metadata"`
+	Components  []CycloneDXComponent `json:"components"`
+}
+
+// CycloneDXMetadata describes the release the bill of materials was generated for.
+type CycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp"`
+	Component CycloneDXComponent `json:"component"`
+}
+
+// CycloneDXComponent is an image or a chart of the release.
+type CycloneDXComponent struct {
+	BOMRef             string                       `json:"bom-ref,omitempty"`
+	Type               string                       `json:"type"`
+	Name               string                       `json:"name"`
+	Version            string                       `json:"version,omitempty"`
+	Description        string                       `json:"description,omitempty"`
+	PURL               string                       `json:"purl,omitempty"`
+	ExternalReferences []CycloneDXExternalReference `json:"externalReferences,omitempty"`
+	Properties         []CycloneDXProperty          `json:"properties,omitempty"`
+}
+
+// CycloneDXExternalReference points to the origin of a component.
+type CycloneDXExternalReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// CycloneDXProperty is a name-value pair attached to a component.
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewSBOM builds a CycloneDX bill of materials listing every image, with its sources and origin, and every chart
+// archive of a Rancher release. Images are given in the "image source1,source2,..." format of
+// rancher-images-sources.txt, keyed by arch.
+func NewSBOM(rancherVersion string, imagesAndSources map[string][]string, chartArchives []string) CycloneDXBOM {
+	images := map[string]*CycloneDXComponent{}
+	for _, arch := range []string{"linux", "windows"} {
+		for image, sources := range imageSourcesMap(imagesAndSources[arch]) {
+			component, ok := images[image]
+			if !ok {
+				component = imageComponent(image)
+				images[image] = component
+			}
+			component.Properties = append(component.Properties, CycloneDXProperty{Name: sbomOSProperty, Value: arch})
+			for _, source := range sources {
+				component.Properties = append(component.Properties, CycloneDXProperty{Name: sbomSourceProperty, Value: source})
+			}
+		}
+	}
+
+	bom := CycloneDXBOM{
+		BOMFormat:   cycloneDXFormat,
+		SpecVersion: cycloneDXSpecVersion,
+		Version:     1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Component: CycloneDXComponent{
+				Type:    "application",
+				Name:    "rancher",
+				Version: rancherVersion,
+			},
+		},
+	}
+
+	var names []string
+	for image := range images {
+		names = append(names, image)
+	}
+	sort.Strings(names)
+	for _, image := range names {
+		component := images[image]
+		component.Properties = uniqueProperties(component.Properties)
+		bom.Components = append(bom.Components, *component)
+	}
+
+	for _, archive := range chartArchives {
+		component, err := chartComponent(archive)
+		if err != nil {
+			log.Printf("Skipping chart %s in SBOM: %v\n", archive, err)
+			continue
+		}
+		bom.Components = append(bom.Components, component)
+	}
+
+	return bom
+}
+
+// SBOMText writes the bill of materials of a Rancher release. Like rancher-images-sources.txt, it only lists the images
+// which are mirrored.
+func SBOMText(rancherVersion string, targetImagesAndSources map[string][]string, chartArchives []string) error {
+	imagesAndSources := make(map[string][]string, len(targetImagesAndSources))
+	for arch, archImagesAndSources := range targetImagesAndSources {
+		imagesAndSources[arch] = saveImagesAndSources(archImagesAndSources)
+	}
+	bom := NewSBOM(rancherVersion, imagesAndSources, chartArchives)
+
+	log.Printf("Creating %s\n", sbomFilename)
+	data, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sbomFilename, append(data, '\n'), 0o644)
+}
+
+func imageComponent(image string) *CycloneDXComponent {
+	repository, tag := splitImage(image)
+	component := &CycloneDXComponent{
+		BOMRef:  "image:" + image,
+		Type:    "container",
+		Name:    repository,
+		Version: tag,
+		PURL:    "pkg:docker/" + repository,
+	}
+	if tag != "" {
+		component.PURL += "@" + tag
+	}
+	if origin := imageOrigin(image); origin != "" {
+		component.ExternalReferences = []CycloneDXExternalReference{{Type: "vcs", URL: origin}}
+	}
+	return component
+}
+
+func chartComponent(archive string) (CycloneDXComponent, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return CycloneDXComponent{}, err
+	}
+	defer f.Close()
+
+	chart, err := loader.LoadArchive(f)
+	if err != nil {
+		return CycloneDXComponent{}, err
+	}
+	metadata := chart.Metadata
+
+	component := CycloneDXComponent{
+		BOMRef:      "chart:" + metadata.Name + ":" + metadata.Version,
+		Type:        "application",
+		Name:        metadata.Name,
+		Version:     metadata.Version,
+		Description: metadata.Description,
+		Properties:  []CycloneDXProperty{{Name: sbomArchiveProperty, Value: filepath.Base(archive)}},
+	}
+	if metadata.Home != "" {
+		component.ExternalReferences = append(component.ExternalReferences, CycloneDXExternalReference{Type: "website", URL: metadata.Home})
+	}
+	for _, source := range metadata.Sources {
+		component.ExternalReferences = append(component.ExternalReferences, CycloneDXExternalReference{Type: "vcs", URL: source})
+	}
+	return component, nil
+}
+
+func imageOrigin(image string) string {
+	origin := img.ImageOrigin(image)
+	if origin == "unknown" || !strings.HasPrefix(origin, "http") {
+		return ""
+	}
+	return origin
+}
+
+// uniqueProperties sorts the properties and removes duplicates, such as the sources of an image used on both Linux and
+// Windows.
+func uniqueProperties(properties []CycloneDXProperty) []CycloneDXProperty {
+	sort.Slice(properties, func(i, j int) bool {
+		if properties[i].Name != properties[j].Name {
+			return properties[i].Name < properties[j].Name
+		}
+		return properties[i].Value < properties[j].Value
+	})
+	var unique []CycloneDXProperty
+	for i, property := range properties {
+		if i == 0 || property != properties[i-1] {
+			unique = append(unique, property)
+		}
+	}
+	return unique
+}
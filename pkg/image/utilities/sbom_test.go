@@ -0,0 +1,34 @@
+package utilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSBOM(t *testing.T) {
+	bom := NewSBOM("2.13.0", map[string][]string{
+		"linux":   {"rancher/fleet:v0.2.0 fleet:104.0.0", "rancher/shell:v0.1.0 rancher:2.13.0"},
+		"windows": {"rancher/fleet:v0.2.0 fleet:104.0.0"},
+	}, []string{"does-not-exist.tgz"})
+
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "2.13.0", bom.Metadata.Component.Version)
+	require.Len(t, bom.Components, 2)
+
+	fleet := bom.Components[0]
+	assert.Equal(t, "image:rancher/fleet:v0.2.0", fleet.BOMRef)
+	assert.Equal(t, "container", fleet.Type)
+	assert.Equal(t, "rancher/fleet", fleet.Name)
+	assert.Equal(t, "v0.2.0", fleet.Version)
+	assert.Equal(t, "pkg:docker/rancher/fleet@v0.2.0", fleet.PURL)
+	assert.Equal(t, []CycloneDXExternalReference{{Type: "vcs", URL: "https://github.com/rancher/fleet"}}, fleet.ExternalReferences)
+	assert.Equal(t, []CycloneDXProperty{
+		{Name: sbomOSProperty, Value: "linux"},
+		{Name: sbomOSProperty, Value: "windows"},
+		{Name: sbomSourceProperty, Value: "fleet:104.0.0"},
+	}, fleet.Properties)
+
+	assert.Equal(t, "rancher/shell", bom.Components[1].Name)
+}
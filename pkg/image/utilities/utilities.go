@@ -63,10 +63,7 @@ func GatherTargetArtifactsAndSources(
 	ociRepository string,
 	rancherVersion string,
 ) (ArtifactTargetsAndSources, error) {
-	if !img.IsValidSemver(rancherVersion) || !settings.IsReleaseServerVersion(rancherVersion) {
-		rancherVersion = settings.RancherVersionDev
-	}
-	rancherVersion = strings.TrimPrefix(rancherVersion, "v")
+	rancherVersion = normalizeRancherVersion(rancherVersion)
 
 	// already downloaded in dapper
 	b, err := os.ReadFile(filepath.Join("data.json"))
@@ -135,6 +132,34 @@ func GatherTargetArtifactsAndSources(
 	}, nil
 }
 
+// GatherChartArchives returns the paths of the chart archives, found in the comma separated list of chart repositories
+// chartPaths, which are relevant to the given Rancher version.
+func GatherChartArchives(chartPaths string, rancherVersion string) ([]string, error) {
+	rancherVersion = normalizeRancherVersion(rancherVersion)
+
+	var archives []string
+	for _, chartPath := range strings.Split(chartPaths, ",") {
+		charts := img.Charts{Config: img.ExportConfig{
+			ChartsPath:     chartPath,
+			RancherVersion: rancherVersion,
+		}}
+		chartArchives, err := charts.FetchArchives()
+		if err != nil {
+			return nil, fmt.Errorf("could not get chart archives from %s: %w", chartPath, err)
+		}
+		archives = append(archives, chartArchives...)
+	}
+
+	return archives, nil
+}
+
+func normalizeRancherVersion(rancherVersion string) string {
+	if !img.IsValidSemver(rancherVersion) || !settings.IsReleaseServerVersion(rancherVersion) {
+		rancherVersion = settings.RancherVersionDev
+	}
+	return strings.TrimPrefix(rancherVersion, "v")
+}
+
 // LoadScript produces executable files for Linux and Windows
 // which will load all images used by Rancher into a given image repository.
 func LoadScript(arch string, targetImages []string) error {
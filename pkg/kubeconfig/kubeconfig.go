@@ -47,10 +47,11 @@ type Cluster struct {
 	Cert   string
 }
 type User struct {
-	Name      string
-	Token     string
-	Host      string
-	ClusterID string
+	Name         string
+	Token        string
+	Host         string
+	ClusterID    string
+	KubeconfigID string
 }
 
 type Context struct {
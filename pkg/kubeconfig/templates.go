@@ -81,6 +81,9 @@
         - --user={{.Name}}
 {{- if ne .ClusterID "" }}
         - --cluster={{.ClusterID}}
+{{- end }}
+{{- if ne .KubeconfigID "" }}
+        - --kubeconfig-id={{.KubeconfigID}}
 {{- end }}
       command: rancher
 {{- end }}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clusterProxyRejectedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_proxy",
+			Name:      "rejected_requests_total",
+			Help:      "Number of requests to downstream clusters rejected by the flow control of the cluster proxy",
+		},
+		[]string{"cluster", "priority_level", "reason"},
+	)
+	clusterProxyInFlightRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_proxy",
+			Name:      "in_flight_requests",
+			Help:      "Number of requests to downstream clusters being served, excluding long running requests such as watches",
+		},
+		[]string{"cluster", "priority_level"},
+	)
+)
+
+// IncClusterProxyRejectedRequests counts a request to the given cluster rejected by the flow control of the cluster
+// proxy.
+func IncClusterProxyRejectedRequests(clusterID, priorityLevel, reason string) {
+	if !prometheusMetrics {
+		return
+	}
+	clusterProxyRejectedRequests.With(prometheus.Labels{
+		"cluster":        clusterID,
+		"priority_level": priorityLevel,
+		"reason":         reason,
+	}).Inc()
+}
+
+// SetClusterProxyInFlightRequests records the number of requests of a priority level being served by the given cluster.
+func SetClusterProxyInFlightRequests(clusterID, priorityLevel string, inFlight int) {
+	if !prometheusMetrics {
+		return
+	}
+	clusterProxyInFlightRequests.With(prometheus.Labels{
+		"cluster":        clusterID,
+		"priority_level": priorityLevel,
+	}).Set(float64(inFlight))
+}
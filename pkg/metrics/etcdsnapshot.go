@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	etcdSnapshotVerificationFailed = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_manager",
+			Name:      "etcd_snapshot_verification_failed",
+			Help:      "Whether the latest verification of an etcd snapshot of a rancher provisioned cluster failed",
+		},
+		[]string{"cluster"},
+	)
+	etcdSnapshotVerificationLastSuccess = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_manager",
+			Name:      "etcd_snapshot_verification_last_success_timestamp_seconds",
+			Help:      "Unix time of the latest successful verification of an etcd snapshot of a rancher provisioned cluster",
+		},
+		[]string{"cluster"},
+	)
+)
+
+// SetETCDSnapshotVerification records the outcome of a verification of an etcd snapshot of the given cluster.
+func SetETCDSnapshotVerification(clusterID string, passed bool, completedAt time.Time) {
+	if !prometheusMetrics {
+		return
+	}
+	labels := prometheus.Labels{"cluster": clusterID}
+	if passed {
+		etcdSnapshotVerificationFailed.With(labels).Set(float64(0))
+		etcdSnapshotVerificationLastSuccess.With(labels).Set(float64(completedAt.Unix()))
+	} else {
+		etcdSnapshotVerificationFailed.With(labels).Set(float64(1))
+	}
+}
@@ -84,7 +84,7 @@ func (gc *metricGarbageCollector) metricGarbageCollection() {
 
 	buildObservedLabelMaps(targetMetricsByNameForClientKey, "clientkey", observedLabelsMap)
 	buildObservedLabelMaps(targetMetricsByIPForPeer, "peer", observedLabelsMap)
-	buildObservedLabelMaps([]interface{}{clusterOwner}, "cluster", observedLabelsMap)
+	buildObservedLabelMaps([]interface{}{clusterOwner, etcdSnapshotVerificationFailed, etcdSnapshotVerificationLastSuccess}, "cluster", observedLabelsMap)
 
 	removedCount := removeMetricsForDeletedResource(observedLabelsMap, observedResourceNames)
 
@@ -49,6 +49,14 @@ func Register(ctx context.Context, scaledContext *config.ScaledContext) {
 	prometheus.MustRegister(numNodes)
 	prometheus.MustRegister(numCores)
 
+	// etcd snapshot verification metrics
+	prometheus.MustRegister(etcdSnapshotVerificationFailed)
+	prometheus.MustRegister(etcdSnapshotVerificationLastSuccess)
+
+	// cluster proxy flow control metrics
+	prometheus.MustRegister(clusterProxyRejectedRequests)
+	prometheus.MustRegister(clusterProxyInFlightRequests)
+
 	gc := metricGarbageCollector{
 		clusterLister:  scaledContext.Management.Clusters("").Controller().Lister(),
 		nodeLister:     scaledContext.Management.Nodes("").Controller().Lister(),
@@ -21,6 +21,7 @@
 	"github.com/rancher/rancher/pkg/cron"
 	managementdata "github.com/rancher/rancher/pkg/data/management"
 	"github.com/rancher/rancher/pkg/dialer"
+	exttokenstore "github.com/rancher/rancher/pkg/ext/stores/tokens"
 	"github.com/rancher/rancher/pkg/jailer"
 	"github.com/rancher/rancher/pkg/metrics"
 	"github.com/rancher/rancher/pkg/namespace"
@@ -208,7 +209,7 @@ func (m *mcm) Start(ctx context.Context) error {
 		}
 
 		go adunmigration.UnmigrateAdGUIDUsersOnce(m.ScaledContext)
-		tokens.StartPurgeDaemon(ctx, management)
+		tokens.StartPurgeDaemon(ctx, management, exttokenstore.NewSystemFromWrangler(m.wranglerContext))
 		providerrefresh.StartRefreshDaemon(m.ScaledContext, management)
 		managementdata.CleanupOrphanedSystemUsers(ctx, management)
 		clusterupstreamrefresher.MigrateEksRefreshCronSetting(m.wranglerContext)
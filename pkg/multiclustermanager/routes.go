@@ -16,6 +16,7 @@
 	"github.com/rancher/rancher/pkg/api/norman/customization/oci"
 	"github.com/rancher/rancher/pkg/api/norman/customization/vsphere"
 	managementapi "github.com/rancher/rancher/pkg/api/norman/server"
+	"github.com/rancher/rancher/pkg/api/steve/clusterquery"
 	"github.com/rancher/rancher/pkg/api/steve/supportconfigs"
 	"github.com/rancher/rancher/pkg/auth/logout"
 	"github.com/rancher/rancher/pkg/auth/providers/publicapi"
@@ -24,8 +25,11 @@
 	"github.com/rancher/rancher/pkg/auth/requests/sar"
 	"github.com/rancher/rancher/pkg/auth/tokens"
 	"github.com/rancher/rancher/pkg/auth/webhook"
+	"github.com/rancher/rancher/pkg/changefreeze"
 	"github.com/rancher/rancher/pkg/channelserver"
 	"github.com/rancher/rancher/pkg/clustermanager"
+	"github.com/rancher/rancher/pkg/clusterrouter"
+	"github.com/rancher/rancher/pkg/clusterrouter/flowcontrol"
 	rancherdialer "github.com/rancher/rancher/pkg/dialer"
 	"github.com/rancher/rancher/pkg/features"
 	"github.com/rancher/rancher/pkg/httpproxy"
@@ -44,6 +48,8 @@
 func router(ctx context.Context, localClusterEnabled bool, scaledContext *config.ScaledContext, clusterManager *clustermanager.Manager) (func(http.Handler) http.Handler, error) {
 	var (
 		k8sProxy       = k8sProxyPkg.New(scaledContext, scaledContext.Dialer, clusterManager)
+		limitedProxy   = flowcontrol.Wrap(k8sProxy, clusterrouter.GetClusterID)
+		frozenProxy    = changefreeze.NewChecker(scaledContext.Wrangler.Mgmt.Cluster().Cache()).Wrap(limitedProxy, clusterrouter.GetClusterID)
 		connectHandler = scaledContext.Dialer.(*rancherdialer.Factory).TunnelServer
 		clusterImport  = clusterregistrationtokens.ClusterImport{Clusters: scaledContext.Management.Clusters("")}
 	)
@@ -78,6 +84,15 @@ func router(ctx context.Context, localClusterEnabled bool, scaledContext *config
 	channelserver := channelserver.NewHandler(ctx)
 
 	supportConfigGenerator := supportconfigs.NewHandler(scaledContext)
+	supportBundleGenerator, err := supportconfigs.NewBundleHandler(scaledContext)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterQuery, err := clusterquery.NewHandler(scaledContext, limitedProxy)
+	if err != nil {
+		return nil, err
+	}
 	// Unauthenticated routes
 	unauthed := mux.NewRouter()
 	unauthed.UseEncodedPath()
@@ -116,7 +131,7 @@ func router(ctx context.Context, localClusterEnabled bool, scaledContext *config
 
 	saauthed := mux.NewRouter()
 	saauthed.UseEncodedPath()
-	saauthed.PathPrefix("/k8s/clusters/{clusterID}").Handler(k8sProxy)
+	saauthed.PathPrefix("/k8s/clusters/{clusterID}").Handler(frozenProxy)
 	saauthed.Use(mux.MiddlewareFunc(saAuth.Chain(impersonatingAuth.ImpersonationMiddleware)))
 	saauthed.Use(mux.MiddlewareFunc(accessControlHandler))
 	saauthed.Use(requests.NewAuthenticatedFilter)
@@ -135,6 +150,8 @@ func router(ctx context.Context, localClusterEnabled bool, scaledContext *config
 	authed.Path("/meta/vsphere/{field}").Methods(http.MethodGet).Handler(vsphere.NewVsphereHandler(scaledContext))
 	authed.Path("/v3/tokenreview").Methods(http.MethodPost).Handler(&webhook.TokenReviewer{})
 	authed.Path(supportconfigs.Endpoint).Handler(&supportConfigGenerator)
+	authed.Path(supportconfigs.BundleEndpoint).Methods(http.MethodGet).Handler(supportBundleGenerator)
+	authed.Path(clusterquery.Endpoint).Methods(http.MethodGet).Handler(clusterQuery)
 	authed.PathPrefix("/meta/proxy").Handler(metaProxy)
 	authed.PathPrefix("/v3/identit").Handler(tokenAPI)
 	authed.PathPrefix("/v3/token").Handler(tokenAPI)
@@ -0,0 +1,231 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+)
+
+const sendTimeout = 30 * time.Second
+
+// Message is a notification as delivered through channels.
+type Message struct {
+	Name            string    `json:"name"`
+	Type            string    `json:"type"`
+	Severity        string    `json:"severity,omitempty"`
+	ClusterName     string    `json:"clusterName,omitempty"`
+	Object          string    `json:"object,omitempty"`
+	UserName        string    `json:"userName,omitempty"`
+	Message         string    `json:"message"`
+	Count           int       `json:"count"`
+	FirstOccurrence time.Time `json:"firstOccurrence"`
+	LastOccurrence  time.Time `json:"lastOccurrence"`
+	Recipients      []string  `json:"recipients,omitempty"`
+}
+
+// NewMessage returns the message delivering a notification to its recipients.
+func NewMessage(notification *v3.RancherUserNotification) *Message {
+	msg := &Message{
+		Name:       notification.Name,
+		Type:       notification.ComponentName,
+		Message:    notification.Message,
+		Recipients: notification.Status.Recipients,
+	}
+	if event := notification.Event; event != nil {
+		msg.Type = string(event.Type)
+		msg.Severity = string(event.Severity)
+		msg.ClusterName = event.ClusterName
+		msg.Object = event.Object
+		msg.UserName = event.UserName
+		msg.Count = event.Count
+		msg.FirstOccurrence = event.FirstOccurrence.Time
+		msg.LastOccurrence = event.LastOccurrence.Time
+	}
+	return msg
+}
+
+// Summary returns a one line summary of the message, such as
+// "[Warning] ClusterDisconnected (cluster c-abcde): the cluster agent disconnected".
+func (m *Message) Summary() string {
+	var b strings.Builder
+	if m.Severity != "" {
+		b.WriteString("[" + m.Severity + "] ")
+	}
+	b.WriteString(m.Type)
+	if m.ClusterName != "" {
+		b.WriteString(" (cluster " + m.ClusterName + ")")
+	}
+	b.WriteString(": " + m.Message)
+	return b.String()
+}
+
+// Channel delivers notifications.
+type Channel interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// ChannelFactory creates a channel from its configuration and the data of its secret, nil if it has none.
+type ChannelFactory func(config ChannelConfig, secret map[string][]byte) (Channel, error)
+
+var channelTypes = map[string]ChannelFactory{
+	"smtp":    newSMTPChannel,
+	"webhook": newWebhookChannel,
+	"slack":   newSlackChannel,
+}
+
+// RegisterChannelType registers a type of channel. It must be called before the configuration is first parsed.
+func RegisterChannelType(name string, factory ChannelFactory) {
+	channelTypes[name] = factory
+}
+
+// NewChannel creates a channel from its configuration and the data of its secret.
+func NewChannel(config ChannelConfig, secret map[string][]byte) (Channel, error) {
+	factory, ok := channelTypes[config.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown channel type %q", config.Type)
+	}
+	return factory(config, secret)
+}
+
+// webhookChannel posts notifications as JSON to a URL, the message itself for generic webhooks or a Slack message.
+type webhookChannel struct {
+	url    string
+	body   func(msg *Message) any
+	client *http.Client
+}
+
+func webhookURL(config ChannelConfig, secret map[string][]byte) (string, error) {
+	target := config.URL
+	if secretURL := string(secret["url"]); secretURL != "" {
+		target = secretURL
+	}
+	if !strings.HasPrefix(target, "https://") && !strings.HasPrefix(target, "http://") {
+		return "", fmt.Errorf("channel %s has no valid URL", config.Name)
+	}
+	return target, nil
+}
+
+func newWebhookChannel(config ChannelConfig, secret map[string][]byte) (Channel, error) {
+	target, err := webhookURL(config, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookChannel{
+		url:    target,
+		body:   func(msg *Message) any { return msg },
+		client: &http.Client{Timeout: sendTimeout},
+	}, nil
+}
+
+func newSlackChannel(config ChannelConfig, secret map[string][]byte) (Channel, error) {
+	target, err := webhookURL(config, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookChannel{
+		url:    target,
+		body:   func(msg *Message) any { return map[string]string{"text": msg.Summary()} },
+		client: &http.Client{Timeout: sendTimeout},
+	}, nil
+}
+
+func (w *webhookChannel) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(w.body(msg))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// the error includes the URL, which may hold credentials such as the token of a Slack webhook
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("posting notification: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("posting notification: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// smtpChannel mails notifications, using STARTTLS when the server supports it.
+type smtpChannel struct {
+	addr     string
+	host     string
+	from     string
+	to       []string
+	username string
+	password string
+}
+
+func newSMTPChannel(config ChannelConfig, secret map[string][]byte) (Channel, error) {
+	if config.SMTP == nil || config.SMTP.Host == "" || config.SMTP.From == "" || len(config.SMTP.To) == 0 {
+		return nil, fmt.Errorf("channel %s requires an SMTP host, sender and recipients", config.Name)
+	}
+	port := config.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+	return &smtpChannel{
+		addr:     net.JoinHostPort(config.SMTP.Host, strconv.Itoa(port)),
+		host:     config.SMTP.Host,
+		from:     config.SMTP.From,
+		to:       config.SMTP.To,
+		username: string(secret["username"]),
+		password: string(secret["password"]),
+	}, nil
+}
+
+func (s *smtpChannel) Send(_ context.Context, msg *Message) error {
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	return smtp.SendMail(s.addr, auth, s.from, s.to, s.mail(msg))
+}
+
+// mail returns the mail of a message.
+func (s *smtpChannel) mail(msg *Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace("Rancher: "+msg.Summary()))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Message + "\r\n\r\n")
+	for _, field := range [][2]string{
+		{"Event", msg.Type},
+		{"Severity", msg.Severity},
+		{"Cluster", msg.ClusterName},
+		{"Object", msg.Object},
+		{"User", msg.UserName},
+		{"Recipients", strings.Join(msg.Recipients, ", ")},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(&b, "%s: %s\r\n", field[0], field[1])
+		}
+	}
+	if msg.Count > 0 {
+		fmt.Fprintf(&b, "Occurrences: %d, last at %s\r\n", msg.Count, msg.LastOccurrence.UTC().Format(time.RFC3339))
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,115 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookChannels(t *testing.T) {
+	msg := &Message{
+		Name:        "event-clusterdisconnected-0123456789abcdef",
+		Type:        "ClusterDisconnected",
+		Severity:    "Error",
+		ClusterName: "c-abcde",
+		Message:     "The agent of cluster prod (c-abcde) disconnected",
+		Count:       1,
+	}
+	tests := []struct {
+		name     string
+		typ      string
+		wantBody map[string]any
+	}{
+		{
+			name: "webhook",
+			typ:  "webhook",
+			wantBody: map[string]any{
+				"name":            msg.Name,
+				"type":            "ClusterDisconnected",
+				"severity":        "Error",
+				"clusterName":     "c-abcde",
+				"message":         msg.Message,
+				"count":           float64(1),
+				"firstOccurrence": "0001-01-01T00:00:00Z",
+				"lastOccurrence":  "0001-01-01T00:00:00Z",
+			},
+		},
+		{
+			name:     "slack",
+			typ:      "slack",
+			wantBody: map[string]any{"text": "[Error] ClusterDisconnected (cluster c-abcde): " + msg.Message},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			}))
+			defer server.Close()
+
+			// the URL of the secret overrides the URL of the configuration
+			channel, err := NewChannel(ChannelConfig{Name: "ops", Type: tt.typ, URL: "https://unused.example.com"},
+				map[string][]byte{"url": []byte(server.URL)})
+			require.NoError(t, err)
+			require.NoError(t, channel.Send(context.Background(), msg))
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestWebhookChannelErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	channel, err := NewChannel(ChannelConfig{Name: "ops", Type: "webhook", URL: server.URL + "/secret-token"}, nil)
+	require.NoError(t, err)
+	assert.EqualError(t, channel.Send(context.Background(), &Message{}), "posting notification: unexpected status 403 Forbidden")
+
+	server.Close()
+	err = channel.Send(context.Background(), &Message{})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+
+	_, err = NewChannel(ChannelConfig{Name: "ops", Type: "webhook"}, nil)
+	assert.EqualError(t, err, "channel ops has no valid URL")
+}
+
+func TestSMTPChannel(t *testing.T) {
+	_, err := NewChannel(ChannelConfig{Name: "mail", Type: "smtp"}, nil)
+	assert.EqualError(t, err, "channel mail requires an SMTP host, sender and recipients")
+
+	channel, err := NewChannel(ChannelConfig{Name: "mail", Type: "smtp", SMTP: &SMTPConfig{
+		Host: "smtp.example.com",
+		From: "rancher@example.com",
+		To:   []string{"ops@example.com", "sre@example.com"},
+	}}, map[string][]byte{"username": []byte("rancher"), "password": []byte("secret")})
+	require.NoError(t, err)
+	s := channel.(*smtpChannel)
+	assert.Equal(t, "smtp.example.com:587", s.addr)
+	assert.Equal(t, "rancher", s.username)
+
+	mail := string(s.mail(&Message{
+		Type:       "TokenExpiring",
+		Severity:   "Warning",
+		UserName:   "u-abcde",
+		Message:    "API key token-abcde of user u-abcde will expire on 2024-01-08T20:00:00Z\nsoon",
+		Recipients: []string{"u-abcde"},
+	}))
+	assert.True(t, strings.HasPrefix(mail, "From: rancher@example.com\r\nTo: ops@example.com, sre@example.com\r\n"+
+		"Subject: Rancher: [Warning] TokenExpiring: API key token-abcde of user u-abcde will expire on 2024-01-08T20:00:00Z soon\r\n"), mail)
+	assert.Contains(t, mail, "\r\nUser: u-abcde\r\nRecipients: u-abcde\r\n")
+	assert.NotContains(t, mail, "Cluster:")
+}
@@ -0,0 +1,187 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+)
+
+// DefaultThrottle is the minimum interval between two deliveries of a notification through a channel, for a
+// subscription which doesn't set one.
+const DefaultThrottle = time.Hour
+
+var severities = map[v3.NotificationSeverity]int{
+	v3.NotificationSeverityInfo:    0,
+	v3.NotificationSeverityWarning: 1,
+	v3.NotificationSeverityError:   2,
+}
+
+// Config is the configuration of the notification channels and subscriptions, read from the notification-config
+// setting. For example:
+//
+//	{
+//	  "channels": [
+//	    {"name": "ops-mail", "type": "smtp", "secretName": "smtp-credentials",
+//	     "smtp": {"host": "smtp.example.com", "port": 587, "from": "rancher@example.com", "to": ["ops@example.com"]}},
+//	    {"name": "ops-slack", "type": "slack", "secretName": "ops-slack-webhook"}
+//	  ],
+//	  "subscriptions": [
+//	    {"name": "prod", "events": ["ClusterDisconnected", "ETCDSnapshotFailed"], "clusters": ["c-abcde"],
+//	     "groups": ["github_team://1234"], "channels": ["ops-mail", "ops-slack"], "throttle": "30m"},
+//	    {"name": "owners", "events": ["TokenExpiring"], "notifyAffectedUser": true, "channels": ["ops-mail"]}
+//	  ]
+//	}
+type Config struct {
+	Channels      []ChannelConfig `json:"channels,omitempty"`
+	Subscriptions []Subscription  `json:"subscriptions,omitempty"`
+}
+
+// ChannelConfig is the configuration of a channel notifications are delivered through.
+type ChannelConfig struct {
+	Name string `json:"name"`
+	// Type is the type of the channel: smtp, webhook or slack, or any type registered with RegisterChannelType.
+	Type string `json:"type"`
+	// URL is the URL of webhook channels.
+	URL string `json:"url,omitempty"`
+	// SecretName is the name of a secret of the cattle-global-data namespace holding the credentials of the channel:
+	// the url key for webhooks, which overrides URL, and the username and password keys for SMTP.
+	SecretName string `json:"secretName,omitempty"`
+	// SMTP is the configuration of smtp channels.
+	SMTP *SMTPConfig `json:"smtp,omitempty"`
+}
+
+// SMTPConfig is the configuration of an SMTP channel.
+type SMTPConfig struct {
+	Host string   `json:"host"`
+	Port int      `json:"port,omitempty"`
+	From string   `json:"from"`
+	To   []string `json:"to"`
+}
+
+// Subscription routes the matching events to users, groups and channels.
+type Subscription struct {
+	Name string `json:"name"`
+	// Events are the types of the matching events, all by default.
+	Events []v3.NotificationEventType `json:"events,omitempty"`
+	// MinSeverity is the minimum severity of the matching events, Info by default.
+	MinSeverity v3.NotificationSeverity `json:"minSeverity,omitempty"`
+	// Clusters are the clusters of the matching events, all by default. Events which aren't about a cluster, such as
+	// expiring tokens, match regardless.
+	Clusters []string `json:"clusters,omitempty"`
+	// Users and Groups are the principals targeted by the notifications of the matching events, which only they can
+	// read: the names of users, such as u-abcde, and the principal IDs of groups, such as github_team://1234.
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	// NotifyAffectedUser targets the user affected by the event too, such as the owner of an expiring token.
+	NotifyAffectedUser bool `json:"notifyAffectedUser,omitempty"`
+	// Channels are the names of the channels the notifications are delivered through.
+	Channels []string `json:"channels,omitempty"`
+	// Throttle is the minimum interval between two deliveries of a notification through a channel, such as 30m.
+	Throttle string `json:"throttle,omitempty"`
+
+	throttle time.Duration
+}
+
+// ParseConfig parses and validates the configuration of the notification channels and subscriptions. An empty
+// configuration has no channel and no subscription.
+func ParseConfig(raw string) (*Config, error) {
+	config := &Config{}
+	if raw == "" {
+		return config, nil
+	}
+	if err := json.Unmarshal([]byte(raw), config); err != nil {
+		return nil, fmt.Errorf("parsing notification configuration: %w", err)
+	}
+
+	channels := map[string]bool{}
+	for _, channel := range config.Channels {
+		if channel.Name == "" {
+			return nil, fmt.Errorf("channel without name")
+		}
+		if channels[channel.Name] {
+			return nil, fmt.Errorf("duplicate channel %s", channel.Name)
+		}
+		channels[channel.Name] = true
+		if _, ok := channelTypes[channel.Type]; !ok {
+			return nil, fmt.Errorf("channel %s has unknown type %q", channel.Name, channel.Type)
+		}
+	}
+
+	subscriptions := map[string]bool{}
+	for i := range config.Subscriptions {
+		sub := &config.Subscriptions[i]
+		if sub.Name == "" {
+			return nil, fmt.Errorf("subscription without name")
+		}
+		if subscriptions[sub.Name] {
+			return nil, fmt.Errorf("duplicate subscription %s", sub.Name)
+		}
+		subscriptions[sub.Name] = true
+		if _, ok := severities[sub.MinSeverity]; sub.MinSeverity != "" && !ok {
+			return nil, fmt.Errorf("subscription %s has invalid minimum severity %q", sub.Name, sub.MinSeverity)
+		}
+		for _, user := range sub.Users {
+			if user == "" || strings.Contains(user, "://") {
+				return nil, fmt.Errorf("subscription %s has invalid user %q, users are targeted by their name", sub.Name, user)
+			}
+		}
+		for _, group := range sub.Groups {
+			if !strings.Contains(group, "://") {
+				return nil, fmt.Errorf("subscription %s has invalid group %q, groups are targeted by their principal ID", sub.Name, group)
+			}
+		}
+		for _, channel := range sub.Channels {
+			if !channels[channel] {
+				return nil, fmt.Errorf("subscription %s has unknown channel %q", sub.Name, channel)
+			}
+		}
+		sub.throttle = DefaultThrottle
+		if sub.Throttle != "" {
+			throttle, err := time.ParseDuration(sub.Throttle)
+			if err != nil || throttle < 0 {
+				return nil, fmt.Errorf("subscription %s has invalid throttle %q", sub.Name, sub.Throttle)
+			}
+			sub.throttle = throttle
+		}
+	}
+	return config, nil
+}
+
+// Channel returns the configuration of the channel with the given name.
+func (c *Config) Channel(name string) (ChannelConfig, bool) {
+	for _, channel := range c.Channels {
+		if channel.Name == name {
+			return channel, true
+		}
+	}
+	return ChannelConfig{}, false
+}
+
+// Matches returns whether an event matches the subscription.
+func (s *Subscription) Matches(event *v3.NotificationEvent) bool {
+	if len(s.Events) > 0 && !slices.Contains(s.Events, event.Type) {
+		return false
+	}
+	if severities[event.Severity] < severities[s.MinSeverity] {
+		return false
+	}
+	return len(s.Clusters) == 0 || event.ClusterName == "" || slices.Contains(s.Clusters, event.ClusterName)
+}
+
+// Recipients returns the users and groups targeted by the subscription for an event.
+func (s *Subscription) Recipients(event *v3.NotificationEvent) []string {
+	recipients := append(slices.Clone(s.Users), s.Groups...)
+	if s.NotifyAffectedUser && event.UserName != "" {
+		recipients = append(recipients, event.UserName)
+	}
+	return recipients
+}
+
+// ThrottleInterval returns the minimum interval between two deliveries of a notification through a channel.
+func (s *Subscription) ThrottleInterval() time.Duration {
+	return s.throttle
+}
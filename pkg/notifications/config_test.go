@@ -0,0 +1,128 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "valid",
+			raw: `{"channels": [{"name": "ops", "type": "slack", "url": "https://hooks.example.com/1"}],
+				"subscriptions": [{"name": "prod", "minSeverity": "Warning", "channels": ["ops"], "throttle": "30m"}]}`,
+		},
+		{
+			name:    "invalid json",
+			raw:     `{`,
+			wantErr: "parsing notification configuration",
+		},
+		{
+			name:    "unknown channel type",
+			raw:     `{"channels": [{"name": "ops", "type": "pager"}]}`,
+			wantErr: `channel ops has unknown type "pager"`,
+		},
+		{
+			name:    "duplicate channel",
+			raw:     `{"channels": [{"name": "ops", "type": "slack"}, {"name": "ops", "type": "webhook"}]}`,
+			wantErr: "duplicate channel ops",
+		},
+		{
+			name:    "unknown subscription channel",
+			raw:     `{"subscriptions": [{"name": "prod", "channels": ["ops"]}]}`,
+			wantErr: `subscription prod has unknown channel "ops"`,
+		},
+		{
+			name:    "invalid severity",
+			raw:     `{"subscriptions": [{"name": "prod", "minSeverity": "Critical"}]}`,
+			wantErr: `subscription prod has invalid minimum severity "Critical"`,
+		},
+		{
+			name:    "invalid throttle",
+			raw:     `{"subscriptions": [{"name": "prod", "throttle": "often"}]}`,
+			wantErr: `subscription prod has invalid throttle "often"`,
+		},
+		{
+			name:    "user targeted by principal ID",
+			raw:     `{"subscriptions": [{"name": "prod", "users": ["github_user://1"]}]}`,
+			wantErr: `subscription prod has invalid user "github_user://1"`,
+		},
+		{
+			name:    "group targeted by name",
+			raw:     `{"subscriptions": [{"name": "prod", "groups": ["admins"]}]}`,
+			wantErr: `subscription prod has invalid group "admins"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseConfig(tt.raw)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	config, err := ParseConfig(`{"channels": [{"name": "ops", "type": "webhook"}], "subscriptions": [
+		{"name": "prod", "events": ["ClusterDisconnected", "TokenExpiring"], "minSeverity": "Warning",
+		 "clusters": ["c-prod"], "users": ["u-admin"], "groups": ["github_team://1"], "notifyAffectedUser": true,
+		 "channels": ["ops"], "throttle": "10m"},
+		{"name": "all"}]}`)
+	require.NoError(t, err)
+	prod, all := &config.Subscriptions[0], &config.Subscriptions[1]
+	assert.Equal(t, 10*time.Minute, prod.ThrottleInterval())
+	assert.Equal(t, DefaultThrottle, all.ThrottleInterval())
+
+	tests := []struct {
+		name  string
+		event v3.NotificationEvent
+		want  bool
+	}{
+		{
+			name:  "matching",
+			event: v3.NotificationEvent{Type: v3.NotificationEventClusterDisconnected, Severity: v3.NotificationSeverityError, ClusterName: "c-prod"},
+			want:  true,
+		},
+		{
+			name:  "other type",
+			event: v3.NotificationEvent{Type: v3.NotificationEventHelmOperationFailed, Severity: v3.NotificationSeverityError, ClusterName: "c-prod"},
+		},
+		{
+			name:  "lower severity",
+			event: v3.NotificationEvent{Type: v3.NotificationEventClusterDisconnected, Severity: v3.NotificationSeverityInfo, ClusterName: "c-prod"},
+		},
+		{
+			name:  "other cluster",
+			event: v3.NotificationEvent{Type: v3.NotificationEventClusterDisconnected, Severity: v3.NotificationSeverityError, ClusterName: "c-dev"},
+		},
+		{
+			name:  "no cluster",
+			event: v3.NotificationEvent{Type: v3.NotificationEventTokenExpiring, Severity: v3.NotificationSeverityWarning},
+			want:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, prod.Matches(&tt.event))
+			assert.True(t, all.Matches(&tt.event))
+		})
+	}
+
+	event := &v3.NotificationEvent{Type: v3.NotificationEventTokenExpiring, UserName: "u-owner"}
+	assert.Equal(t, []string{"u-admin", "github_team://1", "u-owner"}, prod.Recipients(event))
+	assert.Empty(t, all.Recipients(event))
+}
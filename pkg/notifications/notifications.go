@@ -0,0 +1,155 @@
+// Package notifications publishes platform events, such as expiring certificates or disconnected clusters, as
+// RancherUserNotifications. The notifications controller then delivers them to the users and groups subscribed to
+// their events through pluggable channels, see Config.
+package notifications
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// EventTypeLabel is the label holding the event type of the notifications published for events.
+	EventTypeLabel = "notifications.cattle.io/event-type"
+
+	// republishInterval is how often an unchanged event published repeatedly, such as an expiring certificate found
+	// by every sync of a controller, is recorded as a new occurrence.
+	republishInterval = time.Hour
+)
+
+// Event is a platform event.
+type Event struct {
+	Type     v3.NotificationEventType
+	Severity v3.NotificationSeverity
+	// Key identifies the event among the events of its type, the occurrences of an event are published as one
+	// notification.
+	Key string
+
+	ClusterName string
+	Object      string
+	UserName    string
+	Message     string
+}
+
+// Publisher publishes events as notifications.
+type Publisher struct {
+	notifications mgmtcontrollers.RancherUserNotificationClient
+	now           func() time.Time
+
+	mu        sync.Mutex
+	published map[string]published
+}
+
+type published struct {
+	message string
+	at      time.Time
+}
+
+// NewPublisher returns a publisher creating notifications with the given client.
+func NewPublisher(notifications mgmtcontrollers.RancherUserNotificationClient) *Publisher {
+	return &Publisher{
+		notifications: notifications,
+		now:           time.Now,
+		published:     map[string]published{},
+	}
+}
+
+// Name returns the name of the notification of an event.
+func Name(eventType v3.NotificationEventType, key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return "event-" + strings.ToLower(string(eventType)) + "-" + hex.EncodeToString(digest[:])[:16]
+}
+
+// Publish publishes an event, recording a new occurrence if it was already published. An unchanged event published
+// again within an hour isn't recorded again. Errors are logged, publishing never fails the caller. Publishing with a
+// nil publisher does nothing.
+func (p *Publisher) Publish(event Event) {
+	p.publish(event, false)
+}
+
+// PublishOnce publishes an event, unless it was already published. It is meant for the events which can only happen
+// once, such as the failure of a Helm operation, and can be found again by the controllers publishing them.
+func (p *Publisher) PublishOnce(event Event) {
+	p.publish(event, true)
+}
+
+func (p *Publisher) publish(event Event, once bool) {
+	if p == nil {
+		return
+	}
+	name := Name(event.Type, event.Key)
+	now := p.now()
+
+	p.mu.Lock()
+	last, ok := p.published[name]
+	if ok && (once || last.message == event.Message && now.Sub(last.at) < republishInterval) {
+		p.mu.Unlock()
+		return
+	}
+	p.published[name] = published{message: event.Message, at: now}
+	p.mu.Unlock()
+
+	if err := p.createOrUpdate(name, event, metav1.NewTime(now), once); err != nil {
+		logrus.Errorf("[notifications] Failed to publish %s event %s: %v", event.Type, event.Key, err)
+		p.mu.Lock()
+		delete(p.published, name)
+		p.mu.Unlock()
+	}
+}
+
+func (p *Publisher) createOrUpdate(name string, event Event, now metav1.Time, once bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := p.notifications.Get(name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = p.notifications.Create(&v3.RancherUserNotification{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{EventTypeLabel: string(event.Type)},
+				},
+				ComponentName: string(event.Type),
+				Message:       event.Message,
+				Event: &v3.NotificationEvent{
+					Type:            event.Type,
+					Severity:        event.Severity,
+					ClusterName:     event.ClusterName,
+					Object:          event.Object,
+					UserName:        event.UserName,
+					FirstOccurrence: now,
+					LastOccurrence:  now,
+					Count:           1,
+				},
+			})
+			if apierrors.IsAlreadyExists(err) {
+				return apierrors.NewConflict(v3.Resource("rancherusernotifications"), name, err)
+			}
+			return err
+		} else if err != nil || once {
+			return err
+		}
+
+		notification := existing.DeepCopy()
+		if notification.Event == nil {
+			notification.Event = &v3.NotificationEvent{FirstOccurrence: now}
+		}
+		notification.Message = event.Message
+		notification.Event.Type = event.Type
+		notification.Event.Severity = event.Severity
+		notification.Event.ClusterName = event.ClusterName
+		notification.Event.Object = event.Object
+		notification.Event.UserName = event.UserName
+		notification.Event.LastOccurrence = now
+		notification.Event.Count++
+		_, err = p.notifications.Update(notification)
+		return err
+	})
+}
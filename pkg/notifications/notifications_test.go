@@ -0,0 +1,82 @@
+package notifications
+
+import (
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPublish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := fake.NewMockNonNamespacedClientInterface[*v3.RancherUserNotification, *v3.RancherUserNotificationList](ctrl)
+	now := time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC)
+	p := NewPublisher(client)
+	p.now = func() time.Time { return now }
+
+	event := Event{
+		Type:        v3.NotificationEventClusterDisconnected,
+		Severity:    v3.NotificationSeverityError,
+		Key:         "c-abcde",
+		ClusterName: "c-abcde",
+		Message:     "The agent of cluster prod (c-abcde) disconnected",
+	}
+	name := Name(event.Type, event.Key)
+	assert.Equal(t, "event-clusterdisconnected-", name[:len(name)-16])
+
+	var stored *v3.RancherUserNotification
+	client.EXPECT().Get(name, metav1.GetOptions{}).DoAndReturn(func(string, metav1.GetOptions) (*v3.RancherUserNotification, error) {
+		if stored == nil {
+			return nil, apierrors.NewNotFound(v3.Resource("rancherusernotifications"), name)
+		}
+		return stored, nil
+	}).Times(2)
+	client.EXPECT().Create(gomock.Any()).DoAndReturn(func(notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+		stored = notification
+		return notification, nil
+	})
+	client.EXPECT().Update(gomock.Any()).DoAndReturn(func(notification *v3.RancherUserNotification) (*v3.RancherUserNotification, error) {
+		stored = notification
+		return notification, nil
+	})
+
+	p.Publish(event)
+	assert.Equal(t, string(v3.NotificationEventClusterDisconnected), stored.Labels[EventTypeLabel])
+	assert.Equal(t, event.Message, stored.Message)
+	assert.Equal(t, 1, stored.Event.Count)
+	assert.Equal(t, "c-abcde", stored.Event.ClusterName)
+
+	// an unchanged event isn't recorded again within an hour
+	now = now.Add(time.Minute)
+	p.Publish(event)
+	assert.Equal(t, 1, stored.Event.Count)
+
+	now = now.Add(time.Hour)
+	p.Publish(event)
+	assert.Equal(t, 2, stored.Event.Count)
+	assert.Equal(t, metav1.NewTime(now), stored.Event.LastOccurrence)
+	assert.Equal(t, metav1.NewTime(now.Add(-time.Hour-time.Minute)), stored.Event.FirstOccurrence)
+}
+
+func TestPublishOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	client := fake.NewMockNonNamespacedClientInterface[*v3.RancherUserNotification, *v3.RancherUserNotificationList](ctrl)
+	p := NewPublisher(client)
+
+	event := Event{Type: v3.NotificationEventHelmOperationFailed, Key: "cattle-system/helm-operation-abcde", Message: "failed"}
+	existing := &v3.RancherUserNotification{Event: &v3.NotificationEvent{Count: 1}}
+	// the notification already exists, it isn't updated, nor looked up again
+	client.EXPECT().Get(Name(event.Type, event.Key), metav1.GetOptions{}).Return(existing, nil)
+
+	p.PublishOnce(event)
+	p.PublishOnce(event)
+	assert.Equal(t, 1, existing.Event.Count)
+
+	var nilPublisher *Publisher
+	nilPublisher.Publish(event)
+}
@@ -52,16 +52,18 @@ type authorizeHandler struct {
 	oidcClientCache wrangmgmtv3.OIDCClientCache
 	sessionAdder    sessionAdder
 	codeCreator     codeCreator
+	sessionPolicies tokens.SessionPolicyVerifier
 	now             func() time.Time
 }
 
-func newAuthorizeHandler(tokenCache wrangmgmtv3.TokenCache, userLister wrangmgmtv3.UserCache, sessionAdder sessionAdder, codeCreator codeCreator, oidcClientCache wrangmgmtv3.OIDCClientCache) *authorizeHandler {
+func newAuthorizeHandler(tokenCache wrangmgmtv3.TokenCache, userLister wrangmgmtv3.UserCache, sessionAdder sessionAdder, codeCreator codeCreator, oidcClientCache wrangmgmtv3.OIDCClientCache, sessionPolicies tokens.SessionPolicyVerifier) *authorizeHandler {
 	return &authorizeHandler{
 		tokenCache:      tokenCache,
 		userLister:      userLister,
 		sessionAdder:    sessionAdder,
 		codeCreator:     codeCreator,
 		oidcClientCache: oidcClientCache,
+		sessionPolicies: sessionPolicies,
 		now:             time.Now,
 	}
 }
@@ -214,7 +216,7 @@ func (h *authorizeHandler) getAndVerifyRancherTokenFromRequest(r *http.Request)
 			return nil, fmt.Errorf("auth provider is disabled")
 		}
 	}
-	if _, err := tokens.VerifyToken(token, tokenName, tokenKey); err != nil {
+	if _, err := tokens.VerifyToken(token, tokenName, tokenKey, h.sessionPolicies); err != nil {
 		return nil, fmt.Errorf("failed to verify token: %w", err)
 	}
 
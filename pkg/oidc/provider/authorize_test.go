@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -12,6 +13,8 @@
 	"github.com/rancher/rancher/pkg/settings"
 
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/auth/accessor"
+	"github.com/rancher/rancher/pkg/auth/tokens"
 	"github.com/rancher/rancher/pkg/oidc/mocks"
 	"github.com/rancher/wrangler/v3/pkg/generic/fake"
 	"github.com/stretchr/testify/assert"
@@ -45,6 +48,7 @@ type mockParams struct {
 	tests := map[string]struct {
 		req                                func() *http.Request
 		mockSetup                          func(mockParams)
+		sessionPolicies                    tokens.SessionPolicyVerifier
 		wantRedirect                       string
 		wantHttpCode                       int
 		wantError                          string
@@ -147,6 +151,37 @@ type mockParams struct {
 			wantHttpCode: http.StatusFound,
 			wantRedirect: fakeServerUrl + "/dashboard/auth/login?client_id=client-id&code_challenge=code-challenge&code_challenge_method=S256&redirect_uri=https%3A%2F%2Fwww.rancher.com&response_type=code&scope=openid",
 		},
+		"redirect to login page if session timeout policy expired": {
+			req: func() *http.Request {
+				req := &http.Request{
+					URL: &url.URL{
+						Scheme:   "https",
+						Host:     "rancher.com",
+						RawQuery: "code_challenge_method=S256&code_challenge=code-challenge&response_type=code&redirect_uri=https://www.rancher.com&scope=openid&client_id=client-id",
+					},
+					Method: http.MethodGet,
+				}
+				req.Header = map[string][]string{
+					"Cookie": {"R_SESS=" + fakeTokenName + ":" + fakeTokenValue},
+				}
+
+				return req
+			},
+			mockSetup: func(m mockParams) {
+				m.tokenCache.EXPECT().Get(fakeTokenName).Return(&v3.Token{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: fakeTokenName,
+					},
+					Token:  fakeTokenValue,
+					UserID: fakeUserID,
+				}, nil)
+			},
+			sessionPolicies: fakeSessionPolicyVerifier(func(accessor.TokenAccessor, time.Time) (int, error) {
+				return http.StatusGone, fmt.Errorf("must authenticate, session timeout policy admins expired")
+			}),
+			wantHttpCode: http.StatusFound,
+			wantRedirect: fakeServerUrl + "/dashboard/auth/login?client_id=client-id&code_challenge=code-challenge&code_challenge_method=S256&redirect_uri=https%3A%2F%2Fwww.rancher.com&response_type=code&scope=openid",
+		},
 		"response type not supported": {
 			req: func() *http.Request {
 				req := &http.Request{
@@ -567,7 +602,7 @@ type mockParams struct {
 			if test.mockSetup != nil {
 				test.mockSetup(m)
 			}
-			h := newAuthorizeHandler(m.tokenCache, m.userLister, m.sessionAdder, m.codeCreator, m.oidcClientCache)
+			h := newAuthorizeHandler(m.tokenCache, m.userLister, m.sessionAdder, m.codeCreator, m.oidcClientCache, test.sessionPolicies)
 			h.now = func() time.Time {
 				return fakeTime
 			}
@@ -586,3 +621,9 @@ type mockParams struct {
 		})
 	}
 }
+
+type fakeSessionPolicyVerifier func(token accessor.TokenAccessor, now time.Time) (int, error)
+
+func (f fakeSessionPolicyVerifier) Verify(token accessor.TokenAccessor, now time.Time) (int, error) {
+	return f(token, now)
+}
@@ -21,4 +21,6 @@ type PeerManager interface {
 	Leader()
 	AddListener(l chan<- Peers)
 	RemoveListener(l chan<- Peers)
+	// Peers returns the current peer status of this replica.
+	Peers() Peers
 }
@@ -0,0 +1,391 @@
+// Package clustertemplate renders the revisions of provisioning cluster templates. The configuration of a revision,
+// with the answers of a cluster to its questions, is applied to the spec of the clusters bound to the revision, and the
+// fields of their spec that differ from it are reported as drift.
+package clustertemplate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/rancher/rancher/pkg/settings"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+// bindingField is the field of the cluster spec binding it to its template, which is never owned by the template.
+const bindingField = "clusterTemplate"
+
+// TemplateGetter gets cluster templates by namespace and name, it is implemented by the cluster template cache.
+type TemplateGetter interface {
+	Get(namespace, name string) (*rancherv1.ClusterTemplate, error)
+}
+
+// RevisionGetter gets cluster template revisions by namespace and name, it is implemented by the cluster template
+// revision cache.
+type RevisionGetter interface {
+	Get(namespace, name string) (*rancherv1.ClusterTemplateRevision, error)
+}
+
+// Resolve returns the revision a cluster of the given namespace is bound to, the default revision of the template if
+// the binding doesn't name one. The revision must belong to the template of the binding.
+func Resolve(templates TemplateGetter, revisions RevisionGetter, namespace string, binding *rancherv1.ClusterTemplateBinding) (*rancherv1.ClusterTemplateRevision, error) {
+	template, err := templates.Get(namespace, binding.Name)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("cluster template %s/%s not found", namespace, binding.Name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	name := binding.Revision
+	if name == "" {
+		if name = template.Spec.DefaultRevisionName; name == "" {
+			return nil, fmt.Errorf("cluster template %s/%s has no default revision", namespace, binding.Name)
+		}
+	}
+	revision, err := revisions.Get(namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("revision %s of cluster template %s/%s not found", name, namespace, binding.Name)
+	} else if err != nil {
+		return nil, err
+	}
+	if revision.Spec.ClusterTemplateName != binding.Name {
+		return nil, fmt.Errorf("revision %s does not belong to cluster template %s/%s", name, namespace, binding.Name)
+	}
+	return revision, nil
+}
+
+// Enabled returns whether clusters can be created from or upgraded to a revision.
+func Enabled(revision *rancherv1.ClusterTemplateRevision) bool {
+	return revision.Spec.Enabled == nil || *revision.Spec.Enabled
+}
+
+// Enforced returns whether the user may only create clusters from a template, because it is one of the principals of
+// the cluster-template-enforced-principals setting, by name, principal ID or group.
+func Enforced(u user.Info) bool {
+	if u == nil {
+		return false
+	}
+	principals := Principals(settings.ClusterTemplateEnforcedPrincipals.Get())
+	if len(principals) == 0 {
+		return false
+	}
+
+	names := append([]string{u.GetName()}, u.GetGroups()...)
+	names = append(names, u.GetExtra()[common.UserAttributePrincipalID]...)
+	for _, name := range names {
+		if name != "" && slices.Contains(principals, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Principals parses the value of the cluster-template-enforced-principals setting.
+func Principals(value string) []string {
+	var principals []string
+	for _, principal := range strings.Split(value, ",") {
+		if principal = strings.TrimSpace(principal); principal != "" {
+			principals = append(principals, principal)
+		}
+	}
+	return principals
+}
+
+// OwnedFields returns the sorted paths of the fields of the cluster spec owned by a revision, whatever the answers of
+// the clusters: the fields set by its configuration and the variables of its questions with a default or required.
+// The variables of the other questions are only owned once answered, they are returned as optional.
+func OwnedFields(revision *rancherv1.ClusterTemplateRevision) (owned, optional []string, err error) {
+	obj, err := toMap(revision.Spec.ClusterConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	delete(obj, bindingField)
+	if config, _ := prune(obj).(map[string]any); config != nil {
+		leaves(config, "", &owned)
+	}
+	for _, question := range revision.Spec.Questions {
+		if question.Default != "" || question.Required {
+			owned = append(owned, question.Variable)
+		} else {
+			optional = append(optional, question.Variable)
+		}
+	}
+	sort.Strings(owned)
+	sort.Strings(optional)
+	return slices.Compact(owned), slices.Compact(optional), nil
+}
+
+// Config is the rendered configuration of a revision, the fields of the cluster spec owned by the template.
+type Config map[string]any
+
+// Render returns the configuration of a revision with the given answers to its questions. The variable of each
+// question is the path of the field set to its answer, or to its default if the cluster doesn't answer it.
+func Render(revision *rancherv1.ClusterTemplateRevision, answers map[string]string) (Config, error) {
+	obj, err := toMap(revision.Spec.ClusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	delete(obj, bindingField)
+	config, _ := prune(obj).(map[string]any)
+	if config == nil {
+		config = map[string]any{}
+	}
+
+	for _, question := range revision.Spec.Questions {
+		answer, ok := answers[question.Variable]
+		if !ok || answer == "" {
+			answer = question.Default
+		}
+		if answer == "" {
+			if question.Required {
+				return nil, fmt.Errorf("question %s is required", question.Variable)
+			}
+			continue
+		}
+		value, err := convert(question, answer)
+		if err != nil {
+			return nil, err
+		}
+		if err := set(config, question.Variable, value); err != nil {
+			return nil, fmt.Errorf("invalid variable %s: %w", question.Variable, err)
+		}
+	}
+	return config, nil
+}
+
+// Hash returns the hash of a rendered configuration, which changes whenever the configuration does.
+func (c Config) Hash() string {
+	// maps are marshalled with sorted keys
+	data, _ := json.Marshal(c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Apply writes the fields owned by the template to the given spec. Objects are merged field by field, while lists and
+// other values replace those of the spec.
+func (c Config) Apply(spec *rancherv1.ClusterSpec) error {
+	current, err := toMap(spec)
+	if err != nil {
+		return err
+	}
+	merge(current, c)
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	var applied rancherv1.ClusterSpec
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return err
+	}
+	*spec = applied
+	return nil
+}
+
+// Drift returns the sorted paths of the fields owned by the template which differ in the given spec.
+func (c Config) Drift(spec rancherv1.ClusterSpec) ([]string, error) {
+	current, err := toMap(spec)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	drift(c, current, "", &paths)
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// convert converts the answer to a question to the type of the question.
+func convert(question v3.Question, answer string) (any, error) {
+	switch question.Type {
+	case "int":
+		value, err := strconv.ParseInt(answer, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("answer %q to question %s is not an integer", answer, question.Variable)
+		}
+		if (question.Min != 0 && value < int64(question.Min)) || (question.Max != 0 && value > int64(question.Max)) {
+			return nil, fmt.Errorf("answer %d to question %s is out of range [%d, %d]", value, question.Variable, question.Min, question.Max)
+		}
+		return value, nil
+	case "boolean":
+		value, err := strconv.ParseBool(answer)
+		if err != nil {
+			return nil, fmt.Errorf("answer %q to question %s is not a boolean", answer, question.Variable)
+		}
+		return value, nil
+	case "enum":
+		if !slices.Contains(question.Options, answer) {
+			return nil, fmt.Errorf("answer %q to question %s is not one of %s", answer, question.Variable, strings.Join(question.Options, ", "))
+		}
+	default:
+		if question.MinLength != 0 && len(answer) < question.MinLength {
+			return nil, fmt.Errorf("answer to question %s is shorter than %d characters", question.Variable, question.MinLength)
+		}
+		if question.MaxLength != 0 && len(answer) > question.MaxLength {
+			return nil, fmt.Errorf("answer to question %s is longer than %d characters", question.Variable, question.MaxLength)
+		}
+	}
+	return answer, nil
+}
+
+// set sets the field at the given dotted path, creating the objects along it. Numeric segments index lists, which must
+// already be long enough.
+func set(obj map[string]any, path string, value any) error {
+	segments := strings.Split(path, ".")
+	var current any = obj
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch c := current.(type) {
+		case map[string]any:
+			if last {
+				c[segment] = value
+				return nil
+			}
+			next, ok := c[segment]
+			if !ok || next == nil {
+				if _, err := strconv.Atoi(segments[i+1]); err == nil {
+					return fmt.Errorf("list %s is not set", strings.Join(segments[:i+1], "."))
+				}
+				next = map[string]any{}
+				c[segment] = next
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(c) {
+				return fmt.Errorf("index %s of %s is out of range", segment, strings.Join(segments[:i], "."))
+			}
+			if last {
+				c[index] = value
+				return nil
+			}
+			current = c[index]
+		default:
+			return fmt.Errorf("%s is not an object or a list", strings.Join(segments[:i], "."))
+		}
+	}
+	return nil
+}
+
+// merge merges the fields of src into dst, recursively for objects.
+func merge(dst, src map[string]any) {
+	for key, value := range src {
+		if srcObj, ok := value.(map[string]any); ok {
+			if dstObj, ok := dst[key].(map[string]any); ok {
+				merge(dstObj, srcObj)
+				continue
+			}
+			value = maps.Clone(srcObj)
+		}
+		dst[key] = value
+	}
+}
+
+// drift appends the paths of the fields of config that differ in current.
+func drift(config, current map[string]any, prefix string, paths *[]string) {
+	for key, value := range config {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if obj, ok := value.(map[string]any); ok {
+			currentObj, _ := current[key].(map[string]any)
+			drift(obj, currentObj, path, paths)
+			continue
+		}
+		if !reflect.DeepEqual(value, current[key]) {
+			*paths = append(*paths, path)
+		}
+	}
+}
+
+// leaves appends the paths of the fields of config, lists being fields as a whole.
+func leaves(config map[string]any, prefix string, paths *[]string) {
+	for key, value := range config {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if obj, ok := value.(map[string]any); ok {
+			leaves(obj, path, paths)
+			continue
+		}
+		*paths = append(*paths, path)
+	}
+}
+
+// prune removes the zero values and empty objects, which don't set any field. Lists are kept as is, as they are owned
+// as a whole. The answers to questions are set once the configuration is pruned, so that they can set fields to their
+// zero value.
+func prune(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case bool:
+		if !v {
+			return nil
+		}
+	case string:
+		if v == "" {
+			return nil
+		}
+	case int64:
+		if v == 0 {
+			return nil
+		}
+	case map[string]any:
+		for key, field := range v {
+			if field = prune(field); field == nil {
+				delete(v, key)
+			} else {
+				v[key] = field
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+// toMap converts a value to its generic JSON representation, with numbers decoded the way they are once the
+// configuration is marshalled again, so that answers and fields of the spec compare equal.
+func toMap(obj any) (map[string]any, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]any{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return normalize(result).(map[string]any), nil
+}
+
+// normalize converts the floats decoded from JSON to integers when they are integral, the type of the integer answers.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			v[key] = normalize(field)
+		}
+	case []any:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+	case float64:
+		if i := int64(v); float64(i) == v {
+			return i
+		}
+	}
+	return value
+}
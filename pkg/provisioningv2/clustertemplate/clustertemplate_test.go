@@ -0,0 +1,132 @@
+package clustertemplate
+
+import (
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/auth/providers/common"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/utils/ptr"
+)
+
+func newRevision(questions ...v3.Question) *rancherv1.ClusterTemplateRevision {
+	return &rancherv1.ClusterTemplateRevision{
+		Spec: rancherv1.ClusterTemplateRevisionSpec{
+			ClusterTemplateName: "rke2",
+			Questions:           questions,
+			ClusterConfig: rancherv1.ClusterSpec{
+				KubernetesVersion: "v1.33.1+rke2r1",
+				RKEConfig: &rancherv1.RKEConfig{
+					MachinePools: []rancherv1.RKEMachinePool{
+						{Name: "pool", Quantity: ptr.To[int32](1)},
+					},
+				},
+				ClusterTemplate: &rancherv1.ClusterTemplateBinding{Name: "ignored"},
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	revision := newRevision(
+		v3.Question{Variable: "kubernetesVersion", Type: "enum", Options: []string{"v1.33.1+rke2r1", "v1.34.1+rke2r1"}},
+		v3.Question{Variable: "rkeConfig.machinePools.0.quantity", Type: "int", Default: "3", Max: 5},
+		v3.Question{Variable: "defaultPodSecurityAdmissionConfigurationTemplateName", Required: true},
+	)
+
+	config, err := Render(revision, map[string]string{
+		"kubernetesVersion": "v1.34.1+rke2r1",
+		"defaultPodSecurityAdmissionConfigurationTemplateName": "rancher-restricted",
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, config, "clusterTemplate")
+	assert.Equal(t, "v1.34.1+rke2r1", config["kubernetesVersion"])
+	assert.Equal(t, "rancher-restricted", config["defaultPodSecurityAdmissionConfigurationTemplateName"])
+	pools := config["rkeConfig"].(map[string]any)["machinePools"].([]any)
+	assert.Equal(t, int64(3), pools[0].(map[string]any)["quantity"])
+
+	for name, answers := range map[string]map[string]string{
+		"missing required answer": {},
+		"invalid enum":            {"kubernetesVersion": "v1.20.0+rke2r1", "defaultPodSecurityAdmissionConfigurationTemplateName": "x"},
+		"out of range":            {"rkeConfig.machinePools.0.quantity": "6", "defaultPodSecurityAdmissionConfigurationTemplateName": "x"},
+		"not an integer":          {"rkeConfig.machinePools.0.quantity": "three", "defaultPodSecurityAdmissionConfigurationTemplateName": "x"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Render(revision, answers)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = Render(newRevision(v3.Question{Variable: "rkeConfig.machinePools.1.quantity", Type: "int", Default: "1"}), nil)
+	assert.Error(t, err, "index out of range")
+}
+
+func TestApplyAndDrift(t *testing.T) {
+	config, err := Render(newRevision(), nil)
+	require.NoError(t, err)
+
+	spec := rancherv1.ClusterSpec{
+		KubernetesVersion:         "v1.32.0+rke2r1",
+		CloudCredentialSecretName: "cattle-global-data:cc-abcde",
+		ClusterTemplate:           &rancherv1.ClusterTemplateBinding{Name: "rke2", Revision: "r1"},
+	}
+	drift, err := config.Drift(spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubernetesVersion", "rkeConfig.machinePools"}, drift)
+
+	require.NoError(t, config.Apply(&spec))
+	assert.Equal(t, "v1.33.1+rke2r1", spec.KubernetesVersion)
+	assert.Equal(t, "cattle-global-data:cc-abcde", spec.CloudCredentialSecretName, "fields not owned by the template are kept")
+	assert.Equal(t, &rancherv1.ClusterTemplateBinding{Name: "rke2", Revision: "r1"}, spec.ClusterTemplate)
+	require.Len(t, spec.RKEConfig.MachinePools, 1)
+	assert.Equal(t, int32(1), *spec.RKEConfig.MachinePools[0].Quantity)
+
+	drift, err = config.Drift(spec)
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	spec.RKEConfig.MachinePools[0].Quantity = ptr.To[int32](2)
+	drift, err = config.Drift(spec)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rkeConfig.machinePools"}, drift)
+}
+
+func TestHash(t *testing.T) {
+	revision := newRevision(v3.Question{Variable: "kubernetesVersion"})
+	a, err := Render(revision, map[string]string{"kubernetesVersion": "v1.33.1+rke2r1"})
+	require.NoError(t, err)
+	b, err := Render(revision, nil)
+	require.NoError(t, err)
+	c, err := Render(revision, map[string]string{"kubernetesVersion": "v1.34.1+rke2r1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, a.Hash(), b.Hash())
+	assert.NotEqual(t, a.Hash(), c.Hash())
+}
+
+func TestEnforced(t *testing.T) {
+	require.NoError(t, settings.ClusterTemplateEnforcedPrincipals.Set("u-abcde, okta_group://developers"))
+	t.Cleanup(func() { _ = settings.ClusterTemplateEnforcedPrincipals.Set("") })
+
+	assert.True(t, Enforced(&user.DefaultInfo{Name: "u-abcde"}))
+	assert.True(t, Enforced(&user.DefaultInfo{Name: "u-fghij", Groups: []string{"okta_group://developers"}}))
+	assert.True(t, Enforced(&user.DefaultInfo{Name: "u-fghij", Extra: map[string][]string{common.UserAttributePrincipalID: {"u-abcde"}}}))
+	assert.False(t, Enforced(&user.DefaultInfo{Name: "u-fghij", Groups: []string{"okta_group://ops"}}))
+	assert.False(t, Enforced(nil))
+}
+
+func TestOwnedFields(t *testing.T) {
+	revision := newRevision(
+		v3.Question{Variable: "kubernetesVersion", Default: "v1.33.1+rke2r1"},
+		v3.Question{Variable: "rkeConfig.etcd.snapshotRetention", Required: true},
+		v3.Question{Variable: "defaultPodSecurityAdmissionConfigurationTemplateName"},
+	)
+	owned, optional, err := OwnedFields(revision)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kubernetesVersion", "rkeConfig.etcd.snapshotRetention", "rkeConfig.machinePools"}, owned)
+	assert.Equal(t, []string{"defaultPodSecurityAdmissionConfigurationTemplateName"}, optional)
+}
@@ -168,6 +168,12 @@
 	// and it must never be greater than this value.
 	AuthUserSessionIdleTTLMinutes = NewSetting("auth-user-session-idle-ttl-minutes", "960") // 16 hours
 
+	// AuthSessionTimeoutPolicies is the JSON list of the idle and absolute timeout policies applying to the tokens
+	// of the users with given group principals or global roles, and of given client types, see the tokens package
+	// for its format. Policies can only shorten the lifetime of tokens. An invalid value is rejected by the API; if it is
+	// set otherwise, tokens can't be verified until it is fixed, unless valid policies were already loaded.
+	AuthSessionTimeoutPolicies = NewSetting("auth-session-timeout-policies", "")
+
 	// ChartDefaultURL represents the default URL for the system charts repo. It should only be set for test or
 	// debug purposes.
 	ChartDefaultURL = NewSetting("chart-default-url", "https://git.rancher.io/")
@@ -183,6 +189,10 @@
 	// An empty string or a zero value means the feature is disabled.
 	DeleteInactiveUserAfter = NewSetting("delete-inactive-user-after", "")
 
+	// DriverSignatureRequired determines if node and kontainer drivers which are downloaded from a URL must specify a
+	// signature verification. Valid values are "true" and "false". Builtin drivers are not affected.
+	DriverSignatureRequired = NewSetting("driver-signature-required", "false")
+
 	// DeleteMachineOnFailureAfter is the duration after which a machine job that failed to provision a machine will be deleted.
 	// The value should be expressed in valid time.Duration units. See https://pkg.go.dev/time#ParseDuration
 	DeleteMachineOnFailureAfter = NewSetting("delete-machine-on-failure-after", "0s")
@@ -231,6 +241,11 @@
 	// This setting will take effect regardless of the kubeconfig-generate-token status.
 	KubeconfigDefaultTokenTTLMinutes = NewSetting("kubeconfig-default-token-ttl-minutes", "43200") // 30 days
 
+	// KubeconfigExecTokenTTLMinutes is the default time to live of the tokens obtained by the exec credential plugin
+	// of kubeconfigs that don't embed tokens, and the max TTL of these kubeconfigs. It is capped by
+	// kubeconfig-default-token-ttl-minutes.
+	KubeconfigExecTokenTTLMinutes = NewSetting("kubeconfig-exec-token-ttl-minutes", "60")
+
 	// KubeconfigGenerateToken determines whether the UI will return a generate token with kubeconfigs.
 	// If set to false the kubeconfig will contain a command to login to Rancher.
 	KubeconfigGenerateToken = NewSetting("kubeconfig-generate-token", "true")
@@ -412,6 +427,33 @@
 
 	SCCOperatorImage = NewSetting("scc-operator-image", buildconfig.DefaultSccOperatorImage)
 
+	// EtcdSnapshotVerificationImage is the image of the jobs verifying etcd snapshots of provisioned clusters. It must
+	// provide a shell along with the etcd, etcdctl and etcdutl binaries.
+	EtcdSnapshotVerificationImage = NewSetting("etcd-snapshot-verification-image", "rancher/hardened-etcd:v3.5.21-k3s1-build20250612")
+
+	// MachineShellRecordingStore is where the recordings of SSH shell sessions to provisioned machines are stored. With
+	// "secret", each recording is stored in a secret in the cattle-system namespace. With "directory", recordings are
+	// stored as files under MachineShellRecordingDirectory, which should be a volume shared by all Rancher replicas.
+	MachineShellRecordingStore = NewSetting("machine-shell-recording-store", "secret")
+
+	// MachineShellRecordingDirectory is the directory recordings of SSH shell sessions are stored in when
+	// MachineShellRecordingStore is "directory".
+	MachineShellRecordingDirectory = NewSetting("machine-shell-recording-directory", "/var/lib/rancher/machine-shell-recordings")
+
+	// ClusterProxyFlowControl is the JSON configuration of the rate and concurrency limits of the requests proxied to
+	// downstream clusters, see the flowcontrol package for its format. By default, system users are exempt and other
+	// users aren't limited.
+	ClusterProxyFlowControl = NewSetting("cluster-proxy-flow-control", `{"priorityLevels":[{"name":"exempt","exempt":true},{"name":"global-default"}],"flowSchemas":[{"name":"system","priorityLevel":"exempt","users":["system:*"],"groups":["system:masters"]},{"name":"global-default","priorityLevel":"global-default"}]}`)
+
+	// ClusterTemplateEnforcedPrincipals is the comma-separated list of the users and groups that may only create
+	// provisioning clusters from a cluster template. They are either user names, such as u-abcde, or principal IDs, such
+	// as local://u-abcde or okta_group://developers.
+	ClusterTemplateEnforcedPrincipals = NewSetting("cluster-template-enforced-principals", "")
+
+	// NotificationConfig is the JSON configuration of the channels notifications are delivered through and of the
+	// subscriptions routing platform events to users, groups and channels, see the notifications package for its format.
+	NotificationConfig = NewSetting("notification-config", "")
+
 	// This is the limit for request bodies sent to /v3-public/* endpoints in
 	// bytes.
 	// The default = 1MiB
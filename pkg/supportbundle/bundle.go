@@ -0,0 +1,157 @@
+// Package supportbundle collects a redacted diagnostic bundle of a Rancher install. The bundle is served to
+// administrators by the supportconfigs package and written by the support-bundle command.
+package supportbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"time"
+
+	"github.com/rancher/rancher/pkg/auth/audit"
+	"github.com/rancher/rancher/pkg/namespace"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// prefix is the directory holding the files of a bundle.
+	prefix = "rancher-support-bundle"
+	// DefaultLogLines is the number of lines of logs included for each container.
+	DefaultLogLines = 2000
+)
+
+// Collector collects support bundles from the local cluster.
+type Collector struct {
+	client    dynamic.Interface
+	k8s       kubernetes.Interface
+	namespace string
+	logLines  int64
+	now       func() time.Time
+}
+
+// New creates a collector using the given config of the local cluster, including logLines lines of logs for each
+// container.
+func New(restConfig *rest.Config, logLines int64) (*Collector, error) {
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	k8s, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return newCollector(client, k8s, logLines), nil
+}
+
+func newCollector(client dynamic.Interface, k8s kubernetes.Interface, logLines int64) *Collector {
+	return &Collector{
+		client:    client,
+		k8s:       k8s,
+		namespace: namespace.System,
+		logLines:  logLines,
+		now:       time.Now,
+	}
+}
+
+type section struct {
+	name    string
+	collect func(ctx context.Context, a *archive) error
+}
+
+type sectionError struct {
+	Section string `json:"section"`
+	Error   string `json:"error"`
+}
+
+// Write writes the bundle to w as a gzipped tar archive. Sections that cannot be collected are listed in errors.json
+// rather than failing the bundle, so that a partial bundle is still produced for a degraded install.
+func (c *Collector) Write(ctx context.Context, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	a := &archive{
+		tw:      tar.NewWriter(gz),
+		modTime: c.now(),
+	}
+
+	sections := []section{
+		{name: "version", collect: c.version},
+		{name: "settings", collect: c.settings},
+		{name: "logs", collect: c.logs},
+		{name: "management", collect: c.management},
+		{name: "clusters", collect: c.clusters},
+		{name: "provisioning", collect: c.provisioning},
+		{name: "helm", collect: c.helmOperations},
+	}
+	var failures []sectionError
+	for _, s := range sections {
+		if err := s.collect(ctx, a); err != nil {
+			if a.err != nil {
+				return a.err
+			}
+			failures = append(failures, sectionError{Section: s.name, Error: err.Error()})
+		}
+	}
+	if len(failures) > 0 {
+		if err := a.addJSON("errors.json", map[string]any{"errors": failures}); err != nil {
+			return err
+		}
+	}
+
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// archive writes the files of a bundle, and records the first error writing to the underlying writer so that it is not
+// mistaken for a failure to collect a section.
+type archive struct {
+	tw      *tar.Writer
+	modTime time.Time
+	err     error
+}
+
+func (a *archive) add(name string, data []byte) error {
+	if a.err != nil {
+		return a.err
+	}
+	if err := a.tw.WriteHeader(&tar.Header{
+		Name:    path.Join(prefix, name),
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: a.modTime,
+	}); err != nil {
+		a.err = err
+		return err
+	}
+	if _, err := a.tw.Write(data); err != nil {
+		a.err = err
+		return err
+	}
+	return nil
+}
+
+// addJSON adds v as an indented JSON document, after redacting it like the audit log redacts response bodies. v must
+// marshal to a JSON object.
+func (a *archive) addJSON(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	obj := map[string]any{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	if err := audit.RedactObject(obj); err != nil {
+		return err
+	}
+	data, err = json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	return a.add(name, data)
+}
@@ -0,0 +1,391 @@
+package supportbundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	provv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/auth/audit"
+	"github.com/rancher/rancher/pkg/features"
+	"github.com/rancher/rancher/pkg/version"
+	"github.com/rancher/wrangler/v3/pkg/condition"
+	"github.com/rancher/wrangler/v3/pkg/genericcondition"
+	"github.com/rancher/wrangler/v3/pkg/kstatus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// rancherPodSelector selects the pods of the Rancher deployment.
+	rancherPodSelector = "app=rancher"
+	// helmContainer is the container of a helm operation pod running the helm command.
+	helmContainer = "helm"
+)
+
+var (
+	featureResource         = v3.SchemeGroupVersion.WithResource("features")
+	settingResource         = v3.SchemeGroupVersion.WithResource("settings")
+	clusterResource         = v3.SchemeGroupVersion.WithResource("clusters")
+	authConfigResource      = v3.SchemeGroupVersion.WithResource("authconfigs")
+	tokenResource           = v3.SchemeGroupVersion.WithResource("tokens")
+	provClusterResource     = provv1.SchemeGroupVersion.WithResource("clusters")
+	rkeControlPlaneResource = rkev1.SchemeGroupVersion.WithResource("rkecontrolplanes")
+	operationResource       = catalog.SchemeGroupVersion.WithResource("operations")
+
+	// connected is the condition of management clusters reporting whether the cluster agent is connected.
+	connected = condition.Cond("Connected")
+
+	// summarizedResources are the management resources counted in management/summary.json.
+	summarizedResources = []string{
+		"authconfigs",
+		"clusterregistrationtokens",
+		"clusterroletemplatebindings",
+		"clusters",
+		"globalrolebindings",
+		"globalroles",
+		"nodepools",
+		"nodes",
+		"projectroletemplatebindings",
+		"projects",
+		"roletemplates",
+		"tokens",
+		"users",
+	}
+
+	// excludedSettings are settings whose values identify the install, or are too large to be useful in a bundle.
+	excludedSettings = []string{
+		"install-uuid",
+		"k8s-version-to-images",
+		"ui-banner-dark",
+		"ui-banner-light",
+		"ui-favicon",
+		"ui-login-background-dark",
+		"ui-login-background-light",
+		"ui-logo-dark",
+		"ui-logo-light",
+	}
+)
+
+type versionInfo struct {
+	Version   string          `json:"version"`
+	GitCommit string          `json:"gitCommit"`
+	Features  map[string]bool `json:"features,omitempty"`
+}
+
+// version collects the version of Rancher and whether each feature is enabled.
+func (c *Collector) version(ctx context.Context, a *archive) error {
+	info := versionInfo{
+		Version:   version.Version,
+		GitCommit: version.GitCommit,
+	}
+	list, listErr := c.client.Resource(featureResource).List(ctx, metav1.ListOptions{})
+	if listErr == nil {
+		info.Features = map[string]bool{}
+		for _, item := range list.Items {
+			var feature v3.Feature
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &feature); err != nil {
+				return err
+			}
+			info.Features[feature.Name] = features.IsEnabled(&feature)
+		}
+	}
+	if err := a.addJSON("version.json", info); err != nil {
+		return err
+	}
+	return listErr
+}
+
+type settingSummary struct {
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	Default    string `json:"default,omitempty"`
+	Customized bool   `json:"customized,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
+
+// settings collects the settings of Rancher, except for the excluded ones. Credentials embedded in URLs are removed.
+func (c *Collector) settings(ctx context.Context, a *archive) error {
+	list, err := c.client.Resource(settingResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var summaries []settingSummary
+	for _, item := range list.Items {
+		var setting v3.Setting
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &setting); err != nil {
+			return err
+		}
+		if slices.Contains(excludedSettings, setting.Name) {
+			continue
+		}
+		summaries = append(summaries, settingSummary{
+			Name:       setting.Name,
+			Value:      stripURLCredentials(setting.Value),
+			Default:    stripURLCredentials(setting.Default),
+			Customized: setting.Customized,
+			Source:     setting.Source,
+		})
+	}
+	slices.SortFunc(summaries, func(a, b settingSummary) int { return strings.Compare(a.Name, b.Name) })
+	return a.addJSON("settings.json", map[string]any{"settings": summaries})
+}
+
+// stripURLCredentials removes the user info of values that are URLs.
+func stripURLCredentials(value string) string {
+	if !strings.Contains(value, "://") {
+		return value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	u.User = nil
+	return u.String()
+}
+
+// logs collects the recent logs of each container of the Rancher pods, and the logs of the previous instance of the
+// containers that restarted.
+func (c *Collector) logs(ctx context.Context, a *archive) error {
+	pods, err := c.k8s.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: rancherPodSelector})
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if err := c.addLogs(ctx, a, fmt.Sprintf("logs/%s/%s.log", pod.Name, status.Name), pod.Namespace, pod.Name, status.Name, false); err != nil {
+				errs = append(errs, err)
+			}
+			if status.RestartCount == 0 {
+				continue
+			}
+			if err := c.addLogs(ctx, a, fmt.Sprintf("logs/%s/%s.previous.log", pod.Name, status.Name), pod.Namespace, pod.Name, status.Name, true); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// addLogs adds the logs of a container, after redacting the values of the fields with sensitive names.
+func (c *Collector) addLogs(ctx context.Context, a *archive, name, namespace, pod, container string, previous bool) error {
+	data, err := c.k8s.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		Previous:  previous,
+		TailLines: &c.logLines,
+	}).DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("getting logs of container %s of pod %s/%s: %w", container, namespace, pod, err)
+	}
+	return a.add(name, audit.RedactText(data))
+}
+
+type resourceCount struct {
+	Resource string `json:"resource"`
+	Count    int    `json:"count"`
+}
+
+type authProviderSummary struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
+
+// management collects the number of each summarized management resource, the auth providers, and the tokens of users,
+// whose secret values are redacted.
+func (c *Collector) management(ctx context.Context, a *archive) error {
+	var (
+		counts []resourceCount
+		errs   []error
+		lists  = map[string]*unstructured.UnstructuredList{}
+	)
+	for _, resource := range summarizedResources {
+		list, err := c.client.Resource(v3.SchemeGroupVersion.WithResource(resource)).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("listing %s: %w", resource, err))
+			continue
+		}
+		lists[resource] = list
+		counts = append(counts, resourceCount{Resource: resource, Count: len(list.Items)})
+	}
+	if err := a.addJSON("management/summary.json", map[string]any{"resources": counts}); err != nil {
+		return err
+	}
+
+	if list, ok := lists[authConfigResource.Resource]; ok {
+		var providers []authProviderSummary
+		for _, item := range list.Items {
+			enabled, _, _ := unstructured.NestedBool(item.Object, "enabled")
+			providerType, _, _ := unstructured.NestedString(item.Object, "type")
+			providers = append(providers, authProviderSummary{Name: item.GetName(), Type: providerType, Enabled: enabled})
+		}
+		if err := a.addJSON("management/authconfigs.json", map[string]any{"authConfigs": providers}); err != nil {
+			return err
+		}
+	}
+	if list, ok := lists[tokenResource.Resource]; ok {
+		if err := a.addJSON("management/tokens.json", objectList(list.Items)); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type clusterSummary struct {
+	Name          string                              `json:"name"`
+	DisplayName   string                              `json:"displayName,omitempty"`
+	Provider      string                              `json:"provider,omitempty"`
+	Driver        string                              `json:"driver,omitempty"`
+	Version       string                              `json:"version,omitempty"`
+	NodeCount     int                                 `json:"nodeCount"`
+	Connected     bool                                `json:"connected"`
+	AgentImage    string                              `json:"agentImage,omitempty"`
+	AgentFeatures map[string]bool                     `json:"agentFeatures,omitempty"`
+	Conditions    []genericcondition.GenericCondition `json:"conditions,omitempty"`
+}
+
+// clusters collects the connection and agent state of each management cluster.
+func (c *Collector) clusters(ctx context.Context, a *archive) error {
+	list, err := c.client.Resource(clusterResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var summaries []clusterSummary
+	for _, item := range list.Items {
+		var cluster v3.Cluster
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &cluster); err != nil {
+			return err
+		}
+		summary := clusterSummary{
+			Name:          cluster.Name,
+			DisplayName:   cluster.Spec.DisplayName,
+			Provider:      cluster.Status.Provider,
+			Driver:        cluster.Status.Driver,
+			NodeCount:     cluster.Status.NodeCount,
+			Connected:     connected.IsTrue(&cluster),
+			AgentImage:    cluster.Status.AgentImage,
+			AgentFeatures: cluster.Status.AgentFeatures,
+		}
+		if cluster.Status.Version != nil {
+			summary.Version = cluster.Status.Version.GitVersion
+		}
+		for _, condition := range cluster.Status.Conditions {
+			summary.Conditions = append(summary.Conditions, genericcondition.GenericCondition{
+				Type:               string(condition.Type),
+				Status:             condition.Status,
+				LastUpdateTime:     condition.LastUpdateTime,
+				LastTransitionTime: condition.LastTransitionTime,
+				Reason:             condition.Reason,
+				Message:            condition.Message,
+			})
+		}
+		summaries = append(summaries, summary)
+	}
+	return a.addJSON("clusters.json", map[string]any{"clusters": summaries})
+}
+
+type provisioningSummary struct {
+	Namespace    string                              `json:"namespace"`
+	Name         string                              `json:"name"`
+	ClusterName  string                              `json:"clusterName,omitempty"`
+	Ready        bool                                `json:"ready"`
+	Conditions   []genericcondition.GenericCondition `json:"conditions,omitempty"`
+	ControlPlane map[string]any                      `json:"controlPlane,omitempty"`
+}
+
+// provisioning collects the status of each provisioning cluster, along with the status reported by the planner on its
+// control plane. The applied spec of control planes is omitted.
+func (c *Collector) provisioning(ctx context.Context, a *archive) error {
+	list, err := c.client.Resource(provClusterResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	controlPlanes, err := c.client.Resource(rkeControlPlaneResource).List(ctx, metav1.ListOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	statuses := map[string]map[string]any{}
+	if controlPlanes != nil {
+		for _, item := range controlPlanes.Items {
+			status, _, _ := unstructured.NestedMap(item.Object, "status")
+			delete(status, "appliedSpec")
+			statuses[item.GetNamespace()+"/"+item.GetName()] = status
+		}
+	}
+
+	var summaries []provisioningSummary
+	for _, item := range list.Items {
+		var cluster provv1.Cluster
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &cluster); err != nil {
+			return err
+		}
+		summaries = append(summaries, provisioningSummary{
+			Namespace:    cluster.Namespace,
+			Name:         cluster.Name,
+			ClusterName:  cluster.Status.ClusterName,
+			Ready:        cluster.Status.Ready,
+			Conditions:   cluster.Status.Conditions,
+			ControlPlane: statuses[cluster.Namespace+"/"+cluster.Name],
+		})
+	}
+	return a.addJSON("provisioning.json", map[string]any{"clusters": summaries})
+}
+
+// helmOperations collects the helm operations that failed, and the logs of their helm container while their pod still
+// exists.
+func (c *Collector) helmOperations(ctx context.Context, a *archive) error {
+	list, err := c.client.Resource(operationResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	var (
+		failed []unstructured.Unstructured
+		errs   []error
+	)
+	for _, item := range list.Items {
+		var operation catalog.Operation
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &operation); err != nil {
+			return err
+		}
+		// the helm operation controller reports a helm command exiting with an error as a Stalled condition
+		if !kstatus.Stalled.IsTrue(&operation) {
+			continue
+		}
+		failed = append(failed, item)
+		if operation.Status.PodName == "" {
+			continue
+		}
+		name := fmt.Sprintf("helm/logs/%s_%s.log", operation.Namespace, operation.Name)
+		if err := c.addLogs(ctx, a, name, operation.Status.PodNamespace, operation.Status.PodName, helmContainer, false); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	if err := a.addJSON("helm/failed-operations.json", objectList(failed)); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// objectList returns the given objects as a list, without their managed fields.
+func objectList(items []unstructured.Unstructured) map[string]any {
+	result := make([]any, 0, len(items))
+	for _, item := range items {
+		item := item.DeepCopy()
+		item.SetManagedFields(nil)
+		result = append(result, item.Object)
+	}
+	return map[string]any{
+		"kind":  "List",
+		"items": result,
+	}
+}
@@ -0,0 +1,69 @@
+package supportbundle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/urfave/cli"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// RegisterCommand registers the support-bundle command, which writes a bundle of the local cluster for installs whose
+// API is unavailable.
+func RegisterCommand() {
+	reexec.Register("/usr/bin/support-bundle", supportBundle)
+	reexec.Register("support-bundle", supportBundle)
+}
+
+func supportBundle() {
+	app := cli.NewApp()
+	app.Description = "Write a redacted diagnostic bundle of the Rancher install"
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "File to write the gzipped tar archive to, standard output if unset",
+		},
+		cli.Int64Flag{
+			Name:  "log-lines",
+			Usage: "Number of lines of logs to include for each container",
+			Value: DefaultLogLines,
+		},
+	}
+
+	app.Action = func(c *cli.Context) error {
+		kubeConfigPath := os.ExpandEnv("$HOME/.kube/config")
+		if _, err := os.Stat(kubeConfigPath); err != nil {
+			kubeConfigPath = ""
+		}
+
+		conf, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+		if err != nil {
+			return fmt.Errorf("couldn't get kubeconfig: %w", err)
+		}
+
+		collector, err := New(conf, c.Int64("log-lines"))
+		if err != nil {
+			return fmt.Errorf("couldn't create kubernetes clients: %w", err)
+		}
+
+		var out io.Writer = os.Stdout
+		if output := c.String("output"); output != "" {
+			f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		return collector.Write(context.Background(), out)
+	}
+
+	err := app.Run(os.Args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -183,6 +183,19 @@ func (p *peerManager) addRemovePeers(endpoints *v1.Endpoints) {
 }
 
 func (p *peerManager) notify() {
+	peers := p.peerStatus()
+	for c := range p.listeners {
+		c <- peers
+	}
+}
+
+func (p *peerManager) Peers() peermanager.Peers {
+	p.Lock()
+	defer p.Unlock()
+	return p.peerStatus()
+}
+
+func (p *peerManager) peerStatus() peermanager.Peers {
 	peers := peermanager.Peers{
 		Leader: p.leader,
 		Ready:  p.ready,
@@ -192,10 +205,7 @@ func (p *peerManager) notify() {
 	for id := range p.peers {
 		peers.IDs = append(peers.IDs, id)
 	}
-
-	for c := range p.listeners {
-		c <- peers
-	}
+	return peers
 }
 
 func (p *peerManager) AddListener(c chan<- peermanager.Peers) {
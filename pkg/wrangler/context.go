@@ -11,6 +11,7 @@
 	"os"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	fleetv1alpha1api "github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
@@ -186,6 +187,8 @@ type Context struct {
 	telemetry    *telemetry.Factory
 
 	started bool
+	// controllersStarted is set once the controllers of the context have started, after their caches synced.
+	controllersStarted *atomic.Bool
 }
 
 type MultiClusterManager interface {
@@ -301,11 +304,28 @@ func (w *Context) Start(ctx context.Context) error {
 	if err := w.ControllerFactory.Start(ctx, defaultControllerWorkerCount); err != nil {
 		return err
 	}
+	w.controllersStarted.Store(true)
 	w.leadership.Start(ctx)
 	logrus.Trace("Wrangler context has started")
 	return nil
 }
 
+// CachesSynced returns the kinds whose caches have not synced yet, or an error if the controllers of the context have
+// not been started yet.
+func (w *Context) CachesSynced(ctx context.Context) ([]schema.GroupVersionKind, error) {
+	if w.controllersStarted == nil || !w.controllersStarted.Load() {
+		return nil, fmt.Errorf("controllers have not been started")
+	}
+
+	var unsynced []schema.GroupVersionKind
+	for gvk, synced := range w.ControllerFactory.SharedCacheFactory().WaitForCacheSync(ctx) {
+		if !synced {
+			unsynced = append(unsynced, gvk)
+		}
+	}
+	return unsynced, nil
+}
+
 // WithAgent returns a shallow copy of the Context that has been configured to use a user agent in its
 // clients that is the configured server-version and given userAgent insert into "rancher-%s-%s".
 func (w *Context) WithAgent(userAgent string) *Context {
@@ -488,7 +508,8 @@ func NewContext(ctx context.Context, clientConfig clientcmd.ClientConfig, restCo
 		rbac.Rbac().V1(),
 		content,
 		core.Core().V1().Pod(),
-		core.Core().V1().Node())
+		core.Core().V1().Node(),
+		core.Core().V1().Secret())
 
 	cache := memory.NewMemCacheClient(k8s.Discovery())
 	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(cache)
@@ -547,6 +568,7 @@ func NewContext(ctx context.Context, clientConfig clientcmd.ClientConfig, restCo
 		RESTMapper:              restMapper,
 		leadership:              leadership,
 		controllerLock:          &sync.Mutex{},
+		controllersStarted:      &atomic.Bool{},
 		PeerManager:             peerManager,
 		RESTClientGetter:        restClientGetter,
 		CatalogContentManager:   content,